@@ -0,0 +1,196 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+
+	"koin/internal/config"
+	"koin/internal/model/dto"
+	"koin/internal/notify"
+	"koin/internal/repository/postgres"
+	"koin/internal/service"
+	"koin/internal/storage"
+)
+
+// app raccoglie database, repository e servizi: il server e i comandi di amministrazione li
+// costruiscono allo stesso modo, dalla stessa configurazione.
+type app struct {
+	db *sql.DB
+
+	userRepo    *postgres.UserRepository
+	accountRepo *postgres.AccountRepository
+
+	userService            *service.UserService
+	accountService         *service.AccountService
+	categoryService        *service.CategoryService
+	tagService             *service.TagService
+	payeeService           *service.PayeeService
+	attachmentService      *service.AttachmentService
+	reconciliationService  *service.ReconciliationService
+	creditCardService      *service.CreditCardService
+	loanService            *service.LoanService
+	investmentService      *service.InvestmentService
+	householdService       *service.HouseholdService
+	splitService           *service.SplitService
+	reimbursementService   *service.ReimbursementService
+	forecastService        *service.ForecastService
+	subscriptionService    *service.SubscriptionService
+	anomalyService         *service.AnomalyService
+	notificationService    *service.NotificationService
+	webhookService         *service.WebhookService
+	changeService          *service.ChangeService
+	digestService          *service.DigestService
+	statementService       *service.StatementService
+	deductionService       *service.DeductionService
+	exportService          *service.ExportService
+	backupService          *service.BackupService
+	scheduledBackupService *service.ScheduledBackupService
+}
+
+// newApp applica le migrazioni, apre il database e costruisce repository e servizi.
+func newApp(cfg config.Config) (*app, error) {
+	schemaVersion, err := migrateDatabase(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	db, err := openDatabase(cfg.Database.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	blobStore, err := newBlobStore(cfg.Attachments)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	senders, err := newNotificationSenders(cfg.SMTP)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	userRepo := postgres.NewUserRepository(db)
+	accountRepo := postgres.NewAccountRepository(db)
+	categoryRepo := postgres.NewCategoryRepository(db)
+	tagRepo := postgres.NewTagRepository(db)
+	payeeRepo := postgres.NewPayeeRepository(db)
+	attachmentRepo := postgres.NewAttachmentRepository(db)
+	reconciliationRepo := postgres.NewReconciliationRepository(db)
+	creditCardRepo := postgres.NewCreditCardRepository(db)
+	loanRepo := postgres.NewLoanRepository(db)
+	securityRepo := postgres.NewSecurityRepository(db)
+	investmentRepo := postgres.NewInvestmentRepository(db)
+	householdRepo := postgres.NewHouseholdRepository(db)
+	splitRepo := postgres.NewSplitRepository(db)
+	reimbursementRepo := postgres.NewReimbursementRepository(db)
+	forecastRepo := postgres.NewForecastRepository(db)
+	subscriptionRepo := postgres.NewSubscriptionRepository(db)
+	anomalyRepo := postgres.NewAnomalyRepository(db)
+	notificationRepo := postgres.NewNotificationRepository(db)
+	webhookRepo := postgres.NewWebhookRepository(db)
+	changeRepo := postgres.NewChangeRepository(db)
+	digestRepo := postgres.NewDigestRepository(db)
+	statementRepo := postgres.NewStatementRepository(db)
+	deductionRepo := postgres.NewDeductionRepository(db)
+	exportRepo := postgres.NewExportRepository(db)
+	backupRepo := postgres.NewBackupRepository(db)
+	notificationService := service.NewNotificationService(userRepo, notificationRepo, senders)
+	backupService := service.NewBackupService(backupRepo, schemaVersion)
+	return &app{
+		db:                     db,
+		userRepo:               userRepo,
+		accountRepo:            accountRepo,
+		userService:            service.NewUserService(userRepo),
+		accountService:         service.NewAccountService(userRepo, accountRepo, categoryRepo, tagRepo, payeeRepo),
+		categoryService:        service.NewCategoryService(userRepo, categoryRepo),
+		tagService:             service.NewTagService(userRepo, tagRepo, accountRepo),
+		payeeService:           service.NewPayeeService(userRepo, payeeRepo, categoryRepo),
+		attachmentService:      service.NewAttachmentService(userRepo, accountRepo, attachmentRepo, blobStore),
+		reconciliationService:  service.NewReconciliationService(userRepo, accountRepo, reconciliationRepo),
+		creditCardService:      service.NewCreditCardService(userRepo, accountRepo, creditCardRepo, notificationService),
+		loanService:            service.NewLoanService(userRepo, accountRepo, categoryRepo, loanRepo, notificationService),
+		investmentService:      service.NewInvestmentService(userRepo, accountRepo, categoryRepo, securityRepo, investmentRepo),
+		householdService:       service.NewHouseholdService(userRepo, accountRepo, categoryRepo, householdRepo),
+		splitService:           service.NewSplitService(userRepo, accountRepo, categoryRepo, splitRepo),
+		reimbursementService:   service.NewReimbursementService(userRepo, accountRepo, reimbursementRepo),
+		forecastService:        service.NewForecastService(userRepo, accountRepo, categoryRepo, creditCardRepo, loanRepo, forecastRepo),
+		subscriptionService:    service.NewSubscriptionService(userRepo, accountRepo, subscriptionRepo, forecastRepo),
+		anomalyService:         service.NewAnomalyService(userRepo, anomalyRepo, notificationService),
+		notificationService:    notificationService,
+		webhookService:         service.NewWebhookService(userRepo, webhookRepo, notify.NewEventSender()),
+		changeService:          service.NewChangeService(changeRepo),
+		digestService:          service.NewDigestService(userRepo, categoryRepo, digestRepo, senders[dto.ChannelEmail]),
+		statementService:       service.NewStatementService(userRepo, categoryRepo, statementRepo),
+		deductionService:       service.NewDeductionService(userRepo, accountRepo, deductionRepo),
+		exportService:          service.NewExportService(userRepo, accountRepo, categoryRepo, exportRepo),
+		backupService:          backupService,
+		scheduledBackupService: service.NewScheduledBackupService(backupService, backupRepo, newBackupSchedule(cfg)),
+	}, nil
+}
+
+func (app *app) Close() error {
+	return app.db.Close()
+}
+
+// newBackupSchedule: con BACKUP_MODE=pg_dump serve un pg_dump (BACKUP_PG_DUMP) della stessa
+// versione del server.
+func newBackupSchedule(cfg config.Config) dto.BackupSchedule {
+	schedule := dto.BackupSchedule{
+		Directory:   cfg.Backup.Path,
+		Mode:        dto.BackupLogical,
+		Interval:    cfg.Backup.Interval,
+		KeepDaily:   cfg.Backup.KeepDaily,
+		KeepWeekly:  cfg.Backup.KeepWeekly,
+		KeepMonthly: cfg.Backup.KeepMonthly,
+		Gzip:        cfg.Backup.Gzip,
+		Passphrase:  cfg.Backup.Passphrase,
+		PgDumpPath:  cfg.Backup.PgDump,
+		DatabaseURL: cfg.Database.URL,
+	}
+	if cfg.Backup.Mode == "pg_dump" {
+		schedule.Mode = dto.BackupPgDump
+	}
+	return schedule
+}
+
+// newBlobStore sceglie dove salvare gli allegati: filesystem locale (default) oppure
+// un bucket S3 compatibile, ad esempio MinIO, con ATTACHMENTS_STORAGE=s3.
+func newBlobStore(attachments config.AttachmentsConfig) (storage.BlobStore, error) {
+	if attachments.Storage == "s3" {
+		return storage.NewS3BlobStore(storage.S3Config{
+			Endpoint:     attachments.S3.Endpoint,
+			Region:       attachments.S3.Region,
+			Bucket:       attachments.S3.Bucket,
+			AccessKey:    attachments.S3.AccessKey,
+			SecretKey:    attachments.S3.SecretKey,
+			UsePathStyle: attachments.S3.PathStyle,
+		})
+	}
+	return storage.NewLocalBlobStore(attachments.Path)
+}
+
+// newNotificationSenders configura i canali di consegna delle notifiche: il webhook è sempre
+// disponibile, l'email solo se SMTP_HOST è impostato (es. localhost e SMTP_PORT=1025 per MailHog).
+func newNotificationSenders(smtp config.SMTPConfig) (map[dto.NotificationChannel]notify.Sender, error) {
+	senders := map[dto.NotificationChannel]notify.Sender{
+		dto.ChannelWebhook: notify.NewWebhookSender(),
+	}
+
+	if smtp.Host == "" {
+		log.Println("SMTP_HOST not set: email notifications disabled")
+		return senders, nil
+	}
+	smtpSender, err := notify.NewSMTPSender(notify.SMTPConfig{
+		Host:     smtp.Host,
+		Port:     smtp.Port,
+		Username: smtp.Username,
+		Password: smtp.Password,
+		From:     smtp.From,
+	})
+	if err != nil {
+		return nil, err
+	}
+	senders[dto.ChannelEmail] = smtpSender
+	return senders, nil
+}
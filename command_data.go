@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"koin/internal/backup"
+	"koin/internal/config"
+	"koin/internal/model/dto"
+)
+
+// runImport importa le transazioni di un CSV in un account dell'utente.
+func runImport(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	email := flags.String("user", "", "email dell'utente")
+	account := flags.String("account", "", "nome dell'account in cui importare")
+	category := flags.String("category", "", "categoria delle righe che non la indicano")
+	positional := parseInterspersed(flags, args)
+	if len(positional) != 1 {
+		return errors.New("import: exactly one CSV file is required")
+	}
+	if *email == "" || *account == "" {
+		return errors.New("import: -user and -account are required")
+	}
+
+	file, err := os.Open(positional[0])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	user, err := app.userService.GetUserByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	importDto := dto.ImportTransactionsDto{UserID: user.ID, AccountName: *account}
+	if *category != "" {
+		importDto.DefaultCategory = category
+	}
+	result, err := app.accountService.ImportTransactions(ctx, importDto, file)
+	for _, rowError := range result.Errors {
+		fmt.Fprintln(os.Stderr, rowError)
+	}
+	if err != nil {
+		return fmt.Errorf("import stopped after %d transactions: %w", result.Imported, err)
+	}
+	fmt.Printf("imported %d transactions into %s, skipped %d rows\n", result.Imported, *account, len(result.Errors))
+	return nil
+}
+
+// runExport esporta account, categorie o transazioni di un utente con i filtri dell'API.
+func runExport(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	email := flags.String("user", "", "email dell'utente")
+	resource := flags.String("resource", "transactions", "dati da esportare: accounts, categories o transactions")
+	format := flags.String("format", "csv", "formato: csv, json o xlsx")
+	account := flags.String("account", "", "solo l'account con questo nome")
+	from := flags.String("from", "", "solo i movimenti da questa data (2006-01-02)")
+	to := flags.String("to", "", "solo i movimenti fino a questa data (2006-01-02)")
+	tag := flags.String("tag", "", "solo i movimenti con questo tag")
+	out := flags.String("out", "", "file esportato (default: standard output)")
+	_ = flags.Parse(args)
+	if *email == "" {
+		return errors.New("export: -user is required")
+	}
+
+	filter := dto.ExportFilter{
+		Resource: dto.ExportResource(strings.ToUpper(*resource)),
+		Format:   dto.ExportFormat(strings.ToUpper(*format)),
+	}
+	for _, date := range []struct {
+		value  string
+		target **time.Time
+	}{{*from, &filter.DateFrom}, {*to, &filter.DateTo}} {
+		if date.value == "" {
+			continue
+		}
+		parsed, err := time.Parse("2006-01-02", date.value)
+		if err != nil {
+			return fmt.Errorf("export: invalid date %q", date.value)
+		}
+		*date.target = &parsed
+	}
+	if *tag != "" {
+		filter.Tag = tag
+	}
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	user, err := app.userService.GetUserByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	filter.UserID = user.ID
+	if *account != "" {
+		found, err := app.accountRepo.GetAccount(ctx, user, *account)
+		if err != nil {
+			return err
+		}
+		filter.AccountID = &found.ID
+	}
+	file, err := app.exportService.Export(ctx, filter, time.Now())
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, file.Content)
+}
+
+// runBackup scrive l'archivio di backup dell'utente nel file indicato o sullo standard output.
+func runBackup(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	email := flags.String("user", "", "email dell'utente da salvare")
+	out := flags.String("out", "", "file dell'archivio (default: standard output)")
+	_ = flags.Parse(args)
+	if *email == "" {
+		return errors.New("backup: -user is required")
+	}
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	user, err := app.userService.GetUserByEmail(ctx, *email)
+	if err != nil {
+		return err
+	}
+	file, err := app.backupService.Backup(ctx, user.ID, dto.BackupOptions{IncludePassword: true}, time.Now())
+	if err != nil {
+		return err
+	}
+	if closer, ok := file.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+	if err := writeOutput(*out, file.Content); err != nil {
+		return err
+	}
+	if *out != "" {
+		log.Printf("backup of %s written to %s", user.Email, *out)
+	}
+	return nil
+}
+
+// runRestore carica un archivio di backup, dal file indicato o dallo standard input.
+func runRestore(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	in := flags.String("in", "", "file dell'archivio (default: standard input)")
+	email := flags.String("email", "", "ripristina su questo utente invece che su quello dell'archivio")
+	_ = flags.Parse(args)
+
+	var archive io.Reader = os.Stdin
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		archive = file
+	}
+	options := dto.RestoreOptions{}
+	if *email != "" {
+		options.Email = email
+	}
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	result, err := app.backupService.Restore(context.Background(), archive, options)
+	if err != nil {
+		return err
+	}
+
+	recordTypes := make([]string, 0, len(result.Counts))
+	for recordType := range result.Counts {
+		recordTypes = append(recordTypes, recordType)
+	}
+	sort.Strings(recordTypes)
+	counts := make([]string, len(recordTypes))
+	for i, recordType := range recordTypes {
+		counts[i] = fmt.Sprintf("%s=%d", recordType, result.Counts[recordType])
+	}
+	action := "restored into existing user"
+	if result.Created {
+		action = "restored into new user"
+	}
+	log.Printf("%s %s (id %d): %s", action, result.Email, result.UserID, strings.Join(counts, " "))
+	if result.PasswordResetRequired {
+		log.Printf("the archive has no password: set one with koin user reset-password -email %s", result.Email)
+	}
+	return nil
+}
+
+// runDecrypt decifra e decomprime un backup automatico con la passphrase dei backup automatici.
+func runDecrypt(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	in := flags.String("in", "", "file del backup")
+	out := flags.String("out", "", "file in chiaro (default: standard output)")
+	_ = flags.Parse(args)
+	if *in == "" {
+		return errors.New("decrypt: -in is required")
+	}
+
+	sealed, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer sealed.Close()
+	content, err := backup.Open(sealed, cfg.Backup.Passphrase)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, content)
+}
+
+// writeOutput copia content nel file indicato, creato con permessi ristretti e rimosso se la
+// copia fallisce, o sullo standard output se path è vuoto.
+func writeOutput(path string, content io.Reader) error {
+	if path == "" {
+		_, err := io.Copy(os.Stdout, content)
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(file, content); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	return file.Close()
+}
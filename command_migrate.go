@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"koin/internal/config"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// runMigrate gestisce le migrazioni senza applicarle all'avvio come gli altri comandi, così si
+// può intervenire anche su uno schema dirty.
+func runMigrate(cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Uso: koin migrate up|down [N]|status|force VERSIONE")
+	}
+	positional := parseInterspersed(flags, args)
+	if len(positional) == 0 {
+		flags.Usage()
+		return errors.New("migrate: missing subcommand")
+	}
+
+	m, err := migrate.New(migrationsURL(cfg.Database), cfg.Database.URL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch subcommand, positional := positional[0], positional[1:]; subcommand {
+	case "up":
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return err
+		}
+	case "down":
+		// Senza argomenti annulla solo l'ultima migrazione
+		steps := 1
+		if len(positional) > 0 {
+			steps, err = strconv.Atoi(positional[0])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("migrate down: invalid number of steps %q", positional[0])
+			}
+		}
+		if err := m.Steps(-steps); err != nil {
+			return err
+		}
+	case "status":
+	case "force":
+		if len(positional) != 1 {
+			return errors.New("migrate force: a version is required")
+		}
+		version, err := strconv.Atoi(positional[0])
+		if err != nil || version < -1 {
+			return fmt.Errorf("migrate force: invalid version %q", positional[0])
+		}
+		if err := m.Force(version); err != nil {
+			return err
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("migrate: unknown subcommand %q", subcommand)
+	}
+	return printMigrationStatus(cfg.Database, m)
+}
+
+// printMigrationStatus mostra la versione dello schema e l'ultima migrazione disponibile.
+func printMigrationStatus(database config.DatabaseConfig, m *migrate.Migrate) error {
+	latest, err := latestMigration(database)
+	if err != nil {
+		return err
+	}
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Printf("schema version: none (latest %d)\n", latest)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	state := "up to date"
+	switch {
+	case dirty:
+		state = "dirty: fix the database and run migrate force"
+	case version < latest:
+		state = "pending migrations"
+	}
+	fmt.Printf("schema version: %d (latest %d, %s)\n", version, latest, state)
+	return nil
+}
+
+func latestMigration(database config.DatabaseConfig) (uint, error) {
+	migrations, err := source.Open(migrationsURL(database))
+	if err != nil {
+		return 0, err
+	}
+	defer migrations.Close()
+
+	version, err := migrations.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := migrations.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
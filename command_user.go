@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"koin/internal/config"
+	"koin/internal/model/dto"
+	"koin/internal/service"
+)
+
+// runUser crea utenti, ne reimposta la password o li elimina con tutti i loro dati.
+func runUser(cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New("user: missing subcommand (expected create, reset-password or delete)")
+	}
+	subcommand, args := args[0], args[1:]
+	flags := flag.NewFlagSet("user "+subcommand, flag.ExitOnError)
+	email := flags.String("email", "", "email dell'utente")
+	var password *string
+	var confirm *bool
+	switch subcommand {
+	case "create", "reset-password":
+		password = flags.String("password", "", "nuova password (default: prima riga dello standard input)")
+	case "delete":
+		confirm = flags.Bool("yes", false, "conferma l'eliminazione dell'utente e di tutti i suoi dati")
+	default:
+		return fmt.Errorf("user: unknown subcommand %q (expected create, reset-password or delete)", subcommand)
+	}
+	_ = flags.Parse(args)
+	if *email == "" {
+		return fmt.Errorf("user %s: -email is required", subcommand)
+	}
+	if password != nil && *password == "" {
+		line, err := readPassword()
+		if err != nil {
+			return err
+		}
+		password = &line
+	}
+	if confirm != nil && !*confirm {
+		return errors.New("user delete: pass -yes to delete the user and all their data")
+	}
+
+	app, err := newApp(cfg)
+	if err != nil {
+		return err
+	}
+	defer app.Close()
+
+	ctx := context.Background()
+	switch subcommand {
+	case "create":
+		if err := service.ValidatePassword(*password); err != nil {
+			return err
+		}
+		user, err := app.userService.CreateUser(ctx, dto.CreateUserDto{Email: *email, Password: *password})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("created user %s (id %d)\n", user.Email, user.ID)
+	case "reset-password":
+		if err := app.userService.ResetPassword(ctx, *email, *password); err != nil {
+			return err
+		}
+		fmt.Printf("password of %s updated\n", *email)
+	case "delete":
+		user, err := app.userService.GetUserByEmail(ctx, *email)
+		if err != nil {
+			return err
+		}
+		if err := app.attachmentService.DeleteUser(ctx, user.ID); err != nil {
+			return err
+		}
+		fmt.Printf("deleted user %s (id %d)\n", user.Email, user.ID)
+	}
+	return nil
+}
+
+// readPassword legge la password dalla prima riga dello standard input, così non compare tra
+// gli argomenti visibili agli altri processi.
+func readPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password given on the standard input")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
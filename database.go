@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"koin/internal/config"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// migrationsURL è la sorgente golang-migrate della directory delle migrazioni.
+func migrationsURL(database config.DatabaseConfig) string {
+	return "file://" + database.MigrationsPath
+}
+
+// openDatabase apre il database e verifica che sia raggiungibile.
+func openDatabase(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// migrateDatabase applica le migrazioni mancanti e restituisce la versione dello schema, scritta
+// anche nei backup.
+func migrateDatabase(database config.DatabaseConfig) (uint, error) {
+	m, err := migrate.New(migrationsURL(database), database.URL)
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, err
+	}
+	schemaVersion, dirty, err := m.Version()
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("database schema version %d is dirty", schemaVersion)
+	}
+	log.Println("migrations applied")
+	return schemaVersion, nil
+}
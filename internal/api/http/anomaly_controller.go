@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+)
+
+func (ctrl *Controller) GetAnomalies(ctx context.Context, request apigen.GetAnomaliesRequestObject) (apigen.GetAnomaliesResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetAnomalies400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	includeDismissed := request.Params.IncludeDismissed != nil && *request.Params.IncludeDismissed
+	anomalies, err := ctrl.anomalyService.GetAnomalies(ctx, request.Params.UserId, includeDismissed)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetAnomalies400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetAnomalies500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.AnomalyItem, len(anomalies))
+	for i, anomaly := range anomalies {
+		response[i] = ToAnomalyItem(anomaly)
+	}
+
+	return apigen.GetAnomalies200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) ScanAnomalies(ctx context.Context, request apigen.ScanAnomaliesRequestObject) (apigen.ScanAnomaliesResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.ScanAnomalies400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	anomalies, err := ctrl.anomalyService.ScanUser(ctx, request.Params.UserId, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ScanAnomalies400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.ScanAnomalies500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.AnomalyItem, len(anomalies))
+	for i, anomaly := range anomalies {
+		response[i] = ToAnomalyItem(anomaly)
+	}
+
+	return apigen.ScanAnomalies200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) DismissAnomaly(ctx context.Context, request apigen.DismissAnomalyRequestObject) (apigen.DismissAnomalyResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DismissAnomaly400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.anomalyService.Dismiss(ctx, request.Params.UserId, request.AnomalyId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DismissAnomaly400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrAnomalyNotFound) {
+			return apigen.DismissAnomaly404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DismissAnomaly500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DismissAnomaly204Response{}, nil
+}
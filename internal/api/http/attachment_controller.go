@@ -0,0 +1,377 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/service"
+	"koin/internal/storage"
+)
+
+func (ctrl *Controller) UploadAttachment(ctx context.Context, request apigen.UploadAttachmentRequestObject) (apigen.UploadAttachmentResponseObject, error) {
+	if request.Body == nil {
+		return apigen.UploadAttachment400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body multipart richiesto",
+			},
+		}, nil
+	}
+
+	uploadDto, err := readUploadForm(request.Body)
+	if err != nil {
+		return uploadAttachmentError(err), nil
+	}
+	if uploadDto.UserID == 0 || uploadDto.Content == nil {
+		return apigen.UploadAttachment400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e file sono obbligatori",
+			},
+		}, nil
+	}
+	uploadDto.TransactionID = request.TransactionId
+
+	attachment, err := ctrl.attachmentService.UploadAttachment(ctx, uploadDto)
+	if err != nil {
+		return uploadAttachmentError(err), nil
+	}
+
+	return apigen.UploadAttachment201JSONResponse(ToAttachmentItem(attachment)), nil
+}
+
+func uploadAttachmentError(err error) apigen.UploadAttachmentResponseObject {
+	if errors.Is(err, errs.ErrTransactionNotFound) {
+		return apigen.UploadAttachment404JSONResponse{
+			NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+				Code:    "NOT_FOUND",
+				Message: err.Error(),
+			},
+		}
+	}
+	if errors.Is(err, errs.ErrFileTooLarge) {
+		return apigen.UploadAttachment413JSONResponse{
+			PayloadTooLargeJSONResponse: apigen.PayloadTooLargeJSONResponse{
+				Code:    "FILE_TOO_LARGE",
+				Message: err.Error(),
+			},
+		}
+	}
+	if errors.Is(err, errs.ErrUnsupportedFileType) {
+		return apigen.UploadAttachment415JSONResponse{
+			UnsupportedMediaTypeJSONResponse: apigen.UnsupportedMediaTypeJSONResponse{
+				Code:    "UNSUPPORTED_FILE_TYPE",
+				Message: err.Error(),
+			},
+		}
+	}
+	if errors.Is(err, errs.ErrUserNotFound) {
+		return apigen.UploadAttachment400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "NOT_FOUND",
+				Message: err.Error(),
+			},
+		}
+	}
+	if errors.Is(err, errs.ErrInvalidData) {
+		return apigen.UploadAttachment400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: err.Error(),
+			},
+		}
+	}
+	if errors.Is(err, errs.ErrForbidden) {
+		return apigen.UploadAttachment403JSONResponse{
+			ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+				Code:    "FORBIDDEN",
+				Message: err.Error(),
+			},
+		}
+	}
+	return apigen.UploadAttachment500JSONResponse{
+		InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+			Code:    "INTERNAL_ERROR",
+			Message: err.Error(),
+		},
+	}
+}
+
+func (ctrl *Controller) GetAttachments(ctx context.Context, request apigen.GetAttachmentsRequestObject) (apigen.GetAttachmentsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetAttachments400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	attachments, err := ctrl.attachmentService.GetAttachments(ctx, request.Params.UserId, request.TransactionId)
+	if err != nil {
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.GetAttachments404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetAttachments400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetAttachments500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.AttachmentItem, len(attachments))
+	for i, attachment := range attachments {
+		response[i] = ToAttachmentItem(attachment)
+	}
+
+	return apigen.GetAttachments200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) DownloadAttachment(ctx context.Context, request apigen.DownloadAttachmentRequestObject) (apigen.DownloadAttachmentResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DownloadAttachment400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	attachment, content, err := ctrl.attachmentService.OpenAttachment(ctx, request.Params.UserId, request.AttachmentId)
+	if err != nil {
+		if errors.Is(err, errs.ErrAttachmentNotFound) || errors.Is(err, storage.ErrBlobNotFound) {
+			return apigen.DownloadAttachment404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DownloadAttachment400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.DownloadAttachment500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DownloadAttachment200AsteriskResponse{
+		Body: content,
+		Headers: apigen.DownloadAttachment200ResponseHeaders{
+			ContentDisposition: mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}),
+		},
+		ContentType:   attachment.ContentType,
+		ContentLength: attachment.SizeBytes,
+	}, nil
+}
+
+func (ctrl *Controller) GetAttachmentThumbnail(ctx context.Context, request apigen.GetAttachmentThumbnailRequestObject) (apigen.GetAttachmentThumbnailResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetAttachmentThumbnail400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	thumbnail, err := ctrl.attachmentService.OpenThumbnail(ctx, request.Params.UserId, request.AttachmentId)
+	if err != nil {
+		if errors.Is(err, errs.ErrAttachmentNotFound) || errors.Is(err, errs.ErrNotFound) || errors.Is(err, storage.ErrBlobNotFound) {
+			return apigen.GetAttachmentThumbnail404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetAttachmentThumbnail400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetAttachmentThumbnail500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetAttachmentThumbnail200ImagejpegResponse{Body: thumbnail}, nil
+}
+
+func (ctrl *Controller) DeleteAttachment(ctx context.Context, request apigen.DeleteAttachmentRequestObject) (apigen.DeleteAttachmentResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeleteAttachment400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.attachmentService.DeleteAttachment(ctx, request.Params.UserId, request.AttachmentId)
+	if err != nil {
+		if errors.Is(err, errs.ErrAttachmentNotFound) {
+			return apigen.DeleteAttachment404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeleteAttachment400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.DeleteAttachment403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeleteAttachment500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeleteAttachment204Response{}, nil
+}
+
+func (ctrl *Controller) DeleteTransaction(ctx context.Context, request apigen.DeleteTransactionRequestObject) (apigen.DeleteTransactionResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeleteTransaction400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.attachmentService.DeleteTransaction(ctx, request.Params.UserId, request.TransactionId)
+	if err != nil {
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.DeleteTransaction404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrTransactionLocked) {
+			return apigen.DeleteTransaction409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "TRANSACTION_LOCKED",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeleteTransaction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.DeleteTransaction403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeleteTransaction500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeleteTransaction204Response{}, nil
+}
+
+// readUploadForm legge i campi userId e file dal corpo multipart senza caricare
+// in memoria più di service.MaxAttachmentSize byte.
+func readUploadForm(reader *multipart.Reader) (dto.UploadAttachmentDto, error) {
+	var uploadDto dto.UploadAttachmentDto
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return uploadDto, nil
+		}
+		if err != nil {
+			return dto.UploadAttachmentDto{}, fmt.Errorf("%w: multipart non valido: %v", errs.ErrInvalidData, err)
+		}
+
+		switch part.FormName() {
+		case "userId":
+			value, err := io.ReadAll(io.LimitReader(part, 32))
+			if err != nil {
+				return dto.UploadAttachmentDto{}, fmt.Errorf("%w: userId non valido", errs.ErrInvalidData)
+			}
+			userID, err := strconv.ParseInt(strings.TrimSpace(string(value)), 10, 64)
+			if err != nil {
+				return dto.UploadAttachmentDto{}, fmt.Errorf("%w: userId non valido", errs.ErrInvalidData)
+			}
+			uploadDto.UserID = userID
+		case "file":
+			content, err := io.ReadAll(io.LimitReader(part, service.MaxAttachmentSize+1))
+			if err != nil {
+				return dto.UploadAttachmentDto{}, fmt.Errorf("%w: lettura del file: %v", errs.ErrInvalidData, err)
+			}
+			if len(content) > service.MaxAttachmentSize {
+				return dto.UploadAttachmentDto{}, fmt.Errorf("%w: dimensione massima %d byte", errs.ErrFileTooLarge, service.MaxAttachmentSize)
+			}
+			uploadDto.FileName = part.FileName()
+			uploadDto.Content = content
+		}
+		part.Close()
+	}
+}
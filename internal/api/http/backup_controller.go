@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) GetBackup(ctx context.Context, request apigen.GetBackupRequestObject) (apigen.GetBackupResponseObject, error) {
+	userID, ok := sessionUserID(ctx)
+	if !ok {
+		return apigen.GetBackup401JSONResponse{
+			UnauthorizedJSONResponse: apigen.UnauthorizedJSONResponse{
+				Code:    "UNAUTHORIZED",
+				Message: "sessione non valida",
+			},
+		}, nil
+	}
+
+	file, err := ctrl.backupService.Backup(ctx, userID, dto.BackupOptions{}, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetBackup404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetBackup500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetBackup200ApplicationxNdjsonResponse{
+		Body: file.Content,
+		Headers: apigen.GetBackup200ResponseHeaders{
+			ContentDisposition: mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename}),
+		},
+	}, nil
+}
+
+func (ctrl *Controller) GetBackupStatus(ctx context.Context, request apigen.GetBackupStatusRequestObject) (apigen.GetBackupStatusResponseObject, error) {
+	status, err := ctrl.scheduledBackupService.GetStatus(time.Now())
+	if err != nil {
+		return apigen.GetBackupStatus500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+	return apigen.GetBackupStatus200JSONResponse(ToBackupStatus(status)), nil
+}
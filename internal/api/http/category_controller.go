@@ -0,0 +1,426 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/service"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+func (ctrl *Controller) UpdateCategory(ctx context.Context, request apigen.UpdateCategoryRequestObject) (apigen.UpdateCategoryResponseObject, error) {
+	if request.Body == nil {
+		return apigen.UpdateCategory400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || (body.Name == nil && body.Archived == nil) {
+		return apigen.UpdateCategory400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e almeno uno tra name e archived sono obbligatori",
+			},
+		}, nil
+	}
+
+	category, err := ctrl.categoryService.UpdateCategory(ctx, dto.UpdateCategoryDto{
+		UserID:     body.UserId,
+		CategoryID: request.CategoryId,
+		Name:       body.Name,
+		Archived:   body.Archived,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.UpdateCategory404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.UpdateCategory400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.UpdateCategory400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.UpdateCategory409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.UpdateCategory403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.UpdateCategory500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	path, err := ctrl.categoryPath(ctx, body.UserId, category.ID)
+	if err != nil {
+		return apigen.UpdateCategory500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.UpdateCategory200JSONResponse(ToCategoryItem(category, path)), nil
+}
+
+func (ctrl *Controller) SetCategoryParent(ctx context.Context, request apigen.SetCategoryParentRequestObject) (apigen.SetCategoryParentResponseObject, error) {
+	if request.Body == nil {
+		return apigen.SetCategoryParent400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.SetCategoryParent400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+	if body.ParentId != nil && *body.ParentId == request.CategoryId {
+		return apigen.SetCategoryParent400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "una categoria non può essere padre di se stessa",
+			},
+		}, nil
+	}
+
+	category, err := ctrl.categoryService.SetCategoryParent(ctx, dto.SetCategoryParentDto{
+		UserID:     body.UserId,
+		CategoryID: request.CategoryId,
+		ParentID:   body.ParentId,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.SetCategoryParent404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.SetCategoryParent400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) || errors.Is(err, errs.ErrCategoryArchived) {
+			return apigen.SetCategoryParent400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.SetCategoryParent403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.SetCategoryParent500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	path, err := ctrl.categoryPath(ctx, body.UserId, category.ID)
+	if err != nil {
+		return apigen.SetCategoryParent500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.SetCategoryParent200JSONResponse(ToCategoryItem(category, path)), nil
+}
+
+func (ctrl *Controller) SetCategoryBudget(ctx context.Context, request apigen.SetCategoryBudgetRequestObject) (apigen.SetCategoryBudgetResponseObject, error) {
+	if request.Body == nil {
+		return apigen.SetCategoryBudget400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.SetCategoryBudget400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	category, err := ctrl.categoryService.SetBudget(ctx, dto.SetCategoryBudgetDto{
+		UserID:        body.UserId,
+		CategoryID:    request.CategoryId,
+		MonthlyBudget: body.MonthlyBudget,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.SetCategoryBudget400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.SetCategoryBudget404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.SetCategoryBudget403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.SetCategoryBudget400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.SetCategoryBudget500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	path, err := ctrl.categoryPath(ctx, body.UserId, category.ID)
+	if err != nil {
+		return apigen.SetCategoryBudget500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.SetCategoryBudget200JSONResponse(ToCategoryItem(category, path)), nil
+}
+
+func (ctrl *Controller) MergeCategory(ctx context.Context, request apigen.MergeCategoryRequestObject) (apigen.MergeCategoryResponseObject, error) {
+	if request.Body == nil {
+		return apigen.MergeCategory400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.TargetCategoryId == 0 {
+		return apigen.MergeCategory400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e targetCategoryId sono obbligatori",
+			},
+		}, nil
+	}
+
+	target, moved, err := ctrl.categoryService.MergeCategories(ctx, dto.MergeCategoriesDto{
+		UserID:           body.UserId,
+		SourceCategoryID: request.CategoryId,
+		TargetCategoryID: body.TargetCategoryId,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.MergeCategory404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.MergeCategory400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.MergeCategory400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.MergeCategory403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.MergeCategory500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.MergeCategory200JSONResponse(apigen.MergeCategoryResponse{
+		TargetCategoryId: &target.ID,
+		MovedEntries:     &moved,
+	}), nil
+}
+
+func (ctrl *Controller) GetCategoryReport(ctx context.Context, request apigen.GetCategoryReportRequestObject) (apigen.GetCategoryReportResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetCategoryReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	dateFrom, dateTo := reportPeriod(request.Params.From, request.Params.To)
+	if dateFrom.After(dateTo) {
+		return apigen.GetCategoryReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "from deve precedere to",
+			},
+		}, nil
+	}
+
+	totals, err := ctrl.categoryService.GetCategoryReport(ctx, dto.CategoryReportDto{
+		UserID:   request.Params.UserId,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+		Tag:      normalizeTagFilter(request.Params.Tag),
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetCategoryReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetCategoryReport500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.CategoryReportItem, len(totals))
+	for i, total := range totals {
+		response[i] = apigen.CategoryReportItem{
+			CategoryId:    &total.CategoryID,
+			Name:          &total.Name,
+			Path:          &total.Path,
+			CategoryType:  (*string)(&total.CategoryType),
+			ParentId:      total.ParentID,
+			Total:         &total.Total,
+			RolledUpTotal: &total.RolledUpTotal,
+		}
+	}
+
+	return apigen.GetCategoryReport200JSONResponse(response), nil
+}
+
+// categoryPath ricalcola il percorso completo di una categoria dopo una modifica.
+func (ctrl *Controller) categoryPath(ctx context.Context, userID int64, categoryID int64) (string, error) {
+	user, err := ctrl.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	categories, err := ctrl.categoryService.GetCategories(ctx, user, true)
+	if err != nil {
+		return "", err
+	}
+	return service.CategoryPaths(categories)[categoryID], nil
+}
+
+// reportPeriod applica il periodo di default dei report: dal primo del mese corrente a oggi.
+func reportPeriod(from *openapi_types.Date, to *openapi_types.Date) (time.Time, time.Time) {
+	now := time.Now()
+	dateFrom := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	dateTo := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if from != nil {
+		dateFrom = from.Time
+	}
+	if to != nil {
+		dateTo = to.Time
+	}
+	return dateFrom, dateTo
+}
@@ -16,14 +16,58 @@ import (
 )
 
 type Controller struct {
-	userService    *service.UserService
-	accountService *service.AccountService
+	userService            *service.UserService
+	accountService         *service.AccountService
+	categoryService        *service.CategoryService
+	tagService             *service.TagService
+	payeeService           *service.PayeeService
+	attachmentService      *service.AttachmentService
+	reconciliationService  *service.ReconciliationService
+	creditCardService      *service.CreditCardService
+	loanService            *service.LoanService
+	investmentService      *service.InvestmentService
+	householdService       *service.HouseholdService
+	splitService           *service.SplitService
+	reimbursementService   *service.ReimbursementService
+	forecastService        *service.ForecastService
+	subscriptionService    *service.SubscriptionService
+	anomalyService         *service.AnomalyService
+	notificationService    *service.NotificationService
+	webhookService         *service.WebhookService
+	digestService          *service.DigestService
+	statementService       *service.StatementService
+	deductionService       *service.DeductionService
+	exportService          *service.ExportService
+	backupService          *service.BackupService
+	scheduledBackupService *service.ScheduledBackupService
 }
 
-func NewController(userService *service.UserService, accountService *service.AccountService) apigen.ServerInterface {
+func NewController(userService *service.UserService, accountService *service.AccountService, categoryService *service.CategoryService, tagService *service.TagService, payeeService *service.PayeeService, attachmentService *service.AttachmentService, reconciliationService *service.ReconciliationService, creditCardService *service.CreditCardService, loanService *service.LoanService, investmentService *service.InvestmentService, householdService *service.HouseholdService, splitService *service.SplitService, reimbursementService *service.ReimbursementService, forecastService *service.ForecastService, subscriptionService *service.SubscriptionService, anomalyService *service.AnomalyService, notificationService *service.NotificationService, webhookService *service.WebhookService, digestService *service.DigestService, statementService *service.StatementService, deductionService *service.DeductionService, exportService *service.ExportService, backupService *service.BackupService, scheduledBackupService *service.ScheduledBackupService) apigen.ServerInterface {
 	controller := &Controller{
-		userService:    userService,
-		accountService: accountService,
+		userService:            userService,
+		accountService:         accountService,
+		categoryService:        categoryService,
+		tagService:             tagService,
+		payeeService:           payeeService,
+		attachmentService:      attachmentService,
+		reconciliationService:  reconciliationService,
+		creditCardService:      creditCardService,
+		loanService:            loanService,
+		investmentService:      investmentService,
+		householdService:       householdService,
+		splitService:           splitService,
+		reimbursementService:   reimbursementService,
+		forecastService:        forecastService,
+		subscriptionService:    subscriptionService,
+		anomalyService:         anomalyService,
+		notificationService:    notificationService,
+		webhookService:         webhookService,
+		digestService:          digestService,
+		statementService:       statementService,
+		deductionService:       deductionService,
+		exportService:          exportService,
+		backupService:          backupService,
+		scheduledBackupService: scheduledBackupService,
 	}
 	return apigen.NewStrictHandler(controller, nil)
 }
@@ -181,7 +225,7 @@ func (ctrl *Controller) CreateCategory(ctx context.Context, request apigen.Creat
 	createCategoryDto := ToCreateCategoryDto(body)
 
 	// Creare la categoria
-	category, err := ctrl.accountService.CreateCategory(ctx, createCategoryDto)
+	category, err := ctrl.categoryService.CreateCategory(ctx, createCategoryDto)
 	if err != nil {
 		// Gestire i diversi tipi di errore
 		if errors.Is(err, errs.ErrConflict) {
@@ -193,6 +237,24 @@ func (ctrl *Controller) CreateCategory(ctx context.Context, request apigen.Creat
 			}, nil
 		}
 
+		if errors.Is(err, errs.ErrCategoryNotFound) || errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateCategory400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+
+		if errors.Is(err, errs.ErrInvalidData) || errors.Is(err, errs.ErrCategoryArchived) {
+			return apigen.CreateCategory400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+
 		// Errore interno
 		return apigen.CreateCategory500JSONResponse{
 			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
@@ -259,7 +321,33 @@ func (ctrl *Controller) AddTransaction(ctx context.Context, request apigen.AddTr
 			}, nil
 		}
 
+		if errors.Is(err, errs.ErrCategoryArchived) {
+			return apigen.AddTransaction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "CATEGORY_ARCHIVED",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.AddTransaction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+
 		// Errore interno
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.AddTransaction403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
 		return apigen.AddTransaction500JSONResponse{
 			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
 				Code:    "INTERNAL_ERROR",
@@ -313,6 +401,9 @@ func (ctrl *Controller) TransferBetweenAccounts(ctx context.Context, request api
 	if body.Description != nil {
 		transferDto.Description = body.Description
 	}
+	if body.Tags != nil {
+		transferDto.Tags = *body.Tags
+	}
 
 	transactionID, err := ctrl.accountService.TransferBetweenAccounts(ctx, transferDto)
 	if err != nil {
@@ -324,6 +415,14 @@ func (ctrl *Controller) TransferBetweenAccounts(ctx context.Context, request api
 				},
 			}, nil
 		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.TransferBetweenAccounts400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
 		if errors.Is(err, errs.ErrInsufficientBalance) {
 			return apigen.TransferBetweenAccounts409JSONResponse{
 				ConflictJSONResponse: apigen.ConflictJSONResponse{
@@ -332,6 +431,14 @@ func (ctrl *Controller) TransferBetweenAccounts(ctx context.Context, request api
 				},
 			}, nil
 		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.TransferBetweenAccounts403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
 		return apigen.TransferBetweenAccounts500JSONResponse{
 			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
 				Code:    "INTERNAL_ERROR",
@@ -389,13 +496,7 @@ func (ctrl *Controller) GetAccounts(ctx context.Context, request apigen.GetAccou
 		}
 		currentBalance := account.InitialBalance + balance
 
-		response[i] = apigen.AccountItem{
-			Id:             &account.ID,
-			Name:           &account.Name,
-			Currency:       &account.Currency,
-			InitialBalance: &account.InitialBalance,
-			CurrentBalance: &currentBalance,
-		}
+		response[i] = toBalanceAccountItem(account, currentBalance)
 	}
 
 	return apigen.GetAccounts200JSONResponse(response), nil
@@ -427,7 +528,7 @@ func (ctrl *Controller) GetRecentTransactions(ctx context.Context, request apige
 		limit = *request.Params.Limit
 	}
 
-	entries, err := ctrl.accountService.GetRecentTransactions(ctx, userID, limit)
+	entries, err := ctrl.accountService.GetRecentTransactions(ctx, userID, limit, normalizeTagFilter(request.Params.Tag))
 	if err != nil {
 		return apigen.GetRecentTransactions500JSONResponse{
 			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
@@ -453,14 +554,24 @@ func (ctrl *Controller) GetRecentTransactions(ctx context.Context, request apige
 		if entry.Description.Valid {
 			desc = &entry.Description.String
 		}
+		var payeeName *string
+		if entry.PayeeName.Valid {
+			payeeName = &entry.PayeeName.String
+		}
+		status := apigen.TransactionItemStatus(entry.Status)
+		tags := service.SplitTags(entry.Tags)
 		response[i] = apigen.TransactionItem{
 			TransactionId: &entry.TransactionID,
+			EntryId:       &entry.EntryID,
 			OccurredAt:    &occurredAt,
 			AccountName:   &entry.AccountName,
 			CategoryName:  categoryName,
 			CategoryType:  categoryType,
 			Amount:        &entry.Amount,
 			Description:   desc,
+			PayeeName:     payeeName,
+			Status:        &status,
+			Tags:          &tags,
 		}
 	}
 
@@ -488,7 +599,8 @@ func (ctrl *Controller) GetCategories(ctx context.Context, request apigen.GetCat
 		}, nil
 	}
 
-	categories, err := ctrl.accountService.GetCategories(ctx, user)
+	includeArchived := request.Params.IncludeArchived != nil && *request.Params.IncludeArchived
+	categories, err := ctrl.categoryService.GetCategories(ctx, user, includeArchived)
 	if err != nil {
 		return apigen.GetCategories500JSONResponse{
 			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
@@ -499,13 +611,10 @@ func (ctrl *Controller) GetCategories(ctx context.Context, request apigen.GetCat
 	}
 
 	// Mappare le categorie al formato di risposta
+	paths := service.CategoryPaths(categories)
 	response := make([]apigen.CategoryItem, len(categories))
 	for i, category := range categories {
-		response[i] = apigen.CategoryItem{
-			Id:           &category.ID,
-			Name:         &category.Name,
-			CategoryType: (*string)(&category.Type),
-		}
+		response[i] = ToCategoryItem(category, paths[category.ID])
 	}
 
 	return apigen.GetCategories200JSONResponse(response), nil
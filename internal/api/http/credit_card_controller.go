@@ -0,0 +1,337 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) GetCreditCards(ctx context.Context, request apigen.GetCreditCardsRequestObject) (apigen.GetCreditCardsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetCreditCards400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	creditCards, err := ctrl.creditCardService.GetCreditCards(ctx, request.Params.UserId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetCreditCards400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetCreditCards500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.CreditCardItem, len(creditCards))
+	for i, creditCard := range creditCards {
+		response[i] = ToCreditCardItem(creditCard)
+	}
+
+	return apigen.GetCreditCards200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) ConfigureCreditCard(ctx context.Context, request apigen.ConfigureCreditCardRequestObject) (apigen.ConfigureCreditCardResponseObject, error) {
+	if request.Body == nil {
+		return apigen.ConfigureCreditCard400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.PaymentAccountId == 0 {
+		return apigen.ConfigureCreditCard400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e paymentAccountId sono obbligatori",
+			},
+		}, nil
+	}
+
+	configureDto := dto.ConfigureCreditCardDto{
+		UserID:           body.UserId,
+		AccountID:        request.AccountId,
+		PaymentAccountID: body.PaymentAccountId,
+		ClosingDay:       body.ClosingDay,
+		DueDay:           body.DueDay,
+	}
+	if body.AutoPay != nil {
+		configureDto.AutoPay = *body.AutoPay
+	}
+
+	creditCard, err := ctrl.creditCardService.ConfigureCreditCard(ctx, configureDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.ConfigureCreditCard404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ConfigureCreditCard400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.ConfigureCreditCard400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.ConfigureCreditCard403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.ConfigureCreditCard500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.ConfigureCreditCard200JSONResponse(ToCreditCardItem(creditCard)), nil
+}
+
+func (ctrl *Controller) GetCreditCard(ctx context.Context, request apigen.GetCreditCardRequestObject) (apigen.GetCreditCardResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetCreditCard400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	creditCard, err := ctrl.creditCardService.GetCreditCard(ctx, request.Params.UserId, request.AccountId)
+	if err != nil {
+		if errors.Is(err, errs.ErrCreditCardNotFound) {
+			return apigen.GetCreditCard404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetCreditCard400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetCreditCard500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetCreditCard200JSONResponse(ToCreditCardItem(creditCard)), nil
+}
+
+func (ctrl *Controller) RemoveCreditCard(ctx context.Context, request apigen.RemoveCreditCardRequestObject) (apigen.RemoveCreditCardResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.RemoveCreditCard400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.creditCardService.RemoveCreditCard(ctx, request.Params.UserId, request.AccountId)
+	if err != nil {
+		if errors.Is(err, errs.ErrCreditCardNotFound) {
+			return apigen.RemoveCreditCard404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.RemoveCreditCard400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.RemoveCreditCard403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.RemoveCreditCard500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.RemoveCreditCard204Response{}, nil
+}
+
+func (ctrl *Controller) GetCreditCardStatements(ctx context.Context, request apigen.GetCreditCardStatementsRequestObject) (apigen.GetCreditCardStatementsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetCreditCardStatements400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	count := 0
+	if request.Params.Limit != nil {
+		count = int(*request.Params.Limit)
+	}
+
+	statements, err := ctrl.creditCardService.GetStatements(ctx, request.Params.UserId, request.AccountId, count, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrCreditCardNotFound) || errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.GetCreditCardStatements404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetCreditCardStatements400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetCreditCardStatements500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetCreditCardStatements200JSONResponse(ToCreditCardStatements(statements)), nil
+}
+
+func (ctrl *Controller) PayCreditCardStatement(ctx context.Context, request apigen.PayCreditCardStatementRequestObject) (apigen.PayCreditCardStatementResponseObject, error) {
+	if request.Body == nil || request.Body.UserId == 0 {
+		return apigen.PayCreditCardStatement400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	payDto := dto.PayStatementDto{
+		UserID:      body.UserId,
+		AccountID:   request.AccountId,
+		ClosingDate: request.ClosingDate.Time,
+		Amount:      body.Amount,
+	}
+	if body.OccurredAt != nil {
+		payDto.OccurredAt = &body.OccurredAt.Time
+	}
+
+	transactionID, err := ctrl.creditCardService.PayStatement(ctx, payDto, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrCreditCardNotFound) || errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.PayCreditCardStatement404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.PayCreditCardStatement409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInsufficientBalance) {
+			return apigen.PayCreditCardStatement409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "INSUFFICIENT_BALANCE",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.PayCreditCardStatement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.PayCreditCardStatement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.PayCreditCardStatement403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.PayCreditCardStatement500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.PayCreditCardStatement201JSONResponse(apigen.TransferBetweenAccountsResponse{
+		TransactionId: &transactionID,
+	}), nil
+}
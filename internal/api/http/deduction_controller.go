@@ -0,0 +1,262 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) SetCategoryDeduction(ctx context.Context, request apigen.SetCategoryDeductionRequestObject) (apigen.SetCategoryDeductionResponseObject, error) {
+	if request.Body == nil {
+		return apigen.SetCategoryDeduction400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.SetCategoryDeduction400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	category, err := ctrl.categoryService.SetDeduction(ctx, dto.SetCategoryDeductionDto{
+		UserID:     body.UserId,
+		CategoryID: request.CategoryId,
+		Code:       deductionCode(body.DeductionCode),
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.SetCategoryDeduction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.SetCategoryDeduction404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.SetCategoryDeduction403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.SetCategoryDeduction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.SetCategoryDeduction500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	path, err := ctrl.categoryPath(ctx, body.UserId, category.ID)
+	if err != nil {
+		return apigen.SetCategoryDeduction500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.SetCategoryDeduction200JSONResponse(ToCategoryItem(category, path)), nil
+}
+
+func (ctrl *Controller) SetEntryDeduction(ctx context.Context, request apigen.SetEntryDeductionRequestObject) (apigen.SetEntryDeductionResponseObject, error) {
+	if request.Body == nil {
+		return apigen.SetEntryDeduction400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.SetEntryDeduction400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	entry, err := ctrl.deductionService.SetEntryDeduction(ctx, dto.SetEntryDeductionDto{
+		UserID:  body.UserId,
+		EntryID: request.EntryId,
+		Code:    deductionCode(body.DeductionCode),
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.SetEntryDeduction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.SetEntryDeduction404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.SetEntryDeduction403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.SetEntryDeduction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.SetEntryDeduction500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.SetEntryDeduction200JSONResponse(ToEntryDeductionItem(entry)), nil
+}
+
+func (ctrl *Controller) GetDeductionReport(ctx context.Context, request apigen.GetDeductionReportRequestObject) (apigen.GetDeductionReportResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetDeductionReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	deductions, err := ctrl.deductionService.GetReport(ctx, request.Params.UserId, int(request.Params.Year), time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetDeductionReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.GetDeductionReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.GetDeductionReport500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetDeductionReport200JSONResponse(ToDeductionReport(deductions)), nil
+}
+
+func (ctrl *Controller) ExportDeductionReport(ctx context.Context, request apigen.ExportDeductionReportRequestObject) (apigen.ExportDeductionReportResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.ExportDeductionReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	format := dto.DeductionExportFormat(request.Params.Format)
+	content, filename, err := ctrl.deductionService.Export(ctx, request.Params.UserId, int(request.Params.Year), format, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ExportDeductionReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.ExportDeductionReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.ExportDeductionReport500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	headers := apigen.ExportDeductionReport200ResponseHeaders{
+		ContentDisposition: mime.FormatMediaType("attachment", map[string]string{"filename": filename}),
+	}
+	if format == dto.DeductionCSV {
+		return apigen.ExportDeductionReport200TextcsvResponse{
+			Body:          bytes.NewReader(content),
+			Headers:       headers,
+			ContentLength: int64(len(content)),
+		}, nil
+	}
+	return apigen.ExportDeductionReport200ApplicationpdfResponse{
+		Body:          bytes.NewReader(content),
+		Headers:       headers,
+		ContentLength: int64(len(content)),
+	}, nil
+}
+
+func deductionCode(code *string) *dto.DeductionCode {
+	if code == nil {
+		return nil
+	}
+	value := dto.DeductionCode(*code)
+	return &value
+}
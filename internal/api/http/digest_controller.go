@@ -0,0 +1,149 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) GetDigestSettings(ctx context.Context, request apigen.GetDigestSettingsRequestObject) (apigen.GetDigestSettingsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetDigestSettings400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	settings, err := ctrl.digestService.GetSettings(ctx, request.Params.UserId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetDigestSettings400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetDigestSettings500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetDigestSettings200JSONResponse(ToDigestSettings(settings)), nil
+}
+
+func (ctrl *Controller) UpdateDigestSettings(ctx context.Context, request apigen.UpdateDigestSettingsRequestObject) (apigen.UpdateDigestSettingsResponseObject, error) {
+	if request.Body == nil {
+		return apigen.UpdateDigestSettings400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.UpdateDigestSettings400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	settingsDto := dto.DigestSettings{
+		UserID:  body.UserId,
+		Weekly:  body.Weekly,
+		Monthly: body.Monthly,
+		Weekday: time.Monday,
+	}
+	if body.Weekday != nil {
+		settingsDto.Weekday = time.Weekday(*body.Weekday)
+	}
+
+	settings, err := ctrl.digestService.SaveSettings(ctx, settingsDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.UpdateDigestSettings400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.UpdateDigestSettings400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.UpdateDigestSettings500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.UpdateDigestSettings200JSONResponse(ToDigestSettings(settings)), nil
+}
+
+func (ctrl *Controller) GetDigestPreview(ctx context.Context, request apigen.GetDigestPreviewRequestObject) (apigen.GetDigestPreviewResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetDigestPreview400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	var date *time.Time
+	if request.Params.Date != nil {
+		date = &request.Params.Date.Time
+	}
+
+	html, err := ctrl.digestService.Preview(ctx, request.Params.UserId, dto.Frequency(request.Params.Frequency), date, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetDigestPreview400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.GetDigestPreview400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.GetDigestPreview500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetDigestPreview200TexthtmlResponse{
+		Body:          strings.NewReader(html),
+		ContentLength: int64(len(html)),
+	}, nil
+}
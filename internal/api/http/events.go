@@ -0,0 +1,72 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"koin/internal/service"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// eventsHeartbeat mantiene aperta la connessione attraverso proxy che chiudono gli stream inattivi.
+const eventsHeartbeat = 25 * time.Second
+
+// changeEventPayload è il campo data degli eventi "change" inviati al browser.
+type changeEventPayload struct {
+	ID         int64  `json:"id"`
+	Event      string `json:"event"`
+	Resource   string `json:"resource"`
+	ResourceID int64  `json:"resourceId"`
+}
+
+// StreamEvents invia come Server-Sent Events le modifiche visibili all'utente della sessione:
+// un evento "change" per ogni transazione, account o categoria creata, modificata o eliminata,
+// dall'utente stesso o da un membro delle sue household.
+func StreamEvents(changeService *service.ChangeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := sessions.Default(c).Get("userID").(int64)
+		if !ok {
+			writeError(c, http.StatusUnauthorized, "UNAUTHORIZED", "sessione non valida")
+			return
+		}
+
+		events, unsubscribe := changeService.Subscribe(userID)
+		defer unsubscribe()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("Connection", "keep-alive")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		// Il browser si riconnette dopo 5 secondi se la connessione cade
+		fmt.Fprint(c.Writer, "retry: 5000\n\n")
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(eventsHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(c.Writer, ": ping\n\n")
+			case event := <-events:
+				data, err := json.Marshal(changeEventPayload{
+					ID:         event.ID,
+					Event:      event.Event,
+					Resource:   event.Resource,
+					ResourceID: event.ResourceID,
+				})
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(c.Writer, "id: %d\nevent: change\ndata: %s\n\n", event.ID, data)
+			}
+			c.Writer.Flush()
+		}
+	}
+}
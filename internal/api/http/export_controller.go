@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) ExportData(ctx context.Context, request apigen.ExportDataRequestObject) (apigen.ExportDataResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.ExportData400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	params := request.Params
+	filter := dto.ExportFilter{
+		UserID:    params.UserId,
+		Resource:  dto.ExportResource(params.Resource),
+		Format:    dto.ExportFormat(params.Format),
+		AccountID: params.AccountId,
+		Tag:       normalizeTagFilter(params.Tag),
+	}
+	if params.DateFrom != nil {
+		filter.DateFrom = &params.DateFrom.Time
+	}
+	if params.DateTo != nil {
+		filter.DateTo = &params.DateTo.Time
+	}
+
+	file, err := ctrl.exportService.Export(ctx, filter, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ExportData400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.ExportData404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.ExportData400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.ExportData500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.ExportData200AsteriskResponse{
+		Body: file.Content,
+		Headers: apigen.ExportData200ResponseHeaders{
+			ContentDisposition: mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename}),
+		},
+		ContentType: file.ContentType,
+	}, nil
+}
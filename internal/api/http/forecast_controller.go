@@ -0,0 +1,322 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) SetAccountOverdraft(ctx context.Context, request apigen.SetAccountOverdraftRequestObject) (apigen.SetAccountOverdraftResponseObject, error) {
+	if request.Body == nil {
+		return apigen.SetAccountOverdraft400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.SetAccountOverdraft400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	account, err := ctrl.forecastService.SetOverdraft(ctx, dto.SetOverdraftDto{
+		UserID:         body.UserId,
+		AccountID:      request.AccountId,
+		OverdraftLimit: body.OverdraftLimit,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.SetAccountOverdraft400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.SetAccountOverdraft400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.SetAccountOverdraft404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.SetAccountOverdraft403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.SetAccountOverdraft500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	balance, err := ctrl.accountService.GetAccountBalance(ctx, account.ID)
+	if err != nil {
+		return apigen.SetAccountOverdraft500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.SetAccountOverdraft200JSONResponse(toBalanceAccountItem(account, account.InitialBalance+balance)), nil
+}
+
+func (ctrl *Controller) CreateRecurringTransaction(ctx context.Context, request apigen.CreateRecurringTransactionRequestObject) (apigen.CreateRecurringTransactionResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreateRecurringTransaction400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.AccountId == 0 || body.CategoryId == 0 || len(body.Description) == 0 {
+		return apigen.CreateRecurringTransaction400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId, accountId, categoryId e description sono obbligatori",
+			},
+		}, nil
+	}
+
+	createDto := dto.CreateRecurringDto{
+		UserID:      body.UserId,
+		AccountID:   body.AccountId,
+		CategoryID:  body.CategoryId,
+		Amount:      body.Amount,
+		Description: body.Description,
+		Frequency:   dto.Frequency(body.Frequency),
+		RepeatEvery: 1,
+		StartDate:   body.StartDate.Time,
+	}
+	if body.RepeatEvery != nil {
+		createDto.RepeatEvery = *body.RepeatEvery
+	}
+	if body.EndDate != nil {
+		createDto.EndDate = &body.EndDate.Time
+	}
+
+	recurring, err := ctrl.forecastService.CreateRecurring(ctx, createDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateRecurringTransaction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreateRecurringTransaction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.CreateRecurringTransaction404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.CreateRecurringTransaction404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.CreateRecurringTransaction403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreateRecurringTransaction500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreateRecurringTransaction201JSONResponse(ToRecurringTransactionItem(recurring)), nil
+}
+
+func (ctrl *Controller) GetRecurringTransactions(ctx context.Context, request apigen.GetRecurringTransactionsRequestObject) (apigen.GetRecurringTransactionsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetRecurringTransactions400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	recurring, err := ctrl.forecastService.GetRecurring(ctx, request.Params.UserId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetRecurringTransactions400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetRecurringTransactions500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.RecurringTransactionItem, len(recurring))
+	for i, item := range recurring {
+		response[i] = ToRecurringTransactionItem(item)
+	}
+
+	return apigen.GetRecurringTransactions200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) DeleteRecurringTransaction(ctx context.Context, request apigen.DeleteRecurringTransactionRequestObject) (apigen.DeleteRecurringTransactionResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeleteRecurringTransaction400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.forecastService.DeleteRecurring(ctx, request.Params.UserId, request.RecurringId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeleteRecurringTransaction400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrRecurringNotFound) {
+			return apigen.DeleteRecurringTransaction404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.DeleteRecurringTransaction404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.DeleteRecurringTransaction403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeleteRecurringTransaction500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeleteRecurringTransaction204Response{}, nil
+}
+
+func (ctrl *Controller) GetForecast(ctx context.Context, request apigen.GetForecastRequestObject) (apigen.GetForecastResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetForecast400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	forecastDto := dto.ForecastDto{
+		UserID:        request.Params.UserId,
+		Months:        3,
+		HistoryMonths: 6,
+	}
+	if request.Params.Months != nil {
+		forecastDto.Months = *request.Params.Months
+	}
+	if request.Params.HistoryMonths != nil {
+		forecastDto.HistoryMonths = *request.Params.HistoryMonths
+	}
+
+	forecast, err := ctrl.forecastService.GetForecast(ctx, forecastDto, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetForecast400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.GetForecast400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.GetForecast500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetForecast200JSONResponse(ToForecast(forecast)), nil
+}
@@ -0,0 +1,626 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	dbgen "koin/internal/db/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) CreateHousehold(ctx context.Context, request apigen.CreateHouseholdRequestObject) (apigen.CreateHouseholdResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreateHousehold400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Name) == 0 {
+		return apigen.CreateHousehold400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e name sono obbligatori",
+			},
+		}, nil
+	}
+
+	detail, err := ctrl.householdService.CreateHousehold(ctx, dto.CreateHouseholdDto{
+		UserID: body.UserId,
+		Name:   body.Name,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateHousehold400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreateHousehold400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreateHousehold500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreateHousehold201JSONResponse(ToHouseholdDetail(detail)), nil
+}
+
+func (ctrl *Controller) GetHouseholds(ctx context.Context, request apigen.GetHouseholdsRequestObject) (apigen.GetHouseholdsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetHouseholds400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	households, err := ctrl.householdService.GetHouseholds(ctx, request.Params.UserId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetHouseholds400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetHouseholds500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.HouseholdItem, len(households))
+	for i, household := range households {
+		response[i] = ToHouseholdItem(dbgen.Household{
+			ID:        household.ID,
+			Name:      household.Name,
+			CreatedAt: household.CreatedAt,
+		}, household.Role)
+	}
+
+	return apigen.GetHouseholds200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) GetHousehold(ctx context.Context, request apigen.GetHouseholdRequestObject) (apigen.GetHouseholdResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetHousehold400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	detail, err := ctrl.householdService.GetHousehold(ctx, request.Params.UserId, request.HouseholdId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetHousehold400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrHouseholdNotFound) {
+			return apigen.GetHousehold404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.GetHousehold500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetHousehold200JSONResponse(ToHouseholdDetail(detail)), nil
+}
+
+func (ctrl *Controller) CreateHouseholdInvite(ctx context.Context, request apigen.CreateHouseholdInviteRequestObject) (apigen.CreateHouseholdInviteResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreateHouseholdInvite400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.CreateHouseholdInvite400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	inviteDto := dto.CreateInviteDto{
+		UserID:      body.UserId,
+		HouseholdID: request.HouseholdId,
+		Email:       body.Email,
+	}
+	if body.Role != nil {
+		inviteDto.Role = dto.HouseholdRole(*body.Role)
+	}
+	if body.ExpiresAt != nil {
+		inviteDto.ExpiresAt = *body.ExpiresAt
+	}
+
+	invite, err := ctrl.householdService.CreateInvite(ctx, inviteDto, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateHouseholdInvite400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreateHouseholdInvite400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrHouseholdNotFound) {
+			return apigen.CreateHouseholdInvite404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.CreateHouseholdInvite403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreateHouseholdInvite500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreateHouseholdInvite201JSONResponse(ToHouseholdInviteItem(invite)), nil
+}
+
+func (ctrl *Controller) RevokeHouseholdInvite(ctx context.Context, request apigen.RevokeHouseholdInviteRequestObject) (apigen.RevokeHouseholdInviteResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.RevokeHouseholdInvite400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.householdService.RevokeInvite(ctx, request.Params.UserId, request.HouseholdId, request.InviteId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.RevokeHouseholdInvite400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrHouseholdNotFound) {
+			return apigen.RevokeHouseholdInvite404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInviteNotFound) {
+			return apigen.RevokeHouseholdInvite404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.RevokeHouseholdInvite403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.RevokeHouseholdInvite500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.RevokeHouseholdInvite204Response{}, nil
+}
+
+func (ctrl *Controller) AcceptHouseholdInvite(ctx context.Context, request apigen.AcceptHouseholdInviteRequestObject) (apigen.AcceptHouseholdInviteResponseObject, error) {
+	if request.Body == nil {
+		return apigen.AcceptHouseholdInvite400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Code) == 0 {
+		return apigen.AcceptHouseholdInvite400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e code sono obbligatori",
+			},
+		}, nil
+	}
+
+	detail, err := ctrl.householdService.AcceptInvite(ctx, body.UserId, body.Code, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.AcceptHouseholdInvite400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInviteNotFound) {
+			return apigen.AcceptHouseholdInvite404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrHouseholdNotFound) {
+			return apigen.AcceptHouseholdInvite404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.AcceptHouseholdInvite403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.AcceptHouseholdInvite409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.AcceptHouseholdInvite500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.AcceptHouseholdInvite200JSONResponse(ToHouseholdDetail(detail)), nil
+}
+
+func (ctrl *Controller) UpdateHouseholdMember(ctx context.Context, request apigen.UpdateHouseholdMemberRequestObject) (apigen.UpdateHouseholdMemberResponseObject, error) {
+	if request.Body == nil {
+		return apigen.UpdateHouseholdMember400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Role) == 0 {
+		return apigen.UpdateHouseholdMember400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e role sono obbligatori",
+			},
+		}, nil
+	}
+
+	member, err := ctrl.householdService.UpdateMemberRole(ctx, body.UserId, request.HouseholdId, request.MemberId, dto.HouseholdRole(body.Role))
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.UpdateHouseholdMember400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.UpdateHouseholdMember400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrHouseholdNotFound) {
+			return apigen.UpdateHouseholdMember404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.UpdateHouseholdMember403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.UpdateHouseholdMember409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.UpdateHouseholdMember500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.UpdateHouseholdMember200JSONResponse(ToHouseholdMemberItem(member, nil)), nil
+}
+
+func (ctrl *Controller) RemoveHouseholdMember(ctx context.Context, request apigen.RemoveHouseholdMemberRequestObject) (apigen.RemoveHouseholdMemberResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.RemoveHouseholdMember400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.householdService.RemoveMember(ctx, request.Params.UserId, request.HouseholdId, request.MemberId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.RemoveHouseholdMember400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrHouseholdNotFound) {
+			return apigen.RemoveHouseholdMember404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.RemoveHouseholdMember403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.RemoveHouseholdMember409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.RemoveHouseholdMember500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.RemoveHouseholdMember204Response{}, nil
+}
+
+func (ctrl *Controller) ShareAccount(ctx context.Context, request apigen.ShareAccountRequestObject) (apigen.ShareAccountResponseObject, error) {
+	if request.Body == nil {
+		return apigen.ShareAccount400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.ShareAccount400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	account, err := ctrl.householdService.ShareAccount(ctx, body.UserId, request.AccountId, body.HouseholdId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ShareAccount400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.ShareAccount404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrHouseholdNotFound) {
+			return apigen.ShareAccount404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.ShareAccount403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.ShareAccount500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	balance, err := ctrl.accountService.GetAccountBalance(ctx, account.ID)
+	if err != nil {
+		return apigen.ShareAccount500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.ShareAccount200JSONResponse(toBalanceAccountItem(account, account.InitialBalance+balance)), nil
+}
+
+func (ctrl *Controller) ShareCategory(ctx context.Context, request apigen.ShareCategoryRequestObject) (apigen.ShareCategoryResponseObject, error) {
+	if request.Body == nil {
+		return apigen.ShareCategory400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.ShareCategory400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	category, err := ctrl.householdService.ShareCategory(ctx, body.UserId, request.CategoryId, body.HouseholdId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ShareCategory400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.ShareCategory404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrHouseholdNotFound) {
+			return apigen.ShareCategory404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.ShareCategory403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.ShareCategory500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	path, err := ctrl.categoryPath(ctx, body.UserId, category.ID)
+	if err != nil {
+		return apigen.ShareCategory500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.ShareCategory200JSONResponse(ToCategoryItem(category, path)), nil
+}
@@ -0,0 +1,475 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/service"
+)
+
+func (ctrl *Controller) CreateSecurity(ctx context.Context, request apigen.CreateSecurityRequestObject) (apigen.CreateSecurityResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreateSecurity400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Name) == 0 || len(body.Currency) == 0 {
+		return apigen.CreateSecurity400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId, name e currency sono obbligatori",
+			},
+		}, nil
+	}
+
+	security, err := ctrl.investmentService.CreateSecurity(ctx, dto.CreateSecurityDto{
+		UserID:   body.UserId,
+		Isin:     body.Isin,
+		Ticker:   body.Ticker,
+		Name:     body.Name,
+		Currency: body.Currency,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.CreateSecurity409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateSecurity400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreateSecurity400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreateSecurity500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreateSecurity201JSONResponse(ToSecurityItem(security, nil)), nil
+}
+
+func (ctrl *Controller) GetSecurities(ctx context.Context, request apigen.GetSecuritiesRequestObject) (apigen.GetSecuritiesResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetSecurities400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	quotes, err := ctrl.investmentService.GetSecurities(ctx, request.Params.UserId, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetSecurities400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetSecurities500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.SecurityItem, len(quotes))
+	for i, quote := range quotes {
+		response[i] = ToSecurityItem(quote.Security, quote.LastPrice)
+	}
+
+	return apigen.GetSecurities200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) ImportSecurityPrices(ctx context.Context, request apigen.ImportSecurityPricesRequestObject) (apigen.ImportSecurityPricesResponseObject, error) {
+	if request.Params.UserId == 0 || request.Body == nil {
+		return apigen.ImportSecurityPrices400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e file CSV sono obbligatori",
+			},
+		}, nil
+	}
+
+	result, err := ctrl.investmentService.ImportPrices(ctx, request.Params.UserId, request.Body)
+	if err != nil {
+		if errors.Is(err, errs.ErrFileTooLarge) {
+			return apigen.ImportSecurityPrices413JSONResponse{
+				PayloadTooLargeJSONResponse: apigen.PayloadTooLargeJSONResponse{
+					Code:    "FILE_TOO_LARGE",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ImportSecurityPrices400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.ImportSecurityPrices400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.ImportSecurityPrices500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	rowErrors := result.Errors
+	if rowErrors == nil {
+		rowErrors = []string{}
+	}
+	return apigen.ImportSecurityPrices200JSONResponse(apigen.PriceImportResult{
+		Imported: &result.Imported,
+		Errors:   &rowErrors,
+	}), nil
+}
+
+func (ctrl *Controller) GetSecurityPrices(ctx context.Context, request apigen.GetSecurityPricesRequestObject) (apigen.GetSecurityPricesResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetSecurityPrices400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	now := time.Now()
+	dateTo := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	dateFrom := dateTo.AddDate(-1, 0, 0)
+	if request.Params.From != nil {
+		dateFrom = request.Params.From.Time
+	}
+	if request.Params.To != nil {
+		dateTo = request.Params.To.Time
+	}
+	if dateFrom.After(dateTo) {
+		return apigen.GetSecurityPrices400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "from deve precedere to",
+			},
+		}, nil
+	}
+
+	prices, err := ctrl.investmentService.GetPrices(ctx, request.Params.UserId, request.SecurityId, dateFrom, dateTo)
+	if err != nil {
+		if errors.Is(err, errs.ErrSecurityNotFound) {
+			return apigen.GetSecurityPrices404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetSecurityPrices400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetSecurityPrices500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.SecurityPriceItem, len(prices))
+	for i, price := range prices {
+		response[i] = ToSecurityPriceItem(price)
+	}
+
+	return apigen.GetSecurityPrices200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) AddTrade(ctx context.Context, request apigen.AddTradeRequestObject) (apigen.AddTradeResponseObject, error) {
+	if request.Body == nil {
+		return apigen.AddTrade400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.SecurityId == 0 {
+		return apigen.AddTrade400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e securityId sono obbligatori",
+			},
+		}, nil
+	}
+
+	tradeDto := dto.AddTradeDto{
+		UserID:     body.UserId,
+		AccountID:  request.AccountId,
+		SecurityID: body.SecurityId,
+		Kind:       dto.TradeKind(body.Kind),
+		TradeDate:  body.Date.Time,
+	}
+	if body.Quantity != nil {
+		tradeDto.Quantity = service.ToFixed(*body.Quantity)
+	}
+	if body.Price != nil {
+		tradeDto.Price = service.ToFixed(*body.Price)
+	}
+	if body.Fees != nil {
+		tradeDto.Fees = *body.Fees
+	}
+	if body.Amount != nil {
+		tradeDto.Amount = *body.Amount
+	}
+
+	trade, err := ctrl.investmentService.AddTrade(ctx, tradeDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrAccountNotFound) || errors.Is(err, errs.ErrSecurityNotFound) {
+			return apigen.AddTrade404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.AddTrade400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.AddTrade400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.AddTrade403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.AddTrade500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.AddTrade201JSONResponse(ToTradeItem(trade)), nil
+}
+
+func (ctrl *Controller) GetTrades(ctx context.Context, request apigen.GetTradesRequestObject) (apigen.GetTradesResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetTrades400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	trades, err := ctrl.investmentService.GetTrades(ctx, request.Params.UserId, request.AccountId)
+	if err != nil {
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.GetTrades404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetTrades400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetTrades500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.TradeItem, len(trades))
+	for i, trade := range trades {
+		response[i] = ToTradeItem(trade)
+	}
+
+	return apigen.GetTrades200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) GetHoldings(ctx context.Context, request apigen.GetHoldingsRequestObject) (apigen.GetHoldingsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetHoldings400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	holdings, err := ctrl.investmentService.GetHoldings(ctx, request.Params.UserId, request.AccountId, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.GetHoldings404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetHoldings400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetHoldings500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetHoldings200JSONResponse(ToHoldings(holdings)), nil
+}
+
+func (ctrl *Controller) GetGainsReport(ctx context.Context, request apigen.GetGainsReportRequestObject) (apigen.GetGainsReportResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetGainsReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	now := time.Now()
+	dateFrom := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	dateTo := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if request.Params.From != nil {
+		dateFrom = request.Params.From.Time
+	}
+	if request.Params.To != nil {
+		dateTo = request.Params.To.Time
+	}
+	if dateFrom.After(dateTo) {
+		return apigen.GetGainsReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "from deve precedere to",
+			},
+		}, nil
+	}
+
+	report, err := ctrl.investmentService.GetGainsReport(ctx, request.Params.UserId, dateFrom, dateTo, now)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetGainsReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetGainsReport500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetGainsReport200JSONResponse(ToGainsReport(report)), nil
+}
+
+func (ctrl *Controller) GetNetWorth(ctx context.Context, request apigen.GetNetWorthRequestObject) (apigen.GetNetWorthResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetNetWorth400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	netWorth, err := ctrl.investmentService.GetNetWorth(ctx, request.Params.UserId, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetNetWorth400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetNetWorth500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetNetWorth200JSONResponse(ToNetWorth(netWorth)), nil
+}
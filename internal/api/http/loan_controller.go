@@ -0,0 +1,341 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) CreateLoan(ctx context.Context, request apigen.CreateLoanRequestObject) (apigen.CreateLoanResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreateLoan400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Name) == 0 || body.PaymentAccountId == 0 {
+		return apigen.CreateLoan400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId, name e paymentAccountId sono obbligatori",
+			},
+		}, nil
+	}
+
+	createDto := dto.CreateLoanDto{
+		UserID:           body.UserId,
+		Name:             body.Name,
+		PaymentAccountID: body.PaymentAccountId,
+		Principal:        body.Principal,
+		AnnualRateBps:    body.AnnualRateBps,
+		TermMonths:       body.TermMonths,
+		StartDate:        body.StartDate.Time,
+		Method:           dto.AmortizationMethod(body.Method),
+	}
+	if body.Currency != nil {
+		createDto.Currency = *body.Currency
+	}
+
+	detail, err := ctrl.loanService.CreateLoan(ctx, createDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.CreateLoan404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.CreateLoan409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateLoan400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreateLoan400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.CreateLoan403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreateLoan500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreateLoan201JSONResponse(ToLoanDetail(detail)), nil
+}
+
+func (ctrl *Controller) GetLoans(ctx context.Context, request apigen.GetLoansRequestObject) (apigen.GetLoansResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetLoans400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	loans, err := ctrl.loanService.GetLoans(ctx, request.Params.UserId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetLoans400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetLoans500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.LoanItem, len(loans))
+	for i, loan := range loans {
+		response[i] = ToLoanItem(loan)
+	}
+
+	return apigen.GetLoans200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) GetLoan(ctx context.Context, request apigen.GetLoanRequestObject) (apigen.GetLoanResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetLoan400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	detail, err := ctrl.loanService.GetLoan(ctx, request.Params.UserId, request.AccountId)
+	if err != nil {
+		if errors.Is(err, errs.ErrLoanNotFound) {
+			return apigen.GetLoan404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetLoan400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetLoan500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetLoan200JSONResponse(ToLoanDetail(detail)), nil
+}
+
+func (ctrl *Controller) GetLoanBalance(ctx context.Context, request apigen.GetLoanBalanceRequestObject) (apigen.GetLoanBalanceResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetLoanBalance400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	detail, err := ctrl.loanService.GetLoan(ctx, request.Params.UserId, request.AccountId)
+	if err != nil {
+		if errors.Is(err, errs.ErrLoanNotFound) {
+			return apigen.GetLoanBalance404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetLoanBalance400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetLoanBalance500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetLoanBalance200JSONResponse(ToLoanBalance(detail)), nil
+}
+
+func (ctrl *Controller) PostLoanInstallments(ctx context.Context, request apigen.PostLoanInstallmentsRequestObject) (apigen.PostLoanInstallmentsResponseObject, error) {
+	if request.Body == nil || request.Body.UserId == 0 {
+		return apigen.PostLoanInstallments400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	upTo := time.Now()
+	if request.Body.UpTo != nil {
+		upTo = request.Body.UpTo.Time
+	}
+
+	detail, posted, err := ctrl.loanService.PostDueInstallments(ctx, request.Body.UserId, request.AccountId, upTo)
+	if err != nil {
+		if errors.Is(err, errs.ErrLoanNotFound) || errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.PostLoanInstallments404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.PostLoanInstallments409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.PostLoanInstallments400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.PostLoanInstallments403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.PostLoanInstallments500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	loan := ToLoanDetail(detail)
+	return apigen.PostLoanInstallments200JSONResponse(apigen.PostLoanInstallmentsResponse{
+		Posted: &posted,
+		Loan:   &loan,
+	}), nil
+}
+
+func (ctrl *Controller) SimulateLoanPrepayment(ctx context.Context, request apigen.SimulateLoanPrepaymentRequestObject) (apigen.SimulateLoanPrepaymentResponseObject, error) {
+	params := request.Params
+	if params.UserId == 0 || params.Amount <= 0 {
+		return apigen.SimulateLoanPrepayment400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e amount sono obbligatori",
+			},
+		}, nil
+	}
+
+	prepaymentDto := dto.PrepaymentDto{
+		UserID:    params.UserId,
+		AccountID: request.AccountId,
+		Amount:    params.Amount,
+	}
+	if params.Mode != nil {
+		prepaymentDto.Mode = dto.PrepaymentMode(*params.Mode)
+	}
+
+	simulation, err := ctrl.loanService.SimulatePrepayment(ctx, prepaymentDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrLoanNotFound) {
+			return apigen.SimulateLoanPrepayment404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.SimulateLoanPrepayment409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.SimulateLoanPrepayment400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.SimulateLoanPrepayment400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.SimulateLoanPrepayment500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.SimulateLoanPrepayment200JSONResponse(ToPrepaymentSimulation(simulation)), nil
+}
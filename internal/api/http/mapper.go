@@ -1,8 +1,15 @@
 package http
 
 import (
+	"database/sql"
+	"time"
+
 	apigen "koin/internal/api/generated"
+	dbgen "koin/internal/db/generated"
 	"koin/internal/model/dto"
+	"koin/internal/service"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
 func ToCreateUserDto(in *apigen.CreateUserJSONRequestBody) dto.CreateUserDto {
@@ -26,14 +33,24 @@ func ToAddExpenseDto(in *apigen.AddTransactionJSONRequestBody) dto.AddTransactio
 	if in.Description != "" {
 		desc = &in.Description
 	}
+	var tags []string
+	if in.Tags != nil {
+		tags = *in.Tags
+	}
+	categoryName := ""
+	if in.CategoryName != nil {
+		categoryName = *in.CategoryName
+	}
 	return dto.AddTransactionDto{
 		UserID:       in.UserId,
 		AccountName:  in.AccountName,
-		CategoryName: in.CategoryName,
+		CategoryName: categoryName,
 		CategoryType: dto.CategoryType(in.CategoryType),
 		OccurredAt:   in.OccurredAt.Time,
 		Amount:       in.Amount,
 		Description:  desc,
+		Tags:         tags,
+		PayeeName:    in.Payee,
 	}
 }
 
@@ -47,5 +64,871 @@ func ToCreateCategoryDto(in *apigen.CreateCategoryJSONRequestBody) dto.CreateCat
 		Name:         in.Name,
 		CategoryType: dto.CategoryType(in.CategoryType),
 		Description:  description,
+		ParentID:     in.ParentId,
+	}
+}
+
+func ToCategoryItem(category dbgen.Category, path string) apigen.CategoryItem {
+	var parentID *int64
+	if category.ParentID.Valid {
+		parentID = &category.ParentID.Int64
+	}
+	archived := category.ArchivedAt.Valid
+	return apigen.CategoryItem{
+		Id:            &category.ID,
+		Name:          &category.Name,
+		CategoryType:  &category.Type,
+		ParentId:      parentID,
+		Path:          &path,
+		Archived:      &archived,
+		HouseholdId:   nullInt64Ptr(category.HouseholdID),
+		MonthlyBudget: nullInt64Ptr(category.MonthlyBudget),
+		DeductionCode: nullStringPtr(category.DeductionCode),
+	}
+}
+
+func ToTagItem(tag dbgen.Tag) apigen.TagItem {
+	return apigen.TagItem{
+		Id:   &tag.ID,
+		Name: &tag.Name,
+	}
+}
+
+func ToTagItems(tags []dbgen.Tag) []apigen.TagItem {
+	items := make([]apigen.TagItem, len(tags))
+	for i, tag := range tags {
+		items[i] = ToTagItem(tag)
+	}
+	return items
+}
+
+func ToPayeeItem(payee dbgen.Payee, aliases []dbgen.PayeeAlias) apigen.PayeeItem {
+	var defaultCategoryID *int64
+	if payee.DefaultCategoryID.Valid {
+		defaultCategoryID = &payee.DefaultCategoryID.Int64
+	}
+	aliasItems := make([]apigen.PayeeAliasItem, len(aliases))
+	for i, alias := range aliases {
+		aliasItems[i] = ToPayeeAliasItem(alias)
+	}
+	return apigen.PayeeItem{
+		Id:                &payee.ID,
+		Name:              &payee.Name,
+		DefaultCategoryId: defaultCategoryID,
+		Aliases:           &aliasItems,
+	}
+}
+
+func ToPayeeAliasItem(alias dbgen.PayeeAlias) apigen.PayeeAliasItem {
+	return apigen.PayeeAliasItem{
+		Id:      &alias.ID,
+		Pattern: &alias.Pattern,
+	}
+}
+
+func ToAttachmentItem(attachment dbgen.Attachment) apigen.AttachmentItem {
+	hasThumbnail := attachment.ThumbnailKey.Valid
+	return apigen.AttachmentItem{
+		Id:            &attachment.ID,
+		TransactionId: &attachment.TransactionID,
+		FileName:      &attachment.FileName,
+		ContentType:   &attachment.ContentType,
+		SizeBytes:     &attachment.SizeBytes,
+		HasThumbnail:  &hasThumbnail,
+		CreatedAt:     &attachment.CreatedAt,
+	}
+}
+
+func ToReconciliationItem(reconciliation dbgen.Reconciliation) apigen.ReconciliationItem {
+	statementDate := openapi_types.Date{Time: reconciliation.StatementDate}
+	status := apigen.ReconciliationItemStatus(reconciliation.Status)
+	var completedAt *time.Time
+	if reconciliation.CompletedAt.Valid {
+		completedAt = &reconciliation.CompletedAt.Time
+	}
+	return apigen.ReconciliationItem{
+		Id:               &reconciliation.ID,
+		AccountId:        &reconciliation.AccountID,
+		StatementDate:    &statementDate,
+		StatementBalance: &reconciliation.StatementBalance,
+		Status:           &status,
+		CreatedAt:        &reconciliation.CreatedAt,
+		CompletedAt:      completedAt,
+	}
+}
+
+func ToReconciliationDetail(status dto.ReconciliationStatus) apigen.ReconciliationDetail {
+	reconciliation := ToReconciliationItem(status.Reconciliation)
+	entries := make([]apigen.ReconciliationEntry, len(status.Entries))
+	for i, entry := range status.Entries {
+		occurredAt := openapi_types.Date{Time: entry.OccurredAt}
+		cleared := entry.Status == string(dto.Cleared)
+		entries[i] = apigen.ReconciliationEntry{
+			EntryId:       &entry.EntryID,
+			TransactionId: &entry.TransactionID,
+			OccurredAt:    &occurredAt,
+			Amount:        &entry.Amount,
+			Description:   nullStringPtr(entry.Description),
+			CategoryName:  nullStringPtr(entry.CategoryName),
+			PayeeName:     nullStringPtr(entry.PayeeName),
+			Cleared:       &cleared,
+		}
+	}
+	return apigen.ReconciliationDetail{
+		Reconciliation: &reconciliation,
+		AccountName:    &status.Account.Name,
+		ClearedBalance: &status.ClearedBalance,
+		Difference:     &status.Difference,
+		Entries:        &entries,
+	}
+}
+
+func nullStringPtr(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func nullInt64Ptr(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+func ToCreditCardItem(creditCard dbgen.CreditCard) apigen.CreditCardItem {
+	return apigen.CreditCardItem{
+		AccountId:        &creditCard.AccountID,
+		PaymentAccountId: &creditCard.PaymentAccountID,
+		ClosingDay:       &creditCard.ClosingDay,
+		DueDay:           &creditCard.DueDay,
+		AutoPay:          &creditCard.AutoPay,
+	}
+}
+
+func ToCreditCardStatements(statements dto.CreditCardStatements) apigen.CreditCardStatements {
+	creditCard := ToCreditCardItem(statements.CreditCard)
+	items := make([]apigen.CreditCardStatement, len(statements.Statements))
+	for i, statement := range statements.Statements {
+		status := apigen.CreditCardStatementStatus(statement.Status)
+		item := apigen.CreditCardStatement{
+			PeriodStart: &openapi_types.Date{Time: statement.PeriodStart},
+			ClosingDate: &openapi_types.Date{Time: statement.ClosingDate},
+			DueDate:     &openapi_types.Date{Time: statement.DueDate},
+			Owed:        &statement.Owed,
+			Paid:        &statement.Paid,
+			Outstanding: &statement.Outstanding,
+			Status:      &status,
+		}
+		if statement.SuggestedPaymentDate != nil {
+			item.SuggestedPayment = &apigen.SuggestedPayment{
+				FromAccountId:   &statements.PaymentAccount.ID,
+				FromAccountName: &statements.PaymentAccount.Name,
+				Amount:          &statement.Outstanding,
+				Date:            &openapi_types.Date{Time: *statement.SuggestedPaymentDate},
+			}
+		}
+		items[i] = item
+	}
+	return apigen.CreditCardStatements{
+		CreditCard:         &creditCard,
+		AccountName:        &statements.Account.Name,
+		PaymentAccountName: &statements.PaymentAccount.Name,
+		Statements:         &items,
+	}
+}
+
+func ToLoanInstallmentItem(installment dbgen.LoanInstallment, remainingPrincipal int64) apigen.LoanInstallmentItem {
+	payment := installment.Principal + installment.Interest
+	var transactionID *int64
+	if installment.TransactionID.Valid {
+		transactionID = &installment.TransactionID.Int64
+	}
+	return apigen.LoanInstallmentItem{
+		Number:             &installment.Number,
+		DueDate:            &openapi_types.Date{Time: installment.DueDate},
+		Principal:          &installment.Principal,
+		Interest:           &installment.Interest,
+		Payment:            &payment,
+		RemainingPrincipal: &remainingPrincipal,
+		TransactionId:      transactionID,
+	}
+}
+
+func ToScheduledInstallmentItem(installment dto.Installment) apigen.LoanInstallmentItem {
+	payment := installment.Principal + installment.Interest
+	return apigen.LoanInstallmentItem{
+		Number:             &installment.Number,
+		DueDate:            &openapi_types.Date{Time: installment.DueDate},
+		Principal:          &installment.Principal,
+		Interest:           &installment.Interest,
+		Payment:            &payment,
+		RemainingPrincipal: &installment.RemainingPrincipal,
+	}
+}
+
+func ToLoanBalance(detail dto.LoanDetail) apigen.LoanBalance {
+	balance := detail.Balance
+	var next *apigen.LoanInstallmentItem
+	if balance.NextInstallment != nil {
+		item := ToLoanInstallmentItem(*balance.NextInstallment, balance.RemainingPrincipal-balance.NextInstallment.Principal)
+		next = &item
+	}
+	return apigen.LoanBalance{
+		RemainingPrincipal: &balance.RemainingPrincipal,
+		RemainingInterest:  &balance.RemainingInterest,
+		PaidPrincipal:      &balance.PaidPrincipal,
+		PaidInterest:       &balance.PaidInterest,
+		InstallmentsPaid:   &balance.InstallmentsPaid,
+		InstallmentsLeft:   &balance.InstallmentsLeft,
+		NextInstallment:    next,
+	}
+}
+
+func ToLoanItem(detail dto.LoanDetail) apigen.LoanItem {
+	loan := detail.Loan
+	method := apigen.LoanItemMethod(loan.Method)
+	balance := ToLoanBalance(detail)
+	return apigen.LoanItem{
+		AccountId:          &loan.AccountID,
+		Name:               &detail.Account.Name,
+		Currency:           &detail.Account.Currency,
+		PaymentAccountId:   &loan.PaymentAccountID,
+		PaymentAccountName: &detail.PaymentAccount.Name,
+		Principal:          &loan.Principal,
+		AnnualRateBps:      &loan.AnnualRateBps,
+		TermMonths:         &loan.TermMonths,
+		StartDate:          &openapi_types.Date{Time: loan.StartDate},
+		Method:             &method,
+		Balance:            &balance,
+	}
+}
+
+func ToLoanDetail(detail dto.LoanDetail) apigen.LoanDetail {
+	loan := ToLoanItem(detail)
+	installments := make([]apigen.LoanInstallmentItem, len(detail.Installments))
+	remaining := detail.Loan.Principal
+	for i, installment := range detail.Installments {
+		remaining -= installment.Principal
+		installments[i] = ToLoanInstallmentItem(installment, remaining)
+	}
+	return apigen.LoanDetail{
+		Loan:         &loan,
+		Installments: &installments,
+	}
+}
+
+func ToPrepaymentSimulation(simulation dto.PrepaymentSimulation) apigen.PrepaymentSimulation {
+	mode := apigen.PrepaymentSimulationMode(simulation.Mode)
+	schedule := make([]apigen.LoanInstallmentItem, len(simulation.Schedule))
+	for i, installment := range simulation.Schedule {
+		schedule[i] = ToScheduledInstallmentItem(installment)
+	}
+	return apigen.PrepaymentSimulation{
+		Mode:               &mode,
+		Amount:             &simulation.Amount,
+		RemainingPrincipal: &simulation.RemainingPrincipal,
+		CurrentInstallment: &simulation.CurrentInstallment,
+		NewInstallment:     &simulation.NewInstallment,
+		CurrentMonths:      &simulation.CurrentMonths,
+		NewMonths:          &simulation.NewMonths,
+		CurrentInterest:    &simulation.CurrentInterest,
+		NewInterest:        &simulation.NewInterest,
+		InterestSaved:      &simulation.InterestSaved,
+		Schedule:           &schedule,
+	}
+}
+
+func ToSecurityPriceItem(price dbgen.SecurityPrice) apigen.SecurityPriceItem {
+	value := service.FromFixed(price.Price)
+	return apigen.SecurityPriceItem{
+		Date:  &openapi_types.Date{Time: price.PriceDate},
+		Price: &value,
+	}
+}
+
+func ToSecurityItem(security dbgen.Security, lastPrice *dbgen.SecurityPrice) apigen.SecurityItem {
+	item := apigen.SecurityItem{
+		Id:       &security.ID,
+		Isin:     nullStringPtr(security.Isin),
+		Ticker:   nullStringPtr(security.Ticker),
+		Name:     &security.Name,
+		Currency: &security.Currency,
+	}
+	if lastPrice != nil {
+		price := ToSecurityPriceItem(*lastPrice)
+		item.LastPrice = &price
+	}
+	return item
+}
+
+func ToTradeItem(trade dbgen.InvestmentTrade) apigen.TradeItem {
+	kind := apigen.TradeItemKind(trade.Kind)
+	quantity := service.FromFixed(trade.Quantity)
+	price := service.FromFixed(trade.Price)
+	return apigen.TradeItem{
+		Id:            &trade.ID,
+		AccountId:     &trade.AccountID,
+		SecurityId:    &trade.SecurityID,
+		TransactionId: &trade.TransactionID,
+		Kind:          &kind,
+		Date:          &openapi_types.Date{Time: trade.TradeDate},
+		Quantity:      &quantity,
+		Price:         &price,
+		Fees:          &trade.Fees,
+		Amount:        &trade.Amount,
+	}
+}
+
+func ToPositionItem(position dto.Position) apigen.PositionItem {
+	security := ToSecurityItem(position.Security, position.LastPrice)
+	quantity := service.FromFixed(position.Quantity)
+	return apigen.PositionItem{
+		AccountId:      &position.AccountID,
+		Security:       &security,
+		Quantity:       &quantity,
+		CostBasis:      &position.CostBasis,
+		MarketValue:    &position.MarketValue,
+		UnrealizedGain: &position.UnrealizedGain,
+	}
+}
+
+func toPositionItems(positions []dto.Position) []apigen.PositionItem {
+	items := make([]apigen.PositionItem, len(positions))
+	for i, position := range positions {
+		items[i] = ToPositionItem(position)
+	}
+	return items
+}
+
+func toBalanceAccountItem(account dbgen.Account, currentBalance int64) apigen.AccountItem {
+	return apigen.AccountItem{
+		Id:             &account.ID,
+		Name:           &account.Name,
+		Currency:       &account.Currency,
+		InitialBalance: &account.InitialBalance,
+		CurrentBalance: &currentBalance,
+		HouseholdId:    nullInt64Ptr(account.HouseholdID),
+		OverdraftLimit: &account.OverdraftLimit,
+	}
+}
+
+func ToHoldings(holdings dto.Holdings) apigen.Holdings {
+	account := toBalanceAccountItem(holdings.Account, holdings.CashBalance)
+	positions := toPositionItems(holdings.Positions)
+	total := holdings.CashBalance + holdings.MarketValue
+	return apigen.Holdings{
+		Account:     &account,
+		CashBalance: &holdings.CashBalance,
+		MarketValue: &holdings.MarketValue,
+		Total:       &total,
+		Positions:   &positions,
+	}
+}
+
+func ToGainsReport(report dto.GainsReport) apigen.GainsReport {
+	realized := make([]apigen.RealizedGainItem, len(report.Realized))
+	for i, gain := range report.Realized {
+		security := ToSecurityItem(gain.Security, nil)
+		quantity := service.FromFixed(gain.Quantity)
+		realized[i] = apigen.RealizedGainItem{
+			TradeId:   &gain.TradeID,
+			AccountId: &gain.AccountID,
+			Security:  &security,
+			Date:      &openapi_types.Date{Time: gain.SellDate},
+			Quantity:  &quantity,
+			Proceeds:  &gain.Proceeds,
+			CostBasis: &gain.CostBasis,
+			Gain:      &gain.Gain,
+		}
+	}
+	unrealized := toPositionItems(report.Unrealized)
+	return apigen.GainsReport{
+		From:            &openapi_types.Date{Time: report.DateFrom},
+		To:              &openapi_types.Date{Time: report.DateTo},
+		Realized:        &realized,
+		RealizedTotal:   &report.RealizedTotal,
+		Dividends:       &report.Dividends,
+		Unrealized:      &unrealized,
+		UnrealizedTotal: &report.UnrealizedTotal,
+	}
+}
+
+func ToNetWorth(netWorth dto.NetWorth) apigen.NetWorth {
+	accounts := make([]apigen.NetWorthAccount, len(netWorth.Accounts))
+	for i, item := range netWorth.Accounts {
+		account := toBalanceAccountItem(item.Account, item.CashBalance)
+		total := item.CashBalance + item.MarketValue
+		accounts[i] = apigen.NetWorthAccount{
+			Account:     &account,
+			CashBalance: &item.CashBalance,
+			MarketValue: &item.MarketValue,
+			Total:       &total,
+		}
+	}
+	return apigen.NetWorth{
+		Accounts: &accounts,
+		Totals:   &netWorth.Totals,
+	}
+}
+
+func ToHouseholdItem(household dbgen.Household, role string) apigen.HouseholdItem {
+	return apigen.HouseholdItem{
+		Id:        household.ID,
+		Name:      household.Name,
+		Role:      apigen.HouseholdRole(role),
+		CreatedAt: household.CreatedAt,
+	}
+}
+
+func ToHouseholdMemberItem(member dbgen.HouseholdMember, email *string) apigen.HouseholdMemberItem {
+	return apigen.HouseholdMemberItem{
+		UserId:   member.UserID,
+		Email:    email,
+		Role:     apigen.HouseholdRole(member.Role),
+		JoinedAt: member.JoinedAt,
+	}
+}
+
+func ToHouseholdInviteItem(invite dbgen.HouseholdInvite) apigen.HouseholdInviteItem {
+	return apigen.HouseholdInviteItem{
+		Id:        invite.ID,
+		Code:      invite.Code,
+		Email:     nullStringPtr(invite.Email),
+		Role:      apigen.HouseholdRole(invite.Role),
+		ExpiresAt: invite.ExpiresAt,
+	}
+}
+
+func ToHouseholdDetail(detail dto.HouseholdDetail) apigen.HouseholdDetail {
+	members := make([]apigen.HouseholdMemberItem, len(detail.Members))
+	for i, member := range detail.Members {
+		members[i] = ToHouseholdMemberItem(dbgen.HouseholdMember{
+			HouseholdID: member.HouseholdID,
+			UserID:      member.UserID,
+			Role:        member.Role,
+			JoinedAt:    member.JoinedAt,
+		}, &member.Email)
+	}
+	invites := make([]apigen.HouseholdInviteItem, len(detail.Invites))
+	for i, invite := range detail.Invites {
+		invites[i] = ToHouseholdInviteItem(invite)
+	}
+	return apigen.HouseholdDetail{
+		Household: ToHouseholdItem(detail.Household, string(detail.Role)),
+		Members:   members,
+		Invites:   invites,
+	}
+}
+
+func ToSplitGroupItem(group dbgen.SplitGroup) apigen.SplitGroupItem {
+	return apigen.SplitGroupItem{
+		Id:        group.ID,
+		Name:      group.Name,
+		Currency:  group.Currency,
+		CreatedBy: group.CreatedBy,
+		CreatedAt: group.CreatedAt,
+	}
+}
+
+func ToSplitMemberItem(member dbgen.SplitMember) apigen.SplitMemberItem {
+	return apigen.SplitMemberItem{
+		Id:     member.ID,
+		Name:   member.Name,
+		UserId: nullInt64Ptr(member.UserID),
+	}
+}
+
+func ToSplitSettlementItem(settlement dbgen.SplitSettlement) apigen.SplitSettlementItem {
+	return apigen.SplitSettlementItem{
+		Id:                settlement.ID,
+		FromMemberId:      settlement.FromMemberID,
+		ToMemberId:        settlement.ToMemberID,
+		Amount:            settlement.Amount,
+		SettledAt:         openapi_types.Date{Time: settlement.SettledAt},
+		FromTransactionId: nullInt64Ptr(settlement.FromTransactionID),
+		ToTransactionId:   nullInt64Ptr(settlement.ToTransactionID),
+	}
+}
+
+func ToSplitGroupDetail(detail dto.SplitGroupDetail) apigen.SplitGroupDetail {
+	members := make([]apigen.SplitMemberItem, len(detail.Members))
+	for i, member := range detail.Members {
+		members[i] = ToSplitMemberItem(member)
+	}
+	balances := make([]apigen.SplitBalanceItem, len(detail.Balances))
+	for i, balance := range detail.Balances {
+		balances[i] = apigen.SplitBalanceItem{
+			MemberId: balance.Member.ID,
+			Paid:     balance.Paid,
+			Owed:     balance.Owed,
+			Net:      balance.Net,
+		}
+	}
+	settleUp := make([]apigen.SettleUpItem, len(detail.SettleUp))
+	for i, transfer := range detail.SettleUp {
+		settleUp[i] = apigen.SettleUpItem{
+			FromMemberId: transfer.FromMemberID,
+			ToMemberId:   transfer.ToMemberID,
+			Amount:       transfer.Amount,
+		}
+	}
+	settlements := make([]apigen.SplitSettlementItem, len(detail.Settlements))
+	for i, settlement := range detail.Settlements {
+		settlements[i] = ToSplitSettlementItem(settlement)
+	}
+	return apigen.SplitGroupDetail{
+		Group:       ToSplitGroupItem(detail.Group),
+		Members:     members,
+		Balances:    balances,
+		SettleUp:    settleUp,
+		Settlements: settlements,
+	}
+}
+
+func ToSplitExpenseItem(detail dto.SplitExpenseDetail) apigen.SplitExpenseItem {
+	shares := make([]apigen.SplitShareItem, len(detail.Shares))
+	for i, share := range detail.Shares {
+		amount := share.Amount
+		shares[i] = apigen.SplitShareItem{
+			MemberId: share.MemberID,
+			Amount:   &amount,
+		}
+		if share.Shares.Valid {
+			weight := share.Shares.Int32
+			shares[i].Shares = &weight
+		}
+	}
+	expense := detail.Expense
+	return apigen.SplitExpenseItem{
+		Id:            expense.ID,
+		TransactionId: expense.TransactionID,
+		PaidBy:        expense.PaidBy,
+		Amount:        expense.Amount,
+		Method:        apigen.SplitMethod(expense.Method),
+		Description:   &expense.Description,
+		OccurredAt:    expense.OccurredAt,
+		Shares:        shares,
+	}
+}
+
+func ToReimbursableItem(entry dto.ReimbursableEntry) apigen.ReimbursableItem {
+	reimbursements := make([]apigen.ReimbursementItem, len(entry.Reimbursements))
+	for i, reimbursement := range entry.Reimbursements {
+		reimbursements[i] = apigen.ReimbursementItem{
+			Id:            reimbursement.ID,
+			IncomeEntryId: reimbursement.IncomeEntryID,
+			Amount:        reimbursement.Amount,
+		}
+	}
+	row := entry.Entry
+	return apigen.ReimbursableItem{
+		EntryId:        row.EntryID,
+		TransactionId:  row.TransactionID,
+		Party:          row.Party,
+		OccurredAt:     openapi_types.Date{Time: row.OccurredAt},
+		AccountName:    row.AccountName,
+		Currency:       row.Currency,
+		Description:    &row.Description,
+		Amount:         row.Amount,
+		Reimbursed:     row.Reimbursed,
+		Outstanding:    entry.Outstanding(),
+		Reimbursements: reimbursements,
+	}
+}
+
+func ToReimbursementReport(report dto.ReimbursementReport) apigen.ReimbursementReport {
+	entries := make([]apigen.ReimbursableItem, len(report.Entries))
+	for i, entry := range report.Entries {
+		entries[i] = ToReimbursableItem(entry)
+	}
+	parties := make([]apigen.PartyOutstandingItem, len(report.Parties))
+	for i, party := range report.Parties {
+		parties[i] = apigen.PartyOutstandingItem{
+			Party:       party.Party,
+			Currency:    party.Currency,
+			Outstanding: party.Outstanding,
+		}
+	}
+	return apigen.ReimbursementReport{
+		Entries: entries,
+		Parties: parties,
+	}
+}
+
+func ToRecurringTransactionItem(recurring dbgen.GetRecurringTransactionsByUserRow) apigen.RecurringTransactionItem {
+	frequency := apigen.RecurringFrequency(recurring.Frequency)
+	item := apigen.RecurringTransactionItem{
+		Id:           &recurring.ID,
+		AccountId:    &recurring.AccountID,
+		AccountName:  &recurring.AccountName,
+		CategoryId:   &recurring.CategoryID,
+		CategoryName: &recurring.CategoryName,
+		Amount:       &recurring.Amount,
+		Description:  &recurring.Description,
+		Frequency:    &frequency,
+		RepeatEvery:  &recurring.RepeatEvery,
+		StartDate:    &openapi_types.Date{Time: recurring.StartDate},
+	}
+	if recurring.EndDate.Valid {
+		item.EndDate = &openapi_types.Date{Time: recurring.EndDate.Time}
+	}
+	return item
+}
+
+func ToForecast(forecast dto.Forecast) apigen.Forecast {
+	accounts := make([]apigen.AccountForecast, len(forecast.Accounts))
+	for i, accountForecast := range forecast.Accounts {
+		accounts[i] = toAccountForecast(accountForecast)
+	}
+	return apigen.Forecast{
+		From:          &openapi_types.Date{Time: forecast.From},
+		To:            &openapi_types.Date{Time: forecast.To},
+		HistoryMonths: &forecast.HistoryMonths,
+		Accounts:      &accounts,
+	}
+}
+
+func toAccountForecast(forecast dto.AccountForecast) apigen.AccountForecast {
+	account := toBalanceAccountItem(forecast.Account, forecast.StartBalance)
+	points := make([]apigen.ForecastPoint, len(forecast.Points))
+	for i, point := range forecast.Points {
+		points[i] = apigen.ForecastPoint{
+			Date:    &openapi_types.Date{Time: point.Date},
+			Balance: &point.Balance,
+		}
+	}
+	events := make([]apigen.ForecastEvent, len(forecast.Events))
+	for i, event := range forecast.Events {
+		kind := apigen.ForecastEventKind(event.Kind)
+		events[i] = apigen.ForecastEvent{
+			Date:        &openapi_types.Date{Time: event.Date},
+			Kind:        &kind,
+			Amount:      &event.Amount,
+			Description: &event.Description,
+		}
+	}
+	discretionary := make([]apigen.CategoryAverageItem, len(forecast.Discretionary))
+	for i, average := range forecast.Discretionary {
+		discretionary[i] = apigen.CategoryAverageItem{
+			CategoryId:   &average.CategoryID,
+			CategoryName: &average.CategoryName,
+			Monthly:      &average.Monthly,
+		}
+	}
+	alerts := make([]apigen.ForecastAlert, len(forecast.Alerts))
+	for i, alert := range forecast.Alerts {
+		kind := apigen.ForecastAlertKind(alert.Kind)
+		alerts[i] = apigen.ForecastAlert{
+			Date:    &openapi_types.Date{Time: alert.Date},
+			Kind:    &kind,
+			Balance: &alert.Balance,
+		}
+	}
+	return apigen.AccountForecast{
+		Account:        &account,
+		StartBalance:   &forecast.StartBalance,
+		MinBalance:     &forecast.MinBalance,
+		MinBalanceDate: &openapi_types.Date{Time: forecast.MinBalanceDate},
+		Points:         &points,
+		Events:         &events,
+		Discretionary:  &discretionary,
+		Alerts:         &alerts,
+	}
+}
+
+func ToSubscriptionItem(subscription dto.Subscription) apigen.SubscriptionItem {
+	frequency := apigen.RecurringFrequency(subscription.Frequency)
+	item := apigen.SubscriptionItem{
+		Key:          &subscription.Key,
+		AccountId:    &subscription.AccountID,
+		AccountName:  &subscription.AccountName,
+		Currency:     &subscription.Currency,
+		CategoryId:   &subscription.CategoryID,
+		CategoryName: &subscription.CategoryName,
+		PayeeId:      subscription.PayeeID,
+		Name:         &subscription.Name,
+		Frequency:    &frequency,
+		RepeatEvery:  &subscription.RepeatEvery,
+		Amount:       &subscription.Amount,
+		Occurrences:  &subscription.Occurrences,
+		FirstDate:    &openapi_types.Date{Time: subscription.FirstDate},
+		LastDate:     &openapi_types.Date{Time: subscription.LastDate},
+		NextDate:     &openapi_types.Date{Time: subscription.NextDate},
+		AnnualCost:   &subscription.AnnualCost,
+		RecurringId:  subscription.RecurringID,
+	}
+	if subscription.PriceIncrease != nil {
+		item.PriceIncrease = &apigen.PriceIncrease{
+			PreviousAmount: &subscription.PriceIncrease.PreviousAmount,
+			Amount:         &subscription.PriceIncrease.Amount,
+			Since:          &openapi_types.Date{Time: subscription.PriceIncrease.Since},
+		}
+	}
+	return item
+}
+
+func ToAnomalyItem(anomaly dbgen.SpendingAnomaly) apigen.AnomalyItem {
+	kind := apigen.AnomalyItemKind(anomaly.Kind)
+	item := apigen.AnomalyItem{
+		Id:         &anomaly.ID,
+		Kind:       &kind,
+		EntryId:    nullInt64Ptr(anomaly.EntryID),
+		CategoryId: &anomaly.CategoryID,
+		Amount:     &anomaly.Amount,
+		Baseline:   &anomaly.Baseline,
+		RatioPct:   &anomaly.RatioPct,
+		Message:    &anomaly.Message,
+		DetectedAt: &anomaly.DetectedAt,
+	}
+	if anomaly.Month.Valid {
+		item.Month = &openapi_types.Date{Time: anomaly.Month.Time}
+	}
+	if anomaly.DismissedAt.Valid {
+		item.DismissedAt = &anomaly.DismissedAt.Time
+	}
+	return item
+}
+
+func ToNotificationItem(notification dbgen.Notification) apigen.NotificationItem {
+	kind := apigen.NotificationKind(notification.Kind)
+	item := apigen.NotificationItem{
+		Id:        &notification.ID,
+		Kind:      &kind,
+		Title:     &notification.Title,
+		Body:      &notification.Body,
+		CreatedAt: &notification.CreatedAt,
+	}
+	if notification.ReadAt.Valid {
+		item.ReadAt = &notification.ReadAt.Time
+	}
+	return item
+}
+
+func ToNotificationSettings(settings dto.NotificationSettings) apigen.NotificationSettings {
+	preferences := make([]apigen.NotificationPreference, len(settings.Preferences))
+	for i, preference := range settings.Preferences {
+		preferences[i] = apigen.NotificationPreference{
+			Kind:    apigen.NotificationKind(preference.Kind),
+			Email:   preference.Email,
+			Webhook: preference.Webhook,
+			InApp:   preference.InApp,
+		}
+	}
+	return apigen.NotificationSettings{
+		WebhookUrl:          settings.WebhookURL,
+		LowBalanceThreshold: &settings.LowBalanceThreshold,
+		Preferences:         &preferences,
+	}
+}
+
+func ToWebhookItem(endpoint dbgen.WebhookEndpoint) apigen.WebhookItem {
+	return apigen.WebhookItem{
+		Id:          endpoint.ID,
+		Url:         endpoint.Url,
+		Description: nullStringPtr(endpoint.Description),
+		CreatedAt:   endpoint.CreatedAt,
+	}
+}
+
+func ToWebhookDeliveryItem(delivery dbgen.GetWebhookDeliveriesRow) apigen.WebhookDeliveryItem {
+	item := apigen.WebhookDeliveryItem{
+		Id:         delivery.ID,
+		EventId:    delivery.OutboxID,
+		Event:      delivery.Event,
+		Resource:   apigen.WebhookDeliveryItemResource(delivery.Resource),
+		ResourceId: delivery.ResourceID,
+		Status:     apigen.WebhookDeliveryItemStatus(delivery.Status),
+		Attempts:   delivery.Attempts,
+		LastError:  nullStringPtr(delivery.LastError),
+		CreatedAt:  delivery.CreatedAt,
+	}
+	if delivery.Status == "PENDING" {
+		item.NextAttemptAt = &delivery.NextAttemptAt
+	}
+	if delivery.LastStatusCode.Valid {
+		item.LastStatusCode = &delivery.LastStatusCode.Int32
+	}
+	if delivery.DeliveredAt.Valid {
+		item.DeliveredAt = &delivery.DeliveredAt.Time
+	}
+	return item
+}
+
+func ToDigestSettings(settings dto.DigestSettings) apigen.DigestSettings {
+	return apigen.DigestSettings{
+		Weekly:  settings.Weekly,
+		Monthly: settings.Monthly,
+		Weekday: int32(settings.Weekday),
+	}
+}
+
+func ToEntryDeductionItem(entry dbgen.TransactionEntry) apigen.EntryDeductionItem {
+	return apigen.EntryDeductionItem{
+		EntryId:       entry.ID,
+		TransactionId: entry.TransactionID,
+		DeductionCode: nullStringPtr(entry.DeductionCode),
+	}
+}
+
+func ToDeductionReport(deductions dto.DeductionReport) apigen.DeductionReport {
+	lines := make([]apigen.DeductionLine, len(deductions.Lines))
+	for i, line := range deductions.Lines {
+		entries := make([]apigen.DeductionEntryItem, len(line.Entries))
+		for j, entry := range line.Entries {
+			receipts := entry.Receipts
+			if receipts == nil {
+				receipts = []string{}
+			}
+			entries[j] = apigen.DeductionEntryItem{
+				EntryId:       entry.Entry.ID,
+				TransactionId: entry.Entry.TransactionID,
+				Date:          openapi_types.Date{Time: entry.Entry.OccurredAt},
+				AccountName:   entry.Entry.AccountName,
+				CategoryName:  nullStringPtr(entry.Entry.CategoryName),
+				PayeeName:     nullStringPtr(entry.Entry.PayeeName),
+				Description:   nullStringPtr(entry.Entry.Description),
+				Amount:        entry.Deductible(),
+				Receipts:      receipts,
+			}
+		}
+		lines[i] = apigen.DeductionLine{
+			Code:            apigen.DeductionCode(line.Code),
+			Line:            line.Line,
+			Label:           line.Label,
+			Total:           line.Total,
+			MissingReceipts: line.MissingReceipts,
+			Entries:         entries,
+		}
+	}
+	return apigen.DeductionReport{
+		Year:  int32(deductions.Year),
+		Total: deductions.Total,
+		Lines: lines,
+	}
+}
+
+func ToBackupStatus(status dto.BackupStatus) apigen.BackupStatus {
+	files := make([]apigen.BackupFile, len(status.Files))
+	for i, file := range status.Files {
+		files[i] = apigen.BackupFile{
+			Name:      file.Name,
+			SizeBytes: file.SizeBytes,
+			CreatedAt: file.CreatedAt,
+		}
+	}
+	return apigen.BackupStatus{
+		Enabled:         status.Enabled,
+		Mode:            apigen.BackupMode(status.Mode),
+		IntervalSeconds: int64(status.Interval / time.Second),
+		Compressed:      status.Compressed,
+		Encrypted:       status.Encrypted,
+		Running:         status.Running,
+		LastRunAt:       status.LastRunAt,
+		LastSuccessAt:   status.LastSuccessAt,
+		LastError:       status.LastError,
+		NextRunAt:       status.NextRunAt,
+		Files:           files,
 	}
 }
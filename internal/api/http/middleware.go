@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"strings"
 	"time"
@@ -38,6 +39,19 @@ func BearerAuth(expectedToken string) gin.HandlerFunc {
 	}
 }
 
+// SessionOrBearerAuth lascia passare le richieste con una sessione valida e richiede il token
+// alle altre.
+func SessionOrBearerAuth(expectedToken string) gin.HandlerFunc {
+	bearer := BearerAuth(expectedToken)
+	return func(c *gin.Context) {
+		if _, ok := sessions.Default(c).Get("userID").(int64); ok {
+			c.Next()
+			return
+		}
+		bearer(c)
+	}
+}
+
 // AuthMiddleware controlla che l'utente sia loggato via sessione
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -56,6 +70,17 @@ func AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// sessionUserID restituisce l'utente della sessione: gli handler generati ricevono il
+// *gin.Context della richiesta come context.Context.
+func sessionUserID(ctx context.Context) (int64, bool) {
+	c, ok := ctx.(*gin.Context)
+	if !ok {
+		return 0, false
+	}
+	userID, ok := sessions.Default(c).Get("userID").(int64)
+	return userID, ok
+}
+
 // FormsIndex serve la pagina index con i link ai form
 func FormsIndex(c *gin.Context) {
 	session := sessions.Default(c)
@@ -77,6 +102,8 @@ func ServeFormWithUserID(templateName string) gin.HandlerFunc {
 }
 
 // ServeCommonCSS serve il file CSS comune
-func ServeCommonCSS(c *gin.Context) {
-	c.File("./internal/api/http/templates/common.css")
+func ServeCommonCSS(path string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.File(path)
+	}
 }
@@ -0,0 +1,184 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) GetNotifications(ctx context.Context, request apigen.GetNotificationsRequestObject) (apigen.GetNotificationsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetNotifications400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	unreadOnly := request.Params.UnreadOnly != nil && *request.Params.UnreadOnly
+	notifications, err := ctrl.notificationService.GetNotifications(ctx, request.Params.UserId, unreadOnly)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetNotifications400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetNotifications500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.NotificationItem, len(notifications))
+	for i, notification := range notifications {
+		response[i] = ToNotificationItem(notification)
+	}
+
+	return apigen.GetNotifications200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) MarkNotificationRead(ctx context.Context, request apigen.MarkNotificationReadRequestObject) (apigen.MarkNotificationReadResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.MarkNotificationRead400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.notificationService.MarkRead(ctx, request.Params.UserId, request.NotificationId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.MarkNotificationRead400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrNotificationNotFound) {
+			return apigen.MarkNotificationRead404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.MarkNotificationRead500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.MarkNotificationRead204Response{}, nil
+}
+
+func (ctrl *Controller) GetNotificationSettings(ctx context.Context, request apigen.GetNotificationSettingsRequestObject) (apigen.GetNotificationSettingsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetNotificationSettings400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	settings, err := ctrl.notificationService.GetSettings(ctx, request.Params.UserId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetNotificationSettings400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetNotificationSettings500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetNotificationSettings200JSONResponse(ToNotificationSettings(settings)), nil
+}
+
+func (ctrl *Controller) UpdateNotificationSettings(ctx context.Context, request apigen.UpdateNotificationSettingsRequestObject) (apigen.UpdateNotificationSettingsResponseObject, error) {
+	if request.Body == nil {
+		return apigen.UpdateNotificationSettings400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.UpdateNotificationSettings400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	settingsDto := dto.NotificationSettings{
+		UserID:     body.UserId,
+		WebhookURL: body.WebhookUrl,
+	}
+	if body.LowBalanceThreshold != nil {
+		settingsDto.LowBalanceThreshold = *body.LowBalanceThreshold
+	}
+	if body.Preferences != nil {
+		for _, preference := range *body.Preferences {
+			settingsDto.Preferences = append(settingsDto.Preferences, dto.NotificationPreference{
+				Kind:    dto.NotificationKind(preference.Kind),
+				Email:   preference.Email,
+				Webhook: preference.Webhook,
+				InApp:   preference.InApp,
+			})
+		}
+	}
+
+	settings, err := ctrl.notificationService.SaveSettings(ctx, settingsDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.UpdateNotificationSettings400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.UpdateNotificationSettings400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.UpdateNotificationSettings500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.UpdateNotificationSettings200JSONResponse(ToNotificationSettings(settings)), nil
+}
@@ -0,0 +1,432 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) GetPayees(ctx context.Context, request apigen.GetPayeesRequestObject) (apigen.GetPayeesResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetPayees400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	user, err := ctrl.userService.GetUserByID(ctx, request.Params.UserId)
+	if err != nil {
+		return apigen.GetPayees400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "NOT_FOUND",
+				Message: "Utente non trovato",
+			},
+		}, nil
+	}
+
+	payees, err := ctrl.payeeService.GetPayees(ctx, user)
+	if err != nil {
+		return apigen.GetPayees500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	aliases, err := ctrl.payeeService.GetPayeeAliases(ctx, user)
+	if err != nil {
+		return apigen.GetPayees500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.PayeeItem, len(payees))
+	for i, payee := range payees {
+		response[i] = ToPayeeItem(payee, aliases[payee.ID])
+	}
+
+	return apigen.GetPayees200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) CreatePayee(ctx context.Context, request apigen.CreatePayeeRequestObject) (apigen.CreatePayeeResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreatePayee400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Name) == 0 {
+		return apigen.CreatePayee400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e name sono obbligatori",
+			},
+		}, nil
+	}
+
+	var aliases []string
+	if body.Aliases != nil {
+		aliases = *body.Aliases
+	}
+
+	payee, payeeAliases, err := ctrl.payeeService.CreatePayee(ctx, dto.CreatePayeeDto{
+		UserID:            body.UserId,
+		Name:              body.Name,
+		DefaultCategoryID: body.DefaultCategoryId,
+		Aliases:           aliases,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.CreatePayee409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) || errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.CreatePayee400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrCategoryArchived) {
+			return apigen.CreatePayee400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "CATEGORY_ARCHIVED",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreatePayee400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreatePayee500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreatePayee201JSONResponse(ToPayeeItem(payee, payeeAliases)), nil
+}
+
+func (ctrl *Controller) UpdatePayee(ctx context.Context, request apigen.UpdatePayeeRequestObject) (apigen.UpdatePayeeResponseObject, error) {
+	if request.Body == nil {
+		return apigen.UpdatePayee400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Name) == 0 {
+		return apigen.UpdatePayee400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e name sono obbligatori",
+			},
+		}, nil
+	}
+
+	payee, err := ctrl.payeeService.UpdatePayee(ctx, dto.UpdatePayeeDto{
+		UserID:            body.UserId,
+		PayeeID:           request.PayeeId,
+		Name:              body.Name,
+		DefaultCategoryID: body.DefaultCategoryId,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrPayeeNotFound) {
+			return apigen.UpdatePayee404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.UpdatePayee409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) || errors.Is(err, errs.ErrCategoryNotFound) {
+			return apigen.UpdatePayee400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrCategoryArchived) {
+			return apigen.UpdatePayee400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "CATEGORY_ARCHIVED",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.UpdatePayee400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.UpdatePayee500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	user, err := ctrl.userService.GetUserByID(ctx, body.UserId)
+	if err != nil {
+		return apigen.UpdatePayee500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+	aliases, err := ctrl.payeeService.GetPayeeAliases(ctx, user)
+	if err != nil {
+		return apigen.UpdatePayee500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.UpdatePayee200JSONResponse(ToPayeeItem(payee, aliases[payee.ID])), nil
+}
+
+func (ctrl *Controller) DeletePayee(ctx context.Context, request apigen.DeletePayeeRequestObject) (apigen.DeletePayeeResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeletePayee400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.payeeService.DeletePayee(ctx, request.Params.UserId, request.PayeeId)
+	if err != nil {
+		if errors.Is(err, errs.ErrPayeeNotFound) {
+			return apigen.DeletePayee404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeletePayee400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeletePayee500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeletePayee204Response{}, nil
+}
+
+func (ctrl *Controller) AddPayeeAlias(ctx context.Context, request apigen.AddPayeeAliasRequestObject) (apigen.AddPayeeAliasResponseObject, error) {
+	if request.Body == nil {
+		return apigen.AddPayeeAlias400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Pattern) == 0 {
+		return apigen.AddPayeeAlias400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e pattern sono obbligatori",
+			},
+		}, nil
+	}
+
+	alias, err := ctrl.payeeService.AddPayeeAlias(ctx, dto.AddPayeeAliasDto{
+		UserID:  body.UserId,
+		PayeeID: request.PayeeId,
+		Pattern: body.Pattern,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrPayeeNotFound) {
+			return apigen.AddPayeeAlias404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.AddPayeeAlias409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.AddPayeeAlias400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.AddPayeeAlias400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.AddPayeeAlias500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.AddPayeeAlias201JSONResponse(ToPayeeAliasItem(alias)), nil
+}
+
+func (ctrl *Controller) DeletePayeeAlias(ctx context.Context, request apigen.DeletePayeeAliasRequestObject) (apigen.DeletePayeeAliasResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeletePayeeAlias400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.payeeService.DeletePayeeAlias(ctx, request.Params.UserId, request.PayeeId, request.AliasId)
+	if err != nil {
+		if errors.Is(err, errs.ErrPayeeNotFound) || errors.Is(err, errs.ErrNotFound) {
+			return apigen.DeletePayeeAlias404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeletePayeeAlias400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeletePayeeAlias500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeletePayeeAlias204Response{}, nil
+}
+
+func (ctrl *Controller) GetPayeeReport(ctx context.Context, request apigen.GetPayeeReportRequestObject) (apigen.GetPayeeReportResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetPayeeReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	dateFrom, dateTo := reportPeriod(request.Params.From, request.Params.To)
+	if dateFrom.After(dateTo) {
+		return apigen.GetPayeeReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "from deve precedere to",
+			},
+		}, nil
+	}
+
+	totals, err := ctrl.payeeService.GetPayeeReport(ctx, dto.PayeeReportDto{
+		UserID:   request.Params.UserId,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetPayeeReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetPayeeReport500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.PayeeReportItem, len(totals))
+	for i, total := range totals {
+		response[i] = apigen.PayeeReportItem{
+			PayeeId: &total.ID,
+			Name:    &total.Name,
+			Entries: &total.Entries,
+			Total:   &total.Total,
+		}
+	}
+
+	return apigen.GetPayeeReport200JSONResponse(response), nil
+}
@@ -0,0 +1,367 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) StartReconciliation(ctx context.Context, request apigen.StartReconciliationRequestObject) (apigen.StartReconciliationResponseObject, error) {
+	if request.Body == nil {
+		return apigen.StartReconciliation400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.StatementDate.IsZero() {
+		return apigen.StartReconciliation400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e statementDate sono obbligatori",
+			},
+		}, nil
+	}
+
+	status, err := ctrl.reconciliationService.StartReconciliation(ctx, dto.StartReconciliationDto{
+		UserID:           body.UserId,
+		AccountID:        request.AccountId,
+		StatementDate:    body.StatementDate.Time,
+		StatementBalance: body.StatementBalance,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.StartReconciliation404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.StartReconciliation409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.StartReconciliation400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.StartReconciliation400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.StartReconciliation403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.StartReconciliation500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.StartReconciliation201JSONResponse(ToReconciliationDetail(status)), nil
+}
+
+func (ctrl *Controller) GetReconciliations(ctx context.Context, request apigen.GetReconciliationsRequestObject) (apigen.GetReconciliationsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetReconciliations400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	reconciliations, err := ctrl.reconciliationService.GetReconciliations(ctx, request.Params.UserId, request.AccountId)
+	if err != nil {
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.GetReconciliations404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetReconciliations400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetReconciliations500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.ReconciliationItem, len(reconciliations))
+	for i, reconciliation := range reconciliations {
+		response[i] = ToReconciliationItem(reconciliation)
+	}
+
+	return apigen.GetReconciliations200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) GetReconciliation(ctx context.Context, request apigen.GetReconciliationRequestObject) (apigen.GetReconciliationResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetReconciliation400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	status, err := ctrl.reconciliationService.GetReconciliation(ctx, request.Params.UserId, request.ReconciliationId)
+	if err != nil {
+		if errors.Is(err, errs.ErrReconciliationNotFound) {
+			return apigen.GetReconciliation404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetReconciliation400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetReconciliation500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetReconciliation200JSONResponse(ToReconciliationDetail(status)), nil
+}
+
+func (ctrl *Controller) ClearReconciliationEntries(ctx context.Context, request apigen.ClearReconciliationEntriesRequestObject) (apigen.ClearReconciliationEntriesResponseObject, error) {
+	if request.Body == nil {
+		return apigen.ClearReconciliationEntries400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.EntryIds) == 0 {
+		return apigen.ClearReconciliationEntries400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId ed entryIds sono obbligatori",
+			},
+		}, nil
+	}
+
+	status, err := ctrl.reconciliationService.ClearEntries(ctx, dto.ClearEntriesDto{
+		UserID:           body.UserId,
+		ReconciliationID: request.ReconciliationId,
+		EntryIDs:         body.EntryIds,
+		Cleared:          body.Cleared,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrReconciliationNotFound) {
+			return apigen.ClearReconciliationEntries404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.ClearReconciliationEntries409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ClearReconciliationEntries400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.ClearReconciliationEntries400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.ClearReconciliationEntries403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.ClearReconciliationEntries500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.ClearReconciliationEntries200JSONResponse(ToReconciliationDetail(status)), nil
+}
+
+func (ctrl *Controller) CompleteReconciliation(ctx context.Context, request apigen.CompleteReconciliationRequestObject) (apigen.CompleteReconciliationResponseObject, error) {
+	if request.Body == nil || request.Body.UserId == 0 {
+		return apigen.CompleteReconciliation400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	status, err := ctrl.reconciliationService.CompleteReconciliation(ctx, request.Body.UserId, request.ReconciliationId)
+	if err != nil {
+		if errors.Is(err, errs.ErrReconciliationNotFound) {
+			return apigen.CompleteReconciliation404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrNotReconciled) {
+			return apigen.CompleteReconciliation409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "NOT_RECONCILED",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.CompleteReconciliation409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CompleteReconciliation400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.CompleteReconciliation403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CompleteReconciliation500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CompleteReconciliation200JSONResponse(ToReconciliationDetail(status)), nil
+}
+
+func (ctrl *Controller) CancelReconciliation(ctx context.Context, request apigen.CancelReconciliationRequestObject) (apigen.CancelReconciliationResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.CancelReconciliation400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.reconciliationService.CancelReconciliation(ctx, request.Params.UserId, request.ReconciliationId)
+	if err != nil {
+		if errors.Is(err, errs.ErrReconciliationNotFound) {
+			return apigen.CancelReconciliation404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.CancelReconciliation409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CancelReconciliation400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.CancelReconciliation403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CancelReconciliation500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CancelReconciliation204Response{}, nil
+}
@@ -0,0 +1,299 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) MarkReimbursable(ctx context.Context, request apigen.MarkReimbursableRequestObject) (apigen.MarkReimbursableResponseObject, error) {
+	if request.Body == nil {
+		return apigen.MarkReimbursable400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Party) == 0 {
+		return apigen.MarkReimbursable400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e party sono obbligatori",
+			},
+		}, nil
+	}
+
+	entry, err := ctrl.reimbursementService.MarkReimbursable(ctx, dto.MarkReimbursableDto{
+		UserID:  body.UserId,
+		EntryID: request.EntryId,
+		Party:   body.Party,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.MarkReimbursable400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.MarkReimbursable400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.MarkReimbursable404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.MarkReimbursable403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.MarkReimbursable500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.MarkReimbursable200JSONResponse(ToReimbursableItem(entry)), nil
+}
+
+func (ctrl *Controller) UnmarkReimbursable(ctx context.Context, request apigen.UnmarkReimbursableRequestObject) (apigen.UnmarkReimbursableResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.UnmarkReimbursable400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.reimbursementService.UnmarkReimbursable(ctx, request.Params.UserId, request.EntryId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.UnmarkReimbursable400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.UnmarkReimbursable404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrReimbursementNotFound) {
+			return apigen.UnmarkReimbursable404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.UnmarkReimbursable403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.UnmarkReimbursable500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.UnmarkReimbursable204Response{}, nil
+}
+
+func (ctrl *Controller) AddReimbursement(ctx context.Context, request apigen.AddReimbursementRequestObject) (apigen.AddReimbursementResponseObject, error) {
+	if request.Body == nil {
+		return apigen.AddReimbursement400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.IncomeEntryId == 0 {
+		return apigen.AddReimbursement400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e incomeEntryId sono obbligatori",
+			},
+		}, nil
+	}
+
+	entry, err := ctrl.reimbursementService.AddReimbursement(ctx, dto.ReimbursementDto{
+		UserID:         body.UserId,
+		ExpenseEntryID: request.EntryId,
+		IncomeEntryID:  body.IncomeEntryId,
+		Amount:         body.Amount,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.AddReimbursement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.AddReimbursement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.AddReimbursement404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.AddReimbursement403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.AddReimbursement409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.AddReimbursement500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.AddReimbursement201JSONResponse(ToReimbursableItem(entry)), nil
+}
+
+func (ctrl *Controller) DeleteReimbursement(ctx context.Context, request apigen.DeleteReimbursementRequestObject) (apigen.DeleteReimbursementResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeleteReimbursement400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.reimbursementService.DeleteReimbursement(ctx, request.Params.UserId, request.ReimbursementId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeleteReimbursement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrReimbursementNotFound) {
+			return apigen.DeleteReimbursement404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.DeleteReimbursement404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.DeleteReimbursement403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeleteReimbursement500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeleteReimbursement204Response{}, nil
+}
+
+func (ctrl *Controller) GetReimbursementReport(ctx context.Context, request apigen.GetReimbursementReportRequestObject) (apigen.GetReimbursementReportResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetReimbursementReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	includeSettled := request.Params.IncludeSettled != nil && *request.Params.IncludeSettled
+	report, err := ctrl.reimbursementService.GetReport(ctx, request.Params.UserId, request.Params.Party, includeSettled)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetReimbursementReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetReimbursementReport500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetReimbursementReport200JSONResponse(ToReimbursementReport(report)), nil
+}
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"path/filepath"
+
 	apigen "koin/internal/api/generated"
 	"koin/internal/service"
 
@@ -10,9 +12,13 @@ import (
 )
 
 type RouterDeps struct {
-	AuthToken   string
-	Controller  apigen.ServerInterface // importante: dipendenza sul contratto generato
-	UserService *service.UserService
+	AuthToken      string
+	RequireAPIAuth bool                   // /api accetta solo la sessione o AuthToken, in produzione
+	SessionKey     string                 // firma i cookie di sessione
+	TemplatesPath  string                 // directory dei template HTML e del CSS dei form
+	Controller     apigen.ServerInterface // importante: dipendenza sul contratto generato
+	UserService    *service.UserService
+	ChangeService  *service.ChangeService
 }
 
 func NewRouter(deps RouterDeps) *gin.Engine {
@@ -21,10 +27,10 @@ func NewRouter(deps RouterDeps) *gin.Engine {
 	r.Use(RequestLogger())
 
 	// Carica i template HTML
-	r.LoadHTMLGlob("./internal/api/http/templates/*.html")
+	r.LoadHTMLGlob(filepath.Join(deps.TemplatesPath, "*.html"))
 
 	// Setup sessioni
-	store := cookie.NewStore([]byte("secret-key"))
+	store := cookie.NewStore([]byte(deps.SessionKey))
 	r.Use(sessions.Sessions("koin-session", store))
 
 	// Rotte pubbliche (senza autenticazione)
@@ -41,7 +47,7 @@ func NewRouter(deps RouterDeps) *gin.Engine {
 	r.GET("/health", Health)
 
 	// Serve static CSS file
-	r.GET("/forms/common.css", ServeCommonCSS)
+	r.GET("/forms/common.css", ServeCommonCSS(filepath.Join(deps.TemplatesPath, "common.css")))
 
 	// Form pages - protette da autenticazione
 	protected := r.Group("/forms")
@@ -51,10 +57,17 @@ func NewRouter(deps RouterDeps) *gin.Engine {
 		protected.GET("/transactions", ServeFormWithUserID("transaction_form.html"))
 		protected.GET("/accounts", ServeFormWithUserID("account_form.html"))
 		protected.GET("/categories", ServeFormWithUserID("category_form.html"))
+		protected.GET("/reconciliations", ServeFormWithUserID("reconcile_form.html"))
 	}
 
 	api := r.Group("/api")
-	//api.Use(BearerAuth(deps.AuthToken))
+	if deps.RequireAPIAuth {
+		// I form e la dashboard chiamano l'API con il cookie di sessione, gli script con il token
+		api.Use(SessionOrBearerAuth(deps.AuthToken))
+	}
+
+	// Stream delle modifiche per la dashboard, autenticato con la sessione
+	api.GET("/v1/events", StreamEvents(deps.ChangeService))
 
 	// per registrare tutti gli endpoint di quel controller
 	apigen.RegisterHandlers(api, deps.Controller)
@@ -0,0 +1,555 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) CreateSplitGroup(ctx context.Context, request apigen.CreateSplitGroupRequestObject) (apigen.CreateSplitGroupResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreateSplitGroup400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Name) == 0 || len(body.Currency) == 0 {
+		return apigen.CreateSplitGroup400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId, name e currency sono obbligatori",
+			},
+		}, nil
+	}
+
+	detail, err := ctrl.splitService.CreateGroup(ctx, dto.CreateSplitGroupDto{
+		UserID:     body.UserId,
+		Name:       body.Name,
+		Currency:   body.Currency,
+		MemberName: body.MemberName,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateSplitGroup400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreateSplitGroup400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreateSplitGroup500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreateSplitGroup201JSONResponse(ToSplitGroupDetail(detail)), nil
+}
+
+func (ctrl *Controller) GetSplitGroups(ctx context.Context, request apigen.GetSplitGroupsRequestObject) (apigen.GetSplitGroupsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetSplitGroups400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	groups, err := ctrl.splitService.GetGroups(ctx, request.Params.UserId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetSplitGroups400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetSplitGroups500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.SplitGroupItem, len(groups))
+	for i, group := range groups {
+		response[i] = ToSplitGroupItem(group)
+	}
+
+	return apigen.GetSplitGroups200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) GetSplitGroup(ctx context.Context, request apigen.GetSplitGroupRequestObject) (apigen.GetSplitGroupResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetSplitGroup400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	detail, err := ctrl.splitService.GetGroup(ctx, request.Params.UserId, request.GroupId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetSplitGroup400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSplitGroupNotFound) {
+			return apigen.GetSplitGroup404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.GetSplitGroup500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetSplitGroup200JSONResponse(ToSplitGroupDetail(detail)), nil
+}
+
+func (ctrl *Controller) AddSplitMember(ctx context.Context, request apigen.AddSplitMemberRequestObject) (apigen.AddSplitMemberResponseObject, error) {
+	if request.Body == nil {
+		return apigen.AddSplitMember400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || (body.MemberUserId == nil && body.Name == nil) {
+		return apigen.AddSplitMember400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e uno tra memberUserId e name sono obbligatori",
+			},
+		}, nil
+	}
+
+	member, err := ctrl.splitService.AddMember(ctx, dto.AddSplitMemberDto{
+		UserID:       body.UserId,
+		GroupID:      request.GroupId,
+		MemberUserID: body.MemberUserId,
+		Name:         body.Name,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.AddSplitMember400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.AddSplitMember400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSplitGroupNotFound) {
+			return apigen.AddSplitMember404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.AddSplitMember409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.AddSplitMember500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.AddSplitMember201JSONResponse(ToSplitMemberItem(member)), nil
+}
+
+func (ctrl *Controller) AddSplitExpense(ctx context.Context, request apigen.AddSplitExpenseRequestObject) (apigen.AddSplitExpenseResponseObject, error) {
+	if request.Body == nil {
+		return apigen.AddSplitExpense400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.TransactionId == 0 {
+		return apigen.AddSplitExpense400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e transactionId sono obbligatori",
+			},
+		}, nil
+	}
+
+	expenseDto := dto.SplitExpenseDto{
+		UserID:        body.UserId,
+		GroupID:       request.GroupId,
+		TransactionID: body.TransactionId,
+	}
+	if body.Method != nil {
+		expenseDto.Method = dto.SplitMethod(*body.Method)
+	}
+	if body.Shares != nil {
+		for _, share := range *body.Shares {
+			expenseDto.Parts = append(expenseDto.Parts, dto.SplitPart{
+				MemberID: share.MemberId,
+				Shares:   share.Shares,
+				Amount:   share.Amount,
+			})
+		}
+	}
+
+	detail, err := ctrl.splitService.AddExpense(ctx, expenseDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.AddSplitExpense400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.AddSplitExpense400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSplitGroupNotFound) {
+			return apigen.AddSplitExpense404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.AddSplitExpense404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.AddSplitExpense403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.AddSplitExpense409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.AddSplitExpense500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.AddSplitExpense201JSONResponse(ToSplitExpenseItem(detail)), nil
+}
+
+func (ctrl *Controller) GetSplitExpenses(ctx context.Context, request apigen.GetSplitExpensesRequestObject) (apigen.GetSplitExpensesResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetSplitExpenses400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	expenses, err := ctrl.splitService.GetExpenses(ctx, request.Params.UserId, request.GroupId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetSplitExpenses400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSplitGroupNotFound) {
+			return apigen.GetSplitExpenses404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.GetSplitExpenses500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.SplitExpenseItem, len(expenses))
+	for i, expense := range expenses {
+		response[i] = ToSplitExpenseItem(expense)
+	}
+
+	return apigen.GetSplitExpenses200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) DeleteSplitExpense(ctx context.Context, request apigen.DeleteSplitExpenseRequestObject) (apigen.DeleteSplitExpenseResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeleteSplitExpense400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.splitService.DeleteExpense(ctx, request.Params.UserId, request.GroupId, request.ExpenseId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeleteSplitExpense400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSplitGroupNotFound) {
+			return apigen.DeleteSplitExpense404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSplitExpenseNotFound) {
+			return apigen.DeleteSplitExpense404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.DeleteSplitExpense403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeleteSplitExpense500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeleteSplitExpense204Response{}, nil
+}
+
+func (ctrl *Controller) RecordSplitSettlement(ctx context.Context, request apigen.RecordSplitSettlementRequestObject) (apigen.RecordSplitSettlementResponseObject, error) {
+	if request.Body == nil {
+		return apigen.RecordSplitSettlement400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.FromMemberId == 0 || body.ToMemberId == 0 || body.Amount <= 0 {
+		return apigen.RecordSplitSettlement400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId, fromMemberId, toMemberId e amount sono obbligatori",
+			},
+		}, nil
+	}
+
+	settlementDto := dto.SettlementDto{
+		UserID:       body.UserId,
+		GroupID:      request.GroupId,
+		FromMemberID: body.FromMemberId,
+		ToMemberID:   body.ToMemberId,
+		Amount:       body.Amount,
+		AccountID:    body.AccountId,
+	}
+	if body.SettledAt != nil {
+		settlementDto.SettledAt = body.SettledAt.Time
+	}
+
+	settlement, err := ctrl.splitService.RecordSettlement(ctx, settlementDto)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.RecordSplitSettlement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.RecordSplitSettlement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSplitGroupNotFound) {
+			return apigen.RecordSplitSettlement404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.RecordSplitSettlement404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.RecordSplitSettlement403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.RecordSplitSettlement500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.RecordSplitSettlement201JSONResponse(ToSplitSettlementItem(settlement)), nil
+}
+
+func (ctrl *Controller) ConfirmSplitSettlement(ctx context.Context, request apigen.ConfirmSplitSettlementRequestObject) (apigen.ConfirmSplitSettlementResponseObject, error) {
+	if request.Body == nil || request.Body.UserId == 0 || request.Body.AccountId == 0 {
+		return apigen.ConfirmSplitSettlement400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e accountId sono obbligatori",
+			},
+		}, nil
+	}
+
+	settlement, err := ctrl.splitService.ConfirmSettlement(ctx, request.Body.UserId, request.GroupId, request.SettlementId, request.Body.AccountId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.ConfirmSplitSettlement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.ConfirmSplitSettlement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSplitGroupNotFound) || errors.Is(err, errs.ErrSettlementNotFound) || errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.ConfirmSplitSettlement404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.ConfirmSplitSettlement403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.ConfirmSplitSettlement409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.ConfirmSplitSettlement500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.ConfirmSplitSettlement200JSONResponse(ToSplitSettlementItem(settlement)), nil
+}
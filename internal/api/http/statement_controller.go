@@ -0,0 +1,63 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+)
+
+func (ctrl *Controller) GetStatement(ctx context.Context, request apigen.GetStatementRequestObject) (apigen.GetStatementResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetStatement400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	var month *int
+	if request.Params.Month != nil {
+		m := int(*request.Params.Month)
+		month = &m
+	}
+
+	pdf, filename, err := ctrl.statementService.GetStatement(ctx, request.Params.UserId, int(request.Params.Year), month, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetStatement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.GetStatement400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.GetStatement500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetStatement200ApplicationpdfResponse{
+		Body: bytes.NewReader(pdf),
+		Headers: apigen.GetStatement200ResponseHeaders{
+			ContentDisposition: mime.FormatMediaType("attachment", map[string]string{"filename": filename}),
+		},
+		ContentLength: int64(len(pdf)),
+	}, nil
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) GetSubscriptions(ctx context.Context, request apigen.GetSubscriptionsRequestObject) (apigen.GetSubscriptionsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetSubscriptions400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	subscriptions, err := ctrl.subscriptionService.GetSubscriptions(ctx, request.Params.UserId, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetSubscriptions400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetSubscriptions500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.SubscriptionItem, len(subscriptions))
+	for i, subscription := range subscriptions {
+		response[i] = ToSubscriptionItem(subscription)
+	}
+
+	return apigen.GetSubscriptions200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) TrackSubscription(ctx context.Context, request apigen.TrackSubscriptionRequestObject) (apigen.TrackSubscriptionResponseObject, error) {
+	if request.Body == nil {
+		return apigen.TrackSubscription400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Key) == 0 {
+		return apigen.TrackSubscription400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e key sono obbligatori",
+			},
+		}, nil
+	}
+
+	recurring, err := ctrl.subscriptionService.TrackSubscription(ctx, dto.TrackSubscriptionDto{
+		UserID: body.UserId,
+		Key:    body.Key,
+	}, time.Now())
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.TrackSubscription400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrSubscriptionNotFound) {
+			return apigen.TrackSubscription404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrAccountNotFound) {
+			return apigen.TrackSubscription404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.TrackSubscription403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.TrackSubscription409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.TrackSubscription500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.TrackSubscription201JSONResponse(ToRecurringTransactionItem(recurring)), nil
+}
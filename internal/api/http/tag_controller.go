@@ -0,0 +1,422 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) GetTags(ctx context.Context, request apigen.GetTagsRequestObject) (apigen.GetTagsResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetTags400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	user, err := ctrl.userService.GetUserByID(ctx, request.Params.UserId)
+	if err != nil {
+		return apigen.GetTags400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "NOT_FOUND",
+				Message: "Utente non trovato",
+			},
+		}, nil
+	}
+
+	tags, err := ctrl.tagService.GetTags(ctx, user)
+	if err != nil {
+		return apigen.GetTags500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.GetTags200JSONResponse(ToTagItems(tags)), nil
+}
+
+func (ctrl *Controller) CreateTag(ctx context.Context, request apigen.CreateTagRequestObject) (apigen.CreateTagResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreateTag400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Name) == 0 {
+		return apigen.CreateTag400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e name sono obbligatori",
+			},
+		}, nil
+	}
+
+	tag, err := ctrl.tagService.CreateTag(ctx, dto.CreateTagDto{
+		UserID: body.UserId,
+		Name:   body.Name,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.CreateTag409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateTag400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreateTag400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreateTag500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreateTag201JSONResponse(ToTagItem(tag)), nil
+}
+
+func (ctrl *Controller) RenameTag(ctx context.Context, request apigen.RenameTagRequestObject) (apigen.RenameTagResponseObject, error) {
+	if request.Body == nil {
+		return apigen.RenameTag400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || len(body.Name) == 0 {
+		return apigen.RenameTag400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e name sono obbligatori",
+			},
+		}, nil
+	}
+
+	tag, err := ctrl.tagService.RenameTag(ctx, dto.RenameTagDto{
+		UserID: body.UserId,
+		TagID:  request.TagId,
+		Name:   body.Name,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrTagNotFound) {
+			return apigen.RenameTag404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrConflict) {
+			return apigen.RenameTag409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "CONFLICT",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.RenameTag400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.RenameTag400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.RenameTag500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.RenameTag200JSONResponse(ToTagItem(tag)), nil
+}
+
+func (ctrl *Controller) DeleteTag(ctx context.Context, request apigen.DeleteTagRequestObject) (apigen.DeleteTagResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeleteTag400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.tagService.DeleteTag(ctx, request.Params.UserId, request.TagId)
+	if err != nil {
+		if errors.Is(err, errs.ErrTagNotFound) {
+			return apigen.DeleteTag404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeleteTag400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeleteTag500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeleteTag204Response{}, nil
+}
+
+func (ctrl *Controller) MergeTag(ctx context.Context, request apigen.MergeTagRequestObject) (apigen.MergeTagResponseObject, error) {
+	if request.Body == nil {
+		return apigen.MergeTag400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 || body.TargetTagId == 0 {
+		return apigen.MergeTag400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId e targetTagId sono obbligatori",
+			},
+		}, nil
+	}
+
+	target, moved, err := ctrl.tagService.MergeTags(ctx, dto.MergeTagsDto{
+		UserID:      body.UserId,
+		SourceTagID: request.TagId,
+		TargetTagID: body.TargetTagId,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrTagNotFound) {
+			return apigen.MergeTag404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.MergeTag400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.MergeTag400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.MergeTag500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.MergeTag200JSONResponse(apigen.MergeTagResponse{
+		TargetTagId:  &target.ID,
+		MovedEntries: &moved,
+	}), nil
+}
+
+func (ctrl *Controller) SetTransactionTags(ctx context.Context, request apigen.SetTransactionTagsRequestObject) (apigen.SetTransactionTagsResponseObject, error) {
+	if request.Body == nil {
+		return apigen.SetTransactionTags400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.SetTransactionTags400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	tags, err := ctrl.tagService.SetTransactionTags(ctx, dto.SetTransactionTagsDto{
+		UserID:        body.UserId,
+		TransactionID: request.TransactionId,
+		Tags:          body.Tags,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrTransactionNotFound) {
+			return apigen.SetTransactionTags404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrTransactionLocked) {
+			return apigen.SetTransactionTags409JSONResponse{
+				ConflictJSONResponse: apigen.ConflictJSONResponse{
+					Code:    "TRANSACTION_LOCKED",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.SetTransactionTags400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.SetTransactionTags400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrForbidden) {
+			return apigen.SetTransactionTags403JSONResponse{
+				ForbiddenJSONResponse: apigen.ForbiddenJSONResponse{
+					Code:    "FORBIDDEN",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.SetTransactionTags500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.SetTransactionTags200JSONResponse(ToTagItems(tags)), nil
+}
+
+func (ctrl *Controller) GetTagReport(ctx context.Context, request apigen.GetTagReportRequestObject) (apigen.GetTagReportResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetTagReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	dateFrom, dateTo := reportPeriod(request.Params.From, request.Params.To)
+	if dateFrom.After(dateTo) {
+		return apigen.GetTagReport400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "from deve precedere to",
+			},
+		}, nil
+	}
+
+	totals, err := ctrl.tagService.GetTagReport(ctx, dto.TagReportDto{
+		UserID:   request.Params.UserId,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetTagReport400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetTagReport500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.TagReportItem, len(totals))
+	for i, total := range totals {
+		response[i] = apigen.TagReportItem{
+			TagId:   &total.ID,
+			Name:    &total.Name,
+			Entries: &total.Entries,
+			Income:  &total.Income,
+			Expense: &total.Expense,
+			Total:   &total.Total,
+		}
+	}
+
+	return apigen.GetTagReport200JSONResponse(response), nil
+}
+
+// normalizeTagFilter porta il filtro per tag nella stessa forma con cui i tag sono salvati.
+func normalizeTagFilter(tag *string) *string {
+	if tag == nil {
+		return nil
+	}
+	normalized := strings.ToLower(strings.TrimSpace(*tag))
+	if normalized == "" {
+		return nil
+	}
+	return &normalized
+}
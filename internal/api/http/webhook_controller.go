@@ -0,0 +1,196 @@
+package http
+
+import (
+	"context"
+	"errors"
+
+	apigen "koin/internal/api/generated"
+	errs "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+func (ctrl *Controller) GetWebhooks(ctx context.Context, request apigen.GetWebhooksRequestObject) (apigen.GetWebhooksResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetWebhooks400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	endpoints, err := ctrl.webhookService.GetEndpoints(ctx, request.Params.UserId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetWebhooks400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		return apigen.GetWebhooks500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.WebhookItem, len(endpoints))
+	for i, endpoint := range endpoints {
+		response[i] = ToWebhookItem(endpoint)
+	}
+
+	return apigen.GetWebhooks200JSONResponse(response), nil
+}
+
+func (ctrl *Controller) CreateWebhook(ctx context.Context, request apigen.CreateWebhookRequestObject) (apigen.CreateWebhookResponseObject, error) {
+	if request.Body == nil {
+		return apigen.CreateWebhook400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_REQUEST",
+				Message: "body richiesto",
+			},
+		}, nil
+	}
+
+	body := request.Body
+	if body.UserId == 0 {
+		return apigen.CreateWebhook400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	endpoint, err := ctrl.webhookService.CreateEndpoint(ctx, dto.CreateWebhookDto{
+		UserID:      body.UserId,
+		URL:         body.Url,
+		Description: body.Description,
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.CreateWebhook400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.CreateWebhook400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.CreateWebhook500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.CreateWebhook201JSONResponse{
+		Id:          endpoint.ID,
+		Url:         endpoint.Url,
+		Description: nullStringPtr(endpoint.Description),
+		Secret:      endpoint.Secret,
+		CreatedAt:   endpoint.CreatedAt,
+	}, nil
+}
+
+func (ctrl *Controller) DeleteWebhook(ctx context.Context, request apigen.DeleteWebhookRequestObject) (apigen.DeleteWebhookResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.DeleteWebhook400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	err := ctrl.webhookService.DeleteEndpoint(ctx, request.Params.UserId, request.WebhookId)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.DeleteWebhook400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrWebhookNotFound) {
+			return apigen.DeleteWebhook404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.DeleteWebhook500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	return apigen.DeleteWebhook204Response{}, nil
+}
+
+func (ctrl *Controller) GetWebhookDeliveries(ctx context.Context, request apigen.GetWebhookDeliveriesRequestObject) (apigen.GetWebhookDeliveriesResponseObject, error) {
+	if request.Params.UserId == 0 {
+		return apigen.GetWebhookDeliveries400JSONResponse{
+			BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+				Code:    "INVALID_DATA",
+				Message: "userId è obbligatorio",
+			},
+		}, nil
+	}
+
+	deliveries, err := ctrl.webhookService.GetDeliveries(ctx, request.Params.UserId, request.WebhookId, request.Params.Limit)
+	if err != nil {
+		if errors.Is(err, errs.ErrUserNotFound) {
+			return apigen.GetWebhookDeliveries400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: "Utente non trovato",
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrInvalidData) {
+			return apigen.GetWebhookDeliveries400JSONResponse{
+				BadRequestJSONResponse: apigen.BadRequestJSONResponse{
+					Code:    "INVALID_DATA",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		if errors.Is(err, errs.ErrWebhookNotFound) {
+			return apigen.GetWebhookDeliveries404JSONResponse{
+				NotFoundJSONResponse: apigen.NotFoundJSONResponse{
+					Code:    "NOT_FOUND",
+					Message: err.Error(),
+				},
+			}, nil
+		}
+		return apigen.GetWebhookDeliveries500JSONResponse{
+			InternalErrorJSONResponse: apigen.InternalErrorJSONResponse{
+				Code:    "INTERNAL_ERROR",
+				Message: err.Error(),
+			},
+		}, nil
+	}
+
+	response := make([]apigen.WebhookDeliveryItem, len(deliveries))
+	for i, delivery := range deliveries {
+		response[i] = ToWebhookDeliveryItem(delivery)
+	}
+
+	return apigen.GetWebhookDeliveries200JSONResponse(response), nil
+}
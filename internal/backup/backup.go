@@ -0,0 +1,317 @@
+// Package backup definisce l'archivio di backup logico di un utente: un file NDJSON con
+// un'intestazione, un record per riga in ordine di dipendenza e una riga finale con il numero
+// di record per tipo e lo SHA-256 di tutte le righe precedenti.
+//
+// L'archivio contiene i dati che appartengono all'utente e i collegamenti tra di essi. Restano
+// fuori il contenuto degli allegati, che va salvato con il blob store, le household, i webhook,
+// le notifiche ricevute e le anomalie, ricalcolate dalla scansione periodica. Dei dati condivisi
+// con altri utenti entrano solo le parti ripristinabili da sole: carte, prestiti, operazioni e
+// rimborsi che coinvolgono conti o titoli di altri utenti non vengono salvati, e dei gruppi di
+// spesa creati dall'utente solo le spese registrate sui suoi conti.
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// Format identifica l'archivio nella prima riga.
+	Format = "koin-backup"
+	// Version è la versione del formato dell'archivio, indipendente da quella dello schema. La
+	// versione 2 aggiunge carte, prestiti, investimenti, ricorrenze, riconciliazioni, rimborsi,
+	// allegati e spese condivise; gli archivi della versione 1 restano leggibili.
+	Version = 2
+	// ContentType è il tipo MIME dell'archivio.
+	ContentType = "application/x-ndjson"
+)
+
+var (
+	// ErrInvalidArchive indica un file che non è un archivio leggibile: formato sconosciuto,
+	// righe non valide o archivio troncato.
+	ErrInvalidArchive = errors.New("invalid backup archive")
+	// ErrChecksum indica un archivio il cui contenuto non corrisponde al checksum finale.
+	ErrChecksum = errors.New("backup checksum mismatch")
+)
+
+// Tipi dei record, nell'ordine in cui compaiono nell'archivio.
+const (
+	RecordUser                 = "user"
+	RecordNotificationSettings = "notificationSettings"
+	RecordDigestSettings       = "digestSettings"
+	RecordAccount              = "account"
+	RecordCategory             = "category"
+	RecordPayee                = "payee"
+	RecordPayeeAlias           = "payeeAlias"
+	RecordTag                  = "tag"
+	RecordCreditCard           = "creditCard"
+	RecordLoan                 = "loan"
+	RecordSecurity             = "security"
+	RecordSecurityPrice        = "securityPrice"
+	RecordRecurringTransaction = "recurringTransaction"
+	RecordReconciliation       = "reconciliation"
+	RecordTransaction          = "transaction"
+	RecordEntry                = "entry"
+	RecordLoanInstallment      = "loanInstallment"
+	RecordInvestmentTrade      = "investmentTrade"
+	RecordReimbursable         = "reimbursable"
+	RecordReimbursement        = "reimbursement"
+	RecordAttachment           = "attachment"
+	RecordSplitGroup           = "splitGroup"
+	RecordSplitMember          = "splitMember"
+	RecordSplitExpense         = "splitExpense"
+	RecordSplitSettlement      = "splitSettlement"
+
+	recordEnd = "end"
+)
+
+// Header è la prima riga dell'archivio. SchemaVersion è la versione delle migrazioni del
+// database che l'ha prodotto: il ripristino la richiede uguale a quella di destinazione.
+type Header struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion uint      `json:"schemaVersion"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Record è una riga di dati: Data va decodificato nella struttura del suo Type.
+type Record struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Decode decodifica i dati del record in value.
+func (record Record) Decode(value any) error {
+	if err := json.Unmarshal(record.Data, value); err != nil {
+		return fmt.Errorf("%w: %s record: %v", ErrInvalidArchive, record.Type, err)
+	}
+	return nil
+}
+
+// trailer è l'ultima riga dell'archivio.
+type trailer struct {
+	Type     string           `json:"type"`
+	Counts   map[string]int64 `json:"counts"`
+	Checksum string           `json:"sha256"`
+}
+
+// Gli ID nei record sono quelli del database di origine e servono solo a collegare i record
+// tra loro: il ripristino ne assegna di nuovi.
+
+// User.PasswordHash manca negli archivi scaricati dall'API: l'utente ripristinato da questi
+// archivi deve reimpostare la password.
+type User struct {
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"passwordHash,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+type NotificationSettings struct {
+	WebhookURL          *string                  `json:"webhookUrl,omitempty"`
+	LowBalanceThreshold int64                    `json:"lowBalanceThreshold"`
+	Preferences         []NotificationPreference `json:"preferences"`
+}
+
+type NotificationPreference struct {
+	EventKind string `json:"eventKind"`
+	Email     bool   `json:"email"`
+	Webhook   bool   `json:"webhook"`
+	InApp     bool   `json:"inApp"`
+}
+
+type DigestSettings struct {
+	Weekly  bool `json:"weekly"`
+	Monthly bool `json:"monthly"`
+	Weekday int  `json:"weekday"`
+}
+
+type Account struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	Currency       string `json:"currency"`
+	InitialBalance int64  `json:"initialBalance"`
+	OverdraftLimit int64  `json:"overdraftLimit"`
+}
+
+type Category struct {
+	ID            int64      `json:"id"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	ParentID      *int64     `json:"parentId,omitempty"`
+	ArchivedAt    *time.Time `json:"archivedAt,omitempty"`
+	MonthlyBudget *int64     `json:"monthlyBudget,omitempty"`
+	DeductionCode *string    `json:"deductionCode,omitempty"`
+}
+
+type Payee struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	DefaultCategoryID *int64 `json:"defaultCategoryId,omitempty"`
+}
+
+type PayeeAlias struct {
+	PayeeID int64  `json:"payeeId"`
+	Pattern string `json:"pattern"`
+}
+
+type Tag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Transaction struct {
+	ID         int64     `json:"id"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type Entry struct {
+	ID               int64   `json:"id"`
+	TransactionID    int64   `json:"transactionId"`
+	AccountID        int64   `json:"accountId"`
+	CategoryID       *int64  `json:"categoryId,omitempty"`
+	PayeeID          *int64  `json:"payeeId,omitempty"`
+	Amount           int64   `json:"amount"`
+	Description      *string `json:"description,omitempty"`
+	Status           string  `json:"status"`
+	DeductionCode    *string `json:"deductionCode,omitempty"`
+	ReconciliationID *int64  `json:"reconciliationId,omitempty"`
+	TagIDs           []int64 `json:"tagIds,omitempty"`
+}
+
+type CreditCard struct {
+	AccountID        int64 `json:"accountId"`
+	PaymentAccountID int64 `json:"paymentAccountId"`
+	ClosingDay       int32 `json:"closingDay"`
+	DueDay           int32 `json:"dueDay"`
+	AutoPay          bool  `json:"autoPay"`
+}
+
+type Loan struct {
+	AccountID        int64     `json:"accountId"`
+	PaymentAccountID int64     `json:"paymentAccountId"`
+	Principal        int64     `json:"principal"`
+	AnnualRateBps    int32     `json:"annualRateBps"`
+	TermMonths       int32     `json:"termMonths"`
+	StartDate        time.Time `json:"startDate"`
+	Method           string    `json:"method"`
+}
+
+type LoanInstallment struct {
+	AccountID     int64     `json:"accountId"`
+	Number        int32     `json:"number"`
+	DueDate       time.Time `json:"dueDate"`
+	Principal     int64     `json:"principal"`
+	Interest      int64     `json:"interest"`
+	TransactionID *int64    `json:"transactionId,omitempty"`
+}
+
+type Security struct {
+	ID       int64   `json:"id"`
+	ISIN     *string `json:"isin,omitempty"`
+	Ticker   *string `json:"ticker,omitempty"`
+	Name     string  `json:"name"`
+	Currency string  `json:"currency"`
+}
+
+type SecurityPrice struct {
+	SecurityID int64     `json:"securityId"`
+	Date       time.Time `json:"date"`
+	Price      int64     `json:"price"`
+}
+
+type InvestmentTrade struct {
+	AccountID     int64     `json:"accountId"`
+	SecurityID    int64     `json:"securityId"`
+	TransactionID int64     `json:"transactionId"`
+	Kind          string    `json:"kind"`
+	TradeDate     time.Time `json:"tradeDate"`
+	Quantity      int64     `json:"quantity"`
+	Price         int64     `json:"price"`
+	Fees          int64     `json:"fees"`
+	Amount        int64     `json:"amount"`
+}
+
+type RecurringTransaction struct {
+	AccountID   int64      `json:"accountId"`
+	CategoryID  int64      `json:"categoryId"`
+	Amount      int64      `json:"amount"`
+	Description string     `json:"description"`
+	Frequency   string     `json:"frequency"`
+	RepeatEvery int32      `json:"repeatEvery"`
+	StartDate   time.Time  `json:"startDate"`
+	EndDate     *time.Time `json:"endDate,omitempty"`
+}
+
+type Reconciliation struct {
+	ID               int64      `json:"id"`
+	AccountID        int64      `json:"accountId"`
+	StatementDate    time.Time  `json:"statementDate"`
+	StatementBalance int64      `json:"statementBalance"`
+	Status           string     `json:"status"`
+	CompletedAt      *time.Time `json:"completedAt,omitempty"`
+}
+
+type Reimbursable struct {
+	EntryID int64  `json:"entryId"`
+	Party   string `json:"party"`
+}
+
+type Reimbursement struct {
+	ExpenseEntryID int64 `json:"expenseEntryId"`
+	IncomeEntryID  int64 `json:"incomeEntryId"`
+	Amount         int64 `json:"amount"`
+}
+
+// Attachment contiene solo i metadati: StorageKey è la chiave del contenuto nel blob store.
+type Attachment struct {
+	TransactionID int64     `json:"transactionId"`
+	FileName      string    `json:"fileName"`
+	ContentType   string    `json:"contentType"`
+	SizeBytes     int64     `json:"sizeBytes"`
+	StorageKey    string    `json:"storageKey"`
+	ThumbnailKey  *string   `json:"thumbnailKey,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type SplitGroup struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Currency string `json:"currency"`
+}
+
+// SplitMember: Self indica l'utente dell'archivio, Email un altro utente koin, ricollegato al
+// ripristino se esiste nell'istanza di destinazione.
+type SplitMember struct {
+	ID      int64   `json:"id"`
+	GroupID int64   `json:"groupId"`
+	Name    string  `json:"name"`
+	Self    bool    `json:"self,omitempty"`
+	Email   *string `json:"email,omitempty"`
+}
+
+type SplitExpense struct {
+	GroupID       int64        `json:"groupId"`
+	TransactionID int64        `json:"transactionId"`
+	PaidBy        int64        `json:"paidBy"`
+	Amount        int64        `json:"amount"`
+	Method        string       `json:"method"`
+	Shares        []SplitShare `json:"shares"`
+}
+
+type SplitShare struct {
+	MemberID int64  `json:"memberId"`
+	Shares   *int32 `json:"shares,omitempty"`
+	Amount   int64  `json:"amount"`
+}
+
+type SplitSettlement struct {
+	GroupID           int64     `json:"groupId"`
+	FromMemberID      int64     `json:"fromMemberId"`
+	ToMemberID        int64     `json:"toMemberId"`
+	Amount            int64     `json:"amount"`
+	SettledAt         time.Time `json:"settledAt"`
+	FromTransactionID *int64    `json:"fromTransactionId,omitempty"`
+	ToTransactionID   *int64    `json:"toTransactionId,omitempty"`
+}
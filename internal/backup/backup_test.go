@@ -0,0 +1,241 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testHeader = Header{SchemaVersion: 22, CreatedAt: time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC)}
+
+// writeArchive scrive un archivio con l'utente e count account.
+func writeArchive(t *testing.T, count int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer, err := NewWriter(&buf, testHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Write(RecordUser, User{Email: "mario@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range count {
+		if err := writer.Write(RecordAccount, Account{ID: int64(i + 1), Name: fmt.Sprintf("Conto %d", i+1), Currency: "EUR"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// readArchive legge tutto l'archivio e restituisce gli account, o il primo errore.
+func readArchive(r io.Reader) ([]Account, error) {
+	reader, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []Account
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return accounts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if record.Type == RecordAccount {
+			var account Account
+			if err := record.Decode(&account); err != nil {
+				return nil, err
+			}
+			accounts = append(accounts, account)
+		}
+	}
+}
+
+func TestSealRoundTrip(t *testing.T) {
+	// Abbastanza account da superare più blocchi cifrati
+	archive := writeArchive(t, 3000)
+	if len(archive) < 2*sealChunkSize {
+		t.Fatalf("archive of %d bytes is too small to span several blocks", len(archive))
+	}
+
+	for _, options := range []SealOptions{
+		{},
+		{Gzip: true},
+		{Passphrase: "correct horse"},
+		{Gzip: true, Passphrase: "correct horse"},
+	} {
+		t.Run("seal"+options.Extension(), func(t *testing.T) {
+			var sealed bytes.Buffer
+			w, err := Seal(&sealed, options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(archive); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if options.Passphrase != "" && bytes.Contains(sealed.Bytes(), []byte("mario@example.com")) {
+				t.Fatal("encrypted file contains plain text")
+			}
+
+			plain, err := Open(&sealed, options.Passphrase)
+			if err != nil {
+				t.Fatal(err)
+			}
+			accounts, err := readArchive(plain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(accounts) != 3000 || accounts[2999].Name != "Conto 3000" {
+				t.Fatalf("read %d accounts back", len(accounts))
+			}
+		})
+	}
+}
+
+func TestOpenRejectsWrongPassphraseAndTruncation(t *testing.T) {
+	var sealed bytes.Buffer
+	w, err := Seal(&sealed, SealOptions{Passphrase: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(writeArchive(t, 3000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	file := sealed.Bytes()
+
+	tests := []struct {
+		name       string
+		file       []byte
+		passphrase string
+	}{
+		{"wrong passphrase", file, "battery staple"},
+		{"no passphrase", file, ""},
+		{"truncated at a block boundary", file[:len(sealMagic)+sealSaltSize+sealPrefixSize+4+sealChunkSize+16], "correct horse"},
+		{"truncated inside a block", file[:len(file)-10], "correct horse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain, err := Open(bytes.NewReader(tt.file), tt.passphrase)
+			if err == nil {
+				_, err = io.ReadAll(plain)
+			}
+			if !errors.Is(err, ErrDecrypt) {
+				t.Fatalf("got %v, want ErrDecrypt", err)
+			}
+		})
+	}
+}
+
+func TestReaderRejectsDamagedArchives(t *testing.T) {
+	archive := string(writeArchive(t, 3))
+	lines := strings.SplitAfter(archive, "\n")
+
+	tests := []struct {
+		name    string
+		archive string
+		want    error
+	}{
+		{"tampered record", strings.Replace(archive, "Conto 2", "Conto 9", 1), ErrChecksum},
+		{"missing trailer", strings.Join(lines[:len(lines)-2], ""), ErrInvalidArchive},
+		{"missing record", strings.Join(append(append([]string{}, lines[:2]...), lines[3:]...), ""), ErrChecksum},
+		{"data after the trailer", archive + archive, ErrInvalidArchive},
+		{"unknown format", strings.Replace(archive, Format, "other", 1), ErrInvalidArchive},
+		{"newer version", strings.Replace(archive, fmt.Sprintf(`"version":%d`, Version), fmt.Sprintf(`"version":%d`, Version+1), 1), ErrInvalidArchive},
+		{"not json", "hello\n", ErrInvalidArchive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readArchive(strings.NewReader(tt.archive)); !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// closeCounter conta le chiusure della sorgente dei dati.
+type closeCounter struct {
+	closed int
+}
+
+func (counter *closeCounter) Close() error {
+	counter.closed++
+	return nil
+}
+
+func TestStream(t *testing.T) {
+	// Due passi: il primo scrive tre lotti di account, il secondo un lotto di tag
+	batches := 0
+	accounts := func(ctx context.Context, writer *Writer) (bool, error) {
+		batches++
+		for i := range 10 {
+			if err := writer.Write(RecordAccount, Account{ID: int64(batches*10 + i), Name: "Conto", Currency: "EUR"}); err != nil {
+				return false, err
+			}
+		}
+		return batches < 3, nil
+	}
+	tags := func(ctx context.Context, writer *Writer) (bool, error) {
+		return false, writer.Write(RecordTag, Tag{ID: 1, Name: "viaggi"})
+	}
+
+	source := &closeCounter{}
+	stream, err := NewStream(context.Background(), testHeader, source, accounts, tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := readArchive(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 30 {
+		t.Errorf("read %d accounts, want 30", len(read))
+	}
+	if source.closed != 1 {
+		t.Errorf("source closed %d times after the last step, want 1", source.closed)
+	}
+	if err := stream.Close(); err != nil || source.closed != 1 {
+		t.Errorf("Close after the end: %v, source closed %d times", err, source.closed)
+	}
+}
+
+func TestStreamStopsAtTheFirstError(t *testing.T) {
+	failure := errors.New("connection lost")
+	steps := []StepFunc{
+		func(ctx context.Context, writer *Writer) (bool, error) {
+			return false, writer.Write(RecordAccount, Account{ID: 1, Name: "Conto", Currency: "EUR"})
+		},
+		func(ctx context.Context, writer *Writer) (bool, error) {
+			return false, failure
+		},
+	}
+
+	source := &closeCounter{}
+	stream, err := NewStream(context.Background(), testHeader, source, steps...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(stream); !errors.Is(err, failure) {
+		t.Fatalf("got %v, want the step error", err)
+	}
+	if _, err := stream.Read(make([]byte, 16)); !errors.Is(err, failure) {
+		t.Errorf("read after the error: got %v, want the step error again", err)
+	}
+	if source.closed != 1 {
+		t.Errorf("source closed %d times, want 1", source.closed)
+	}
+}
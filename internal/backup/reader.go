@@ -0,0 +1,114 @@
+package backup
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// Reader legge un archivio record per record, verificando alla fine conteggi e checksum: chi
+// scrive i record letti deve quindi confermarli solo dopo che Next ha restituito io.EOF.
+type Reader struct {
+	r      *bufio.Reader
+	hash   hash.Hash
+	counts map[string]int64
+	header Header
+	done   bool
+}
+
+// NewReader legge l'intestazione e rifiuta formati e versioni sconosciuti: le versioni
+// precedenti hanno solo meno tipi di record.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReader(r), hash: sha256.New(), counts: make(map[string]int64)}
+	line, err := reader.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(line, &reader.header); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidArchive, err)
+	}
+	if reader.header.Format != Format {
+		return nil, fmt.Errorf("%w: not a %s file", ErrInvalidArchive, Format)
+	}
+	if reader.header.Version < 1 || reader.header.Version > Version {
+		return nil, fmt.Errorf("%w: unsupported archive version %d", ErrInvalidArchive, reader.header.Version)
+	}
+	reader.hash.Write(line)
+	return reader, nil
+}
+
+func (reader *Reader) Header() Header {
+	return reader.header
+}
+
+// Next restituisce il record successivo, o io.EOF dopo una riga finale valida.
+func (reader *Reader) Next() (Record, error) {
+	if reader.done {
+		return Record{}, io.EOF
+	}
+	line, err := reader.readLine()
+	if err != nil {
+		return Record{}, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(line, &record); err != nil || record.Type == "" {
+		return Record{}, fmt.Errorf("%w: line after %d records", ErrInvalidArchive, reader.total())
+	}
+	if record.Type == recordEnd {
+		reader.done = true
+		return Record{}, reader.verify(line)
+	}
+
+	reader.hash.Write(line)
+	reader.counts[record.Type]++
+	return record, nil
+}
+
+func (reader *Reader) verify(line []byte) error {
+	var end trailer
+	if err := json.Unmarshal(line, &end); err != nil {
+		return fmt.Errorf("%w: trailer: %v", ErrInvalidArchive, err)
+	}
+	if end.Checksum != hex.EncodeToString(reader.hash.Sum(nil)) {
+		return ErrChecksum
+	}
+	for recordType, count := range end.Counts {
+		if reader.counts[recordType] != count {
+			return fmt.Errorf("%w: %d %s records, expected %d", ErrInvalidArchive, reader.counts[recordType], recordType, count)
+		}
+	}
+	if len(end.Counts) != len(reader.counts) {
+		return fmt.Errorf("%w: unexpected record types", ErrInvalidArchive)
+	}
+	if _, err := reader.r.ReadByte(); err != io.EOF {
+		return fmt.Errorf("%w: data after the trailer", ErrInvalidArchive)
+	}
+	return io.EOF
+}
+
+// readLine restituisce una riga completa, con il suo a capo: un archivio che finisce prima
+// della riga finale è troncato.
+func (reader *Reader) readLine() ([]byte, error) {
+	line, err := reader.r.ReadBytes('\n')
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: truncated after %d records", ErrInvalidArchive, reader.total())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+func (reader *Reader) total() int64 {
+	var total int64
+	for _, count := range reader.counts {
+		total += count
+	}
+	return total
+}
@@ -0,0 +1,269 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// I file sigillati hanno l'intestazione sealMagic, il sale della chiave e il prefisso dei
+// nonce, poi blocchi AES-256-GCM di al più sealChunkSize byte in chiaro, ciascuno preceduto
+// dalla sua lunghezza. L'ultimo blocco è autenticato come tale, quindi un file troncato non si
+// apre.
+const (
+	sealMagic      = "KOINSEAL1"
+	sealSaltSize   = 16
+	sealPrefixSize = 4
+	sealChunkSize  = 64 * 1024
+	sealIterations = 600_000
+)
+
+// ErrDecrypt indica una passphrase errata o un file cifrato danneggiato.
+var ErrDecrypt = errors.New("wrong passphrase or corrupted backup file")
+
+// SealOptions: con Gzip il contenuto viene compresso, con Passphrase non vuota anche cifrato.
+type SealOptions struct {
+	Gzip       bool
+	Passphrase string
+}
+
+// Extension è il suffisso da aggiungere al nome del file sigillato con options.
+func (options SealOptions) Extension() string {
+	extension := ""
+	if options.Gzip {
+		extension += ".gz"
+	}
+	if options.Passphrase != "" {
+		extension += ".enc"
+	}
+	return extension
+}
+
+// Seal restituisce un writer che comprime e cifra su w secondo options: Close va sempre
+// chiamato per scrivere gli ultimi blocchi, ma non chiude w.
+func Seal(w io.Writer, options SealOptions) (io.WriteCloser, error) {
+	var sealed io.WriteCloser = nopCloser{w}
+	if options.Passphrase != "" {
+		encrypter, err := newEncrypter(w, options.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		sealed = encrypter
+	}
+	if options.Gzip {
+		return &gzipSealer{Writer: gzip.NewWriter(sealed), next: sealed}, nil
+	}
+	return sealed, nil
+}
+
+// Open riconosce cifratura e compressione dalle intestazioni e restituisce il contenuto in
+// chiaro: la passphrase serve solo per i file cifrati.
+func Open(r io.Reader, passphrase string) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(len(sealMagic))
+	if err == nil && string(header) == sealMagic {
+		if passphrase == "" {
+			return nil, fmt.Errorf("%w: the file is encrypted and no passphrase was given", ErrDecrypt)
+		}
+		decrypter, err := newDecrypter(buffered, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		buffered = bufio.NewReader(decrypter)
+	}
+
+	header, err = buffered.Peek(2)
+	if err == nil && header[0] == 0x1f && header[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+// gzipSealer chiude la compressione e poi il livello sottostante.
+type gzipSealer struct {
+	*gzip.Writer
+	next io.Closer
+}
+
+func (sealer *gzipSealer) Close() error {
+	if err := sealer.Writer.Close(); err != nil {
+		return err
+	}
+	return sealer.next.Close()
+}
+
+func sealKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, sealIterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealNonce è il prefisso casuale del file seguito dal numero del blocco.
+func sealNonce(prefix []byte, counter uint64) []byte {
+	nonce := make([]byte, sealPrefixSize+8)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint64(nonce[sealPrefixSize:], counter)
+	return nonce
+}
+
+// sealAdditionalData distingue l'ultimo blocco dagli altri.
+func sealAdditionalData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+type encrypter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint64
+	buf     []byte
+}
+
+func newEncrypter(w io.Writer, passphrase string) (*encrypter, error) {
+	header := make([]byte, len(sealMagic)+sealSaltSize+sealPrefixSize)
+	copy(header, sealMagic)
+	if _, err := rand.Read(header[len(sealMagic):]); err != nil {
+		return nil, err
+	}
+	salt := header[len(sealMagic) : len(sealMagic)+sealSaltSize]
+	aead, err := sealKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encrypter{
+		w:      w,
+		aead:   aead,
+		prefix: header[len(sealMagic)+sealSaltSize:],
+		buf:    make([]byte, 0, sealChunkSize),
+	}, nil
+}
+
+// Write scrive un blocco pieno solo quando arrivano altri dati, così Close sa sempre quale
+// blocco è l'ultimo.
+func (encrypter *encrypter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if len(encrypter.buf) == sealChunkSize {
+			if err := encrypter.flush(false); err != nil {
+				return written, err
+			}
+		}
+		n := min(sealChunkSize-len(encrypter.buf), len(p))
+		encrypter.buf = append(encrypter.buf, p[:n]...)
+		p = p[n:]
+		written += n
+	}
+	return written, nil
+}
+
+func (encrypter *encrypter) Close() error {
+	return encrypter.flush(true)
+}
+
+func (encrypter *encrypter) flush(final bool) error {
+	sealed := encrypter.aead.Seal(nil, sealNonce(encrypter.prefix, encrypter.counter), encrypter.buf, sealAdditionalData(final))
+	encrypter.counter++
+	encrypter.buf = encrypter.buf[:0]
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(sealed)))
+	if _, err := encrypter.w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := encrypter.w.Write(sealed)
+	return err
+}
+
+type decrypter struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint64
+	buf     bytes.Buffer
+	done    bool
+}
+
+func newDecrypter(r io.Reader, passphrase string) (*decrypter, error) {
+	header := make([]byte, len(sealMagic)+sealSaltSize+sealPrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecrypt, err)
+	}
+	aead, err := sealKey(passphrase, header[len(sealMagic):len(sealMagic)+sealSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	return &decrypter{r: r, aead: aead, prefix: header[len(sealMagic)+sealSaltSize:]}, nil
+}
+
+func (decrypter *decrypter) Read(p []byte) (int, error) {
+	for decrypter.buf.Len() == 0 && !decrypter.done {
+		if err := decrypter.next(); err != nil {
+			return 0, err
+		}
+	}
+	if decrypter.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return decrypter.buf.Read(p)
+}
+
+func (decrypter *decrypter) next() error {
+	var length [4]byte
+	if _, err := io.ReadFull(decrypter.r, length[:]); err != nil {
+		return fmt.Errorf("%w: truncated file", ErrDecrypt)
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > sealChunkSize+uint32(decrypter.aead.Overhead()) {
+		return fmt.Errorf("%w: invalid block size", ErrDecrypt)
+	}
+	sealed := make([]byte, size)
+	if _, err := io.ReadFull(decrypter.r, sealed); err != nil {
+		return fmt.Errorf("%w: truncated file", ErrDecrypt)
+	}
+
+	nonce := sealNonce(decrypter.prefix, decrypter.counter)
+	decrypter.counter++
+	plain, err := decrypter.aead.Open(nil, nonce, sealed, sealAdditionalData(false))
+	if err != nil {
+		plain, err = decrypter.aead.Open(nil, nonce, sealed, sealAdditionalData(true))
+		if err != nil {
+			return ErrDecrypt
+		}
+		decrypter.done = true
+		var extra [1]byte
+		if n, _ := decrypter.r.Read(extra[:]); n > 0 {
+			return fmt.Errorf("%w: data after the last block", ErrDecrypt)
+		}
+	}
+	decrypter.buf.Write(plain)
+	return nil
+}
@@ -0,0 +1,139 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+)
+
+// Writer scrive un archivio: l'intestazione alla creazione, poi un record per riga e la riga
+// finale con Close.
+type Writer struct {
+	w      io.Writer
+	hash   hash.Hash
+	counts map[string]int64
+}
+
+// NewWriter scrive l'intestazione con formato e versione correnti.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	writer := &Writer{w: w, hash: sha256.New(), counts: make(map[string]int64)}
+	header.Format = Format
+	header.Version = Version
+	if err := writer.writeLine(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Write aggiunge un record del tipo indicato.
+func (writer *Writer) Write(recordType string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := writer.writeLine(Record{Type: recordType, Data: encoded}); err != nil {
+		return err
+	}
+	writer.counts[recordType]++
+	return nil
+}
+
+// Close scrive la riga finale, esclusa dal checksum.
+func (writer *Writer) Close() error {
+	line, err := json.Marshal(trailer{
+		Type:     recordEnd,
+		Counts:   writer.counts,
+		Checksum: hex.EncodeToString(writer.hash.Sum(nil)),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = writer.w.Write(append(line, '\n'))
+	return err
+}
+
+func (writer *Writer) writeLine(value any) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	writer.hash.Write(line)
+	_, err = writer.w.Write(line)
+	return err
+}
+
+// StepFunc scrive il lotto di record successivo e restituisce false quando il passo non ha
+// altro da scrivere.
+type StepFunc func(ctx context.Context, writer *Writer) (bool, error)
+
+// Stream produce l'archivio mentre viene letto, eseguendo i passi in ordine: come per le
+// esportazioni, in memoria resta al più un lotto. source, se presente, è la sorgente dei dati
+// letta dai passi: viene chiusa dopo l'ultimo passo, al primo errore o con Close.
+type Stream struct {
+	ctx    context.Context
+	steps  []StepFunc
+	source io.Closer
+	buf    bytes.Buffer
+	writer *Writer
+	done   bool
+	err    error
+}
+
+func NewStream(ctx context.Context, header Header, source io.Closer, steps ...StepFunc) (*Stream, error) {
+	stream := &Stream{ctx: ctx, steps: steps, source: source}
+	writer, err := NewWriter(&stream.buf, header)
+	if err != nil {
+		_ = stream.Close()
+		return nil, err
+	}
+	stream.writer = writer
+	return stream, nil
+}
+
+func (stream *Stream) Read(p []byte) (int, error) {
+	if stream.err != nil {
+		return 0, stream.err
+	}
+	for stream.buf.Len() == 0 && !stream.done {
+		if len(stream.steps) == 0 {
+			stream.done = true
+			err := stream.writer.Close()
+			if closeErr := stream.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return 0, err
+			}
+			break
+		}
+		more, err := stream.steps[0](stream.ctx, stream.writer)
+		if err != nil {
+			// Un archivio parziale non deve sembrare completo a chi continua a leggere
+			stream.err = err
+			_ = stream.Close()
+			return 0, err
+		}
+		if !more {
+			stream.steps = stream.steps[1:]
+		}
+	}
+	if stream.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return stream.buf.Read(p)
+}
+
+// Close chiude la sorgente dei dati, anche se l'archivio non è stato letto fino in fondo.
+func (stream *Stream) Close() error {
+	if stream.source == nil {
+		return nil
+	}
+	source := stream.source
+	stream.source = nil
+	return source.Close()
+}
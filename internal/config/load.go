@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// setting collega una voce della configurazione alla sua variabile d'ambiente e, per quelle
+// che non sono segreti, all'opzione da riga di comando.
+type setting struct {
+	env    string
+	flag   string
+	usage  string
+	target any // *string, *int, *bool, *time.Duration o *Mode
+}
+
+func (cfg *Config) settings() []setting {
+	return []setting{
+		{"KOIN_MODE", "mode", "modalità: development o production", &cfg.Mode},
+		{"LISTEN_ADDR", "addr", "indirizzo di ascolto del server", &cfg.Server.Addr},
+		{"API_TOKEN", "", "", &cfg.Server.AuthToken},
+		{"SESSION_KEY", "", "", &cfg.Server.SessionKey},
+		{"TEMPLATES_PATH", "templates-path", "directory dei template HTML", &cfg.Server.TemplatesPath},
+		{"DATABASE_URL", "", "", &cfg.Database.URL},
+		{"MIGRATIONS_PATH", "migrations-path", "directory delle migrazioni", &cfg.Database.MigrationsPath},
+		{"ATTACHMENTS_STORAGE", "attachments-storage", "storage degli allegati: local o s3", &cfg.Attachments.Storage},
+		{"ATTACHMENTS_PATH", "attachments-path", "directory degli allegati con lo storage local", &cfg.Attachments.Path},
+		{"S3_ENDPOINT", "", "", &cfg.Attachments.S3.Endpoint},
+		{"S3_REGION", "", "", &cfg.Attachments.S3.Region},
+		{"S3_BUCKET", "", "", &cfg.Attachments.S3.Bucket},
+		{"S3_ACCESS_KEY", "", "", &cfg.Attachments.S3.AccessKey},
+		{"S3_SECRET_KEY", "", "", &cfg.Attachments.S3.SecretKey},
+		{"S3_PATH_STYLE", "", "", &cfg.Attachments.S3.PathStyle},
+		{"SMTP_HOST", "", "", &cfg.SMTP.Host},
+		{"SMTP_PORT", "", "", &cfg.SMTP.Port},
+		{"SMTP_USERNAME", "", "", &cfg.SMTP.Username},
+		{"SMTP_PASSWORD", "", "", &cfg.SMTP.Password},
+		{"SMTP_FROM", "", "", &cfg.SMTP.From},
+		{"BACKUP_BASE_PATH", "backup-path", "directory dei backup automatici", &cfg.Backup.Path},
+		{"BACKUP_MODE", "", "", &cfg.Backup.Mode},
+		{"BACKUP_INTERVAL", "", "", &cfg.Backup.Interval},
+		{"BACKUP_GZIP", "", "", &cfg.Backup.Gzip},
+		{"BACKUP_KEEP_DAILY", "", "", &cfg.Backup.KeepDaily},
+		{"BACKUP_KEEP_WEEKLY", "", "", &cfg.Backup.KeepWeekly},
+		{"BACKUP_KEEP_MONTHLY", "", "", &cfg.Backup.KeepMonthly},
+		{"BACKUP_PASSPHRASE", "", "", &cfg.Backup.Passphrase},
+		{"BACKUP_PG_DUMP", "", "", &cfg.Backup.PgDump},
+	}
+}
+
+// Load registra su flags le opzioni globali, le analizza da args e carica la configurazione:
+// il file YAML è quello di -config o di KOIN_CONFIG, se indicato. Restituisce gli argomenti
+// che seguono le opzioni; la configurazione va poi verificata con Validate.
+func Load(flags *flag.FlagSet, args []string) (Config, []string, error) {
+	cfg := Default()
+	settings := cfg.settings()
+
+	configPath := flags.String("config", os.Getenv("KOIN_CONFIG"), "file di configurazione YAML")
+	// Le opzioni vengono applicate per ultime, dopo file e variabili d'ambiente
+	var overrides []func() error
+	for _, setting := range settings {
+		if setting.flag == "" {
+			continue
+		}
+		flags.Func(setting.flag, setting.usage, func(value string) error {
+			overrides = append(overrides, func() error {
+				if err := set(setting.target, value); err != nil {
+					return fmt.Errorf("invalid -%s %q: %w", setting.flag, value, err)
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if *configPath != "" {
+		if err := loadFile(&cfg, *configPath); err != nil {
+			return Config{}, nil, err
+		}
+	}
+	for _, setting := range settings {
+		value := os.Getenv(setting.env)
+		if value == "" {
+			continue
+		}
+		if err := set(setting.target, value); err != nil {
+			return Config{}, nil, fmt.Errorf("invalid %s %q: %w", setting.env, value, err)
+		}
+	}
+	for _, override := range overrides {
+		if err := override(); err != nil {
+			return Config{}, nil, err
+		}
+	}
+	return cfg, flags.Args(), nil
+}
+
+// loadFile sovrascrive cfg con le voci presenti nel file, rifiutando quelle sconosciute.
+func loadFile(cfg *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func set(target any, value string) error {
+	switch target := target.(type) {
+	case *string:
+		*target = value
+	case *Mode:
+		*target = Mode(value)
+	case *int:
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+	case *bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+	case *time.Duration:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*target = parsed
+	default:
+		return fmt.Errorf("unsupported setting type %T", target)
+	}
+	return nil
+}
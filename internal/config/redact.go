@@ -0,0 +1,53 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "REDACTED"
+
+// dsnPassword trova la password nei DSN in formato chiave=valore.
+var dsnPassword = regexp.MustCompile(`(password=)('[^']*'|\S+)`)
+
+// Redacted restituisce una copia della configurazione con i segreti nascosti: quelli non
+// impostati restano vuoti, così si vede comunque quali mancano.
+func (cfg Config) Redacted() Config {
+	for _, secret := range []*string{
+		&cfg.Server.AuthToken,
+		&cfg.Server.SessionKey,
+		&cfg.Attachments.S3.SecretKey,
+		&cfg.SMTP.Password,
+		&cfg.Backup.Passphrase,
+	} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	cfg.Database.URL = redactURL(cfg.Database.URL)
+	return cfg
+}
+
+// WriteRedacted scrive su w la configurazione in YAML, senza segreti.
+func (cfg Config) WriteRedacted(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func redactURL(dsn string) string {
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.User == nil {
+		return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+	}
+	if _, hasPassword := parsed.User.Password(); hasPassword {
+		parsed.User = url.UserPassword(parsed.User.Username(), redacted)
+	}
+	return parsed.String()
+}
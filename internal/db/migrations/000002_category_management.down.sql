@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS transaction_entries_category_id_idx;
+DROP INDEX IF EXISTS category_parent_id_idx;
+
+ALTER TABLE CATEGORY
+    DROP COLUMN ARCHIVED_AT,
+    DROP COLUMN PARENT_ID;
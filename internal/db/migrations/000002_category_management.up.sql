@@ -0,0 +1,7 @@
+-- 7. GERARCHIA E ARCHIVIAZIONE CATEGORIE (es. "Casa > Bollette > Luce")
+ALTER TABLE CATEGORY
+    ADD COLUMN PARENT_ID   BIGINT REFERENCES CATEGORY (ID) ON DELETE SET NULL,
+    ADD COLUMN ARCHIVED_AT TIMESTAMPTZ;
+
+CREATE INDEX category_parent_id_idx ON category (parent_id);
+CREATE INDEX transaction_entries_category_id_idx ON transaction_entries (category_id);
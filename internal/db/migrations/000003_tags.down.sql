@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS transaction_entries_transaction_id_idx;
+DROP TABLE TRANSACTION_ENTRY_TAGS;
+DROP TABLE TAGS;
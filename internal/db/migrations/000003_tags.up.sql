@@ -0,0 +1,19 @@
+-- 8. TAG (etichette libere, indipendenti dalle categorie: es. "vacanza-2026", "rimborsabile")
+CREATE TABLE TAGS
+(
+    ID      BIGSERIAL PRIMARY KEY,
+    USER_ID BIGINT      NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    NAME    VARCHAR(64) NOT NULL,
+    UNIQUE (USER_ID, NAME)
+);
+
+-- 9. ASSOCIAZIONE MOVIMENTI <-> TAG
+CREATE TABLE TRANSACTION_ENTRY_TAGS
+(
+    ENTRY_ID BIGINT NOT NULL REFERENCES TRANSACTION_ENTRIES (ID) ON DELETE CASCADE,
+    TAG_ID   BIGINT NOT NULL REFERENCES TAGS (ID) ON DELETE CASCADE,
+    PRIMARY KEY (ENTRY_ID, TAG_ID)
+);
+
+CREATE INDEX transaction_entry_tags_tag_id_idx ON transaction_entry_tags (tag_id);
+CREATE INDEX transaction_entries_transaction_id_idx ON transaction_entries (transaction_id);
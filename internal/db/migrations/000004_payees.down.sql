@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS transaction_entries_payee_id_idx;
+
+ALTER TABLE TRANSACTION_ENTRIES
+    DROP COLUMN PAYEE_ID;
+
+DROP TABLE PAYEE_ALIASES;
+DROP TABLE PAYEES;
@@ -0,0 +1,23 @@
+-- 10. BENEFICIARI / ESERCENTI (es. "Esselunga")
+CREATE TABLE PAYEES
+(
+    ID                  BIGSERIAL PRIMARY KEY,
+    USER_ID             BIGINT       NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    NAME                VARCHAR(100) NOT NULL,
+    DEFAULT_CATEGORY_ID BIGINT REFERENCES CATEGORY (ID) ON DELETE SET NULL,
+    UNIQUE (USER_ID, NAME)
+);
+
+-- 11. ALIAS DEI BENEFICIARI (stringhe grezze della banca, es. "ESSELUNGA SPA MILANO")
+CREATE TABLE PAYEE_ALIASES
+(
+    ID       BIGSERIAL PRIMARY KEY,
+    PAYEE_ID BIGINT       NOT NULL REFERENCES PAYEES (ID) ON DELETE CASCADE,
+    PATTERN  VARCHAR(255) NOT NULL,
+    UNIQUE (PAYEE_ID, PATTERN)
+);
+
+ALTER TABLE TRANSACTION_ENTRIES
+    ADD COLUMN PAYEE_ID BIGINT REFERENCES PAYEES (ID) ON DELETE SET NULL;
+
+CREATE INDEX transaction_entries_payee_id_idx ON transaction_entries (payee_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS attachments_user_id_idx;
+DROP INDEX IF EXISTS attachments_transaction_id_idx;
+
+DROP TABLE ATTACHMENTS;
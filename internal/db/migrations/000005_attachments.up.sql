@@ -0,0 +1,16 @@
+-- 12. ALLEGATI (scontrini, fatture) collegati alle transazioni
+CREATE TABLE ATTACHMENTS
+(
+    ID             BIGSERIAL PRIMARY KEY,
+    USER_ID        BIGINT       NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    TRANSACTION_ID BIGINT       NOT NULL REFERENCES TRANSACTIONS (ID) ON DELETE CASCADE,
+    FILE_NAME      VARCHAR(255) NOT NULL,
+    CONTENT_TYPE   VARCHAR(100) NOT NULL,
+    SIZE_BYTES     BIGINT       NOT NULL,
+    STORAGE_KEY    VARCHAR(512) NOT NULL UNIQUE,
+    THUMBNAIL_KEY  VARCHAR(512),
+    CREATED_AT     TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX attachments_transaction_id_idx ON attachments (transaction_id);
+CREATE INDEX attachments_user_id_idx ON attachments (user_id);
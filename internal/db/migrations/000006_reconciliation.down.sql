@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS transaction_entries_account_status_idx;
+
+ALTER TABLE TRANSACTION_ENTRIES
+    DROP COLUMN RECONCILIATION_ID,
+    DROP COLUMN STATUS;
+
+DROP INDEX IF EXISTS reconciliations_open_account_idx;
+DROP TABLE RECONCILIATIONS;
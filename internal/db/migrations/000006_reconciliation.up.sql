@@ -0,0 +1,20 @@
+-- 13. RICONCILIAZIONI con l'estratto conto della banca
+CREATE TABLE RECONCILIATIONS
+(
+    ID                BIGSERIAL PRIMARY KEY,
+    ACCOUNT_ID        BIGINT      NOT NULL REFERENCES ACCOUNTS (ID) ON DELETE CASCADE,
+    STATEMENT_DATE    DATE        NOT NULL,
+    STATEMENT_BALANCE BIGINT      NOT NULL, -- Saldo dell'estratto conto in centesimi
+    STATUS            VARCHAR(16) NOT NULL DEFAULT 'IN_PROGRESS' CHECK (STATUS IN ('IN_PROGRESS', 'COMPLETED')),
+    CREATED_AT        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    COMPLETED_AT      TIMESTAMPTZ
+);
+
+-- Al più una riconciliazione aperta per account
+CREATE UNIQUE INDEX reconciliations_open_account_idx ON reconciliations (account_id) WHERE status = 'IN_PROGRESS';
+
+ALTER TABLE TRANSACTION_ENTRIES
+    ADD COLUMN STATUS            VARCHAR(16) NOT NULL DEFAULT 'UNCLEARED' CHECK (STATUS IN ('UNCLEARED', 'CLEARED', 'RECONCILED')),
+    ADD COLUMN RECONCILIATION_ID BIGINT REFERENCES RECONCILIATIONS (ID) ON DELETE SET NULL;
+
+CREATE INDEX transaction_entries_account_status_idx ON transaction_entries (account_id, status);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS credit_cards_auto_pay_idx;
+DROP TABLE CREDIT_CARDS;
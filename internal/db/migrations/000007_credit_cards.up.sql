@@ -0,0 +1,13 @@
+-- 14. CARTE DI CREDITO: ciclo di estratto conto e conto di addebito del saldo
+CREATE TABLE CREDIT_CARDS
+(
+    ACCOUNT_ID         BIGINT PRIMARY KEY REFERENCES ACCOUNTS (ID) ON DELETE CASCADE,
+    PAYMENT_ACCOUNT_ID BIGINT      NOT NULL REFERENCES ACCOUNTS (ID) ON DELETE RESTRICT,
+    CLOSING_DAY        INTEGER     NOT NULL CHECK (CLOSING_DAY BETWEEN 1 AND 31),
+    DUE_DAY            INTEGER     NOT NULL CHECK (DUE_DAY BETWEEN 1 AND 31),
+    AUTO_PAY           BOOLEAN     NOT NULL DEFAULT FALSE,
+    CREATED_AT         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ACCOUNT_ID <> PAYMENT_ACCOUNT_ID)
+);
+
+CREATE INDEX credit_cards_auto_pay_idx ON credit_cards (auto_pay) WHERE auto_pay;
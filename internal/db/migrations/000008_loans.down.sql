@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS loan_installments_due_idx;
+DROP TABLE LOAN_INSTALLMENTS;
+DROP TABLE LOANS;
@@ -0,0 +1,28 @@
+-- 15. PRESTITI E MUTUI: l'account del prestito parte con saldo negativo pari al capitale
+CREATE TABLE LOANS
+(
+    ACCOUNT_ID         BIGINT PRIMARY KEY REFERENCES ACCOUNTS (ID) ON DELETE CASCADE,
+    PAYMENT_ACCOUNT_ID BIGINT      NOT NULL REFERENCES ACCOUNTS (ID) ON DELETE RESTRICT,
+    PRINCIPAL          BIGINT      NOT NULL CHECK (PRINCIPAL > 0), -- Capitale in centesimi
+    ANNUAL_RATE_BPS    INTEGER     NOT NULL CHECK (ANNUAL_RATE_BPS >= 0), -- Tasso annuo in punti base (350 = 3,50%)
+    TERM_MONTHS        INTEGER     NOT NULL CHECK (TERM_MONTHS > 0),
+    START_DATE         DATE        NOT NULL,
+    METHOD             VARCHAR(10) NOT NULL CHECK (METHOD IN ('FRENCH', 'ITALIAN')),
+    CREATED_AT         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (ACCOUNT_ID <> PAYMENT_ACCOUNT_ID)
+);
+
+-- 16. RATE del piano di ammortamento; TRANSACTION_ID è valorizzato quando la rata è contabilizzata
+CREATE TABLE LOAN_INSTALLMENTS
+(
+    ID             BIGSERIAL PRIMARY KEY,
+    ACCOUNT_ID     BIGINT  NOT NULL REFERENCES LOANS (ACCOUNT_ID) ON DELETE CASCADE,
+    NUMBER         INTEGER NOT NULL,
+    DUE_DATE       DATE    NOT NULL,
+    PRINCIPAL      BIGINT  NOT NULL, -- Quota capitale in centesimi
+    INTEREST       BIGINT  NOT NULL, -- Quota interessi in centesimi
+    TRANSACTION_ID BIGINT REFERENCES TRANSACTIONS (ID) ON DELETE SET NULL,
+    UNIQUE (ACCOUNT_ID, NUMBER)
+);
+
+CREATE INDEX loan_installments_due_idx ON loan_installments (due_date) WHERE transaction_id IS NULL;
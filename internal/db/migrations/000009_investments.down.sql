@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS investment_trades_account_idx;
+DROP TABLE INVESTMENT_TRADES;
+DROP TABLE SECURITY_PRICES;
+DROP TABLE SECURITIES;
@@ -0,0 +1,41 @@
+-- 17. TITOLI (azioni, ETF, obbligazioni) identificati da ISIN e/o ticker
+CREATE TABLE SECURITIES
+(
+    ID         BIGSERIAL PRIMARY KEY,
+    USER_ID    BIGINT       NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    ISIN       VARCHAR(12),
+    TICKER     VARCHAR(20),
+    NAME       VARCHAR(100) NOT NULL,
+    CURRENCY   CHAR(3)      NOT NULL,
+    CREATED_AT TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CHECK (ISIN IS NOT NULL OR TICKER IS NOT NULL),
+    UNIQUE (USER_ID, ISIN),
+    UNIQUE (USER_ID, TICKER)
+);
+
+-- 18. STORICO PREZZI: un prezzo di chiusura per titolo e giorno
+CREATE TABLE SECURITY_PRICES
+(
+    SECURITY_ID BIGINT NOT NULL REFERENCES SECURITIES (ID) ON DELETE CASCADE,
+    PRICE_DATE  DATE   NOT NULL,
+    PRICE       BIGINT NOT NULL CHECK (PRICE >= 0), -- Prezzo unitario in decimillesimi (4 decimali)
+    PRIMARY KEY (SECURITY_ID, PRICE_DATE)
+);
+
+-- 19. OPERAZIONI SU TITOLI; la parte in denaro è registrata nella transazione collegata
+CREATE TABLE INVESTMENT_TRADES
+(
+    ID             BIGSERIAL PRIMARY KEY,
+    ACCOUNT_ID     BIGINT      NOT NULL REFERENCES ACCOUNTS (ID) ON DELETE CASCADE,
+    SECURITY_ID    BIGINT      NOT NULL REFERENCES SECURITIES (ID) ON DELETE RESTRICT,
+    TRANSACTION_ID BIGINT      NOT NULL REFERENCES TRANSACTIONS (ID) ON DELETE CASCADE,
+    KIND           VARCHAR(10) NOT NULL CHECK (KIND IN ('BUY', 'SELL', 'DIVIDEND')),
+    TRADE_DATE     DATE        NOT NULL,
+    QUANTITY       BIGINT      NOT NULL DEFAULT 0 CHECK (QUANTITY >= 0), -- Quantità in decimillesimi (4 decimali)
+    PRICE          BIGINT      NOT NULL DEFAULT 0 CHECK (PRICE >= 0),    -- Prezzo unitario in decimillesimi
+    FEES           BIGINT      NOT NULL DEFAULT 0 CHECK (FEES >= 0),     -- Commissioni in centesimi
+    AMOUNT         BIGINT      NOT NULL,                                 -- Movimento di cassa in centesimi
+    CREATED_AT     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX investment_trades_account_idx ON investment_trades (account_id, security_id, trade_date);
@@ -0,0 +1,11 @@
+DROP VIEW IF EXISTS TRANSACTION_ACCESS;
+DROP VIEW IF EXISTS CATEGORY_ACCESS;
+DROP VIEW IF EXISTS ACCOUNT_ACCESS;
+ALTER TABLE CATEGORY
+    DROP COLUMN HOUSEHOLD_ID;
+ALTER TABLE ACCOUNTS
+    DROP COLUMN HOUSEHOLD_ID;
+DROP TABLE HOUSEHOLD_INVITES;
+DROP INDEX IF EXISTS household_members_user_idx;
+DROP TABLE HOUSEHOLD_MEMBERS;
+DROP TABLE HOUSEHOLDS;
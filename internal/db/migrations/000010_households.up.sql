@@ -0,0 +1,67 @@
+-- 20. HOUSEHOLD: gruppi di utenti che condividono account e categorie
+CREATE TABLE HOUSEHOLDS
+(
+    ID         BIGSERIAL PRIMARY KEY,
+    NAME       VARCHAR(100) NOT NULL,
+    CREATED_AT TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- 21. MEMBRI: OWNER gestisce membri e inviti, EDITOR modifica, VIEWER legge soltanto
+CREATE TABLE HOUSEHOLD_MEMBERS
+(
+    HOUSEHOLD_ID BIGINT      NOT NULL REFERENCES HOUSEHOLDS (ID) ON DELETE CASCADE,
+    USER_ID      BIGINT      NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    ROLE         VARCHAR(10) NOT NULL CHECK (ROLE IN ('OWNER', 'EDITOR', 'VIEWER')),
+    JOINED_AT    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (HOUSEHOLD_ID, USER_ID)
+);
+CREATE INDEX household_members_user_idx ON household_members (user_id);
+
+-- 22. INVITI: codice monouso, facoltativamente riservato a un indirizzo email
+CREATE TABLE HOUSEHOLD_INVITES
+(
+    ID           BIGSERIAL PRIMARY KEY,
+    HOUSEHOLD_ID BIGINT      NOT NULL REFERENCES HOUSEHOLDS (ID) ON DELETE CASCADE,
+    CODE         VARCHAR(32) NOT NULL UNIQUE,
+    EMAIL        VARCHAR(100),
+    ROLE         VARCHAR(10) NOT NULL CHECK (ROLE IN ('OWNER', 'EDITOR', 'VIEWER')),
+    INVITED_BY   BIGINT      NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    EXPIRES_AT   TIMESTAMPTZ NOT NULL,
+    ACCEPTED_BY  BIGINT REFERENCES USERS (ID) ON DELETE SET NULL,
+    ACCEPTED_AT  TIMESTAMPTZ,
+    CREATED_AT   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Account e categorie restano dell'utente che li ha creati e possono essere condivisi con una household
+ALTER TABLE ACCOUNTS
+    ADD COLUMN HOUSEHOLD_ID BIGINT REFERENCES HOUSEHOLDS (ID) ON DELETE SET NULL;
+ALTER TABLE CATEGORY
+    ADD COLUMN HOUSEHOLD_ID BIGINT REFERENCES HOUSEHOLDS (ID) ON DELETE SET NULL;
+
+-- Diritti di accesso: il proprietario ha sempre OWNER, i membri della household il proprio ruolo
+CREATE VIEW ACCOUNT_ACCESS AS
+SELECT a.id AS account_id, a.user_id, 'OWNER'::VARCHAR(10) AS role
+FROM accounts a
+UNION
+SELECT a.id, hm.user_id, hm.role
+FROM accounts a
+         JOIN household_members hm ON hm.household_id = a.household_id
+WHERE hm.user_id <> a.user_id;
+
+CREATE VIEW CATEGORY_ACCESS AS
+SELECT c.id AS category_id, c.user_id, 'OWNER'::VARCHAR(10) AS role
+FROM category c
+UNION
+SELECT c.id, hm.user_id, hm.role
+FROM category c
+         JOIN household_members hm ON hm.household_id = c.household_id
+WHERE hm.user_id <> c.user_id;
+
+-- Una transazione è visibile a chi l'ha registrata e a chi accede ad almeno uno dei suoi account
+CREATE VIEW TRANSACTION_ACCESS AS
+SELECT t.id AS transaction_id, t.user_id, 'OWNER'::VARCHAR(10) AS role
+FROM transactions t
+UNION
+SELECT te.transaction_id, aa.user_id, aa.role
+FROM transaction_entries te
+         JOIN account_access aa ON aa.account_id = te.account_id;
@@ -0,0 +1,6 @@
+DROP TABLE SPLIT_SETTLEMENTS;
+DROP TABLE SPLIT_SHARES;
+DROP TABLE SPLIT_EXPENSES;
+DROP INDEX IF EXISTS split_members_user_idx;
+DROP TABLE SPLIT_MEMBERS;
+DROP TABLE SPLIT_GROUPS;
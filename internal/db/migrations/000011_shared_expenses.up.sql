@@ -0,0 +1,58 @@
+-- 23. GRUPPI DI SPESA CONDIVISA (viaggi, coinquilini, ...)
+CREATE TABLE SPLIT_GROUPS
+(
+    ID         BIGSERIAL PRIMARY KEY,
+    NAME       VARCHAR(100) NOT NULL,
+    CURRENCY   CHAR(3)      NOT NULL,
+    CREATED_BY BIGINT       NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    CREATED_AT TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- 24. PARTECIPANTI: utenti koin oppure persone senza account, identificate dal nome
+CREATE TABLE SPLIT_MEMBERS
+(
+    ID       BIGSERIAL PRIMARY KEY,
+    GROUP_ID BIGINT       NOT NULL REFERENCES SPLIT_GROUPS (ID) ON DELETE CASCADE,
+    USER_ID  BIGINT REFERENCES USERS (ID) ON DELETE SET NULL,
+    NAME     VARCHAR(100) NOT NULL,
+    UNIQUE (GROUP_ID, USER_ID),
+    UNIQUE (GROUP_ID, NAME)
+);
+CREATE INDEX split_members_user_idx ON split_members (user_id);
+
+-- 25. SPESE DIVISE: la spesa è la transazione registrata da chi ha pagato
+CREATE TABLE SPLIT_EXPENSES
+(
+    ID             BIGSERIAL PRIMARY KEY,
+    GROUP_ID       BIGINT      NOT NULL REFERENCES SPLIT_GROUPS (ID) ON DELETE CASCADE,
+    TRANSACTION_ID BIGINT      NOT NULL UNIQUE REFERENCES TRANSACTIONS (ID) ON DELETE CASCADE,
+    PAID_BY        BIGINT      NOT NULL REFERENCES SPLIT_MEMBERS (ID) ON DELETE RESTRICT,
+    AMOUNT         BIGINT      NOT NULL CHECK (AMOUNT > 0),
+    METHOD         VARCHAR(10) NOT NULL CHECK (METHOD IN ('EQUAL', 'SHARES', 'EXACT')),
+    CREATED_AT     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 26. QUOTE: quanto deve ciascun partecipante per la spesa
+CREATE TABLE SPLIT_SHARES
+(
+    EXPENSE_ID BIGINT NOT NULL REFERENCES SPLIT_EXPENSES (ID) ON DELETE CASCADE,
+    MEMBER_ID  BIGINT NOT NULL REFERENCES SPLIT_MEMBERS (ID) ON DELETE RESTRICT,
+    SHARES     INT,                                  -- Peso della quota con il metodo SHARES
+    AMOUNT     BIGINT NOT NULL CHECK (AMOUNT >= 0), -- Importo dovuto in centesimi
+    PRIMARY KEY (EXPENSE_ID, MEMBER_ID)
+);
+
+-- 27. SALDI TRA PARTECIPANTI, con le transazioni create negli account degli utenti koin
+CREATE TABLE SPLIT_SETTLEMENTS
+(
+    ID                  BIGSERIAL PRIMARY KEY,
+    GROUP_ID            BIGINT      NOT NULL REFERENCES SPLIT_GROUPS (ID) ON DELETE CASCADE,
+    FROM_MEMBER_ID      BIGINT      NOT NULL REFERENCES SPLIT_MEMBERS (ID) ON DELETE RESTRICT,
+    TO_MEMBER_ID        BIGINT      NOT NULL REFERENCES SPLIT_MEMBERS (ID) ON DELETE RESTRICT,
+    AMOUNT              BIGINT      NOT NULL CHECK (AMOUNT > 0),
+    SETTLED_AT          DATE        NOT NULL,
+    FROM_TRANSACTION_ID BIGINT REFERENCES TRANSACTIONS (ID) ON DELETE SET NULL,
+    TO_TRANSACTION_ID   BIGINT REFERENCES TRANSACTIONS (ID) ON DELETE SET NULL,
+    CREATED_AT          TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (FROM_MEMBER_ID <> TO_MEMBER_ID)
+);
@@ -0,0 +1,5 @@
+DROP VIEW IF EXISTS ENTRY_NET_AMOUNTS;
+DROP INDEX IF EXISTS reimbursements_income_idx;
+DROP TABLE REIMBURSEMENTS;
+DROP INDEX IF EXISTS reimbursables_user_idx;
+DROP TABLE REIMBURSABLES;
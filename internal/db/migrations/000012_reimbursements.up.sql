@@ -0,0 +1,30 @@
+-- 28. SPESE RIMBORSABILI: movimenti di spesa anticipati per conto di qualcun altro
+CREATE TABLE REIMBURSABLES
+(
+    ENTRY_ID   BIGINT PRIMARY KEY REFERENCES TRANSACTION_ENTRIES (ID) ON DELETE CASCADE,
+    USER_ID    BIGINT       NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    PARTY      VARCHAR(100) NOT NULL, -- Chi deve rimborsare (datore di lavoro, persona, ...)
+    CREATED_AT TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+CREATE INDEX reimbursables_user_idx ON reimbursables (user_id, party);
+
+-- 29. RIMBORSI: quota di un movimento di entrata che rimborsa una spesa, anche solo in parte
+CREATE TABLE REIMBURSEMENTS
+(
+    ID               BIGSERIAL PRIMARY KEY,
+    EXPENSE_ENTRY_ID BIGINT      NOT NULL REFERENCES REIMBURSABLES (ENTRY_ID) ON DELETE CASCADE,
+    INCOME_ENTRY_ID  BIGINT      NOT NULL REFERENCES TRANSACTION_ENTRIES (ID) ON DELETE CASCADE,
+    AMOUNT           BIGINT      NOT NULL CHECK (AMOUNT > 0),
+    CREATED_AT       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (EXPENSE_ENTRY_ID, INCOME_ENTRY_ID)
+);
+CREATE INDEX reimbursements_income_idx ON reimbursements (income_entry_id);
+
+-- Importo dei movimenti al netto dei rimborsi, usato dai report di spesa: la spesa rimborsata
+-- non pesa sulla sua categoria e l'entrata che la rimborsa non conta come guadagno
+CREATE VIEW ENTRY_NET_AMOUNTS AS
+SELECT te.id AS entry_id,
+       (te.amount
+           + COALESCE((SELECT SUM(r.amount) FROM reimbursements r WHERE r.expense_entry_id = te.id), 0)
+           - COALESCE((SELECT SUM(r.amount) FROM reimbursements r WHERE r.income_entry_id = te.id), 0))::BIGINT AS amount
+FROM transaction_entries te;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS recurring_transactions_account_idx;
+DROP TABLE RECURRING_TRANSACTIONS;
+ALTER TABLE ACCOUNTS DROP COLUMN OVERDRAFT_LIMIT;
@@ -0,0 +1,20 @@
+-- Fido dell'account: il saldo può scendere fino a -OVERDRAFT_LIMIT prima di andare in sconfinamento
+ALTER TABLE ACCOUNTS
+    ADD COLUMN OVERDRAFT_LIMIT BIGINT NOT NULL DEFAULT 0 CHECK (OVERDRAFT_LIMIT >= 0);
+
+-- 30. TRANSAZIONI RICORRENTI note (stipendio, affitto, utenze, ...), usate dalla previsione dei saldi
+CREATE TABLE RECURRING_TRANSACTIONS
+(
+    ID           BIGSERIAL PRIMARY KEY,
+    USER_ID      BIGINT       NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    ACCOUNT_ID   BIGINT       NOT NULL REFERENCES ACCOUNTS (ID) ON DELETE CASCADE,
+    CATEGORY_ID  BIGINT       NOT NULL REFERENCES CATEGORY (ID) ON DELETE CASCADE,
+    AMOUNT       BIGINT       NOT NULL CHECK (AMOUNT <> 0),            -- Negativo per le uscite, in centesimi
+    DESCRIPTION  VARCHAR(255) NOT NULL,
+    FREQUENCY    VARCHAR(10)  NOT NULL CHECK (FREQUENCY IN ('WEEKLY', 'MONTHLY', 'YEARLY')),
+    REPEAT_EVERY INTEGER      NOT NULL DEFAULT 1 CHECK (REPEAT_EVERY > 0), -- Ogni quante settimane/mesi/anni
+    START_DATE   DATE         NOT NULL,                                -- Prima occorrenza
+    END_DATE     DATE CHECK (END_DATE IS NULL OR END_DATE >= START_DATE),
+    CREATED_AT   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+CREATE INDEX recurring_transactions_account_idx ON recurring_transactions (account_id);
@@ -0,0 +1,3 @@
+DROP TABLE ANOMALY_SCANS;
+DROP INDEX IF EXISTS spending_anomalies_user_idx;
+DROP TABLE SPENDING_ANOMALIES;
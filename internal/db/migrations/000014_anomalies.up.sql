@@ -0,0 +1,28 @@
+-- 31. ANOMALIE DI SPESA: singole spese o totali mensili di categoria fuori dalla norma dell'utente
+CREATE TABLE SPENDING_ANOMALIES
+(
+    ID           BIGSERIAL PRIMARY KEY,
+    USER_ID      BIGINT       NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    KIND         VARCHAR(10)  NOT NULL CHECK (KIND IN ('EXPENSE', 'CATEGORY')),
+    ENTRY_ID     BIGINT REFERENCES TRANSACTION_ENTRIES (ID) ON DELETE CASCADE, -- Spesa anomala (EXPENSE)
+    CATEGORY_ID  BIGINT       NOT NULL REFERENCES CATEGORY (ID) ON DELETE CASCADE,
+    MONTH        DATE,                                                         -- Primo giorno del mese (CATEGORY)
+    AMOUNT       BIGINT       NOT NULL,                                        -- Importo osservato in centesimi
+    BASELINE     BIGINT       NOT NULL,                                        -- Mediana storica in centesimi
+    RATIO_PCT    INTEGER      NOT NULL,                                        -- AMOUNT in percentuale di BASELINE
+    MESSAGE      VARCHAR(255) NOT NULL,
+    DETECTED_AT  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    DISMISSED_AT TIMESTAMPTZ,
+    CHECK ((KIND = 'EXPENSE' AND ENTRY_ID IS NOT NULL) OR (KIND = 'CATEGORY' AND MONTH IS NOT NULL)),
+    UNIQUE (USER_ID, ENTRY_ID),
+    UNIQUE (USER_ID, CATEGORY_ID, MONTH)
+);
+CREATE INDEX spending_anomalies_user_idx ON spending_anomalies (user_id, detected_at) WHERE dismissed_at IS NULL;
+
+-- 32. AVANZAMENTO DEL CONTROLLO ANOMALIE: ultimo movimento già esaminato per ogni utente
+CREATE TABLE ANOMALY_SCANS
+(
+    USER_ID       BIGINT PRIMARY KEY REFERENCES USERS (ID) ON DELETE CASCADE,
+    LAST_ENTRY_ID BIGINT      NOT NULL,
+    SCANNED_AT    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS notification_deliveries_pending_idx;
+DROP TABLE NOTIFICATION_DELIVERIES;
+DROP INDEX IF EXISTS notifications_inbox_idx;
+DROP TABLE NOTIFICATIONS;
+DROP TABLE NOTIFICATION_PREFERENCES;
+DROP TABLE NOTIFICATION_SETTINGS;
+ALTER TABLE CATEGORY DROP COLUMN MONTHLY_BUDGET;
@@ -0,0 +1,54 @@
+-- Budget mensile di spesa della categoria, in centesimi: superarlo genera una notifica
+ALTER TABLE CATEGORY
+    ADD COLUMN MONTHLY_BUDGET BIGINT CHECK (MONTHLY_BUDGET > 0);
+
+-- 33. IMPOSTAZIONI DELLE NOTIFICHE: destinazione dei webhook e soglia di saldo basso dell'utente
+CREATE TABLE NOTIFICATION_SETTINGS
+(
+    USER_ID               BIGINT PRIMARY KEY REFERENCES USERS (ID) ON DELETE CASCADE,
+    WEBHOOK_URL           VARCHAR(2048),
+    LOW_BALANCE_THRESHOLD BIGINT      NOT NULL DEFAULT 0 CHECK (LOW_BALANCE_THRESHOLD >= 0), -- 0 = disattivata
+    UPDATED_AT            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 34. CANALI PER TIPO DI EVENTO: senza riga l'evento arriva solo nella inbox dell'app
+CREATE TABLE NOTIFICATION_PREFERENCES
+(
+    USER_ID    BIGINT      NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    EVENT_KIND VARCHAR(20) NOT NULL,
+    EMAIL      BOOLEAN     NOT NULL DEFAULT FALSE,
+    WEBHOOK    BOOLEAN     NOT NULL DEFAULT FALSE,
+    IN_APP     BOOLEAN     NOT NULL DEFAULT TRUE,
+    PRIMARY KEY (USER_ID, EVENT_KIND)
+);
+
+-- 35. NOTIFICHE pubblicate dai servizi; DEDUP_KEY evita di notificare due volte lo stesso fatto
+CREATE TABLE NOTIFICATIONS
+(
+    ID         BIGSERIAL PRIMARY KEY,
+    USER_ID    BIGINT       NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    KIND       VARCHAR(20)  NOT NULL,
+    TITLE      VARCHAR(255) NOT NULL,
+    BODY       TEXT         NOT NULL,
+    DEDUP_KEY  VARCHAR(255) NOT NULL,
+    IN_APP     BOOLEAN      NOT NULL, -- Visibile nella inbox
+    CREATED_AT TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    READ_AT    TIMESTAMPTZ,
+    UNIQUE (USER_ID, DEDUP_KEY)
+);
+CREATE INDEX notifications_inbox_idx ON notifications (user_id, created_at) WHERE in_app;
+
+-- 36. INVII delle notifiche via email e webhook, ritentati con backoff esponenziale
+CREATE TABLE NOTIFICATION_DELIVERIES
+(
+    ID              BIGSERIAL PRIMARY KEY,
+    NOTIFICATION_ID BIGINT        NOT NULL REFERENCES NOTIFICATIONS (ID) ON DELETE CASCADE,
+    CHANNEL         VARCHAR(10)   NOT NULL CHECK (CHANNEL IN ('EMAIL', 'WEBHOOK')),
+    TARGET          VARCHAR(2048) NOT NULL, -- Indirizzo email o URL del webhook
+    STATUS          VARCHAR(10)   NOT NULL DEFAULT 'PENDING' CHECK (STATUS IN ('PENDING', 'SENT', 'FAILED')),
+    ATTEMPTS        INTEGER       NOT NULL DEFAULT 0,
+    NEXT_ATTEMPT_AT TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    LAST_ERROR      TEXT,
+    SENT_AT         TIMESTAMPTZ
+);
+CREATE INDEX notification_deliveries_pending_idx ON notification_deliveries (next_attempt_at) WHERE status = 'PENDING';
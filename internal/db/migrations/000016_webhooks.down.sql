@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS transaction_entries_webhook_outbox ON transaction_entries;
+DROP TRIGGER IF EXISTS transactions_webhook_outbox ON transactions;
+DROP TRIGGER IF EXISTS category_webhook_outbox ON category;
+DROP TRIGGER IF EXISTS accounts_webhook_outbox ON accounts;
+DROP FUNCTION IF EXISTS webhook_transaction_event();
+DROP FUNCTION IF EXISTS webhook_category_event();
+DROP FUNCTION IF EXISTS webhook_account_event();
+DROP FUNCTION IF EXISTS webhook_outbox_insert(BIGINT, VARCHAR, BIGINT, VARCHAR, JSONB);
+DROP INDEX IF EXISTS webhook_deliveries_endpoint_idx;
+DROP INDEX IF EXISTS webhook_deliveries_pending_idx;
+DROP TABLE WEBHOOK_DELIVERIES;
+DROP INDEX IF EXISTS webhook_outbox_pending_idx;
+DROP TABLE WEBHOOK_OUTBOX;
+DROP INDEX IF EXISTS webhook_endpoints_user_idx;
+DROP TABLE WEBHOOK_ENDPOINTS;
@@ -0,0 +1,190 @@
+-- 37. ENDPOINT WEBHOOK registrati dall'utente: ricevono gli eventi firmati con SECRET (HMAC-SHA256)
+CREATE TABLE WEBHOOK_ENDPOINTS
+(
+    ID          BIGSERIAL PRIMARY KEY,
+    USER_ID     BIGINT        NOT NULL REFERENCES USERS (ID) ON DELETE CASCADE,
+    URL         VARCHAR(2048) NOT NULL,
+    SECRET      VARCHAR(100)  NOT NULL,
+    DESCRIPTION VARCHAR(255),
+    CREATED_AT  TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+CREATE INDEX webhook_endpoints_user_idx ON webhook_endpoints (user_id);
+
+-- 38. OUTBOX degli eventi: scritta dai trigger nella stessa transazione della modifica, così
+-- nessun evento va perso. Una risorsa modificata più volte nella stessa transazione genera un
+-- solo evento (TX_ID). USER_ID non ha vincoli: gli eventi di un utente eliminato vengono scartati.
+CREATE TABLE WEBHOOK_OUTBOX
+(
+    ID            BIGSERIAL PRIMARY KEY,
+    USER_ID       BIGINT      NOT NULL,
+    EVENT         VARCHAR(40) NOT NULL, -- es. transaction.created, account.deleted
+    RESOURCE      VARCHAR(20) NOT NULL,
+    RESOURCE_ID   BIGINT      NOT NULL,
+    PAYLOAD       JSONB       NOT NULL,
+    TX_ID         BIGINT      NOT NULL DEFAULT txid_current(),
+    CREATED_AT    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    DISPATCHED_AT TIMESTAMPTZ, -- Smistato sugli endpoint dell'utente
+    UNIQUE (TX_ID, RESOURCE, RESOURCE_ID)
+);
+CREATE INDEX webhook_outbox_pending_idx ON webhook_outbox (id) WHERE dispatched_at IS NULL;
+
+-- 39. INVII di un evento a un endpoint, con l'esito dell'ultimo tentativo
+CREATE TABLE WEBHOOK_DELIVERIES
+(
+    ID               BIGSERIAL PRIMARY KEY,
+    ENDPOINT_ID      BIGINT      NOT NULL REFERENCES WEBHOOK_ENDPOINTS (ID) ON DELETE CASCADE,
+    OUTBOX_ID        BIGINT      NOT NULL REFERENCES WEBHOOK_OUTBOX (ID) ON DELETE CASCADE,
+    STATUS           VARCHAR(10) NOT NULL DEFAULT 'PENDING' CHECK (STATUS IN ('PENDING', 'SENT', 'FAILED')),
+    ATTEMPTS         INTEGER     NOT NULL DEFAULT 0,
+    NEXT_ATTEMPT_AT  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    LAST_STATUS_CODE INTEGER, -- Codice HTTP dell'ultima risposta
+    LAST_ERROR       TEXT,
+    DELIVERED_AT     TIMESTAMPTZ,
+    CREATED_AT       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (ENDPOINT_ID, OUTBOX_ID)
+);
+CREATE INDEX webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX webhook_deliveries_endpoint_idx ON webhook_deliveries (endpoint_id, created_at);
+
+CREATE FUNCTION webhook_outbox_insert(p_user_id BIGINT, p_resource VARCHAR, p_resource_id BIGINT, p_action VARCHAR,
+                                      p_payload JSONB) RETURNS VOID AS
+$$
+BEGIN
+    INSERT INTO webhook_outbox(user_id, event, resource, resource_id, payload)
+    VALUES (p_user_id, p_resource || '.' || p_action, p_resource, p_resource_id, p_payload)
+    ON CONFLICT (tx_id, resource, resource_id) DO NOTHING;
+END;
+$$ LANGUAGE plpgsql;
+
+-- I trigger sono differiti al commit: il payload riflette lo stato finale della risorsa (per le
+-- transazioni, righe contabili comprese) e la prima modifica della transazione decide l'evento.
+CREATE FUNCTION webhook_account_event() RETURNS TRIGGER AS
+$$
+DECLARE
+    v_account accounts;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_account := OLD;
+    ELSE
+        SELECT * INTO v_account FROM accounts WHERE id = NEW.id;
+        IF NOT FOUND THEN
+            RETURN NULL; -- Eliminato nella stessa transazione: basta l'evento deleted
+        END IF;
+    END IF;
+
+    PERFORM webhook_outbox_insert(v_account.user_id, 'account', v_account.id, lower(TG_OP) || 'd',
+                                  jsonb_build_object('id', v_account.id,
+                                                     'userId', v_account.user_id,
+                                                     'name', v_account.name,
+                                                     'currency', v_account.currency,
+                                                     'initialBalance', v_account.initial_balance,
+                                                     'householdId', v_account.household_id,
+                                                     'overdraftLimit', v_account.overdraft_limit));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER accounts_webhook_outbox
+    AFTER INSERT OR UPDATE OR DELETE
+    ON accounts
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+EXECUTE FUNCTION webhook_account_event();
+
+CREATE FUNCTION webhook_category_event() RETURNS TRIGGER AS
+$$
+DECLARE
+    v_category category;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        v_category := OLD;
+    ELSE
+        SELECT * INTO v_category FROM category WHERE id = NEW.id;
+        IF NOT FOUND THEN
+            RETURN NULL;
+        END IF;
+    END IF;
+
+    PERFORM webhook_outbox_insert(v_category.user_id, 'category', v_category.id, lower(TG_OP) || 'd',
+                                  jsonb_build_object('id', v_category.id,
+                                                     'userId', v_category.user_id,
+                                                     'name', v_category.name,
+                                                     'type', v_category.type,
+                                                     'parentId', v_category.parent_id,
+                                                     'archivedAt', v_category.archived_at,
+                                                     'householdId', v_category.household_id,
+                                                     'monthlyBudget', v_category.monthly_budget));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER category_webhook_outbox
+    AFTER INSERT OR UPDATE OR DELETE
+    ON category
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+EXECUTE FUNCTION webhook_category_event();
+
+-- Una transazione è la testata con le sue righe contabili: modificare una riga genera
+-- transaction.updated per la transazione, a meno che non sia stata creata o eliminata nella
+-- stessa transazione del database.
+CREATE FUNCTION webhook_transaction_event() RETURNS TRIGGER AS
+$$
+DECLARE
+    v_transaction_id BIGINT;
+    v_user_id        BIGINT;
+    v_occurred_at    DATE;
+BEGIN
+    IF TG_TABLE_NAME = 'transactions' AND TG_OP = 'DELETE' THEN
+        PERFORM webhook_outbox_insert(OLD.user_id, 'transaction', OLD.id, 'deleted',
+                                      jsonb_build_object('id', OLD.id,
+                                                         'userId', OLD.user_id,
+                                                         'occurredAt', OLD.occurred_at));
+        RETURN NULL;
+    END IF;
+
+    IF TG_TABLE_NAME = 'transactions' THEN
+        v_transaction_id := NEW.id;
+    ELSIF TG_OP = 'DELETE' THEN
+        v_transaction_id := OLD.transaction_id;
+    ELSE
+        v_transaction_id := NEW.transaction_id;
+    END IF;
+
+    SELECT t.user_id, t.occurred_at INTO v_user_id, v_occurred_at FROM transactions t WHERE t.id = v_transaction_id;
+    IF NOT FOUND THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM webhook_outbox_insert(v_user_id, 'transaction', v_transaction_id,
+                                  CASE WHEN TG_TABLE_NAME = 'transactions' AND TG_OP = 'INSERT' THEN 'created' ELSE 'updated' END,
+                                  jsonb_build_object('id', v_transaction_id,
+                                                     'userId', v_user_id,
+                                                     'occurredAt', v_occurred_at,
+                                                     'entries', COALESCE((SELECT jsonb_agg(jsonb_build_object('id', te.id,
+                                                                                                              'accountId', te.account_id,
+                                                                                                              'categoryId', te.category_id,
+                                                                                                              'payeeId', te.payee_id,
+                                                                                                              'amount', te.amount,
+                                                                                                              'description', te.description,
+                                                                                                              'status', te.status)
+                                                                                           ORDER BY te.id)
+                                                                          FROM transaction_entries te
+                                                                          WHERE te.transaction_id = v_transaction_id), '[]'::JSONB)));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER transactions_webhook_outbox
+    AFTER INSERT OR UPDATE OR DELETE
+    ON transactions
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+EXECUTE FUNCTION webhook_transaction_event();
+
+CREATE CONSTRAINT TRIGGER transaction_entries_webhook_outbox
+    AFTER INSERT OR UPDATE OR DELETE
+    ON transaction_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+EXECUTE FUNCTION webhook_transaction_event();
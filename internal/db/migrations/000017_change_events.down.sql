@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS webhook_outbox_notify ON webhook_outbox;
+DROP FUNCTION IF EXISTS webhook_outbox_notify();
@@ -0,0 +1,43 @@
+-- Ogni evento dell'outbox viene notificato sul canale koin_changes agli utenti che vedono la
+-- risorsa: il proprietario e i membri delle household con cui è condivisa. La notifica parte al
+-- commit, quindi arriva a tutte le istanze in ascolto solo per le modifiche confermate.
+CREATE FUNCTION webhook_outbox_notify() RETURNS TRIGGER AS
+$$
+DECLARE
+    v_users BIGINT[];
+BEGIN
+    SELECT array_agg(DISTINCT recipients.user_id ORDER BY recipients.user_id)
+    INTO v_users
+    FROM (SELECT NEW.user_id AS user_id
+          UNION
+          -- Account e categorie condivisi
+          SELECT hm.user_id
+          FROM household_members hm
+          WHERE hm.household_id = (NEW.payload ->> 'householdId')::BIGINT
+          UNION
+          -- Transazioni sugli account condivisi
+          SELECT aa.user_id
+          FROM account_access aa
+          WHERE aa.account_id IN (SELECT (entry ->> 'accountId')::BIGINT
+                                  FROM jsonb_array_elements(COALESCE(NEW.payload -> 'entries', '[]'::JSONB)) entry)
+          UNION
+          -- Di una transazione eliminata non si conoscono più gli account: avvisa le household del proprietario
+          SELECT hm.user_id
+          FROM household_members hm
+          WHERE NEW.event = 'transaction.deleted'
+            AND hm.household_id IN (SELECT household_id FROM household_members WHERE user_id = NEW.user_id)) recipients;
+
+    PERFORM pg_notify('koin_changes', jsonb_build_object('id', NEW.id,
+                                                         'event', NEW.event,
+                                                         'resource', NEW.resource,
+                                                         'resourceId', NEW.resource_id,
+                                                         'userIds', to_jsonb(v_users))::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER webhook_outbox_notify
+    AFTER INSERT
+    ON webhook_outbox
+    FOR EACH ROW
+EXECUTE FUNCTION webhook_outbox_notify();
@@ -0,0 +1 @@
+DROP TABLE DIGEST_SETTINGS;
@@ -0,0 +1,12 @@
+-- 40. RIEPILOGHI periodici via email: settimanale nel giorno WEEKDAY (0 = domenica) sui sette
+-- giorni precedenti, mensile il primo del mese sul mese precedente
+CREATE TABLE DIGEST_SETTINGS
+(
+    USER_ID           BIGINT PRIMARY KEY REFERENCES USERS (ID) ON DELETE CASCADE,
+    WEEKLY            BOOLEAN     NOT NULL DEFAULT FALSE,
+    MONTHLY           BOOLEAN     NOT NULL DEFAULT FALSE,
+    WEEKDAY           SMALLINT    NOT NULL DEFAULT 1 CHECK (WEEKDAY BETWEEN 0 AND 6),
+    LAST_WEEKLY_SENT  DATE, -- Data dell'ultimo invio, per non ripeterlo
+    LAST_MONTHLY_SENT DATE,
+    UPDATED_AT        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,2 @@
+ALTER TABLE TRANSACTION_ENTRIES DROP COLUMN DEDUCTION_CODE;
+ALTER TABLE CATEGORY DROP COLUMN DEDUCTION_CODE;
@@ -0,0 +1,9 @@
+-- Classificazione delle spese detraibili nel 730 (quadro E): il codice della riga contabile
+-- prevale su quello della categoria, NONE esclude la riga anche se la categoria è detraibile
+ALTER TABLE CATEGORY
+    ADD COLUMN DEDUCTION_CODE VARCHAR(10) CHECK (DEDUCTION_CODE IN
+        ('E1', 'E3', 'E7', 'E8_12', 'E8_13', 'E8_14', 'E8_15', 'E8_16', 'E8_17', 'E8_18', 'E41'));
+
+ALTER TABLE TRANSACTION_ENTRIES
+    ADD COLUMN DEDUCTION_CODE VARCHAR(10) CHECK (DEDUCTION_CODE IN
+        ('E1', 'E3', 'E7', 'E8_12', 'E8_13', 'E8_14', 'E8_15', 'E8_16', 'E8_17', 'E8_18', 'E41', 'NONE'));
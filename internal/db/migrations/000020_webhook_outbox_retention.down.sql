@@ -0,0 +1,48 @@
+DROP INDEX webhook_outbox_dispatched_idx;
+
+CREATE OR REPLACE FUNCTION webhook_outbox_insert(p_user_id BIGINT, p_resource VARCHAR, p_resource_id BIGINT,
+                                                 p_action VARCHAR, p_payload JSONB) RETURNS VOID AS
+$$
+BEGIN
+    INSERT INTO webhook_outbox(user_id, event, resource, resource_id, payload)
+    VALUES (p_user_id, p_resource || '.' || p_action, p_resource, p_resource_id, p_payload)
+    ON CONFLICT (tx_id, resource, resource_id) DO NOTHING;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION webhook_outbox_notify() RETURNS TRIGGER AS
+$$
+DECLARE
+    v_users BIGINT[];
+BEGIN
+    SELECT array_agg(DISTINCT recipients.user_id ORDER BY recipients.user_id)
+    INTO v_users
+    FROM (SELECT NEW.user_id AS user_id
+          UNION
+          -- Account e categorie condivisi
+          SELECT hm.user_id
+          FROM household_members hm
+          WHERE hm.household_id = (NEW.payload ->> 'householdId')::BIGINT
+          UNION
+          -- Transazioni sugli account condivisi
+          SELECT aa.user_id
+          FROM account_access aa
+          WHERE aa.account_id IN (SELECT (entry ->> 'accountId')::BIGINT
+                                  FROM jsonb_array_elements(COALESCE(NEW.payload -> 'entries', '[]'::JSONB)) entry)
+          UNION
+          -- Di una transazione eliminata non si conoscono più gli account: avvisa le household del proprietario
+          SELECT hm.user_id
+          FROM household_members hm
+          WHERE NEW.event = 'transaction.deleted'
+            AND hm.household_id IN (SELECT household_id FROM household_members WHERE user_id = NEW.user_id)) recipients;
+
+    PERFORM pg_notify('koin_changes', jsonb_build_object('id', NEW.id,
+                                                         'event', NEW.event,
+                                                         'resource', NEW.resource,
+                                                         'resourceId', NEW.resource_id,
+                                                         'userIds', to_jsonb(v_users))::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION change_notify(BIGINT, BIGINT, VARCHAR, VARCHAR, BIGINT, JSONB);
@@ -0,0 +1,77 @@
+-- La notifica sul canale koin_changes diventa una funzione a sé: serve anche per le modifiche
+-- degli utenti senza endpoint webhook, che non scrivono più nell'outbox.
+CREATE FUNCTION change_notify(p_id BIGINT, p_user_id BIGINT, p_event VARCHAR, p_resource VARCHAR,
+                              p_resource_id BIGINT, p_payload JSONB) RETURNS VOID AS
+$$
+DECLARE
+    v_users BIGINT[];
+BEGIN
+    SELECT array_agg(DISTINCT recipients.user_id ORDER BY recipients.user_id)
+    INTO v_users
+    FROM (SELECT p_user_id AS user_id
+          UNION
+          -- Account e categorie condivisi
+          SELECT hm.user_id
+          FROM household_members hm
+          WHERE hm.household_id = (p_payload ->> 'householdId')::BIGINT
+          UNION
+          -- Transazioni sugli account condivisi
+          SELECT aa.user_id
+          FROM account_access aa
+          WHERE aa.account_id IN (SELECT (entry ->> 'accountId')::BIGINT
+                                  FROM jsonb_array_elements(COALESCE(p_payload -> 'entries', '[]'::JSONB)) entry)
+          UNION
+          -- Di una transazione eliminata non si conoscono più gli account: avvisa le household del proprietario
+          SELECT hm.user_id
+          FROM household_members hm
+          WHERE p_event = 'transaction.deleted'
+            AND hm.household_id IN (SELECT household_id FROM household_members WHERE user_id = p_user_id)) recipients;
+
+    PERFORM pg_notify('koin_changes', jsonb_build_object('id', p_id,
+                                                         'event', p_event,
+                                                         'resource', p_resource,
+                                                         'resourceId', p_resource_id,
+                                                         'userIds', to_jsonb(v_users))::TEXT);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION webhook_outbox_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM change_notify(NEW.id, NEW.user_id, NEW.event, NEW.resource, NEW.resource_id, NEW.payload);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- L'outbox riceve solo gli eventi degli utenti con almeno un endpoint. Per gli altri la modifica
+-- viene solo notificata, una volta per risorsa e transazione come farebbe il vincolo UNIQUE
+-- dell'outbox: le risorse già notificate sono tenute in koin.notified_changes, che vale fino al
+-- commit. L'id dell'evento viene comunque dalla sequenza dell'outbox, così resta crescente.
+CREATE OR REPLACE FUNCTION webhook_outbox_insert(p_user_id BIGINT, p_resource VARCHAR, p_resource_id BIGINT,
+                                                 p_action VARCHAR, p_payload JSONB) RETURNS VOID AS
+$$
+DECLARE
+    v_notified TEXT := COALESCE(NULLIF(current_setting('koin.notified_changes', TRUE), ''), ',');
+    v_key      TEXT := p_resource || ':' || p_resource_id || ',';
+BEGIN
+    IF EXISTS (SELECT 1 FROM webhook_endpoints WHERE user_id = p_user_id) THEN
+        INSERT INTO webhook_outbox(user_id, event, resource, resource_id, payload)
+        VALUES (p_user_id, p_resource || '.' || p_action, p_resource, p_resource_id, p_payload)
+        ON CONFLICT (tx_id, resource, resource_id) DO NOTHING;
+    ELSIF position(',' || v_key IN v_notified) = 0 THEN
+        PERFORM set_config('koin.notified_changes', v_notified || v_key, TRUE);
+        PERFORM change_notify(nextval(pg_get_serial_sequence('webhook_outbox', 'id')), p_user_id,
+                              p_resource || '.' || p_action, p_resource, p_resource_id, p_payload);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Gli eventi smistati restano nell'outbox solo per il registro degli invii: la retention li
+-- cancella insieme ai loro invii.
+CREATE INDEX webhook_outbox_dispatched_idx ON webhook_outbox (dispatched_at) WHERE dispatched_at IS NOT NULL;
+
+-- Eventi già accumulati per utenti senza endpoint
+DELETE
+FROM webhook_outbox o
+WHERE NOT EXISTS (SELECT 1 FROM webhook_endpoints e WHERE e.user_id = o.user_id)
+  AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.outbox_id = o.id);
@@ -0,0 +1,4 @@
+DROP TRIGGER reconciled_transaction_guard ON transactions;
+DROP FUNCTION reconciled_transaction_guard();
+DROP TRIGGER reconciled_entry_guard ON transaction_entries;
+DROP FUNCTION reconciled_entry_guard();
@@ -0,0 +1,50 @@
+-- Le righe contabili riconciliate appartengono a una riconciliazione completata e non possono
+-- più cambiare: importo, account, descrizione e stato sono quelli confrontati con l'estratto
+-- conto. Restano modificabili categoria e beneficiario (riassegnati quando vengono uniti o
+-- eliminati) e la classificazione fiscale. Il controllo vale per ogni scrittura, non solo per
+-- quelle passate dai servizi; le cancellazioni a cascata (account o utente eliminati) arrivano
+-- dai trigger delle chiavi esterne, con pg_trigger_depth() > 1, e passano.
+-- Lo SQLSTATE KL001 viene tradotto in ErrTransactionLocked dal repository.
+CREATE FUNCTION reconciled_entry_guard() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF OLD.status <> 'RECONCILED' OR pg_trigger_depth() > 1 THEN
+        RETURN COALESCE(NEW, OLD);
+    END IF;
+    IF TG_OP = 'DELETE' OR (NEW.transaction_id, NEW.account_id, NEW.amount, NEW.description, NEW.status,
+                            NEW.reconciliation_id) IS DISTINCT FROM
+                           (OLD.transaction_id, OLD.account_id, OLD.amount, OLD.description, OLD.status,
+                            OLD.reconciliation_id) THEN
+        RAISE EXCEPTION 'transaction % is reconciled and cannot be modified', OLD.transaction_id
+            USING ERRCODE = 'KL001';
+    END IF;
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER reconciled_entry_guard
+    BEFORE UPDATE OR DELETE
+    ON transaction_entries
+    FOR EACH ROW
+EXECUTE FUNCTION reconciled_entry_guard();
+
+-- Una transazione con righe riconciliate non può essere eliminata né spostata di data.
+CREATE FUNCTION reconciled_transaction_guard() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF pg_trigger_depth() > 1 OR (TG_OP = 'UPDATE' AND (NEW.user_id, NEW.occurred_at) IS NOT DISTINCT FROM (OLD.user_id, OLD.occurred_at)) THEN
+        RETURN COALESCE(NEW, OLD);
+    END IF;
+    IF EXISTS (SELECT 1 FROM transaction_entries WHERE transaction_id = OLD.id AND status = 'RECONCILED') THEN
+        RAISE EXCEPTION 'transaction % is reconciled and cannot be modified', OLD.id
+            USING ERRCODE = 'KL001';
+    END IF;
+    RETURN COALESCE(NEW, OLD);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER reconciled_transaction_guard
+    BEFORE UPDATE OR DELETE
+    ON transactions
+    FOR EACH ROW
+EXECUTE FUNCTION reconciled_transaction_guard();
@@ -0,0 +1 @@
+DROP VIEW TRANSACTION_EDIT_ACCESS;
@@ -0,0 +1,17 @@
+-- Per modificare una transazione servono i diritti di scrittura su tutti gli account dei suoi
+-- movimenti: il ruolo è il più basso tra quelli sugli account, VIEWER se uno di essi non è
+-- accessibile. Una transazione senza movimenti resta di chi l'ha registrata.
+CREATE VIEW TRANSACTION_EDIT_ACCESS AS
+SELECT ta.transaction_id,
+       ta.user_id,
+       (CASE MAX(CASE COALESCE(aa.role, CASE WHEN te.id IS NULL THEN 'OWNER' ELSE 'VIEWER' END)
+                     WHEN 'OWNER' THEN 0
+                     WHEN 'EDITOR' THEN 1
+                     ELSE 2 END)
+            WHEN 0 THEN 'OWNER'
+            WHEN 1 THEN 'EDITOR'
+            ELSE 'VIEWER' END)::VARCHAR(10) AS role
+FROM (SELECT DISTINCT transaction_id, user_id FROM transaction_access) ta
+         LEFT JOIN transaction_entries te ON te.transaction_id = ta.transaction_id
+         LEFT JOIN account_access aa ON aa.account_id = te.account_id AND aa.user_id = ta.user_id
+GROUP BY ta.transaction_id, ta.user_id;
@@ -14,28 +14,32 @@ VALUES ($1, $2)
 RETURNING id, email, password_hash, created_at;
 
 -- name: GetAccount :one
+-- A parità di nome l'account dell'utente prevale su quelli condivisi dalla household.
 SELECT a.*
 FROM ACCOUNTS a
-         JOIN USERS u ON a.USER_ID = u.ID
-WHERE a.USER_ID = $1
-  AND a.NAME = $2;
+WHERE a.ID IN (SELECT account_id FROM account_access WHERE user_id = $1)
+  AND a.NAME = $2
+ORDER BY a.USER_ID <> $1, a.ID
+LIMIT 1;
 
 -- name: CreateAccount :one
 INSERT INTO ACCOUNTS(USER_ID, NAME, CURRENCY, INITIAL_BALANCE)
 VALUES ($1, $2, $3, $4)
-RETURNING ID, USER_ID, NAME, CURRENCY, INITIAL_BALANCE;
+RETURNING *;
 
 -- name: GetCategory :one
 SELECT *
 FROM category
-WHERE user_id = $1
+WHERE id IN (SELECT category_id FROM category_access WHERE user_id = $1)
   AND name = $2
-  AND "type" = $3;
+  AND "type" = $3
+ORDER BY user_id <> $1, id
+LIMIT 1;
 
 -- name: CreateCategory :one
-INSERT INTO category(user_id, name, "type")
-VALUES ($1, $2, $3)
-RETURNING id, user_id, name, "type";
+INSERT INTO category(user_id, name, "type", parent_id)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
 
 -- name: GetAccountBalance :one
 SELECT COALESCE(SUM(te.amount), 0)::BIGINT AS balance
@@ -54,37 +58,1886 @@ INSERT INTO TRANSACTION_ENTRIES(transaction_id,
                                 account_id,
                                 category_id,
                                 amount,
-                                description)
+                                description,
+                                payee_id)
 VALUES ($1,
         $2,
         $3,
         $4,
-        $5);
+        $5,
+        $6);
 
 -- name: GetAccountsByUser :many
-SELECT id, user_id, name, currency, initial_balance
+SELECT *
 FROM ACCOUNTS
-WHERE user_id = $1
+WHERE id IN (SELECT account_id FROM account_access WHERE user_id = $1)
 ORDER BY id;
 
 -- name: GetCategoriesByUser :many
-SELECT id, user_id, name, "type"
+SELECT *
 FROM category
-WHERE user_id = $1
+WHERE id IN (SELECT category_id FROM category_access WHERE user_id = $1)
 ORDER BY id;
 
 -- name: GetRecentTransactionEntriesByUser :many
 SELECT t.id AS transaction_id,
+       te.id AS entry_id,
        t.occurred_at,
        a.name AS account_name,
        c.name AS category_name,
        c."type" AS category_type,
        te.amount,
-       te.description
+       te.description,
+       p.name AS payee_name,
+       te.status,
+       COALESCE((SELECT string_agg(tg.name, ',' ORDER BY tg.name)
+                 FROM transaction_entry_tags tet
+                          JOIN tags tg ON tg.id = tet.tag_id
+                 WHERE tet.entry_id = te.id), '')::TEXT AS tags
 FROM transactions t
          JOIN transaction_entries te ON te.transaction_id = t.id
          JOIN accounts a ON a.id = te.account_id
          LEFT JOIN category c ON c.id = te.category_id
-WHERE t.user_id = $1
+         LEFT JOIN payees p ON p.id = te.payee_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND (sqlc.narg(tag)::TEXT IS NULL OR EXISTS (SELECT 1
+                                               FROM transaction_entry_tags tet
+                                                        JOIN tags tg ON tg.id = tet.tag_id
+                                               WHERE tet.entry_id = te.id
+                                                 AND tg.name = sqlc.narg(tag)::TEXT))
 ORDER BY t.occurred_at DESC, te.id DESC
-LIMIT $2;
+LIMIT sqlc.arg('limit')::INT;
+
+-- name: GetCategoryByID :one
+SELECT *
+FROM category
+WHERE id = $1
+  AND id IN (SELECT category_id FROM category_access WHERE user_id = $2);
+
+-- name: RenameCategory :one
+UPDATE category
+SET name = $3
+WHERE id = $1
+  AND id IN (SELECT category_id FROM category_access WHERE user_id = $2 AND role <> 'VIEWER')
+RETURNING *;
+
+-- name: SetCategoryParent :one
+UPDATE category
+SET parent_id = $3
+WHERE id = $1
+  AND id IN (SELECT category_id FROM category_access WHERE user_id = $2 AND role <> 'VIEWER')
+RETURNING *;
+
+-- name: ArchiveCategory :one
+UPDATE category
+SET archived_at = COALESCE(archived_at, NOW())
+WHERE id = $1
+  AND id IN (SELECT category_id FROM category_access WHERE user_id = $2 AND role <> 'VIEWER')
+RETURNING *;
+
+-- name: UnarchiveCategory :one
+UPDATE category
+SET archived_at = NULL
+WHERE id = $1
+  AND id IN (SELECT category_id FROM category_access WHERE user_id = $2 AND role <> 'VIEWER')
+RETURNING *;
+
+-- name: ReassignCategoryEntries :execrows
+UPDATE transaction_entries
+SET category_id = sqlc.arg(target_category_id)::BIGINT
+WHERE category_id = sqlc.arg(source_category_id)::BIGINT;
+
+-- name: ReparentCategoryChildren :exec
+UPDATE category
+SET parent_id = sqlc.arg(target_category_id)::BIGINT
+WHERE parent_id = sqlc.arg(source_category_id)::BIGINT
+  AND id <> sqlc.arg(target_category_id)::BIGINT;
+
+-- name: DeleteCategory :exec
+DELETE
+FROM category
+WHERE id = $1
+  AND id IN (SELECT category_id FROM category_access WHERE user_id = $2 AND role <> 'VIEWER');
+
+-- name: GetCategoryTotalsByUser :many
+SELECT c.id,
+       c.name,
+       c."type",
+       c.parent_id,
+       COALESCE(totals.total, 0)::BIGINT AS total
+FROM category c
+         LEFT JOIN (SELECT te.category_id,
+                           SUM(na.amount) AS total
+                    FROM transaction_entries te
+                             JOIN transactions t ON t.id = te.transaction_id
+                             JOIN entry_net_amounts na ON na.entry_id = te.id
+                    WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+                      AND t.occurred_at >= sqlc.arg(date_from)::DATE
+                      AND t.occurred_at <= sqlc.arg(date_to)::DATE
+                      AND (sqlc.narg(tag)::TEXT IS NULL OR EXISTS (SELECT 1
+                                                                   FROM transaction_entry_tags tet
+                                                                            JOIN tags tg ON tg.id = tet.tag_id
+                                                                   WHERE tet.entry_id = te.id
+                                                                     AND tg.name = sqlc.narg(tag)::TEXT))
+                    GROUP BY te.category_id) totals ON totals.category_id = c.id
+WHERE c.id IN (SELECT category_id FROM category_access WHERE user_id = sqlc.arg(user_id))
+ORDER BY c.id;
+
+-- name: GetTransactionByID :one
+SELECT *
+FROM transactions
+WHERE id = $1
+  AND id IN (SELECT transaction_id FROM transaction_access WHERE user_id = $2);
+
+-- name: GetTagsByUser :many
+SELECT *
+FROM tags
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: GetTagByID :one
+SELECT *
+FROM tags
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetTagByName :one
+SELECT *
+FROM tags
+WHERE user_id = $1
+  AND name = $2;
+
+-- name: CreateTag :one
+INSERT INTO tags(user_id, name)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetOrCreateTag :one
+INSERT INTO tags(user_id, name)
+VALUES ($1, $2)
+ON CONFLICT (user_id, name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: RenameTag :one
+UPDATE tags
+SET name = $3
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: DeleteTag :exec
+DELETE
+FROM tags
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: MoveTagEntries :execrows
+INSERT INTO transaction_entry_tags(entry_id, tag_id)
+SELECT tet.entry_id, sqlc.arg(target_tag_id)::BIGINT
+FROM transaction_entry_tags tet
+WHERE tet.tag_id = sqlc.arg(source_tag_id)::BIGINT
+ON CONFLICT DO NOTHING;
+
+-- name: ClearTransactionTags :exec
+DELETE
+FROM transaction_entry_tags
+WHERE entry_id IN (SELECT te.id
+                   FROM transaction_entries te
+                   WHERE te.transaction_id = $1);
+
+-- name: TagTransactionEntries :exec
+INSERT INTO transaction_entry_tags(entry_id, tag_id)
+SELECT te.id, sqlc.arg(tag_id)::BIGINT
+FROM transaction_entries te
+WHERE te.transaction_id = sqlc.arg(transaction_id)::BIGINT
+ON CONFLICT DO NOTHING;
+
+-- name: GetTransactionTags :many
+SELECT DISTINCT tg.*
+FROM tags tg
+         JOIN transaction_entry_tags tet ON tet.tag_id = tg.id
+         JOIN transaction_entries te ON te.id = tet.entry_id
+WHERE te.transaction_id = $1
+ORDER BY tg.name;
+
+-- name: GetTagTotalsByUser :many
+SELECT tg.id,
+       tg.name,
+       COUNT(te.id)::BIGINT AS entries,
+       COALESCE(SUM(na.amount) FILTER (WHERE te.amount > 0), 0)::BIGINT AS income,
+       COALESCE(SUM(na.amount) FILTER (WHERE te.amount < 0), 0)::BIGINT AS expense,
+       COALESCE(SUM(na.amount), 0)::BIGINT AS total
+FROM tags tg
+         LEFT JOIN transaction_entry_tags tet ON tet.tag_id = tg.id
+         LEFT JOIN transaction_entries te ON te.id = tet.entry_id
+    AND te.category_id IS NOT NULL
+    AND te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+    AND te.transaction_id IN (SELECT t.id
+                              FROM transactions t
+                              WHERE t.occurred_at >= sqlc.arg(date_from)::DATE
+                                AND t.occurred_at <= sqlc.arg(date_to)::DATE)
+         LEFT JOIN entry_net_amounts na ON na.entry_id = te.id
+WHERE tg.user_id = sqlc.arg(user_id)
+GROUP BY tg.id, tg.name
+ORDER BY tg.name;
+
+-- name: GetPayeesByUser :many
+SELECT *
+FROM payees
+WHERE user_id = $1
+ORDER BY name;
+
+-- name: GetPayeeByID :one
+SELECT *
+FROM payees
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetPayeeByName :one
+SELECT *
+FROM payees
+WHERE user_id = $1
+  AND name = $2;
+
+-- name: CreatePayee :one
+INSERT INTO payees(user_id, name, default_category_id)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: UpdatePayee :one
+UPDATE payees
+SET name                = $3,
+    default_category_id = $4
+WHERE id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: DeletePayee :exec
+DELETE
+FROM payees
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: GetPayeeAliasesByUser :many
+SELECT pa.*
+FROM payee_aliases pa
+         JOIN payees p ON p.id = pa.payee_id
+WHERE p.user_id = $1
+ORDER BY pa.payee_id, pa.pattern;
+
+-- name: AddPayeeAlias :one
+INSERT INTO payee_aliases(payee_id, pattern)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: DeletePayeeAlias :execrows
+DELETE
+FROM payee_aliases
+WHERE id = $1
+  AND payee_id = $2;
+
+-- name: DetectPayee :one
+-- Cerca il beneficiario il cui nome o alias compare nella descrizione; vince la corrispondenza più lunga.
+SELECT p.*
+FROM payees p
+         LEFT JOIN payee_aliases pa ON pa.payee_id = p.id
+WHERE p.user_id = sqlc.arg(user_id)
+  AND (POSITION(LOWER(p.name) IN LOWER(sqlc.arg(description)::TEXT)) > 0
+    OR POSITION(LOWER(pa.pattern) IN LOWER(sqlc.arg(description)::TEXT)) > 0)
+ORDER BY GREATEST(
+                 CASE WHEN POSITION(LOWER(p.name) IN LOWER(sqlc.arg(description)::TEXT)) > 0 THEN LENGTH(p.name) ELSE 0 END,
+                 CASE WHEN POSITION(LOWER(pa.pattern) IN LOWER(sqlc.arg(description)::TEXT)) > 0 THEN LENGTH(pa.pattern) ELSE 0 END
+         ) DESC, p.id
+LIMIT 1;
+
+-- name: GetPayeeTotalsByUser :many
+SELECT p.id,
+       p.name,
+       COUNT(te.id)::BIGINT AS entries,
+       COALESCE(SUM(na.amount), 0)::BIGINT AS total
+FROM payees p
+         LEFT JOIN transaction_entries te ON te.payee_id = p.id
+    AND te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+    AND te.transaction_id IN (SELECT t.id
+                              FROM transactions t
+                              WHERE t.occurred_at >= sqlc.arg(date_from)::DATE
+                                AND t.occurred_at <= sqlc.arg(date_to)::DATE)
+         LEFT JOIN entry_net_amounts na ON na.entry_id = te.id
+WHERE p.user_id = sqlc.arg(user_id)
+GROUP BY p.id, p.name
+ORDER BY total, p.name;
+
+-- name: DeleteTransaction :execrows
+DELETE
+FROM transactions
+WHERE id = $1
+  AND id IN (SELECT transaction_id FROM transaction_edit_access WHERE user_id = $2 AND role <> 'VIEWER');
+
+-- name: CreateAttachment :one
+INSERT INTO attachments(user_id, transaction_id, file_name, content_type, size_bytes, storage_key, thumbnail_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetAttachmentsByTransaction :many
+SELECT *
+FROM attachments
+WHERE transaction_id = $1
+  AND transaction_id IN (SELECT transaction_id FROM transaction_access WHERE user_id = $2)
+ORDER BY created_at, id;
+
+-- name: GetAttachmentByID :one
+SELECT *
+FROM attachments
+WHERE id = $1
+  AND transaction_id IN (SELECT transaction_id FROM transaction_access WHERE user_id = $2);
+
+-- name: DeleteAttachment :exec
+DELETE
+FROM attachments
+WHERE id = $1
+  AND transaction_id IN (SELECT transaction_id FROM transaction_edit_access WHERE user_id = $2 AND role <> 'VIEWER');
+
+-- name: GetAccountByID :one
+SELECT *
+FROM accounts
+WHERE id = $1
+  AND id IN (SELECT account_id FROM account_access WHERE user_id = $2);
+
+-- name: IsTransactionReconciled :one
+SELECT EXISTS (SELECT 1
+               FROM transaction_entries
+               WHERE transaction_id = $1
+                 AND status = 'RECONCILED')::BOOLEAN AS reconciled;
+
+-- name: CreateReconciliation :one
+INSERT INTO reconciliations(account_id, statement_date, statement_balance)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetReconciliationByID :one
+SELECT *
+FROM reconciliations
+WHERE id = $1
+  AND account_id IN (SELECT account_id FROM account_access WHERE user_id = $2);
+
+-- name: GetReconciliationsByAccount :many
+SELECT *
+FROM reconciliations
+WHERE account_id = $1
+ORDER BY statement_date DESC, id DESC;
+
+-- name: GetReconciliationEntries :many
+-- Movimenti dell'account fino alla data dell'estratto conto non ancora riconciliati.
+SELECT te.id AS entry_id,
+       t.id AS transaction_id,
+       t.occurred_at,
+       te.amount,
+       te.description,
+       te.status,
+       c.name AS category_name,
+       p.name AS payee_name
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         LEFT JOIN category c ON c.id = te.category_id
+         LEFT JOIN payees p ON p.id = te.payee_id
+WHERE te.account_id = sqlc.arg(account_id)
+  AND te.status <> 'RECONCILED'
+  AND t.occurred_at <= sqlc.arg(statement_date)::DATE
+ORDER BY t.occurred_at, te.id;
+
+-- name: GetClearedBalance :one
+SELECT COALESCE(SUM(te.amount), 0)::BIGINT AS balance
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+WHERE te.account_id = sqlc.arg(account_id)
+  AND te.status IN ('CLEARED', 'RECONCILED')
+  AND t.occurred_at <= sqlc.arg(statement_date)::DATE;
+
+-- name: GetReconciledBalance :one
+-- Saldo dei movimenti riconciliati fino alla data dell'estratto conto: dentro la transazione che
+-- chiude la riconciliazione comprende quelli appena riconciliati, bloccati fino al commit.
+SELECT COALESCE(SUM(te.amount), 0)::BIGINT AS balance
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+WHERE te.account_id = sqlc.arg(account_id)
+  AND te.status = 'RECONCILED'
+  AND t.occurred_at <= sqlc.arg(statement_date)::DATE;
+
+-- name: SetEntryStatus :execrows
+UPDATE transaction_entries
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+  AND account_id = sqlc.arg(account_id)
+  AND status <> 'RECONCILED';
+
+-- name: ReconcileClearedEntries :execrows
+UPDATE transaction_entries te
+SET status            = 'RECONCILED',
+    reconciliation_id = sqlc.arg(reconciliation_id)::BIGINT
+FROM transactions t
+WHERE t.id = te.transaction_id
+  AND te.account_id = sqlc.arg(account_id)
+  AND te.status = 'CLEARED'
+  AND t.occurred_at <= sqlc.arg(statement_date)::DATE;
+
+-- name: GetReconciliationForUpdate :one
+SELECT *
+FROM reconciliations
+WHERE id = $1
+    FOR UPDATE;
+
+-- name: CompleteReconciliation :one
+UPDATE reconciliations
+SET status       = 'COMPLETED',
+    completed_at = NOW()
+WHERE id = $1
+  AND status = 'IN_PROGRESS'
+RETURNING *;
+
+-- name: DeleteReconciliation :execrows
+DELETE
+FROM reconciliations
+WHERE id = $1
+  AND status = 'IN_PROGRESS';
+
+-- name: UpsertCreditCard :one
+INSERT INTO credit_cards(account_id, payment_account_id, closing_day, due_day, auto_pay)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (account_id) DO UPDATE
+    SET payment_account_id = EXCLUDED.payment_account_id,
+        closing_day        = EXCLUDED.closing_day,
+        due_day            = EXCLUDED.due_day,
+        auto_pay           = EXCLUDED.auto_pay
+RETURNING *;
+
+-- name: GetCreditCard :one
+SELECT *
+FROM credit_cards
+WHERE account_id = $1
+  AND account_id IN (SELECT account_id FROM account_access WHERE user_id = $2);
+
+-- name: GetCreditCardsByUser :many
+SELECT cc.*
+FROM credit_cards cc
+         JOIN accounts a ON a.id = cc.account_id
+WHERE a.id IN (SELECT account_id FROM account_access WHERE user_id = $1)
+ORDER BY a.name;
+
+-- name: GetAutoPayCreditCards :many
+SELECT cc.*, a.user_id
+FROM credit_cards cc
+         JOIN accounts a ON a.id = cc.account_id
+WHERE cc.auto_pay
+ORDER BY cc.account_id;
+
+-- name: DeleteCreditCard :execrows
+DELETE
+FROM credit_cards
+WHERE account_id = $1;
+
+-- name: GetCreditCardForUpdate :one
+SELECT *
+FROM credit_cards
+WHERE account_id = $1
+    FOR UPDATE;
+
+-- name: GetCreditCardMovements :many
+-- Movimenti della carta dalla data indicata: i trasferimenti in entrata sono i pagamenti del saldo.
+SELECT t.occurred_at,
+       te.amount,
+       (te.category_id IS NULL AND te.amount > 0)::BOOLEAN AS is_payment
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+WHERE te.account_id = sqlc.arg(account_id)
+  AND t.occurred_at > sqlc.arg(date_from)::DATE
+ORDER BY t.occurred_at, te.id;
+
+-- name: CreateLoan :one
+INSERT INTO loans(account_id, payment_account_id, principal, annual_rate_bps, term_months, start_date, method)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: CreateLoanInstallment :exec
+INSERT INTO loan_installments(account_id, number, due_date, principal, interest)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetLoan :one
+SELECT *
+FROM loans
+WHERE account_id = $1
+  AND account_id IN (SELECT account_id FROM account_access WHERE user_id = $2);
+
+-- name: GetLoansByUser :many
+SELECT l.*
+FROM loans l
+         JOIN accounts a ON a.id = l.account_id
+WHERE a.id IN (SELECT account_id FROM account_access WHERE user_id = $1)
+ORDER BY a.name;
+
+-- name: GetLoansWithDueInstallments :many
+SELECT l.*, a.user_id
+FROM loans l
+         JOIN accounts a ON a.id = l.account_id
+WHERE EXISTS (SELECT 1
+              FROM loan_installments li
+              WHERE li.account_id = l.account_id
+                AND li.transaction_id IS NULL
+                AND li.due_date <= sqlc.arg(due_date)::DATE)
+ORDER BY l.account_id;
+
+-- name: GetLoanInstallments :many
+SELECT *
+FROM loan_installments
+WHERE account_id = $1
+ORDER BY number;
+
+-- name: SetInstallmentTransaction :execrows
+UPDATE loan_installments
+SET transaction_id = sqlc.arg(transaction_id)::BIGINT
+WHERE id = sqlc.arg(id)
+  AND transaction_id IS NULL;
+
+-- name: CreateSecurity :one
+INSERT INTO securities(user_id, isin, ticker, name, currency)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetSecuritiesByUser :many
+-- Titoli dell'utente e quelli movimentati sugli account condivisi con lui.
+SELECT *
+FROM securities
+WHERE user_id = $1
+   OR id IN (SELECT it.security_id
+             FROM investment_trades it
+             WHERE it.account_id IN (SELECT account_id FROM account_access WHERE user_id = $1))
+ORDER BY name;
+
+-- name: GetSecurityByID :one
+SELECT *
+FROM securities
+WHERE id = $1
+  AND (user_id = $2
+    OR id IN (SELECT it.security_id
+              FROM investment_trades it
+              WHERE it.account_id IN (SELECT account_id FROM account_access WHERE user_id = $2)));
+
+-- name: GetSecurityByIdentifier :one
+SELECT *
+FROM securities
+WHERE user_id = sqlc.arg(user_id)
+  AND (isin = sqlc.arg(identifier)::TEXT OR ticker = sqlc.arg(identifier)::TEXT)
+LIMIT 1;
+
+-- name: UpsertSecurityPrice :exec
+INSERT INTO security_prices(security_id, price_date, price)
+VALUES ($1, $2, $3)
+ON CONFLICT (security_id, price_date) DO UPDATE
+    SET price = EXCLUDED.price;
+
+-- name: GetSecurityPrices :many
+SELECT *
+FROM security_prices
+WHERE security_id = sqlc.arg(security_id)
+  AND price_date BETWEEN sqlc.arg(date_from)::DATE AND sqlc.arg(date_to)::DATE
+ORDER BY price_date;
+
+-- name: GetLatestSecurityPrices :many
+-- Ultimo prezzo disponibile entro la data indicata per ogni titolo dell'utente.
+SELECT DISTINCT ON (sp.security_id) sp.*
+FROM security_prices sp
+         JOIN securities s ON s.id = sp.security_id
+WHERE (s.user_id = sqlc.arg(user_id)
+    OR s.id IN (SELECT it.security_id
+                FROM investment_trades it
+                WHERE it.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))))
+  AND sp.price_date <= sqlc.arg(as_of)::DATE
+ORDER BY sp.security_id, sp.price_date DESC;
+
+-- name: CreateInvestmentTrade :one
+INSERT INTO investment_trades(account_id, security_id, transaction_id, kind, trade_date, quantity, price, fees, amount)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetInvestmentTradesByUser :many
+SELECT *
+FROM investment_trades
+WHERE account_id IN (SELECT account_id FROM account_access WHERE user_id = $1)
+ORDER BY trade_date, id;
+
+-- name: GetAccountRole :one
+-- Ruolo più ampio con cui l'utente accede all'account.
+SELECT role::TEXT
+FROM account_access
+WHERE account_id = $1
+  AND user_id = $2
+ORDER BY CASE role WHEN 'OWNER' THEN 0 WHEN 'EDITOR' THEN 1 ELSE 2 END
+LIMIT 1;
+
+-- name: GetCategoryRole :one
+SELECT role::TEXT
+FROM category_access
+WHERE category_id = $1
+  AND user_id = $2
+ORDER BY CASE role WHEN 'OWNER' THEN 0 WHEN 'EDITOR' THEN 1 ELSE 2 END
+LIMIT 1;
+
+-- name: GetTransactionRole :one
+SELECT role::TEXT
+FROM transaction_edit_access
+WHERE transaction_id = $1
+  AND user_id = $2;
+
+-- name: CreateHousehold :one
+INSERT INTO households(name)
+VALUES ($1)
+RETURNING *;
+
+-- name: GetHouseholdByID :one
+SELECT *
+FROM households
+WHERE id = $1;
+
+-- name: GetHouseholdsByUser :many
+SELECT h.*, hm.role
+FROM households h
+         JOIN household_members hm ON hm.household_id = h.id
+WHERE hm.user_id = $1
+ORDER BY h.name, h.id;
+
+-- name: DeleteHousehold :exec
+DELETE
+FROM households
+WHERE id = $1;
+
+-- name: AddHouseholdMember :one
+INSERT INTO household_members(household_id, user_id, role)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetHouseholdMember :one
+SELECT *
+FROM household_members
+WHERE household_id = $1
+  AND user_id = $2;
+
+-- name: GetHouseholdMembers :many
+SELECT hm.*, u.email
+FROM household_members hm
+         JOIN users u ON u.id = hm.user_id
+WHERE hm.household_id = $1
+ORDER BY hm.joined_at, hm.user_id;
+
+-- name: UpdateHouseholdMemberRole :one
+UPDATE household_members
+SET role = $3
+WHERE household_id = $1
+  AND user_id = $2
+RETURNING *;
+
+-- name: DeleteHouseholdMember :execrows
+DELETE
+FROM household_members
+WHERE household_id = $1
+  AND user_id = $2;
+
+-- name: CountHouseholdMembers :one
+SELECT COUNT(*) FILTER (WHERE role = 'OWNER')::BIGINT AS owners,
+       COUNT(*)::BIGINT                               AS members
+FROM household_members
+WHERE household_id = $1;
+
+-- name: UnshareMemberAccounts :exec
+UPDATE accounts
+SET household_id = NULL
+WHERE household_id = $1
+  AND user_id = $2;
+
+-- name: UnshareMemberCategories :exec
+UPDATE category
+SET household_id = NULL
+WHERE household_id = $1
+  AND user_id = $2;
+
+-- name: CreateHouseholdInvite :one
+INSERT INTO household_invites(household_id, code, email, role, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetHouseholdInviteByCode :one
+SELECT *
+FROM household_invites
+WHERE code = $1;
+
+-- name: GetPendingHouseholdInvites :many
+SELECT *
+FROM household_invites
+WHERE household_id = $1
+  AND accepted_at IS NULL
+  AND expires_at > NOW()
+ORDER BY created_at, id;
+
+-- name: AcceptHouseholdInvite :execrows
+UPDATE household_invites
+SET accepted_by = sqlc.arg(accepted_by),
+    accepted_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND accepted_at IS NULL
+  AND expires_at > NOW();
+
+-- name: DeleteHouseholdInvite :execrows
+DELETE
+FROM household_invites
+WHERE id = $1
+  AND household_id = $2
+  AND accepted_at IS NULL;
+
+-- name: SetAccountHousehold :one
+UPDATE accounts
+SET household_id = sqlc.narg(household_id)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetCategoryHousehold :one
+UPDATE category
+SET household_id = sqlc.narg(household_id)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CreateSplitGroup :one
+INSERT INTO split_groups(name, currency, created_by)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetSplitGroupByID :one
+SELECT *
+FROM split_groups
+WHERE id = $1;
+
+-- name: GetSplitGroupsByUser :many
+SELECT g.*
+FROM split_groups g
+         JOIN split_members sm ON sm.group_id = g.id
+WHERE sm.user_id = $1
+ORDER BY g.created_at DESC, g.id DESC;
+
+-- name: AddSplitMember :one
+INSERT INTO split_members(group_id, user_id, name)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetSplitMembers :many
+SELECT *
+FROM split_members
+WHERE group_id = $1
+ORDER BY id;
+
+-- name: GetSplitMemberByUser :one
+SELECT *
+FROM split_members
+WHERE group_id = $1
+  AND user_id = $2;
+
+-- Importo e valuta della parte di spesa (movimenti con categoria) di una transazione.
+-- name: GetTransactionExpense :one
+SELECT COALESCE(-SUM(te.amount), 0)::BIGINT    AS amount,
+       COALESCE(MIN(a.currency), '')::TEXT     AS currency,
+       COUNT(DISTINCT a.currency)::BIGINT      AS currencies
+FROM transaction_entries te
+         JOIN accounts a ON a.id = te.account_id
+WHERE te.transaction_id = $1
+  AND te.category_id IS NOT NULL;
+
+-- Una transazione può essere divisa una sola volta: se è già divisa non restituisce righe.
+-- name: CreateSplitExpense :one
+INSERT INTO split_expenses(group_id, transaction_id, paid_by, amount, method)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (transaction_id) DO NOTHING
+RETURNING *;
+
+-- name: AddSplitShare :exec
+INSERT INTO split_shares(expense_id, member_id, shares, amount)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetSplitExpenses :many
+SELECT se.*,
+       t.occurred_at,
+       COALESCE((SELECT te.description
+                 FROM transaction_entries te
+                 WHERE te.transaction_id = se.transaction_id
+                   AND te.description IS NOT NULL
+                 ORDER BY te.id
+                 LIMIT 1), '')::TEXT AS description
+FROM split_expenses se
+         JOIN transactions t ON t.id = se.transaction_id
+WHERE se.group_id = $1
+ORDER BY t.occurred_at DESC, se.id DESC;
+
+-- name: GetSplitSharesByGroup :many
+SELECT ss.*
+FROM split_shares ss
+         JOIN split_expenses se ON se.id = ss.expense_id
+WHERE se.group_id = $1
+ORDER BY ss.expense_id, ss.member_id;
+
+-- name: GetSplitExpense :one
+SELECT *
+FROM split_expenses
+WHERE id = $1
+  AND group_id = $2;
+
+-- name: DeleteSplitExpense :execrows
+DELETE
+FROM split_expenses
+WHERE id = $1
+  AND group_id = $2;
+
+-- name: CreateSplitSettlement :one
+INSERT INTO split_settlements(group_id, from_member_id, to_member_id, amount, settled_at, from_transaction_id,
+                              to_transaction_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetSplitSettlements :many
+SELECT *
+FROM split_settlements
+WHERE group_id = $1
+ORDER BY settled_at DESC, id DESC;
+
+-- name: GetSplitSettlement :one
+SELECT *
+FROM split_settlements
+WHERE id = $1
+  AND group_id = $2;
+
+-- name: GetSplitSettlementForUpdate :one
+SELECT *
+FROM split_settlements
+WHERE id = $1
+    FOR UPDATE;
+
+-- name: SetSplitSettlementTransactions :one
+-- Collega il movimento di chi conferma il pagamento, senza sostituire quelli già presenti.
+UPDATE split_settlements
+SET from_transaction_id = COALESCE(from_transaction_id, sqlc.narg(from_transaction_id)),
+    to_transaction_id   = COALESCE(to_transaction_id, sqlc.narg(to_transaction_id))
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetTransactionEntry :one
+SELECT *
+FROM transaction_entries
+WHERE id = $1
+  AND account_id IN (SELECT account_id FROM account_access WHERE user_id = $2);
+
+-- name: MarkReimbursable :one
+INSERT INTO reimbursables(entry_id, user_id, party)
+VALUES ($1, $2, $3)
+ON CONFLICT (entry_id) DO UPDATE SET party = EXCLUDED.party
+RETURNING *;
+
+-- name: GetReimbursableForUpdate :one
+SELECT *
+FROM reimbursables
+WHERE entry_id = $1
+    FOR UPDATE;
+
+-- name: DeleteReimbursable :execrows
+DELETE
+FROM reimbursables
+WHERE entry_id = $1;
+
+-- Spese rimborsabili visibili all'utente con l'importo già rimborsato; senza include_settled
+-- solo quelle ancora da rimborsare.
+-- name: GetReimbursableEntries :many
+WITH entries AS (SELECT r.entry_id,
+                        r.party,
+                        te.transaction_id,
+                        t.occurred_at,
+                        a.name                                            AS account_name,
+                        a.currency,
+                        COALESCE(te.description, '')::TEXT                AS description,
+                        -te.amount                                        AS amount,
+                        COALESCE((SELECT SUM(rb.amount)
+                                  FROM reimbursements rb
+                                  WHERE rb.expense_entry_id = r.entry_id), 0)::BIGINT AS reimbursed
+                 FROM reimbursables r
+                          JOIN transaction_entries te ON te.id = r.entry_id
+                          JOIN transactions t ON t.id = te.transaction_id
+                          JOIN accounts a ON a.id = te.account_id
+                 WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+                   AND (sqlc.narg(entry_id)::BIGINT IS NULL OR r.entry_id = sqlc.narg(entry_id)::BIGINT)
+                   AND (sqlc.narg(party)::TEXT IS NULL OR r.party = sqlc.narg(party)::TEXT))
+SELECT *
+FROM entries
+WHERE sqlc.arg(include_settled)::BOOLEAN
+   OR reimbursed < amount
+ORDER BY occurred_at, entry_id;
+
+-- name: GetReimbursementsByUser :many
+SELECT rb.*
+FROM reimbursements rb
+         JOIN transaction_entries te ON te.id = rb.expense_entry_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = $1)
+ORDER BY rb.expense_entry_id, rb.id;
+
+-- name: GetReimbursedAmount :one
+SELECT COALESCE(SUM(amount), 0)::BIGINT
+FROM reimbursements
+WHERE expense_entry_id = $1;
+
+-- name: GetAllocatedIncome :one
+SELECT COALESCE(SUM(amount), 0)::BIGINT
+FROM reimbursements
+WHERE income_entry_id = $1;
+
+-- name: AddReimbursement :one
+INSERT INTO reimbursements(expense_entry_id, income_entry_id, amount)
+VALUES ($1, $2, $3)
+ON CONFLICT (expense_entry_id, income_entry_id) DO UPDATE SET amount = reimbursements.amount + EXCLUDED.amount
+RETURNING *;
+
+-- name: GetReimbursement :one
+SELECT rb.*
+FROM reimbursements rb
+         JOIN transaction_entries te ON te.id = rb.expense_entry_id
+WHERE rb.id = $1
+  AND te.account_id IN (SELECT account_id FROM account_access WHERE user_id = $2);
+
+-- name: DeleteReimbursement :execrows
+DELETE
+FROM reimbursements
+WHERE id = $1;
+
+-- name: SetAccountOverdraft :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateRecurringTransaction :one
+INSERT INTO recurring_transactions(user_id, account_id, category_id, amount, description, frequency, repeat_every,
+                                   start_date, end_date)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetRecurringTransactionsByUser :many
+-- Ricorrenze degli account visibili all'utente, comprese quelle create dagli altri membri della household.
+SELECT rt.*, a.name AS account_name, c.name AS category_name
+FROM recurring_transactions rt
+         JOIN accounts a ON a.id = rt.account_id
+         JOIN category c ON c.id = rt.category_id
+WHERE rt.account_id IN (SELECT account_id FROM account_access WHERE user_id = $1)
+ORDER BY rt.start_date, rt.id;
+
+-- name: GetRecurringTransaction :one
+SELECT rt.*
+FROM recurring_transactions rt
+WHERE rt.id = $1
+  AND rt.account_id IN (SELECT account_id FROM account_access WHERE user_id = $2);
+
+-- name: DeleteRecurringTransaction :execrows
+DELETE
+FROM recurring_transactions
+WHERE id = $1;
+
+-- name: GetDiscretionarySpending :many
+-- Spese per account e categoria nel periodo, al netto dei rimborsi: sono escluse le categorie
+-- coperte da una ricorrenza, le rate dei prestiti e le operazioni su titoli, che la previsione
+-- considera a parte.
+SELECT te.account_id,
+       te.category_id,
+       c.name                    AS category_name,
+       (-SUM(na.amount))::BIGINT AS total
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN entry_net_amounts na ON na.entry_id = te.id
+         JOIN category c ON c.id = te.category_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND c."type" = 'EXPENSE'
+  AND t.occurred_at >= sqlc.arg(date_from)::DATE
+  AND t.occurred_at <= sqlc.arg(date_to)::DATE
+  AND NOT EXISTS (SELECT 1
+                  FROM recurring_transactions rt
+                  WHERE rt.account_id = te.account_id
+                    AND rt.category_id = te.category_id)
+  AND NOT EXISTS (SELECT 1 FROM loan_installments li WHERE li.transaction_id = t.id)
+  AND NOT EXISTS (SELECT 1 FROM investment_trades it WHERE it.transaction_id = t.id)
+GROUP BY te.account_id, te.category_id, c.name
+HAVING SUM(na.amount) < 0
+ORDER BY te.account_id, total DESC;
+
+-- name: GetSubscriptionCandidates :many
+-- Spese degli account visibili all'utente da date_from, in ordine cronologico, tra cui cercare gli
+-- abbonamenti; rate dei prestiti e operazioni su titoli hanno già un loro piano e sono escluse.
+SELECT te.id,
+       te.account_id,
+       a.name                             AS account_name,
+       a.currency,
+       te.category_id,
+       c.name                             AS category_name,
+       te.payee_id,
+       p.name                             AS payee_name,
+       COALESCE(te.description, '')::TEXT AS description,
+       t.occurred_at,
+       (-te.amount)::BIGINT               AS amount
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN accounts a ON a.id = te.account_id
+         JOIN category c ON c.id = te.category_id
+         LEFT JOIN payees p ON p.id = te.payee_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND c."type" = 'EXPENSE'
+  AND te.amount < 0
+  AND t.occurred_at >= sqlc.arg(date_from)::DATE
+  AND NOT EXISTS (SELECT 1 FROM loan_installments li WHERE li.transaction_id = t.id)
+  AND NOT EXISTS (SELECT 1 FROM investment_trades it WHERE it.transaction_id = t.id)
+ORDER BY t.occurred_at, te.id;
+
+-- name: GetUsersToScanForAnomalies :many
+-- Utenti con movimenti successivi all'ultimo controllo delle anomalie.
+SELECT u.*
+FROM users u
+WHERE EXISTS (SELECT 1
+              FROM transaction_entries te
+              WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = u.id)
+                AND te.id > COALESCE((SELECT s.last_entry_id FROM anomaly_scans s WHERE s.user_id = u.id), 0))
+ORDER BY u.id;
+
+-- name: GetAnomalyWatermark :one
+SELECT COALESCE((SELECT last_entry_id FROM anomaly_scans WHERE user_id = $1), 0)::BIGINT;
+
+-- name: GetLatestEntryID :one
+SELECT COALESCE(MAX(te.id), 0)::BIGINT
+FROM transaction_entries te
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = $1);
+
+-- name: SetAnomalyWatermark :exec
+INSERT INTO anomaly_scans(user_id, last_entry_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET last_entry_id = EXCLUDED.last_entry_id,
+                                    scanned_at    = NOW();
+
+-- name: GetNewExpenses :many
+-- Spese registrate dopo l'ultimo controllo (id maggiore di after_id) avvenute da date_from.
+SELECT te.id,
+       te.category_id,
+       c.name                             AS category_name,
+       te.payee_id,
+       p.name                             AS payee_name,
+       COALESCE(te.description, '')::TEXT AS description,
+       a.currency,
+       t.occurred_at,
+       (-te.amount)::BIGINT               AS amount
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN accounts a ON a.id = te.account_id
+         JOIN category c ON c.id = te.category_id
+         LEFT JOIN payees p ON p.id = te.payee_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND c."type" = 'EXPENSE'
+  AND te.amount < 0
+  AND te.id > sqlc.arg(after_id)
+  AND t.occurred_at >= sqlc.arg(date_from)::DATE
+ORDER BY te.id;
+
+-- name: GetExpenseHistory :many
+-- Importi delle spese dello stesso beneficiario o, senza beneficiario, della stessa categoria
+-- avvenute da date_from e prima di as_of: le spese successive non fanno parte dello storico.
+-- Come in GetNewExpenses contano solo le uscite su categorie di spesa, non trasferimenti e rimborsi.
+SELECT (-te.amount)::BIGINT AS amount
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN category c ON c.id = te.category_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND c."type" = 'EXPENSE'
+  AND te.amount < 0
+  AND te.id <> sqlc.arg(entry_id)
+  AND t.occurred_at >= sqlc.arg(date_from)::DATE
+  AND t.occurred_at < sqlc.arg(as_of)::DATE
+  AND CASE
+          WHEN sqlc.narg(payee_id)::BIGINT IS NOT NULL THEN te.payee_id = sqlc.narg(payee_id)::BIGINT
+          ELSE te.category_id = sqlc.arg(category_id) END;
+
+-- name: GetMonthlyCategoryTotals :many
+-- Spesa netta per categoria e mese, dal mese di date_from a quello di date_to.
+SELECT te.category_id,
+       c.name                                   AS category_name,
+       a.currency,
+       date_trunc('month', t.occurred_at)::DATE AS month,
+       (-SUM(na.amount))::BIGINT                AS total
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN entry_net_amounts na ON na.entry_id = te.id
+         JOIN accounts a ON a.id = te.account_id
+         JOIN category c ON c.id = te.category_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND c."type" = 'EXPENSE'
+  AND t.occurred_at >= sqlc.arg(date_from)::DATE
+  AND t.occurred_at <= sqlc.arg(date_to)::DATE
+GROUP BY te.category_id, c.name, a.currency, date_trunc('month', t.occurred_at)
+HAVING SUM(na.amount) < 0
+ORDER BY te.category_id, month;
+
+-- name: CreateExpenseAnomaly :one
+-- Una spesa viene segnalata una volta sola: ErrNoRows se lo è già stata.
+INSERT INTO spending_anomalies(user_id, kind, entry_id, category_id, amount, baseline, ratio_pct, message)
+VALUES ($1, 'EXPENSE', $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id, entry_id) DO NOTHING
+RETURNING *;
+
+-- name: UpsertCategoryAnomaly :one
+-- Il totale del mese in corso cresce: la segnalazione viene aggiornata, anche se ignorata.
+INSERT INTO spending_anomalies(user_id, kind, category_id, month, amount, baseline, ratio_pct, message)
+VALUES ($1, 'CATEGORY', $2, $3, $4, $5, $6, $7)
+ON CONFLICT (user_id, category_id, month) DO UPDATE SET amount    = EXCLUDED.amount,
+                                                        baseline  = EXCLUDED.baseline,
+                                                        ratio_pct = EXCLUDED.ratio_pct,
+                                                        message   = EXCLUDED.message
+RETURNING *;
+
+-- name: GetAnomaliesByUser :many
+SELECT *
+FROM spending_anomalies
+WHERE user_id = sqlc.arg(user_id)
+  AND (sqlc.arg(include_dismissed)::BOOLEAN OR dismissed_at IS NULL)
+ORDER BY detected_at DESC, id DESC;
+
+-- name: DismissAnomaly :execrows
+UPDATE spending_anomalies
+SET dismissed_at = COALESCE(dismissed_at, NOW())
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: SetCategoryBudget :one
+UPDATE category
+SET monthly_budget = sqlc.narg(monthly_budget)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetAllCreditCards :many
+SELECT cc.*, a.user_id
+FROM credit_cards cc
+         JOIN accounts a ON a.id = cc.account_id
+ORDER BY cc.account_id;
+
+-- name: GetNotificationSettings :one
+SELECT *
+FROM notification_settings
+WHERE user_id = $1;
+
+-- name: UpsertNotificationSettings :one
+INSERT INTO notification_settings(user_id, webhook_url, low_balance_threshold)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE SET webhook_url           = EXCLUDED.webhook_url,
+                                    low_balance_threshold = EXCLUDED.low_balance_threshold,
+                                    updated_at            = NOW()
+RETURNING *;
+
+-- name: GetNotificationPreferences :many
+SELECT *
+FROM notification_preferences
+WHERE user_id = $1
+ORDER BY event_kind;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences(user_id, event_kind, email, webhook, in_app)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (user_id, event_kind) DO UPDATE SET email   = EXCLUDED.email,
+                                                webhook = EXCLUDED.webhook,
+                                                in_app  = EXCLUDED.in_app;
+
+-- name: CreateNotification :one
+-- Un evento già notificato (stessa dedup_key) viene ignorato: ErrNoRows.
+INSERT INTO notifications(user_id, kind, title, body, dedup_key, in_app)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, dedup_key) DO NOTHING
+RETURNING *;
+
+-- name: CreateNotificationDelivery :exec
+INSERT INTO notification_deliveries(notification_id, channel, target)
+VALUES ($1, $2, $3);
+
+-- name: ClaimNotificationDeliveries :many
+-- Prende in carico gli invii scaduti spostando il prossimo tentativo a lease_until: un'altra
+-- istanza non li riprende mentre l'invio è in corso, e se il processo si ferma vengono ritentati.
+UPDATE notification_deliveries d
+SET next_attempt_at = sqlc.arg(lease_until)::TIMESTAMPTZ
+FROM notifications n
+WHERE n.id = d.notification_id
+  AND d.id IN (SELECT nd.id
+               FROM notification_deliveries nd
+               WHERE nd.status = 'PENDING'
+                 AND nd.next_attempt_at <= sqlc.arg(now)::TIMESTAMPTZ
+               ORDER BY nd.next_attempt_at
+               LIMIT sqlc.arg(max_deliveries) FOR UPDATE SKIP LOCKED)
+RETURNING d.*, n.user_id, n.kind, n.title, n.body, n.created_at AS notification_created_at;
+
+-- name: MarkDeliverySent :exec
+UPDATE notification_deliveries
+SET status     = 'SENT',
+    attempts   = attempts + 1,
+    sent_at    = NOW(),
+    last_error = NULL
+WHERE id = $1;
+
+-- name: MarkDeliveryFailed :exec
+-- Senza next_attempt_at i tentativi sono esauriti e l'invio resta FAILED.
+UPDATE notification_deliveries
+SET status          = CASE WHEN sqlc.narg(next_attempt_at)::TIMESTAMPTZ IS NULL THEN 'FAILED' ELSE 'PENDING' END,
+    attempts        = attempts + 1,
+    next_attempt_at = COALESCE(sqlc.narg(next_attempt_at)::TIMESTAMPTZ, next_attempt_at),
+    last_error      = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id);
+
+-- name: GetNotificationsByUser :many
+SELECT *
+FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND in_app
+  AND (NOT sqlc.arg(unread_only)::BOOLEAN OR read_at IS NULL)
+ORDER BY created_at DESC, id DESC
+LIMIT 100;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1
+  AND user_id = $2
+  AND in_app;
+
+-- name: GetExceededBudgets :many
+-- Categorie la cui spesa netta tra date_from e date_to supera il budget mensile, sugli account
+-- visibili al proprietario della categoria.
+SELECT c.user_id,
+       c.id                      AS category_id,
+       c.name                    AS category_name,
+       c.monthly_budget::BIGINT  AS monthly_budget,
+       (-SUM(na.amount))::BIGINT AS spent
+FROM category c
+         JOIN transaction_entries te ON te.category_id = c.id
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN entry_net_amounts na ON na.entry_id = te.id
+WHERE c.monthly_budget IS NOT NULL
+  AND te.account_id IN (SELECT account_id FROM account_access aa WHERE aa.user_id = c.user_id)
+  AND t.occurred_at >= sqlc.arg(date_from)::DATE
+  AND t.occurred_at <= sqlc.arg(date_to)::DATE
+GROUP BY c.user_id, c.id, c.name, c.monthly_budget
+HAVING -SUM(na.amount) > c.monthly_budget
+ORDER BY c.user_id, c.id;
+
+-- name: GetLowBalanceAccounts :many
+-- Account (escluse carte di credito e prestiti) con saldo sotto la soglia impostata dall'utente.
+SELECT ns.user_id,
+       a.id                                       AS account_id,
+       a.name                                     AS account_name,
+       a.currency,
+       ns.low_balance_threshold,
+       (a.initial_balance + movements.total)::BIGINT AS balance
+FROM notification_settings ns
+         JOIN account_access aa ON aa.user_id = ns.user_id
+         JOIN accounts a ON a.id = aa.account_id
+         JOIN LATERAL (SELECT COALESCE(SUM(te.amount), 0) AS total
+                       FROM transaction_entries te
+                       WHERE te.account_id = a.id) movements ON TRUE
+WHERE ns.low_balance_threshold > 0
+  AND NOT EXISTS (SELECT 1 FROM credit_cards cc WHERE cc.account_id = a.id)
+  AND NOT EXISTS (SELECT 1 FROM loans l WHERE l.account_id = a.id)
+  AND a.initial_balance + movements.total < ns.low_balance_threshold
+ORDER BY ns.user_id, a.id;
+
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints(user_id, url, secret, description)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetWebhookEndpointsByUser :many
+SELECT *
+FROM webhook_endpoints
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetWebhookEndpoint :one
+SELECT *
+FROM webhook_endpoints
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: DeleteWebhookEndpoint :execrows
+DELETE
+FROM webhook_endpoints
+WHERE id = $1
+  AND user_id = $2;
+
+-- name: FanOutWebhookOutbox :execrows
+-- Smista un lotto di eventi dell'outbox sugli endpoint registrati dall'utente al momento
+-- dello smistamento e li segna come smistati; restituisce il numero di eventi del lotto.
+WITH batch AS (SELECT o.id, o.user_id
+               FROM webhook_outbox o
+               WHERE o.dispatched_at IS NULL
+               ORDER BY o.id
+               LIMIT sqlc.arg(max_events) FOR UPDATE SKIP LOCKED),
+     fan_out AS (INSERT INTO webhook_deliveries (endpoint_id, outbox_id)
+         SELECT e.id, b.id
+         FROM batch b
+                  JOIN webhook_endpoints e ON e.user_id = b.user_id
+         ON CONFLICT (endpoint_id, outbox_id) DO NOTHING)
+UPDATE webhook_outbox o
+SET dispatched_at = NOW()
+FROM batch b
+WHERE o.id = b.id;
+
+-- name: PurgeWebhookOutbox :execrows
+-- Cancella gli eventi smistati prima di sqlc.arg(before) che non hanno più invii da fare;
+-- i loro invii, ormai chiusi, vengono cancellati a cascata.
+DELETE
+FROM webhook_outbox o
+WHERE o.dispatched_at < sqlc.arg(before)::TIMESTAMPTZ
+  AND NOT EXISTS (SELECT 1
+                  FROM webhook_deliveries d
+                  WHERE d.outbox_id = o.id
+                    AND d.status = 'PENDING');
+
+-- name: ClaimWebhookDeliveries :many
+-- Come ClaimNotificationDeliveries: il prossimo tentativo passa a lease_until finché l'invio è in corso.
+UPDATE webhook_deliveries d
+SET next_attempt_at = sqlc.arg(lease_until)::TIMESTAMPTZ
+FROM webhook_endpoints e,
+     webhook_outbox o
+WHERE e.id = d.endpoint_id
+  AND o.id = d.outbox_id
+  AND d.id IN (SELECT wd.id
+               FROM webhook_deliveries wd
+               WHERE wd.status = 'PENDING'
+                 AND wd.next_attempt_at <= sqlc.arg(now)::TIMESTAMPTZ
+               ORDER BY wd.next_attempt_at
+               LIMIT sqlc.arg(max_deliveries) FOR UPDATE SKIP LOCKED)
+RETURNING d.*, e.url, e.secret, o.event, o.payload, o.created_at AS event_created_at;
+
+-- name: MarkWebhookDeliverySent :exec
+UPDATE webhook_deliveries
+SET status           = 'SENT',
+    attempts         = attempts + 1,
+    last_status_code = sqlc.narg(status_code),
+    last_error       = NULL,
+    delivered_at     = NOW()
+WHERE id = sqlc.arg(id);
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- Senza next_attempt_at i tentativi sono esauriti e l'invio resta FAILED.
+UPDATE webhook_deliveries
+SET status           = CASE WHEN sqlc.narg(next_attempt_at)::TIMESTAMPTZ IS NULL THEN 'FAILED' ELSE 'PENDING' END,
+    attempts         = attempts + 1,
+    next_attempt_at  = COALESCE(sqlc.narg(next_attempt_at)::TIMESTAMPTZ, next_attempt_at),
+    last_status_code = sqlc.narg(status_code),
+    last_error       = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id);
+
+-- name: GetWebhookDeliveries :many
+SELECT d.*, o.event, o.resource, o.resource_id
+FROM webhook_deliveries d
+         JOIN webhook_outbox o ON o.id = d.outbox_id
+WHERE d.endpoint_id = sqlc.arg(endpoint_id)
+ORDER BY d.created_at DESC, d.id DESC
+LIMIT sqlc.arg(max_deliveries);
+
+-- name: GetDigestSettings :one
+SELECT *
+FROM digest_settings
+WHERE user_id = $1;
+
+-- name: UpsertDigestSettings :one
+INSERT INTO digest_settings(user_id, weekly, monthly, weekday)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id) DO UPDATE SET weekly     = EXCLUDED.weekly,
+                                    monthly    = EXCLUDED.monthly,
+                                    weekday    = EXCLUDED.weekday,
+                                    updated_at = NOW()
+RETURNING *;
+
+-- name: GetEnabledDigestSettings :many
+SELECT *
+FROM digest_settings
+WHERE weekly
+   OR monthly
+ORDER BY user_id;
+
+-- name: ClaimWeeklyDigest :execrows
+-- Prenota l'invio previsto per sent_on prima di spedirlo: con più istanze una sola aggiorna la riga.
+UPDATE digest_settings
+SET last_weekly_sent = sqlc.arg(sent_on)::DATE
+WHERE user_id = sqlc.arg(user_id)
+  AND (last_weekly_sent IS NULL OR last_weekly_sent < sqlc.arg(sent_on)::DATE);
+
+-- name: ReleaseWeeklyDigest :exec
+-- Annulla la prenotazione di un invio fallito, se nel frattempo non è stata sostituita.
+UPDATE digest_settings
+SET last_weekly_sent = sqlc.narg(previous)::DATE
+WHERE user_id = sqlc.arg(user_id)
+  AND last_weekly_sent = sqlc.arg(sent_on)::DATE;
+
+-- name: ClaimMonthlyDigest :execrows
+UPDATE digest_settings
+SET last_monthly_sent = sqlc.arg(sent_on)::DATE
+WHERE user_id = sqlc.arg(user_id)
+  AND (last_monthly_sent IS NULL OR last_monthly_sent < sqlc.arg(sent_on)::DATE);
+
+-- name: ReleaseMonthlyDigest :exec
+UPDATE digest_settings
+SET last_monthly_sent = sqlc.narg(previous)::DATE
+WHERE user_id = sqlc.arg(user_id)
+  AND last_monthly_sent = sqlc.arg(sent_on)::DATE;
+
+-- name: GetLargestEntries :many
+-- Le entrate e le uscite più grandi del periodo in valore assoluto, esclusi i trasferimenti.
+SELECT te.id,
+       t.occurred_at,
+       a.name     AS account_name,
+       a.currency,
+       c.name     AS category_name,
+       p.name     AS payee_name,
+       te.description,
+       na.amount
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN accounts a ON a.id = te.account_id
+         JOIN category c ON c.id = te.category_id
+         JOIN entry_net_amounts na ON na.entry_id = te.id
+         LEFT JOIN payees p ON p.id = te.payee_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND c."type" <> 'TRANSFER'
+  AND t.occurred_at >= sqlc.arg(date_from)::DATE
+  AND t.occurred_at <= sqlc.arg(date_to)::DATE
+ORDER BY ABS(na.amount) DESC, te.id
+LIMIT sqlc.arg(max_entries);
+
+-- name: GetAccountBalanceChanges :many
+-- Saldo di ogni account visibile all'inizio (prima di date_from) e alla fine (date_to inclusa) del
+-- periodo, con il totale dei movimenti in entrata e in uscita nel periodo.
+SELECT a.id,
+       a.name,
+       a.currency,
+       (a.initial_balance + COALESCE(SUM(te.amount) FILTER (WHERE t.occurred_at < sqlc.arg(date_from)::DATE), 0))::BIGINT  AS opening_balance,
+       COALESCE(SUM(te.amount) FILTER (WHERE te.amount > 0
+           AND t.occurred_at >= sqlc.arg(date_from)::DATE
+           AND t.occurred_at <= sqlc.arg(date_to)::DATE), 0)::BIGINT                                                       AS inflows,
+       COALESCE(SUM(te.amount) FILTER (WHERE te.amount < 0
+           AND t.occurred_at >= sqlc.arg(date_from)::DATE
+           AND t.occurred_at <= sqlc.arg(date_to)::DATE), 0)::BIGINT                                                       AS outflows,
+       (a.initial_balance + COALESCE(SUM(te.amount) FILTER (WHERE t.occurred_at <= sqlc.arg(date_to)::DATE), 0))::BIGINT AS closing_balance
+FROM accounts a
+         LEFT JOIN transaction_entries te ON te.account_id = a.id
+         LEFT JOIN transactions t ON t.id = te.transaction_id
+WHERE a.id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+GROUP BY a.id, a.name, a.currency, a.initial_balance
+ORDER BY a.id;
+
+-- name: GetStatementEntries :many
+-- Tutti i movimenti del periodo sugli account visibili all'utente, in ordine cronologico.
+SELECT te.id,
+       t.id      AS transaction_id,
+       t.occurred_at,
+       a.name    AS account_name,
+       a.currency,
+       c.name    AS category_name,
+       p.name    AS payee_name,
+       te.description,
+       te.amount
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN accounts a ON a.id = te.account_id
+         LEFT JOIN category c ON c.id = te.category_id
+         LEFT JOIN payees p ON p.id = te.payee_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND t.occurred_at >= sqlc.arg(date_from)::DATE
+  AND t.occurred_at <= sqlc.arg(date_to)::DATE
+ORDER BY t.occurred_at, t.id, te.id;
+
+-- name: SetCategoryDeduction :one
+UPDATE category
+SET deduction_code = sqlc.narg(deduction_code)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: SetEntryDeduction :one
+UPDATE transaction_entries
+SET deduction_code = sqlc.narg(deduction_code)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: GetDeductibleEntries :many
+-- Righe contabili detraibili dell'anno sugli account in euro visibili all'utente: il codice della
+-- riga prevale su quello della categoria, NONE la esclude.
+SELECT te.id,
+       t.id                                         AS transaction_id,
+       t.occurred_at,
+       a.name                                       AS account_name,
+       c.name                                       AS category_name,
+       p.name                                       AS payee_name,
+       te.description,
+       te.amount,
+       COALESCE(te.deduction_code, c.deduction_code)::VARCHAR AS deduction_code
+FROM transaction_entries te
+         JOIN transactions t ON t.id = te.transaction_id
+         JOIN accounts a ON a.id = te.account_id
+         LEFT JOIN category c ON c.id = te.category_id
+         LEFT JOIN payees p ON p.id = te.payee_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND a.currency = 'EUR'
+  AND t.occurred_at >= make_date(sqlc.arg(year)::INT, 1, 1)
+  AND t.occurred_at < make_date(sqlc.arg(year)::INT + 1, 1, 1)
+  AND COALESCE(te.deduction_code, c.deduction_code) <> 'NONE'
+ORDER BY deduction_code, t.occurred_at, t.id, te.id;
+
+-- name: GetDeductionReceipts :many
+-- Allegati (ricevute, fatture) delle transazioni con righe detraibili nell'anno.
+SELECT DISTINCT at.id, at.transaction_id, at.file_name
+FROM attachments at
+         JOIN transactions t ON t.id = at.transaction_id
+         JOIN transaction_entries te ON te.transaction_id = t.id
+         JOIN accounts a ON a.id = te.account_id
+         LEFT JOIN category c ON c.id = te.category_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND a.currency = 'EUR'
+  AND t.occurred_at >= make_date(sqlc.arg(year)::INT, 1, 1)
+  AND t.occurred_at < make_date(sqlc.arg(year)::INT + 1, 1, 1)
+  AND COALESCE(te.deduction_code, c.deduction_code) <> 'NONE'
+ORDER BY at.transaction_id, at.id;
+
+-- name: GetExportAccounts :many
+-- Account visibili all'utente con il saldo attuale, eventualmente solo quello indicato.
+SELECT a.id,
+       a.name,
+       a.currency,
+       a.initial_balance,
+       (a.initial_balance + COALESCE(SUM(te.amount), 0))::BIGINT AS balance,
+       a.overdraft_limit,
+       a.household_id
+FROM accounts a
+         LEFT JOIN transaction_entries te ON te.account_id = a.id
+WHERE a.id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND (sqlc.narg(account_id)::BIGINT IS NULL OR a.id = sqlc.narg(account_id)::BIGINT)
+GROUP BY a.id
+ORDER BY a.name, a.id;
+
+-- name: GetExportEntries :many
+-- Un lotto di movimenti da esportare, in ordine cronologico: il lotto successivo riparte dopo
+-- l'ultima coppia (after_date, after_id) ricevuta.
+SELECT t.id     AS transaction_id,
+       te.id    AS entry_id,
+       t.occurred_at,
+       a.name   AS account_name,
+       a.currency,
+       c.name   AS category_name,
+       c."type" AS category_type,
+       p.name   AS payee_name,
+       te.description,
+       te.amount,
+       te.status,
+       COALESCE((SELECT string_agg(tg.name, ',' ORDER BY tg.name)
+                 FROM transaction_entry_tags tet
+                          JOIN tags tg ON tg.id = tet.tag_id
+                 WHERE tet.entry_id = te.id), '')::TEXT AS tags
+FROM transactions t
+         JOIN transaction_entries te ON te.transaction_id = t.id
+         JOIN accounts a ON a.id = te.account_id
+         LEFT JOIN category c ON c.id = te.category_id
+         LEFT JOIN payees p ON p.id = te.payee_id
+WHERE te.account_id IN (SELECT account_id FROM account_access WHERE user_id = sqlc.arg(user_id))
+  AND (sqlc.narg(account_id)::BIGINT IS NULL OR te.account_id = sqlc.narg(account_id)::BIGINT)
+  AND (sqlc.narg(date_from)::DATE IS NULL OR t.occurred_at >= sqlc.narg(date_from)::DATE)
+  AND (sqlc.narg(date_to)::DATE IS NULL OR t.occurred_at <= sqlc.narg(date_to)::DATE)
+  AND (sqlc.narg(tag)::TEXT IS NULL OR EXISTS (SELECT 1
+                                               FROM transaction_entry_tags tet
+                                                        JOIN tags tg ON tg.id = tet.tag_id
+                                               WHERE tet.entry_id = te.id
+                                                 AND tg.name = sqlc.narg(tag)::TEXT))
+  AND (sqlc.narg(after_date)::DATE IS NULL
+    OR (t.occurred_at, te.id) > (sqlc.narg(after_date)::DATE, sqlc.narg(after_id)::BIGINT))
+ORDER BY t.occurred_at, te.id
+LIMIT sqlc.arg('limit')::INT;
+
+-- name: GetBackupAccounts :many
+SELECT *
+FROM accounts
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetBackupCategories :many
+SELECT *
+FROM category
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetBackupPayees :many
+SELECT *
+FROM payees
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetBackupPayeeAliases :many
+SELECT pa.*
+FROM payee_aliases pa
+         JOIN payees p ON p.id = pa.payee_id
+WHERE p.user_id = $1
+ORDER BY pa.id;
+
+-- name: GetBackupTags :many
+SELECT *
+FROM tags
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetBackupTransactions :many
+-- Le transazioni con almeno un movimento sui conti dell'utente, a lotti in ordine di id.
+SELECT t.*
+FROM transactions t
+WHERE t.id > sqlc.arg(after_id)::BIGINT
+  AND EXISTS (SELECT 1
+              FROM transaction_entries te
+                       JOIN accounts a ON a.id = te.account_id
+              WHERE te.transaction_id = t.id
+                AND a.user_id = sqlc.arg(user_id))
+ORDER BY t.id
+LIMIT sqlc.arg('limit')::INT;
+
+-- name: GetBackupEntries :many
+-- I movimenti sui conti dell'utente con i tag, anch'essi dell'utente, a lotti in ordine di id.
+SELECT te.*,
+       ARRAY(SELECT tet.tag_id
+             FROM transaction_entry_tags tet
+                      JOIN tags tg ON tg.id = tet.tag_id
+             WHERE tet.entry_id = te.id
+               AND tg.user_id = a.user_id
+             ORDER BY tet.tag_id)::BIGINT[] AS tag_ids
+FROM transaction_entries te
+         JOIN accounts a ON a.id = te.account_id
+WHERE a.user_id = sqlc.arg(user_id)
+  AND te.id > sqlc.arg(after_id)::BIGINT
+ORDER BY te.id
+LIMIT sqlc.arg('limit')::INT;
+
+-- name: HasUserData :one
+SELECT EXISTS (SELECT 1 FROM accounts WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM category WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM transactions WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM payees WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM tags WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM securities WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM recurring_transactions WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM reimbursables WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM attachments WHERE user_id = $1)
+           OR EXISTS (SELECT 1 FROM split_groups WHERE created_by = $1);
+
+-- name: RestoreAccount :one
+INSERT INTO accounts(user_id, name, currency, initial_balance, overdraft_limit)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: RestoreCategory :one
+INSERT INTO category(user_id, name, "type", archived_at, monthly_budget, deduction_code)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id;
+
+-- name: RestoreCategoryParent :exec
+UPDATE category
+SET parent_id = $2
+WHERE id = $1;
+
+-- name: RestoreEntry :one
+INSERT INTO transaction_entries(transaction_id, account_id, category_id, amount, description, payee_id, status,
+                                deduction_code, reconciliation_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id;
+
+-- name: AddEntryTag :exec
+INSERT INTO transaction_entry_tags(entry_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: GetBackupCreditCards :many
+-- Carte dell'utente addebitate su un suo conto: solo queste si possono ripristinare dall'archivio.
+SELECT cc.*
+FROM credit_cards cc
+         JOIN accounts a ON a.id = cc.account_id
+         JOIN accounts pa ON pa.id = cc.payment_account_id
+WHERE a.user_id = $1
+  AND pa.user_id = $1
+ORDER BY cc.account_id;
+
+-- name: GetBackupLoans :many
+SELECT l.*
+FROM loans l
+         JOIN accounts a ON a.id = l.account_id
+         JOIN accounts pa ON pa.id = l.payment_account_id
+WHERE a.user_id = $1
+  AND pa.user_id = $1
+ORDER BY l.account_id;
+
+-- name: GetBackupLoanInstallments :many
+SELECT li.*
+FROM loan_installments li
+         JOIN loans l ON l.account_id = li.account_id
+         JOIN accounts a ON a.id = l.account_id
+         JOIN accounts pa ON pa.id = l.payment_account_id
+WHERE a.user_id = $1
+  AND pa.user_id = $1
+ORDER BY li.account_id, li.number;
+
+-- name: GetBackupSecurities :many
+SELECT *
+FROM securities
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: GetBackupSecurityPrices :many
+SELECT sp.*
+FROM security_prices sp
+         JOIN securities s ON s.id = sp.security_id
+WHERE s.user_id = $1
+ORDER BY sp.security_id, sp.price_date;
+
+-- name: GetBackupInvestmentTrades :many
+SELECT it.*
+FROM investment_trades it
+         JOIN accounts a ON a.id = it.account_id
+         JOIN securities s ON s.id = it.security_id
+WHERE a.user_id = $1
+  AND s.user_id = $1
+ORDER BY it.id;
+
+-- name: GetBackupRecurringTransactions :many
+SELECT rt.*
+FROM recurring_transactions rt
+         JOIN accounts a ON a.id = rt.account_id
+         JOIN category c ON c.id = rt.category_id
+WHERE a.user_id = $1
+  AND c.user_id = $1
+ORDER BY rt.id;
+
+-- name: GetBackupReconciliations :many
+SELECT r.*
+FROM reconciliations r
+         JOIN accounts a ON a.id = r.account_id
+WHERE a.user_id = $1
+ORDER BY r.id;
+
+-- name: GetBackupReimbursables :many
+SELECT r.*
+FROM reimbursables r
+         JOIN transaction_entries te ON te.id = r.entry_id
+         JOIN accounts a ON a.id = te.account_id
+WHERE a.user_id = $1
+ORDER BY r.entry_id;
+
+-- name: GetBackupReimbursements :many
+-- Rimborsi tra movimenti entrambi sui conti dell'utente.
+SELECT rb.*
+FROM reimbursements rb
+         JOIN transaction_entries ee ON ee.id = rb.expense_entry_id
+         JOIN accounts ea ON ea.id = ee.account_id
+         JOIN transaction_entries ie ON ie.id = rb.income_entry_id
+         JOIN accounts ia ON ia.id = ie.account_id
+WHERE ea.user_id = $1
+  AND ia.user_id = $1
+ORDER BY rb.id;
+
+-- name: GetBackupAttachments :many
+-- Metadati degli allegati delle transazioni dell'archivio: il contenuto resta nel blob store.
+SELECT at.*
+FROM attachments at
+WHERE EXISTS (SELECT 1
+              FROM transaction_entries te
+                       JOIN accounts a ON a.id = te.account_id
+              WHERE te.transaction_id = at.transaction_id
+                AND a.user_id = $1)
+ORDER BY at.id;
+
+-- name: GetBackupSplitGroups :many
+SELECT *
+FROM split_groups
+WHERE created_by = $1
+ORDER BY id;
+
+-- name: GetBackupSplitMembers :many
+SELECT sm.*, u.email::TEXT AS email
+FROM split_members sm
+         JOIN split_groups g ON g.id = sm.group_id
+         LEFT JOIN users u ON u.id = sm.user_id
+WHERE g.created_by = $1
+ORDER BY sm.id;
+
+-- name: GetBackupSplitExpenses :many
+-- Spese dei gruppi dell'utente registrate su transazioni dell'archivio.
+SELECT se.*
+FROM split_expenses se
+         JOIN split_groups g ON g.id = se.group_id
+WHERE g.created_by = $1
+  AND EXISTS (SELECT 1
+              FROM transaction_entries te
+                       JOIN accounts a ON a.id = te.account_id
+              WHERE te.transaction_id = se.transaction_id
+                AND a.user_id = $1)
+ORDER BY se.id;
+
+-- name: GetBackupSplitShares :many
+SELECT ss.*
+FROM split_shares ss
+         JOIN split_expenses se ON se.id = ss.expense_id
+         JOIN split_groups g ON g.id = se.group_id
+WHERE g.created_by = $1
+ORDER BY ss.expense_id, ss.member_id;
+
+-- name: GetBackupSplitSettlements :many
+SELECT ss.*
+FROM split_settlements ss
+         JOIN split_groups g ON g.id = ss.group_id
+WHERE g.created_by = $1
+ORDER BY ss.id;
+
+-- name: RestoreLoanInstallment :exec
+INSERT INTO loan_installments(account_id, number, due_date, principal, interest, transaction_id)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: RestoreReconciliation :one
+INSERT INTO reconciliations(account_id, statement_date, statement_balance, status, completed_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id;
+
+-- name: RestoreAttachment :execrows
+-- La chiave del blob è unica: se l'allegato originale esiste ancora in questa istanza il
+-- ripristino non lo duplica.
+INSERT INTO attachments(user_id, transaction_id, file_name, content_type, size_bytes, storage_key, thumbnail_key,
+                        created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (storage_key) DO NOTHING;
+
+-- name: GetBackupUsers :many
+SELECT *
+FROM users
+ORDER BY id;
+
+-- name: TryAdvisoryLock :one
+-- Lock di sessione: va rilasciato sulla stessa connessione con ReleaseAdvisoryLock.
+SELECT pg_try_advisory_lock(sqlc.arg(lock_key)::BIGINT)::BOOLEAN AS locked;
+
+-- name: ReleaseAdvisoryLock :one
+SELECT pg_advisory_unlock(sqlc.arg(lock_key)::BIGINT)::BOOLEAN AS released;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2
+WHERE id = $1;
+
+-- name: DeleteUser :exec
+DELETE
+FROM users
+WHERE id = $1;
+
+-- name: GetUserAttachments :many
+-- Allegati caricati dall'utente o collegati alle sue transazioni: sono quelli cancellati a
+-- cascata con l'utente.
+SELECT *
+FROM attachments
+WHERE user_id = $1
+   OR transaction_id IN (SELECT id FROM transactions WHERE user_id = $1)
+ORDER BY id;
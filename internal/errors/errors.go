@@ -3,10 +3,36 @@ package errors
 import "errors"
 
 var (
-	ErrNotFound            = errors.New("not found")
-	ErrUserNotFound        = errors.New("user not found")
-	ErrAccountNotFound     = errors.New("account not found")
-	ErrConflict            = errors.New("conflict")
-	ErrInvalidData         = errors.New("invalid data")
-	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrNotFound               = errors.New("not found")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrAccountNotFound        = errors.New("account not found")
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrCategoryArchived       = errors.New("category archived")
+	ErrTagNotFound            = errors.New("tag not found")
+	ErrTransactionNotFound    = errors.New("transaction not found")
+	ErrPayeeNotFound          = errors.New("payee not found")
+	ErrAttachmentNotFound     = errors.New("attachment not found")
+	ErrFileTooLarge           = errors.New("file too large")
+	ErrUnsupportedFileType    = errors.New("unsupported file type")
+	ErrReconciliationNotFound = errors.New("reconciliation not found")
+	ErrNotReconciled          = errors.New("reconciliation difference is not zero")
+	ErrTransactionLocked      = errors.New("transaction is reconciled and cannot be modified")
+	ErrCreditCardNotFound     = errors.New("credit card not found")
+	ErrLoanNotFound           = errors.New("loan not found")
+	ErrSecurityNotFound       = errors.New("security not found")
+	ErrHouseholdNotFound      = errors.New("household not found")
+	ErrInviteNotFound         = errors.New("invite not found")
+	ErrSplitGroupNotFound     = errors.New("split group not found")
+	ErrSplitExpenseNotFound   = errors.New("split expense not found")
+	ErrSettlementNotFound     = errors.New("settlement not found")
+	ErrReimbursementNotFound  = errors.New("reimbursement not found")
+	ErrRecurringNotFound      = errors.New("recurring transaction not found")
+	ErrSubscriptionNotFound   = errors.New("subscription not found")
+	ErrAnomalyNotFound        = errors.New("anomaly not found")
+	ErrNotificationNotFound   = errors.New("notification not found")
+	ErrWebhookNotFound        = errors.New("webhook not found")
+	ErrForbidden              = errors.New("forbidden")
+	ErrConflict               = errors.New("conflict")
+	ErrInvalidData            = errors.New("invalid data")
+	ErrInsufficientBalance    = errors.New("insufficient balance")
 )
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvWriter scrive un CSV standard (virgola come separatore, punto decimale): gli importi sono
+// in unità di valuta, le date in formato ISO.
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []Column
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []Column) (*csvWriter, error) {
+	writer := &csvWriter{writer: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+	for i, column := range columns {
+		writer.record[i] = column.Name
+	}
+	if err := writer.writer.Write(writer.record); err != nil {
+		return nil, fmt.Errorf("write csv header: %w", err)
+	}
+	return writer, nil
+}
+
+func (writer *csvWriter) WriteRow(values []any) error {
+	for i, column := range writer.columns {
+		writer.record[i] = csvValue(column.Kind, values[i])
+	}
+	if err := writer.writer.Write(writer.record); err != nil {
+		return fmt.Errorf("write csv row: %w", err)
+	}
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+func (writer *csvWriter) Close() error {
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+func csvValue(kind Kind, value any) string {
+	if value == nil {
+		return ""
+	}
+	switch kind {
+	case Integer:
+		return strconv.FormatInt(value.(int64), 10)
+	case Money:
+		return decimal(value.(int64))
+	case Date:
+		return value.(time.Time).Format("2006-01-02")
+	case Bool:
+		return strconv.FormatBool(value.(bool))
+	case List:
+		return strings.Join(value.([]string), ",")
+	default:
+		return value.(string)
+	}
+}
+
+// decimal formatta i centesimi come numero con il punto decimale, es. -123456 → "-1234.56".
+func decimal(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
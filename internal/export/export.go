@@ -0,0 +1,124 @@
+// Package export scrive tabelle di dati in CSV, JSON o XLSX riga per riga, senza tenere in
+// memoria l'intero risultato.
+package export
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"koin/internal/model/dto"
+)
+
+// Kind è il tipo dei valori di una colonna.
+type Kind int
+
+const (
+	Text    Kind = iota // string
+	Integer             // int64
+	Money               // int64 in centesimi
+	Date                // time.Time
+	Bool                // bool
+	List                // []string
+)
+
+// Column è una colonna della tabella: Name è l'intestazione in CSV e XLSX e la chiave in JSON.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Writer scrive le righe di una tabella. Ogni riga ha un valore per colonna, del tipo indicato
+// dal Kind o nil se assente. Close completa il file.
+type Writer interface {
+	WriteRow(values []any) error
+	Close() error
+}
+
+// NewWriter crea il Writer del formato e ne scrive l'intestazione; sheet è il nome del foglio
+// XLSX.
+func NewWriter(format dto.ExportFormat, w io.Writer, sheet string, columns []Column) (Writer, error) {
+	switch format {
+	case dto.ExportCSV:
+		return newCSVWriter(w, columns)
+	case dto.ExportJSON:
+		return newJSONWriter(w, columns)
+	case dto.ExportXLSX:
+		return newXLSXWriter(w, sheet, columns)
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ContentType è il tipo MIME del formato.
+func ContentType(format dto.ExportFormat) string {
+	switch format {
+	case dto.ExportCSV:
+		return "text/csv; charset=utf-8"
+	case dto.ExportJSON:
+		return "application/json"
+	default:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+}
+
+// Extension è l'estensione del file nel formato, senza punto.
+func Extension(format dto.ExportFormat) string {
+	switch format {
+	case dto.ExportCSV:
+		return "csv"
+	case dto.ExportJSON:
+		return "json"
+	default:
+		return "xlsx"
+	}
+}
+
+// NextFunc restituisce il lotto di righe successivo, o nessuna riga quando i dati sono finiti.
+type NextFunc func(ctx context.Context) ([][]any, error)
+
+// Reader produce il file mentre viene letto: chiede a next un lotto di righe solo quando le
+// precedenti sono state consumate, così in memoria resta al più un lotto.
+type Reader struct {
+	ctx    context.Context
+	next   NextFunc
+	buf    bytes.Buffer
+	writer Writer
+	done   bool
+}
+
+func NewReader(ctx context.Context, format dto.ExportFormat, sheet string, columns []Column, next NextFunc) (*Reader, error) {
+	reader := &Reader{ctx: ctx, next: next}
+	writer, err := NewWriter(format, &reader.buf, sheet, columns)
+	if err != nil {
+		return nil, err
+	}
+	reader.writer = writer
+	return reader, nil
+}
+
+func (reader *Reader) Read(p []byte) (int, error) {
+	for reader.buf.Len() == 0 && !reader.done {
+		rows, err := reader.next(reader.ctx)
+		if err != nil {
+			return 0, err
+		}
+		if len(rows) == 0 {
+			reader.done = true
+			if err := reader.writer.Close(); err != nil {
+				return 0, err
+			}
+			break
+		}
+		for _, row := range rows {
+			if err := reader.writer.WriteRow(row); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if reader.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return reader.buf.Read(p)
+}
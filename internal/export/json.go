@@ -0,0 +1,73 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// jsonWriter scrive un array di oggetti, uno per riga, con le chiavi nell'ordine delle colonne.
+// Come nelle API gli importi restano in centesimi.
+type jsonWriter struct {
+	w       io.Writer
+	columns []Column
+	keys    [][]byte
+	rows    int
+}
+
+func newJSONWriter(w io.Writer, columns []Column) (*jsonWriter, error) {
+	writer := &jsonWriter{w: w, columns: columns, keys: make([][]byte, len(columns))}
+	for i, column := range columns {
+		key, err := json.Marshal(column.Name)
+		if err != nil {
+			return nil, err
+		}
+		writer.keys[i] = key
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, fmt.Errorf("write json: %w", err)
+	}
+	return writer, nil
+}
+
+func (writer *jsonWriter) WriteRow(values []any) error {
+	row := []byte("\n  {")
+	if writer.rows > 0 {
+		row = []byte(",\n  {")
+	}
+	for i, column := range writer.columns {
+		if i > 0 {
+			row = append(row, ',')
+		}
+		value := values[i]
+		if column.Kind == Date && value != nil {
+			value = value.(time.Time).Format("2006-01-02")
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", column.Name, err)
+		}
+		row = append(row, writer.keys[i]...)
+		row = append(row, ':')
+		row = append(row, encoded...)
+	}
+	row = append(row, '}')
+	writer.rows++
+
+	if _, err := writer.w.Write(row); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
+
+func (writer *jsonWriter) Close() error {
+	end := "\n]\n"
+	if writer.rows == 0 {
+		end = "]\n"
+	}
+	if _, err := io.WriteString(writer.w, end); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
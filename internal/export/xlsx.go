@@ -0,0 +1,210 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stili della cella definiti in xlsxStyles (indici di cellXfs).
+const (
+	styleDefault = 0
+	styleHeader  = 1
+	styleMoney   = 2
+	styleDate    = 3
+)
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="4">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>`
+
+const xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>
+<sheetData>
+`
+
+const xlsxSheetEnd = `</sheetData>
+</worksheet>`
+
+// xlsxWriter scrive una cartella di lavoro con un solo foglio. Le parti fisse sono scritte subito,
+// il foglio una riga alla volta: lo zip viene completato da Close. Gli importi sono numeri in
+// unità di valuta, le date numeri di serie con formato gg/mm/aaaa.
+type xlsxWriter struct {
+	zip     *zip.Writer
+	sheet   io.Writer
+	columns []Column
+	refs    []string
+	row     int
+	buf     bytes.Buffer
+}
+
+func newXLSXWriter(w io.Writer, sheet string, columns []Column) (*xlsxWriter, error) {
+	var name bytes.Buffer
+	if err := xml.EscapeText(&name, []byte(sheet)); err != nil {
+		return nil, err
+	}
+
+	writer := &xlsxWriter{zip: zip.NewWriter(w), columns: columns, refs: make([]string, len(columns))}
+	for i := range columns {
+		writer.refs[i] = columnRef(i)
+	}
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, name.String())},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		file, err := writer.zip.Create(part.name)
+		if err != nil {
+			return nil, fmt.Errorf("create %s: %w", part.name, err)
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return nil, fmt.Errorf("write %s: %w", part.name, err)
+		}
+	}
+
+	file, err := writer.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("create sheet: %w", err)
+	}
+	writer.sheet = file
+	if _, err := io.WriteString(file, xlsxSheetStart); err != nil {
+		return nil, fmt.Errorf("write sheet: %w", err)
+	}
+
+	header := make([]any, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	if err := writer.writeRow(header, true); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func (writer *xlsxWriter) WriteRow(values []any) error {
+	return writer.writeRow(values, false)
+}
+
+func (writer *xlsxWriter) writeRow(values []any, header bool) error {
+	writer.row++
+	row := strconv.Itoa(writer.row)
+	buf := &writer.buf
+	buf.Reset()
+	fmt.Fprintf(buf, `<row r="%s">`, row)
+	for i, column := range writer.columns {
+		value := values[i]
+		if value == nil {
+			continue
+		}
+		ref := writer.refs[i] + row
+		kind := column.Kind
+		if header {
+			kind = Text
+		} else if kind == List {
+			kind = Text
+			value = strings.Join(value.([]string), ", ")
+		}
+		switch kind {
+		case Integer:
+			fmt.Fprintf(buf, `<c r="%s"><v>%d</v></c>`, ref, value.(int64))
+		case Money:
+			fmt.Fprintf(buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleMoney, decimal(value.(int64)))
+		case Date:
+			fmt.Fprintf(buf, `<c r="%s" s="%d"><v>%d</v></c>`, ref, styleDate, serialDate(value.(time.Time)))
+		case Bool:
+			flag := 0
+			if value.(bool) {
+				flag = 1
+			}
+			fmt.Fprintf(buf, `<c r="%s" t="b"><v>%d</v></c>`, ref, flag)
+		default:
+			style := styleDefault
+			if header {
+				style = styleHeader
+			}
+			fmt.Fprintf(buf, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">`, ref, style)
+			if err := xml.EscapeText(buf, []byte(value.(string))); err != nil {
+				return err
+			}
+			buf.WriteString(`</t></is></c>`)
+		}
+	}
+	buf.WriteString("</row>\n")
+
+	if _, err := writer.sheet.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write sheet: %w", err)
+	}
+	return nil
+}
+
+func (writer *xlsxWriter) Close() error {
+	if _, err := io.WriteString(writer.sheet, xlsxSheetEnd); err != nil {
+		return fmt.Errorf("write sheet: %w", err)
+	}
+	if err := writer.zip.Close(); err != nil {
+		return fmt.Errorf("close xlsx: %w", err)
+	}
+	return nil
+}
+
+// columnRef restituisce la lettera della colonna: 0 → "A", 25 → "Z", 26 → "AA".
+func columnRef(index int) string {
+	ref := ""
+	for index >= 0 {
+		ref = string(rune('A'+index%26)) + ref
+		index = index/26 - 1
+	}
+	return ref
+}
+
+// serialDate è il numero di serie della data nei fogli di calcolo, in giorni dal 30/12/1899.
+func serialDate(date time.Time) int64 {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return day.Unix()/86400 + 25569
+}
@@ -30,6 +30,8 @@ type AddTransactionDto struct {
 	OccurredAt   time.Time
 	Amount       int64
 	Description  *string
+	Tags         []string
+	PayeeName    *string
 }
 
 type CreateCategoryDto struct {
@@ -37,6 +39,7 @@ type CreateCategoryDto struct {
 	Name         string
 	CategoryType CategoryType
 	Description  string
+	ParentID     *int64
 }
 
 type TransferBetweenAccountsDto struct {
@@ -46,4 +49,19 @@ type TransferBetweenAccountsDto struct {
 	Amount      int64
 	OccurredAt  time.Time
 	Description *string
+	Tags        []string
+}
+
+// ImportTransactionsDto: DefaultCategory si applica alle righe senza categoria.
+type ImportTransactionsDto struct {
+	UserID          int64
+	AccountName     string
+	DefaultCategory *string
+}
+
+// TransactionImportResult riporta l'esito dell'importazione di un CSV di transazioni: le righe
+// non valide vengono saltate e descritte in Errors.
+type TransactionImportResult struct {
+	Imported int
+	Errors   []string
 }
@@ -0,0 +1,18 @@
+package dto
+
+// AnomalyKind distingue le segnalazioni su una singola spesa da quelle sul totale mensile di
+// una categoria.
+type AnomalyKind string
+
+const (
+	AnomalyExpense  AnomalyKind = "EXPENSE"
+	AnomalyCategory AnomalyKind = "CATEGORY"
+)
+
+// Outlier è un importo fuori dalla norma: Baseline è la mediana storica e RatioPct l'importo
+// in percentuale della mediana.
+type Outlier struct {
+	Amount   int64
+	Baseline int64
+	RatioPct int32
+}
@@ -0,0 +1,8 @@
+package dto
+
+type UploadAttachmentDto struct {
+	UserID        int64
+	TransactionID int64
+	FileName      string
+	Content       []byte
+}
@@ -0,0 +1,75 @@
+package dto
+
+import "time"
+
+// BackupOptions: IncludePassword scrive nell'archivio l'hash della password dell'utente,
+// necessario per ripristinarlo senza reimpostarla. Gli archivi scaricati dall'API non lo
+// contengono.
+type BackupOptions struct {
+	IncludePassword bool
+}
+
+// RestoreOptions: Email, se indicata, sostituisce quella dell'archivio come utente di
+// destinazione.
+type RestoreOptions struct {
+	Email *string
+}
+
+// RestoreResult descrive l'utente ripristinato e quanti record di ogni tipo sono stati scritti.
+// PasswordResetRequired indica un utente creato da un archivio senza password, che non può
+// accedere finché la password non viene reimpostata.
+type RestoreResult struct {
+	UserID                int64
+	Email                 string
+	Created               bool
+	PasswordResetRequired bool
+	Counts                map[string]int64
+}
+
+// BackupMode è il tipo dei backup automatici: archivi logici di tutti gli utenti oppure il
+// dump dell'intero database con pg_dump.
+type BackupMode string
+
+const (
+	BackupLogical BackupMode = "LOGICAL"
+	BackupPgDump  BackupMode = "PG_DUMP"
+)
+
+// BackupSchedule configura i backup automatici: Interval a 0 li disattiva. Dei file nella
+// directory restano l'ultimo di ciascuno degli ultimi KeepDaily giorni, KeepWeekly settimane
+// e KeepMonthly mesi.
+type BackupSchedule struct {
+	Directory   string
+	Mode        BackupMode
+	Interval    time.Duration
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	Gzip        bool
+	Passphrase  string
+	PgDumpPath  string
+	DatabaseURL string
+}
+
+// BackupFileInfo è un backup presente nella directory.
+type BackupFileInfo struct {
+	Name      string
+	SizeBytes int64
+	CreatedAt time.Time
+}
+
+// BackupStatus è lo stato dei backup automatici: LastError è l'errore dell'ultima esecuzione,
+// nil se è riuscita.
+type BackupStatus struct {
+	Enabled       bool
+	Mode          BackupMode
+	Interval      time.Duration
+	Compressed    bool
+	Encrypted     bool
+	Running       bool
+	LastRunAt     *time.Time
+	LastSuccessAt *time.Time
+	LastError     *string
+	NextRunAt     *time.Time
+	Files         []BackupFileInfo
+}
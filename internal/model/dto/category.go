@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+type UpdateCategoryDto struct {
+	UserID     int64
+	CategoryID int64
+	Name       *string
+	Archived   *bool
+}
+
+type SetCategoryParentDto struct {
+	UserID     int64
+	CategoryID int64
+	ParentID   *int64
+}
+
+type MergeCategoriesDto struct {
+	UserID           int64
+	SourceCategoryID int64
+	TargetCategoryID int64
+}
+
+type CategoryReportDto struct {
+	UserID   int64
+	DateFrom time.Time
+	DateTo   time.Time
+	Tag      *string
+}
+
+// CategoryTotal riporta il totale di una categoria: Total è relativo alle sole
+// righe della categoria, RolledUpTotal include anche tutte le sottocategorie.
+type CategoryTotal struct {
+	CategoryID    int64
+	Name          string
+	Path          string
+	CategoryType  CategoryType
+	ParentID      *int64
+	Total         int64
+	RolledUpTotal int64
+}
@@ -0,0 +1,11 @@
+package dto
+
+// ChangeEvent segnala la modifica di una risorsa agli utenti che la vedono. ID è l'evento
+// dell'outbox, Event il suo nome (es. transaction.created).
+type ChangeEvent struct {
+	ID         int64
+	Event      string
+	Resource   string
+	ResourceID int64
+	UserIDs    []int64
+}
@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// StatementStatus è lo stato di un estratto conto della carta di credito.
+type StatementStatus string
+
+const (
+	StatementOpen    StatementStatus = "OPEN"    // ciclo in corso, non ancora chiuso
+	StatementDue     StatementStatus = "DUE"     // chiuso, da pagare entro la scadenza
+	StatementPaid    StatementStatus = "PAID"    // saldato
+	StatementOverdue StatementStatus = "OVERDUE" // scaduto senza essere saldato
+)
+
+type ConfigureCreditCardDto struct {
+	UserID           int64
+	AccountID        int64
+	PaymentAccountID int64
+	ClosingDay       int32
+	DueDay           int32
+	AutoPay          bool
+}
+
+type PayStatementDto struct {
+	UserID      int64
+	AccountID   int64
+	ClosingDate time.Time
+	Amount      *int64
+	OccurredAt  *time.Time
+}
+
+// CreditCardStatement è l'estratto conto di un ciclo: Owed sono gli addebiti netti del
+// ciclo, Paid i pagamenti ricevuti dalla chiusura fino alla chiusura successiva.
+type CreditCardStatement struct {
+	PeriodStart time.Time
+	ClosingDate time.Time
+	DueDate     time.Time
+	Owed        int64
+	Paid        int64
+	Outstanding int64
+	Status      StatementStatus
+	// SuggestedPaymentDate è valorizzata quando c'è un saldo da pagare: la scadenza,
+	// oppure oggi se la scadenza è già passata.
+	SuggestedPaymentDate *time.Time
+}
+
+// CreditCardStatements raccoglie gli estratti conto di una carta, dal più recente.
+type CreditCardStatements struct {
+	CreditCard     dbgen.CreditCard
+	Account        dbgen.Account
+	PaymentAccount dbgen.Account
+	Statements     []CreditCardStatement
+}
@@ -0,0 +1,96 @@
+package dto
+
+import (
+	dbgen "koin/internal/db/generated"
+)
+
+// DeductionCode classifica una spesa detraibile nel quadro E del 730. NoDeduction, solo sulle
+// righe contabili, esclude la riga anche se la sua categoria è detraibile.
+type DeductionCode string
+
+const (
+	MedicalExpenses      DeductionCode = "E1"
+	DisabilityExpenses   DeductionCode = "E3"
+	MortgageInterest     DeductionCode = "E7"
+	SchoolExpenses       DeductionCode = "E8_12"
+	UniversityExpenses   DeductionCode = "E8_13"
+	FuneralExpenses      DeductionCode = "E8_14"
+	PersonalCareExpenses DeductionCode = "E8_15"
+	YouthSportsExpenses  DeductionCode = "E8_16"
+	RealEstateAgencyFees DeductionCode = "E8_17"
+	StudentRent          DeductionCode = "E8_18"
+	BuildingRenovation   DeductionCode = "E41"
+	NoDeduction          DeductionCode = "NONE"
+)
+
+// DeductionInfo descrive un codice: Line è il rigo del quadro E dove va riportata la spesa.
+type DeductionInfo struct {
+	Code  DeductionCode
+	Line  string
+	Label string
+}
+
+// DeductionCodes elenca i codici supportati nell'ordine del quadro E.
+var DeductionCodes = []DeductionInfo{
+	{Code: MedicalExpenses, Line: "Rigo E1 col. 2", Label: "Spese sanitarie"},
+	{Code: DisabilityExpenses, Line: "Rigo E3", Label: "Spese sanitarie per persone con disabilità"},
+	{Code: MortgageInterest, Line: "Rigo E7", Label: "Interessi del mutuo per l'abitazione principale"},
+	{Code: SchoolExpenses, Line: "Righi E8-E10 cod. 12", Label: "Spese di istruzione"},
+	{Code: UniversityExpenses, Line: "Righi E8-E10 cod. 13", Label: "Spese universitarie"},
+	{Code: FuneralExpenses, Line: "Righi E8-E10 cod. 14", Label: "Spese funebri"},
+	{Code: PersonalCareExpenses, Line: "Righi E8-E10 cod. 15", Label: "Spese per addetti all'assistenza personale"},
+	{Code: YouthSportsExpenses, Line: "Righi E8-E10 cod. 16", Label: "Attività sportive per ragazzi"},
+	{Code: RealEstateAgencyFees, Line: "Righi E8-E10 cod. 17", Label: "Intermediazione immobiliare"},
+	{Code: StudentRent, Line: "Righi E8-E10 cod. 18", Label: "Canoni di locazione per studenti fuori sede"},
+	{Code: BuildingRenovation, Line: "Righi E41-E43", Label: "Interventi di recupero del patrimonio edilizio"},
+}
+
+// SetCategoryDeductionDto classifica le spese di una categoria; nil toglie la classificazione.
+type SetCategoryDeductionDto struct {
+	UserID     int64
+	CategoryID int64
+	Code       *DeductionCode
+}
+
+// SetEntryDeductionDto classifica una singola riga contabile, prevalendo sulla categoria; nil
+// torna a usare il codice della categoria.
+type SetEntryDeductionDto struct {
+	UserID  int64
+	EntryID int64
+	Code    *DeductionCode
+}
+
+// DeductionEntry è una riga detraibile con i nomi dei file allegati alla sua transazione.
+type DeductionEntry struct {
+	Entry    dbgen.GetDeductibleEntriesRow
+	Receipts []string
+}
+
+// Deductible è la spesa in positivo, in centesimi: negativa per rimborsi e storni.
+func (entry DeductionEntry) Deductible() int64 {
+	return -entry.Entry.Amount
+}
+
+// DeductionLine raccoglie le spese di un codice con il loro totale.
+type DeductionLine struct {
+	DeductionInfo
+	Total           int64
+	MissingReceipts int32
+	Entries         []DeductionEntry
+}
+
+// DeductionReport è il riepilogo annuale delle spese detraibili per codice del quadro E.
+type DeductionReport struct {
+	UserEmail string
+	Year      int
+	Total     int64
+	Lines     []DeductionLine
+}
+
+// DeductionExportFormat è il formato di esportazione del riepilogo delle detrazioni.
+type DeductionExportFormat string
+
+const (
+	DeductionCSV DeductionExportFormat = "CSV"
+	DeductionPDF DeductionExportFormat = "PDF"
+)
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// DigestSettings indica quali riepiloghi email ricevere: il settimanale arriva nel giorno Weekday
+// e copre i sette giorni precedenti, il mensile arriva il primo del mese e copre il mese precedente.
+type DigestSettings struct {
+	UserID  int64
+	Weekly  bool
+	Monthly bool
+	Weekday time.Weekday
+}
+
+// DigestCategory è la spesa (positiva, in centesimi) di una categoria nel periodo; SharePct è la
+// quota sul totale delle uscite.
+type DigestCategory struct {
+	Name     string
+	Amount   int64
+	SharePct int32
+}
+
+// DigestBudget è lo stato del budget mensile di una categoria nel mese in cui termina il periodo.
+type DigestBudget struct {
+	Name     string
+	Budget   int64
+	Spent    int64
+	UsedPct  int32
+	Exceeded bool
+}
+
+// Digest è il riepilogo di un periodo, dal DateFrom al DateTo inclusi.
+type Digest struct {
+	Frequency     Frequency
+	DateFrom      time.Time
+	DateTo        time.Time
+	Income        int64
+	Expenses      int64
+	TopCategories []DigestCategory
+	BudgetMonth   time.Time
+	Budgets       []DigestBudget
+	Largest       []dbgen.GetLargestEntriesRow
+	Accounts      []dbgen.GetAccountBalanceChangesRow
+}
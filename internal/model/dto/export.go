@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"io"
+	"time"
+)
+
+// ExportFormat è il formato del file esportato.
+type ExportFormat string
+
+const (
+	ExportCSV  ExportFormat = "CSV"
+	ExportJSON ExportFormat = "JSON"
+	ExportXLSX ExportFormat = "XLSX"
+)
+
+// ExportResource indica quali dati esportare.
+type ExportResource string
+
+const (
+	ExportAccounts     ExportResource = "ACCOUNTS"
+	ExportCategories   ExportResource = "CATEGORIES"
+	ExportTransactions ExportResource = "TRANSACTIONS"
+)
+
+// ExportFilter usa gli stessi filtri dell'elenco delle transazioni: AccountID vale anche per
+// l'esportazione degli account, date e tag solo per i movimenti.
+type ExportFilter struct {
+	UserID    int64
+	Resource  ExportResource
+	Format    ExportFormat
+	AccountID *int64
+	DateFrom  *time.Time
+	DateTo    *time.Time
+	Tag       *string
+}
+
+// ExportFile è un file esportato: Content viene prodotto man mano che lo si legge.
+type ExportFile struct {
+	Filename    string
+	ContentType string
+	Content     io.Reader
+}
@@ -0,0 +1,109 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// Frequency è la cadenza di una transazione ricorrente; RepeatEvery indica ogni quante unità
+// si ripete (ogni 2 settimane, ogni 3 mesi, ...).
+type Frequency string
+
+const (
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// CreateRecurringDto registra una transazione ricorrente nota: Amount è negativo per le uscite.
+// Senza EndDate la ricorrenza non ha fine.
+type CreateRecurringDto struct {
+	UserID      int64
+	AccountID   int64
+	CategoryID  int64
+	Amount      int64
+	Description string
+	Frequency   Frequency
+	RepeatEvery int32
+	StartDate   time.Time
+	EndDate     *time.Time
+}
+
+type SetOverdraftDto struct {
+	UserID         int64
+	AccountID      int64
+	OverdraftLimit int64
+}
+
+// ForecastEventKind è l'origine di un movimento previsto.
+type ForecastEventKind string
+
+const (
+	EventRecurring       ForecastEventKind = "RECURRING"
+	EventCreditCardDue   ForecastEventKind = "CREDIT_CARD_DUE"
+	EventLoanInstallment ForecastEventKind = "LOAN_INSTALLMENT"
+)
+
+// ForecastAlertKind indica la soglia superata: saldo negativo oppure oltre il fido.
+type ForecastAlertKind string
+
+const (
+	AlertBelowZero      ForecastAlertKind = "BELOW_ZERO"
+	AlertBelowOverdraft ForecastAlertKind = "BELOW_OVERDRAFT"
+)
+
+type ForecastDto struct {
+	UserID        int64
+	Months        int
+	HistoryMonths int
+}
+
+// ForecastEvent è un movimento previsto in una data precisa.
+type ForecastEvent struct {
+	Date        time.Time
+	AccountID   int64
+	Kind        ForecastEventKind
+	Amount      int64
+	Description string
+}
+
+// CategoryAverage è la spesa discrezionale media mensile di una categoria su un account,
+// in centesimi e positiva.
+type CategoryAverage struct {
+	CategoryID   int64
+	CategoryName string
+	Monthly      int64
+}
+
+// ForecastPoint è il saldo previsto a fine giornata.
+type ForecastPoint struct {
+	Date    time.Time
+	Balance int64
+}
+
+// ForecastAlert segnala il giorno in cui il saldo previsto supera una soglia.
+type ForecastAlert struct {
+	Date    time.Time
+	Kind    ForecastAlertKind
+	Balance int64
+}
+
+type AccountForecast struct {
+	Account        dbgen.Account
+	StartBalance   int64
+	MinBalance     int64
+	MinBalanceDate time.Time
+	Points         []ForecastPoint
+	Events         []ForecastEvent
+	Discretionary  []CategoryAverage
+	Alerts         []ForecastAlert
+}
+
+// Forecast è la previsione dei saldi da From (oggi) a To, giorno per giorno.
+type Forecast struct {
+	From          time.Time
+	To            time.Time
+	HistoryMonths int
+	Accounts      []AccountForecast
+}
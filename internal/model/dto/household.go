@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// HouseholdRole è il ruolo di un membro: OWNER gestisce membri e inviti, EDITOR può modificare
+// account e categorie condivisi, VIEWER li vede in sola lettura.
+type HouseholdRole string
+
+const (
+	Owner  HouseholdRole = "OWNER"
+	Editor HouseholdRole = "EDITOR"
+	Viewer HouseholdRole = "VIEWER"
+)
+
+// CanEdit indica se il ruolo consente di modificare i dati condivisi.
+func (role HouseholdRole) CanEdit() bool {
+	return role == Owner || role == Editor
+}
+
+type CreateHouseholdDto struct {
+	UserID int64
+	Name   string
+}
+
+// CreateInviteDto descrive un invito: se Email è valorizzata solo l'utente con quell'indirizzo
+// può accettarlo, altrimenti basta conoscere il codice.
+type CreateInviteDto struct {
+	UserID      int64
+	HouseholdID int64
+	Email       *string
+	Role        HouseholdRole
+	ExpiresAt   time.Time
+}
+
+type HouseholdDetail struct {
+	Household dbgen.Household
+	Role      HouseholdRole
+	Members   []dbgen.GetHouseholdMembersRow
+	Invites   []dbgen.HouseholdInvite
+}
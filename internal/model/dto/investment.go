@@ -0,0 +1,117 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// TradeKind è il tipo di operazione su un titolo.
+type TradeKind string
+
+const (
+	Buy      TradeKind = "BUY"
+	Sell     TradeKind = "SELL"
+	Dividend TradeKind = "DIVIDEND"
+)
+
+// QuantityScale è il fattore di scala di quantità e prezzi unitari, memorizzati con 4 decimali.
+const QuantityScale = 10000
+
+type CreateSecurityDto struct {
+	UserID   int64
+	Isin     *string
+	Ticker   *string
+	Name     string
+	Currency string
+}
+
+// AddTradeDto descrive un'operazione: Quantity e Price sono in decimillesimi, Fees e
+// Amount (importo lordo del dividendo) in centesimi.
+type AddTradeDto struct {
+	UserID     int64
+	AccountID  int64
+	SecurityID int64
+	Kind       TradeKind
+	TradeDate  time.Time
+	Quantity   int64
+	Price      int64
+	Fees       int64
+	Amount     int64
+}
+
+// TradeCashEntry è una riga della transazione di cassa generata da un'operazione.
+type TradeCashEntry struct {
+	Amount     int64
+	CategoryID *int64
+}
+
+type SecurityPriceDto struct {
+	SecurityID int64
+	PriceDate  time.Time
+	Price      int64
+}
+
+// PriceImportResult riporta l'esito dell'importazione di un CSV di prezzi: le righe non
+// valide vengono saltate e descritte in Errors.
+type PriceImportResult struct {
+	Imported int
+	Errors   []string
+}
+
+type SecurityQuote struct {
+	Security  dbgen.Security
+	LastPrice *dbgen.SecurityPrice
+}
+
+// Position è la posizione su un titolo: CostBasis è il costo dei lotti FIFO ancora aperti.
+type Position struct {
+	Security       dbgen.Security
+	AccountID      int64
+	Quantity       int64
+	CostBasis      int64
+	LastPrice      *dbgen.SecurityPrice
+	MarketValue    int64
+	UnrealizedGain int64
+}
+
+type Holdings struct {
+	Account     dbgen.Account
+	CashBalance int64
+	MarketValue int64
+	Positions   []Position
+}
+
+// RealizedGain è la plusvalenza (o minusvalenza) di una vendita rispetto ai lotti FIFO consumati.
+type RealizedGain struct {
+	TradeID   int64
+	AccountID int64
+	Security  dbgen.Security
+	SellDate  time.Time
+	Quantity  int64
+	Proceeds  int64
+	CostBasis int64
+	Gain      int64
+}
+
+type GainsReport struct {
+	DateFrom        time.Time
+	DateTo          time.Time
+	Realized        []RealizedGain
+	RealizedTotal   int64
+	Dividends       int64
+	Unrealized      []Position
+	UnrealizedTotal int64
+}
+
+type NetWorthAccount struct {
+	Account     dbgen.Account
+	CashBalance int64
+	MarketValue int64
+}
+
+// NetWorth è il patrimonio netto: saldi di cassa più valore di mercato dei titoli, totalizzato per valuta.
+type NetWorth struct {
+	Accounts []NetWorthAccount
+	Totals   map[string]int64
+}
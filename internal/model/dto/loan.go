@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// AmortizationMethod è il tipo di piano di ammortamento: alla francese la rata è costante,
+// all'italiana è costante la quota capitale e la rata decresce.
+type AmortizationMethod string
+
+const (
+	French  AmortizationMethod = "FRENCH"
+	Italian AmortizationMethod = "ITALIAN"
+)
+
+// PrepaymentMode indica come viene usata un'estinzione parziale: riducendo la durata
+// (stessa rata) oppure la rata (stessa durata).
+type PrepaymentMode string
+
+const (
+	ReduceTerm        PrepaymentMode = "REDUCE_TERM"
+	ReduceInstallment PrepaymentMode = "REDUCE_INSTALLMENT"
+)
+
+type CreateLoanDto struct {
+	UserID           int64
+	Name             string
+	Currency         string
+	PaymentAccountID int64
+	Principal        int64
+	AnnualRateBps    int32
+	TermMonths       int32
+	StartDate        time.Time
+	Method           AmortizationMethod
+}
+
+type PrepaymentDto struct {
+	UserID    int64
+	AccountID int64
+	Amount    int64
+	Mode      PrepaymentMode
+}
+
+// Installment è una rata calcolata del piano di ammortamento.
+type Installment struct {
+	Number             int32
+	DueDate            time.Time
+	Principal          int64
+	Interest           int64
+	RemainingPrincipal int64
+}
+
+// LoanBalance riassume la situazione del prestito in base alle rate contabilizzate.
+type LoanBalance struct {
+	RemainingPrincipal int64
+	RemainingInterest  int64
+	PaidPrincipal      int64
+	PaidInterest       int64
+	InstallmentsPaid   int32
+	InstallmentsLeft   int32
+	NextInstallment    *dbgen.LoanInstallment
+}
+
+type LoanDetail struct {
+	Loan           dbgen.Loan
+	Account        dbgen.Account
+	PaymentAccount dbgen.Account
+	Installments   []dbgen.LoanInstallment
+	Balance        LoanBalance
+}
+
+// PrepaymentSimulation confronta il piano residuo attuale con quello dopo un'estinzione parziale.
+type PrepaymentSimulation struct {
+	Mode               PrepaymentMode
+	Amount             int64
+	RemainingPrincipal int64
+	CurrentInstallment int64
+	NewInstallment     int64
+	CurrentMonths      int32
+	NewMonths          int32
+	CurrentInterest    int64
+	NewInterest        int64
+	InterestSaved      int64
+	Schedule           []Installment
+}
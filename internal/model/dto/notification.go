@@ -0,0 +1,66 @@
+package dto
+
+// NotificationKind è il tipo di evento notificato all'utente. NotifyRecurringPosted copre gli
+// addebiti automatici, cioè le rate dei prestiti e il saldo delle carte: le transazioni
+// ricorrenti servono solo alla previsione dei saldi e non vengono mai contabilizzate.
+type NotificationKind string
+
+const (
+	NotifyBudgetExceeded  NotificationKind = "BUDGET_EXCEEDED"
+	NotifyLowBalance      NotificationKind = "LOW_BALANCE"
+	NotifyRecurringPosted NotificationKind = "RECURRING_POSTED"
+	NotifyStatementDue    NotificationKind = "STATEMENT_DUE"
+	NotifySpendingAnomaly NotificationKind = "SPENDING_ANOMALY"
+)
+
+// NotificationKinds elenca i tipi di evento nell'ordine in cui vengono mostrate le preferenze.
+var NotificationKinds = []NotificationKind{
+	NotifyBudgetExceeded,
+	NotifyLowBalance,
+	NotifyRecurringPosted,
+	NotifyStatementDue,
+	NotifySpendingAnomaly,
+}
+
+// NotificationChannel è un canale di consegna; IN_APP è la inbox consultabile dall'app.
+type NotificationChannel string
+
+const (
+	ChannelEmail   NotificationChannel = "EMAIL"
+	ChannelWebhook NotificationChannel = "WEBHOOK"
+	ChannelInApp   NotificationChannel = "IN_APP"
+)
+
+// NotificationEvent è un evento pubblicato da un servizio. DedupKey identifica il fatto
+// notificato (es. "budget:12:2026-10"): lo stesso evento pubblicato più volte arriva una volta sola.
+type NotificationEvent struct {
+	UserID   int64
+	Kind     NotificationKind
+	Title    string
+	Body     string
+	DedupKey string
+}
+
+// NotificationPreference indica su quali canali arriva un tipo di evento.
+type NotificationPreference struct {
+	Kind    NotificationKind
+	Email   bool
+	Webhook bool
+	InApp   bool
+}
+
+// NotificationSettings sono le impostazioni dell'utente: LowBalanceThreshold a 0 disattiva
+// gli avvisi di saldo basso.
+type NotificationSettings struct {
+	UserID              int64
+	WebhookURL          *string
+	LowBalanceThreshold int64
+	Preferences         []NotificationPreference
+}
+
+// SetCategoryBudgetDto imposta il budget mensile di una categoria; nil lo rimuove.
+type SetCategoryBudgetDto struct {
+	UserID        int64
+	CategoryID    int64
+	MonthlyBudget *int64
+}
@@ -0,0 +1,29 @@
+package dto
+
+import "time"
+
+type CreatePayeeDto struct {
+	UserID            int64
+	Name              string
+	DefaultCategoryID *int64
+	Aliases           []string
+}
+
+type UpdatePayeeDto struct {
+	UserID            int64
+	PayeeID           int64
+	Name              string
+	DefaultCategoryID *int64
+}
+
+type AddPayeeAliasDto struct {
+	UserID  int64
+	PayeeID int64
+	Pattern string
+}
+
+type PayeeReportDto struct {
+	UserID   int64
+	DateFrom time.Time
+	DateTo   time.Time
+}
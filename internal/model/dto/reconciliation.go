@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// EntryStatus è lo stato di un movimento rispetto all'estratto conto.
+type EntryStatus string
+
+const (
+	Uncleared  EntryStatus = "UNCLEARED"
+	Cleared    EntryStatus = "CLEARED"
+	Reconciled EntryStatus = "RECONCILED"
+)
+
+const (
+	ReconciliationInProgress = "IN_PROGRESS"
+	ReconciliationCompleted  = "COMPLETED"
+)
+
+type StartReconciliationDto struct {
+	UserID           int64
+	AccountID        int64
+	StatementDate    time.Time
+	StatementBalance int64
+}
+
+type ClearEntriesDto struct {
+	UserID           int64
+	ReconciliationID int64
+	EntryIDs         []int64
+	Cleared          bool
+}
+
+// ReconciliationStatus riassume lo stato di una riconciliazione: il saldo dei movimenti
+// spuntati fino alla data dell'estratto conto e la differenza ancora da giustificare.
+type ReconciliationStatus struct {
+	Reconciliation dbgen.Reconciliation
+	Account        dbgen.Account
+	ClearedBalance int64
+	Difference     int64
+	Entries        []dbgen.GetReconciliationEntriesRow
+}
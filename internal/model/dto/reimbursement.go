@@ -0,0 +1,40 @@
+package dto
+
+import dbgen "koin/internal/db/generated"
+
+type MarkReimbursableDto struct {
+	UserID  int64
+	EntryID int64
+	Party   string
+}
+
+// ReimbursementDto collega un movimento di entrata a una spesa rimborsabile; senza Amount
+// viene usato il massimo possibile tra il residuo della spesa e la parte libera dell'entrata.
+type ReimbursementDto struct {
+	UserID         int64
+	ExpenseEntryID int64
+	IncomeEntryID  int64
+	Amount         *int64
+}
+
+type ReimbursableEntry struct {
+	Entry          dbgen.GetReimbursableEntriesRow
+	Reimbursements []dbgen.Reimbursement
+}
+
+// Outstanding è l'importo ancora da rimborsare, in centesimi.
+func (entry ReimbursableEntry) Outstanding() int64 {
+	return entry.Entry.Amount - entry.Entry.Reimbursed
+}
+
+// PartyOutstanding è il totale ancora dovuto da una controparte in una valuta.
+type PartyOutstanding struct {
+	Party       string
+	Currency    string
+	Outstanding int64
+}
+
+type ReimbursementReport struct {
+	Entries []ReimbursableEntry
+	Parties []PartyOutstanding
+}
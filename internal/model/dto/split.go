@@ -0,0 +1,118 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// SplitMethod è il criterio di divisione di una spesa: in parti uguali, in proporzione a
+// dei pesi oppure per importi esatti.
+type SplitMethod string
+
+const (
+	SplitEqual  SplitMethod = "EQUAL"
+	SplitShares SplitMethod = "SHARES"
+	SplitExact  SplitMethod = "EXACT"
+)
+
+// Categorie in cui vengono registrati i saldi tra partecipanti.
+const (
+	SharedExpensesCategory       = "Spese condivise"
+	SharedExpensesRefundCategory = "Rimborsi spese condivise"
+)
+
+// CreateSplitGroupDto crea un gruppo di cui il creatore è il primo partecipante; MemberName
+// è il nome con cui compare nel gruppo (di default la sua email).
+type CreateSplitGroupDto struct {
+	UserID     int64
+	Name       string
+	Currency   string
+	MemberName *string
+}
+
+// AddSplitMemberDto aggiunge un utente koin (MemberUserID) oppure una persona senza account,
+// identificata solo da Name.
+type AddSplitMemberDto struct {
+	UserID       int64
+	GroupID      int64
+	MemberUserID *int64
+	Name         *string
+}
+
+// SplitPart è la partecipazione di un membro a una spesa: Shares è il peso con il metodo
+// SHARES, Amount l'importo in centesimi con il metodo EXACT.
+type SplitPart struct {
+	MemberID int64
+	Shares   *int32
+	Amount   *int64
+}
+
+// SplitExpenseDto divide la parte di spesa di una transazione tra i membri del gruppo; senza
+// Parts la spesa è divisa in parti uguali tra tutti i membri.
+type SplitExpenseDto struct {
+	UserID        int64
+	GroupID       int64
+	TransactionID int64
+	Method        SplitMethod
+	Parts         []SplitPart
+}
+
+// SplitShareAmount è la quota calcolata di un membro.
+type SplitShareAmount struct {
+	MemberID int64
+	Shares   *int32
+	Amount   int64
+}
+
+// SettlementDto registra un pagamento da FromMemberID a ToMemberID. Se chi lo registra è uno
+// dei due deve indicare AccountID, il proprio account su cui registrare il movimento; senza
+// SettledAt il pagamento è datato oggi.
+type SettlementDto struct {
+	UserID       int64
+	GroupID      int64
+	FromMemberID int64
+	ToMemberID   int64
+	Amount       int64
+	SettledAt    time.Time
+	AccountID    *int64
+}
+
+// SettlementEntry è il movimento creato nell'account di un utente koin quando salda:
+// Amount è negativo per chi paga e positivo per chi riceve.
+type SettlementEntry struct {
+	User        dbgen.User
+	Account     dbgen.Account
+	Category    dbgen.Category
+	Amount      int64
+	Description string
+}
+
+// MemberBalance riassume la posizione di un membro: Net positivo indica un credito verso il
+// gruppo, negativo un debito.
+type MemberBalance struct {
+	Member dbgen.SplitMember
+	Paid   int64
+	Owed   int64
+	Net    int64
+}
+
+// SettleUpTransfer è un pagamento suggerito per azzerare i saldi.
+type SettleUpTransfer struct {
+	FromMemberID int64
+	ToMemberID   int64
+	Amount       int64
+}
+
+type SplitGroupDetail struct {
+	Group       dbgen.SplitGroup
+	Members     []dbgen.SplitMember
+	Balances    []MemberBalance
+	SettleUp    []SettleUpTransfer
+	Settlements []dbgen.SplitSettlement
+}
+
+type SplitExpenseDetail struct {
+	Expense dbgen.GetSplitExpensesRow
+	Shares  []dbgen.SplitShare
+}
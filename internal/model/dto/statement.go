@@ -0,0 +1,20 @@
+package dto
+
+import (
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+// Statement è l'estratto conto di un mese o di un anno, dal DateFrom al DateTo inclusi: per un
+// periodo in corso DateTo è la data di oggi.
+type Statement struct {
+	UserEmail  string
+	Month      *time.Month
+	Year       int
+	DateFrom   time.Time
+	DateTo     time.Time
+	Accounts   []dbgen.GetAccountBalanceChangesRow
+	Categories []dbgen.GetCategoryTotalsByUserRow
+	Entries    []dbgen.GetStatementEntriesRow
+}
@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+// PriceIncrease segnala un aumento di prezzo: da Since l'abbonamento costa Amount invece di
+// PreviousAmount.
+type PriceIncrease struct {
+	PreviousAmount int64
+	Amount         int64
+	Since          time.Time
+}
+
+// Subscription è un abbonamento riconosciuto nello storico: spese dello stesso beneficiario (o con
+// descrizione simile) sullo stesso account, di importo simile e a intervalli regolari. Gli importi
+// sono positivi, in centesimi; Key identifica l'abbonamento tra una rilevazione e l'altra.
+type Subscription struct {
+	Key           string
+	AccountID     int64
+	AccountName   string
+	Currency      string
+	CategoryID    int64
+	CategoryName  string
+	PayeeID       *int64
+	Name          string
+	Frequency     Frequency
+	RepeatEvery   int32
+	Amount        int64
+	Occurrences   int
+	FirstDate     time.Time
+	LastDate      time.Time
+	NextDate      time.Time
+	AnnualCost    int64
+	PriceIncrease *PriceIncrease
+	// RecurringID è la transazione ricorrente che già traccia l'abbonamento, se esiste.
+	RecurringID *int64
+}
+
+type TrackSubscriptionDto struct {
+	UserID int64
+	Key    string
+}
@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+type CreateTagDto struct {
+	UserID int64
+	Name   string
+}
+
+type RenameTagDto struct {
+	UserID int64
+	TagID  int64
+	Name   string
+}
+
+type MergeTagsDto struct {
+	UserID      int64
+	SourceTagID int64
+	TargetTagID int64
+}
+
+type SetTransactionTagsDto struct {
+	UserID        int64
+	TransactionID int64
+	Tags          []string
+}
+
+type TagReportDto struct {
+	UserID   int64
+	DateFrom time.Time
+	DateTo   time.Time
+}
@@ -0,0 +1,7 @@
+package dto
+
+type CreateWebhookDto struct {
+	UserID      int64
+	URL         string
+	Description *string
+}
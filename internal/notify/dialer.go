@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// sharedAddressSpace (RFC 6598) è usato dal NAT dei provider e non è raggiungibile da internet.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicClient restituisce un client HTTP che si connette solo a indirizzi pubblici. Gli URL dei
+// webhook sono scelti dagli utenti: il controllo è sull'indirizzo risolto al momento della
+// connessione, quindi copre anche i nomi DNS che puntano alla rete interna e i redirect.
+func publicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkPublicAddress,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Nessun proxy: la connessione deve andare all'indirizzo controllato
+			DialContext:         dialer.DialContext,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+	}
+}
+
+// checkPublicAddress rifiuta le connessioni verso loopback, reti private, link-local (compresi
+// i metadata dei cloud), multicast e indirizzi non specificati.
+func checkPublicAddress(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%s is not a public address", ip)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckPublicAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		public  bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.1.2.3:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.10:8080", false},
+		{"169.254.169.254:80", false},
+		{"100.64.0.1:80", false},
+		{"0.0.0.0:80", false},
+		{"224.0.0.1:80", false},
+		{"[fd00::1]:80", false},
+		{"[fe80::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+	}
+	for _, test := range tests {
+		err := checkPublicAddress("tcp", test.address, nil)
+		if public := err == nil; public != test.public {
+			t.Errorf("checkPublicAddress(%s) = %v, want public %v", test.address, err, test.public)
+		}
+	}
+}
+
+func TestEventSenderRefusesLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	statusCode, err := NewEventSender().Send(context.Background(), server.URL, "whsec_test", Event{ID: 1, Name: "account.created", Data: []byte(`{}`), CreatedAt: time.Now()})
+	if err == nil || statusCode != 0 || called {
+		t.Fatalf("Send to %s = %d, %v, want refused connection", server.URL, statusCode, err)
+	}
+}
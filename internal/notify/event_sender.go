@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Event è un evento dell'outbox da consegnare a un endpoint webhook registrato dall'utente.
+type Event struct {
+	ID         int64
+	DeliveryID int64
+	Name       string
+	Data       json.RawMessage
+	CreatedAt  time.Time
+}
+
+// eventPayload è il corpo JSON firmato inviato agli endpoint.
+type eventPayload struct {
+	ID        int64           `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"createdAt"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sign calcola la firma di un corpo: HMAC-SHA256 con il segreto dell'endpoint di
+// "<timestamp>.<corpo>", in esadecimale. Il timestamp nella firma impedisce di riusare una
+// richiesta intercettata in un secondo momento.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// EventSender invia gli eventi in POST firmandoli nell'header X-Koin-Signature
+// ("t=<timestamp>,v1=<firma>"); qualsiasi risposta diversa da 2xx è un errore.
+type EventSender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewEventSender() *EventSender {
+	return &EventSender{
+		client: publicClient(15 * time.Second),
+		now:    time.Now,
+	}
+}
+
+// Send consegna l'evento e restituisce il codice HTTP della risposta, 0 se la richiesta non è
+// arrivata al server.
+func (sender *EventSender) Send(ctx context.Context, url string, secret string, event Event) (int, error) {
+	body, err := json.Marshal(eventPayload{
+		ID:        event.ID,
+		Event:     event.Name,
+		CreatedAt: event.CreatedAt,
+		Data:      event.Data,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("webhook: %w", err)
+	}
+	timestamp := sender.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "koin-webhooks")
+	req.Header.Set("X-Koin-Event", event.Name)
+	req.Header.Set("X-Koin-Delivery", strconv.FormatInt(event.DeliveryID, 10))
+	req.Header.Set("X-Koin-Signature", fmt.Sprintf("t=%d,v1=%s", timestamp, Sign(secret, timestamp, body)))
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook: %s responded %s", url, resp.Status)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Message è una notifica da consegnare su un canale esterno. HTML, se presente, è la versione
+// HTML di Body per i canali che la supportano (l'email); NotificationID è zero per i messaggi
+// che non passano dalla inbox, come i riepiloghi periodici.
+type Message struct {
+	NotificationID int64
+	UserID         int64
+	Kind           string
+	Title          string
+	Body           string
+	HTML           string
+	CreatedAt      time.Time
+}
+
+// Sender consegna un messaggio a una destinazione del proprio canale: un indirizzo email o
+// l'URL di un webhook. Un errore indica che la consegna va ritentata.
+type Sender interface {
+	Send(ctx context.Context, target string, message Message) error
+}
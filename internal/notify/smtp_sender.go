@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     int    // 25, 587 con STARTTLS, 1025 per MailHog e simili
+	Username string // vuoto per i server senza autenticazione
+	Password string
+	From     string
+}
+
+// SMTPSender invia le notifiche come email in testo semplice. Usa STARTTLS quando il server lo
+// offre; l'autenticazione PLAIN è ammessa solo su connessioni cifrate o verso localhost.
+type SMTPSender struct {
+	config    SMTPConfig
+	dialer    net.Dialer
+	tlsConfig *tls.Config
+}
+
+func NewSMTPSender(config SMTPConfig) (*SMTPSender, error) {
+	if config.Host == "" || config.From == "" {
+		return nil, fmt.Errorf("smtp sender: host and from address are required")
+	}
+	if config.Port == 0 {
+		config.Port = 25
+	}
+	return &SMTPSender{
+		config: config,
+		dialer: net.Dialer{Timeout: 15 * time.Second},
+		// Il certificato del server viene verificato sul nome configurato
+		tlsConfig: &tls.Config{ServerName: config.Host, MinVersion: tls.VersionTLS12},
+	}, nil
+}
+
+func (sender *SMTPSender) Send(ctx context.Context, target string, message Message) error {
+	addr := net.JoinHostPort(sender.config.Host, strconv.Itoa(sender.config.Port))
+	conn, err := sender.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp: dial %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	} else {
+		_ = conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	client, err := smtp.NewClient(conn, sender.config.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(sender.tlsConfig.Clone()); err != nil {
+			return fmt.Errorf("smtp: starttls: %w", err)
+		}
+	}
+	if sender.config.Username != "" {
+		auth := smtp.PlainAuth("", sender.config.Username, sender.config.Password, sender.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp: auth: %w", err)
+		}
+	}
+	if err := client.Mail(sender.config.From); err != nil {
+		return fmt.Errorf("smtp: mail from: %w", err)
+	}
+	if err := client.Rcpt(target); err != nil {
+		return fmt.Errorf("smtp: rcpt to %s: %w", target, err)
+	}
+	body, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: data: %w", err)
+	}
+	if _, err := body.Write(sender.email(target, message)); err != nil {
+		return fmt.Errorf("smtp: write message: %w", err)
+	}
+	if err := body.Close(); err != nil {
+		return fmt.Errorf("smtp: send message: %w", err)
+	}
+	return client.Quit()
+}
+
+// email compone il messaggio RFC 5322; l'oggetto è codificato perché contiene lettere accentate.
+func (sender *SMTPSender) email(target string, message Message) []byte {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "From: %s\r\n", sender.config.From)
+	fmt.Fprintf(&builder, "To: %s\r\n", target)
+	fmt.Fprintf(&builder, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Title))
+	fmt.Fprintf(&builder, "Date: %s\r\n", message.CreatedAt.Format(time.RFC1123Z))
+	if message.NotificationID != 0 {
+		fmt.Fprintf(&builder, "Message-ID: <koin-notification-%d@%s>\r\n", message.NotificationID, sender.config.Host)
+	} else {
+		fmt.Fprintf(&builder, "Message-ID: <koin-%s-%d-%d@%s>\r\n", strings.ToLower(message.Kind), message.UserID, message.CreatedAt.UnixNano(), sender.config.Host)
+	}
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	if message.HTML == "" {
+		writeTextPart(&builder, "text/plain", message.Body)
+		return []byte(builder.String())
+	}
+
+	// Versione testuale e HTML: il client mostra la migliore che supporta
+	boundary := fmt.Sprintf("koin-%d", message.CreatedAt.UnixNano())
+	fmt.Fprintf(&builder, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&builder, "--%s\r\n", boundary)
+	writeTextPart(&builder, "text/plain", message.Body)
+	fmt.Fprintf(&builder, "--%s\r\n", boundary)
+	writeTextPart(&builder, "text/html", message.HTML)
+	fmt.Fprintf(&builder, "--%s--\r\n", boundary)
+	return []byte(builder.String())
+}
+
+func writeTextPart(builder *strings.Builder, contentType string, text string) {
+	fmt.Fprintf(builder, "Content-Type: %s; charset=utf-8\r\n", contentType)
+	builder.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\n", "\r\n"))
+	builder.WriteString("\r\n")
+}
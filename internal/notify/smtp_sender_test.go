@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer accetta una sola connessione e risponde come un server SMTP minimale, con
+// STARTTLS se tlsConfig non è nil, come MailHog altrimenti.
+type fakeSMTPServer struct {
+	listener  net.Listener
+	tlsConfig *tls.Config
+	done      chan struct{}
+	usedTLS   bool
+	rcpt      string
+	data      string
+	err       error
+}
+
+func startFakeSMTPServer(t *testing.T, tlsConfig *tls.Config) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTPServer{listener: listener, tlsConfig: tlsConfig, done: make(chan struct{})}
+	t.Cleanup(func() { _ = listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (server *fakeSMTPServer) port() int {
+	return server.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (server *fakeSMTPServer) serve() {
+	defer close(server.done)
+	conn, err := server.listener.Accept()
+	if err != nil {
+		server.err = err
+		return
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	text := textproto.NewConn(conn)
+	reply := func(line string) { _ = text.PrintfLine("%s", line) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			server.err = err
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO":
+			if server.tlsConfig != nil && !server.usedTLS {
+				reply("250-localhost")
+				reply("250 STARTTLS")
+			} else {
+				reply("250 localhost")
+			}
+		case "STARTTLS":
+			reply("220 ready")
+			tlsConn := tls.Server(conn, server.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				server.err = err
+				return
+			}
+			server.usedTLS = true
+			text = textproto.NewConn(tlsConn)
+			reply = func(line string) { _ = text.PrintfLine("%s", line) }
+		case "MAIL":
+			reply("250 ok")
+		case "RCPT":
+			server.rcpt = line
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				server.err = err
+				return
+			}
+			server.data = string(data)
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 unsupported")
+		}
+	}
+}
+
+func testMessage() Message {
+	return Message{
+		NotificationID: 7,
+		UserID:         1,
+		Kind:           "LOW_BALANCE",
+		Title:          "Saldo basso: è sotto la soglia",
+		Body:           "Il conto Corrente è sotto 100,00 EUR",
+		CreatedAt:      time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSMTPSenderUsesStartTLS(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	server := startFakeSMTPServer(t, tlsServer.TLS)
+
+	// Il certificato di prova di httptest vale per 127.0.0.1
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), From: "koin@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender.tlsConfig.RootCAs = tlsServer.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	if err := sender.Send(context.Background(), "mario@example.com", testMessage()); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	<-server.done
+	if server.err != nil {
+		t.Fatalf("server: %v", server.err)
+	}
+	if !server.usedTLS {
+		t.Error("message sent without STARTTLS")
+	}
+	if !strings.Contains(server.rcpt, "mario@example.com") {
+		t.Errorf("rcpt %q", server.rcpt)
+	}
+}
+
+func TestSMTPSenderRejectsUntrustedCertificate(t *testing.T) {
+	tlsServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer tlsServer.Close()
+	server := startFakeSMTPServer(t, tlsServer.TLS)
+
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), From: "koin@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Send(context.Background(), "mario@example.com", testMessage()); err == nil {
+		t.Fatal("expected a certificate error")
+	}
+}
+
+func TestSMTPSenderWithoutStartTLS(t *testing.T) {
+	server := startFakeSMTPServer(t, nil)
+
+	sender, err := NewSMTPSender(SMTPConfig{Host: "127.0.0.1", Port: server.port(), From: "koin@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := testMessage()
+	message.HTML = "<p>Il conto Corrente è sotto 100,00 EUR</p>"
+	if err := sender.Send(context.Background(), "mario@example.com", message); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	<-server.done
+	if server.err != nil {
+		t.Fatalf("server: %v", server.err)
+	}
+
+	headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(server.data))).ReadMIMEHeader()
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if got := headers.Get("Subject"); !strings.HasPrefix(got, "=?utf-8?q?") {
+		t.Errorf("subject not encoded: %q", got)
+	}
+	if got := headers.Get("Message-Id"); got != "<koin-notification-7@127.0.0.1>" {
+		t.Errorf("message id %q", got)
+	}
+	if !strings.Contains(headers.Get("Content-Type"), "multipart/alternative") {
+		t.Errorf("content type %q", headers.Get("Content-Type"))
+	}
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhookPayload è il corpo JSON inviato ai webhook.
+type webhookPayload struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"userId"`
+	Kind      string    `json:"kind"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// WebhookSender invia le notifiche in POST come JSON; qualsiasi risposta diversa da 2xx è un
+// errore e la consegna viene ritentata.
+type WebhookSender struct {
+	client *http.Client
+}
+
+func NewWebhookSender() *WebhookSender {
+	return &WebhookSender{
+		client: publicClient(15 * time.Second),
+	}
+}
+
+func (sender *WebhookSender) Send(ctx context.Context, target string, message Message) error {
+	payload, err := json.Marshal(webhookPayload{
+		ID:        message.NotificationID,
+		UserID:    message.UserID,
+		Kind:      message.Kind,
+		Title:     message.Title,
+		Body:      message.Body,
+		CreatedAt: message.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "koin-notifications")
+
+	resp, err := sender.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s responded %s", target, resp.Status)
+	}
+	return nil
+}
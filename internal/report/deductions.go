@@ -0,0 +1,137 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"koin/internal/model/dto"
+)
+
+var (
+	deductionSummaryColumns = []pdfColumn{
+		{title: "Rigo", width: 110},
+		{title: "Spesa", width: 205.28},
+		{title: "Movimenti", width: 60, right: true},
+		{title: "Senza ricevuta", width: 70, right: true},
+		{title: "Totale", width: 70, right: true},
+	}
+	deductionEntryColumns = []pdfColumn{
+		{title: "Data", width: 55},
+		{title: "Beneficiario e descrizione", width: 200.28},
+		{title: "Ricevute", width: 180},
+		{title: "Importo", width: 80, right: true},
+	}
+)
+
+// DeductionsTitle è il titolo del riepilogo, es. "Spese detraibili 2026".
+func DeductionsTitle(deductions dto.DeductionReport) string {
+	return fmt.Sprintf("Spese detraibili %d", deductions.Year)
+}
+
+// DeductionsFilename è il nome del file esportato, es. "koin-detrazioni-2026.csv".
+func DeductionsFilename(deductions dto.DeductionReport, extension string) string {
+	return fmt.Sprintf("koin-detrazioni-%d.%s", deductions.Year, extension)
+}
+
+// RenderDeductionsPDF compone il riepilogo delle spese detraibili: i totali per rigo del quadro
+// E e, per ogni rigo, l'elenco delle spese con le ricevute allegate.
+func RenderDeductionsPDF(deductions dto.DeductionReport, createdAt time.Time) ([]byte, error) {
+	title := DeductionsTitle(deductions)
+	w := newPDFWriter(title)
+	w.banner(title, fmt.Sprintf("Quadro E del modello 730 • %s • generato il %s", deductions.UserEmail, Date(createdAt)))
+
+	w.heading("Riepilogo per rigo")
+	if len(deductions.Lines) == 0 {
+		w.y += 12
+		w.text(pageMargin, w.y, fontRegular, 9, colorMuted, "Nessuna spesa detraibile nell'anno")
+		return w.bytes(createdAt)
+	}
+	w.tableHeader(deductionSummaryColumns)
+	for _, line := range deductions.Lines {
+		w.tableRow(deductionSummaryColumns, []string{
+			line.Line,
+			line.Label,
+			strconv.Itoa(len(line.Entries)),
+			strconv.Itoa(int(line.MissingReceipts)),
+			Money(line.Total),
+		}, fontRegular, nil)
+	}
+	w.tableRow(deductionSummaryColumns, []string{"Totale", "", "", "", Money(deductions.Total)}, fontBold, nil)
+	w.y += 14
+	w.text(pageMargin, w.y, fontRegular, 8, colorMuted,
+		"Gli importi sono le spese sostenute: franchigie e limiti della detrazione si applicano in dichiarazione.")
+
+	for _, line := range deductions.Lines {
+		w.heading(line.Line + " • " + line.Label)
+		w.tableHeader(deductionEntryColumns)
+		w.onNewPage = func() { w.tableHeader(deductionEntryColumns) }
+		for _, entry := range line.Entries {
+			receipts := strings.Join(entry.Receipts, ", ")
+			receiptColor := colorText
+			if receipts == "" && entry.Deductible() > 0 {
+				receipts = "Nessuna ricevuta"
+				receiptColor = colorNegative
+			}
+			w.tableRow(deductionEntryColumns, []string{
+				Date(entry.Entry.OccurredAt),
+				deductionDetails(entry),
+				receipts,
+				Money(entry.Deductible()),
+			}, fontRegular, []pdfColor{colorText, colorText, receiptColor, colorText})
+		}
+		w.tableRow(deductionEntryColumns, []string{"Totale", "", "", Money(line.Total)}, fontBold, nil)
+		w.onNewPage = nil
+	}
+
+	return w.bytes(createdAt)
+}
+
+// RenderDeductionsCSV esporta una riga per spesa, separata da punto e virgola e con la virgola
+// decimale, come si aspetta Excel in italiano; il BOM iniziale gli fa leggere il file in UTF-8.
+// Le ricevute sono separate da " | ".
+func RenderDeductionsCSV(deductions dto.DeductionReport) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	writer := csv.NewWriter(&buf)
+	writer.Comma = ';'
+
+	records := [][]string{{"Codice", "Rigo", "Spesa", "Data", "Account", "Categoria", "Beneficiario", "Descrizione", "Importo", "Ricevute"}}
+	for _, line := range deductions.Lines {
+		for _, entry := range line.Entries {
+			records = append(records, []string{
+				string(line.Code),
+				line.Line,
+				line.Label,
+				entry.Entry.OccurredAt.Format("2006-01-02"),
+				entry.Entry.AccountName,
+				entry.Entry.CategoryName.String,
+				entry.Entry.PayeeName.String,
+				entry.Entry.Description.String,
+				strings.ReplaceAll(Money(entry.Deductible()), ".", ""),
+				strings.Join(entry.Receipts, " | "),
+			})
+		}
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("write deductions csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func deductionDetails(entry dto.DeductionEntry) string {
+	var parts []string
+	if entry.Entry.PayeeName.Valid {
+		parts = append(parts, entry.Entry.PayeeName.String)
+	}
+	if entry.Entry.Description.Valid && entry.Entry.Description.String != "" {
+		parts = append(parts, entry.Entry.Description.String)
+	}
+	if len(parts) == 0 && entry.Entry.CategoryName.Valid {
+		parts = append(parts, entry.Entry.CategoryName.String)
+	}
+	return strings.Join(parts, " • ")
+}
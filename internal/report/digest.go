@@ -0,0 +1,67 @@
+package report
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+//go:embed templates/*.html
+var templates embed.FS
+
+var digestTemplate = template.Must(template.New("digest.html").Funcs(template.FuncMap{
+	"money": Money,
+	"date":  Date,
+	"signed": func(cents int64) string {
+		if cents > 0 {
+			return "+" + Money(cents)
+		}
+		return Money(cents)
+	},
+	"amountColor": func(cents int64) string {
+		if cents < 0 {
+			return "#d32f2f"
+		}
+		return "#2e7d32"
+	},
+	"change": func(account dbgen.GetAccountBalanceChangesRow) int64 {
+		return account.ClosingBalance - account.OpeningBalance
+	},
+}).ParseFS(templates, "templates/digest.html"))
+
+type digestView struct {
+	Title       string
+	Period      string
+	BudgetMonth string
+	Net         int64
+	Digest      dto.Digest
+}
+
+// DigestTitle è l'oggetto dell'email del riepilogo, es. "Riepilogo settimanale 6 – 12 ottobre 2026".
+func DigestTitle(digest dto.Digest) string {
+	if digest.Frequency == dto.Monthly {
+		return "Riepilogo mensile " + MonthName(digest.DateFrom)
+	}
+	return "Riepilogo settimanale " + DateRange(digest.DateFrom, digest.DateTo)
+}
+
+// RenderDigest compone il riepilogo come pagina HTML adatta ai client email.
+func RenderDigest(digest dto.Digest) (string, error) {
+	view := digestView{
+		Title:       DigestTitle(digest),
+		Period:      fmt.Sprintf("Dal %s al %s", Date(digest.DateFrom), Date(digest.DateTo)),
+		BudgetMonth: MonthName(digest.BudgetMonth),
+		Net:         digest.Income - digest.Expenses,
+		Digest:      digest,
+	}
+
+	var buf bytes.Buffer
+	if err := digestTemplate.Execute(&buf, view); err != nil {
+		return "", fmt.Errorf("render digest: %w", err)
+	}
+	return buf.String(), nil
+}
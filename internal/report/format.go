@@ -0,0 +1,48 @@
+package report
+
+import (
+	"fmt"
+	"time"
+)
+
+var italianMonths = [...]string{
+	"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno",
+	"luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre",
+}
+
+// Money formatta un importo in centesimi all'italiana, con il separatore delle migliaia:
+// -123456 diventa "-1.234,56".
+func Money(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	units := fmt.Sprintf("%d", cents/100)
+	for i := len(units) - 3; i > 0; i -= 3 {
+		units = units[:i] + "." + units[i:]
+	}
+	return fmt.Sprintf("%s%s,%02d", sign, units, cents%100)
+}
+
+// Date formatta una data come 02/01/2006.
+func Date(date time.Time) string {
+	return date.Format("02/01/2006")
+}
+
+// MonthName restituisce il mese per esteso con l'anno, es. "ottobre 2026".
+func MonthName(date time.Time) string {
+	return fmt.Sprintf("%s %d", italianMonths[date.Month()-1], date.Year())
+}
+
+// DateRange descrive un intervallo di date, es. "6 – 12 ottobre 2026" o "28 settembre – 4 ottobre 2026".
+func DateRange(from time.Time, to time.Time) string {
+	switch {
+	case from.Year() != to.Year():
+		return fmt.Sprintf("%d %s %d – %d %s", from.Day(), italianMonths[from.Month()-1], from.Year(), to.Day(), MonthName(to))
+	case from.Month() != to.Month():
+		return fmt.Sprintf("%d %s – %d %s", from.Day(), italianMonths[from.Month()-1], to.Day(), MonthName(to))
+	default:
+		return fmt.Sprintf("%d – %d %s", from.Day(), to.Day(), MonthName(to))
+	}
+}
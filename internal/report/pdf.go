@@ -0,0 +1,357 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Scrittore PDF minimale, senza dipendenze: pagine A4 con testo nei font standard Helvetica e
+// Helvetica-Bold (codifica WinAnsi, quindi lettere accentate ed euro), linee e rettangoli pieni.
+// Le coordinate partono dall'angolo in alto a sinistra e sono in punti tipografici.
+
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	pageMargin = 40.0
+)
+
+type pdfFont int
+
+const (
+	fontRegular pdfFont = iota
+	fontBold
+)
+
+type pdfColor struct{ r, g, b float64 }
+
+// Colori di common.css
+var (
+	colorText     = pdfColor{0.2, 0.2, 0.2}       // #333
+	colorMuted    = pdfColor{0.6, 0.6, 0.6}       // #999
+	colorAccent   = pdfColor{0.4, 0.494, 0.918}   // #667eea
+	colorRule     = pdfColor{0.867, 0.867, 0.867} // #ddd
+	colorShade    = pdfColor{0.976, 0.976, 0.976} // #f9f9f9
+	colorNegative = pdfColor{0.827, 0.184, 0.184} // #d32f2f
+	colorPositive = pdfColor{0.18, 0.49, 0.196}   // #2e7d32
+	colorWhite    = pdfColor{1, 1, 1}
+)
+
+type pdfWriter struct {
+	title string
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	// y è la posizione della prossima riga nella pagina corrente
+	y float64
+	// onNewPage disegna l'intestazione ripetuta sulle pagine successive alla prima, es. le
+	// colonne di una tabella spezzata
+	onNewPage func()
+}
+
+func newPDFWriter(title string) *pdfWriter {
+	w := &pdfWriter{title: title}
+	w.addPage()
+	return w
+}
+
+func (w *pdfWriter) addPage() {
+	w.page = &bytes.Buffer{}
+	w.pages = append(w.pages, w.page)
+	w.y = pageMargin
+	if w.onNewPage != nil {
+		w.onNewPage()
+	}
+}
+
+// ensureSpace passa a una nuova pagina se nella corrente non restano height punti.
+func (w *pdfWriter) ensureSpace(height float64) {
+	if w.y+height > pageHeight-pageMargin-20 {
+		w.addPage()
+	}
+}
+
+func (w *pdfWriter) setColor(color pdfColor, stroke bool) {
+	op := "rg"
+	if stroke {
+		op = "RG"
+	}
+	fmt.Fprintf(w.page, "%.3f %.3f %.3f %s\n", color.r, color.g, color.b, op)
+}
+
+// text scrive una riga di testo con la linea di base a y.
+func (w *pdfWriter) text(x float64, y float64, font pdfFont, size float64, color pdfColor, text string) {
+	w.setColor(color, false)
+	fmt.Fprintf(w.page, "BT /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font+1, size, x, pageHeight-y, pdfEscape(winAnsi(text)))
+}
+
+// textRight scrive il testo allineato a destra su right.
+func (w *pdfWriter) textRight(right float64, y float64, font pdfFont, size float64, color pdfColor, text string) {
+	w.text(right-textWidth(text, font, size), y, font, size, color, text)
+}
+
+func (w *pdfWriter) line(x1 float64, y1 float64, x2 float64, y2 float64, color pdfColor, width float64) {
+	w.setColor(color, true)
+	fmt.Fprintf(w.page, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, pageHeight-y1, x2, pageHeight-y2)
+}
+
+func (w *pdfWriter) fillRect(x float64, y float64, width float64, height float64, color pdfColor) {
+	w.setColor(color, false)
+	fmt.Fprintf(w.page, "%.2f %.2f %.2f %.2f re f\n", x, pageHeight-y-height, width, height)
+}
+
+// bytes compone il documento, con il numero di pagina in fondo a ciascuna.
+func (w *pdfWriter) bytes(createdAt time.Time) ([]byte, error) {
+	for i, page := range w.pages {
+		w.page = page
+		w.text(pageMargin, pageHeight-pageMargin+10, fontRegular, 8, colorMuted, w.title)
+		w.textRight(pageWidth-pageMargin, pageHeight-pageMargin+10, fontRegular, 8, colorMuted, fmt.Sprintf("Pagina %d di %d", i+1, len(w.pages)))
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1 catalogo, 2 albero delle pagine, 3-4 font, 5 informazioni, poi pagina e contenuto per ogni pagina
+	const firstPage = 6
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title %s /Producer (koin) /CreationDate (D:%s) >>", pdfTextString(w.title), createdAt.UTC().Format("20060102150405Z")))
+
+	for i, page := range w.pages {
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return nil, fmt.Errorf("compress pdf page: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("compress pdf page: %w", err)
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes(), nil
+}
+
+// truncate accorcia il testo con "…" perché stia in width punti.
+func truncate(text string, font pdfFont, size float64, width float64) string {
+	if textWidth(text, font, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && textWidth(string(runes)+"…", font, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+func textWidth(text string, font pdfFont, size float64) float64 {
+	widths := &helveticaWidths
+	if font == fontBold {
+		widths = &helveticaBoldWidths
+	}
+	total := 0
+	for _, r := range text {
+		total += glyphWidth(widths, r)
+	}
+	return float64(total) * size / 1000
+}
+
+// glyphWidth restituisce la larghezza in millesimi di punto del carattere; le lettere accentate
+// hanno la larghezza della lettera di base.
+func glyphWidth(widths *[95]int, r rune) int {
+	if r >= 32 && r <= 126 {
+		return widths[r-32]
+	}
+	if base, ok := accentBase[r]; ok {
+		if base == 'i' || base == 'I' {
+			return 278
+		}
+		return widths[base-32]
+	}
+	switch r {
+	case '•':
+		return 350
+	case '…':
+		return 1000
+	case '°':
+		return 400
+	}
+	return 556
+}
+
+var accentBase = map[rune]rune{
+	'à': 'a', 'á': 'a', 'è': 'e', 'é': 'e', 'ì': 'i', 'í': 'i', 'ò': 'o', 'ó': 'o', 'ù': 'u', 'ú': 'u',
+	'À': 'A', 'Á': 'A', 'È': 'E', 'É': 'E', 'Ì': 'I', 'Í': 'I', 'Ò': 'O', 'Ó': 'O', 'Ù': 'U', 'Ú': 'U',
+	'ä': 'a', 'ë': 'e', 'ï': 'i', 'ö': 'o', 'ü': 'u', 'ç': 'c', 'ñ': 'n', 'Ç': 'C', 'Ñ': 'N',
+}
+
+// Larghezze dei caratteri ASCII stampabili (dallo spazio alla tilde) dalle metriche AFM standard.
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+var helveticaBoldWidths = [95]int{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
+
+// winAnsi converte il testo nella codifica dei font standard; i caratteri non rappresentabili
+// diventano "?".
+func winAnsi(text string) []byte {
+	out := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r < 128 || (r >= 0xA0 && r <= 0xFF):
+			out = append(out, byte(r))
+		case r == '€':
+			out = append(out, 0x80)
+		case r == '…':
+			out = append(out, 0x85)
+		case r == '‘':
+			out = append(out, 0x91)
+		case r == '’':
+			out = append(out, 0x92)
+		case r == '“':
+			out = append(out, 0x93)
+		case r == '”':
+			out = append(out, 0x94)
+		case r == '•':
+			out = append(out, 0x95)
+		case r == '–':
+			out = append(out, 0x96)
+		case r == '—':
+			out = append(out, 0x97)
+		default:
+			out = append(out, '?')
+		}
+	}
+	return out
+}
+
+func pdfEscape(text []byte) string {
+	var builder strings.Builder
+	for _, c := range text {
+		switch c {
+		case '\\', '(', ')':
+			builder.WriteByte('\\')
+			builder.WriteByte(c)
+		case '\n', '\r', '\t':
+			builder.WriteByte(' ')
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// pdfTextString codifica una stringa dei metadati in UTF-16BE.
+func pdfTextString(text string) string {
+	var builder strings.Builder
+	builder.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&builder, "%04X", unit)
+	}
+	builder.WriteString(">")
+	return builder.String()
+}
+
+// pdfColumn è una colonna di tabella; le colonne right (gli importi) sono allineate a destra.
+type pdfColumn struct {
+	title string
+	width float64
+	right bool
+}
+
+const (
+	rowHeight = 16.0
+	cellPad   = 4.0
+	tableSize = 8.5
+)
+
+// tableHeader disegna l'intestazione delle colonne su sfondo grigio.
+func (w *pdfWriter) tableHeader(columns []pdfColumn) {
+	w.ensureSpace(2 * rowHeight)
+	w.fillRect(pageMargin, w.y, pageWidth-2*pageMargin, rowHeight, colorShade)
+	w.tableCells(columns, columnTitles(columns), fontBold, nil)
+}
+
+// tableRow disegna una riga; colors, se presente, indica il colore di ogni cella.
+func (w *pdfWriter) tableRow(columns []pdfColumn, cells []string, font pdfFont, colors []pdfColor) {
+	w.ensureSpace(rowHeight)
+	w.tableCells(columns, cells, font, colors)
+	w.line(pageMargin, w.y, pageWidth-pageMargin, w.y, colorRule, 0.5)
+}
+
+func (w *pdfWriter) tableCells(columns []pdfColumn, cells []string, font pdfFont, colors []pdfColor) {
+	baseline := w.y + rowHeight - 5
+	x := pageMargin
+	for i, column := range columns {
+		color := colorText
+		if colors != nil {
+			color = colors[i]
+		}
+		text := truncate(cells[i], font, tableSize, column.width-2*cellPad)
+		if column.right {
+			w.textRight(x+column.width-cellPad, baseline, font, tableSize, color, text)
+		} else {
+			w.text(x+cellPad, baseline, font, tableSize, color, text)
+		}
+		x += column.width
+	}
+	w.y += rowHeight
+}
+
+// banner disegna la fascia colorata in testa alla prima pagina, con titolo e sottotitolo.
+func (w *pdfWriter) banner(title string, subtitle string) {
+	w.fillRect(0, 0, pageWidth, 90, colorAccent)
+	w.text(pageMargin, 38, fontBold, 11, colorWhite, "Koin")
+	w.text(pageMargin, 62, fontBold, 20, colorWhite, title)
+	w.text(pageMargin, 78, fontRegular, 9, colorWhite, subtitle)
+	w.y = 100
+}
+
+// heading scrive il titolo di una sezione.
+func (w *pdfWriter) heading(text string) {
+	w.ensureSpace(3 * rowHeight)
+	w.y += 18
+	w.text(pageMargin, w.y, fontBold, 13, colorText, text)
+	w.y += 8
+}
+
+func columnTitles(columns []pdfColumn) []string {
+	titles := make([]string, len(columns))
+	for i, column := range columns {
+		titles[i] = column.title
+	}
+	return titles
+}
@@ -0,0 +1,157 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+var (
+	accountColumns = []pdfColumn{
+		{title: "Account", width: 167},
+		{title: "Valuta", width: 40},
+		{title: "Saldo iniziale", width: 77, right: true},
+		{title: "Entrate", width: 77, right: true},
+		{title: "Uscite", width: 77, right: true},
+		{title: "Saldo finale", width: 77.28, right: true},
+	}
+	categoryColumns = []pdfColumn{
+		{title: "Categoria", width: 315.28},
+		{title: "Quota", width: 100, right: true},
+		{title: "Totale", width: 100, right: true},
+	}
+	entryColumns = []pdfColumn{
+		{title: "Data", width: 55},
+		{title: "Account", width: 95},
+		{title: "Categoria", width: 95},
+		{title: "Beneficiario e descrizione", width: 185.28},
+		{title: "Importo", width: 85, right: true},
+	}
+)
+
+// StatementTitle è il titolo dell'estratto conto, es. "Estratto conto ottobre 2026" o
+// "Estratto conto 2026".
+func StatementTitle(statement dto.Statement) string {
+	if statement.Month != nil {
+		return "Estratto conto " + MonthName(statement.DateFrom)
+	}
+	return fmt.Sprintf("Estratto conto %d", statement.Year)
+}
+
+// StatementFilename è il nome del file PDF, es. "koin-estratto-2026-10.pdf".
+func StatementFilename(statement dto.Statement) string {
+	if statement.Month != nil {
+		return fmt.Sprintf("koin-estratto-%d-%02d.pdf", statement.Year, *statement.Month)
+	}
+	return fmt.Sprintf("koin-estratto-%d.pdf", statement.Year)
+}
+
+// RenderStatement compone l'estratto conto in PDF: riepilogo degli account con saldi iniziali e
+// finali, totali per categoria ed elenco completo dei movimenti.
+func RenderStatement(statement dto.Statement, createdAt time.Time) ([]byte, error) {
+	title := StatementTitle(statement)
+	w := newPDFWriter(title)
+
+	w.banner(title, fmt.Sprintf("Dal %s al %s • %s • generato il %s",
+		Date(statement.DateFrom), Date(statement.DateTo), statement.UserEmail, Date(createdAt)))
+
+	w.heading("Riepilogo account")
+	w.tableHeader(accountColumns)
+	for _, account := range statement.Accounts {
+		w.tableRow(accountColumns, []string{
+			account.Name,
+			account.Currency,
+			Money(account.OpeningBalance),
+			Money(account.Inflows),
+			Money(account.Outflows),
+			Money(account.ClosingBalance),
+		}, fontRegular, nil)
+	}
+
+	writeCategories(w, "Entrate per categoria", statement.Categories, dto.Income)
+	writeCategories(w, "Uscite per categoria", statement.Categories, dto.Expense)
+
+	w.heading("Movimenti")
+	if len(statement.Entries) == 0 {
+		w.y += 12
+		w.text(pageMargin, w.y, fontRegular, 9, colorMuted, "Nessun movimento nel periodo")
+	} else {
+		w.tableHeader(entryColumns)
+		w.onNewPage = func() { w.tableHeader(entryColumns) }
+		for _, entry := range statement.Entries {
+			color := colorPositive
+			if entry.Amount < 0 {
+				color = colorNegative
+			}
+			w.tableRow(entryColumns, []string{
+				Date(entry.OccurredAt),
+				entry.AccountName,
+				entryCategory(entry),
+				entryDetails(entry),
+				Money(entry.Amount) + " " + entry.Currency,
+			}, fontRegular, []pdfColor{colorText, colorText, colorText, colorText, color})
+		}
+		w.onNewPage = nil
+	}
+
+	return w.bytes(createdAt)
+}
+
+// writeCategories scrive i totali delle categorie di un tipo, dalla più grande, con la quota sul
+// totale del tipo. Le categorie senza movimenti sono omesse.
+func writeCategories(w *pdfWriter, title string, categories []dbgen.GetCategoryTotalsByUserRow, categoryType dto.CategoryType) {
+	var rows []dbgen.GetCategoryTotalsByUserRow
+	var total int64
+	for _, category := range categories {
+		if dto.CategoryType(category.Type) == categoryType && category.Total != 0 {
+			rows = append(rows, category)
+			total += category.Total
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return abs(rows[i].Total) > abs(rows[j].Total) })
+
+	w.heading(title)
+	if len(rows) == 0 {
+		w.y += 12
+		w.text(pageMargin, w.y, fontRegular, 9, colorMuted, "Nessun movimento nel periodo")
+		return
+	}
+	w.tableHeader(categoryColumns)
+	for _, row := range rows {
+		share := ""
+		if total != 0 {
+			share = fmt.Sprintf("%d%%", row.Total*100/total)
+		}
+		w.tableRow(categoryColumns, []string{row.Name, share, Money(row.Total)}, fontRegular, nil)
+	}
+	w.tableRow(categoryColumns, []string{"Totale", "", Money(total)}, fontBold, nil)
+}
+
+func entryCategory(entry dbgen.GetStatementEntriesRow) string {
+	if entry.CategoryName.Valid {
+		return entry.CategoryName.String
+	}
+	return "Trasferimento"
+}
+
+func entryDetails(entry dbgen.GetStatementEntriesRow) string {
+	var parts []string
+	if entry.PayeeName.Valid {
+		parts = append(parts, entry.PayeeName.String)
+	}
+	if entry.Description.Valid && entry.Description.String != "" {
+		parts = append(parts, entry.Description.String)
+	}
+	return strings.Join(parts, " • ")
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,176 @@
+package report
+
+import (
+	"bytes"
+	"compress/zlib"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+func TestMoney(t *testing.T) {
+	tests := map[int64]string{
+		0:          "0,00",
+		5:          "0,05",
+		-5:         "-0,05",
+		123456:     "1.234,56",
+		-123456789: "-1.234.567,89",
+		100000:     "1.000,00",
+	}
+	for cents, want := range tests {
+		if got := Money(cents); got != want {
+			t.Errorf("Money(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
+
+func TestStatementTitleAndFilename(t *testing.T) {
+	october := time.October
+	tests := []struct {
+		statement dto.Statement
+		title     string
+		filename  string
+	}{
+		{dto.Statement{Year: 2026, Month: &october, DateFrom: time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)}, "Estratto conto ottobre 2026", "koin-estratto-2026-10.pdf"},
+		{dto.Statement{Year: 2025, DateFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}, "Estratto conto 2025", "koin-estratto-2025.pdf"},
+	}
+	for _, tt := range tests {
+		if title := StatementTitle(tt.statement); title != tt.title {
+			t.Errorf("StatementTitle = %q, want %q", title, tt.title)
+		}
+		if filename := StatementFilename(tt.statement); filename != tt.filename {
+			t.Errorf("StatementFilename = %q, want %q", filename, tt.filename)
+		}
+	}
+}
+
+func TestRenderStatement(t *testing.T) {
+	october := time.October
+	statement := dto.Statement{
+		UserEmail: "mario@example.com",
+		Year:      2026,
+		Month:     &october,
+		DateFrom:  time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		DateTo:    time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC),
+		Accounts: []dbgen.GetAccountBalanceChangesRow{
+			{ID: 1, Name: "Conto corrente", Currency: "EUR", OpeningBalance: 100000, Inflows: 250000, Outflows: -180000, ClosingBalance: 170000},
+		},
+		Categories: []dbgen.GetCategoryTotalsByUserRow{
+			{ID: 1, Name: "Stipendio", Type: string(dto.Income), Total: 250000},
+			{ID: 2, Name: "Spesa", Type: string(dto.Expense), Total: -135000},
+			{ID: 3, Name: "Ristoranti", Type: string(dto.Expense), Total: -45000},
+			{ID: 4, Name: "Viaggi", Type: string(dto.Expense), Total: 0},
+		},
+	}
+	for i := range 120 {
+		statement.Entries = append(statement.Entries, dbgen.GetStatementEntriesRow{
+			ID:           int64(i + 1),
+			OccurredAt:   statement.DateFrom.AddDate(0, 0, i%31),
+			AccountName:  "Conto corrente",
+			Currency:     "EUR",
+			CategoryName: sql.NullString{String: "Spesa", Valid: true},
+			PayeeName:    sql.NullString{String: "Supermercato", Valid: true},
+			Description:  sql.NullString{String: fmt.Sprintf("Scontrino %d", i+1), Valid: true},
+			Amount:       -1125,
+		})
+	}
+
+	pdf, err := RenderStatement(statement, time.Date(2026, 11, 2, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := pdfPages(t, pdf)
+	if len(pages) < 2 {
+		t.Fatalf("120 entries fit in %d page, expected more", len(pages))
+	}
+
+	content := strings.Join(pages, "\n")
+	for _, want := range []string{
+		"(Estratto conto ottobre 2026)",
+		"(Conto corrente)",
+		"(1.700,00)",
+		"(Spesa)", "(75%)", "(Ristoranti)", "(25%)", "(-1.800,00)",
+		"Scontrino 120)",
+		fmt.Sprintf("(Pagina %d di %d)", len(pages), len(pages)),
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("statement does not contain %s", want)
+		}
+	}
+	if strings.Contains(content, "(Viaggi)") {
+		t.Error("categories without entries should be omitted")
+	}
+}
+
+func TestRenderStatementWithoutEntries(t *testing.T) {
+	statement := dto.Statement{
+		Year:     2025,
+		DateFrom: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		DateTo:   time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+	pdf, err := RenderStatement(statement, time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := pdfPages(t, pdf)
+	if len(pages) != 1 || !strings.Contains(pages[0], "(Nessun movimento nel periodo)") {
+		t.Fatalf("expected a single page without entries, got %d pages", len(pages))
+	}
+}
+
+var objectHeader = regexp.MustCompile(`^\d+ 0 obj\n`)
+
+// pdfPages verifica la struttura del PDF (intestazione, tabella xref e trailer) e restituisce il
+// contenuto decompresso di ogni pagina.
+func pdfPages(t *testing.T, pdf []byte) []string {
+	t.Helper()
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+
+	startxref := bytes.LastIndex(pdf, []byte("startxref\n"))
+	xrefOffset, err := strconv.Atoi(strings.TrimSpace(strings.Split(string(pdf[startxref+len("startxref\n"):]), "\n")[0]))
+	if err != nil || !bytes.HasPrefix(pdf[xrefOffset:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point to the xref table: %v", err)
+	}
+	lines := strings.Split(string(pdf[xrefOffset:]), "\n")
+	for _, line := range lines[3:] {
+		if line == "trailer" {
+			break
+		}
+		offset, err := strconv.Atoi(line[:10])
+		if err != nil || !objectHeader.Match(pdf[offset:]) {
+			t.Fatalf("xref entry %q does not point to an object", line)
+		}
+	}
+
+	var pages []string
+	rest := pdf
+	for {
+		start := bytes.Index(rest, []byte(">>\nstream\n"))
+		if start < 0 {
+			break
+		}
+		rest = rest[start+len(">>\nstream\n"):]
+		end := bytes.Index(rest, []byte("\nendstream"))
+		reader, err := zlib.NewReader(bytes.NewReader(rest[:end]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		page, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, string(page))
+		rest = rest[end+len("\nendstream"):]
+	}
+	return pages
+}
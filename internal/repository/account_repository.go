@@ -8,10 +8,16 @@ import (
 
 type AccountRepository interface {
 	GetAccount(ctx context.Context, user dbgen.User, accountName string) (dbgen.Account, error)
+	GetAccountByID(ctx context.Context, user dbgen.User, accountID int64) (dbgen.Account, error)
 	CreateAccount(ctx context.Context, user dbgen.User, createAccountDto dto.CreateAccountDto) (dbgen.Account, error)
-	AddTransaction(ctx context.Context, user dbgen.User, account dbgen.Account, category dbgen.Category, addExpenseDto dto.AddTransactionDto) (int64, error)
+	AddTransaction(ctx context.Context, user dbgen.User, account dbgen.Account, category dbgen.Category, payeeID *int64, addExpenseDto dto.AddTransactionDto) (int64, error)
 	GetAccounts(ctx context.Context, user dbgen.User) ([]dbgen.Account, error)
 	GetAccountBalance(ctx context.Context, accountID int64) (int64, error)
-	GetRecentTransactions(ctx context.Context, userID int64, limit int32) ([]dbgen.GetRecentTransactionEntriesByUserRow, error)
+	GetRecentTransactions(ctx context.Context, userID int64, limit int32, tag *string) ([]dbgen.GetRecentTransactionEntriesByUserRow, error)
+	GetTransaction(ctx context.Context, user dbgen.User, transactionID int64) (dbgen.Transaction, error)
+	DeleteTransaction(ctx context.Context, user dbgen.User, transaction dbgen.Transaction) error
+	IsTransactionReconciled(ctx context.Context, transaction dbgen.Transaction) (bool, error)
+	GetAccountRole(ctx context.Context, user dbgen.User, accountID int64) (dto.HouseholdRole, error)
+	GetTransactionRole(ctx context.Context, user dbgen.User, transactionID int64) (dto.HouseholdRole, error)
 	TransferBetweenAccounts(ctx context.Context, user dbgen.User, fromAccount dbgen.Account, toAccount dbgen.Account, transfer dto.TransferBetweenAccountsDto) (int64, error)
 }
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	"time"
+)
+
+type AnomalyRepository interface {
+	GetUsersToScan(ctx context.Context) ([]dbgen.User, error)
+	GetWatermark(ctx context.Context, user dbgen.User) (int64, error)
+	GetLatestEntryID(ctx context.Context, user dbgen.User) (int64, error)
+	SetWatermark(ctx context.Context, user dbgen.User, entryID int64) error
+	GetNewExpenses(ctx context.Context, user dbgen.User, afterID int64, dateFrom time.Time) ([]dbgen.GetNewExpensesRow, error)
+	GetExpenseHistory(ctx context.Context, user dbgen.User, expense dbgen.GetNewExpensesRow, dateFrom time.Time) ([]int64, error)
+	GetMonthlyCategoryTotals(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetMonthlyCategoryTotalsRow, error)
+	CreateExpenseAnomaly(ctx context.Context, user dbgen.User, expense dbgen.GetNewExpensesRow, outlier dto.Outlier, message string) (dbgen.SpendingAnomaly, bool, error)
+	UpsertCategoryAnomaly(ctx context.Context, user dbgen.User, categoryID int64, month time.Time, outlier dto.Outlier, message string) (dbgen.SpendingAnomaly, error)
+	GetAnomalies(ctx context.Context, user dbgen.User, includeDismissed bool) ([]dbgen.SpendingAnomaly, error)
+	DismissAnomaly(ctx context.Context, user dbgen.User, anomalyID int64) error
+}
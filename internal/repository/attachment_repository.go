@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+)
+
+type AttachmentRepository interface {
+	CreateAttachment(ctx context.Context, user dbgen.User, transaction dbgen.Transaction, params dbgen.CreateAttachmentParams) (dbgen.Attachment, error)
+	GetAttachments(ctx context.Context, user dbgen.User, transaction dbgen.Transaction) ([]dbgen.Attachment, error)
+	GetAttachmentByID(ctx context.Context, user dbgen.User, attachmentID int64) (dbgen.Attachment, error)
+	DeleteAttachment(ctx context.Context, user dbgen.User, attachment dbgen.Attachment) error
+	GetUserAttachments(ctx context.Context, user dbgen.User) ([]dbgen.Attachment, error)
+}
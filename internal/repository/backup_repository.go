@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+type BackupRepository interface {
+	GetUsers(ctx context.Context) ([]dbgen.User, error)
+	// Snapshot apre una transazione in sola lettura REPEATABLE READ: tutte le letture dello
+	// snapshot vedono lo stesso stato del database, quindi l'archivio è coerente anche se
+	// l'utente scrive durante il backup. Close va sempre chiamato.
+	Snapshot(ctx context.Context) (BackupSnapshot, error)
+	// LockScheduledBackup prende il lock dei backup automatici, condiviso da tutte le istanze
+	// che usano lo stesso database: ok è falso se lo tiene già un'altra istanza. Con ok vero
+	// unlock va sempre chiamato.
+	LockScheduledBackup(ctx context.Context) (unlock func(), ok bool, err error)
+	// Restore esegue restore in un'unica transazione, confermata solo se restore non fallisce.
+	Restore(ctx context.Context, restore func(BackupRestorer) error) error
+}
+
+// BackupSnapshot legge i dati di un utente dentro la transazione aperta da Snapshot.
+type BackupSnapshot interface {
+	GetUser(ctx context.Context, userID int64) (dbgen.User, error)
+	GetNotificationSettings(ctx context.Context, user dbgen.User) (dto.NotificationSettings, error)
+	GetDigestSettings(ctx context.Context, user dbgen.User) (dbgen.DigestSetting, error)
+	GetAccounts(ctx context.Context, user dbgen.User) ([]dbgen.Account, error)
+	GetCategories(ctx context.Context, user dbgen.User) ([]dbgen.Category, error)
+	GetPayees(ctx context.Context, user dbgen.User) ([]dbgen.Payee, error)
+	GetPayeeAliases(ctx context.Context, user dbgen.User) ([]dbgen.PayeeAlias, error)
+	GetTags(ctx context.Context, user dbgen.User) ([]dbgen.Tag, error)
+	GetTransactions(ctx context.Context, user dbgen.User, afterID int64, limit int32) ([]dbgen.Transaction, error)
+	GetEntries(ctx context.Context, user dbgen.User, afterID int64, limit int32) ([]dbgen.GetBackupEntriesRow, error)
+	GetCreditCards(ctx context.Context, user dbgen.User) ([]dbgen.CreditCard, error)
+	GetLoans(ctx context.Context, user dbgen.User) ([]dbgen.Loan, error)
+	GetLoanInstallments(ctx context.Context, user dbgen.User) ([]dbgen.LoanInstallment, error)
+	GetSecurities(ctx context.Context, user dbgen.User) ([]dbgen.Security, error)
+	GetSecurityPrices(ctx context.Context, user dbgen.User) ([]dbgen.SecurityPrice, error)
+	GetInvestmentTrades(ctx context.Context, user dbgen.User) ([]dbgen.InvestmentTrade, error)
+	GetRecurringTransactions(ctx context.Context, user dbgen.User) ([]dbgen.RecurringTransaction, error)
+	GetReconciliations(ctx context.Context, user dbgen.User) ([]dbgen.Reconciliation, error)
+	GetReimbursables(ctx context.Context, user dbgen.User) ([]dbgen.Reimbursable, error)
+	GetReimbursements(ctx context.Context, user dbgen.User) ([]dbgen.Reimbursement, error)
+	GetAttachments(ctx context.Context, user dbgen.User) ([]dbgen.Attachment, error)
+	GetSplitGroups(ctx context.Context, user dbgen.User) ([]dbgen.SplitGroup, error)
+	GetSplitMembers(ctx context.Context, user dbgen.User) ([]dbgen.GetBackupSplitMembersRow, error)
+	GetSplitExpenses(ctx context.Context, user dbgen.User) ([]dbgen.SplitExpense, error)
+	GetSplitShares(ctx context.Context, user dbgen.User) ([]dbgen.SplitShare, error)
+	GetSplitSettlements(ctx context.Context, user dbgen.User) ([]dbgen.SplitSettlement, error)
+	// Close chiude la transazione dello snapshot.
+	Close() error
+}
+
+// BackupRestorer scrive i dati ripristinati dentro la transazione aperta da Restore: gli
+// inserimenti restituiscono il nuovo ID, gli ID di origine nei parametri sono ignorati.
+type BackupRestorer interface {
+	GetUser(ctx context.Context, email string) (dbgen.User, error)
+	CreateUser(ctx context.Context, email, passwordHash string) (dbgen.User, error)
+	HasData(ctx context.Context, user dbgen.User) (bool, error)
+	SaveNotificationSettings(ctx context.Context, settings dto.NotificationSettings) error
+	SaveDigestSettings(ctx context.Context, settings dto.DigestSettings) error
+	CreateAccount(ctx context.Context, account dbgen.Account) (int64, error)
+	CreateCategory(ctx context.Context, category dbgen.Category) (int64, error)
+	SetCategoryParent(ctx context.Context, categoryID, parentID int64) error
+	CreatePayee(ctx context.Context, payee dbgen.Payee) (int64, error)
+	CreatePayeeAlias(ctx context.Context, alias dbgen.PayeeAlias) error
+	CreateTag(ctx context.Context, tag dbgen.Tag) (int64, error)
+	CreateTransaction(ctx context.Context, transaction dbgen.Transaction) (int64, error)
+	CreateEntry(ctx context.Context, entry dbgen.TransactionEntry, tagIDs []int64) (int64, error)
+	CreateCreditCard(ctx context.Context, creditCard dbgen.CreditCard) error
+	CreateLoan(ctx context.Context, loan dbgen.Loan) error
+	CreateLoanInstallment(ctx context.Context, installment dbgen.LoanInstallment) error
+	CreateSecurity(ctx context.Context, security dbgen.Security) (int64, error)
+	CreateSecurityPrice(ctx context.Context, price dbgen.SecurityPrice) error
+	CreateInvestmentTrade(ctx context.Context, trade dbgen.InvestmentTrade) error
+	CreateRecurringTransaction(ctx context.Context, recurring dbgen.RecurringTransaction) error
+	CreateReconciliation(ctx context.Context, reconciliation dbgen.Reconciliation) (int64, error)
+	CreateReimbursable(ctx context.Context, reimbursable dbgen.Reimbursable) error
+	CreateReimbursement(ctx context.Context, reimbursement dbgen.Reimbursement) error
+	// CreateAttachment non fa nulla se un allegato con la stessa chiave esiste già.
+	CreateAttachment(ctx context.Context, attachment dbgen.Attachment) error
+	CreateSplitGroup(ctx context.Context, group dbgen.SplitGroup) (int64, error)
+	CreateSplitMember(ctx context.Context, member dbgen.SplitMember) (int64, error)
+	CreateSplitExpense(ctx context.Context, expense dbgen.SplitExpense, shares []dbgen.SplitShare) error
+	CreateSplitSettlement(ctx context.Context, settlement dbgen.SplitSettlement) error
+}
@@ -4,10 +4,21 @@ import (
 	"context"
 	dbgen "koin/internal/db/generated"
 	"koin/internal/model/dto"
+	"time"
 )
 
 type CategoryRepository interface {
 	GetCategory(ctx context.Context, user dbgen.User, categoryName string, categoryType dto.CategoryType) (dbgen.Category, error)
-	CreateCategory(ctx context.Context, user dbgen.User, categoryName string, categoryType dto.CategoryType) (dbgen.Category, error)
+	GetCategoryByID(ctx context.Context, user dbgen.User, categoryID int64) (dbgen.Category, error)
+	CreateCategory(ctx context.Context, user dbgen.User, categoryName string, categoryType dto.CategoryType, parentID *int64) (dbgen.Category, error)
 	GetCategories(ctx context.Context, user dbgen.User) ([]dbgen.Category, error)
+	GetCategoryRole(ctx context.Context, user dbgen.User, categoryID int64) (dto.HouseholdRole, error)
+	RenameCategory(ctx context.Context, user dbgen.User, categoryID int64, name string) (dbgen.Category, error)
+	SetCategoryParent(ctx context.Context, user dbgen.User, categoryID int64, parentID *int64) (dbgen.Category, error)
+	ArchiveCategory(ctx context.Context, user dbgen.User, categoryID int64) (dbgen.Category, error)
+	UnarchiveCategory(ctx context.Context, user dbgen.User, categoryID int64) (dbgen.Category, error)
+	SetCategoryBudget(ctx context.Context, categoryID int64, monthlyBudget *int64) (dbgen.Category, error)
+	SetCategoryDeduction(ctx context.Context, categoryID int64, code *string) (dbgen.Category, error)
+	MergeCategories(ctx context.Context, user dbgen.User, source dbgen.Category, target dbgen.Category) (int64, error)
+	GetCategoryTotals(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time, tag *string) ([]dbgen.GetCategoryTotalsByUserRow, error)
 }
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"koin/internal/model/dto"
+)
+
+type ChangeRepository interface {
+	// Listen resta in ascolto delle modifiche confermate e le passa a handle finché il contesto
+	// non viene annullato o la connessione cade.
+	Listen(ctx context.Context, handle func(dto.ChangeEvent)) error
+}
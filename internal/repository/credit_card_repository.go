@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	"time"
+)
+
+type CreditCardRepository interface {
+	SaveCreditCard(ctx context.Context, configureDto dto.ConfigureCreditCardDto) (dbgen.CreditCard, error)
+	GetCreditCard(ctx context.Context, user dbgen.User, accountID int64) (dbgen.CreditCard, error)
+	GetCreditCards(ctx context.Context, user dbgen.User) ([]dbgen.CreditCard, error)
+	GetAutoPayCreditCards(ctx context.Context) ([]dbgen.GetAutoPayCreditCardsRow, error)
+	GetAllCreditCards(ctx context.Context) ([]dbgen.GetAllCreditCardsRow, error)
+	DeleteCreditCard(ctx context.Context, creditCard dbgen.CreditCard) error
+	GetMovements(ctx context.Context, creditCard dbgen.CreditCard, dateFrom time.Time) ([]dbgen.GetCreditCardMovementsRow, error)
+	PayStatement(ctx context.Context, user dbgen.User, creditCard dbgen.CreditCard, fromAccount dbgen.Account, toAccount dbgen.Account, transfer dto.TransferBetweenAccountsDto, dateFrom time.Time, amount func([]dbgen.GetCreditCardMovementsRow) (int64, error)) (int64, error)
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+)
+
+type DeductionRepository interface {
+	GetEntry(ctx context.Context, user dbgen.User, entryID int64) (dbgen.TransactionEntry, error)
+	SetEntryDeduction(ctx context.Context, entryID int64, code *string) (dbgen.TransactionEntry, error)
+	GetDeductibleEntries(ctx context.Context, user dbgen.User, year int) ([]dbgen.GetDeductibleEntriesRow, error)
+	GetReceipts(ctx context.Context, user dbgen.User, year int) ([]dbgen.GetDeductionReceiptsRow, error)
+}
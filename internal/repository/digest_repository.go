@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	"time"
+)
+
+type DigestRepository interface {
+	GetSettings(ctx context.Context, user dbgen.User) (dbgen.DigestSetting, error)
+	SaveSettings(ctx context.Context, settings dto.DigestSettings) (dbgen.DigestSetting, error)
+	GetEnabledSettings(ctx context.Context) ([]dbgen.DigestSetting, error)
+	ClaimSend(ctx context.Context, userID int64, frequency dto.Frequency, sentOn time.Time) (bool, error)
+	ReleaseSend(ctx context.Context, userID int64, frequency dto.Frequency, sentOn time.Time, previous sql.NullTime) error
+	GetLargestEntries(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time, limit int32) ([]dbgen.GetLargestEntriesRow, error)
+	GetBalanceChanges(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetAccountBalanceChangesRow, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+type ExportRepository interface {
+	GetAccounts(ctx context.Context, user dbgen.User, accountID *int64) ([]dbgen.GetExportAccountsRow, error)
+	GetEntries(ctx context.Context, user dbgen.User, filter dto.ExportFilter, after *dbgen.GetExportEntriesRow, limit int32) ([]dbgen.GetExportEntriesRow, error)
+}
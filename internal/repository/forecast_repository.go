@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	"time"
+)
+
+type ForecastRepository interface {
+	SetOverdraft(ctx context.Context, account dbgen.Account, overdraftLimit int64) (dbgen.Account, error)
+	CreateRecurring(ctx context.Context, createDto dto.CreateRecurringDto) (dbgen.RecurringTransaction, error)
+	GetRecurring(ctx context.Context, user dbgen.User) ([]dbgen.GetRecurringTransactionsByUserRow, error)
+	GetRecurringByID(ctx context.Context, user dbgen.User, recurringID int64) (dbgen.RecurringTransaction, error)
+	DeleteRecurring(ctx context.Context, recurring dbgen.RecurringTransaction) error
+	GetDiscretionarySpending(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetDiscretionarySpendingRow, error)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+type HouseholdRepository interface {
+	CreateHousehold(ctx context.Context, user dbgen.User, name string) (dbgen.Household, error)
+	GetHouseholds(ctx context.Context, user dbgen.User) ([]dbgen.GetHouseholdsByUserRow, error)
+	GetHousehold(ctx context.Context, householdID int64) (dbgen.Household, error)
+	GetMember(ctx context.Context, household dbgen.Household, userID int64) (dbgen.HouseholdMember, error)
+	GetMembers(ctx context.Context, household dbgen.Household) ([]dbgen.GetHouseholdMembersRow, error)
+	CountMembers(ctx context.Context, household dbgen.Household) (dbgen.CountHouseholdMembersRow, error)
+	UpdateMemberRole(ctx context.Context, household dbgen.Household, userID int64, role dto.HouseholdRole) (dbgen.HouseholdMember, error)
+	RemoveMember(ctx context.Context, household dbgen.Household, userID int64) error
+	CreateInvite(ctx context.Context, household dbgen.Household, code string, inviteDto dto.CreateInviteDto) (dbgen.HouseholdInvite, error)
+	GetInviteByCode(ctx context.Context, code string) (dbgen.HouseholdInvite, error)
+	GetPendingInvites(ctx context.Context, household dbgen.Household) ([]dbgen.HouseholdInvite, error)
+	AcceptInvite(ctx context.Context, user dbgen.User, invite dbgen.HouseholdInvite) (dbgen.HouseholdMember, error)
+	RevokeInvite(ctx context.Context, household dbgen.Household, inviteID int64) error
+	ShareAccount(ctx context.Context, account dbgen.Account, householdID *int64) (dbgen.Account, error)
+	ShareCategory(ctx context.Context, category dbgen.Category, householdID *int64) (dbgen.Category, error)
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+type InvestmentRepository interface {
+	AddTrade(ctx context.Context, user dbgen.User, account dbgen.Account, tradeDto dto.AddTradeDto, entries []dto.TradeCashEntry, description string) (dbgen.InvestmentTrade, error)
+	GetTrades(ctx context.Context, user dbgen.User) ([]dbgen.InvestmentTrade, error)
+}
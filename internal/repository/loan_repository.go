@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	"time"
+)
+
+type LoanRepository interface {
+	CreateLoan(ctx context.Context, user dbgen.User, createDto dto.CreateLoanDto, installments []dto.Installment) (dbgen.Loan, error)
+	GetLoan(ctx context.Context, user dbgen.User, accountID int64) (dbgen.Loan, error)
+	GetLoans(ctx context.Context, user dbgen.User) ([]dbgen.Loan, error)
+	GetLoansWithDueInstallments(ctx context.Context, dueDate time.Time) ([]dbgen.GetLoansWithDueInstallmentsRow, error)
+	GetInstallments(ctx context.Context, loan dbgen.Loan) ([]dbgen.LoanInstallment, error)
+	PostInstallment(ctx context.Context, user dbgen.User, loan dbgen.Loan, installment dbgen.LoanInstallment, interestCategory dbgen.Category, description string) (int64, error)
+}
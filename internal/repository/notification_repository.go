@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	"time"
+)
+
+type NotificationRepository interface {
+	GetSettings(ctx context.Context, user dbgen.User) (dto.NotificationSettings, error)
+	SaveSettings(ctx context.Context, settings dto.NotificationSettings) error
+	CreateNotification(ctx context.Context, event dto.NotificationEvent, inApp bool, deliveries map[dto.NotificationChannel]string) (dbgen.Notification, bool, error)
+	ClaimDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int32) ([]dbgen.ClaimNotificationDeliveriesRow, error)
+	MarkDeliverySent(ctx context.Context, deliveryID int64) error
+	MarkDeliveryFailed(ctx context.Context, deliveryID int64, lastError string, nextAttempt *time.Time) error
+	GetNotifications(ctx context.Context, user dbgen.User, unreadOnly bool) ([]dbgen.Notification, error)
+	MarkRead(ctx context.Context, user dbgen.User, notificationID int64) error
+	GetExceededBudgets(ctx context.Context, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetExceededBudgetsRow, error)
+	GetLowBalanceAccounts(ctx context.Context) ([]dbgen.GetLowBalanceAccountsRow, error)
+}
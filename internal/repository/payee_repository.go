@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"time"
+)
+
+type PayeeRepository interface {
+	GetPayees(ctx context.Context, user dbgen.User) ([]dbgen.Payee, error)
+	GetPayeeByID(ctx context.Context, user dbgen.User, payeeID int64) (dbgen.Payee, error)
+	GetPayeeByName(ctx context.Context, user dbgen.User, name string) (dbgen.Payee, error)
+	CreatePayee(ctx context.Context, user dbgen.User, name string, defaultCategoryID *int64) (dbgen.Payee, error)
+	UpdatePayee(ctx context.Context, user dbgen.User, payeeID int64, name string, defaultCategoryID *int64) (dbgen.Payee, error)
+	DeletePayee(ctx context.Context, user dbgen.User, payeeID int64) error
+	GetPayeeAliases(ctx context.Context, user dbgen.User) ([]dbgen.PayeeAlias, error)
+	AddPayeeAlias(ctx context.Context, payee dbgen.Payee, pattern string) (dbgen.PayeeAlias, error)
+	DeletePayeeAlias(ctx context.Context, payee dbgen.Payee, aliasID int64) error
+	DetectPayee(ctx context.Context, user dbgen.User, description string) (dbgen.Payee, error)
+	GetPayeeTotals(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetPayeeTotalsByUserRow, error)
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	apierr "koin/internal/errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// transactionLockedCode è lo SQLSTATE dei trigger che impediscono di modificare le transazioni
+// riconciliate (migrazione 000021).
+const transactionLockedCode = "KL001"
+
+// lockedError traduce l'errore dei trigger sulle transazioni riconciliate in ErrTransactionLocked
+// e restituisce invariati gli altri errori.
+func lockedError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == transactionLockedCode {
+		return fmt.Errorf("%w: %s", apierr.ErrTransactionLocked, pgErr.Message)
+	}
+	return err
+}
@@ -50,7 +50,21 @@ func (repo *AccountRepository) GetAccount(ctx context.Context, user dbgen.User,
 	return account, nil
 }
 
-func (repo *AccountRepository) AddTransaction(ctx context.Context, user dbgen.User, account dbgen.Account, category dbgen.Category, addExpenseDto dto.AddTransactionDto) (int64, error) {
+func (repo *AccountRepository) GetAccountByID(ctx context.Context, user dbgen.User, accountID int64) (dbgen.Account, error) {
+	account, err := repo.queries.GetAccountByID(ctx, dbgen.GetAccountByIDParams{
+		ID:     accountID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Account{}, fmt.Errorf("%w: %d", apierr.ErrAccountNotFound, accountID)
+		}
+		return dbgen.Account{}, fmt.Errorf("get account %d: %w", accountID, err)
+	}
+	return account, nil
+}
+
+func (repo *AccountRepository) AddTransaction(ctx context.Context, user dbgen.User, account dbgen.Account, category dbgen.Category, payeeID *int64, addExpenseDto dto.AddTransactionDto) (int64, error) {
 	transactionId, err := repo.queries.AddTransaction(ctx, dbgen.AddTransactionParams{
 		UserID:     user.ID,
 		OccurredAt: addExpenseDto.OccurredAt,
@@ -76,6 +90,7 @@ func (repo *AccountRepository) AddTransaction(ctx context.Context, user dbgen.Us
 			}(),
 			Valid: addExpenseDto.Description != nil,
 		},
+		PayeeID: nullInt64(payeeID),
 	})
 	if err != nil {
 		return 0, err
@@ -99,9 +114,10 @@ func (repo *AccountRepository) GetAccountBalance(ctx context.Context, accountID
 	return balance, nil
 }
 
-func (repo *AccountRepository) GetRecentTransactions(ctx context.Context, userID int64, limit int32) ([]dbgen.GetRecentTransactionEntriesByUserRow, error) {
+func (repo *AccountRepository) GetRecentTransactions(ctx context.Context, userID int64, limit int32, tag *string) ([]dbgen.GetRecentTransactionEntriesByUserRow, error) {
 	entries, err := repo.queries.GetRecentTransactionEntriesByUser(ctx, dbgen.GetRecentTransactionEntriesByUserParams{
 		UserID: userID,
+		Tag:    nullString(tag),
 		Limit:  limit,
 	})
 	if err != nil {
@@ -110,6 +126,76 @@ func (repo *AccountRepository) GetRecentTransactions(ctx context.Context, userID
 	return entries, nil
 }
 
+func (repo *AccountRepository) GetTransaction(ctx context.Context, user dbgen.User, transactionID int64) (dbgen.Transaction, error) {
+	transaction, err := repo.queries.GetTransactionByID(ctx, dbgen.GetTransactionByIDParams{
+		ID:     transactionID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Transaction{}, fmt.Errorf("%w: %d", apierr.ErrTransactionNotFound, transactionID)
+		}
+		return dbgen.Transaction{}, fmt.Errorf("get transaction %d: %w", transactionID, err)
+	}
+	return transaction, nil
+}
+
+// DeleteTransaction elimina la transazione; movimenti, tag e allegati vengono rimossi in cascata.
+func (repo *AccountRepository) DeleteTransaction(ctx context.Context, user dbgen.User, transaction dbgen.Transaction) error {
+	deleted, err := repo.queries.DeleteTransaction(ctx, dbgen.DeleteTransactionParams{
+		ID:     transaction.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("delete transaction %d: %w", transaction.ID, lockedError(err))
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrTransactionNotFound, transaction.ID)
+	}
+	return nil
+}
+
+// IsTransactionReconciled indica se almeno un movimento della transazione è già riconciliato.
+func (repo *AccountRepository) IsTransactionReconciled(ctx context.Context, transaction dbgen.Transaction) (bool, error) {
+	reconciled, err := repo.queries.IsTransactionReconciled(ctx, transaction.ID)
+	if err != nil {
+		return false, fmt.Errorf("check reconciliation of transaction %d: %w", transaction.ID, err)
+	}
+	return reconciled, nil
+}
+
+// GetAccountRole restituisce il ruolo con cui l'utente accede all'account: OWNER se è suo,
+// altrimenti il ruolo nella household con cui l'account è condiviso.
+func (repo *AccountRepository) GetAccountRole(ctx context.Context, user dbgen.User, accountID int64) (dto.HouseholdRole, error) {
+	role, err := repo.queries.GetAccountRole(ctx, dbgen.GetAccountRoleParams{
+		AccountID: accountID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %d", apierr.ErrAccountNotFound, accountID)
+		}
+		return "", fmt.Errorf("get role on account %d: %w", accountID, err)
+	}
+	return dto.HouseholdRole(role), nil
+}
+
+// GetTransactionRole restituisce il ruolo con cui l'utente può modificare la transazione: il più
+// basso tra quelli sugli account dei suoi movimenti.
+func (repo *AccountRepository) GetTransactionRole(ctx context.Context, user dbgen.User, transactionID int64) (dto.HouseholdRole, error) {
+	role, err := repo.queries.GetTransactionRole(ctx, dbgen.GetTransactionRoleParams{
+		TransactionID: transactionID,
+		UserID:        user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %d", apierr.ErrTransactionNotFound, transactionID)
+		}
+		return "", fmt.Errorf("get role on transaction %d: %w", transactionID, err)
+	}
+	return dto.HouseholdRole(role), nil
+}
+
 func (repo *AccountRepository) TransferBetweenAccounts(ctx context.Context, user dbgen.User, fromAccount dbgen.Account, toAccount dbgen.Account, transfer dto.TransferBetweenAccountsDto) (int64, error) {
 	if fromAccount.ID == toAccount.ID {
 		return 0, fmt.Errorf("accounts must be different")
@@ -123,16 +209,28 @@ func (repo *AccountRepository) TransferBetweenAccounts(ctx context.Context, user
 		return 0, err
 	}
 
-	queries := repo.queries.WithTx(tx)
+	transactionID, err := addTransfer(ctx, repo.queries.WithTx(tx), user, fromAccount, toAccount, transfer)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return transactionID, nil
+}
+
+// addTransfer scrive il trasferimento nella transazione di queries, dopo aver verificato il
+// saldo del conto di origine.
+func addTransfer(ctx context.Context, queries *dbgen.Queries, user dbgen.User, fromAccount dbgen.Account, toAccount dbgen.Account, transfer dto.TransferBetweenAccountsDto) (int64, error) {
 	balance, err := queries.GetAccountBalance(ctx, fromAccount.ID)
 	if err != nil {
-		_ = tx.Rollback()
 		return 0, err
 	}
 	currentBalance := fromAccount.InitialBalance + balance
 	if currentBalance < transfer.Amount {
-		_ = tx.Rollback()
 		return 0, fmt.Errorf("%w", apierr.ErrInsufficientBalance)
 	}
 
@@ -141,7 +239,6 @@ func (repo *AccountRepository) TransferBetweenAccounts(ctx context.Context, user
 		OccurredAt: transfer.OccurredAt,
 	})
 	if err != nil {
-		_ = tx.Rollback()
 		return 0, err
 	}
 
@@ -163,7 +260,6 @@ func (repo *AccountRepository) TransferBetweenAccounts(ctx context.Context, user
 		},
 	})
 	if err != nil {
-		_ = tx.Rollback()
 		return 0, err
 	}
 
@@ -178,11 +274,6 @@ func (repo *AccountRepository) TransferBetweenAccounts(ctx context.Context, user
 		},
 	})
 	if err != nil {
-		_ = tx.Rollback()
-		return 0, err
-	}
-
-	if err := tx.Commit(); err != nil {
 		return 0, err
 	}
 
@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type AnomalyRepository struct {
+	queries *dbgen.Queries
+}
+
+func NewAnomalyRepository(db *sql.DB) *AnomalyRepository {
+	return &AnomalyRepository{
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *AnomalyRepository) GetUsersToScan(ctx context.Context) ([]dbgen.User, error) {
+	users, err := repo.queries.GetUsersToScanForAnomalies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get users to scan for anomalies: %w", err)
+	}
+	return users, nil
+}
+
+func (repo *AnomalyRepository) GetWatermark(ctx context.Context, user dbgen.User) (int64, error) {
+	watermark, err := repo.queries.GetAnomalyWatermark(ctx, user.ID)
+	if err != nil {
+		return 0, fmt.Errorf("get anomaly watermark of user %d: %w", user.ID, err)
+	}
+	return watermark, nil
+}
+
+func (repo *AnomalyRepository) GetLatestEntryID(ctx context.Context, user dbgen.User) (int64, error) {
+	entryID, err := repo.queries.GetLatestEntryID(ctx, user.ID)
+	if err != nil {
+		return 0, fmt.Errorf("get latest entry of user %d: %w", user.ID, err)
+	}
+	return entryID, nil
+}
+
+func (repo *AnomalyRepository) SetWatermark(ctx context.Context, user dbgen.User, entryID int64) error {
+	err := repo.queries.SetAnomalyWatermark(ctx, dbgen.SetAnomalyWatermarkParams{
+		UserID:      user.ID,
+		LastEntryID: entryID,
+	})
+	if err != nil {
+		return fmt.Errorf("set anomaly watermark of user %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+func (repo *AnomalyRepository) GetNewExpenses(ctx context.Context, user dbgen.User, afterID int64, dateFrom time.Time) ([]dbgen.GetNewExpensesRow, error) {
+	expenses, err := repo.queries.GetNewExpenses(ctx, dbgen.GetNewExpensesParams{
+		UserID:   user.ID,
+		AfterID:  afterID,
+		DateFrom: dateFrom,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get new expenses of user %d: %w", user.ID, err)
+	}
+	return expenses, nil
+}
+
+// GetExpenseHistory restituisce gli importi delle spese dello stesso beneficiario tra dateFrom e
+// il giorno prima della spesa, oppure della stessa categoria se la spesa non ha beneficiario.
+func (repo *AnomalyRepository) GetExpenseHistory(ctx context.Context, user dbgen.User, expense dbgen.GetNewExpensesRow, dateFrom time.Time) ([]int64, error) {
+	amounts, err := repo.queries.GetExpenseHistory(ctx, dbgen.GetExpenseHistoryParams{
+		UserID:     user.ID,
+		EntryID:    expense.ID,
+		DateFrom:   dateFrom,
+		AsOf:       expense.OccurredAt,
+		PayeeID:    expense.PayeeID,
+		CategoryID: expense.CategoryID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get history of entry %d: %w", expense.ID, err)
+	}
+	return amounts, nil
+}
+
+func (repo *AnomalyRepository) GetMonthlyCategoryTotals(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetMonthlyCategoryTotalsRow, error) {
+	totals, err := repo.queries.GetMonthlyCategoryTotals(ctx, dbgen.GetMonthlyCategoryTotalsParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get monthly category totals of user %d: %w", user.ID, err)
+	}
+	return totals, nil
+}
+
+// CreateExpenseAnomaly segnala la spesa; restituisce false se era già stata segnalata.
+func (repo *AnomalyRepository) CreateExpenseAnomaly(ctx context.Context, user dbgen.User, expense dbgen.GetNewExpensesRow, outlier dto.Outlier, message string) (dbgen.SpendingAnomaly, bool, error) {
+	anomaly, err := repo.queries.CreateExpenseAnomaly(ctx, dbgen.CreateExpenseAnomalyParams{
+		UserID:     user.ID,
+		EntryID:    sql.NullInt64{Int64: expense.ID, Valid: true},
+		CategoryID: expense.CategoryID.Int64,
+		Amount:     outlier.Amount,
+		Baseline:   outlier.Baseline,
+		RatioPct:   outlier.RatioPct,
+		Message:    message,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.SpendingAnomaly{}, false, nil
+		}
+		return dbgen.SpendingAnomaly{}, false, fmt.Errorf("create anomaly for entry %d: %w", expense.ID, err)
+	}
+	return anomaly, true, nil
+}
+
+func (repo *AnomalyRepository) UpsertCategoryAnomaly(ctx context.Context, user dbgen.User, categoryID int64, month time.Time, outlier dto.Outlier, message string) (dbgen.SpendingAnomaly, error) {
+	anomaly, err := repo.queries.UpsertCategoryAnomaly(ctx, dbgen.UpsertCategoryAnomalyParams{
+		UserID:     user.ID,
+		CategoryID: categoryID,
+		Month:      sql.NullTime{Time: month, Valid: true},
+		Amount:     outlier.Amount,
+		Baseline:   outlier.Baseline,
+		RatioPct:   outlier.RatioPct,
+		Message:    message,
+	})
+	if err != nil {
+		return dbgen.SpendingAnomaly{}, fmt.Errorf("save anomaly for category %d: %w", categoryID, err)
+	}
+	return anomaly, nil
+}
+
+func (repo *AnomalyRepository) GetAnomalies(ctx context.Context, user dbgen.User, includeDismissed bool) ([]dbgen.SpendingAnomaly, error) {
+	anomalies, err := repo.queries.GetAnomaliesByUser(ctx, dbgen.GetAnomaliesByUserParams{
+		UserID:           user.ID,
+		IncludeDismissed: includeDismissed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get anomalies of user %d: %w", user.ID, err)
+	}
+	return anomalies, nil
+}
+
+func (repo *AnomalyRepository) DismissAnomaly(ctx context.Context, user dbgen.User, anomalyID int64) error {
+	dismissed, err := repo.queries.DismissAnomaly(ctx, dbgen.DismissAnomalyParams{
+		ID:     anomalyID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("dismiss anomaly %d: %w", anomalyID, err)
+	}
+	if dismissed == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrAnomalyNotFound, anomalyID)
+	}
+	return nil
+}
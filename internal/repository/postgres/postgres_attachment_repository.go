@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+)
+
+type AttachmentRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+// CreateAttachment registra un allegato già salvato nel blob store; utente e transazione
+// prevalgono su quelli eventualmente indicati nei parametri.
+func (repo *AttachmentRepository) CreateAttachment(ctx context.Context, user dbgen.User, transaction dbgen.Transaction, params dbgen.CreateAttachmentParams) (dbgen.Attachment, error) {
+	params.UserID = user.ID
+	params.TransactionID = transaction.ID
+	attachment, err := repo.queries.CreateAttachment(ctx, params)
+	if err != nil {
+		return dbgen.Attachment{}, fmt.Errorf("create attachment for transaction %d: %w", transaction.ID, err)
+	}
+	return attachment, nil
+}
+
+func (repo *AttachmentRepository) GetAttachments(ctx context.Context, user dbgen.User, transaction dbgen.Transaction) ([]dbgen.Attachment, error) {
+	attachments, err := repo.queries.GetAttachmentsByTransaction(ctx, dbgen.GetAttachmentsByTransactionParams{
+		TransactionID: transaction.ID,
+		UserID:        user.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get attachments of transaction %d: %w", transaction.ID, err)
+	}
+	return attachments, nil
+}
+
+func (repo *AttachmentRepository) GetAttachmentByID(ctx context.Context, user dbgen.User, attachmentID int64) (dbgen.Attachment, error) {
+	attachment, err := repo.queries.GetAttachmentByID(ctx, dbgen.GetAttachmentByIDParams{
+		ID:     attachmentID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Attachment{}, fmt.Errorf("%w: %d", apierr.ErrAttachmentNotFound, attachmentID)
+		}
+		return dbgen.Attachment{}, fmt.Errorf("get attachment %d: %w", attachmentID, err)
+	}
+	return attachment, nil
+}
+
+func (repo *AttachmentRepository) DeleteAttachment(ctx context.Context, user dbgen.User, attachment dbgen.Attachment) error {
+	err := repo.queries.DeleteAttachment(ctx, dbgen.DeleteAttachmentParams{
+		ID:     attachment.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("delete attachment %d: %w", attachment.ID, err)
+	}
+	return nil
+}
+
+func (repo *AttachmentRepository) GetUserAttachments(ctx context.Context, user dbgen.User) ([]dbgen.Attachment, error) {
+	attachments, err := repo.queries.GetUserAttachments(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get attachments of user %d: %w", user.ID, err)
+	}
+	return attachments, nil
+}
@@ -0,0 +1,722 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/repository"
+)
+
+type BackupRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewBackupRepository(db *sql.DB) *BackupRepository {
+	return &BackupRepository{
+		queries: dbgen.New(db),
+		db:      db,
+	}
+}
+
+func (repo *BackupRepository) GetUsers(ctx context.Context) ([]dbgen.User, error) {
+	users, err := repo.queries.GetBackupUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get backup users: %w", err)
+	}
+	return users, nil
+}
+
+// Snapshot apre la transazione del backup: ReadOnly la rende anche più economica per il
+// database, che non deve prevedere scritture.
+func (repo *BackupRepository) Snapshot(ctx context.Context) (repository.BackupSnapshot, error) {
+	tx, err := repo.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin backup snapshot: %w", err)
+	}
+	return &backupSnapshot{tx: tx, queries: repo.queries.WithTx(tx)}, nil
+}
+
+// scheduledBackupLockKey identifica il lock advisory dei backup automatici.
+const scheduledBackupLockKey int64 = 0x6b6f696e_6261636b // "koinback"
+
+// LockScheduledBackup tiene il lock su una connessione dedicata, perché i lock advisory di
+// sessione appartengono alla connessione che li ha presi. Se il rilascio fallisce la
+// connessione viene scartata, così il database rilascia il lock chiudendola.
+func (repo *BackupRepository) LockScheduledBackup(ctx context.Context) (func(), bool, error) {
+	conn, err := repo.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("get connection for the backup lock: %w", err)
+	}
+	queries := dbgen.New(conn)
+	locked, err := queries.TryAdvisoryLock(ctx, scheduledBackupLockKey)
+	if err != nil || !locked {
+		_ = conn.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("take the backup lock: %w", err)
+		}
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		released, err := queries.ReleaseAdvisoryLock(context.WithoutCancel(ctx), scheduledBackupLockKey)
+		if err != nil || !released {
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}
+	return unlock, true, nil
+}
+
+func (repo *BackupRepository) Restore(ctx context.Context, restore func(repository.BackupRestorer) error) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := restore(&backupRestorer{queries: repo.queries.WithTx(tx)}); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// backupSnapshot esegue le letture del backup nella transazione di Snapshot.
+type backupSnapshot struct {
+	tx      *sql.Tx
+	queries *dbgen.Queries
+}
+
+func (snapshot *backupSnapshot) GetUser(ctx context.Context, userID int64) (dbgen.User, error) {
+	user, err := snapshot.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.User{}, fmt.Errorf("%w: user ID %d not found", apierr.ErrUserNotFound, userID)
+		}
+		return dbgen.User{}, fmt.Errorf("get user by ID %d: %w", userID, err)
+	}
+	return user, nil
+}
+
+func (snapshot *backupSnapshot) GetNotificationSettings(ctx context.Context, user dbgen.User) (dto.NotificationSettings, error) {
+	return notificationSettings(ctx, snapshot.queries, user)
+}
+
+func (snapshot *backupSnapshot) GetDigestSettings(ctx context.Context, user dbgen.User) (dbgen.DigestSetting, error) {
+	return digestSettings(ctx, snapshot.queries, user)
+}
+
+func (snapshot *backupSnapshot) GetAccounts(ctx context.Context, user dbgen.User) ([]dbgen.Account, error) {
+	accounts, err := snapshot.queries.GetBackupAccounts(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup accounts of user %d: %w", user.ID, err)
+	}
+	return accounts, nil
+}
+
+func (snapshot *backupSnapshot) GetCategories(ctx context.Context, user dbgen.User) ([]dbgen.Category, error) {
+	categories, err := snapshot.queries.GetBackupCategories(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup categories of user %d: %w", user.ID, err)
+	}
+	return categories, nil
+}
+
+func (snapshot *backupSnapshot) GetPayees(ctx context.Context, user dbgen.User) ([]dbgen.Payee, error) {
+	payees, err := snapshot.queries.GetBackupPayees(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup payees of user %d: %w", user.ID, err)
+	}
+	return payees, nil
+}
+
+func (snapshot *backupSnapshot) GetPayeeAliases(ctx context.Context, user dbgen.User) ([]dbgen.PayeeAlias, error) {
+	aliases, err := snapshot.queries.GetBackupPayeeAliases(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup payee aliases of user %d: %w", user.ID, err)
+	}
+	return aliases, nil
+}
+
+func (snapshot *backupSnapshot) GetTags(ctx context.Context, user dbgen.User) ([]dbgen.Tag, error) {
+	tags, err := snapshot.queries.GetBackupTags(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup tags of user %d: %w", user.ID, err)
+	}
+	return tags, nil
+}
+
+func (snapshot *backupSnapshot) GetTransactions(ctx context.Context, user dbgen.User, afterID int64, limit int32) ([]dbgen.Transaction, error) {
+	transactions, err := snapshot.queries.GetBackupTransactions(ctx, dbgen.GetBackupTransactionsParams{
+		AfterID: afterID,
+		UserID:  user.ID,
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get backup transactions of user %d: %w", user.ID, err)
+	}
+	return transactions, nil
+}
+
+func (snapshot *backupSnapshot) GetEntries(ctx context.Context, user dbgen.User, afterID int64, limit int32) ([]dbgen.GetBackupEntriesRow, error) {
+	entries, err := snapshot.queries.GetBackupEntries(ctx, dbgen.GetBackupEntriesParams{
+		UserID:  user.ID,
+		AfterID: afterID,
+		Limit:   limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get backup entries of user %d: %w", user.ID, err)
+	}
+	return entries, nil
+}
+
+func (snapshot *backupSnapshot) GetCreditCards(ctx context.Context, user dbgen.User) ([]dbgen.CreditCard, error) {
+	creditCards, err := snapshot.queries.GetBackupCreditCards(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup credit cards of user %d: %w", user.ID, err)
+	}
+	return creditCards, nil
+}
+
+func (snapshot *backupSnapshot) GetLoans(ctx context.Context, user dbgen.User) ([]dbgen.Loan, error) {
+	loans, err := snapshot.queries.GetBackupLoans(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup loans of user %d: %w", user.ID, err)
+	}
+	return loans, nil
+}
+
+func (snapshot *backupSnapshot) GetLoanInstallments(ctx context.Context, user dbgen.User) ([]dbgen.LoanInstallment, error) {
+	loanInstallments, err := snapshot.queries.GetBackupLoanInstallments(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup loan installments of user %d: %w", user.ID, err)
+	}
+	return loanInstallments, nil
+}
+
+func (snapshot *backupSnapshot) GetSecurities(ctx context.Context, user dbgen.User) ([]dbgen.Security, error) {
+	securities, err := snapshot.queries.GetBackupSecurities(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup securities of user %d: %w", user.ID, err)
+	}
+	return securities, nil
+}
+
+func (snapshot *backupSnapshot) GetSecurityPrices(ctx context.Context, user dbgen.User) ([]dbgen.SecurityPrice, error) {
+	securityPrices, err := snapshot.queries.GetBackupSecurityPrices(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup security prices of user %d: %w", user.ID, err)
+	}
+	return securityPrices, nil
+}
+
+func (snapshot *backupSnapshot) GetInvestmentTrades(ctx context.Context, user dbgen.User) ([]dbgen.InvestmentTrade, error) {
+	investmentTrades, err := snapshot.queries.GetBackupInvestmentTrades(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup investment trades of user %d: %w", user.ID, err)
+	}
+	return investmentTrades, nil
+}
+
+func (snapshot *backupSnapshot) GetRecurringTransactions(ctx context.Context, user dbgen.User) ([]dbgen.RecurringTransaction, error) {
+	recurringTransactions, err := snapshot.queries.GetBackupRecurringTransactions(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup recurring transactions of user %d: %w", user.ID, err)
+	}
+	return recurringTransactions, nil
+}
+
+func (snapshot *backupSnapshot) GetReconciliations(ctx context.Context, user dbgen.User) ([]dbgen.Reconciliation, error) {
+	reconciliations, err := snapshot.queries.GetBackupReconciliations(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup reconciliations of user %d: %w", user.ID, err)
+	}
+	return reconciliations, nil
+}
+
+func (snapshot *backupSnapshot) GetReimbursables(ctx context.Context, user dbgen.User) ([]dbgen.Reimbursable, error) {
+	reimbursables, err := snapshot.queries.GetBackupReimbursables(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup reimbursables of user %d: %w", user.ID, err)
+	}
+	return reimbursables, nil
+}
+
+func (snapshot *backupSnapshot) GetReimbursements(ctx context.Context, user dbgen.User) ([]dbgen.Reimbursement, error) {
+	reimbursements, err := snapshot.queries.GetBackupReimbursements(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup reimbursements of user %d: %w", user.ID, err)
+	}
+	return reimbursements, nil
+}
+
+func (snapshot *backupSnapshot) GetAttachments(ctx context.Context, user dbgen.User) ([]dbgen.Attachment, error) {
+	attachments, err := snapshot.queries.GetBackupAttachments(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup attachments of user %d: %w", user.ID, err)
+	}
+	return attachments, nil
+}
+
+func (snapshot *backupSnapshot) GetSplitGroups(ctx context.Context, user dbgen.User) ([]dbgen.SplitGroup, error) {
+	splitGroups, err := snapshot.queries.GetBackupSplitGroups(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup split groups of user %d: %w", user.ID, err)
+	}
+	return splitGroups, nil
+}
+
+func (snapshot *backupSnapshot) GetSplitMembers(ctx context.Context, user dbgen.User) ([]dbgen.GetBackupSplitMembersRow, error) {
+	splitMembers, err := snapshot.queries.GetBackupSplitMembers(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup split members of user %d: %w", user.ID, err)
+	}
+	return splitMembers, nil
+}
+
+func (snapshot *backupSnapshot) GetSplitExpenses(ctx context.Context, user dbgen.User) ([]dbgen.SplitExpense, error) {
+	splitExpenses, err := snapshot.queries.GetBackupSplitExpenses(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup split expenses of user %d: %w", user.ID, err)
+	}
+	return splitExpenses, nil
+}
+
+func (snapshot *backupSnapshot) GetSplitShares(ctx context.Context, user dbgen.User) ([]dbgen.SplitShare, error) {
+	splitShares, err := snapshot.queries.GetBackupSplitShares(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup split shares of user %d: %w", user.ID, err)
+	}
+	return splitShares, nil
+}
+
+func (snapshot *backupSnapshot) GetSplitSettlements(ctx context.Context, user dbgen.User) ([]dbgen.SplitSettlement, error) {
+	splitSettlements, err := snapshot.queries.GetBackupSplitSettlements(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get backup split settlements of user %d: %w", user.ID, err)
+	}
+	return splitSettlements, nil
+}
+
+// Close termina la transazione: non avendo scritto nulla, il rollback equivale al commit.
+func (snapshot *backupSnapshot) Close() error {
+	err := snapshot.tx.Rollback()
+	if errors.Is(err, sql.ErrTxDone) {
+		return nil
+	}
+	return err
+}
+
+// backupRestorer esegue le query del ripristino nella transazione di Restore.
+type backupRestorer struct {
+	queries *dbgen.Queries
+}
+
+func (restorer *backupRestorer) GetUser(ctx context.Context, email string) (dbgen.User, error) {
+	user, err := restorer.queries.GetUser(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.User{}, fmt.Errorf("%w: %s", apierr.ErrUserNotFound, email)
+		}
+		return dbgen.User{}, fmt.Errorf("get user by email %q: %w", email, err)
+	}
+	return user, nil
+}
+
+func (restorer *backupRestorer) CreateUser(ctx context.Context, email, passwordHash string) (dbgen.User, error) {
+	user, err := restorer.queries.CreateUser(ctx, dbgen.CreateUserParams{
+		Email:        email,
+		PasswordHash: passwordHash,
+	})
+	if err != nil {
+		return dbgen.User{}, fmt.Errorf("create user %q: %w", email, err)
+	}
+	return user, nil
+}
+
+func (restorer *backupRestorer) HasData(ctx context.Context, user dbgen.User) (bool, error) {
+	hasData, err := restorer.queries.HasUserData(ctx, user.ID)
+	if err != nil {
+		return false, fmt.Errorf("check data of user %d: %w", user.ID, err)
+	}
+	return hasData, nil
+}
+
+func (restorer *backupRestorer) SaveNotificationSettings(ctx context.Context, settings dto.NotificationSettings) error {
+	_, err := restorer.queries.UpsertNotificationSettings(ctx, dbgen.UpsertNotificationSettingsParams{
+		UserID:              settings.UserID,
+		WebhookUrl:          nullString(settings.WebhookURL),
+		LowBalanceThreshold: settings.LowBalanceThreshold,
+	})
+	if err != nil {
+		return fmt.Errorf("restore notification settings of user %d: %w", settings.UserID, err)
+	}
+	for _, preference := range settings.Preferences {
+		err := restorer.queries.UpsertNotificationPreference(ctx, dbgen.UpsertNotificationPreferenceParams{
+			UserID:    settings.UserID,
+			EventKind: string(preference.Kind),
+			Email:     preference.Email,
+			Webhook:   preference.Webhook,
+			InApp:     preference.InApp,
+		})
+		if err != nil {
+			return fmt.Errorf("restore notification preference %s of user %d: %w", preference.Kind, settings.UserID, err)
+		}
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) SaveDigestSettings(ctx context.Context, settings dto.DigestSettings) error {
+	_, err := restorer.queries.UpsertDigestSettings(ctx, dbgen.UpsertDigestSettingsParams{
+		UserID:  settings.UserID,
+		Weekly:  settings.Weekly,
+		Monthly: settings.Monthly,
+		Weekday: int16(settings.Weekday),
+	})
+	if err != nil {
+		return fmt.Errorf("restore digest settings of user %d: %w", settings.UserID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateAccount(ctx context.Context, account dbgen.Account) (int64, error) {
+	id, err := restorer.queries.RestoreAccount(ctx, dbgen.RestoreAccountParams{
+		UserID:         account.UserID,
+		Name:           account.Name,
+		Currency:       account.Currency,
+		InitialBalance: account.InitialBalance,
+		OverdraftLimit: account.OverdraftLimit,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore account %q: %w", account.Name, err)
+	}
+	return id, nil
+}
+
+func (restorer *backupRestorer) CreateCategory(ctx context.Context, category dbgen.Category) (int64, error) {
+	id, err := restorer.queries.RestoreCategory(ctx, dbgen.RestoreCategoryParams{
+		UserID:        category.UserID,
+		Name:          category.Name,
+		Type:          category.Type,
+		ArchivedAt:    category.ArchivedAt,
+		MonthlyBudget: category.MonthlyBudget,
+		DeductionCode: category.DeductionCode,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore category %q: %w", category.Name, err)
+	}
+	return id, nil
+}
+
+func (restorer *backupRestorer) SetCategoryParent(ctx context.Context, categoryID, parentID int64) error {
+	err := restorer.queries.RestoreCategoryParent(ctx, dbgen.RestoreCategoryParentParams{
+		ID:       categoryID,
+		ParentID: sql.NullInt64{Int64: parentID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("restore parent of category %d: %w", categoryID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreatePayee(ctx context.Context, payee dbgen.Payee) (int64, error) {
+	created, err := restorer.queries.CreatePayee(ctx, dbgen.CreatePayeeParams{
+		UserID:            payee.UserID,
+		Name:              payee.Name,
+		DefaultCategoryID: payee.DefaultCategoryID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore payee %q: %w", payee.Name, err)
+	}
+	return created.ID, nil
+}
+
+func (restorer *backupRestorer) CreatePayeeAlias(ctx context.Context, alias dbgen.PayeeAlias) error {
+	_, err := restorer.queries.AddPayeeAlias(ctx, dbgen.AddPayeeAliasParams{
+		PayeeID: alias.PayeeID,
+		Pattern: alias.Pattern,
+	})
+	if err != nil {
+		return fmt.Errorf("restore alias %q of payee %d: %w", alias.Pattern, alias.PayeeID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateTag(ctx context.Context, tag dbgen.Tag) (int64, error) {
+	created, err := restorer.queries.CreateTag(ctx, dbgen.CreateTagParams{
+		UserID: tag.UserID,
+		Name:   tag.Name,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore tag %q: %w", tag.Name, err)
+	}
+	return created.ID, nil
+}
+
+func (restorer *backupRestorer) CreateTransaction(ctx context.Context, transaction dbgen.Transaction) (int64, error) {
+	id, err := restorer.queries.AddTransaction(ctx, dbgen.AddTransactionParams{
+		UserID:     transaction.UserID,
+		OccurredAt: transaction.OccurredAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore transaction of %s: %w", transaction.OccurredAt.Format("2006-01-02"), err)
+	}
+	return id, nil
+}
+
+func (restorer *backupRestorer) CreateEntry(ctx context.Context, entry dbgen.TransactionEntry, tagIDs []int64) (int64, error) {
+	id, err := restorer.queries.RestoreEntry(ctx, dbgen.RestoreEntryParams{
+		TransactionID:    entry.TransactionID,
+		AccountID:        entry.AccountID,
+		CategoryID:       entry.CategoryID,
+		Amount:           entry.Amount,
+		Description:      entry.Description,
+		PayeeID:          entry.PayeeID,
+		Status:           entry.Status,
+		DeductionCode:    entry.DeductionCode,
+		ReconciliationID: entry.ReconciliationID,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore entry of transaction %d: %w", entry.TransactionID, err)
+	}
+	for _, tagID := range tagIDs {
+		err := restorer.queries.AddEntryTag(ctx, dbgen.AddEntryTagParams{EntryID: id, TagID: tagID})
+		if err != nil {
+			return 0, fmt.Errorf("tag entry %d: %w", id, err)
+		}
+	}
+	return id, nil
+}
+
+func (restorer *backupRestorer) CreateCreditCard(ctx context.Context, creditCard dbgen.CreditCard) error {
+	_, err := restorer.queries.UpsertCreditCard(ctx, dbgen.UpsertCreditCardParams{
+		AccountID:        creditCard.AccountID,
+		PaymentAccountID: creditCard.PaymentAccountID,
+		ClosingDay:       creditCard.ClosingDay,
+		DueDay:           creditCard.DueDay,
+		AutoPay:          creditCard.AutoPay,
+	})
+	if err != nil {
+		return fmt.Errorf("restore credit card %d: %w", creditCard.AccountID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateLoan(ctx context.Context, loan dbgen.Loan) error {
+	_, err := restorer.queries.CreateLoan(ctx, dbgen.CreateLoanParams{
+		AccountID:        loan.AccountID,
+		PaymentAccountID: loan.PaymentAccountID,
+		Principal:        loan.Principal,
+		AnnualRateBps:    loan.AnnualRateBps,
+		TermMonths:       loan.TermMonths,
+		StartDate:        loan.StartDate,
+		Method:           loan.Method,
+	})
+	if err != nil {
+		return fmt.Errorf("restore loan %d: %w", loan.AccountID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateLoanInstallment(ctx context.Context, installment dbgen.LoanInstallment) error {
+	err := restorer.queries.RestoreLoanInstallment(ctx, dbgen.RestoreLoanInstallmentParams{
+		AccountID:     installment.AccountID,
+		Number:        installment.Number,
+		DueDate:       installment.DueDate,
+		Principal:     installment.Principal,
+		Interest:      installment.Interest,
+		TransactionID: installment.TransactionID,
+	})
+	if err != nil {
+		return fmt.Errorf("restore installment %d of loan %d: %w", installment.Number, installment.AccountID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateSecurity(ctx context.Context, security dbgen.Security) (int64, error) {
+	created, err := restorer.queries.CreateSecurity(ctx, dbgen.CreateSecurityParams{
+		UserID:   security.UserID,
+		Isin:     security.Isin,
+		Ticker:   security.Ticker,
+		Name:     security.Name,
+		Currency: security.Currency,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore security %q: %w", security.Name, err)
+	}
+	return created.ID, nil
+}
+
+func (restorer *backupRestorer) CreateSecurityPrice(ctx context.Context, price dbgen.SecurityPrice) error {
+	err := restorer.queries.UpsertSecurityPrice(ctx, dbgen.UpsertSecurityPriceParams{
+		SecurityID: price.SecurityID,
+		PriceDate:  price.PriceDate,
+		Price:      price.Price,
+	})
+	if err != nil {
+		return fmt.Errorf("restore price of security %d: %w", price.SecurityID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateInvestmentTrade(ctx context.Context, trade dbgen.InvestmentTrade) error {
+	_, err := restorer.queries.CreateInvestmentTrade(ctx, dbgen.CreateInvestmentTradeParams{
+		AccountID:     trade.AccountID,
+		SecurityID:    trade.SecurityID,
+		TransactionID: trade.TransactionID,
+		Kind:          trade.Kind,
+		TradeDate:     trade.TradeDate,
+		Quantity:      trade.Quantity,
+		Price:         trade.Price,
+		Fees:          trade.Fees,
+		Amount:        trade.Amount,
+	})
+	if err != nil {
+		return fmt.Errorf("restore trade of transaction %d: %w", trade.TransactionID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateRecurringTransaction(ctx context.Context, recurring dbgen.RecurringTransaction) error {
+	_, err := restorer.queries.CreateRecurringTransaction(ctx, dbgen.CreateRecurringTransactionParams{
+		UserID:      recurring.UserID,
+		AccountID:   recurring.AccountID,
+		CategoryID:  recurring.CategoryID,
+		Amount:      recurring.Amount,
+		Description: recurring.Description,
+		Frequency:   recurring.Frequency,
+		RepeatEvery: recurring.RepeatEvery,
+		StartDate:   recurring.StartDate,
+		EndDate:     recurring.EndDate,
+	})
+	if err != nil {
+		return fmt.Errorf("restore recurring transaction %q: %w", recurring.Description, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateReconciliation(ctx context.Context, reconciliation dbgen.Reconciliation) (int64, error) {
+	id, err := restorer.queries.RestoreReconciliation(ctx, dbgen.RestoreReconciliationParams{
+		AccountID:        reconciliation.AccountID,
+		StatementDate:    reconciliation.StatementDate,
+		StatementBalance: reconciliation.StatementBalance,
+		Status:           reconciliation.Status,
+		CompletedAt:      reconciliation.CompletedAt,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore reconciliation of account %d: %w", reconciliation.AccountID, err)
+	}
+	return id, nil
+}
+
+func (restorer *backupRestorer) CreateReimbursable(ctx context.Context, reimbursable dbgen.Reimbursable) error {
+	_, err := restorer.queries.MarkReimbursable(ctx, dbgen.MarkReimbursableParams{
+		EntryID: reimbursable.EntryID,
+		UserID:  reimbursable.UserID,
+		Party:   reimbursable.Party,
+	})
+	if err != nil {
+		return fmt.Errorf("restore reimbursable entry %d: %w", reimbursable.EntryID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateReimbursement(ctx context.Context, reimbursement dbgen.Reimbursement) error {
+	_, err := restorer.queries.AddReimbursement(ctx, dbgen.AddReimbursementParams{
+		ExpenseEntryID: reimbursement.ExpenseEntryID,
+		IncomeEntryID:  reimbursement.IncomeEntryID,
+		Amount:         reimbursement.Amount,
+	})
+	if err != nil {
+		return fmt.Errorf("restore reimbursement of entry %d: %w", reimbursement.ExpenseEntryID, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateAttachment(ctx context.Context, attachment dbgen.Attachment) error {
+	_, err := restorer.queries.RestoreAttachment(ctx, dbgen.RestoreAttachmentParams{
+		UserID:        attachment.UserID,
+		TransactionID: attachment.TransactionID,
+		FileName:      attachment.FileName,
+		ContentType:   attachment.ContentType,
+		SizeBytes:     attachment.SizeBytes,
+		StorageKey:    attachment.StorageKey,
+		ThumbnailKey:  attachment.ThumbnailKey,
+		CreatedAt:     attachment.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("restore attachment %q: %w", attachment.FileName, err)
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateSplitGroup(ctx context.Context, group dbgen.SplitGroup) (int64, error) {
+	created, err := restorer.queries.CreateSplitGroup(ctx, dbgen.CreateSplitGroupParams{
+		Name:      group.Name,
+		Currency:  group.Currency,
+		CreatedBy: group.CreatedBy,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore split group %q: %w", group.Name, err)
+	}
+	return created.ID, nil
+}
+
+func (restorer *backupRestorer) CreateSplitMember(ctx context.Context, member dbgen.SplitMember) (int64, error) {
+	created, err := restorer.queries.AddSplitMember(ctx, dbgen.AddSplitMemberParams{
+		GroupID: member.GroupID,
+		UserID:  member.UserID,
+		Name:    member.Name,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("restore member %q of split group %d: %w", member.Name, member.GroupID, err)
+	}
+	return created.ID, nil
+}
+
+func (restorer *backupRestorer) CreateSplitExpense(ctx context.Context, expense dbgen.SplitExpense, shares []dbgen.SplitShare) error {
+	created, err := restorer.queries.CreateSplitExpense(ctx, dbgen.CreateSplitExpenseParams{
+		GroupID:       expense.GroupID,
+		TransactionID: expense.TransactionID,
+		PaidBy:        expense.PaidBy,
+		Amount:        expense.Amount,
+		Method:        expense.Method,
+	})
+	if err != nil {
+		return fmt.Errorf("restore split expense of transaction %d: %w", expense.TransactionID, err)
+	}
+	for _, share := range shares {
+		err := restorer.queries.AddSplitShare(ctx, dbgen.AddSplitShareParams{
+			ExpenseID: created.ID,
+			MemberID:  share.MemberID,
+			Shares:    share.Shares,
+			Amount:    share.Amount,
+		})
+		if err != nil {
+			return fmt.Errorf("restore share of member %d: %w", share.MemberID, err)
+		}
+	}
+	return nil
+}
+
+func (restorer *backupRestorer) CreateSplitSettlement(ctx context.Context, settlement dbgen.SplitSettlement) error {
+	_, err := restorer.queries.CreateSplitSettlement(ctx, dbgen.CreateSplitSettlementParams{
+		GroupID:           settlement.GroupID,
+		FromMemberID:      settlement.FromMemberID,
+		ToMemberID:        settlement.ToMemberID,
+		Amount:            settlement.Amount,
+		SettledAt:         settlement.SettledAt,
+		FromTransactionID: settlement.FromTransactionID,
+		ToTransactionID:   settlement.ToTransactionID,
+	})
+	if err != nil {
+		return fmt.Errorf("restore settlement of split group %d: %w", settlement.GroupID, err)
+	}
+	return nil
+}
@@ -3,10 +3,13 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"koin/internal/model/dto"
+	"time"
 
 	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
 )
 
 type CategoryRepository struct {
@@ -33,11 +36,26 @@ func (repo *CategoryRepository) GetCategory(ctx context.Context, user dbgen.User
 	return category, nil
 }
 
-func (repo *CategoryRepository) CreateCategory(ctx context.Context, user dbgen.User, categoryName string, categoryType dto.CategoryType) (dbgen.Category, error) {
-	category, err := repo.queries.CreateCategory(ctx, dbgen.CreateCategoryParams{
+func (repo *CategoryRepository) GetCategoryByID(ctx context.Context, user dbgen.User, categoryID int64) (dbgen.Category, error) {
+	category, err := repo.queries.GetCategoryByID(ctx, dbgen.GetCategoryByIDParams{
+		ID:     categoryID,
 		UserID: user.ID,
-		Name:   categoryName,
-		Type:   string(categoryType),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Category{}, fmt.Errorf("%w: %d", apierr.ErrCategoryNotFound, categoryID)
+		}
+		return dbgen.Category{}, fmt.Errorf("get category %d: %w", categoryID, err)
+	}
+	return category, nil
+}
+
+func (repo *CategoryRepository) CreateCategory(ctx context.Context, user dbgen.User, categoryName string, categoryType dto.CategoryType, parentID *int64) (dbgen.Category, error) {
+	category, err := repo.queries.CreateCategory(ctx, dbgen.CreateCategoryParams{
+		UserID:   user.ID,
+		Name:     categoryName,
+		Type:     string(categoryType),
+		ParentID: nullInt64(parentID),
 	})
 	if err != nil {
 		return dbgen.Category{}, fmt.Errorf("create category %q: %w", categoryName, err)
@@ -52,3 +70,161 @@ func (repo *CategoryRepository) GetCategories(ctx context.Context, user dbgen.Us
 	}
 	return categories, nil
 }
+
+// GetCategoryRole restituisce il ruolo con cui l'utente accede alla categoria.
+func (repo *CategoryRepository) GetCategoryRole(ctx context.Context, user dbgen.User, categoryID int64) (dto.HouseholdRole, error) {
+	role, err := repo.queries.GetCategoryRole(ctx, dbgen.GetCategoryRoleParams{
+		CategoryID: categoryID,
+		UserID:     user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %d", apierr.ErrCategoryNotFound, categoryID)
+		}
+		return "", fmt.Errorf("get role on category %d: %w", categoryID, err)
+	}
+	return dto.HouseholdRole(role), nil
+}
+
+func (repo *CategoryRepository) RenameCategory(ctx context.Context, user dbgen.User, categoryID int64, name string) (dbgen.Category, error) {
+	category, err := repo.queries.RenameCategory(ctx, dbgen.RenameCategoryParams{
+		ID:     categoryID,
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Category{}, fmt.Errorf("%w: %d", apierr.ErrCategoryNotFound, categoryID)
+		}
+		return dbgen.Category{}, fmt.Errorf("rename category %d to %q: %w", categoryID, name, err)
+	}
+	return category, nil
+}
+
+func (repo *CategoryRepository) SetCategoryParent(ctx context.Context, user dbgen.User, categoryID int64, parentID *int64) (dbgen.Category, error) {
+	category, err := repo.queries.SetCategoryParent(ctx, dbgen.SetCategoryParentParams{
+		ID:       categoryID,
+		UserID:   user.ID,
+		ParentID: nullInt64(parentID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Category{}, fmt.Errorf("%w: %d", apierr.ErrCategoryNotFound, categoryID)
+		}
+		return dbgen.Category{}, fmt.Errorf("set parent of category %d: %w", categoryID, err)
+	}
+	return category, nil
+}
+
+func (repo *CategoryRepository) ArchiveCategory(ctx context.Context, user dbgen.User, categoryID int64) (dbgen.Category, error) {
+	category, err := repo.queries.ArchiveCategory(ctx, dbgen.ArchiveCategoryParams{
+		ID:     categoryID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Category{}, fmt.Errorf("%w: %d", apierr.ErrCategoryNotFound, categoryID)
+		}
+		return dbgen.Category{}, fmt.Errorf("archive category %d: %w", categoryID, err)
+	}
+	return category, nil
+}
+
+func (repo *CategoryRepository) UnarchiveCategory(ctx context.Context, user dbgen.User, categoryID int64) (dbgen.Category, error) {
+	category, err := repo.queries.UnarchiveCategory(ctx, dbgen.UnarchiveCategoryParams{
+		ID:     categoryID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Category{}, fmt.Errorf("%w: %d", apierr.ErrCategoryNotFound, categoryID)
+		}
+		return dbgen.Category{}, fmt.Errorf("unarchive category %d: %w", categoryID, err)
+	}
+	return category, nil
+}
+
+func (repo *CategoryRepository) SetCategoryBudget(ctx context.Context, categoryID int64, monthlyBudget *int64) (dbgen.Category, error) {
+	category, err := repo.queries.SetCategoryBudget(ctx, dbgen.SetCategoryBudgetParams{
+		MonthlyBudget: nullInt64(monthlyBudget),
+		ID:            categoryID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Category{}, fmt.Errorf("%w: %d", apierr.ErrCategoryNotFound, categoryID)
+		}
+		return dbgen.Category{}, fmt.Errorf("set budget of category %d: %w", categoryID, err)
+	}
+	return category, nil
+}
+
+func (repo *CategoryRepository) SetCategoryDeduction(ctx context.Context, categoryID int64, code *string) (dbgen.Category, error) {
+	category, err := repo.queries.SetCategoryDeduction(ctx, dbgen.SetCategoryDeductionParams{
+		DeductionCode: nullString(code),
+		ID:            categoryID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Category{}, fmt.Errorf("%w: %d", apierr.ErrCategoryNotFound, categoryID)
+		}
+		return dbgen.Category{}, fmt.Errorf("set deduction of category %d: %w", categoryID, err)
+	}
+	return category, nil
+}
+
+// MergeCategories sposta tutte le righe contabili e le sottocategorie di source
+// su target ed elimina source, in un'unica transazione.
+func (repo *CategoryRepository) MergeCategories(ctx context.Context, user dbgen.User, source dbgen.Category, target dbgen.Category) (int64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	moved, err := queries.ReassignCategoryEntries(ctx, dbgen.ReassignCategoryEntriesParams{
+		TargetCategoryID: target.ID,
+		SourceCategoryID: source.ID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("reassign entries of category %d: %w", source.ID, err)
+	}
+
+	err = queries.ReparentCategoryChildren(ctx, dbgen.ReparentCategoryChildrenParams{
+		TargetCategoryID: target.ID,
+		SourceCategoryID: source.ID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("reparent children of category %d: %w", source.ID, err)
+	}
+
+	err = queries.DeleteCategory(ctx, dbgen.DeleteCategoryParams{
+		ID:     source.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("delete category %d: %w", source.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+func (repo *CategoryRepository) GetCategoryTotals(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time, tag *string) ([]dbgen.GetCategoryTotalsByUserRow, error) {
+	totals, err := repo.queries.GetCategoryTotalsByUser(ctx, dbgen.GetCategoryTotalsByUserParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+		Tag:      nullString(tag),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get category totals: %w", err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"koin/internal/model/dto"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// changesChannel è il canale su cui il trigger dell'outbox notifica le modifiche.
+const changesChannel = "koin_changes"
+
+// changeNotification è il payload JSON inviato da webhook_outbox_notify.
+type changeNotification struct {
+	ID         int64   `json:"id"`
+	Event      string  `json:"event"`
+	Resource   string  `json:"resource"`
+	ResourceID int64   `json:"resourceId"`
+	UserIDs    []int64 `json:"userIds"`
+}
+
+type ChangeRepository struct {
+	db *sql.DB
+}
+
+func NewChangeRepository(db *sql.DB) *ChangeRepository {
+	return &ChangeRepository{
+		db: db,
+	}
+}
+
+// Listen tiene occupata una connessione del pool con LISTEN; all'uscita la connessione viene
+// scartata invece di tornare nel pool.
+func (repo *ChangeRepository) Listen(ctx context.Context, handle func(dto.ChangeEvent)) error {
+	conn, err := repo.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", changesChannel, err)
+	}
+	defer conn.Close()
+
+	var listenErr error
+	err = conn.Raw(func(driverConn any) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		if _, err := pgxConn.Exec(ctx, "LISTEN "+changesChannel); err != nil {
+			listenErr = err
+			return driver.ErrBadConn
+		}
+		for {
+			notification, err := pgxConn.WaitForNotification(ctx)
+			if err != nil {
+				listenErr = err
+				return driver.ErrBadConn
+			}
+
+			var change changeNotification
+			if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+				log.Printf("changes: invalid notification %q: %v", notification.Payload, err)
+				continue
+			}
+			handle(dto.ChangeEvent{
+				ID:         change.ID,
+				Event:      change.Event,
+				Resource:   change.Resource,
+				ResourceID: change.ResourceID,
+				UserIDs:    change.UserIDs,
+			})
+		}
+	})
+	if listenErr != nil {
+		return fmt.Errorf("listen %s: %w", changesChannel, listenErr)
+	}
+	return err
+}
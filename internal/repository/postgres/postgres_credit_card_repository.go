@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type CreditCardRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewCreditCardRepository(db *sql.DB) *CreditCardRepository {
+	return &CreditCardRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *CreditCardRepository) SaveCreditCard(ctx context.Context, configureDto dto.ConfigureCreditCardDto) (dbgen.CreditCard, error) {
+	creditCard, err := repo.queries.UpsertCreditCard(ctx, dbgen.UpsertCreditCardParams{
+		AccountID:        configureDto.AccountID,
+		PaymentAccountID: configureDto.PaymentAccountID,
+		ClosingDay:       configureDto.ClosingDay,
+		DueDay:           configureDto.DueDay,
+		AutoPay:          configureDto.AutoPay,
+	})
+	if err != nil {
+		return dbgen.CreditCard{}, fmt.Errorf("save credit card %d: %w", configureDto.AccountID, err)
+	}
+	return creditCard, nil
+}
+
+func (repo *CreditCardRepository) GetCreditCard(ctx context.Context, user dbgen.User, accountID int64) (dbgen.CreditCard, error) {
+	creditCard, err := repo.queries.GetCreditCard(ctx, dbgen.GetCreditCardParams{
+		AccountID: accountID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.CreditCard{}, fmt.Errorf("%w: %d", apierr.ErrCreditCardNotFound, accountID)
+		}
+		return dbgen.CreditCard{}, fmt.Errorf("get credit card %d: %w", accountID, err)
+	}
+	return creditCard, nil
+}
+
+func (repo *CreditCardRepository) GetCreditCards(ctx context.Context, user dbgen.User) ([]dbgen.CreditCard, error) {
+	creditCards, err := repo.queries.GetCreditCardsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get credit cards by user: %w", err)
+	}
+	return creditCards, nil
+}
+
+func (repo *CreditCardRepository) GetAutoPayCreditCards(ctx context.Context) ([]dbgen.GetAutoPayCreditCardsRow, error) {
+	creditCards, err := repo.queries.GetAutoPayCreditCards(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get auto pay credit cards: %w", err)
+	}
+	return creditCards, nil
+}
+
+func (repo *CreditCardRepository) GetAllCreditCards(ctx context.Context) ([]dbgen.GetAllCreditCardsRow, error) {
+	creditCards, err := repo.queries.GetAllCreditCards(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get all credit cards: %w", err)
+	}
+	return creditCards, nil
+}
+
+func (repo *CreditCardRepository) DeleteCreditCard(ctx context.Context, creditCard dbgen.CreditCard) error {
+	deleted, err := repo.queries.DeleteCreditCard(ctx, creditCard.AccountID)
+	if err != nil {
+		return fmt.Errorf("delete credit card %d: %w", creditCard.AccountID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrCreditCardNotFound, creditCard.AccountID)
+	}
+	return nil
+}
+
+func (repo *CreditCardRepository) GetMovements(ctx context.Context, creditCard dbgen.CreditCard, dateFrom time.Time) ([]dbgen.GetCreditCardMovementsRow, error) {
+	movements, err := repo.queries.GetCreditCardMovements(ctx, dbgen.GetCreditCardMovementsParams{
+		AccountID: creditCard.AccountID,
+		DateFrom:  dateFrom,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get movements of credit card %d: %w", creditCard.AccountID, err)
+	}
+	return movements, nil
+}
+
+// PayStatement registra il pagamento di un estratto conto come trasferimento dal conto di
+// addebito alla carta. La carta resta bloccata fino al commit e amount calcola l'importo dai
+// movimenti da dateFrom letti dopo il blocco, così che l'addebito automatico e i pagamenti
+// dell'utente non saldino due volte lo stesso estratto conto.
+func (repo *CreditCardRepository) PayStatement(ctx context.Context, user dbgen.User, creditCard dbgen.CreditCard, fromAccount dbgen.Account, toAccount dbgen.Account, transfer dto.TransferBetweenAccountsDto, dateFrom time.Time, amount func([]dbgen.GetCreditCardMovementsRow) (int64, error)) (int64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	if _, err := queries.GetCreditCardForUpdate(ctx, creditCard.AccountID); err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("%w: %d", apierr.ErrCreditCardNotFound, creditCard.AccountID)
+		}
+		return 0, fmt.Errorf("lock credit card %d: %w", creditCard.AccountID, err)
+	}
+
+	movements, err := queries.GetCreditCardMovements(ctx, dbgen.GetCreditCardMovementsParams{
+		AccountID: creditCard.AccountID,
+		DateFrom:  dateFrom,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("get movements of credit card %d: %w", creditCard.AccountID, err)
+	}
+
+	transfer.Amount, err = amount(movements)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	transactionID, err := addTransfer(ctx, queries, user, fromAccount, toAccount, transfer)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return transactionID, nil
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+)
+
+type DeductionRepository struct {
+	queries *dbgen.Queries
+}
+
+func NewDeductionRepository(db *sql.DB) *DeductionRepository {
+	return &DeductionRepository{
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *DeductionRepository) GetEntry(ctx context.Context, user dbgen.User, entryID int64) (dbgen.TransactionEntry, error) {
+	entry, err := repo.queries.GetTransactionEntry(ctx, dbgen.GetTransactionEntryParams{
+		ID:     entryID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.TransactionEntry{}, fmt.Errorf("%w: entry %d", apierr.ErrTransactionNotFound, entryID)
+		}
+		return dbgen.TransactionEntry{}, fmt.Errorf("get entry %d: %w", entryID, err)
+	}
+	return entry, nil
+}
+
+func (repo *DeductionRepository) SetEntryDeduction(ctx context.Context, entryID int64, code *string) (dbgen.TransactionEntry, error) {
+	entry, err := repo.queries.SetEntryDeduction(ctx, dbgen.SetEntryDeductionParams{
+		DeductionCode: nullString(code),
+		ID:            entryID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.TransactionEntry{}, fmt.Errorf("%w: entry %d", apierr.ErrTransactionNotFound, entryID)
+		}
+		return dbgen.TransactionEntry{}, fmt.Errorf("set deduction of entry %d: %w", entryID, err)
+	}
+	return entry, nil
+}
+
+func (repo *DeductionRepository) GetDeductibleEntries(ctx context.Context, user dbgen.User, year int) ([]dbgen.GetDeductibleEntriesRow, error) {
+	entries, err := repo.queries.GetDeductibleEntries(ctx, dbgen.GetDeductibleEntriesParams{
+		UserID: user.ID,
+		Year:   int32(year),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get deductible entries of user %d: %w", user.ID, err)
+	}
+	return entries, nil
+}
+
+func (repo *DeductionRepository) GetReceipts(ctx context.Context, user dbgen.User, year int) ([]dbgen.GetDeductionReceiptsRow, error) {
+	receipts, err := repo.queries.GetDeductionReceipts(ctx, dbgen.GetDeductionReceiptsParams{
+		UserID: user.ID,
+		Year:   int32(year),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get deduction receipts of user %d: %w", user.ID, err)
+	}
+	return receipts, nil
+}
@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+type DigestRepository struct {
+	queries *dbgen.Queries
+}
+
+func NewDigestRepository(db *sql.DB) *DigestRepository {
+	return &DigestRepository{
+		queries: dbgen.New(db),
+	}
+}
+
+// GetSettings restituisce le impostazioni dell'utente; senza impostazioni salvate i riepiloghi
+// sono disattivati e il settimanale è previsto di lunedì.
+func (repo *DigestRepository) GetSettings(ctx context.Context, user dbgen.User) (dbgen.DigestSetting, error) {
+	return digestSettings(ctx, repo.queries, user)
+}
+
+// digestSettings legge le impostazioni con queries, anche dentro una transazione: senza
+// impostazioni salvate i digest sono disattivati.
+func digestSettings(ctx context.Context, queries *dbgen.Queries, user dbgen.User) (dbgen.DigestSetting, error) {
+	settings, err := queries.GetDigestSettings(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.DigestSetting{UserID: user.ID, Weekday: int16(time.Monday)}, nil
+		}
+		return dbgen.DigestSetting{}, fmt.Errorf("get digest settings of user %d: %w", user.ID, err)
+	}
+	return settings, nil
+}
+
+func (repo *DigestRepository) SaveSettings(ctx context.Context, settings dto.DigestSettings) (dbgen.DigestSetting, error) {
+	saved, err := repo.queries.UpsertDigestSettings(ctx, dbgen.UpsertDigestSettingsParams{
+		UserID:  settings.UserID,
+		Weekly:  settings.Weekly,
+		Monthly: settings.Monthly,
+		Weekday: int16(settings.Weekday),
+	})
+	if err != nil {
+		return dbgen.DigestSetting{}, fmt.Errorf("save digest settings of user %d: %w", settings.UserID, err)
+	}
+	return saved, nil
+}
+
+func (repo *DigestRepository) GetEnabledSettings(ctx context.Context) ([]dbgen.DigestSetting, error) {
+	settings, err := repo.queries.GetEnabledDigestSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get enabled digest settings: %w", err)
+	}
+	return settings, nil
+}
+
+// ClaimSend segna come inviato il riepilogo previsto per sentOn se non lo è già e indica se
+// la prenotazione è riuscita: solo chi la ottiene deve spedire l'email.
+func (repo *DigestRepository) ClaimSend(ctx context.Context, userID int64, frequency dto.Frequency, sentOn time.Time) (bool, error) {
+	var claimed int64
+	var err error
+	if frequency == dto.Weekly {
+		claimed, err = repo.queries.ClaimWeeklyDigest(ctx, dbgen.ClaimWeeklyDigestParams{UserID: userID, SentOn: sentOn})
+	} else {
+		claimed, err = repo.queries.ClaimMonthlyDigest(ctx, dbgen.ClaimMonthlyDigestParams{UserID: userID, SentOn: sentOn})
+	}
+	if err != nil {
+		return false, fmt.Errorf("claim %s digest of user %d: %w", frequency, userID, err)
+	}
+	return claimed > 0, nil
+}
+
+// ReleaseSend ripristina l'ultimo invio precedente dopo una spedizione fallita, così il
+// riepilogo viene ritentato.
+func (repo *DigestRepository) ReleaseSend(ctx context.Context, userID int64, frequency dto.Frequency, sentOn time.Time, previous sql.NullTime) error {
+	var err error
+	if frequency == dto.Weekly {
+		err = repo.queries.ReleaseWeeklyDigest(ctx, dbgen.ReleaseWeeklyDigestParams{UserID: userID, SentOn: sentOn, Previous: previous})
+	} else {
+		err = repo.queries.ReleaseMonthlyDigest(ctx, dbgen.ReleaseMonthlyDigestParams{UserID: userID, SentOn: sentOn, Previous: previous})
+	}
+	if err != nil {
+		return fmt.Errorf("release %s digest of user %d: %w", frequency, userID, err)
+	}
+	return nil
+}
+
+func (repo *DigestRepository) GetLargestEntries(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time, limit int32) ([]dbgen.GetLargestEntriesRow, error) {
+	entries, err := repo.queries.GetLargestEntries(ctx, dbgen.GetLargestEntriesParams{
+		UserID:     user.ID,
+		DateFrom:   dateFrom,
+		DateTo:     dateTo,
+		MaxEntries: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get largest entries of user %d: %w", user.ID, err)
+	}
+	return entries, nil
+}
+
+func (repo *DigestRepository) GetBalanceChanges(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetAccountBalanceChangesRow, error) {
+	accounts, err := repo.queries.GetAccountBalanceChanges(ctx, dbgen.GetAccountBalanceChangesParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get balance changes of user %d: %w", user.ID, err)
+	}
+	return accounts, nil
+}
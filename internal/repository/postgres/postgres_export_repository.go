@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+type ExportRepository struct {
+	queries *dbgen.Queries
+}
+
+func NewExportRepository(db *sql.DB) *ExportRepository {
+	return &ExportRepository{
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *ExportRepository) GetAccounts(ctx context.Context, user dbgen.User, accountID *int64) ([]dbgen.GetExportAccountsRow, error) {
+	accounts, err := repo.queries.GetExportAccounts(ctx, dbgen.GetExportAccountsParams{
+		UserID:    user.ID,
+		AccountID: nullInt64(accountID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get export accounts of user %d: %w", user.ID, err)
+	}
+	return accounts, nil
+}
+
+// GetEntries restituisce il lotto di movimenti successivo ad after, o il primo se after è nil.
+func (repo *ExportRepository) GetEntries(ctx context.Context, user dbgen.User, filter dto.ExportFilter, after *dbgen.GetExportEntriesRow, limit int32) ([]dbgen.GetExportEntriesRow, error) {
+	params := dbgen.GetExportEntriesParams{
+		UserID:    user.ID,
+		AccountID: nullInt64(filter.AccountID),
+		DateFrom:  nullTime(filter.DateFrom),
+		DateTo:    nullTime(filter.DateTo),
+		Tag:       nullString(filter.Tag),
+		Limit:     limit,
+	}
+	if after != nil {
+		params.AfterDate = sql.NullTime{Time: after.OccurredAt, Valid: true}
+		params.AfterID = sql.NullInt64{Int64: after.EntryID, Valid: true}
+	}
+
+	entries, err := repo.queries.GetExportEntries(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("get export entries of user %d: %w", user.ID, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type ForecastRepository struct {
+	queries *dbgen.Queries
+}
+
+func NewForecastRepository(db *sql.DB) *ForecastRepository {
+	return &ForecastRepository{
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *ForecastRepository) SetOverdraft(ctx context.Context, account dbgen.Account, overdraftLimit int64) (dbgen.Account, error) {
+	updated, err := repo.queries.SetAccountOverdraft(ctx, dbgen.SetAccountOverdraftParams{
+		ID:             account.ID,
+		OverdraftLimit: overdraftLimit,
+	})
+	if err != nil {
+		return dbgen.Account{}, fmt.Errorf("set overdraft of account %d: %w", account.ID, err)
+	}
+	return updated, nil
+}
+
+func (repo *ForecastRepository) CreateRecurring(ctx context.Context, createDto dto.CreateRecurringDto) (dbgen.RecurringTransaction, error) {
+	var endDate sql.NullTime
+	if createDto.EndDate != nil {
+		endDate = sql.NullTime{Time: *createDto.EndDate, Valid: true}
+	}
+
+	recurring, err := repo.queries.CreateRecurringTransaction(ctx, dbgen.CreateRecurringTransactionParams{
+		UserID:      createDto.UserID,
+		AccountID:   createDto.AccountID,
+		CategoryID:  createDto.CategoryID,
+		Amount:      createDto.Amount,
+		Description: createDto.Description,
+		Frequency:   string(createDto.Frequency),
+		RepeatEvery: createDto.RepeatEvery,
+		StartDate:   createDto.StartDate,
+		EndDate:     endDate,
+	})
+	if err != nil {
+		return dbgen.RecurringTransaction{}, fmt.Errorf("create recurring transaction on account %d: %w", createDto.AccountID, err)
+	}
+	return recurring, nil
+}
+
+func (repo *ForecastRepository) GetRecurring(ctx context.Context, user dbgen.User) ([]dbgen.GetRecurringTransactionsByUserRow, error) {
+	recurring, err := repo.queries.GetRecurringTransactionsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get recurring transactions of user %d: %w", user.ID, err)
+	}
+	return recurring, nil
+}
+
+func (repo *ForecastRepository) GetRecurringByID(ctx context.Context, user dbgen.User, recurringID int64) (dbgen.RecurringTransaction, error) {
+	recurring, err := repo.queries.GetRecurringTransaction(ctx, dbgen.GetRecurringTransactionParams{
+		ID:     recurringID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.RecurringTransaction{}, fmt.Errorf("%w: %d", apierr.ErrRecurringNotFound, recurringID)
+		}
+		return dbgen.RecurringTransaction{}, fmt.Errorf("get recurring transaction %d: %w", recurringID, err)
+	}
+	return recurring, nil
+}
+
+func (repo *ForecastRepository) DeleteRecurring(ctx context.Context, recurring dbgen.RecurringTransaction) error {
+	deleted, err := repo.queries.DeleteRecurringTransaction(ctx, recurring.ID)
+	if err != nil {
+		return fmt.Errorf("delete recurring transaction %d: %w", recurring.ID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrRecurringNotFound, recurring.ID)
+	}
+	return nil
+}
+
+func (repo *ForecastRepository) GetDiscretionarySpending(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetDiscretionarySpendingRow, error) {
+	spending, err := repo.queries.GetDiscretionarySpending(ctx, dbgen.GetDiscretionarySpendingParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get discretionary spending of user %d: %w", user.ID, err)
+	}
+	return spending, nil
+}
@@ -0,0 +1,276 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type HouseholdRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewHouseholdRepository(db *sql.DB) *HouseholdRepository {
+	return &HouseholdRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+// CreateHousehold crea la household e ne registra il creatore come OWNER.
+func (repo *HouseholdRepository) CreateHousehold(ctx context.Context, user dbgen.User, name string) (dbgen.Household, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.Household{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	household, err := queries.CreateHousehold(ctx, name)
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Household{}, fmt.Errorf("create household %q: %w", name, err)
+	}
+
+	_, err = queries.AddHouseholdMember(ctx, dbgen.AddHouseholdMemberParams{
+		HouseholdID: household.ID,
+		UserID:      user.ID,
+		Role:        string(dto.Owner),
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Household{}, fmt.Errorf("add owner to household %d: %w", household.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.Household{}, err
+	}
+	return household, nil
+}
+
+func (repo *HouseholdRepository) GetHouseholds(ctx context.Context, user dbgen.User) ([]dbgen.GetHouseholdsByUserRow, error) {
+	households, err := repo.queries.GetHouseholdsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get households of user %d: %w", user.ID, err)
+	}
+	return households, nil
+}
+
+func (repo *HouseholdRepository) GetHousehold(ctx context.Context, householdID int64) (dbgen.Household, error) {
+	household, err := repo.queries.GetHouseholdByID(ctx, householdID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Household{}, fmt.Errorf("%w: %d", apierr.ErrHouseholdNotFound, householdID)
+		}
+		return dbgen.Household{}, fmt.Errorf("get household %d: %w", householdID, err)
+	}
+	return household, nil
+}
+
+func (repo *HouseholdRepository) GetMember(ctx context.Context, household dbgen.Household, userID int64) (dbgen.HouseholdMember, error) {
+	member, err := repo.queries.GetHouseholdMember(ctx, dbgen.GetHouseholdMemberParams{
+		HouseholdID: household.ID,
+		UserID:      userID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.HouseholdMember{}, fmt.Errorf("%w: user %d is not a member of household %d", apierr.ErrHouseholdNotFound, userID, household.ID)
+		}
+		return dbgen.HouseholdMember{}, fmt.Errorf("get member %d of household %d: %w", userID, household.ID, err)
+	}
+	return member, nil
+}
+
+func (repo *HouseholdRepository) GetMembers(ctx context.Context, household dbgen.Household) ([]dbgen.GetHouseholdMembersRow, error) {
+	members, err := repo.queries.GetHouseholdMembers(ctx, household.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get members of household %d: %w", household.ID, err)
+	}
+	return members, nil
+}
+
+func (repo *HouseholdRepository) CountMembers(ctx context.Context, household dbgen.Household) (dbgen.CountHouseholdMembersRow, error) {
+	count, err := repo.queries.CountHouseholdMembers(ctx, household.ID)
+	if err != nil {
+		return dbgen.CountHouseholdMembersRow{}, fmt.Errorf("count members of household %d: %w", household.ID, err)
+	}
+	return count, nil
+}
+
+func (repo *HouseholdRepository) UpdateMemberRole(ctx context.Context, household dbgen.Household, userID int64, role dto.HouseholdRole) (dbgen.HouseholdMember, error) {
+	member, err := repo.queries.UpdateHouseholdMemberRole(ctx, dbgen.UpdateHouseholdMemberRoleParams{
+		HouseholdID: household.ID,
+		UserID:      userID,
+		Role:        string(role),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.HouseholdMember{}, fmt.Errorf("%w: user %d is not a member of household %d", apierr.ErrHouseholdNotFound, userID, household.ID)
+		}
+		return dbgen.HouseholdMember{}, fmt.Errorf("update role of member %d in household %d: %w", userID, household.ID, err)
+	}
+	return member, nil
+}
+
+// RemoveMember toglie l'utente dalla household e smette di condividere i suoi account e le sue
+// categorie; la household viene eliminata quando esce l'ultimo membro.
+func (repo *HouseholdRepository) RemoveMember(ctx context.Context, household dbgen.Household, userID int64) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	queries := repo.queries.WithTx(tx)
+	householdID := sql.NullInt64{Int64: household.ID, Valid: true}
+
+	if err := queries.UnshareMemberAccounts(ctx, dbgen.UnshareMemberAccountsParams{HouseholdID: householdID, UserID: userID}); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("unshare accounts of member %d: %w", userID, err)
+	}
+	if err := queries.UnshareMemberCategories(ctx, dbgen.UnshareMemberCategoriesParams{HouseholdID: householdID, UserID: userID}); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("unshare categories of member %d: %w", userID, err)
+	}
+
+	removed, err := queries.DeleteHouseholdMember(ctx, dbgen.DeleteHouseholdMemberParams{
+		HouseholdID: household.ID,
+		UserID:      userID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("remove member %d from household %d: %w", userID, household.ID, err)
+	}
+	if removed == 0 {
+		_ = tx.Rollback()
+		return fmt.Errorf("%w: user %d is not a member of household %d", apierr.ErrHouseholdNotFound, userID, household.ID)
+	}
+
+	count, err := queries.CountHouseholdMembers(ctx, household.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("count members of household %d: %w", household.ID, err)
+	}
+	if count.Members == 0 {
+		if err := queries.DeleteHousehold(ctx, household.ID); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("delete household %d: %w", household.ID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *HouseholdRepository) CreateInvite(ctx context.Context, household dbgen.Household, code string, inviteDto dto.CreateInviteDto) (dbgen.HouseholdInvite, error) {
+	invite, err := repo.queries.CreateHouseholdInvite(ctx, dbgen.CreateHouseholdInviteParams{
+		HouseholdID: household.ID,
+		Code:        code,
+		Email:       nullString(inviteDto.Email),
+		Role:        string(inviteDto.Role),
+		InvitedBy:   inviteDto.UserID,
+		ExpiresAt:   inviteDto.ExpiresAt,
+	})
+	if err != nil {
+		return dbgen.HouseholdInvite{}, fmt.Errorf("create invite for household %d: %w", household.ID, err)
+	}
+	return invite, nil
+}
+
+func (repo *HouseholdRepository) GetInviteByCode(ctx context.Context, code string) (dbgen.HouseholdInvite, error) {
+	invite, err := repo.queries.GetHouseholdInviteByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.HouseholdInvite{}, fmt.Errorf("%w: %s", apierr.ErrInviteNotFound, code)
+		}
+		return dbgen.HouseholdInvite{}, fmt.Errorf("get invite %q: %w", code, err)
+	}
+	return invite, nil
+}
+
+func (repo *HouseholdRepository) GetPendingInvites(ctx context.Context, household dbgen.Household) ([]dbgen.HouseholdInvite, error) {
+	invites, err := repo.queries.GetPendingHouseholdInvites(ctx, household.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get invites of household %d: %w", household.ID, err)
+	}
+	return invites, nil
+}
+
+// AcceptInvite consuma l'invito e aggiunge l'utente alla household con il ruolo indicato.
+// Un invito già usato o scaduto nel frattempo restituisce ErrConflict.
+func (repo *HouseholdRepository) AcceptInvite(ctx context.Context, user dbgen.User, invite dbgen.HouseholdInvite) (dbgen.HouseholdMember, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.HouseholdMember{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	accepted, err := queries.AcceptHouseholdInvite(ctx, dbgen.AcceptHouseholdInviteParams{
+		AcceptedBy: sql.NullInt64{Int64: user.ID, Valid: true},
+		ID:         invite.ID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.HouseholdMember{}, fmt.Errorf("accept invite %d: %w", invite.ID, err)
+	}
+	if accepted == 0 {
+		_ = tx.Rollback()
+		return dbgen.HouseholdMember{}, fmt.Errorf("%w: invite already used or expired", apierr.ErrConflict)
+	}
+
+	member, err := queries.AddHouseholdMember(ctx, dbgen.AddHouseholdMemberParams{
+		HouseholdID: invite.HouseholdID,
+		UserID:      user.ID,
+		Role:        invite.Role,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.HouseholdMember{}, fmt.Errorf("add member %d to household %d: %w", user.ID, invite.HouseholdID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.HouseholdMember{}, err
+	}
+	return member, nil
+}
+
+func (repo *HouseholdRepository) RevokeInvite(ctx context.Context, household dbgen.Household, inviteID int64) error {
+	deleted, err := repo.queries.DeleteHouseholdInvite(ctx, dbgen.DeleteHouseholdInviteParams{
+		ID:          inviteID,
+		HouseholdID: household.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("revoke invite %d: %w", inviteID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrInviteNotFound, inviteID)
+	}
+	return nil
+}
+
+func (repo *HouseholdRepository) ShareAccount(ctx context.Context, account dbgen.Account, householdID *int64) (dbgen.Account, error) {
+	shared, err := repo.queries.SetAccountHousehold(ctx, dbgen.SetAccountHouseholdParams{
+		HouseholdID: nullInt64(householdID),
+		ID:          account.ID,
+	})
+	if err != nil {
+		return dbgen.Account{}, fmt.Errorf("share account %d: %w", account.ID, err)
+	}
+	return shared, nil
+}
+
+func (repo *HouseholdRepository) ShareCategory(ctx context.Context, category dbgen.Category, householdID *int64) (dbgen.Category, error) {
+	shared, err := repo.queries.SetCategoryHousehold(ctx, dbgen.SetCategoryHouseholdParams{
+		HouseholdID: nullInt64(householdID),
+		ID:          category.ID,
+	})
+	if err != nil {
+		return dbgen.Category{}, fmt.Errorf("share category %d: %w", category.ID, err)
+	}
+	return shared, nil
+}
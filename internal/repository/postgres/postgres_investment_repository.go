@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+type InvestmentRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewInvestmentRepository(db *sql.DB) *InvestmentRepository {
+	return &InvestmentRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+// AddTrade registra l'operazione insieme alla transazione con i movimenti di cassa sull'account.
+func (repo *InvestmentRepository) AddTrade(ctx context.Context, user dbgen.User, account dbgen.Account, tradeDto dto.AddTradeDto, entries []dto.TradeCashEntry, description string) (dbgen.InvestmentTrade, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.InvestmentTrade{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	transactionID, err := queries.AddTransaction(ctx, dbgen.AddTransactionParams{
+		UserID:     user.ID,
+		OccurredAt: tradeDto.TradeDate,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.InvestmentTrade{}, err
+	}
+
+	var amount int64
+	for _, entry := range entries {
+		amount += entry.Amount
+		err = queries.AddTransactionEntry(ctx, dbgen.AddTransactionEntryParams{
+			TransactionID: transactionID,
+			AccountID:     account.ID,
+			CategoryID:    nullInt64(entry.CategoryID),
+			Amount:        entry.Amount,
+			Description: sql.NullString{
+				String: description,
+				Valid:  true,
+			},
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return dbgen.InvestmentTrade{}, err
+		}
+	}
+
+	trade, err := queries.CreateInvestmentTrade(ctx, dbgen.CreateInvestmentTradeParams{
+		AccountID:     account.ID,
+		SecurityID:    tradeDto.SecurityID,
+		TransactionID: transactionID,
+		Kind:          string(tradeDto.Kind),
+		TradeDate:     tradeDto.TradeDate,
+		Quantity:      tradeDto.Quantity,
+		Price:         tradeDto.Price,
+		Fees:          tradeDto.Fees,
+		Amount:        amount,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.InvestmentTrade{}, fmt.Errorf("create trade on account %d: %w", account.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.InvestmentTrade{}, err
+	}
+	return trade, nil
+}
+
+func (repo *InvestmentRepository) GetTrades(ctx context.Context, user dbgen.User) ([]dbgen.InvestmentTrade, error) {
+	trades, err := repo.queries.GetInvestmentTradesByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get investment trades by user: %w", err)
+	}
+	return trades, nil
+}
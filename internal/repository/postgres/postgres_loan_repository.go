@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type LoanRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewLoanRepository(db *sql.DB) *LoanRepository {
+	return &LoanRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+// CreateLoan crea l'account del prestito (saldo iniziale pari al capitale, in negativo),
+// la sua configurazione e l'intero piano di ammortamento in un'unica transazione.
+func (repo *LoanRepository) CreateLoan(ctx context.Context, user dbgen.User, createDto dto.CreateLoanDto, installments []dto.Installment) (dbgen.Loan, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.Loan{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	account, err := queries.CreateAccount(ctx, dbgen.CreateAccountParams{
+		UserID:         user.ID,
+		Name:           createDto.Name,
+		Currency:       createDto.Currency,
+		InitialBalance: -createDto.Principal,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Loan{}, fmt.Errorf("create loan account %q: %w", createDto.Name, err)
+	}
+
+	loan, err := queries.CreateLoan(ctx, dbgen.CreateLoanParams{
+		AccountID:        account.ID,
+		PaymentAccountID: createDto.PaymentAccountID,
+		Principal:        createDto.Principal,
+		AnnualRateBps:    createDto.AnnualRateBps,
+		TermMonths:       createDto.TermMonths,
+		StartDate:        createDto.StartDate,
+		Method:           string(createDto.Method),
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Loan{}, fmt.Errorf("create loan %q: %w", createDto.Name, err)
+	}
+
+	for _, installment := range installments {
+		err = queries.CreateLoanInstallment(ctx, dbgen.CreateLoanInstallmentParams{
+			AccountID: loan.AccountID,
+			Number:    installment.Number,
+			DueDate:   installment.DueDate,
+			Principal: installment.Principal,
+			Interest:  installment.Interest,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return dbgen.Loan{}, fmt.Errorf("create installment %d of loan %q: %w", installment.Number, createDto.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.Loan{}, err
+	}
+	return loan, nil
+}
+
+func (repo *LoanRepository) GetLoan(ctx context.Context, user dbgen.User, accountID int64) (dbgen.Loan, error) {
+	loan, err := repo.queries.GetLoan(ctx, dbgen.GetLoanParams{
+		AccountID: accountID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Loan{}, fmt.Errorf("%w: %d", apierr.ErrLoanNotFound, accountID)
+		}
+		return dbgen.Loan{}, fmt.Errorf("get loan %d: %w", accountID, err)
+	}
+	return loan, nil
+}
+
+func (repo *LoanRepository) GetLoans(ctx context.Context, user dbgen.User) ([]dbgen.Loan, error) {
+	loans, err := repo.queries.GetLoansByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get loans by user: %w", err)
+	}
+	return loans, nil
+}
+
+func (repo *LoanRepository) GetLoansWithDueInstallments(ctx context.Context, dueDate time.Time) ([]dbgen.GetLoansWithDueInstallmentsRow, error) {
+	loans, err := repo.queries.GetLoansWithDueInstallments(ctx, dueDate)
+	if err != nil {
+		return nil, fmt.Errorf("get loans with due installments: %w", err)
+	}
+	return loans, nil
+}
+
+func (repo *LoanRepository) GetInstallments(ctx context.Context, loan dbgen.Loan) ([]dbgen.LoanInstallment, error) {
+	installments, err := repo.queries.GetLoanInstallments(ctx, loan.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("get installments of loan %d: %w", loan.AccountID, err)
+	}
+	return installments, nil
+}
+
+// PostInstallment contabilizza la rata alla sua scadenza: gli interessi come spesa sul conto
+// di addebito, la quota capitale come trasferimento dal conto di addebito al prestito.
+func (repo *LoanRepository) PostInstallment(ctx context.Context, user dbgen.User, loan dbgen.Loan, installment dbgen.LoanInstallment, interestCategory dbgen.Category, description string) (int64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	transactionID, err := queries.AddTransaction(ctx, dbgen.AddTransactionParams{
+		UserID:     user.ID,
+		OccurredAt: installment.DueDate,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, err
+	}
+
+	entries := []dbgen.AddTransactionEntryParams{
+		{
+			AccountID: loan.PaymentAccountID,
+			Amount:    -installment.Principal,
+		},
+		{
+			AccountID: loan.AccountID,
+			Amount:    installment.Principal,
+		},
+	}
+	if installment.Interest > 0 {
+		entries = append(entries, dbgen.AddTransactionEntryParams{
+			AccountID: loan.PaymentAccountID,
+			CategoryID: sql.NullInt64{
+				Int64: interestCategory.ID,
+				Valid: true,
+			},
+			Amount: -installment.Interest,
+		})
+	}
+	for _, entry := range entries {
+		entry.TransactionID = transactionID
+		entry.Description = sql.NullString{
+			String: description,
+			Valid:  true,
+		}
+		if err := queries.AddTransactionEntry(ctx, entry); err != nil {
+			_ = tx.Rollback()
+			return 0, err
+		}
+	}
+
+	updated, err := queries.SetInstallmentTransaction(ctx, dbgen.SetInstallmentTransactionParams{
+		TransactionID: transactionID,
+		ID:            installment.ID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("post installment %d of loan %d: %w", installment.Number, loan.AccountID, err)
+	}
+	if updated == 0 {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("%w: installment %d is already posted", apierr.ErrConflict, installment.Number)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return transactionID, nil
+}
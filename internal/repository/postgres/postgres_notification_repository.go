@@ -0,0 +1,227 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type NotificationRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) *NotificationRepository {
+	return &NotificationRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+// GetSettings restituisce le impostazioni dell'utente con una preferenza per ogni tipo di
+// evento: quelli mai configurati arrivano solo nella inbox.
+func (repo *NotificationRepository) GetSettings(ctx context.Context, user dbgen.User) (dto.NotificationSettings, error) {
+	return notificationSettings(ctx, repo.queries, user)
+}
+
+// notificationSettings legge impostazioni e preferenze con queries, anche dentro una
+// transazione: le preferenze mai salvate hanno i valori predefiniti.
+func notificationSettings(ctx context.Context, queries *dbgen.Queries, user dbgen.User) (dto.NotificationSettings, error) {
+	settings := dto.NotificationSettings{UserID: user.ID}
+
+	row, err := queries.GetNotificationSettings(ctx, user.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return dto.NotificationSettings{}, fmt.Errorf("get notification settings of user %d: %w", user.ID, err)
+	}
+	if err == nil {
+		if row.WebhookUrl.Valid {
+			settings.WebhookURL = &row.WebhookUrl.String
+		}
+		settings.LowBalanceThreshold = row.LowBalanceThreshold
+	}
+
+	rows, err := queries.GetNotificationPreferences(ctx, user.ID)
+	if err != nil {
+		return dto.NotificationSettings{}, fmt.Errorf("get notification preferences of user %d: %w", user.ID, err)
+	}
+	saved := make(map[dto.NotificationKind]dbgen.NotificationPreference, len(rows))
+	for _, preference := range rows {
+		saved[dto.NotificationKind(preference.EventKind)] = preference
+	}
+	for _, kind := range dto.NotificationKinds {
+		preference := dto.NotificationPreference{Kind: kind, InApp: true}
+		if row, ok := saved[kind]; ok {
+			preference.Email = row.Email
+			preference.Webhook = row.Webhook
+			preference.InApp = row.InApp
+		}
+		settings.Preferences = append(settings.Preferences, preference)
+	}
+	return settings, nil
+}
+
+// SaveSettings salva impostazioni e preferenze in un'unica transazione.
+func (repo *NotificationRepository) SaveSettings(ctx context.Context, settings dto.NotificationSettings) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	_, err = queries.UpsertNotificationSettings(ctx, dbgen.UpsertNotificationSettingsParams{
+		UserID:              settings.UserID,
+		WebhookUrl:          nullString(settings.WebhookURL),
+		LowBalanceThreshold: settings.LowBalanceThreshold,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("save notification settings of user %d: %w", settings.UserID, err)
+	}
+
+	for _, preference := range settings.Preferences {
+		err = queries.UpsertNotificationPreference(ctx, dbgen.UpsertNotificationPreferenceParams{
+			UserID:    settings.UserID,
+			EventKind: string(preference.Kind),
+			Email:     preference.Email,
+			Webhook:   preference.Webhook,
+			InApp:     preference.InApp,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("save notification preference %s of user %d: %w", preference.Kind, settings.UserID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CreateNotification registra la notifica e i suoi invii, uno per canale con la relativa
+// destinazione. Restituisce false se lo stesso evento era già stato notificato.
+func (repo *NotificationRepository) CreateNotification(ctx context.Context, event dto.NotificationEvent, inApp bool, deliveries map[dto.NotificationChannel]string) (dbgen.Notification, bool, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.Notification{}, false, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	notification, err := queries.CreateNotification(ctx, dbgen.CreateNotificationParams{
+		UserID:   event.UserID,
+		Kind:     string(event.Kind),
+		Title:    event.Title,
+		Body:     event.Body,
+		DedupKey: event.DedupKey,
+		InApp:    inApp,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Notification{}, false, nil
+		}
+		return dbgen.Notification{}, false, fmt.Errorf("create notification %q for user %d: %w", event.DedupKey, event.UserID, err)
+	}
+
+	for channel, target := range deliveries {
+		err = queries.CreateNotificationDelivery(ctx, dbgen.CreateNotificationDeliveryParams{
+			NotificationID: notification.ID,
+			Channel:        string(channel),
+			Target:         target,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return dbgen.Notification{}, false, fmt.Errorf("create %s delivery of notification %d: %w", channel, notification.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.Notification{}, false, err
+	}
+	return notification, true, nil
+}
+
+func (repo *NotificationRepository) ClaimDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int32) ([]dbgen.ClaimNotificationDeliveriesRow, error) {
+	deliveries, err := repo.queries.ClaimNotificationDeliveries(ctx, dbgen.ClaimNotificationDeliveriesParams{
+		LeaseUntil:    leaseUntil,
+		Now:           now,
+		MaxDeliveries: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (repo *NotificationRepository) MarkDeliverySent(ctx context.Context, deliveryID int64) error {
+	if err := repo.queries.MarkDeliverySent(ctx, deliveryID); err != nil {
+		return fmt.Errorf("mark delivery %d sent: %w", deliveryID, err)
+	}
+	return nil
+}
+
+// MarkDeliveryFailed registra il tentativo fallito; senza nextAttempt l'invio viene abbandonato.
+func (repo *NotificationRepository) MarkDeliveryFailed(ctx context.Context, deliveryID int64, lastError string, nextAttempt *time.Time) error {
+	var next sql.NullTime
+	if nextAttempt != nil {
+		next = sql.NullTime{Time: *nextAttempt, Valid: true}
+	}
+	err := repo.queries.MarkDeliveryFailed(ctx, dbgen.MarkDeliveryFailedParams{
+		NextAttemptAt: next,
+		LastError:     sql.NullString{String: lastError, Valid: true},
+		ID:            deliveryID,
+	})
+	if err != nil {
+		return fmt.Errorf("mark delivery %d failed: %w", deliveryID, err)
+	}
+	return nil
+}
+
+func (repo *NotificationRepository) GetNotifications(ctx context.Context, user dbgen.User, unreadOnly bool) ([]dbgen.Notification, error) {
+	notifications, err := repo.queries.GetNotificationsByUser(ctx, dbgen.GetNotificationsByUserParams{
+		UserID:     user.ID,
+		UnreadOnly: unreadOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get notifications of user %d: %w", user.ID, err)
+	}
+	return notifications, nil
+}
+
+func (repo *NotificationRepository) MarkRead(ctx context.Context, user dbgen.User, notificationID int64) error {
+	updated, err := repo.queries.MarkNotificationRead(ctx, dbgen.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("mark notification %d read: %w", notificationID, err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrNotificationNotFound, notificationID)
+	}
+	return nil
+}
+
+func (repo *NotificationRepository) GetExceededBudgets(ctx context.Context, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetExceededBudgetsRow, error) {
+	budgets, err := repo.queries.GetExceededBudgets(ctx, dbgen.GetExceededBudgetsParams{
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get exceeded budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+func (repo *NotificationRepository) GetLowBalanceAccounts(ctx context.Context) ([]dbgen.GetLowBalanceAccountsRow, error) {
+	accounts, err := repo.queries.GetLowBalanceAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get low balance accounts: %w", err)
+	}
+	return accounts, nil
+}
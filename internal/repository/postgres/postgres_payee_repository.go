@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+)
+
+type PayeeRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewPayeeRepository(db *sql.DB) *PayeeRepository {
+	return &PayeeRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *PayeeRepository) GetPayees(ctx context.Context, user dbgen.User) ([]dbgen.Payee, error) {
+	payees, err := repo.queries.GetPayeesByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get payees by user: %w", err)
+	}
+	return payees, nil
+}
+
+func (repo *PayeeRepository) GetPayeeByID(ctx context.Context, user dbgen.User, payeeID int64) (dbgen.Payee, error) {
+	payee, err := repo.queries.GetPayeeByID(ctx, dbgen.GetPayeeByIDParams{
+		ID:     payeeID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Payee{}, fmt.Errorf("%w: %d", apierr.ErrPayeeNotFound, payeeID)
+		}
+		return dbgen.Payee{}, fmt.Errorf("get payee %d: %w", payeeID, err)
+	}
+	return payee, nil
+}
+
+func (repo *PayeeRepository) GetPayeeByName(ctx context.Context, user dbgen.User, name string) (dbgen.Payee, error) {
+	payee, err := repo.queries.GetPayeeByName(ctx, dbgen.GetPayeeByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Payee{}, fmt.Errorf("%w: %s", apierr.ErrPayeeNotFound, name)
+		}
+		return dbgen.Payee{}, fmt.Errorf("get payee %q: %w", name, err)
+	}
+	return payee, nil
+}
+
+func (repo *PayeeRepository) CreatePayee(ctx context.Context, user dbgen.User, name string, defaultCategoryID *int64) (dbgen.Payee, error) {
+	payee, err := repo.queries.CreatePayee(ctx, dbgen.CreatePayeeParams{
+		UserID:            user.ID,
+		Name:              name,
+		DefaultCategoryID: nullInt64(defaultCategoryID),
+	})
+	if err != nil {
+		return dbgen.Payee{}, fmt.Errorf("create payee %q: %w", name, err)
+	}
+	return payee, nil
+}
+
+func (repo *PayeeRepository) UpdatePayee(ctx context.Context, user dbgen.User, payeeID int64, name string, defaultCategoryID *int64) (dbgen.Payee, error) {
+	payee, err := repo.queries.UpdatePayee(ctx, dbgen.UpdatePayeeParams{
+		ID:                payeeID,
+		UserID:            user.ID,
+		Name:              name,
+		DefaultCategoryID: nullInt64(defaultCategoryID),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Payee{}, fmt.Errorf("%w: %d", apierr.ErrPayeeNotFound, payeeID)
+		}
+		return dbgen.Payee{}, fmt.Errorf("update payee %d: %w", payeeID, err)
+	}
+	return payee, nil
+}
+
+func (repo *PayeeRepository) DeletePayee(ctx context.Context, user dbgen.User, payeeID int64) error {
+	err := repo.queries.DeletePayee(ctx, dbgen.DeletePayeeParams{
+		ID:     payeeID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("delete payee %d: %w", payeeID, err)
+	}
+	return nil
+}
+
+func (repo *PayeeRepository) GetPayeeAliases(ctx context.Context, user dbgen.User) ([]dbgen.PayeeAlias, error) {
+	aliases, err := repo.queries.GetPayeeAliasesByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get payee aliases by user: %w", err)
+	}
+	return aliases, nil
+}
+
+func (repo *PayeeRepository) AddPayeeAlias(ctx context.Context, payee dbgen.Payee, pattern string) (dbgen.PayeeAlias, error) {
+	alias, err := repo.queries.AddPayeeAlias(ctx, dbgen.AddPayeeAliasParams{
+		PayeeID: payee.ID,
+		Pattern: pattern,
+	})
+	if err != nil {
+		return dbgen.PayeeAlias{}, fmt.Errorf("add alias %q to payee %d: %w", pattern, payee.ID, err)
+	}
+	return alias, nil
+}
+
+func (repo *PayeeRepository) DeletePayeeAlias(ctx context.Context, payee dbgen.Payee, aliasID int64) error {
+	deleted, err := repo.queries.DeletePayeeAlias(ctx, dbgen.DeletePayeeAliasParams{
+		ID:      aliasID,
+		PayeeID: payee.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("delete alias %d of payee %d: %w", aliasID, payee.ID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: alias %d", apierr.ErrNotFound, aliasID)
+	}
+	return nil
+}
+
+// DetectPayee restituisce il beneficiario il cui nome o alias compare nella descrizione.
+func (repo *PayeeRepository) DetectPayee(ctx context.Context, user dbgen.User, description string) (dbgen.Payee, error) {
+	payee, err := repo.queries.DetectPayee(ctx, dbgen.DetectPayeeParams{
+		UserID:      user.ID,
+		Description: description,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Payee{}, fmt.Errorf("%w: no match for %q", apierr.ErrPayeeNotFound, description)
+		}
+		return dbgen.Payee{}, fmt.Errorf("detect payee from %q: %w", description, err)
+	}
+	return payee, nil
+}
+
+func (repo *PayeeRepository) GetPayeeTotals(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetPayeeTotalsByUserRow, error) {
+	totals, err := repo.queries.GetPayeeTotalsByUser(ctx, dbgen.GetPayeeTotalsByUserParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get payee totals: %w", err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,183 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type ReconciliationRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewReconciliationRepository(db *sql.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *ReconciliationRepository) CreateReconciliation(ctx context.Context, account dbgen.Account, statementDate time.Time, statementBalance int64) (dbgen.Reconciliation, error) {
+	reconciliation, err := repo.queries.CreateReconciliation(ctx, dbgen.CreateReconciliationParams{
+		AccountID:        account.ID,
+		StatementDate:    statementDate,
+		StatementBalance: statementBalance,
+	})
+	if err != nil {
+		return dbgen.Reconciliation{}, fmt.Errorf("create reconciliation for account %d: %w", account.ID, err)
+	}
+	return reconciliation, nil
+}
+
+func (repo *ReconciliationRepository) GetReconciliationByID(ctx context.Context, user dbgen.User, reconciliationID int64) (dbgen.Reconciliation, error) {
+	reconciliation, err := repo.queries.GetReconciliationByID(ctx, dbgen.GetReconciliationByIDParams{
+		ID:     reconciliationID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Reconciliation{}, fmt.Errorf("%w: %d", apierr.ErrReconciliationNotFound, reconciliationID)
+		}
+		return dbgen.Reconciliation{}, fmt.Errorf("get reconciliation %d: %w", reconciliationID, err)
+	}
+	return reconciliation, nil
+}
+
+func (repo *ReconciliationRepository) GetReconciliations(ctx context.Context, account dbgen.Account) ([]dbgen.Reconciliation, error) {
+	reconciliations, err := repo.queries.GetReconciliationsByAccount(ctx, account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get reconciliations of account %d: %w", account.ID, err)
+	}
+	return reconciliations, nil
+}
+
+func (repo *ReconciliationRepository) GetReconciliationEntries(ctx context.Context, account dbgen.Account, statementDate time.Time) ([]dbgen.GetReconciliationEntriesRow, error) {
+	entries, err := repo.queries.GetReconciliationEntries(ctx, dbgen.GetReconciliationEntriesParams{
+		AccountID:     account.ID,
+		StatementDate: statementDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get reconciliation entries of account %d: %w", account.ID, err)
+	}
+	return entries, nil
+}
+
+// GetClearedBalance restituisce la somma dei movimenti spuntati o riconciliati fino alla data indicata,
+// escluso il saldo iniziale dell'account.
+func (repo *ReconciliationRepository) GetClearedBalance(ctx context.Context, account dbgen.Account, statementDate time.Time) (int64, error) {
+	balance, err := repo.queries.GetClearedBalance(ctx, dbgen.GetClearedBalanceParams{
+		AccountID:     account.ID,
+		StatementDate: statementDate,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get cleared balance of account %d: %w", account.ID, err)
+	}
+	return balance, nil
+}
+
+// SetEntriesStatus aggiorna lo stato dei movimenti indicati; fallisce senza modificare nulla
+// se uno di essi non appartiene all'account o è già riconciliato.
+func (repo *ReconciliationRepository) SetEntriesStatus(ctx context.Context, account dbgen.Account, entryIDs []int64, status dto.EntryStatus) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	for _, entryID := range entryIDs {
+		updated, err := queries.SetEntryStatus(ctx, dbgen.SetEntryStatusParams{
+			Status:    string(status),
+			ID:        entryID,
+			AccountID: account.ID,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("set status of entry %d: %w", entryID, err)
+		}
+		if updated == 0 {
+			_ = tx.Rollback()
+			return fmt.Errorf("%w: entry %d is not an open entry of account %d", apierr.ErrInvalidData, entryID, account.ID)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CompleteReconciliation segna come riconciliati i movimenti spuntati e chiude la riconciliazione.
+// La riconciliazione resta bloccata fino al commit e balanced riceve il saldo dei movimenti
+// riconciliati ricalcolato dopo l'aggiornamento: se restituisce un errore non cambia nulla.
+func (repo *ReconciliationRepository) CompleteReconciliation(ctx context.Context, reconciliation dbgen.Reconciliation, balanced func(reconciled int64) error) (dbgen.Reconciliation, int64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.Reconciliation{}, 0, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	locked, err := queries.GetReconciliationForUpdate(ctx, reconciliation.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Reconciliation{}, 0, fmt.Errorf("%w: %d", apierr.ErrReconciliationNotFound, reconciliation.ID)
+		}
+		return dbgen.Reconciliation{}, 0, fmt.Errorf("lock reconciliation %d: %w", reconciliation.ID, err)
+	}
+	if locked.Status != dto.ReconciliationInProgress {
+		_ = tx.Rollback()
+		return dbgen.Reconciliation{}, 0, fmt.Errorf("%w: reconciliation %d is already completed", apierr.ErrConflict, reconciliation.ID)
+	}
+
+	reconciled, err := queries.ReconcileClearedEntries(ctx, dbgen.ReconcileClearedEntriesParams{
+		ReconciliationID: locked.ID,
+		AccountID:        locked.AccountID,
+		StatementDate:    locked.StatementDate,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Reconciliation{}, 0, fmt.Errorf("reconcile entries of account %d: %w", locked.AccountID, err)
+	}
+
+	balance, err := queries.GetReconciledBalance(ctx, dbgen.GetReconciledBalanceParams{
+		AccountID:     locked.AccountID,
+		StatementDate: locked.StatementDate,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Reconciliation{}, 0, fmt.Errorf("get reconciled balance of account %d: %w", locked.AccountID, err)
+	}
+	if err := balanced(balance); err != nil {
+		_ = tx.Rollback()
+		return dbgen.Reconciliation{}, 0, err
+	}
+
+	completed, err := queries.CompleteReconciliation(ctx, locked.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Reconciliation{}, 0, fmt.Errorf("complete reconciliation %d: %w", locked.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.Reconciliation{}, 0, err
+	}
+
+	return completed, reconciled, nil
+}
+
+func (repo *ReconciliationRepository) DeleteReconciliation(ctx context.Context, reconciliation dbgen.Reconciliation) error {
+	deleted, err := repo.queries.DeleteReconciliation(ctx, reconciliation.ID)
+	if err != nil {
+		return fmt.Errorf("delete reconciliation %d: %w", reconciliation.ID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: reconciliation %d is already completed", apierr.ErrConflict, reconciliation.ID)
+	}
+	return nil
+}
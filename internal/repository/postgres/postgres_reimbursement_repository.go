@@ -0,0 +1,169 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+)
+
+type ReimbursementRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewReimbursementRepository(db *sql.DB) *ReimbursementRepository {
+	return &ReimbursementRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *ReimbursementRepository) GetEntry(ctx context.Context, user dbgen.User, entryID int64) (dbgen.TransactionEntry, error) {
+	entry, err := repo.queries.GetTransactionEntry(ctx, dbgen.GetTransactionEntryParams{
+		ID:     entryID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.TransactionEntry{}, fmt.Errorf("%w: entry %d", apierr.ErrTransactionNotFound, entryID)
+		}
+		return dbgen.TransactionEntry{}, fmt.Errorf("get entry %d: %w", entryID, err)
+	}
+	return entry, nil
+}
+
+// MarkReimbursable segna la spesa come rimborsabile, o ne cambia la controparte se lo è già.
+func (repo *ReimbursementRepository) MarkReimbursable(ctx context.Context, user dbgen.User, entry dbgen.TransactionEntry, party string) (dbgen.Reimbursable, error) {
+	reimbursable, err := repo.queries.MarkReimbursable(ctx, dbgen.MarkReimbursableParams{
+		EntryID: entry.ID,
+		UserID:  user.ID,
+		Party:   party,
+	})
+	if err != nil {
+		return dbgen.Reimbursable{}, fmt.Errorf("mark entry %d as reimbursable: %w", entry.ID, err)
+	}
+	return reimbursable, nil
+}
+
+// UnmarkReimbursable toglie il segno di rimborsabile e scollega gli eventuali rimborsi.
+func (repo *ReimbursementRepository) UnmarkReimbursable(ctx context.Context, entry dbgen.TransactionEntry) error {
+	deleted, err := repo.queries.DeleteReimbursable(ctx, entry.ID)
+	if err != nil {
+		return fmt.Errorf("unmark entry %d as reimbursable: %w", entry.ID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: entry %d is not reimbursable", apierr.ErrReimbursementNotFound, entry.ID)
+	}
+	return nil
+}
+
+func (repo *ReimbursementRepository) GetReimbursables(ctx context.Context, user dbgen.User, entryID *int64, party *string, includeSettled bool) ([]dbgen.GetReimbursableEntriesRow, error) {
+	entries, err := repo.queries.GetReimbursableEntries(ctx, dbgen.GetReimbursableEntriesParams{
+		UserID:         user.ID,
+		EntryID:        nullInt64(entryID),
+		Party:          nullString(party),
+		IncludeSettled: includeSettled,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get reimbursable entries of user %d: %w", user.ID, err)
+	}
+	return entries, nil
+}
+
+func (repo *ReimbursementRepository) GetReimbursements(ctx context.Context, user dbgen.User) ([]dbgen.Reimbursement, error) {
+	reimbursements, err := repo.queries.GetReimbursementsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get reimbursements of user %d: %w", user.ID, err)
+	}
+	return reimbursements, nil
+}
+
+// AddReimbursement collega l'entrata alla spesa. La spesa rimborsabile resta bloccata fino al
+// commit, così che rimborsi concorrenti non superino l'importo da rimborsare; senza amount
+// viene collegato il massimo possibile.
+func (repo *ReimbursementRepository) AddReimbursement(ctx context.Context, expense dbgen.TransactionEntry, income dbgen.TransactionEntry, amount *int64) (dbgen.Reimbursement, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.Reimbursement{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	_, err = queries.GetReimbursableForUpdate(ctx, expense.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Reimbursement{}, fmt.Errorf("%w: entry %d is not reimbursable", apierr.ErrInvalidData, expense.ID)
+		}
+		return dbgen.Reimbursement{}, fmt.Errorf("lock reimbursable entry %d: %w", expense.ID, err)
+	}
+
+	reimbursed, err := queries.GetReimbursedAmount(ctx, expense.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Reimbursement{}, err
+	}
+	allocated, err := queries.GetAllocatedIncome(ctx, income.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Reimbursement{}, err
+	}
+
+	available := min(-expense.Amount-reimbursed, income.Amount-allocated)
+	linked := available
+	if amount != nil {
+		linked = *amount
+	}
+	if available <= 0 {
+		_ = tx.Rollback()
+		return dbgen.Reimbursement{}, fmt.Errorf("%w: nothing left to reimburse between entries %d and %d", apierr.ErrConflict, expense.ID, income.ID)
+	}
+	if linked <= 0 || linked > available {
+		_ = tx.Rollback()
+		return dbgen.Reimbursement{}, fmt.Errorf("%w: amount must be between 1 and %d", apierr.ErrInvalidData, available)
+	}
+
+	reimbursement, err := queries.AddReimbursement(ctx, dbgen.AddReimbursementParams{
+		ExpenseEntryID: expense.ID,
+		IncomeEntryID:  income.ID,
+		Amount:         linked,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.Reimbursement{}, fmt.Errorf("link entry %d to entry %d: %w", income.ID, expense.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.Reimbursement{}, err
+	}
+	return reimbursement, nil
+}
+
+func (repo *ReimbursementRepository) GetReimbursement(ctx context.Context, user dbgen.User, reimbursementID int64) (dbgen.Reimbursement, error) {
+	reimbursement, err := repo.queries.GetReimbursement(ctx, dbgen.GetReimbursementParams{
+		ID:     reimbursementID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Reimbursement{}, fmt.Errorf("%w: %d", apierr.ErrReimbursementNotFound, reimbursementID)
+		}
+		return dbgen.Reimbursement{}, fmt.Errorf("get reimbursement %d: %w", reimbursementID, err)
+	}
+	return reimbursement, nil
+}
+
+func (repo *ReimbursementRepository) DeleteReimbursement(ctx context.Context, reimbursement dbgen.Reimbursement) error {
+	deleted, err := repo.queries.DeleteReimbursement(ctx, reimbursement.ID)
+	if err != nil {
+		return fmt.Errorf("delete reimbursement %d: %w", reimbursement.ID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrReimbursementNotFound, reimbursement.ID)
+	}
+	return nil
+}
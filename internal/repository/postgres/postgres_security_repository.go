@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type SecurityRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewSecurityRepository(db *sql.DB) *SecurityRepository {
+	return &SecurityRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *SecurityRepository) CreateSecurity(ctx context.Context, user dbgen.User, createDto dto.CreateSecurityDto) (dbgen.Security, error) {
+	security, err := repo.queries.CreateSecurity(ctx, dbgen.CreateSecurityParams{
+		UserID:   user.ID,
+		Isin:     nullString(createDto.Isin),
+		Ticker:   nullString(createDto.Ticker),
+		Name:     createDto.Name,
+		Currency: createDto.Currency,
+	})
+	if err != nil {
+		return dbgen.Security{}, fmt.Errorf("create security %q: %w", createDto.Name, err)
+	}
+	return security, nil
+}
+
+func (repo *SecurityRepository) GetSecurities(ctx context.Context, user dbgen.User) ([]dbgen.Security, error) {
+	securities, err := repo.queries.GetSecuritiesByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get securities by user: %w", err)
+	}
+	return securities, nil
+}
+
+func (repo *SecurityRepository) GetSecurityByID(ctx context.Context, user dbgen.User, securityID int64) (dbgen.Security, error) {
+	security, err := repo.queries.GetSecurityByID(ctx, dbgen.GetSecurityByIDParams{
+		ID:     securityID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Security{}, fmt.Errorf("%w: %d", apierr.ErrSecurityNotFound, securityID)
+		}
+		return dbgen.Security{}, fmt.Errorf("get security %d: %w", securityID, err)
+	}
+	return security, nil
+}
+
+func (repo *SecurityRepository) GetSecurityByIdentifier(ctx context.Context, user dbgen.User, identifier string) (dbgen.Security, error) {
+	security, err := repo.queries.GetSecurityByIdentifier(ctx, dbgen.GetSecurityByIdentifierParams{
+		UserID:     user.ID,
+		Identifier: identifier,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Security{}, fmt.Errorf("%w: %s", apierr.ErrSecurityNotFound, identifier)
+		}
+		return dbgen.Security{}, fmt.Errorf("get security %q: %w", identifier, err)
+	}
+	return security, nil
+}
+
+// SavePrices inserisce (o aggiorna) i prezzi in un'unica transazione.
+func (repo *SecurityRepository) SavePrices(ctx context.Context, prices []dto.SecurityPriceDto) error {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	queries := repo.queries.WithTx(tx)
+	for _, price := range prices {
+		err := queries.UpsertSecurityPrice(ctx, dbgen.UpsertSecurityPriceParams{
+			SecurityID: price.SecurityID,
+			PriceDate:  price.PriceDate,
+			Price:      price.Price,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("save price of security %d at %s: %w", price.SecurityID, price.PriceDate.Format("2006-01-02"), err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (repo *SecurityRepository) GetPrices(ctx context.Context, security dbgen.Security, dateFrom time.Time, dateTo time.Time) ([]dbgen.SecurityPrice, error) {
+	prices, err := repo.queries.GetSecurityPrices(ctx, dbgen.GetSecurityPricesParams{
+		SecurityID: security.ID,
+		DateFrom:   dateFrom,
+		DateTo:     dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get prices of security %d: %w", security.ID, err)
+	}
+	return prices, nil
+}
+
+func (repo *SecurityRepository) GetLatestPrices(ctx context.Context, user dbgen.User, asOf time.Time) ([]dbgen.SecurityPrice, error) {
+	prices, err := repo.queries.GetLatestSecurityPrices(ctx, dbgen.GetLatestSecurityPricesParams{
+		UserID: user.ID,
+		AsOf:   asOf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get latest security prices: %w", err)
+	}
+	return prices, nil
+}
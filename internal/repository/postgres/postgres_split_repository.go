@@ -0,0 +1,356 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+type SplitRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewSplitRepository(db *sql.DB) *SplitRepository {
+	return &SplitRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+// CreateGroup crea il gruppo e ne registra il creatore come primo partecipante.
+func (repo *SplitRepository) CreateGroup(ctx context.Context, user dbgen.User, createDto dto.CreateSplitGroupDto, memberName string) (dbgen.SplitGroup, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.SplitGroup{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	group, err := queries.CreateSplitGroup(ctx, dbgen.CreateSplitGroupParams{
+		Name:      createDto.Name,
+		Currency:  createDto.Currency,
+		CreatedBy: user.ID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.SplitGroup{}, fmt.Errorf("create split group %q: %w", createDto.Name, err)
+	}
+
+	_, err = queries.AddSplitMember(ctx, dbgen.AddSplitMemberParams{
+		GroupID: group.ID,
+		UserID:  sql.NullInt64{Int64: user.ID, Valid: true},
+		Name:    memberName,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.SplitGroup{}, fmt.Errorf("add creator to split group %d: %w", group.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.SplitGroup{}, err
+	}
+	return group, nil
+}
+
+func (repo *SplitRepository) GetGroups(ctx context.Context, user dbgen.User) ([]dbgen.SplitGroup, error) {
+	groups, err := repo.queries.GetSplitGroupsByUser(ctx, sql.NullInt64{Int64: user.ID, Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("get split groups of user %d: %w", user.ID, err)
+	}
+	return groups, nil
+}
+
+func (repo *SplitRepository) GetGroup(ctx context.Context, groupID int64) (dbgen.SplitGroup, error) {
+	group, err := repo.queries.GetSplitGroupByID(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.SplitGroup{}, fmt.Errorf("%w: %d", apierr.ErrSplitGroupNotFound, groupID)
+		}
+		return dbgen.SplitGroup{}, fmt.Errorf("get split group %d: %w", groupID, err)
+	}
+	return group, nil
+}
+
+func (repo *SplitRepository) GetMembers(ctx context.Context, group dbgen.SplitGroup) ([]dbgen.SplitMember, error) {
+	members, err := repo.queries.GetSplitMembers(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get members of split group %d: %w", group.ID, err)
+	}
+	return members, nil
+}
+
+// GetMemberByUser restituisce ErrSplitGroupNotFound se l'utente non partecipa al gruppo.
+func (repo *SplitRepository) GetMemberByUser(ctx context.Context, group dbgen.SplitGroup, userID int64) (dbgen.SplitMember, error) {
+	member, err := repo.queries.GetSplitMemberByUser(ctx, dbgen.GetSplitMemberByUserParams{
+		GroupID: group.ID,
+		UserID:  sql.NullInt64{Int64: userID, Valid: true},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.SplitMember{}, fmt.Errorf("%w: %d", apierr.ErrSplitGroupNotFound, group.ID)
+		}
+		return dbgen.SplitMember{}, fmt.Errorf("get member %d of split group %d: %w", userID, group.ID, err)
+	}
+	return member, nil
+}
+
+func (repo *SplitRepository) AddMember(ctx context.Context, group dbgen.SplitGroup, userID *int64, name string) (dbgen.SplitMember, error) {
+	member, err := repo.queries.AddSplitMember(ctx, dbgen.AddSplitMemberParams{
+		GroupID: group.ID,
+		UserID:  nullInt64(userID),
+		Name:    name,
+	})
+	if err != nil {
+		return dbgen.SplitMember{}, fmt.Errorf("add member %q to split group %d: %w", name, group.ID, err)
+	}
+	return member, nil
+}
+
+func (repo *SplitRepository) GetTransactionExpense(ctx context.Context, transaction dbgen.Transaction) (dbgen.GetTransactionExpenseRow, error) {
+	expense, err := repo.queries.GetTransactionExpense(ctx, transaction.ID)
+	if err != nil {
+		return dbgen.GetTransactionExpenseRow{}, fmt.Errorf("get expense of transaction %d: %w", transaction.ID, err)
+	}
+	return expense, nil
+}
+
+// CreateExpense registra la spesa divisa e le quote dei membri in un'unica transazione.
+func (repo *SplitRepository) CreateExpense(ctx context.Context, group dbgen.SplitGroup, transaction dbgen.Transaction, payer dbgen.SplitMember, amount int64, method dto.SplitMethod, shares []dto.SplitShareAmount) (dbgen.SplitExpense, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.SplitExpense{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	expense, err := queries.CreateSplitExpense(ctx, dbgen.CreateSplitExpenseParams{
+		GroupID:       group.ID,
+		TransactionID: transaction.ID,
+		PaidBy:        payer.ID,
+		Amount:        amount,
+		Method:        string(method),
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.SplitExpense{}, fmt.Errorf("%w: transaction %d is already split", apierr.ErrConflict, transaction.ID)
+		}
+		return dbgen.SplitExpense{}, fmt.Errorf("split transaction %d: %w", transaction.ID, err)
+	}
+
+	for _, share := range shares {
+		var weight sql.NullInt32
+		if share.Shares != nil {
+			weight = sql.NullInt32{Int32: *share.Shares, Valid: true}
+		}
+		err := queries.AddSplitShare(ctx, dbgen.AddSplitShareParams{
+			ExpenseID: expense.ID,
+			MemberID:  share.MemberID,
+			Shares:    weight,
+			Amount:    share.Amount,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return dbgen.SplitExpense{}, fmt.Errorf("add share of member %d: %w", share.MemberID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.SplitExpense{}, err
+	}
+	return expense, nil
+}
+
+func (repo *SplitRepository) GetExpense(ctx context.Context, group dbgen.SplitGroup, expenseID int64) (dbgen.SplitExpense, error) {
+	expense, err := repo.queries.GetSplitExpense(ctx, dbgen.GetSplitExpenseParams{
+		ID:      expenseID,
+		GroupID: group.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.SplitExpense{}, fmt.Errorf("%w: %d", apierr.ErrSplitExpenseNotFound, expenseID)
+		}
+		return dbgen.SplitExpense{}, fmt.Errorf("get split expense %d: %w", expenseID, err)
+	}
+	return expense, nil
+}
+
+func (repo *SplitRepository) GetExpenses(ctx context.Context, group dbgen.SplitGroup) ([]dbgen.GetSplitExpensesRow, error) {
+	expenses, err := repo.queries.GetSplitExpenses(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get expenses of split group %d: %w", group.ID, err)
+	}
+	return expenses, nil
+}
+
+func (repo *SplitRepository) GetShares(ctx context.Context, group dbgen.SplitGroup) ([]dbgen.SplitShare, error) {
+	shares, err := repo.queries.GetSplitSharesByGroup(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get shares of split group %d: %w", group.ID, err)
+	}
+	return shares, nil
+}
+
+// DeleteExpense elimina la divisione della spesa; la transazione originale resta invariata.
+func (repo *SplitRepository) DeleteExpense(ctx context.Context, group dbgen.SplitGroup, expense dbgen.SplitExpense) error {
+	deleted, err := repo.queries.DeleteSplitExpense(ctx, dbgen.DeleteSplitExpenseParams{
+		ID:      expense.ID,
+		GroupID: group.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("delete split expense %d: %w", expense.ID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrSplitExpenseNotFound, expense.ID)
+	}
+	return nil
+}
+
+// RecordSettlement registra il saldo tra due partecipanti insieme ai movimenti negli account
+// degli utenti koin coinvolti (nil per i partecipanti senza account), in un'unica transazione.
+func (repo *SplitRepository) RecordSettlement(ctx context.Context, group dbgen.SplitGroup, settlementDto dto.SettlementDto, fromEntry *dto.SettlementEntry, toEntry *dto.SettlementEntry) (dbgen.SplitSettlement, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	var transactionIDs [2]sql.NullInt64
+	for i, entry := range []*dto.SettlementEntry{fromEntry, toEntry} {
+		if entry == nil {
+			continue
+		}
+		transactionIDs[i], err = addSettlementTransaction(ctx, queries, settlementDto.SettledAt, *entry)
+		if err != nil {
+			_ = tx.Rollback()
+			return dbgen.SplitSettlement{}, err
+		}
+	}
+
+	settlement, err := queries.CreateSplitSettlement(ctx, dbgen.CreateSplitSettlementParams{
+		GroupID:           group.ID,
+		FromMemberID:      settlementDto.FromMemberID,
+		ToMemberID:        settlementDto.ToMemberID,
+		Amount:            settlementDto.Amount,
+		SettledAt:         settlementDto.SettledAt,
+		FromTransactionID: transactionIDs[0],
+		ToTransactionID:   transactionIDs[1],
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.SplitSettlement{}, fmt.Errorf("record settlement in split group %d: %w", group.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+	return settlement, nil
+}
+
+// ConfirmSettlement registra il movimento di chi riceve o paga un pagamento registrato da
+// un altro membro: entry.Amount positivo indica chi riceve. Il pagamento resta bloccato fino al
+// commit, così due conferme concorrenti non creano due movimenti.
+func (repo *SplitRepository) ConfirmSettlement(ctx context.Context, settlement dbgen.SplitSettlement, entry dto.SettlementEntry) (dbgen.SplitSettlement, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	locked, err := queries.GetSplitSettlementForUpdate(ctx, settlement.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.SplitSettlement{}, fmt.Errorf("%w: %d", apierr.ErrSettlementNotFound, settlement.ID)
+		}
+		return dbgen.SplitSettlement{}, fmt.Errorf("lock settlement %d: %w", settlement.ID, err)
+	}
+	current := locked.FromTransactionID
+	if entry.Amount > 0 {
+		current = locked.ToTransactionID
+	}
+	if current.Valid {
+		_ = tx.Rollback()
+		return dbgen.SplitSettlement{}, fmt.Errorf("%w: settlement %d is already confirmed", apierr.ErrConflict, settlement.ID)
+	}
+
+	transactionID, err := addSettlementTransaction(ctx, queries, locked.SettledAt, entry)
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.SplitSettlement{}, err
+	}
+	params := dbgen.SetSplitSettlementTransactionsParams{ID: locked.ID, FromTransactionID: transactionID}
+	if entry.Amount > 0 {
+		params = dbgen.SetSplitSettlementTransactionsParams{ID: locked.ID, ToTransactionID: transactionID}
+	}
+	confirmed, err := queries.SetSplitSettlementTransactions(ctx, params)
+	if err != nil {
+		_ = tx.Rollback()
+		return dbgen.SplitSettlement{}, fmt.Errorf("confirm settlement %d: %w", settlement.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+	return confirmed, nil
+}
+
+// addSettlementTransaction crea la transazione con il movimento del pagamento nell'account
+// dell'utente.
+func addSettlementTransaction(ctx context.Context, queries *dbgen.Queries, settledAt time.Time, entry dto.SettlementEntry) (sql.NullInt64, error) {
+	transactionID, err := queries.AddTransaction(ctx, dbgen.AddTransactionParams{
+		UserID:     entry.User.ID,
+		OccurredAt: settledAt,
+	})
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	err = queries.AddTransactionEntry(ctx, dbgen.AddTransactionEntryParams{
+		TransactionID: transactionID,
+		AccountID:     entry.Account.ID,
+		CategoryID: sql.NullInt64{
+			Int64: entry.Category.ID,
+			Valid: true,
+		},
+		Amount: entry.Amount,
+		Description: sql.NullString{
+			String: entry.Description,
+			Valid:  true,
+		},
+	})
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: transactionID, Valid: true}, nil
+}
+
+func (repo *SplitRepository) GetSettlement(ctx context.Context, group dbgen.SplitGroup, settlementID int64) (dbgen.SplitSettlement, error) {
+	settlement, err := repo.queries.GetSplitSettlement(ctx, dbgen.GetSplitSettlementParams{
+		ID:      settlementID,
+		GroupID: group.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.SplitSettlement{}, fmt.Errorf("%w: %d", apierr.ErrSettlementNotFound, settlementID)
+		}
+		return dbgen.SplitSettlement{}, fmt.Errorf("get settlement %d: %w", settlementID, err)
+	}
+	return settlement, nil
+}
+
+func (repo *SplitRepository) GetSettlements(ctx context.Context, group dbgen.SplitGroup) ([]dbgen.SplitSettlement, error) {
+	settlements, err := repo.queries.GetSplitSettlements(ctx, group.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get settlements of split group %d: %w", group.ID, err)
+	}
+	return settlements, nil
+}
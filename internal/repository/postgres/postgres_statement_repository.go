@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+type StatementRepository struct {
+	queries *dbgen.Queries
+}
+
+func NewStatementRepository(db *sql.DB) *StatementRepository {
+	return &StatementRepository{
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *StatementRepository) GetAccountSummaries(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetAccountBalanceChangesRow, error) {
+	accounts, err := repo.queries.GetAccountBalanceChanges(ctx, dbgen.GetAccountBalanceChangesParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get account summaries of user %d: %w", user.ID, err)
+	}
+	return accounts, nil
+}
+
+func (repo *StatementRepository) GetEntries(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetStatementEntriesRow, error) {
+	entries, err := repo.queries.GetStatementEntries(ctx, dbgen.GetStatementEntriesParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get statement entries of user %d: %w", user.ID, err)
+	}
+	return entries, nil
+}
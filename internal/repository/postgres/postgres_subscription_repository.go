@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+)
+
+type SubscriptionRepository struct {
+	queries *dbgen.Queries
+}
+
+func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *SubscriptionRepository) GetCandidates(ctx context.Context, user dbgen.User, dateFrom time.Time) ([]dbgen.GetSubscriptionCandidatesRow, error) {
+	candidates, err := repo.queries.GetSubscriptionCandidates(ctx, dbgen.GetSubscriptionCandidatesParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get subscription candidates of user %d: %w", user.ID, err)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+)
+
+type TagRepository struct {
+	queries *dbgen.Queries
+	db      *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{
+		db:      db,
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *TagRepository) GetTags(ctx context.Context, user dbgen.User) ([]dbgen.Tag, error) {
+	tags, err := repo.queries.GetTagsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get tags by user: %w", err)
+	}
+	return tags, nil
+}
+
+func (repo *TagRepository) GetTagByID(ctx context.Context, user dbgen.User, tagID int64) (dbgen.Tag, error) {
+	tag, err := repo.queries.GetTagByID(ctx, dbgen.GetTagByIDParams{
+		ID:     tagID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Tag{}, fmt.Errorf("%w: %d", apierr.ErrTagNotFound, tagID)
+		}
+		return dbgen.Tag{}, fmt.Errorf("get tag %d: %w", tagID, err)
+	}
+	return tag, nil
+}
+
+func (repo *TagRepository) GetTagByName(ctx context.Context, user dbgen.User, name string) (dbgen.Tag, error) {
+	tag, err := repo.queries.GetTagByName(ctx, dbgen.GetTagByNameParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Tag{}, fmt.Errorf("%w: %s", apierr.ErrTagNotFound, name)
+		}
+		return dbgen.Tag{}, fmt.Errorf("get tag %q: %w", name, err)
+	}
+	return tag, nil
+}
+
+func (repo *TagRepository) CreateTag(ctx context.Context, user dbgen.User, name string) (dbgen.Tag, error) {
+	tag, err := repo.queries.CreateTag(ctx, dbgen.CreateTagParams{
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return dbgen.Tag{}, fmt.Errorf("create tag %q: %w", name, err)
+	}
+	return tag, nil
+}
+
+func (repo *TagRepository) RenameTag(ctx context.Context, user dbgen.User, tagID int64, name string) (dbgen.Tag, error) {
+	tag, err := repo.queries.RenameTag(ctx, dbgen.RenameTagParams{
+		ID:     tagID,
+		UserID: user.ID,
+		Name:   name,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.Tag{}, fmt.Errorf("%w: %d", apierr.ErrTagNotFound, tagID)
+		}
+		return dbgen.Tag{}, fmt.Errorf("rename tag %d to %q: %w", tagID, name, err)
+	}
+	return tag, nil
+}
+
+func (repo *TagRepository) DeleteTag(ctx context.Context, user dbgen.User, tagID int64) error {
+	err := repo.queries.DeleteTag(ctx, dbgen.DeleteTagParams{
+		ID:     tagID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("delete tag %d: %w", tagID, err)
+	}
+	return nil
+}
+
+// MergeTags riassegna a target tutti i movimenti etichettati con source ed elimina source.
+func (repo *TagRepository) MergeTags(ctx context.Context, user dbgen.User, source dbgen.Tag, target dbgen.Tag) (int64, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	moved, err := queries.MoveTagEntries(ctx, dbgen.MoveTagEntriesParams{
+		TargetTagID: target.ID,
+		SourceTagID: source.ID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("move entries of tag %d: %w", source.ID, err)
+	}
+
+	err = queries.DeleteTag(ctx, dbgen.DeleteTagParams{
+		ID:     source.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, fmt.Errorf("delete tag %d: %w", source.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+// SetTransactionTags sostituisce i tag di tutti i movimenti della transazione,
+// creando i tag che non esistono ancora.
+func (repo *TagRepository) SetTransactionTags(ctx context.Context, user dbgen.User, transaction dbgen.Transaction, names []string) ([]dbgen.Tag, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	queries := repo.queries.WithTx(tx)
+
+	err = queries.ClearTransactionTags(ctx, transaction.ID)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, fmt.Errorf("clear tags of transaction %d: %w", transaction.ID, err)
+	}
+
+	tags := make([]dbgen.Tag, 0, len(names))
+	for _, name := range names {
+		tag, err := queries.GetOrCreateTag(ctx, dbgen.GetOrCreateTagParams{
+			UserID: user.ID,
+			Name:   name,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("get or create tag %q: %w", name, err)
+		}
+
+		err = queries.TagTransactionEntries(ctx, dbgen.TagTransactionEntriesParams{
+			TagID:         tag.ID,
+			TransactionID: transaction.ID,
+		})
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, fmt.Errorf("tag transaction %d with %q: %w", transaction.ID, name, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+func (repo *TagRepository) GetTransactionTags(ctx context.Context, transactionID int64) ([]dbgen.Tag, error) {
+	tags, err := repo.queries.GetTransactionTags(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("get tags of transaction %d: %w", transactionID, err)
+	}
+	return tags, nil
+}
+
+func (repo *TagRepository) GetTagTotals(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetTagTotalsByUserRow, error) {
+	totals, err := repo.queries.GetTagTotalsByUser(ctx, dbgen.GetTagTotalsByUserParams{
+		UserID:   user.ID,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get tag totals: %w", err)
+	}
+	return totals, nil
+}
@@ -84,3 +84,27 @@ func (userRepo *UserRepository) GetUserByID(ctx context.Context, userID int64) (
 	}
 	return user, nil
 }
+
+// UpdatePassword sostituisce la password dell'utente, salvandone solo l'hash.
+func (userRepo *UserRepository) UpdatePassword(ctx context.Context, user dbgen.User, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("hash password: %w", err)
+	}
+	err = userRepo.queries.UpdateUserPassword(ctx, dbgen.UpdateUserPasswordParams{
+		ID:           user.ID,
+		PasswordHash: string(hashedPassword),
+	})
+	if err != nil {
+		return fmt.Errorf("update password of user %d: %w", user.ID, err)
+	}
+	return nil
+}
+
+// DeleteUser elimina l'utente: i suoi dati vengono cancellati a cascata dal database.
+func (userRepo *UserRepository) DeleteUser(ctx context.Context, user dbgen.User) error {
+	if err := userRepo.queries.DeleteUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("delete user %d: %w", user.ID, err)
+	}
+	return nil
+}
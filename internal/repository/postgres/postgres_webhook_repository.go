@@ -0,0 +1,149 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+)
+
+type WebhookRepository struct {
+	queries *dbgen.Queries
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{
+		queries: dbgen.New(db),
+	}
+}
+
+func (repo *WebhookRepository) CreateEndpoint(ctx context.Context, user dbgen.User, url string, secret string, description *string) (dbgen.WebhookEndpoint, error) {
+	endpoint, err := repo.queries.CreateWebhookEndpoint(ctx, dbgen.CreateWebhookEndpointParams{
+		UserID:      user.ID,
+		Url:         url,
+		Secret:      secret,
+		Description: nullString(description),
+	})
+	if err != nil {
+		return dbgen.WebhookEndpoint{}, fmt.Errorf("create webhook for user %d: %w", user.ID, err)
+	}
+	return endpoint, nil
+}
+
+func (repo *WebhookRepository) GetEndpoints(ctx context.Context, user dbgen.User) ([]dbgen.WebhookEndpoint, error) {
+	endpoints, err := repo.queries.GetWebhookEndpointsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get webhooks of user %d: %w", user.ID, err)
+	}
+	return endpoints, nil
+}
+
+func (repo *WebhookRepository) GetEndpoint(ctx context.Context, user dbgen.User, endpointID int64) (dbgen.WebhookEndpoint, error) {
+	endpoint, err := repo.queries.GetWebhookEndpoint(ctx, dbgen.GetWebhookEndpointParams{
+		ID:     endpointID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dbgen.WebhookEndpoint{}, fmt.Errorf("%w: %d", apierr.ErrWebhookNotFound, endpointID)
+		}
+		return dbgen.WebhookEndpoint{}, fmt.Errorf("get webhook %d: %w", endpointID, err)
+	}
+	return endpoint, nil
+}
+
+func (repo *WebhookRepository) DeleteEndpoint(ctx context.Context, user dbgen.User, endpointID int64) error {
+	deleted, err := repo.queries.DeleteWebhookEndpoint(ctx, dbgen.DeleteWebhookEndpointParams{
+		ID:     endpointID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("delete webhook %d: %w", endpointID, err)
+	}
+	if deleted == 0 {
+		return fmt.Errorf("%w: %d", apierr.ErrWebhookNotFound, endpointID)
+	}
+	return nil
+}
+
+// FanOutOutbox smista un lotto di eventi sugli endpoint e restituisce quanti eventi ha smistato.
+func (repo *WebhookRepository) FanOutOutbox(ctx context.Context, limit int32) (int64, error) {
+	events, err := repo.queries.FanOutWebhookOutbox(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("fan out webhook outbox: %w", err)
+	}
+	return events, nil
+}
+
+// PurgeOutbox cancella gli eventi smistati prima di before e senza invii in sospeso.
+func (repo *WebhookRepository) PurgeOutbox(ctx context.Context, before time.Time) (int64, error) {
+	events, err := repo.queries.PurgeWebhookOutbox(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("purge webhook outbox: %w", err)
+	}
+	return events, nil
+}
+
+func (repo *WebhookRepository) ClaimDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int32) ([]dbgen.ClaimWebhookDeliveriesRow, error) {
+	deliveries, err := repo.queries.ClaimWebhookDeliveries(ctx, dbgen.ClaimWebhookDeliveriesParams{
+		LeaseUntil:    leaseUntil,
+		Now:           now,
+		MaxDeliveries: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (repo *WebhookRepository) MarkDeliverySent(ctx context.Context, deliveryID int64, statusCode int) error {
+	err := repo.queries.MarkWebhookDeliverySent(ctx, dbgen.MarkWebhookDeliverySentParams{
+		StatusCode: nullStatusCode(statusCode),
+		ID:         deliveryID,
+	})
+	if err != nil {
+		return fmt.Errorf("mark webhook delivery %d sent: %w", deliveryID, err)
+	}
+	return nil
+}
+
+// MarkDeliveryFailed registra il tentativo fallito; senza nextAttempt l'invio viene abbandonato.
+func (repo *WebhookRepository) MarkDeliveryFailed(ctx context.Context, deliveryID int64, statusCode int, lastError string, nextAttempt *time.Time) error {
+	var next sql.NullTime
+	if nextAttempt != nil {
+		next = sql.NullTime{Time: *nextAttempt, Valid: true}
+	}
+	err := repo.queries.MarkWebhookDeliveryFailed(ctx, dbgen.MarkWebhookDeliveryFailedParams{
+		NextAttemptAt: next,
+		StatusCode:    nullStatusCode(statusCode),
+		LastError:     sql.NullString{String: lastError, Valid: true},
+		ID:            deliveryID,
+	})
+	if err != nil {
+		return fmt.Errorf("mark webhook delivery %d failed: %w", deliveryID, err)
+	}
+	return nil
+}
+
+func (repo *WebhookRepository) GetDeliveries(ctx context.Context, endpoint dbgen.WebhookEndpoint, limit int32) ([]dbgen.GetWebhookDeliveriesRow, error) {
+	deliveries, err := repo.queries.GetWebhookDeliveries(ctx, dbgen.GetWebhookDeliveriesParams{
+		EndpointID:    endpoint.ID,
+		MaxDeliveries: limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get deliveries of webhook %d: %w", endpoint.ID, err)
+	}
+	return deliveries, nil
+}
+
+// nullStatusCode: 0 significa che la richiesta non ha ricevuto risposta.
+func nullStatusCode(statusCode int) sql.NullInt32 {
+	if statusCode == 0 {
+		return sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(statusCode), Valid: true}
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	"time"
+)
+
+type ReconciliationRepository interface {
+	CreateReconciliation(ctx context.Context, account dbgen.Account, statementDate time.Time, statementBalance int64) (dbgen.Reconciliation, error)
+	GetReconciliationByID(ctx context.Context, user dbgen.User, reconciliationID int64) (dbgen.Reconciliation, error)
+	GetReconciliations(ctx context.Context, account dbgen.Account) ([]dbgen.Reconciliation, error)
+	GetReconciliationEntries(ctx context.Context, account dbgen.Account, statementDate time.Time) ([]dbgen.GetReconciliationEntriesRow, error)
+	GetClearedBalance(ctx context.Context, account dbgen.Account, statementDate time.Time) (int64, error)
+	SetEntriesStatus(ctx context.Context, account dbgen.Account, entryIDs []int64, status dto.EntryStatus) error
+	CompleteReconciliation(ctx context.Context, reconciliation dbgen.Reconciliation, balanced func(reconciled int64) error) (dbgen.Reconciliation, int64, error)
+	DeleteReconciliation(ctx context.Context, reconciliation dbgen.Reconciliation) error
+}
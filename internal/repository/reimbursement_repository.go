@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+)
+
+type ReimbursementRepository interface {
+	GetEntry(ctx context.Context, user dbgen.User, entryID int64) (dbgen.TransactionEntry, error)
+	MarkReimbursable(ctx context.Context, user dbgen.User, entry dbgen.TransactionEntry, party string) (dbgen.Reimbursable, error)
+	UnmarkReimbursable(ctx context.Context, entry dbgen.TransactionEntry) error
+	GetReimbursables(ctx context.Context, user dbgen.User, entryID *int64, party *string, includeSettled bool) ([]dbgen.GetReimbursableEntriesRow, error)
+	GetReimbursements(ctx context.Context, user dbgen.User) ([]dbgen.Reimbursement, error)
+	AddReimbursement(ctx context.Context, expense dbgen.TransactionEntry, income dbgen.TransactionEntry, amount *int64) (dbgen.Reimbursement, error)
+	GetReimbursement(ctx context.Context, user dbgen.User, reimbursementID int64) (dbgen.Reimbursement, error)
+	DeleteReimbursement(ctx context.Context, reimbursement dbgen.Reimbursement) error
+}
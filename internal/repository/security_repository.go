@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	"time"
+)
+
+type SecurityRepository interface {
+	CreateSecurity(ctx context.Context, user dbgen.User, createDto dto.CreateSecurityDto) (dbgen.Security, error)
+	GetSecurities(ctx context.Context, user dbgen.User) ([]dbgen.Security, error)
+	GetSecurityByID(ctx context.Context, user dbgen.User, securityID int64) (dbgen.Security, error)
+	GetSecurityByIdentifier(ctx context.Context, user dbgen.User, identifier string) (dbgen.Security, error)
+	SavePrices(ctx context.Context, prices []dto.SecurityPriceDto) error
+	GetPrices(ctx context.Context, security dbgen.Security, dateFrom time.Time, dateTo time.Time) ([]dbgen.SecurityPrice, error)
+	GetLatestPrices(ctx context.Context, user dbgen.User, asOf time.Time) ([]dbgen.SecurityPrice, error)
+}
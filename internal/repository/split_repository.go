@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+type SplitRepository interface {
+	CreateGroup(ctx context.Context, user dbgen.User, createDto dto.CreateSplitGroupDto, memberName string) (dbgen.SplitGroup, error)
+	GetGroups(ctx context.Context, user dbgen.User) ([]dbgen.SplitGroup, error)
+	GetGroup(ctx context.Context, groupID int64) (dbgen.SplitGroup, error)
+	GetMembers(ctx context.Context, group dbgen.SplitGroup) ([]dbgen.SplitMember, error)
+	GetMemberByUser(ctx context.Context, group dbgen.SplitGroup, userID int64) (dbgen.SplitMember, error)
+	AddMember(ctx context.Context, group dbgen.SplitGroup, userID *int64, name string) (dbgen.SplitMember, error)
+	GetTransactionExpense(ctx context.Context, transaction dbgen.Transaction) (dbgen.GetTransactionExpenseRow, error)
+	CreateExpense(ctx context.Context, group dbgen.SplitGroup, transaction dbgen.Transaction, payer dbgen.SplitMember, amount int64, method dto.SplitMethod, shares []dto.SplitShareAmount) (dbgen.SplitExpense, error)
+	GetExpense(ctx context.Context, group dbgen.SplitGroup, expenseID int64) (dbgen.SplitExpense, error)
+	GetExpenses(ctx context.Context, group dbgen.SplitGroup) ([]dbgen.GetSplitExpensesRow, error)
+	GetShares(ctx context.Context, group dbgen.SplitGroup) ([]dbgen.SplitShare, error)
+	DeleteExpense(ctx context.Context, group dbgen.SplitGroup, expense dbgen.SplitExpense) error
+	RecordSettlement(ctx context.Context, group dbgen.SplitGroup, settlementDto dto.SettlementDto, fromEntry *dto.SettlementEntry, toEntry *dto.SettlementEntry) (dbgen.SplitSettlement, error)
+	GetSettlements(ctx context.Context, group dbgen.SplitGroup) ([]dbgen.SplitSettlement, error)
+	GetSettlement(ctx context.Context, group dbgen.SplitGroup, settlementID int64) (dbgen.SplitSettlement, error)
+	ConfirmSettlement(ctx context.Context, settlement dbgen.SplitSettlement, entry dto.SettlementEntry) (dbgen.SplitSettlement, error)
+}
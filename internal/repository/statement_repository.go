@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"time"
+)
+
+type StatementRepository interface {
+	GetAccountSummaries(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetAccountBalanceChangesRow, error)
+	GetEntries(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetStatementEntriesRow, error)
+}
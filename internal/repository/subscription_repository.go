@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"time"
+)
+
+type SubscriptionRepository interface {
+	GetCandidates(ctx context.Context, user dbgen.User, dateFrom time.Time) ([]dbgen.GetSubscriptionCandidatesRow, error)
+}
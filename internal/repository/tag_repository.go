@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"time"
+)
+
+type TagRepository interface {
+	GetTags(ctx context.Context, user dbgen.User) ([]dbgen.Tag, error)
+	GetTagByID(ctx context.Context, user dbgen.User, tagID int64) (dbgen.Tag, error)
+	GetTagByName(ctx context.Context, user dbgen.User, name string) (dbgen.Tag, error)
+	CreateTag(ctx context.Context, user dbgen.User, name string) (dbgen.Tag, error)
+	RenameTag(ctx context.Context, user dbgen.User, tagID int64, name string) (dbgen.Tag, error)
+	DeleteTag(ctx context.Context, user dbgen.User, tagID int64) error
+	MergeTags(ctx context.Context, user dbgen.User, source dbgen.Tag, target dbgen.Tag) (int64, error)
+	SetTransactionTags(ctx context.Context, user dbgen.User, transaction dbgen.Transaction, names []string) ([]dbgen.Tag, error)
+	GetTransactionTags(ctx context.Context, transactionID int64) ([]dbgen.Tag, error)
+	GetTagTotals(ctx context.Context, user dbgen.User, dateFrom time.Time, dateTo time.Time) ([]dbgen.GetTagTotalsByUserRow, error)
+}
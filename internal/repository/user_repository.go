@@ -11,4 +11,6 @@ type UserRepository interface {
 	GetUser(ctx context.Context, email string) (dbgen.User, error)
 	GetUserByEmail(ctx context.Context, email string) (dbgen.User, error)
 	GetUserByID(ctx context.Context, userID int64) (dbgen.User, error)
+	UpdatePassword(ctx context.Context, user dbgen.User, password string) error
+	DeleteUser(ctx context.Context, user dbgen.User) error
 }
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	dbgen "koin/internal/db/generated"
+	"time"
+)
+
+type WebhookRepository interface {
+	CreateEndpoint(ctx context.Context, user dbgen.User, url string, secret string, description *string) (dbgen.WebhookEndpoint, error)
+	GetEndpoints(ctx context.Context, user dbgen.User) ([]dbgen.WebhookEndpoint, error)
+	GetEndpoint(ctx context.Context, user dbgen.User, endpointID int64) (dbgen.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, user dbgen.User, endpointID int64) error
+	FanOutOutbox(ctx context.Context, limit int32) (int64, error)
+	PurgeOutbox(ctx context.Context, before time.Time) (int64, error)
+	ClaimDeliveries(ctx context.Context, now time.Time, leaseUntil time.Time, limit int32) ([]dbgen.ClaimWebhookDeliveriesRow, error)
+	MarkDeliverySent(ctx context.Context, deliveryID int64, statusCode int) error
+	MarkDeliveryFailed(ctx context.Context, deliveryID int64, statusCode int, lastError string, nextAttempt *time.Time) error
+	GetDeliveries(ctx context.Context, endpoint dbgen.WebhookEndpoint, limit int32) ([]dbgen.GetWebhookDeliveriesRow, error)
+}
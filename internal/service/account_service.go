@@ -1,28 +1,41 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
 	"koin/internal/model/dto"
 	repo "koin/internal/repository"
+	"math"
+	"strings"
 )
 
 type AccountService struct {
 	userRepo     repo.UserRepository
 	accountRepo  repo.AccountRepository
 	categoryRepo repo.CategoryRepository
+	tagRepo      repo.TagRepository
+	payeeRepo    repo.PayeeRepository
 }
 
 func NewAccountService(
 	userRepo repo.UserRepository,
 	accountRepo repo.AccountRepository,
 	categoryRepo repo.CategoryRepository,
+	tagRepo repo.TagRepository,
+	payeeRepo repo.PayeeRepository,
 ) *AccountService {
 	return &AccountService{
 		userRepo:     userRepo,
 		accountRepo:  accountRepo,
 		categoryRepo: categoryRepo,
+		tagRepo:      tagRepo,
+		payeeRepo:    payeeRepo,
 	}
 }
 
@@ -54,20 +67,41 @@ func (accountService *AccountService) AddTransaction(ctx context.Context, addExp
 	if err2 != nil {
 		return 0, err2
 	}
+	if err2 := ensureCanEditAccount(ctx, accountService.accountRepo, user, account); err2 != nil {
+		return 0, err2
+	}
 
-	// Tenta di ottenere la category, se non esiste la crea
-	category, err2 := accountService.categoryRepo.GetCategory(ctx, user, addExpenseDto.CategoryName, addExpenseDto.CategoryType)
+	tags, err2 := NormalizeTags(addExpenseDto.Tags)
 	if err2 != nil {
-		category, err2 = accountService.categoryRepo.CreateCategory(ctx, user, addExpenseDto.CategoryName, addExpenseDto.CategoryType)
-		if err2 != nil {
-			return 0, err2
-		}
+		return 0, err2
+	}
+
+	payee, hasPayee, err2 := accountService.resolvePayee(ctx, user, addExpenseDto)
+	if err2 != nil {
+		return 0, err2
 	}
 
-	transactionId, err2 := accountService.accountRepo.AddTransaction(ctx, user, account, category, addExpenseDto)
+	category, err2 := accountService.resolveCategory(ctx, user, payee, hasPayee, addExpenseDto)
 	if err2 != nil {
 		return 0, err2
 	}
+	if category.ArchivedAt.Valid {
+		return 0, fmt.Errorf("%w: %s", apierr.ErrCategoryArchived, category.Name)
+	}
+
+	var payeeID *int64
+	if hasPayee {
+		payeeID = &payee.ID
+	}
+
+	transactionId, err2 := accountService.accountRepo.AddTransaction(ctx, user, account, category, payeeID, addExpenseDto)
+	if err2 != nil {
+		return 0, err2
+	}
+
+	if err2 := accountService.tagTransaction(ctx, user, transactionId, tags); err2 != nil {
+		return 0, err2
+	}
 	return transactionId, nil
 }
 
@@ -87,11 +121,98 @@ func (accountService *AccountService) TransferBetweenAccounts(ctx context.Contex
 		return 0, err
 	}
 
-	return accountService.accountRepo.TransferBetweenAccounts(ctx, user, fromAccount, toAccount, transfer)
+	for _, account := range []dbgen.Account{fromAccount, toAccount} {
+		if err := ensureCanEditAccount(ctx, accountService.accountRepo, user, account); err != nil {
+			return 0, err
+		}
+	}
+
+	tags, err := NormalizeTags(transfer.Tags)
+	if err != nil {
+		return 0, err
+	}
+
+	transactionID, err := accountService.accountRepo.TransferBetweenAccounts(ctx, user, fromAccount, toAccount, transfer)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := accountService.tagTransaction(ctx, user, transactionID, tags); err != nil {
+		return 0, err
+	}
+	return transactionID, nil
+}
+
+// resolvePayee individua il beneficiario del movimento: quello indicato esplicitamente
+// (creato se non esiste) oppure quello riconosciuto dalla descrizione.
+func (accountService *AccountService) resolvePayee(ctx context.Context, user dbgen.User, addExpenseDto dto.AddTransactionDto) (dbgen.Payee, bool, error) {
+	if addExpenseDto.PayeeName != nil && strings.TrimSpace(*addExpenseDto.PayeeName) != "" {
+		name, err := NormalizePayeeName(*addExpenseDto.PayeeName)
+		if err != nil {
+			return dbgen.Payee{}, false, err
+		}
+
+		payee, err := accountService.payeeRepo.GetPayeeByName(ctx, user, name)
+		if err == nil {
+			return payee, true, nil
+		}
+		if !errors.Is(err, apierr.ErrPayeeNotFound) {
+			return dbgen.Payee{}, false, err
+		}
+
+		payee, err = accountService.payeeRepo.CreatePayee(ctx, user, name, nil)
+		if err != nil {
+			return dbgen.Payee{}, false, err
+		}
+		return payee, true, nil
+	}
+
+	if addExpenseDto.Description == nil {
+		return dbgen.Payee{}, false, nil
+	}
+	return detectPayee(ctx, accountService.payeeRepo, user, *addExpenseDto.Description)
+}
+
+// resolveCategory restituisce la categoria indicata, creandola se non esiste. In assenza
+// di categoria usa quella predefinita del beneficiario, se è dello stesso tipo.
+func (accountService *AccountService) resolveCategory(ctx context.Context, user dbgen.User, payee dbgen.Payee, hasPayee bool, addExpenseDto dto.AddTransactionDto) (dbgen.Category, error) {
+	if strings.TrimSpace(addExpenseDto.CategoryName) == "" {
+		if hasPayee && payee.DefaultCategoryID.Valid {
+			category, err := accountService.categoryRepo.GetCategoryByID(ctx, user, payee.DefaultCategoryID.Int64)
+			if err != nil {
+				return dbgen.Category{}, err
+			}
+			if category.Type == string(addExpenseDto.CategoryType) {
+				return category, nil
+			}
+		}
+		return dbgen.Category{}, fmt.Errorf("%w: category is required", apierr.ErrInvalidData)
+	}
+
+	// Tenta di ottenere la category, se non esiste la crea
+	category, err := accountService.categoryRepo.GetCategory(ctx, user, addExpenseDto.CategoryName, addExpenseDto.CategoryType)
+	if err != nil {
+		category, err = accountService.categoryRepo.CreateCategory(ctx, user, addExpenseDto.CategoryName, addExpenseDto.CategoryType, nil)
+		if err != nil {
+			return dbgen.Category{}, err
+		}
+	}
+	return category, nil
 }
 
-func (accountService *AccountService) GetCategories(ctx context.Context, user dbgen.User) ([]dbgen.Category, error) {
-	return accountService.categoryRepo.GetCategories(ctx, user)
+// tagTransaction applica i tag a tutti i movimenti di una transazione appena creata.
+func (accountService *AccountService) tagTransaction(ctx context.Context, user dbgen.User, transactionID int64, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	transaction, err := accountService.accountRepo.GetTransaction(ctx, user, transactionID)
+	if err != nil {
+		return err
+	}
+
+	_, err = accountService.tagRepo.SetTransactionTags(ctx, user, transaction, tags)
+	return err
 }
 
 func (accountService *AccountService) GetAccounts(ctx context.Context, user dbgen.User) ([]dbgen.Account, error) {
@@ -102,26 +223,149 @@ func (accountService *AccountService) GetAccountBalance(ctx context.Context, acc
 	return accountService.accountRepo.GetAccountBalance(ctx, accountID)
 }
 
-func (accountService *AccountService) GetRecentTransactions(ctx context.Context, userID int64, limit int32) ([]dbgen.GetRecentTransactionEntriesByUserRow, error) {
-	return accountService.accountRepo.GetRecentTransactions(ctx, userID, limit)
+func (accountService *AccountService) GetRecentTransactions(ctx context.Context, userID int64, limit int32, tag *string) ([]dbgen.GetRecentTransactionEntriesByUserRow, error) {
+	return accountService.accountRepo.GetRecentTransactions(ctx, userID, limit, tag)
 }
 
-func (accountService *AccountService) CreateCategory(ctx context.Context, createCategoryDto dto.CreateCategoryDto) (dbgen.Category, error) {
-	user, err := accountService.userRepo.GetUserByID(ctx, createCategoryDto.UserID)
+// ImportTransactions registra una transazione per ogni riga di un CSV con colonne data, importo
+// (negativo per le spese), descrizione e, se c'è l'intestazione, anche categoria, beneficiario
+// e tag separati da "|". Separatori, date e decimali sono quelli accettati da ImportPrices. Le
+// righe già importate non vengono riconosciute, quindi importare due volte lo stesso file le
+// duplica; le righe non valide vengono saltate e segnalate.
+func (accountService *AccountService) ImportTransactions(ctx context.Context, importDto dto.ImportTransactionsDto, content io.Reader) (dto.TransactionImportResult, error) {
+	user, err := accountService.userRepo.GetUserByID(ctx, importDto.UserID)
 	if err != nil {
-		return dbgen.Category{}, err
+		return dto.TransactionImportResult{}, err
 	}
-
-	// Verificare se la categoria esiste già
-	_, err = accountService.categoryRepo.GetCategory(ctx, user, createCategoryDto.Name, createCategoryDto.CategoryType)
-	if err == nil {
-		return dbgen.Category{}, fmt.Errorf("category %q already exists", createCategoryDto.Name)
+	account, err := accountService.accountRepo.GetAccount(ctx, user, importDto.AccountName)
+	if err != nil {
+		return dto.TransactionImportResult{}, err
+	}
+	if err := ensureCanEditAccount(ctx, accountService.accountRepo, user, account); err != nil {
+		return dto.TransactionImportResult{}, err
 	}
 
-	category, err := accountService.categoryRepo.CreateCategory(ctx, user, createCategoryDto.Name, createCategoryDto.CategoryType)
+	data, err := io.ReadAll(content)
 	if err != nil {
-		return dbgen.Category{}, err
+		return dto.TransactionImportResult{}, err
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	decimalComma := false
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Contains(firstLine, []byte(";")) {
+		reader.Comma = ';'
+		decimalComma = true
 	}
 
-	return category, nil
+	columns := map[string]int{"date": 0, "amount": 1, "description": 2}
+	var result dto.TransactionImportResult
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, fmt.Errorf("%w: csv non valido alla riga %d: %v", apierr.ErrInvalidData, line, err)
+		}
+		if line == 1 {
+			if header, ok := transactionHeader(record); ok {
+				columns = header
+				continue
+			}
+		}
+		if len(record) <= max(columns["date"], columns["amount"]) {
+			result.Errors = append(result.Errors, fmt.Sprintf("riga %d: colonne mancanti", line))
+			continue
+		}
+
+		occurredAt, err := parseImportDate(record[columns["date"]])
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("riga %d: data non valida %q", line, record[columns["date"]]))
+			continue
+		}
+		amount, err := parseAmount(record[columns["amount"]], decimalComma)
+		if err != nil || amount == 0 {
+			result.Errors = append(result.Errors, fmt.Sprintf("riga %d: importo non valido %q", line, record[columns["amount"]]))
+			continue
+		}
+
+		addTransactionDto := dto.AddTransactionDto{
+			UserID:       importDto.UserID,
+			AccountName:  importDto.AccountName,
+			CategoryType: dto.Income,
+			OccurredAt:   occurredAt,
+			Amount:       amount,
+			Description:  importColumn(record, columns, "description"),
+			PayeeName:    importColumn(record, columns, "payee"),
+		}
+		if amount < 0 {
+			addTransactionDto.CategoryType = dto.Expense
+		}
+		if category := importColumn(record, columns, "category"); category != nil {
+			addTransactionDto.CategoryName = *category
+		} else if importDto.DefaultCategory != nil {
+			addTransactionDto.CategoryName = *importDto.DefaultCategory
+		}
+		if tags := importColumn(record, columns, "tags"); tags != nil {
+			addTransactionDto.Tags = strings.Split(*tags, "|")
+		}
+
+		if _, err := accountService.AddTransaction(ctx, addTransactionDto); err != nil {
+			if errors.Is(err, apierr.ErrInvalidData) || errors.Is(err, apierr.ErrCategoryArchived) || errors.Is(err, apierr.ErrInsufficientBalance) {
+				result.Errors = append(result.Errors, fmt.Sprintf("riga %d: %v", line, err))
+				continue
+			}
+			return result, err
+		}
+		result.Imported++
+	}
+	return result, nil
+}
+
+// transactionHeader riconosce l'intestazione del CSV delle transazioni e la posizione delle colonne.
+func transactionHeader(record []string) (map[string]int, bool) {
+	columns := make(map[string]int)
+	for i, name := range record {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "date", "data":
+			columns["date"] = i
+		case "amount", "importo":
+			columns["amount"] = i
+		case "description", "descrizione":
+			columns["description"] = i
+		case "category", "categoria":
+			columns["category"] = i
+		case "payee", "beneficiario":
+			columns["payee"] = i
+		case "tags", "tag":
+			columns["tags"] = i
+		}
+	}
+	_, hasDate := columns["date"]
+	_, hasAmount := columns["amount"]
+	return columns, hasDate && hasAmount
+}
+
+// importColumn restituisce il valore della colonna, o nil se manca o è vuota.
+func importColumn(record []string, columns map[string]int, name string) *string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return nil
+	}
+	value := strings.TrimSpace(record[i])
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+// parseAmount converte un importo decimale in centesimi.
+func parseAmount(value string, decimalComma bool) (int64, error) {
+	fixed, err := parseDecimal(value, decimalComma)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Round(float64(fixed) / 100)), nil
 }
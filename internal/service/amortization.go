@@ -0,0 +1,139 @@
+package service
+
+import (
+	"math"
+	"time"
+
+	"koin/internal/model/dto"
+)
+
+// maxLoanMonths limita la durata dei piani calcolati (50 anni).
+const maxLoanMonths = 600
+
+// monthlyRate converte il tasso annuo in punti base nel tasso mensile.
+func monthlyRate(annualRateBps int32) float64 {
+	return float64(annualRateBps) / 10000 / 12
+}
+
+// amortize calcola il piano di ammortamento di principal in months rate mensili. Gli importi
+// sono arrotondati al centesimo e l'ultima rata assorbe gli scarti di arrotondamento.
+func amortize(principal int64, annualRateBps int32, months int32, method dto.AmortizationMethod) []dto.Installment {
+	if months <= 0 || principal <= 0 {
+		return nil
+	}
+	if method == dto.Italian {
+		return amortizeWithPrincipal(principal, annualRateBps, principal/int64(months), months)
+	}
+	return amortizeFrench(principal, annualRateBps, months)
+}
+
+// amortizeFrench calcola il piano alla francese ricalcolando la rata sul residuo a ogni
+// scadenza: gli arrotondamenti al centesimo spostano la rata di al più qualche centesimo
+// invece di allungare o accorciare il piano.
+func amortizeFrench(principal int64, annualRateBps int32, months int32) []dto.Installment {
+	rate := monthlyRate(annualRateBps)
+	installments := make([]dto.Installment, 0, months)
+	remaining := principal
+	for number := int32(1); number <= months; number++ {
+		interest := int64(math.Round(float64(remaining) * rate))
+		quota := frenchPayment(remaining, annualRateBps, months-number+1) - interest
+		if number == months {
+			quota = remaining
+		}
+		if quota <= 0 {
+			return nil
+		}
+		quota = min(quota, remaining)
+		remaining -= quota
+		installments = append(installments, dto.Installment{
+			Number:             number,
+			Principal:          quota,
+			Interest:           interest,
+			RemainingPrincipal: remaining,
+		})
+	}
+	return installments
+}
+
+// frenchPayment è la rata costante del piano alla francese.
+func frenchPayment(principal int64, annualRateBps int32, months int32) int64 {
+	rate := monthlyRate(annualRateBps)
+	if rate == 0 {
+		return principal / int64(months)
+	}
+	return int64(math.Round(float64(principal) * rate / (1 - math.Pow(1+rate, -float64(months)))))
+}
+
+// amortizeWithPayment rimborsa il capitale con una rata costante finché non si azzera; la rata
+// numero months (se positivo) chiude comunque il piano rimborsando tutto il capitale residuo.
+func amortizeWithPayment(principal int64, annualRateBps int32, payment int64, months int32) []dto.Installment {
+	rate := monthlyRate(annualRateBps)
+	last := lastInstallment(months)
+	var installments []dto.Installment
+	remaining := principal
+	for number := int32(1); remaining > 0 && number <= last; number++ {
+		interest := int64(math.Round(float64(remaining) * rate))
+		quota := payment - interest
+		if number == last {
+			quota = remaining
+		}
+		if quota <= 0 {
+			// La rata non copre gli interessi: il piano non si chiuderebbe mai
+			return nil
+		}
+		quota = min(quota, remaining)
+		remaining -= quota
+		installments = append(installments, dto.Installment{
+			Number:             number,
+			Principal:          quota,
+			Interest:           interest,
+			RemainingPrincipal: remaining,
+		})
+	}
+	return installments
+}
+
+// amortizeWithPrincipal rimborsa il capitale con quota capitale costante (piano all'italiana);
+// come in amortizeWithPayment, la rata numero months rimborsa tutto il residuo.
+func amortizeWithPrincipal(principal int64, annualRateBps int32, quota int64, months int32) []dto.Installment {
+	rate := monthlyRate(annualRateBps)
+	quota = max(quota, 1)
+	last := lastInstallment(months)
+	var installments []dto.Installment
+	remaining := principal
+	for number := int32(1); remaining > 0 && number <= last; number++ {
+		interest := int64(math.Round(float64(remaining) * rate))
+		current := min(quota, remaining)
+		// Il resto della divisione viene rimborsato con l'ultima rata
+		if number == last || (remaining-current < quota && remaining-current > 0 && remaining < 2*quota) {
+			current = remaining
+		}
+		remaining -= current
+		installments = append(installments, dto.Installment{
+			Number:             number,
+			Principal:          current,
+			Interest:           interest,
+			RemainingPrincipal: remaining,
+		})
+	}
+	return installments
+}
+
+// lastInstallment è il numero della rata che chiude il piano: months se indicato, altrimenti
+// il limite maxLoanMonths.
+func lastInstallment(months int32) int32 {
+	if months <= 0 || months > maxLoanMonths {
+		return maxLoanMonths
+	}
+	return months
+}
+
+// scheduleDates numera le rate da firstNumber e assegna le scadenze: la rata n scade n mesi
+// dopo l'inizio del prestito, nello stesso giorno (o l'ultimo del mese se più corto).
+func scheduleDates(installments []dto.Installment, startDate time.Time, firstNumber int32) []dto.Installment {
+	for i := range installments {
+		installments[i].Number = firstNumber + int32(i)
+		installments[i].DueDate = closingInMonth(startDate, int(installments[i].Number), int32(startDate.Day()))
+	}
+	return installments
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"math/rand"
+	"testing"
+
+	"koin/internal/model/dto"
+)
+
+func TestAmortizeClosesInTerm(t *testing.T) {
+	tests := []struct {
+		name          string
+		principal     int64
+		annualRateBps int32
+		months        int32
+		method        dto.AmortizationMethod
+	}{
+		{"french mortgage", 15000000, 350, 360, dto.French},
+		{"french short loan", 1000000, 799, 12, dto.French},
+		{"french zero rate", 1000000, 0, 36, dto.French},
+		{"french single installment", 50000, 500, 1, dto.French},
+		{"italian mortgage", 15000000, 350, 360, dto.Italian},
+		{"italian uneven division", 1000001, 420, 7, dto.Italian},
+		{"italian zero rate", 999999, 0, 48, dto.Italian},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSchedule(t, amortize(tt.principal, tt.annualRateBps, tt.months, tt.method), tt.principal, tt.months)
+		})
+	}
+}
+
+func TestAmortizeRandomInputs(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 2000 {
+		principal := 100000 + random.Int63n(50000000)
+		rate := int32(random.Intn(1500))
+		months := int32(1 + random.Intn(maxLoanMonths))
+		for _, method := range []dto.AmortizationMethod{dto.French, dto.Italian} {
+			schedule := amortize(principal, rate, months, method)
+			if int32(len(schedule)) != months {
+				t.Fatalf("%s %d at %d bps over %d months: got %d installments", method, principal, rate, months, len(schedule))
+			}
+			checkSchedule(t, schedule, principal, months)
+		}
+	}
+}
+
+func TestAmortizeWithPaymentReducesTerm(t *testing.T) {
+	payment := frenchPayment(10000000, 400, 240)
+	schedule := amortizeWithPayment(8000000, 400, payment, 240)
+	if len(schedule) == 0 || len(schedule) >= 240 {
+		t.Fatalf("expected a shorter schedule, got %d installments", len(schedule))
+	}
+	assertPrincipal(t, schedule, 8000000)
+}
+
+func TestAmortizeRejectsInvalidInput(t *testing.T) {
+	if schedule := amortize(0, 300, 12, dto.French); schedule != nil {
+		t.Errorf("zero principal: got %d installments", len(schedule))
+	}
+	if schedule := amortize(100000, 300, 0, dto.Italian); schedule != nil {
+		t.Errorf("zero months: got %d installments", len(schedule))
+	}
+}
+
+func checkSchedule(t *testing.T, schedule []dto.Installment, principal int64, months int32) {
+	t.Helper()
+	if int32(len(schedule)) != months {
+		t.Fatalf("got %d installments, want %d", len(schedule), months)
+	}
+	assertPrincipal(t, schedule, principal)
+	for i, installment := range schedule {
+		if installment.Principal <= 0 || installment.Interest < 0 {
+			t.Fatalf("installment %d: principal %d, interest %d", i+1, installment.Principal, installment.Interest)
+		}
+	}
+	if last := schedule[len(schedule)-1]; last.RemainingPrincipal != 0 {
+		t.Fatalf("last installment leaves %d", last.RemainingPrincipal)
+	}
+}
+
+func assertPrincipal(t *testing.T, schedule []dto.Installment, principal int64) {
+	t.Helper()
+	var total int64
+	for _, installment := range schedule {
+		total += installment.Principal
+	}
+	if total != principal {
+		t.Fatalf("principal sum %d, want %d", total, principal)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+const (
+	// madScale rende la MAD (deviazione assoluta mediana) confrontabile con una deviazione
+	// standard per importi distribuiti normalmente.
+	madScale = 1.4826
+	// anomalyZScore è la distanza dalla mediana, in MAD scalate, oltre la quale un importo è anomalo.
+	anomalyZScore = 3.5
+	// anomalyMinRatio è il rapporto minimo con la mediana, in percentuale: evita di segnalare
+	// scostamenti piccoli in storici molto regolari, dove la MAD è quasi nulla.
+	anomalyMinRatio = 150
+	// minExpenseHistory e minCategoryMonths sono i dati minimi perché la mediana sia significativa.
+	minExpenseHistory = 5
+	minCategoryMonths = 4
+)
+
+// isOutlier confronta value con la distribuzione storica: è anomalo se supera la mediana di
+// almeno anomalyZScore MAD scalate ed è almeno anomalyMinRatio% della mediana. Si segnalano
+// solo gli importi più alti del solito.
+func isOutlier(value int64, history []int64) (dto.Outlier, bool) {
+	median := medianOf(history)
+	if median <= 0 {
+		return dto.Outlier{}, false
+	}
+	deviations := make([]int64, len(history))
+	for i, amount := range history {
+		deviations[i] = abs64(amount - median)
+	}
+	threshold := float64(median) + anomalyZScore*madScale*float64(medianOf(deviations))
+	if float64(value) <= threshold || value*100 < median*anomalyMinRatio {
+		return dto.Outlier{}, false
+	}
+	return dto.Outlier{
+		Amount:   value,
+		Baseline: median,
+		RatioPct: int32(min(value*100/median, math.MaxInt32)),
+	}, true
+}
+
+// expenseAnomalyMessage descrive la spesa anomala, per esempio "La spesa di 120,00 EUR da
+// Amazon è 4x il solito (30,00 EUR)".
+func expenseAnomalyMessage(expense dbgen.GetNewExpensesRow, outlier dto.Outlier) string {
+	name := strings.TrimSpace(expense.Description)
+	if expense.PayeeID.Valid {
+		name = expense.PayeeName.String
+	}
+	if name == "" {
+		name = expense.CategoryName
+	}
+	return fmt.Sprintf("La spesa di %s da %s è %s il solito (%s)",
+		formatCents(outlier.Amount, expense.Currency), name, formatRatio(outlier.RatioPct), formatCents(outlier.Baseline, expense.Currency))
+}
+
+// categoryAnomalyMessage descrive il totale mensile anomalo, per esempio "Ristoranti è al 180%
+// del normale questo mese (540,00 EUR contro 300,00 EUR)".
+func categoryAnomalyMessage(total dbgen.GetMonthlyCategoryTotalsRow, outlier dto.Outlier) string {
+	return fmt.Sprintf("%s è al %d%% del normale questo mese (%s contro %s)",
+		total.CategoryName, outlier.RatioPct, formatCents(outlier.Amount, total.Currency), formatCents(outlier.Baseline, total.Currency))
+}
+
+// formatRatio scrive il rapporto come moltiplicatore con al più un decimale: "4x", "2,5x".
+func formatRatio(ratioPct int32) string {
+	tenths := (ratioPct + 5) / 10
+	if tenths%10 == 0 {
+		return fmt.Sprintf("%dx", tenths/10)
+	}
+	return fmt.Sprintf("%d,%dx", tenths/10, tenths%10)
+}
+
+// formatCents scrive un importo in centesimi nel formato italiano, es. "1234,50 EUR"; senza
+// valuta restituisce solo il numero.
+func formatCents(cents int64, currency string) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+	}
+	cents = abs64(cents)
+	return strings.TrimSpace(fmt.Sprintf("%s%d,%02d %s", sign, cents/100, cents%100, currency))
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+const (
+	// anomalyHistoryMonths è lo storico con cui si confrontano spese e totali mensili.
+	anomalyHistoryMonths = 12
+	// anomalyLookbackDays limita la prima analisi (e le spese inserite in ritardo) alle spese recenti.
+	anomalyLookbackDays = 90
+)
+
+type AnomalyService struct {
+	userRepo      repo.UserRepository
+	anomalyRepo   repo.AnomalyRepository
+	notifications NotificationBus
+}
+
+func NewAnomalyService(
+	userRepo repo.UserRepository,
+	anomalyRepo repo.AnomalyRepository,
+	notifications NotificationBus,
+) *AnomalyService {
+	return &AnomalyService{
+		userRepo:      userRepo,
+		anomalyRepo:   anomalyRepo,
+		notifications: notifications,
+	}
+}
+
+func (anomalyService *AnomalyService) GetAnomalies(ctx context.Context, userID int64, includeDismissed bool) ([]dbgen.SpendingAnomaly, error) {
+	user, err := anomalyService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return anomalyService.anomalyRepo.GetAnomalies(ctx, user, includeDismissed)
+}
+
+// Dismiss archivia la segnalazione, che non compare più tra quelle attive; resta archiviata anche
+// se il totale mensile della categoria viene aggiornato.
+func (anomalyService *AnomalyService) Dismiss(ctx context.Context, userID int64, anomalyID int64) error {
+	user, err := anomalyService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return anomalyService.anomalyRepo.DismissAnomaly(ctx, user, anomalyID)
+}
+
+// ScanUser analizza subito le spese dell'utente e restituisce le nuove segnalazioni.
+func (anomalyService *AnomalyService) ScanUser(ctx context.Context, userID int64, now time.Time) ([]dbgen.SpendingAnomaly, error) {
+	user, err := anomalyService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return anomalyService.scan(ctx, user, toDate(now))
+}
+
+// RunScan analizza le spese di tutti gli utenti con movimenti nuovi; gli errori vengono
+// registrati e l'utente viene riprovato al giro successivo.
+func (anomalyService *AnomalyService) RunScan(ctx context.Context, now time.Time) {
+	users, err := anomalyService.anomalyRepo.GetUsersToScan(ctx)
+	if err != nil {
+		log.Printf("anomalies: scan: %v", err)
+		return
+	}
+
+	for _, user := range users {
+		if _, err := anomalyService.scan(ctx, user, toDate(now)); err != nil {
+			log.Printf("anomalies: scan user %d: %v", user.ID, err)
+		}
+	}
+}
+
+// StartScan esegue RunScan subito e poi a ogni intervallo, finché il contesto non viene annullato.
+func (anomalyService *AnomalyService) StartScan(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			anomalyService.RunScan(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// scan confronta ogni spesa registrata dopo l'ultima analisi con le spese dello stesso
+// beneficiario (o della stessa categoria) dell'ultimo anno, e il totale del mese corrente di ogni
+// categoria con i totali dei dodici mesi precedenti. Restituisce le segnalazioni nuove o
+// aggiornate; ogni segnalazione viene notificata una volta sola.
+func (anomalyService *AnomalyService) scan(ctx context.Context, user dbgen.User, today time.Time) ([]dbgen.SpendingAnomaly, error) {
+	// Il watermark va letto prima delle spese: quelle inserite durante l'analisi restano per il
+	// giro successivo.
+	watermark, err := anomalyService.anomalyRepo.GetWatermark(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := anomalyService.anomalyRepo.GetLatestEntryID(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	var anomalies []dbgen.SpendingAnomaly
+	expenses, err := anomalyService.anomalyRepo.GetNewExpenses(ctx, user, watermark, today.AddDate(0, 0, -anomalyLookbackDays))
+	if err != nil {
+		return nil, err
+	}
+	for _, expense := range expenses {
+		if expense.ID > latest {
+			continue
+		}
+		history, err := anomalyService.anomalyRepo.GetExpenseHistory(ctx, user, expense, toDate(expense.OccurredAt).AddDate(0, -anomalyHistoryMonths, 0))
+		if err != nil {
+			return nil, err
+		}
+		if len(history) < minExpenseHistory {
+			continue
+		}
+		outlier, ok := isOutlier(expense.Amount, history)
+		if !ok {
+			continue
+		}
+		anomaly, created, err := anomalyService.anomalyRepo.CreateExpenseAnomaly(ctx, user, expense, outlier, expenseAnomalyMessage(expense, outlier))
+		if err != nil {
+			return nil, err
+		}
+		if created {
+			anomalies = append(anomalies, anomaly)
+		}
+	}
+
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	totals, err := anomalyService.anomalyRepo.GetMonthlyCategoryTotals(ctx, user, month.AddDate(0, -anomalyHistoryMonths, 0), today)
+	if err != nil {
+		return nil, err
+	}
+	type categoryKey struct {
+		categoryID int64
+		currency   string
+	}
+	history := make(map[categoryKey][]int64)
+	var current []dbgen.GetMonthlyCategoryTotalsRow
+	for _, total := range totals {
+		if !total.CategoryID.Valid {
+			continue
+		}
+		if toDate(total.Month).Equal(month) {
+			current = append(current, total)
+			continue
+		}
+		key := categoryKey{categoryID: total.CategoryID.Int64, currency: total.Currency}
+		history[key] = append(history[key], total.Total)
+	}
+	for _, total := range current {
+		months := history[categoryKey{categoryID: total.CategoryID.Int64, currency: total.Currency}]
+		if len(months) < minCategoryMonths {
+			continue
+		}
+		outlier, ok := isOutlier(total.Total, months)
+		if !ok {
+			continue
+		}
+		anomaly, err := anomalyService.anomalyRepo.UpsertCategoryAnomaly(ctx, user, total.CategoryID.Int64, month, outlier, categoryAnomalyMessage(total, outlier))
+		if err != nil {
+			return nil, err
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	for _, anomaly := range anomalies {
+		title := "Spesa fuori dalla norma"
+		if dto.AnomalyKind(anomaly.Kind) == dto.AnomalyCategory {
+			title = "Categoria fuori dalla norma"
+		}
+		anomalyService.notifications.Publish(ctx, dto.NotificationEvent{
+			UserID:   user.ID,
+			Kind:     dto.NotifySpendingAnomaly,
+			Title:    title,
+			Body:     anomaly.Message,
+			DedupKey: fmt.Sprintf("anomaly:%d", anomaly.ID),
+		})
+	}
+
+	if latest > watermark {
+		if err := anomalyService.anomalyRepo.SetWatermark(ctx, user, latest); err != nil {
+			return nil, err
+		}
+	}
+	return anomalies, nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import "testing"
+
+func TestIsOutlier(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    int64
+		history  []int64
+		outlier  bool
+		ratioPct int32
+	}{
+		{"regular history, at the minimum ratio", 4500, []int64{3000, 3000, 3000, 3000, 3000}, true, 150},
+		{"regular history, below the minimum ratio", 4400, []int64{3000, 3000, 3000, 3000, 3000}, false, 0},
+		{"noisy history, within the spread", 5500, []int64{2000, 3000, 4000, 2500, 3500}, false, 0},
+		{"noisy history, beyond the spread", 5600, []int64{2000, 3000, 4000, 2500, 3500}, true, 186},
+		{"lower than usual", 100, []int64{3000, 3000, 3000, 3000, 3000}, false, 0},
+		{"one-off spike in the history", 9000, []int64{1000, 1100, 900, 50000, 1000, 1050}, true, 900},
+		{"no positive baseline", 5000, []int64{0, 0, 0, 0, 0}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outlier, ok := isOutlier(tt.value, tt.history)
+			if ok != tt.outlier {
+				t.Fatalf("isOutlier(%d, %v) = %v, want %v", tt.value, tt.history, ok, tt.outlier)
+			}
+			if ok && (outlier.Amount != tt.value || outlier.RatioPct != tt.ratioPct) {
+				t.Errorf("got %+v, want amount %d and ratio %d%%", outlier, tt.value, tt.ratioPct)
+			}
+		})
+	}
+}
+
+func TestFormatRatio(t *testing.T) {
+	tests := map[int32]string{100: "1x", 150: "1,5x", 186: "1,9x", 400: "4x", 996: "10x"}
+	for ratioPct, want := range tests {
+		if got := formatRatio(ratioPct); got != want {
+			t.Errorf("formatRatio(%d) = %q, want %q", ratioPct, got, want)
+		}
+	}
+}
+
+func TestFormatCents(t *testing.T) {
+	tests := []struct {
+		cents    int64
+		currency string
+		want     string
+	}{
+		{123450, "EUR", "1234,50 EUR"},
+		{5, "EUR", "0,05 EUR"},
+		{-5, "USD", "-0,05 USD"},
+		{100, "", "1,00"},
+	}
+	for _, tt := range tests {
+		if got := formatCents(tt.cents, tt.currency); got != tt.want {
+			t.Errorf("formatCents(%d, %q) = %q, want %q", tt.cents, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+	"koin/internal/storage"
+)
+
+// MaxAttachmentSize è la dimensione massima di un allegato (10 MiB).
+const MaxAttachmentSize = 10 << 20
+
+// maxFileNameLength corrisponde a ATTACHMENTS.FILE_NAME VARCHAR(255).
+const maxFileNameLength = 255
+
+// allowedAttachmentTypes elenca i tipi MIME accettati, riconosciuti dal contenuto del file
+// e non dall'estensione o dall'header inviato dal client.
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+type AttachmentService struct {
+	userRepo       repo.UserRepository
+	accountRepo    repo.AccountRepository
+	attachmentRepo repo.AttachmentRepository
+	blobStore      storage.BlobStore
+}
+
+func NewAttachmentService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	attachmentRepo repo.AttachmentRepository,
+	blobStore storage.BlobStore,
+) *AttachmentService {
+	return &AttachmentService{
+		userRepo:       userRepo,
+		accountRepo:    accountRepo,
+		attachmentRepo: attachmentRepo,
+		blobStore:      blobStore,
+	}
+}
+
+// UploadAttachment valida il file, lo salva nel blob store insieme alla miniatura
+// (solo per le immagini) e lo collega alla transazione.
+func (attachmentService *AttachmentService) UploadAttachment(ctx context.Context, uploadDto dto.UploadAttachmentDto) (dbgen.Attachment, error) {
+	user, err := attachmentService.userRepo.GetUserByID(ctx, uploadDto.UserID)
+	if err != nil {
+		return dbgen.Attachment{}, err
+	}
+
+	transaction, err := attachmentService.accountRepo.GetTransaction(ctx, user, uploadDto.TransactionID)
+	if err != nil {
+		return dbgen.Attachment{}, err
+	}
+	if err := ensureCanEditTransaction(ctx, attachmentService.accountRepo, user, transaction); err != nil {
+		return dbgen.Attachment{}, err
+	}
+
+	if len(uploadDto.Content) == 0 {
+		return dbgen.Attachment{}, fmt.Errorf("%w: file is empty", apierr.ErrInvalidData)
+	}
+	if len(uploadDto.Content) > MaxAttachmentSize {
+		return dbgen.Attachment{}, fmt.Errorf("%w: maximum size is %d bytes", apierr.ErrFileTooLarge, MaxAttachmentSize)
+	}
+
+	contentType := DetectContentType(uploadDto.Content)
+	if !allowedAttachmentTypes[contentType] {
+		return dbgen.Attachment{}, fmt.Errorf("%w: %s", apierr.ErrUnsupportedFileType, contentType)
+	}
+
+	fileName, err := sanitizeFileName(uploadDto.FileName)
+	if err != nil {
+		return dbgen.Attachment{}, err
+	}
+
+	var thumbnail []byte
+	hasThumbnail := false
+	if strings.HasPrefix(contentType, "image/") {
+		thumbnail, hasThumbnail, err = makeThumbnail(uploadDto.Content)
+		if err != nil {
+			return dbgen.Attachment{}, err
+		}
+	}
+
+	storageKey, err := newStorageKey(user.ID, transaction.ID)
+	if err != nil {
+		return dbgen.Attachment{}, err
+	}
+	if err := attachmentService.blobStore.Put(ctx, storageKey, bytes.NewReader(uploadDto.Content), int64(len(uploadDto.Content)), contentType); err != nil {
+		return dbgen.Attachment{}, err
+	}
+	keys := []string{storageKey}
+
+	thumbnailKey := sql.NullString{}
+	if hasThumbnail {
+		thumbnailKey = sql.NullString{String: storageKey + ".thumb.jpg", Valid: true}
+		if err := attachmentService.blobStore.Put(ctx, thumbnailKey.String, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+			removeBlobs(ctx, attachmentService.blobStore, keys)
+			return dbgen.Attachment{}, err
+		}
+		keys = append(keys, thumbnailKey.String)
+	}
+
+	attachment, err := attachmentService.attachmentRepo.CreateAttachment(ctx, user, transaction, dbgen.CreateAttachmentParams{
+		FileName:     fileName,
+		ContentType:  contentType,
+		SizeBytes:    int64(len(uploadDto.Content)),
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+	})
+	if err != nil {
+		removeBlobs(ctx, attachmentService.blobStore, keys)
+		return dbgen.Attachment{}, err
+	}
+	return attachment, nil
+}
+
+func (attachmentService *AttachmentService) GetAttachments(ctx context.Context, userID int64, transactionID int64) ([]dbgen.Attachment, error) {
+	user, err := attachmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := attachmentService.accountRepo.GetTransaction(ctx, user, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return attachmentService.attachmentRepo.GetAttachments(ctx, user, transaction)
+}
+
+// OpenAttachment restituisce i metadati dell'allegato e un lettore sul contenuto,
+// che il chiamante deve chiudere.
+func (attachmentService *AttachmentService) OpenAttachment(ctx context.Context, userID int64, attachmentID int64) (dbgen.Attachment, io.ReadCloser, error) {
+	attachment, err := attachmentService.getAttachment(ctx, userID, attachmentID)
+	if err != nil {
+		return dbgen.Attachment{}, nil, err
+	}
+
+	content, err := attachmentService.blobStore.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return dbgen.Attachment{}, nil, err
+	}
+	return attachment, content, nil
+}
+
+// OpenThumbnail restituisce un lettore sulla miniatura JPEG dell'allegato, se è un'immagine.
+func (attachmentService *AttachmentService) OpenThumbnail(ctx context.Context, userID int64, attachmentID int64) (io.ReadCloser, error) {
+	attachment, err := attachmentService.getAttachment(ctx, userID, attachmentID)
+	if err != nil {
+		return nil, err
+	}
+	if !attachment.ThumbnailKey.Valid {
+		return nil, fmt.Errorf("%w: attachment %d has no thumbnail", apierr.ErrNotFound, attachmentID)
+	}
+
+	return attachmentService.blobStore.Get(ctx, attachment.ThumbnailKey.String)
+}
+
+func (attachmentService *AttachmentService) DeleteAttachment(ctx context.Context, userID int64, attachmentID int64) error {
+	user, err := attachmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	attachment, err := attachmentService.attachmentRepo.GetAttachmentByID(ctx, user, attachmentID)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := attachmentService.accountRepo.GetTransaction(ctx, user, attachment.TransactionID)
+	if err != nil {
+		return err
+	}
+	if err := ensureCanEditTransaction(ctx, attachmentService.accountRepo, user, transaction); err != nil {
+		return err
+	}
+
+	if err := attachmentService.attachmentRepo.DeleteAttachment(ctx, user, attachment); err != nil {
+		return err
+	}
+	removeBlobs(ctx, attachmentService.blobStore, attachmentKeys([]dbgen.Attachment{attachment}))
+	return nil
+}
+
+// DeleteTransaction elimina una transazione con i suoi movimenti e rimuove dal blob store
+// i file degli allegati. I file vengono cancellati dopo il commit: un errore lascia al più
+// un file orfano, mai un allegato che punta a un file inesistente.
+func (attachmentService *AttachmentService) DeleteTransaction(ctx context.Context, userID int64, transactionID int64) error {
+	user, err := attachmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := attachmentService.accountRepo.GetTransaction(ctx, user, transactionID)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureCanEditTransaction(ctx, attachmentService.accountRepo, user, transaction); err != nil {
+		return err
+	}
+	if err := ensureNotReconciled(ctx, attachmentService.accountRepo, transaction); err != nil {
+		return err
+	}
+
+	attachments, err := attachmentService.attachmentRepo.GetAttachments(ctx, user, transaction)
+	if err != nil {
+		return err
+	}
+
+	if err := attachmentService.accountRepo.DeleteTransaction(ctx, user, transaction); err != nil {
+		return err
+	}
+	removeBlobs(ctx, attachmentService.blobStore, attachmentKeys(attachments))
+	return nil
+}
+
+// DeleteUser elimina l'utente con tutti i suoi dati e poi i file degli allegati che il database
+// cancella a cascata, come DeleteTransaction.
+func (attachmentService *AttachmentService) DeleteUser(ctx context.Context, userID int64) error {
+	user, err := attachmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	attachments, err := attachmentService.attachmentRepo.GetUserAttachments(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if err := attachmentService.userRepo.DeleteUser(ctx, user); err != nil {
+		return err
+	}
+	removeBlobs(ctx, attachmentService.blobStore, attachmentKeys(attachments))
+	return nil
+}
+
+func (attachmentService *AttachmentService) getAttachment(ctx context.Context, userID int64, attachmentID int64) (dbgen.Attachment, error) {
+	user, err := attachmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dbgen.Attachment{}, err
+	}
+	return attachmentService.attachmentRepo.GetAttachmentByID(ctx, user, attachmentID)
+}
+
+// DetectContentType riconosce il tipo MIME dai primi byte del contenuto.
+func DetectContentType(content []byte) string {
+	contentType := http.DetectContentType(content)
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+// sanitizeFileName conserva solo il nome base del file, senza percorsi né caratteri di controllo.
+func sanitizeFileName(fileName string) (string, error) {
+	name := path.Base(strings.ReplaceAll(fileName, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == "/" {
+		return "", fmt.Errorf("%w: file name is required", apierr.ErrInvalidData)
+	}
+	for len(name) > maxFileNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name, nil
+}
+
+func newStorageKey(userID int64, transactionID int64) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("generate storage key: %w", err)
+	}
+	return fmt.Sprintf("users/%d/transactions/%d/%s", userID, transactionID, hex.EncodeToString(random)), nil
+}
+
+func attachmentKeys(attachments []dbgen.Attachment) []string {
+	keys := make([]string, 0, len(attachments)*2)
+	for _, attachment := range attachments {
+		keys = append(keys, attachment.StorageKey)
+		if attachment.ThumbnailKey.Valid {
+			keys = append(keys, attachment.ThumbnailKey.String)
+		}
+	}
+	return keys
+}
+
+// removeBlobs cancella i file indicati; gli errori vengono solo registrati nel log
+// perché a questo punto i riferimenti nel database non esistono più.
+func removeBlobs(ctx context.Context, blobStore storage.BlobStore, keys []string) {
+	for _, key := range keys {
+		if err := blobStore.Delete(ctx, key); err != nil {
+			log.Printf("attachments: cannot delete blob %s: %v", key, err)
+		}
+	}
+}
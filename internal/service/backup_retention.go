@@ -0,0 +1,65 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"koin/internal/model/dto"
+)
+
+// backupFilePattern riconosce i file dei backup automatici, con l'ora UTC di creazione nel
+// nome: koin-backup-20261019-020000.tar.gz.enc, koin-pgdump-20261019-020000.dump, ...
+var backupFilePattern = regexp.MustCompile(`^koin-(?:backup|pgdump)-(\d{8}-\d{6})\.`)
+
+const backupFileTimeLayout = "20060102-150405"
+
+// backupFileTime restituisce l'ora di creazione di un file di backup automatico.
+func backupFileTime(name string) (time.Time, bool) {
+	match := backupFilePattern.FindStringSubmatch(name)
+	if match == nil {
+		return time.Time{}, false
+	}
+	createdAt, err := time.Parse(backupFileTimeLayout, match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
+
+// retainedBackups restituisce i nomi dei file da tenere, dati i backup ordinati dal più
+// recente: l'ultimo di ciascuno dei keepDaily giorni, keepWeekly settimane ISO e keepMonthly
+// mesi più recenti che hanno backup, e in ogni caso il più recente.
+func retainedBackups(files []dto.BackupFileInfo, keepDaily, keepWeekly, keepMonthly int) map[string]bool {
+	keep := make(map[string]bool)
+	if len(files) > 0 {
+		keep[files[0].Name] = true
+	}
+
+	periods := []struct {
+		keep   int
+		period func(time.Time) string
+	}{
+		{keepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{keepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{keepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, policy := range periods {
+		seen := make(map[string]bool)
+		for _, file := range files {
+			if len(seen) >= policy.keep {
+				break
+			}
+			period := policy.period(file.CreatedAt)
+			if seen[period] {
+				continue
+			}
+			seen[period] = true
+			keep[file.Name] = true
+		}
+	}
+	return keep
+}
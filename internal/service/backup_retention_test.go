@@ -0,0 +1,103 @@
+package service
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"koin/internal/model/dto"
+)
+
+// backupFiles costruisce i file dei backup creati alle ore indicate (formato del nome dei
+// file), ordinati dal più recente come li passa applyRetention.
+func backupFiles(t *testing.T, stamps ...string) []dto.BackupFileInfo {
+	t.Helper()
+	files := make([]dto.BackupFileInfo, len(stamps))
+	for i, stamp := range stamps {
+		name := "koin-backup-" + stamp + ".tar.gz.enc"
+		createdAt, ok := backupFileTime(name)
+		if !ok {
+			t.Fatalf("invalid backup time %q", stamp)
+		}
+		files[i] = dto.BackupFileInfo{Name: name, CreatedAt: createdAt}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].CreatedAt.After(files[j].CreatedAt) })
+	return files
+}
+
+func TestRetainedBackups(t *testing.T) {
+	tests := []struct {
+		name                               string
+		stamps                             []string
+		keepDaily, keepWeekly, keepMonthly int
+		want                               []string
+	}{
+		{
+			name:   "always keeps the most recent",
+			stamps: []string{"20261019-020000", "20261018-020000"},
+			want:   []string{"20261019-020000"},
+		},
+		{
+			name:      "last backup of each day",
+			stamps:    []string{"20261019-140000", "20261019-020000", "20261018-020000", "20261017-230000", "20261017-020000", "20261016-020000"},
+			keepDaily: 3,
+			want:      []string{"20261019-140000", "20261018-020000", "20261017-230000"},
+		},
+		{
+			name:       "last backup of each ISO week",
+			stamps:     []string{"20261019-020000", "20261018-020000", "20261014-020000", "20261011-020000", "20261005-020000", "20261004-020000"},
+			keepWeekly: 3,
+			want:       []string{"20261019-020000", "20261018-020000", "20261011-020000"},
+		},
+		{
+			name:        "months without backups are skipped",
+			stamps:      []string{"20261010-020000", "20260705-020000", "20260701-020000", "20260303-020000", "20251231-020000"},
+			keepMonthly: 3,
+			want:        []string{"20261010-020000", "20260705-020000", "20260303-020000"},
+		},
+		{
+			name:        "policies overlap",
+			stamps:      []string{"20261019-020000", "20261018-020000", "20261012-020000", "20260930-020000", "20260901-020000", "20260815-020000"},
+			keepDaily:   2,
+			keepWeekly:  2,
+			keepMonthly: 2,
+			want:        []string{"20261019-020000", "20261018-020000", "20260930-020000"},
+		},
+		{
+			name: "no backups",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep := retainedBackups(backupFiles(t, tt.stamps...), tt.keepDaily, tt.keepWeekly, tt.keepMonthly)
+			if len(keep) != len(tt.want) {
+				t.Errorf("kept %d backups, want %d: %v", len(keep), len(tt.want), keep)
+			}
+			for _, stamp := range tt.want {
+				if !keep["koin-backup-"+stamp+".tar.gz.enc"] {
+					t.Errorf("backup %s was not kept", stamp)
+				}
+			}
+		})
+	}
+}
+
+func TestBackupFileTime(t *testing.T) {
+	tests := []struct {
+		name string
+		want time.Time
+		ok   bool
+	}{
+		{"koin-backup-20261019-020000.tar.gz.enc", time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC), true},
+		{"koin-pgdump-20261019-020000.dump", time.Date(2026, 10, 19, 2, 0, 0, 0, time.UTC), true},
+		{"koin-backup-20261319-020000.tar.gz.enc", time.Time{}, false},
+		{"koin-backup-latest.tar.gz.enc", time.Time{}, false},
+		{"notes.txt", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		createdAt, ok := backupFileTime(tt.name)
+		if ok != tt.ok || !createdAt.Equal(tt.want) {
+			t.Errorf("backupFileTime(%q) = %s, %v; want %s, %v", tt.name, createdAt, ok, tt.want, tt.ok)
+		}
+	}
+}
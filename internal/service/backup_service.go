@@ -0,0 +1,1251 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"koin/internal/backup"
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+// backupBatchSize è il numero di transazioni o movimenti letti dal database per volta durante
+// il backup.
+const backupBatchSize = 1000
+
+// BackupService produce e ripristina l'archivio di backup logico di un utente. schemaVersion
+// è la versione delle migrazioni applicate al database, scritta nell'archivio e richiesta
+// uguale al ripristino.
+type BackupService struct {
+	backupRepo    repo.BackupRepository
+	schemaVersion uint
+}
+
+func NewBackupService(backupRepo repo.BackupRepository, schemaVersion uint) *BackupService {
+	return &BackupService{
+		backupRepo:    backupRepo,
+		schemaVersion: schemaVersion,
+	}
+}
+
+// Backup prepara l'archivio dell'utente: come per le esportazioni transazioni e movimenti
+// vengono letti a lotti mentre il contenuto è letto, quindi ctx deve restare valido fino alla
+// fine. Tutte le letture avvengono nello stesso snapshot del database, chiuso a fine lettura
+// o chiudendo il contenuto, che è un io.ReadCloser.
+func (backupService *BackupService) Backup(ctx context.Context, userID int64, options dto.BackupOptions, now time.Time) (dto.ExportFile, error) {
+	snapshot, err := backupService.backupRepo.Snapshot(ctx)
+	if err != nil {
+		return dto.ExportFile{}, err
+	}
+	user, err := snapshot.GetUser(ctx, userID)
+	if err != nil {
+		_ = snapshot.Close()
+		return dto.ExportFile{}, err
+	}
+
+	content, err := backup.NewStream(ctx,
+		backup.Header{SchemaVersion: backupService.schemaVersion, CreatedAt: now.UTC()},
+		snapshot,
+		writeProfile(snapshot, user, options),
+		writeSetup(snapshot, user),
+		writeTransactions(snapshot, user),
+		writeEntries(snapshot, user),
+		writeLinks(snapshot, user),
+		writeSplits(snapshot, user),
+	)
+	if err != nil {
+		return dto.ExportFile{}, err
+	}
+	return dto.ExportFile{
+		Filename:    fmt.Sprintf("koin-backup-%d-%s.ndjson", user.ID, now.Format("2006-01-02")),
+		ContentType: backup.ContentType,
+		Content:     content,
+	}, nil
+}
+
+// writeProfile scrive in un solo passo utente, impostazioni e anagrafiche.
+func writeProfile(snapshot repo.BackupSnapshot, user dbgen.User, options dto.BackupOptions) backup.StepFunc {
+	return func(ctx context.Context, writer *backup.Writer) (bool, error) {
+		archived := backup.User{Email: user.Email, CreatedAt: user.CreatedAt}
+		if options.IncludePassword {
+			archived.PasswordHash = user.PasswordHash
+		}
+		if err := writer.Write(backup.RecordUser, archived); err != nil {
+			return false, err
+		}
+
+		notifications, err := snapshot.GetNotificationSettings(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		settings := backup.NotificationSettings{
+			WebhookURL:          notifications.WebhookURL,
+			LowBalanceThreshold: notifications.LowBalanceThreshold,
+		}
+		for _, preference := range notifications.Preferences {
+			settings.Preferences = append(settings.Preferences, backup.NotificationPreference{
+				EventKind: string(preference.Kind),
+				Email:     preference.Email,
+				Webhook:   preference.Webhook,
+				InApp:     preference.InApp,
+			})
+		}
+		if err := writer.Write(backup.RecordNotificationSettings, settings); err != nil {
+			return false, err
+		}
+
+		digest, err := snapshot.GetDigestSettings(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		err = writer.Write(backup.RecordDigestSettings, backup.DigestSettings{
+			Weekly:  digest.Weekly,
+			Monthly: digest.Monthly,
+			Weekday: int(digest.Weekday),
+		})
+		if err != nil {
+			return false, err
+		}
+
+		accounts, err := snapshot.GetAccounts(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, account := range accounts {
+			err := writer.Write(backup.RecordAccount, backup.Account{
+				ID:             account.ID,
+				Name:           account.Name,
+				Currency:       account.Currency,
+				InitialBalance: account.InitialBalance,
+				OverdraftLimit: account.OverdraftLimit,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		categories, err := snapshot.GetCategories(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, category := range categories {
+			record := backup.Category{
+				ID:            category.ID,
+				Name:          category.Name,
+				Type:          category.Type,
+				ParentID:      nullInt64Pointer(category.ParentID),
+				MonthlyBudget: nullInt64Pointer(category.MonthlyBudget),
+				DeductionCode: nullStringPointer(category.DeductionCode),
+			}
+			if category.ArchivedAt.Valid {
+				record.ArchivedAt = &category.ArchivedAt.Time
+			}
+			if err := writer.Write(backup.RecordCategory, record); err != nil {
+				return false, err
+			}
+		}
+
+		payees, err := snapshot.GetPayees(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, payee := range payees {
+			err := writer.Write(backup.RecordPayee, backup.Payee{
+				ID:                payee.ID,
+				Name:              payee.Name,
+				DefaultCategoryID: nullInt64Pointer(payee.DefaultCategoryID),
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		aliases, err := snapshot.GetPayeeAliases(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, alias := range aliases {
+			err := writer.Write(backup.RecordPayeeAlias, backup.PayeeAlias{PayeeID: alias.PayeeID, Pattern: alias.Pattern})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		tags, err := snapshot.GetTags(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, tag := range tags {
+			if err := writer.Write(backup.RecordTag, backup.Tag{ID: tag.ID, Name: tag.Name}); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// writeSetup scrive i dati dei conti e dei titoli che non dipendono dalle transazioni.
+func writeSetup(snapshot repo.BackupSnapshot, user dbgen.User) backup.StepFunc {
+	return func(ctx context.Context, writer *backup.Writer) (bool, error) {
+		creditCards, err := snapshot.GetCreditCards(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, creditCard := range creditCards {
+			err := writer.Write(backup.RecordCreditCard, backup.CreditCard{
+				AccountID:        creditCard.AccountID,
+				PaymentAccountID: creditCard.PaymentAccountID,
+				ClosingDay:       creditCard.ClosingDay,
+				DueDay:           creditCard.DueDay,
+				AutoPay:          creditCard.AutoPay,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		loans, err := snapshot.GetLoans(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, loan := range loans {
+			err := writer.Write(backup.RecordLoan, backup.Loan{
+				AccountID:        loan.AccountID,
+				PaymentAccountID: loan.PaymentAccountID,
+				Principal:        loan.Principal,
+				AnnualRateBps:    loan.AnnualRateBps,
+				TermMonths:       loan.TermMonths,
+				StartDate:        loan.StartDate,
+				Method:           loan.Method,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		securities, err := snapshot.GetSecurities(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, security := range securities {
+			err := writer.Write(backup.RecordSecurity, backup.Security{
+				ID:       security.ID,
+				ISIN:     nullStringPointer(security.Isin),
+				Ticker:   nullStringPointer(security.Ticker),
+				Name:     security.Name,
+				Currency: security.Currency,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		prices, err := snapshot.GetSecurityPrices(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, price := range prices {
+			err := writer.Write(backup.RecordSecurityPrice, backup.SecurityPrice{
+				SecurityID: price.SecurityID,
+				Date:       price.PriceDate,
+				Price:      price.Price,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		recurring, err := snapshot.GetRecurringTransactions(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, item := range recurring {
+			record := backup.RecurringTransaction{
+				AccountID:   item.AccountID,
+				CategoryID:  item.CategoryID,
+				Amount:      item.Amount,
+				Description: item.Description,
+				Frequency:   item.Frequency,
+				RepeatEvery: item.RepeatEvery,
+				StartDate:   item.StartDate,
+			}
+			if item.EndDate.Valid {
+				record.EndDate = &item.EndDate.Time
+			}
+			if err := writer.Write(backup.RecordRecurringTransaction, record); err != nil {
+				return false, err
+			}
+		}
+
+		reconciliations, err := snapshot.GetReconciliations(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, reconciliation := range reconciliations {
+			record := backup.Reconciliation{
+				ID:               reconciliation.ID,
+				AccountID:        reconciliation.AccountID,
+				StatementDate:    reconciliation.StatementDate,
+				StatementBalance: reconciliation.StatementBalance,
+				Status:           reconciliation.Status,
+			}
+			if reconciliation.CompletedAt.Valid {
+				record.CompletedAt = &reconciliation.CompletedAt.Time
+			}
+			if err := writer.Write(backup.RecordReconciliation, record); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+}
+
+func writeTransactions(snapshot repo.BackupSnapshot, user dbgen.User) backup.StepFunc {
+	var afterID int64
+	return func(ctx context.Context, writer *backup.Writer) (bool, error) {
+		transactions, err := snapshot.GetTransactions(ctx, user, afterID, backupBatchSize)
+		if err != nil {
+			return false, err
+		}
+		for _, transaction := range transactions {
+			err := writer.Write(backup.RecordTransaction, backup.Transaction{
+				ID:         transaction.ID,
+				OccurredAt: transaction.OccurredAt,
+			})
+			if err != nil {
+				return false, err
+			}
+			afterID = transaction.ID
+		}
+		return len(transactions) == backupBatchSize, nil
+	}
+}
+
+func writeEntries(snapshot repo.BackupSnapshot, user dbgen.User) backup.StepFunc {
+	var afterID int64
+	return func(ctx context.Context, writer *backup.Writer) (bool, error) {
+		entries, err := snapshot.GetEntries(ctx, user, afterID, backupBatchSize)
+		if err != nil {
+			return false, err
+		}
+		for _, entry := range entries {
+			err := writer.Write(backup.RecordEntry, backup.Entry{
+				ID:               entry.ID,
+				TransactionID:    entry.TransactionID,
+				AccountID:        entry.AccountID,
+				CategoryID:       nullInt64Pointer(entry.CategoryID),
+				PayeeID:          nullInt64Pointer(entry.PayeeID),
+				Amount:           entry.Amount,
+				Description:      nullStringPointer(entry.Description),
+				Status:           entry.Status,
+				DeductionCode:    nullStringPointer(entry.DeductionCode),
+				ReconciliationID: nullInt64Pointer(entry.ReconciliationID),
+				TagIDs:           entry.TagIds,
+			})
+			if err != nil {
+				return false, err
+			}
+			afterID = entry.ID
+		}
+		return len(entries) == backupBatchSize, nil
+	}
+}
+
+// writeLinks scrive i dati collegati a transazioni e movimenti, dopo di essi.
+func writeLinks(snapshot repo.BackupSnapshot, user dbgen.User) backup.StepFunc {
+	return func(ctx context.Context, writer *backup.Writer) (bool, error) {
+		installments, err := snapshot.GetLoanInstallments(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, installment := range installments {
+			err := writer.Write(backup.RecordLoanInstallment, backup.LoanInstallment{
+				AccountID:     installment.AccountID,
+				Number:        installment.Number,
+				DueDate:       installment.DueDate,
+				Principal:     installment.Principal,
+				Interest:      installment.Interest,
+				TransactionID: nullInt64Pointer(installment.TransactionID),
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		trades, err := snapshot.GetInvestmentTrades(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, trade := range trades {
+			err := writer.Write(backup.RecordInvestmentTrade, backup.InvestmentTrade{
+				AccountID:     trade.AccountID,
+				SecurityID:    trade.SecurityID,
+				TransactionID: trade.TransactionID,
+				Kind:          trade.Kind,
+				TradeDate:     trade.TradeDate,
+				Quantity:      trade.Quantity,
+				Price:         trade.Price,
+				Fees:          trade.Fees,
+				Amount:        trade.Amount,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		reimbursables, err := snapshot.GetReimbursables(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, reimbursable := range reimbursables {
+			err := writer.Write(backup.RecordReimbursable, backup.Reimbursable{EntryID: reimbursable.EntryID, Party: reimbursable.Party})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		reimbursements, err := snapshot.GetReimbursements(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, reimbursement := range reimbursements {
+			err := writer.Write(backup.RecordReimbursement, backup.Reimbursement{
+				ExpenseEntryID: reimbursement.ExpenseEntryID,
+				IncomeEntryID:  reimbursement.IncomeEntryID,
+				Amount:         reimbursement.Amount,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		attachments, err := snapshot.GetAttachments(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, attachment := range attachments {
+			err := writer.Write(backup.RecordAttachment, backup.Attachment{
+				TransactionID: attachment.TransactionID,
+				FileName:      attachment.FileName,
+				ContentType:   attachment.ContentType,
+				SizeBytes:     attachment.SizeBytes,
+				StorageKey:    attachment.StorageKey,
+				ThumbnailKey:  nullStringPointer(attachment.ThumbnailKey),
+				CreatedAt:     attachment.CreatedAt,
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// writeSplits scrive i gruppi di spesa creati dall'utente, con le quote dentro le spese.
+func writeSplits(snapshot repo.BackupSnapshot, user dbgen.User) backup.StepFunc {
+	return func(ctx context.Context, writer *backup.Writer) (bool, error) {
+		groups, err := snapshot.GetSplitGroups(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, group := range groups {
+			err := writer.Write(backup.RecordSplitGroup, backup.SplitGroup{ID: group.ID, Name: group.Name, Currency: group.Currency})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		members, err := snapshot.GetSplitMembers(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, member := range members {
+			record := backup.SplitMember{ID: member.ID, GroupID: member.GroupID, Name: member.Name}
+			switch {
+			case member.UserID.Valid && member.UserID.Int64 == user.ID:
+				record.Self = true
+			case member.UserID.Valid:
+				record.Email = &member.Email
+			}
+			if err := writer.Write(backup.RecordSplitMember, record); err != nil {
+				return false, err
+			}
+		}
+
+		shares, err := snapshot.GetSplitShares(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		sharesByExpense := make(map[int64][]backup.SplitShare)
+		for _, share := range shares {
+			record := backup.SplitShare{MemberID: share.MemberID, Amount: share.Amount}
+			if share.Shares.Valid {
+				record.Shares = &share.Shares.Int32
+			}
+			sharesByExpense[share.ExpenseID] = append(sharesByExpense[share.ExpenseID], record)
+		}
+
+		expenses, err := snapshot.GetSplitExpenses(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, expense := range expenses {
+			err := writer.Write(backup.RecordSplitExpense, backup.SplitExpense{
+				GroupID:       expense.GroupID,
+				TransactionID: expense.TransactionID,
+				PaidBy:        expense.PaidBy,
+				Amount:        expense.Amount,
+				Method:        expense.Method,
+				Shares:        sharesByExpense[expense.ID],
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+
+		settlements, err := snapshot.GetSplitSettlements(ctx, user)
+		if err != nil {
+			return false, err
+		}
+		for _, settlement := range settlements {
+			err := writer.Write(backup.RecordSplitSettlement, backup.SplitSettlement{
+				GroupID:           settlement.GroupID,
+				FromMemberID:      settlement.FromMemberID,
+				ToMemberID:        settlement.ToMemberID,
+				Amount:            settlement.Amount,
+				SettledAt:         settlement.SettledAt,
+				FromTransactionID: nullInt64Pointer(settlement.FromTransactionID),
+				ToTransactionID:   nullInt64Pointer(settlement.ToTransactionID),
+			})
+			if err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+}
+
+// Restore carica l'archivio in un'unica transazione, confermata solo se l'archivio è completo
+// e il checksum corrisponde. L'utente viene creato con la password dell'archivio se non
+// esiste, o senza password se l'archivio non la contiene; se esiste deve essere ancora vuoto.
+func (backupService *BackupService) Restore(ctx context.Context, archive io.Reader, options dto.RestoreOptions) (dto.RestoreResult, error) {
+	reader, err := backup.NewReader(archive)
+	if err != nil {
+		return dto.RestoreResult{}, archiveError(err)
+	}
+	if version := reader.Header().SchemaVersion; version != backupService.schemaVersion {
+		return dto.RestoreResult{}, fmt.Errorf("%w: archive schema version %d, database schema version %d",
+			apierr.ErrInvalidData, version, backupService.schemaVersion)
+	}
+
+	var result dto.RestoreResult
+	err = backupService.backupRepo.Restore(ctx, func(restorer repo.BackupRestorer) error {
+		restore := &archiveRestore{
+			restorer:        restorer,
+			accounts:        make(map[int64]int64),
+			categories:      make(map[int64]int64),
+			payees:          make(map[int64]int64),
+			tags:            make(map[int64]int64),
+			transactions:    make(map[int64]int64),
+			entries:         make(map[int64]int64),
+			securities:      make(map[int64]int64),
+			reconciliations: make(map[int64]int64),
+			splitGroups:     make(map[int64]int64),
+			splitMembers:    make(map[int64]int64),
+			parents:         make(map[int64]int64),
+			result:          dto.RestoreResult{Counts: make(map[string]int64)},
+		}
+		if err := restore.run(ctx, reader, options); err != nil {
+			return err
+		}
+		result = restore.result
+		return nil
+	})
+	if err != nil {
+		return dto.RestoreResult{}, archiveError(err)
+	}
+	return result, nil
+}
+
+// archiveRestore tiene la corrispondenza tra gli ID dell'archivio e quelli assegnati dal
+// ripristino.
+type archiveRestore struct {
+	restorer        repo.BackupRestorer
+	user            dbgen.User
+	accounts        map[int64]int64
+	categories      map[int64]int64
+	payees          map[int64]int64
+	tags            map[int64]int64
+	transactions    map[int64]int64
+	entries         map[int64]int64
+	securities      map[int64]int64
+	reconciliations map[int64]int64
+	splitGroups     map[int64]int64
+	splitMembers    map[int64]int64
+	// parents associa ogni categoria ripristinata all'ID del padre nell'archivio: i padri
+	// vengono collegati alla fine, quando tutte le categorie esistono.
+	parents map[int64]int64
+	result  dto.RestoreResult
+}
+
+func (restore *archiveRestore) run(ctx context.Context, reader *backup.Reader, options dto.RestoreOptions) error {
+	record, err := reader.Next()
+	if err != nil {
+		return err
+	}
+	if record.Type != backup.RecordUser {
+		return fmt.Errorf("%w: the archive does not start with the user", backup.ErrInvalidArchive)
+	}
+	if err := restore.restoreUser(ctx, record, options); err != nil {
+		return err
+	}
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := restore.restoreRecord(ctx, record); err != nil {
+			return err
+		}
+		restore.result.Counts[record.Type]++
+	}
+
+	for categoryID, parentID := range restore.parents {
+		newParentID, ok := restore.categories[parentID]
+		if !ok {
+			continue
+		}
+		if err := restore.restorer.SetCategoryParent(ctx, categoryID, newParentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (restore *archiveRestore) restoreUser(ctx context.Context, record backup.Record, options dto.RestoreOptions) error {
+	var archived backup.User
+	if err := record.Decode(&archived); err != nil {
+		return err
+	}
+	email := archived.Email
+	if options.Email != nil {
+		email = *options.Email
+	}
+
+	user, err := restore.restorer.GetUser(ctx, email)
+	switch {
+	case errors.Is(err, apierr.ErrUserNotFound):
+		user, err = restore.restorer.CreateUser(ctx, email, archived.PasswordHash)
+		if err != nil {
+			return err
+		}
+		restore.result.Created = true
+		restore.result.PasswordResetRequired = archived.PasswordHash == ""
+	case err != nil:
+		return err
+	default:
+		hasData, err := restore.restorer.HasData(ctx, user)
+		if err != nil {
+			return err
+		}
+		if hasData {
+			return fmt.Errorf("%w: user %s already has data", apierr.ErrConflict, email)
+		}
+	}
+
+	restore.user = user
+	restore.result.UserID = user.ID
+	restore.result.Email = user.Email
+	return nil
+}
+
+// restoreRecord scrive un record: i riferimenti a record non presenti nell'archivio, ad
+// esempio a categorie condivise da altri utenti della household, vengono azzerati, tranne
+// conto e transazione dei movimenti che sono obbligatori.
+func (restore *archiveRestore) restoreRecord(ctx context.Context, record backup.Record) error {
+	switch record.Type {
+	case backup.RecordNotificationSettings:
+		var archived backup.NotificationSettings
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		settings := dto.NotificationSettings{
+			UserID:              restore.user.ID,
+			WebhookURL:          archived.WebhookURL,
+			LowBalanceThreshold: archived.LowBalanceThreshold,
+		}
+		for _, preference := range archived.Preferences {
+			settings.Preferences = append(settings.Preferences, dto.NotificationPreference{
+				Kind:    dto.NotificationKind(preference.EventKind),
+				Email:   preference.Email,
+				Webhook: preference.Webhook,
+				InApp:   preference.InApp,
+			})
+		}
+		return restore.restorer.SaveNotificationSettings(ctx, settings)
+
+	case backup.RecordDigestSettings:
+		var archived backup.DigestSettings
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		return restore.restorer.SaveDigestSettings(ctx, dto.DigestSettings{
+			UserID:  restore.user.ID,
+			Weekly:  archived.Weekly,
+			Monthly: archived.Monthly,
+			Weekday: time.Weekday(archived.Weekday),
+		})
+
+	case backup.RecordAccount:
+		var archived backup.Account
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		id, err := restore.restorer.CreateAccount(ctx, dbgen.Account{
+			UserID:         restore.user.ID,
+			Name:           archived.Name,
+			Currency:       archived.Currency,
+			InitialBalance: archived.InitialBalance,
+			OverdraftLimit: archived.OverdraftLimit,
+		})
+		if err != nil {
+			return err
+		}
+		restore.accounts[archived.ID] = id
+
+	case backup.RecordCategory:
+		var archived backup.Category
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		category := dbgen.Category{
+			UserID:        restore.user.ID,
+			Name:          archived.Name,
+			Type:          archived.Type,
+			MonthlyBudget: nullInt64(archived.MonthlyBudget),
+			DeductionCode: nullString(archived.DeductionCode),
+		}
+		if archived.ArchivedAt != nil {
+			category.ArchivedAt = sql.NullTime{Time: *archived.ArchivedAt, Valid: true}
+		}
+		id, err := restore.restorer.CreateCategory(ctx, category)
+		if err != nil {
+			return err
+		}
+		restore.categories[archived.ID] = id
+		if archived.ParentID != nil {
+			restore.parents[id] = *archived.ParentID
+		}
+
+	case backup.RecordPayee:
+		var archived backup.Payee
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		id, err := restore.restorer.CreatePayee(ctx, dbgen.Payee{
+			UserID:            restore.user.ID,
+			Name:              archived.Name,
+			DefaultCategoryID: mappedID(restore.categories, archived.DefaultCategoryID),
+		})
+		if err != nil {
+			return err
+		}
+		restore.payees[archived.ID] = id
+
+	case backup.RecordPayeeAlias:
+		var archived backup.PayeeAlias
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		payeeID, ok := restore.payees[archived.PayeeID]
+		if !ok {
+			return fmt.Errorf("%w: alias %q of unknown payee %d", backup.ErrInvalidArchive, archived.Pattern, archived.PayeeID)
+		}
+		return restore.restorer.CreatePayeeAlias(ctx, dbgen.PayeeAlias{PayeeID: payeeID, Pattern: archived.Pattern})
+
+	case backup.RecordTag:
+		var archived backup.Tag
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		id, err := restore.restorer.CreateTag(ctx, dbgen.Tag{UserID: restore.user.ID, Name: archived.Name})
+		if err != nil {
+			return err
+		}
+		restore.tags[archived.ID] = id
+
+	case backup.RecordTransaction:
+		var archived backup.Transaction
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		id, err := restore.restorer.CreateTransaction(ctx, dbgen.Transaction{
+			UserID:     restore.user.ID,
+			OccurredAt: archived.OccurredAt,
+		})
+		if err != nil {
+			return err
+		}
+		restore.transactions[archived.ID] = id
+
+	case backup.RecordEntry:
+		var archived backup.Entry
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		transactionID, ok := restore.transactions[archived.TransactionID]
+		if !ok {
+			return fmt.Errorf("%w: entry %d of unknown transaction %d", backup.ErrInvalidArchive, archived.ID, archived.TransactionID)
+		}
+		accountID, ok := restore.accounts[archived.AccountID]
+		if !ok {
+			return fmt.Errorf("%w: entry %d of unknown account %d", backup.ErrInvalidArchive, archived.ID, archived.AccountID)
+		}
+		var tagIDs []int64
+		for _, tagID := range archived.TagIDs {
+			if id, ok := restore.tags[tagID]; ok {
+				tagIDs = append(tagIDs, id)
+			}
+		}
+		id, err := restore.restorer.CreateEntry(ctx, dbgen.TransactionEntry{
+			TransactionID:    transactionID,
+			AccountID:        accountID,
+			CategoryID:       mappedID(restore.categories, archived.CategoryID),
+			Amount:           archived.Amount,
+			Description:      nullString(archived.Description),
+			PayeeID:          mappedID(restore.payees, archived.PayeeID),
+			Status:           archived.Status,
+			DeductionCode:    nullString(archived.DeductionCode),
+			ReconciliationID: mappedID(restore.reconciliations, archived.ReconciliationID),
+		}, tagIDs)
+		if err != nil {
+			return err
+		}
+		restore.entries[archived.ID] = id
+
+	default:
+		return restore.restoreLinkedRecord(ctx, record)
+	}
+	return nil
+}
+
+// restoreLinkedRecord scrive i record aggiunti con la versione 2 dell'archivio: come per i
+// movimenti, i riferimenti obbligatori devono essere nell'archivio.
+func (restore *archiveRestore) restoreLinkedRecord(ctx context.Context, record backup.Record) error {
+	switch record.Type {
+	case backup.RecordCreditCard:
+		var archived backup.CreditCard
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		accountID, paymentAccountID, err := restore.accountPair(record.Type, archived.AccountID, archived.PaymentAccountID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateCreditCard(ctx, dbgen.CreditCard{
+			AccountID:        accountID,
+			PaymentAccountID: paymentAccountID,
+			ClosingDay:       archived.ClosingDay,
+			DueDay:           archived.DueDay,
+			AutoPay:          archived.AutoPay,
+		})
+
+	case backup.RecordLoan:
+		var archived backup.Loan
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		accountID, paymentAccountID, err := restore.accountPair(record.Type, archived.AccountID, archived.PaymentAccountID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateLoan(ctx, dbgen.Loan{
+			AccountID:        accountID,
+			PaymentAccountID: paymentAccountID,
+			Principal:        archived.Principal,
+			AnnualRateBps:    archived.AnnualRateBps,
+			TermMonths:       archived.TermMonths,
+			StartDate:        archived.StartDate,
+			Method:           archived.Method,
+		})
+
+	case backup.RecordLoanInstallment:
+		var archived backup.LoanInstallment
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		accountID, err := restore.required(restore.accounts, record.Type, "account", archived.AccountID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateLoanInstallment(ctx, dbgen.LoanInstallment{
+			AccountID:     accountID,
+			Number:        archived.Number,
+			DueDate:       archived.DueDate,
+			Principal:     archived.Principal,
+			Interest:      archived.Interest,
+			TransactionID: mappedID(restore.transactions, archived.TransactionID),
+		})
+
+	case backup.RecordSecurity:
+		var archived backup.Security
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		id, err := restore.restorer.CreateSecurity(ctx, dbgen.Security{
+			UserID:   restore.user.ID,
+			Isin:     nullString(archived.ISIN),
+			Ticker:   nullString(archived.Ticker),
+			Name:     archived.Name,
+			Currency: archived.Currency,
+		})
+		if err != nil {
+			return err
+		}
+		restore.securities[archived.ID] = id
+
+	case backup.RecordSecurityPrice:
+		var archived backup.SecurityPrice
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		securityID, err := restore.required(restore.securities, record.Type, "security", archived.SecurityID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateSecurityPrice(ctx, dbgen.SecurityPrice{
+			SecurityID: securityID,
+			PriceDate:  archived.Date,
+			Price:      archived.Price,
+		})
+
+	case backup.RecordInvestmentTrade:
+		var archived backup.InvestmentTrade
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		accountID, err := restore.required(restore.accounts, record.Type, "account", archived.AccountID)
+		if err != nil {
+			return err
+		}
+		securityID, err := restore.required(restore.securities, record.Type, "security", archived.SecurityID)
+		if err != nil {
+			return err
+		}
+		transactionID, err := restore.required(restore.transactions, record.Type, "transaction", archived.TransactionID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateInvestmentTrade(ctx, dbgen.InvestmentTrade{
+			AccountID:     accountID,
+			SecurityID:    securityID,
+			TransactionID: transactionID,
+			Kind:          archived.Kind,
+			TradeDate:     archived.TradeDate,
+			Quantity:      archived.Quantity,
+			Price:         archived.Price,
+			Fees:          archived.Fees,
+			Amount:        archived.Amount,
+		})
+
+	case backup.RecordRecurringTransaction:
+		var archived backup.RecurringTransaction
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		accountID, err := restore.required(restore.accounts, record.Type, "account", archived.AccountID)
+		if err != nil {
+			return err
+		}
+		categoryID, err := restore.required(restore.categories, record.Type, "category", archived.CategoryID)
+		if err != nil {
+			return err
+		}
+		recurring := dbgen.RecurringTransaction{
+			UserID:      restore.user.ID,
+			AccountID:   accountID,
+			CategoryID:  categoryID,
+			Amount:      archived.Amount,
+			Description: archived.Description,
+			Frequency:   archived.Frequency,
+			RepeatEvery: archived.RepeatEvery,
+			StartDate:   archived.StartDate,
+		}
+		if archived.EndDate != nil {
+			recurring.EndDate = sql.NullTime{Time: *archived.EndDate, Valid: true}
+		}
+		return restore.restorer.CreateRecurringTransaction(ctx, recurring)
+
+	case backup.RecordReconciliation:
+		var archived backup.Reconciliation
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		accountID, err := restore.required(restore.accounts, record.Type, "account", archived.AccountID)
+		if err != nil {
+			return err
+		}
+		reconciliation := dbgen.Reconciliation{
+			AccountID:        accountID,
+			StatementDate:    archived.StatementDate,
+			StatementBalance: archived.StatementBalance,
+			Status:           archived.Status,
+		}
+		if archived.CompletedAt != nil {
+			reconciliation.CompletedAt = sql.NullTime{Time: *archived.CompletedAt, Valid: true}
+		}
+		id, err := restore.restorer.CreateReconciliation(ctx, reconciliation)
+		if err != nil {
+			return err
+		}
+		restore.reconciliations[archived.ID] = id
+
+	case backup.RecordReimbursable:
+		var archived backup.Reimbursable
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		entryID, err := restore.required(restore.entries, record.Type, "entry", archived.EntryID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateReimbursable(ctx, dbgen.Reimbursable{
+			EntryID: entryID,
+			UserID:  restore.user.ID,
+			Party:   archived.Party,
+		})
+
+	case backup.RecordReimbursement:
+		var archived backup.Reimbursement
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		expenseEntryID, err := restore.required(restore.entries, record.Type, "entry", archived.ExpenseEntryID)
+		if err != nil {
+			return err
+		}
+		incomeEntryID, err := restore.required(restore.entries, record.Type, "entry", archived.IncomeEntryID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateReimbursement(ctx, dbgen.Reimbursement{
+			ExpenseEntryID: expenseEntryID,
+			IncomeEntryID:  incomeEntryID,
+			Amount:         archived.Amount,
+		})
+
+	case backup.RecordAttachment:
+		var archived backup.Attachment
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		transactionID, err := restore.required(restore.transactions, record.Type, "transaction", archived.TransactionID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateAttachment(ctx, dbgen.Attachment{
+			UserID:        restore.user.ID,
+			TransactionID: transactionID,
+			FileName:      archived.FileName,
+			ContentType:   archived.ContentType,
+			SizeBytes:     archived.SizeBytes,
+			StorageKey:    archived.StorageKey,
+			ThumbnailKey:  nullString(archived.ThumbnailKey),
+			CreatedAt:     archived.CreatedAt,
+		})
+
+	case backup.RecordSplitGroup:
+		var archived backup.SplitGroup
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		id, err := restore.restorer.CreateSplitGroup(ctx, dbgen.SplitGroup{
+			Name:      archived.Name,
+			Currency:  archived.Currency,
+			CreatedBy: restore.user.ID,
+		})
+		if err != nil {
+			return err
+		}
+		restore.splitGroups[archived.ID] = id
+
+	case backup.RecordSplitMember:
+		var archived backup.SplitMember
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		groupID, err := restore.required(restore.splitGroups, record.Type, "split group", archived.GroupID)
+		if err != nil {
+			return err
+		}
+		member := dbgen.SplitMember{GroupID: groupID, Name: archived.Name}
+		switch {
+		case archived.Self:
+			member.UserID = sql.NullInt64{Int64: restore.user.ID, Valid: true}
+		case archived.Email != nil:
+			// Gli altri utenti vengono ricollegati solo se esistono in questa istanza
+			user, err := restore.restorer.GetUser(ctx, *archived.Email)
+			if err != nil && !errors.Is(err, apierr.ErrUserNotFound) {
+				return err
+			}
+			if err == nil && user.ID != restore.user.ID {
+				member.UserID = sql.NullInt64{Int64: user.ID, Valid: true}
+			}
+		}
+		id, err := restore.restorer.CreateSplitMember(ctx, member)
+		if err != nil {
+			return err
+		}
+		restore.splitMembers[archived.ID] = id
+
+	case backup.RecordSplitExpense:
+		var archived backup.SplitExpense
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		groupID, err := restore.required(restore.splitGroups, record.Type, "split group", archived.GroupID)
+		if err != nil {
+			return err
+		}
+		transactionID, err := restore.required(restore.transactions, record.Type, "transaction", archived.TransactionID)
+		if err != nil {
+			return err
+		}
+		paidBy, err := restore.required(restore.splitMembers, record.Type, "split member", archived.PaidBy)
+		if err != nil {
+			return err
+		}
+		shares := make([]dbgen.SplitShare, 0, len(archived.Shares))
+		for _, share := range archived.Shares {
+			memberID, err := restore.required(restore.splitMembers, record.Type, "split member", share.MemberID)
+			if err != nil {
+				return err
+			}
+			restored := dbgen.SplitShare{MemberID: memberID, Amount: share.Amount}
+			if share.Shares != nil {
+				restored.Shares = sql.NullInt32{Int32: *share.Shares, Valid: true}
+			}
+			shares = append(shares, restored)
+		}
+		return restore.restorer.CreateSplitExpense(ctx, dbgen.SplitExpense{
+			GroupID:       groupID,
+			TransactionID: transactionID,
+			PaidBy:        paidBy,
+			Amount:        archived.Amount,
+			Method:        archived.Method,
+		}, shares)
+
+	case backup.RecordSplitSettlement:
+		var archived backup.SplitSettlement
+		if err := record.Decode(&archived); err != nil {
+			return err
+		}
+		groupID, err := restore.required(restore.splitGroups, record.Type, "split group", archived.GroupID)
+		if err != nil {
+			return err
+		}
+		fromMemberID, err := restore.required(restore.splitMembers, record.Type, "split member", archived.FromMemberID)
+		if err != nil {
+			return err
+		}
+		toMemberID, err := restore.required(restore.splitMembers, record.Type, "split member", archived.ToMemberID)
+		if err != nil {
+			return err
+		}
+		return restore.restorer.CreateSplitSettlement(ctx, dbgen.SplitSettlement{
+			GroupID:           groupID,
+			FromMemberID:      fromMemberID,
+			ToMemberID:        toMemberID,
+			Amount:            archived.Amount,
+			SettledAt:         archived.SettledAt,
+			FromTransactionID: mappedID(restore.transactions, archived.FromTransactionID),
+			ToTransactionID:   mappedID(restore.transactions, archived.ToTransactionID),
+		})
+
+	default:
+		return fmt.Errorf("%w: unknown record type %q", backup.ErrInvalidArchive, record.Type)
+	}
+	return nil
+}
+
+// required restituisce il nuovo ID di un riferimento obbligatorio del record.
+func (restore *archiveRestore) required(ids map[int64]int64, recordType, target string, id int64) (int64, error) {
+	newID, ok := ids[id]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s of unknown %s %d", backup.ErrInvalidArchive, recordType, target, id)
+	}
+	return newID, nil
+}
+
+// accountPair restituisce i nuovi ID del conto di una carta o di un prestito e del conto da
+// cui viene pagato.
+func (restore *archiveRestore) accountPair(recordType string, accountID, paymentAccountID int64) (int64, int64, error) {
+	newAccountID, err := restore.required(restore.accounts, recordType, "account", accountID)
+	if err != nil {
+		return 0, 0, err
+	}
+	newPaymentAccountID, err := restore.required(restore.accounts, recordType, "account", paymentAccountID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return newAccountID, newPaymentAccountID, nil
+}
+
+// archiveError riporta gli archivi illeggibili o alterati come dati non validi.
+func archiveError(err error) error {
+	if errors.Is(err, backup.ErrInvalidArchive) || errors.Is(err, backup.ErrChecksum) {
+		return fmt.Errorf("%w: %w", apierr.ErrInvalidData, err)
+	}
+	return err
+}
+
+// mappedID restituisce il nuovo ID del record dell'archivio, nullo se il record non è stato
+// ripristinato.
+func mappedID(ids map[int64]int64, id *int64) sql.NullInt64 {
+	if id == nil {
+		return sql.NullInt64{}
+	}
+	newID, ok := ids[*id]
+	if !ok {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: newID, Valid: true}
+}
+
+func nullInt64Pointer(value sql.NullInt64) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+func nullStringPointer(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	return &value.String
+}
+
+func nullInt64(value *int64) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *value, Valid: true}
+}
+
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
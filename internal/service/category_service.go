@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+	"strings"
+)
+
+// CategoryPathSeparator separa i livelli nel percorso di una categoria (es. "Casa > Bollette > Luce").
+const CategoryPathSeparator = " > "
+
+type CategoryService struct {
+	userRepo     repo.UserRepository
+	categoryRepo repo.CategoryRepository
+}
+
+func NewCategoryService(
+	userRepo repo.UserRepository,
+	categoryRepo repo.CategoryRepository,
+) *CategoryService {
+	return &CategoryService{
+		userRepo:     userRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+func (categoryService *CategoryService) CreateCategory(ctx context.Context, createCategoryDto dto.CreateCategoryDto) (dbgen.Category, error) {
+	user, err := categoryService.userRepo.GetUserByID(ctx, createCategoryDto.UserID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+
+	// Verificare se la categoria esiste già
+	_, err = categoryService.categoryRepo.GetCategory(ctx, user, createCategoryDto.Name, createCategoryDto.CategoryType)
+	if err == nil {
+		return dbgen.Category{}, fmt.Errorf("%w: category %q already exists", apierr.ErrConflict, createCategoryDto.Name)
+	}
+
+	if createCategoryDto.ParentID != nil {
+		parent, err := categoryService.categoryRepo.GetCategoryByID(ctx, user, *createCategoryDto.ParentID)
+		if err != nil {
+			return dbgen.Category{}, err
+		}
+		if err := validateParent(parent, string(createCategoryDto.CategoryType)); err != nil {
+			return dbgen.Category{}, err
+		}
+	}
+
+	category, err := categoryService.categoryRepo.CreateCategory(ctx, user, createCategoryDto.Name, createCategoryDto.CategoryType, createCategoryDto.ParentID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (categoryService *CategoryService) GetCategories(ctx context.Context, user dbgen.User, includeArchived bool) ([]dbgen.Category, error) {
+	categories, err := categoryService.categoryRepo.GetCategories(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if includeArchived {
+		return categories, nil
+	}
+
+	active := make([]dbgen.Category, 0, len(categories))
+	for _, category := range categories {
+		if !category.ArchivedAt.Valid {
+			active = append(active, category)
+		}
+	}
+	return active, nil
+}
+
+// UpdateCategory rinomina e/o archivia una categoria.
+func (categoryService *CategoryService) UpdateCategory(ctx context.Context, updateCategoryDto dto.UpdateCategoryDto) (dbgen.Category, error) {
+	user, err := categoryService.userRepo.GetUserByID(ctx, updateCategoryDto.UserID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+
+	category, err := categoryService.categoryRepo.GetCategoryByID(ctx, user, updateCategoryDto.CategoryID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+	if err := ensureCanEditCategory(ctx, categoryService.categoryRepo, user, category); err != nil {
+		return dbgen.Category{}, err
+	}
+
+	if updateCategoryDto.Name != nil && *updateCategoryDto.Name != category.Name {
+		name := strings.TrimSpace(*updateCategoryDto.Name)
+		if name == "" {
+			return dbgen.Category{}, fmt.Errorf("%w: name must not be empty", apierr.ErrInvalidData)
+		}
+		_, err = categoryService.categoryRepo.GetCategory(ctx, user, name, dto.CategoryType(category.Type))
+		if err == nil {
+			return dbgen.Category{}, fmt.Errorf("%w: category %q already exists, use merge instead", apierr.ErrConflict, name)
+		}
+		category, err = categoryService.categoryRepo.RenameCategory(ctx, user, category.ID, name)
+		if err != nil {
+			return dbgen.Category{}, err
+		}
+	}
+
+	if updateCategoryDto.Archived != nil {
+		if *updateCategoryDto.Archived {
+			category, err = categoryService.categoryRepo.ArchiveCategory(ctx, user, category.ID)
+		} else {
+			category, err = categoryService.categoryRepo.UnarchiveCategory(ctx, user, category.ID)
+		}
+		if err != nil {
+			return dbgen.Category{}, err
+		}
+	}
+
+	return category, nil
+}
+
+// SetCategoryParent sposta una categoria sotto un'altra, o la rende di primo livello se ParentID è nil.
+func (categoryService *CategoryService) SetCategoryParent(ctx context.Context, setParentDto dto.SetCategoryParentDto) (dbgen.Category, error) {
+	user, err := categoryService.userRepo.GetUserByID(ctx, setParentDto.UserID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+
+	category, err := categoryService.categoryRepo.GetCategoryByID(ctx, user, setParentDto.CategoryID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+	if err := ensureCanEditCategory(ctx, categoryService.categoryRepo, user, category); err != nil {
+		return dbgen.Category{}, err
+	}
+
+	if setParentDto.ParentID != nil {
+		parent, err := categoryService.categoryRepo.GetCategoryByID(ctx, user, *setParentDto.ParentID)
+		if err != nil {
+			return dbgen.Category{}, err
+		}
+		if err := validateParent(parent, category.Type); err != nil {
+			return dbgen.Category{}, err
+		}
+
+		categories, err := categoryService.categoryRepo.GetCategories(ctx, user)
+		if err != nil {
+			return dbgen.Category{}, err
+		}
+		if isDescendant(categories, parent.ID, category.ID) {
+			return dbgen.Category{}, fmt.Errorf("%w: category %q cannot be moved under one of its subcategories", apierr.ErrInvalidData, category.Name)
+		}
+	}
+
+	return categoryService.categoryRepo.SetCategoryParent(ctx, user, category.ID, setParentDto.ParentID)
+}
+
+// SetBudget imposta il budget mensile di spesa della categoria, o lo rimuove se MonthlyBudget è
+// nil: superarlo genera una notifica al proprietario della categoria.
+func (categoryService *CategoryService) SetBudget(ctx context.Context, budgetDto dto.SetCategoryBudgetDto) (dbgen.Category, error) {
+	if budgetDto.MonthlyBudget != nil && *budgetDto.MonthlyBudget <= 0 {
+		return dbgen.Category{}, fmt.Errorf("%w: monthlyBudget must be positive", apierr.ErrInvalidData)
+	}
+
+	user, err := categoryService.userRepo.GetUserByID(ctx, budgetDto.UserID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+
+	category, err := categoryService.categoryRepo.GetCategoryByID(ctx, user, budgetDto.CategoryID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+	if err := ensureCanEditCategory(ctx, categoryService.categoryRepo, user, category); err != nil {
+		return dbgen.Category{}, err
+	}
+	if dto.CategoryType(category.Type) != dto.Expense {
+		return dbgen.Category{}, fmt.Errorf("%w: only expense categories can have a budget", apierr.ErrInvalidData)
+	}
+
+	return categoryService.categoryRepo.SetCategoryBudget(ctx, category.ID, budgetDto.MonthlyBudget)
+}
+
+// SetDeduction classifica le spese della categoria con un codice del quadro E del 730, o toglie la
+// classificazione se Code è nil.
+func (categoryService *CategoryService) SetDeduction(ctx context.Context, deductionDto dto.SetCategoryDeductionDto) (dbgen.Category, error) {
+	var code *string
+	if deductionDto.Code != nil {
+		if !validDeductionCode(*deductionDto.Code) {
+			return dbgen.Category{}, fmt.Errorf("%w: unknown deduction code %q", apierr.ErrInvalidData, *deductionDto.Code)
+		}
+		value := string(*deductionDto.Code)
+		code = &value
+	}
+
+	user, err := categoryService.userRepo.GetUserByID(ctx, deductionDto.UserID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+
+	category, err := categoryService.categoryRepo.GetCategoryByID(ctx, user, deductionDto.CategoryID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+	if err := ensureCanEditCategory(ctx, categoryService.categoryRepo, user, category); err != nil {
+		return dbgen.Category{}, err
+	}
+	if dto.CategoryType(category.Type) != dto.Expense {
+		return dbgen.Category{}, fmt.Errorf("%w: only expense categories can be deductible", apierr.ErrInvalidData)
+	}
+
+	return categoryService.categoryRepo.SetCategoryDeduction(ctx, category.ID, code)
+}
+
+// MergeCategories unisce la categoria sorgente nella destinazione: le righe contabili
+// e le sottocategorie della sorgente passano alla destinazione e la sorgente viene eliminata.
+func (categoryService *CategoryService) MergeCategories(ctx context.Context, mergeDto dto.MergeCategoriesDto) (dbgen.Category, int64, error) {
+	if mergeDto.SourceCategoryID == mergeDto.TargetCategoryID {
+		return dbgen.Category{}, 0, fmt.Errorf("%w: source and target category must be different", apierr.ErrInvalidData)
+	}
+
+	user, err := categoryService.userRepo.GetUserByID(ctx, mergeDto.UserID)
+	if err != nil {
+		return dbgen.Category{}, 0, err
+	}
+
+	source, err := categoryService.categoryRepo.GetCategoryByID(ctx, user, mergeDto.SourceCategoryID)
+	if err != nil {
+		return dbgen.Category{}, 0, err
+	}
+	target, err := categoryService.categoryRepo.GetCategoryByID(ctx, user, mergeDto.TargetCategoryID)
+	if err != nil {
+		return dbgen.Category{}, 0, err
+	}
+	for _, category := range []dbgen.Category{source, target} {
+		if err := ensureCanEditCategory(ctx, categoryService.categoryRepo, user, category); err != nil {
+			return dbgen.Category{}, 0, err
+		}
+	}
+	if source.Type != target.Type {
+		return dbgen.Category{}, 0, fmt.Errorf("%w: cannot merge a %s category into a %s category", apierr.ErrInvalidData, source.Type, target.Type)
+	}
+
+	categories, err := categoryService.categoryRepo.GetCategories(ctx, user)
+	if err != nil {
+		return dbgen.Category{}, 0, err
+	}
+	if isDescendant(categories, target.ID, source.ID) {
+		return dbgen.Category{}, 0, fmt.Errorf("%w: cannot merge category %q into one of its subcategories", apierr.ErrInvalidData, source.Name)
+	}
+
+	moved, err := categoryService.categoryRepo.MergeCategories(ctx, user, source, target)
+	if err != nil {
+		return dbgen.Category{}, 0, err
+	}
+	return target, moved, nil
+}
+
+// GetCategoryReport calcola i totali per categoria nel periodo, sommando i totali
+// delle sottocategorie su tutti i loro antenati.
+func (categoryService *CategoryService) GetCategoryReport(ctx context.Context, reportDto dto.CategoryReportDto) ([]dto.CategoryTotal, error) {
+	user, err := categoryService.userRepo.GetUserByID(ctx, reportDto.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := categoryService.categoryRepo.GetCategoryTotals(ctx, user, reportDto.DateFrom, reportDto.DateTo, reportDto.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := make(map[int64]int64, len(rows))
+	names := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		names[row.ID] = row.Name
+		if row.ParentID.Valid {
+			parents[row.ID] = row.ParentID.Int64
+		}
+	}
+
+	rolledUp := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		rolledUp[row.ID] += row.Total
+		visited := map[int64]bool{row.ID: true}
+		for parentID, ok := parents[row.ID]; ok && !visited[parentID]; parentID, ok = parents[parentID] {
+			visited[parentID] = true
+			rolledUp[parentID] += row.Total
+		}
+	}
+
+	report := make([]dto.CategoryTotal, len(rows))
+	for i, row := range rows {
+		var parentID *int64
+		if row.ParentID.Valid {
+			id := row.ParentID.Int64
+			parentID = &id
+		}
+		report[i] = dto.CategoryTotal{
+			CategoryID:    row.ID,
+			Name:          row.Name,
+			Path:          buildPath(row.ID, names, parents),
+			CategoryType:  dto.CategoryType(row.Type),
+			ParentID:      parentID,
+			Total:         row.Total,
+			RolledUpTotal: rolledUp[row.ID],
+		}
+	}
+	return report, nil
+}
+
+// CategoryPaths restituisce, per ogni categoria, il percorso completo a partire dalla radice.
+func CategoryPaths(categories []dbgen.Category) map[int64]string {
+	names := make(map[int64]string, len(categories))
+	parents := make(map[int64]int64, len(categories))
+	for _, category := range categories {
+		names[category.ID] = category.Name
+		if category.ParentID.Valid {
+			parents[category.ID] = category.ParentID.Int64
+		}
+	}
+
+	paths := make(map[int64]string, len(categories))
+	for _, category := range categories {
+		paths[category.ID] = buildPath(category.ID, names, parents)
+	}
+	return paths
+}
+
+func buildPath(categoryID int64, names map[int64]string, parents map[int64]int64) string {
+	path := []string{names[categoryID]}
+	visited := map[int64]bool{categoryID: true}
+	for parentID, ok := parents[categoryID]; ok && !visited[parentID]; parentID, ok = parents[parentID] {
+		visited[parentID] = true
+		path = append([]string{names[parentID]}, path...)
+	}
+	return strings.Join(path, CategoryPathSeparator)
+}
+
+func validateParent(parent dbgen.Category, categoryType string) error {
+	if parent.Type != categoryType {
+		return fmt.Errorf("%w: parent category %q is %s, expected %s", apierr.ErrInvalidData, parent.Name, parent.Type, categoryType)
+	}
+	if parent.ArchivedAt.Valid {
+		return fmt.Errorf("%w: %s", apierr.ErrCategoryArchived, parent.Name)
+	}
+	return nil
+}
+
+// isDescendant indica se categoryID coincide con ancestorID o si trova nel suo sottoalbero.
+func isDescendant(categories []dbgen.Category, categoryID int64, ancestorID int64) bool {
+	parents := make(map[int64]int64, len(categories))
+	for _, category := range categories {
+		if category.ParentID.Valid {
+			parents[category.ID] = category.ParentID.Int64
+		}
+	}
+
+	visited := map[int64]bool{}
+	for current, ok := categoryID, true; ok && !visited[current]; current, ok = parents[current] {
+		if current == ancestorID {
+			return true
+		}
+		visited[current] = true
+	}
+	return false
+}
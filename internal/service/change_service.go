@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+const (
+	// changeBufferSize è il numero di eventi accodati per ogni client; oltre, gli eventi vengono
+	// scartati per non bloccare gli altri client.
+	changeBufferSize = 32
+	// listenRetryDelay è l'attesa prima di riaprire la connessione LISTEN dopo un errore.
+	listenRetryDelay = 5 * time.Second
+)
+
+// ChangeService distribuisce ai client connessi le modifiche a transazioni, account e categorie.
+// Le modifiche arrivano da Postgres con LISTEN/NOTIFY, quindi ogni istanza di koin riceve anche
+// quelle fatte sulle altre.
+type ChangeService struct {
+	changeRepo  repo.ChangeRepository
+	mu          sync.Mutex
+	subscribers map[int64]map[chan dto.ChangeEvent]struct{}
+}
+
+func NewChangeService(changeRepo repo.ChangeRepository) *ChangeService {
+	return &ChangeService{
+		changeRepo:  changeRepo,
+		subscribers: make(map[int64]map[chan dto.ChangeEvent]struct{}),
+	}
+}
+
+// Subscribe registra un client dell'utente: riceve le modifiche sul canale restituito finché non
+// chiama la funzione di cancellazione.
+func (changeService *ChangeService) Subscribe(userID int64) (<-chan dto.ChangeEvent, func()) {
+	events := make(chan dto.ChangeEvent, changeBufferSize)
+
+	changeService.mu.Lock()
+	if changeService.subscribers[userID] == nil {
+		changeService.subscribers[userID] = make(map[chan dto.ChangeEvent]struct{})
+	}
+	changeService.subscribers[userID][events] = struct{}{}
+	changeService.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			changeService.mu.Lock()
+			defer changeService.mu.Unlock()
+			delete(changeService.subscribers[userID], events)
+			if len(changeService.subscribers[userID]) == 0 {
+				delete(changeService.subscribers, userID)
+			}
+		})
+	}
+}
+
+func (changeService *ChangeService) broadcast(event dto.ChangeEvent) {
+	changeService.mu.Lock()
+	defer changeService.mu.Unlock()
+	for _, userID := range event.UserIDs {
+		for events := range changeService.subscribers[userID] {
+			select {
+			case events <- event:
+			default:
+				log.Printf("changes: client of user %d is too slow, dropping %s %d", userID, event.Event, event.ID)
+			}
+		}
+	}
+}
+
+// StartListening ascolta le modifiche in background, riaprendo la connessione dopo un errore,
+// finché il contesto non viene annullato.
+func (changeService *ChangeService) StartListening(ctx context.Context) {
+	go func() {
+		for {
+			err := changeService.changeRepo.Listen(ctx, changeService.broadcast)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("changes: %v, retrying in %s", err, listenRetryDelay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(listenRetryDelay):
+			}
+		}
+	}()
+}
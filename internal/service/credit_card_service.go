@@ -0,0 +1,484 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+const (
+	defaultStatementCount = 12
+	maxStatementCount     = 60
+	// statementReminderDays è quanti giorni prima della scadenza viene notificato l'estratto conto.
+	statementReminderDays = 3
+)
+
+type CreditCardService struct {
+	userRepo       repo.UserRepository
+	accountRepo    repo.AccountRepository
+	creditCardRepo repo.CreditCardRepository
+	notifications  NotificationBus
+}
+
+func NewCreditCardService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	creditCardRepo repo.CreditCardRepository,
+	notifications NotificationBus,
+) *CreditCardService {
+	return &CreditCardService{
+		userRepo:       userRepo,
+		accountRepo:    accountRepo,
+		creditCardRepo: creditCardRepo,
+		notifications:  notifications,
+	}
+}
+
+// ConfigureCreditCard trasforma un account in carta di credito (o ne aggiorna il ciclo):
+// giorno di chiusura dell'estratto conto, giorno di addebito e conto da cui pagare il saldo.
+func (creditCardService *CreditCardService) ConfigureCreditCard(ctx context.Context, configureDto dto.ConfigureCreditCardDto) (dbgen.CreditCard, error) {
+	if configureDto.ClosingDay < 1 || configureDto.ClosingDay > 31 || configureDto.DueDay < 1 || configureDto.DueDay > 31 {
+		return dbgen.CreditCard{}, fmt.Errorf("%w: closing and due day must be between 1 and 31", apierr.ErrInvalidData)
+	}
+	if configureDto.AccountID == configureDto.PaymentAccountID {
+		return dbgen.CreditCard{}, fmt.Errorf("%w: payment account must be different from the card", apierr.ErrInvalidData)
+	}
+
+	user, err := creditCardService.userRepo.GetUserByID(ctx, configureDto.UserID)
+	if err != nil {
+		return dbgen.CreditCard{}, err
+	}
+
+	account, err := creditCardService.accountRepo.GetAccountByID(ctx, user, configureDto.AccountID)
+	if err != nil {
+		return dbgen.CreditCard{}, err
+	}
+
+	paymentAccount, err := creditCardService.accountRepo.GetAccountByID(ctx, user, configureDto.PaymentAccountID)
+	if err != nil {
+		return dbgen.CreditCard{}, err
+	}
+	for _, editable := range []dbgen.Account{account, paymentAccount} {
+		if err := ensureCanEditAccount(ctx, creditCardService.accountRepo, user, editable); err != nil {
+			return dbgen.CreditCard{}, err
+		}
+	}
+	if paymentAccount.Currency != account.Currency {
+		return dbgen.CreditCard{}, fmt.Errorf("%w: payment account currency %s differs from card currency %s", apierr.ErrInvalidData, paymentAccount.Currency, account.Currency)
+	}
+
+	return creditCardService.creditCardRepo.SaveCreditCard(ctx, configureDto)
+}
+
+func (creditCardService *CreditCardService) GetCreditCard(ctx context.Context, userID int64, accountID int64) (dbgen.CreditCard, error) {
+	user, err := creditCardService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dbgen.CreditCard{}, err
+	}
+
+	return creditCardService.creditCardRepo.GetCreditCard(ctx, user, accountID)
+}
+
+func (creditCardService *CreditCardService) GetCreditCards(ctx context.Context, userID int64) ([]dbgen.CreditCard, error) {
+	user, err := creditCardService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return creditCardService.creditCardRepo.GetCreditCards(ctx, user)
+}
+
+// RemoveCreditCard toglie la configurazione di carta di credito; l'account e i suoi movimenti restano.
+func (creditCardService *CreditCardService) RemoveCreditCard(ctx context.Context, userID int64, accountID int64) error {
+	user, err := creditCardService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	creditCard, err := creditCardService.creditCardRepo.GetCreditCard(ctx, user, accountID)
+	if err != nil {
+		return err
+	}
+
+	account, err := creditCardService.accountRepo.GetAccountByID(ctx, user, creditCard.AccountID)
+	if err != nil {
+		return err
+	}
+	if err := ensureCanEditAccount(ctx, creditCardService.accountRepo, user, account); err != nil {
+		return err
+	}
+
+	return creditCardService.creditCardRepo.DeleteCreditCard(ctx, creditCard)
+}
+
+// GetStatements restituisce gli ultimi count estratti conto della carta, dal ciclo in corso a ritroso.
+func (creditCardService *CreditCardService) GetStatements(ctx context.Context, userID int64, accountID int64, count int, today time.Time) (dto.CreditCardStatements, error) {
+	if count <= 0 {
+		count = defaultStatementCount
+	}
+	if count > maxStatementCount {
+		count = maxStatementCount
+	}
+
+	user, err := creditCardService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.CreditCardStatements{}, err
+	}
+
+	return creditCardService.statements(ctx, user, accountID, count, toDate(today))
+}
+
+// PayStatement registra il pagamento dell'estratto conto chiuso alla data indicata con un
+// trasferimento dal conto di addebito alla carta. Senza importo salda tutto il residuo.
+func (creditCardService *CreditCardService) PayStatement(ctx context.Context, payDto dto.PayStatementDto, today time.Time) (int64, error) {
+	today = toDate(today)
+
+	user, err := creditCardService.userRepo.GetUserByID(ctx, payDto.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	creditCard, err := creditCardService.creditCardRepo.GetCreditCard(ctx, user, payDto.AccountID)
+	if err != nil {
+		return 0, err
+	}
+
+	closingDate := toDate(payDto.ClosingDate)
+	if !closingDate.Equal(closingInMonth(closingDate, 0, creditCard.ClosingDay)) {
+		return 0, fmt.Errorf("%w: %s is not a closing date of this card", apierr.ErrInvalidData, closingDate.Format("2006-01-02"))
+	}
+	if !closingDate.Before(today) {
+		return 0, fmt.Errorf("%w: statement closing on %s is still open", apierr.ErrInvalidData, closingDate.Format("2006-01-02"))
+	}
+
+	count := monthsBetween(closingDate, currentClosing(today, creditCard.ClosingDay)) + 1
+	statements, err := creditCardService.statements(ctx, user, creditCard.AccountID, count, today)
+	if err != nil {
+		return 0, err
+	}
+	statement := statements.Statements[len(statements.Statements)-1]
+	for _, account := range []dbgen.Account{statements.Account, statements.PaymentAccount} {
+		if err := ensureCanEditAccount(ctx, creditCardService.accountRepo, user, account); err != nil {
+			return 0, err
+		}
+	}
+
+	occurredAt := today
+	if payDto.OccurredAt != nil {
+		occurredAt = toDate(*payDto.OccurredAt)
+	}
+	if !occurredAt.After(closingDate) {
+		return 0, fmt.Errorf("%w: payment date must be after the closing date", apierr.ErrInvalidData)
+	}
+
+	transactionID, _, err := creditCardService.pay(ctx, user, statements, statement, payDto.Amount, occurredAt, today)
+	return transactionID, err
+}
+
+// RunAutoPay salda gli estratti conto scaduti delle carte con addebito automatico. Gli
+// errori su una carta (es. saldo insufficiente) vengono registrati e riprovati al giro successivo.
+func (creditCardService *CreditCardService) RunAutoPay(ctx context.Context, now time.Time) {
+	today := toDate(now)
+
+	creditCards, err := creditCardService.creditCardRepo.GetAutoPayCreditCards(ctx)
+	if err != nil {
+		log.Printf("credit cards: auto pay: %v", err)
+		return
+	}
+
+	for _, creditCard := range creditCards {
+		user, err := creditCardService.userRepo.GetUserByID(ctx, creditCard.UserID)
+		if err != nil {
+			log.Printf("credit cards: auto pay card %d: %v", creditCard.AccountID, err)
+			continue
+		}
+
+		statements, err := creditCardService.statements(ctx, user, creditCard.AccountID, defaultStatementCount, today)
+		if err != nil {
+			log.Printf("credit cards: auto pay card %d: %v", creditCard.AccountID, err)
+			continue
+		}
+
+		// Dal più vecchio, così i pagamenti vengono imputati nell'ordine delle scadenze
+		for i := len(statements.Statements) - 1; i >= 0; i-- {
+			statement := statements.Statements[i]
+			if statement.Status == dto.StatementOpen || statement.Outstanding == 0 || today.Before(statement.DueDate) {
+				continue
+			}
+			_, paid, err := creditCardService.pay(ctx, user, statements, statement, nil, today, today)
+			if err != nil {
+				log.Printf("credit cards: auto pay card %d statement %s: %v", creditCard.AccountID, statement.ClosingDate.Format("2006-01-02"), err)
+				break
+			}
+			log.Printf("credit cards: auto paid %d cents on card %d for statement %s", paid, creditCard.AccountID, statement.ClosingDate.Format("2006-01-02"))
+			creditCardService.notifications.Publish(ctx, dto.NotificationEvent{
+				UserID:   user.ID,
+				Kind:     dto.NotifyRecurringPosted,
+				Title:    fmt.Sprintf("Carta saldata: %s", statements.Account.Name),
+				Body:     fmt.Sprintf("Addebitati %s su %s per l'estratto conto del %s.", formatCents(paid, statements.Account.Currency), statements.PaymentAccount.Name, statement.ClosingDate.Format("02/01/2006")),
+				DedupKey: fmt.Sprintf("card-paid:%d:%s", creditCard.AccountID, statement.ClosingDate.Format("2006-01-02")),
+			})
+		}
+	}
+}
+
+// RunDueReminders notifica gli estratti conto da pagare entro statementReminderDays giorni e quelli
+// scaduti senza essere saldati, una volta per estratto conto e stato.
+func (creditCardService *CreditCardService) RunDueReminders(ctx context.Context, now time.Time) {
+	today := toDate(now)
+
+	creditCards, err := creditCardService.creditCardRepo.GetAllCreditCards(ctx)
+	if err != nil {
+		log.Printf("credit cards: due reminders: %v", err)
+		return
+	}
+
+	for _, creditCard := range creditCards {
+		user, err := creditCardService.userRepo.GetUserByID(ctx, creditCard.UserID)
+		if err != nil {
+			log.Printf("credit cards: due reminders card %d: %v", creditCard.AccountID, err)
+			continue
+		}
+
+		statements, err := creditCardService.statements(ctx, user, creditCard.AccountID, 2, today)
+		if err != nil {
+			log.Printf("credit cards: due reminders card %d: %v", creditCard.AccountID, err)
+			continue
+		}
+
+		for _, statement := range statements.Statements {
+			if statement.Outstanding <= 0 {
+				continue
+			}
+			amount := formatCents(statement.Outstanding, statements.Account.Currency)
+			closing := statement.ClosingDate.Format("02/01/2006")
+			switch {
+			case statement.Status == dto.StatementDue && daysBetween(today, statement.DueDate) <= statementReminderDays:
+				body := fmt.Sprintf("L'estratto conto del %s (%s) scade il %s.", closing, amount, statement.DueDate.Format("02/01/2006"))
+				if creditCard.AutoPay {
+					body += fmt.Sprintf(" Verrà addebitato automaticamente su %s.", statements.PaymentAccount.Name)
+				}
+				creditCardService.notifications.Publish(ctx, dto.NotificationEvent{
+					UserID:   user.ID,
+					Kind:     dto.NotifyStatementDue,
+					Title:    fmt.Sprintf("Estratto conto in scadenza: %s", statements.Account.Name),
+					Body:     body,
+					DedupKey: fmt.Sprintf("statement-due:%d:%s", creditCard.AccountID, statement.ClosingDate.Format("2006-01-02")),
+				})
+			case statement.Status == dto.StatementOverdue:
+				creditCardService.notifications.Publish(ctx, dto.NotificationEvent{
+					UserID:   user.ID,
+					Kind:     dto.NotifyStatementDue,
+					Title:    fmt.Sprintf("Estratto conto scaduto: %s", statements.Account.Name),
+					Body:     fmt.Sprintf("L'estratto conto del %s è scaduto il %s e restano da pagare %s.", closing, statement.DueDate.Format("02/01/2006"), amount),
+					DedupKey: fmt.Sprintf("statement-overdue:%d:%s", creditCard.AccountID, statement.ClosingDate.Format("2006-01-02")),
+				})
+			}
+		}
+	}
+}
+
+// StartAutoPay esegue RunAutoPay e RunDueReminders subito e poi a ogni intervallo, finché il
+// contesto non viene annullato.
+func (creditCardService *CreditCardService) StartAutoPay(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			now := time.Now()
+			creditCardService.RunAutoPay(ctx, now)
+			creditCardService.RunDueReminders(ctx, now)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// pay salda l'estratto conto con un trasferimento dal conto di addebito; senza amount paga tutto
+// il residuo. Il residuo viene ricalcolato con la carta bloccata, quindi un secondo pagamento
+// concorrente dello stesso estratto conto fallisce con ErrConflict invece di pagarlo due volte.
+// Restituisce la transazione e l'importo pagato.
+func (creditCardService *CreditCardService) pay(ctx context.Context, user dbgen.User, statements dto.CreditCardStatements, statement dto.CreditCardStatement, amount *int64, occurredAt time.Time, today time.Time) (int64, int64, error) {
+	latest := statements.Statements[0].ClosingDate
+	count := len(statements.Statements)
+	dateFrom := closingInMonth(statements.Statements[count-1].ClosingDate, -1, statements.CreditCard.ClosingDay)
+
+	var paid int64
+	description := fmt.Sprintf("Saldo %s estratto conto del %s", statements.Account.Name, statement.ClosingDate.Format("02/01/2006"))
+	transactionID, err := creditCardService.creditCardRepo.PayStatement(ctx, user, statements.CreditCard, statements.PaymentAccount, statements.Account, dto.TransferBetweenAccountsDto{
+		UserID:      user.ID,
+		AccountFrom: statements.PaymentAccount.Name,
+		AccountTo:   statements.Account.Name,
+		OccurredAt:  occurredAt,
+		Description: &description,
+	}, dateFrom, func(movements []dbgen.GetCreditCardMovementsRow) (int64, error) {
+		var outstanding int64
+		for _, current := range buildStatements(statements.CreditCard, latest, count, movements, today) {
+			if current.ClosingDate.Equal(statement.ClosingDate) {
+				outstanding = current.Outstanding
+			}
+		}
+		if outstanding == 0 {
+			return 0, fmt.Errorf("%w: statement closing on %s is already paid", apierr.ErrConflict, statement.ClosingDate.Format("2006-01-02"))
+		}
+		paid = outstanding
+		if amount != nil {
+			paid = *amount
+		}
+		if paid <= 0 || paid > outstanding {
+			return 0, fmt.Errorf("%w: amount must be between 1 and %d", apierr.ErrInvalidData, outstanding)
+		}
+		return paid, nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return transactionID, paid, nil
+}
+
+func (creditCardService *CreditCardService) statements(ctx context.Context, user dbgen.User, accountID int64, count int, today time.Time) (dto.CreditCardStatements, error) {
+	creditCard, err := creditCardService.creditCardRepo.GetCreditCard(ctx, user, accountID)
+	if err != nil {
+		return dto.CreditCardStatements{}, err
+	}
+
+	account, err := creditCardService.accountRepo.GetAccountByID(ctx, user, creditCard.AccountID)
+	if err != nil {
+		return dto.CreditCardStatements{}, err
+	}
+
+	paymentAccount, err := creditCardService.accountRepo.GetAccountByID(ctx, user, creditCard.PaymentAccountID)
+	if err != nil {
+		return dto.CreditCardStatements{}, err
+	}
+
+	latest := currentClosing(today, creditCard.ClosingDay)
+	oldest := closingInMonth(latest, -(count - 1), creditCard.ClosingDay)
+	movements, err := creditCardService.creditCardRepo.GetMovements(ctx, creditCard, closingInMonth(oldest, -1, creditCard.ClosingDay))
+	if err != nil {
+		return dto.CreditCardStatements{}, err
+	}
+
+	return dto.CreditCardStatements{
+		CreditCard:     creditCard,
+		Account:        account,
+		PaymentAccount: paymentAccount,
+		Statements:     buildStatements(creditCard, latest, count, movements, today),
+	}, nil
+}
+
+// buildStatements calcola gli estratti conto dal più vecchio al più recente e li restituisce
+// dal più recente. Gli addebiti del ciclo sono i movimenti tra la chiusura precedente (esclusa)
+// e quella del ciclo (inclusa); i pagamenti vengono imputati agli estratti più vecchi ancora
+// aperti, considerando solo quelli successivi all'inizio del ciclo.
+func buildStatements(creditCard dbgen.CreditCard, latest time.Time, count int, movements []dbgen.GetCreditCardMovementsRow, today time.Time) []dto.CreditCardStatement {
+	type payment struct {
+		occurredAt time.Time
+		remaining  int64
+	}
+	var payments []payment
+	for _, movement := range movements {
+		if movement.IsPayment {
+			payments = append(payments, payment{occurredAt: movement.OccurredAt, remaining: movement.Amount})
+		}
+	}
+
+	statements := make([]dto.CreditCardStatement, count)
+	for i := 0; i < count; i++ {
+		closingDate := closingInMonth(latest, -(count - 1 - i), creditCard.ClosingDay)
+		previousClosing := closingInMonth(closingDate, -1, creditCard.ClosingDay)
+
+		var charges int64
+		for _, movement := range movements {
+			if movement.IsPayment || !movement.OccurredAt.After(previousClosing) || movement.OccurredAt.After(closingDate) {
+				continue
+			}
+			charges += movement.Amount
+		}
+		owed := -charges
+
+		var paid int64
+		for j := range payments {
+			if paid >= owed {
+				break
+			}
+			if !payments[j].occurredAt.After(previousClosing) || payments[j].remaining == 0 {
+				continue
+			}
+			taken := min(payments[j].remaining, owed-paid)
+			payments[j].remaining -= taken
+			paid += taken
+		}
+
+		statement := dto.CreditCardStatement{
+			PeriodStart: previousClosing.AddDate(0, 0, 1),
+			ClosingDate: closingDate,
+			DueDate:     dueDate(closingDate, creditCard),
+			Owed:        owed,
+			Paid:        paid,
+			Outstanding: max(owed-paid, 0),
+		}
+		switch {
+		case !today.After(closingDate):
+			statement.Status = dto.StatementOpen
+		case statement.Outstanding == 0:
+			statement.Status = dto.StatementPaid
+		case today.After(statement.DueDate):
+			statement.Status = dto.StatementOverdue
+		default:
+			statement.Status = dto.StatementDue
+		}
+		if statement.Status == dto.StatementDue || statement.Status == dto.StatementOverdue {
+			suggested := statement.DueDate
+			if today.After(suggested) {
+				suggested = today
+			}
+			statement.SuggestedPaymentDate = &suggested
+		}
+		statements[count-1-i] = statement
+	}
+	return statements
+}
+
+// closingInMonth restituisce il giorno di chiusura nel mese spostato di offset rispetto a
+// quello di ref; nei mesi più corti la chiusura cade l'ultimo giorno del mese.
+func closingInMonth(ref time.Time, offset int, day int32) time.Time {
+	first := time.Date(ref.Year(), ref.Month()+time.Month(offset), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	return time.Date(first.Year(), first.Month(), min(int(day), lastDay), 0, 0, 0, 0, time.UTC)
+}
+
+// currentClosing è la chiusura del ciclo in corso: la prima a partire da oggi.
+func currentClosing(today time.Time, day int32) time.Time {
+	closing := closingInMonth(today, 0, day)
+	if closing.Before(today) {
+		return closingInMonth(today, 1, day)
+	}
+	return closing
+}
+
+// dueDate è il giorno di addebito successivo alla chiusura: nello stesso mese se cade dopo
+// il giorno di chiusura, altrimenti nel mese seguente.
+func dueDate(closingDate time.Time, creditCard dbgen.CreditCard) time.Time {
+	if creditCard.DueDay > creditCard.ClosingDay {
+		return closingInMonth(closingDate, 0, creditCard.DueDay)
+	}
+	return closingInMonth(closingDate, 1, creditCard.DueDay)
+}
+
+func monthsBetween(from time.Time, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+func toDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
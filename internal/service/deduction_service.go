@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/report"
+	repo "koin/internal/repository"
+)
+
+// DeductionService classifica le spese detraibili nel 730 e ne produce il riepilogo annuale.
+type DeductionService struct {
+	userRepo      repo.UserRepository
+	accountRepo   repo.AccountRepository
+	deductionRepo repo.DeductionRepository
+}
+
+func NewDeductionService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	deductionRepo repo.DeductionRepository,
+) *DeductionService {
+	return &DeductionService{
+		userRepo:      userRepo,
+		accountRepo:   accountRepo,
+		deductionRepo: deductionRepo,
+	}
+}
+
+// SetEntryDeduction classifica una singola riga contabile: il codice prevale su quello della
+// categoria e NoDeduction la esclude dal riepilogo. Con Code nil la riga segue la categoria.
+func (deductionService *DeductionService) SetEntryDeduction(ctx context.Context, deductionDto dto.SetEntryDeductionDto) (dbgen.TransactionEntry, error) {
+	var code *string
+	if deductionDto.Code != nil {
+		if *deductionDto.Code != dto.NoDeduction && !validDeductionCode(*deductionDto.Code) {
+			return dbgen.TransactionEntry{}, fmt.Errorf("%w: unknown deduction code %q", apierr.ErrInvalidData, *deductionDto.Code)
+		}
+		value := string(*deductionDto.Code)
+		code = &value
+	}
+
+	user, err := deductionService.userRepo.GetUserByID(ctx, deductionDto.UserID)
+	if err != nil {
+		return dbgen.TransactionEntry{}, err
+	}
+
+	entry, err := deductionService.deductionRepo.GetEntry(ctx, user, deductionDto.EntryID)
+	if err != nil {
+		return dbgen.TransactionEntry{}, err
+	}
+	transaction, err := deductionService.accountRepo.GetTransaction(ctx, user, entry.TransactionID)
+	if err != nil {
+		return dbgen.TransactionEntry{}, err
+	}
+	if err := ensureCanEditTransaction(ctx, deductionService.accountRepo, user, transaction); err != nil {
+		return dbgen.TransactionEntry{}, err
+	}
+
+	return deductionService.deductionRepo.SetEntryDeduction(ctx, entry.ID, code)
+}
+
+// GetReport totalizza le spese detraibili dell'anno per codice del quadro E, con le ricevute
+// allegate a ogni transazione. Sono considerati solo gli account in euro.
+func (deductionService *DeductionService) GetReport(ctx context.Context, userID int64, year int, now time.Time) (dto.DeductionReport, error) {
+	user, err := deductionService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.DeductionReport{}, err
+	}
+	if year < 1900 || year > now.Year() {
+		return dto.DeductionReport{}, fmt.Errorf("%w: invalid year %d", apierr.ErrInvalidData, year)
+	}
+
+	entries, err := deductionService.deductionRepo.GetDeductibleEntries(ctx, user, year)
+	if err != nil {
+		return dto.DeductionReport{}, err
+	}
+	receipts, err := deductionService.deductionRepo.GetReceipts(ctx, user, year)
+	if err != nil {
+		return dto.DeductionReport{}, err
+	}
+
+	return buildDeductionReport(user.Email, year, entries, receipts), nil
+}
+
+// Export restituisce il riepilogo dell'anno in CSV o PDF, con il nome del file.
+func (deductionService *DeductionService) Export(ctx context.Context, userID int64, year int, format dto.DeductionExportFormat, now time.Time) ([]byte, string, error) {
+	if format != dto.DeductionCSV && format != dto.DeductionPDF {
+		return nil, "", fmt.Errorf("%w: unknown format %q", apierr.ErrInvalidData, format)
+	}
+
+	deductions, err := deductionService.GetReport(ctx, userID, year, now)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if format == dto.DeductionCSV {
+		content, err := report.RenderDeductionsCSV(deductions)
+		if err != nil {
+			return nil, "", err
+		}
+		return content, report.DeductionsFilename(deductions, "csv"), nil
+	}
+
+	content, err := report.RenderDeductionsPDF(deductions, now)
+	if err != nil {
+		return nil, "", err
+	}
+	return content, report.DeductionsFilename(deductions, "pdf"), nil
+}
+
+// buildDeductionReport raggruppa le righe per codice nell'ordine del quadro E; i codici senza
+// spese sono omessi. Le spese sono riportate in positivo; ai rimborsi non serve una ricevuta.
+func buildDeductionReport(email string, year int, entries []dbgen.GetDeductibleEntriesRow, receipts []dbgen.GetDeductionReceiptsRow) dto.DeductionReport {
+	receiptsByTransaction := make(map[int64][]string)
+	for _, receipt := range receipts {
+		receiptsByTransaction[receipt.TransactionID] = append(receiptsByTransaction[receipt.TransactionID], receipt.FileName)
+	}
+
+	entriesByCode := make(map[dto.DeductionCode][]dbgen.GetDeductibleEntriesRow)
+	for _, entry := range entries {
+		code := dto.DeductionCode(entry.DeductionCode)
+		entriesByCode[code] = append(entriesByCode[code], entry)
+	}
+
+	deductions := dto.DeductionReport{UserEmail: email, Year: year}
+	for _, info := range dto.DeductionCodes {
+		rows := entriesByCode[info.Code]
+		if len(rows) == 0 {
+			continue
+		}
+
+		line := dto.DeductionLine{DeductionInfo: info}
+		for _, row := range rows {
+			entry := dto.DeductionEntry{
+				Entry:    row,
+				Receipts: receiptsByTransaction[row.TransactionID],
+			}
+			if len(entry.Receipts) == 0 && entry.Deductible() > 0 {
+				line.MissingReceipts++
+			}
+			line.Total += entry.Deductible()
+			line.Entries = append(line.Entries, entry)
+		}
+		deductions.Total += line.Total
+		deductions.Lines = append(deductions.Lines, line)
+	}
+	return deductions
+}
+
+func validDeductionCode(code dto.DeductionCode) bool {
+	for _, info := range dto.DeductionCodes {
+		if info.Code == code {
+			return true
+		}
+	}
+	return false
+}
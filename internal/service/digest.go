@@ -0,0 +1,88 @@
+package service
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+// digestTopCategories è il numero di categorie di spesa mostrate nel riepilogo.
+const digestTopCategories = 5
+
+// digestPeriod restituisce l'ultimo invio previsto entro today (sendOn) e il periodo che copre:
+// il settimanale parte nel giorno weekday e copre i sette giorni precedenti, il mensile parte il
+// primo del mese e copre il mese precedente.
+func digestPeriod(frequency dto.Frequency, today time.Time, weekday time.Weekday) (sendOn time.Time, dateFrom time.Time, dateTo time.Time) {
+	if frequency == dto.Monthly {
+		sendOn = time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return sendOn, sendOn.AddDate(0, -1, 0), sendOn.AddDate(0, 0, -1)
+	}
+	sendOn = today.AddDate(0, 0, -((int(today.Weekday()) - int(weekday) + 7) % 7))
+	return sendOn, sendOn.AddDate(0, 0, -7), sendOn.AddDate(0, 0, -1)
+}
+
+// digestDue indica se il riepilogo previsto per sendOn va ancora inviato: non è già partito e
+// le impostazioni erano attive quel giorno, così chi attiva il riepilogo non ne riceve subito
+// uno arretrato.
+func digestDue(lastSent sql.NullTime, sendOn time.Time, updatedAt time.Time) bool {
+	if lastSent.Valid && !lastSent.Time.Before(sendOn) {
+		return false
+	}
+	return !sendOn.Before(toDate(updatedAt))
+}
+
+// summarizeCategories calcola entrate, uscite e categorie di spesa principali dai totali per
+// categoria del periodo.
+func summarizeCategories(totals []dbgen.GetCategoryTotalsByUserRow) (income int64, expenses int64, top []dto.DigestCategory) {
+	for _, total := range totals {
+		switch dto.CategoryType(total.Type) {
+		case dto.Income:
+			income += total.Total
+		case dto.Expense:
+			expenses -= total.Total
+			if total.Total < 0 {
+				top = append(top, dto.DigestCategory{Name: total.Name, Amount: -total.Total})
+			}
+		}
+	}
+
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Amount > top[j].Amount })
+	if len(top) > digestTopCategories {
+		top = top[:digestTopCategories]
+	}
+	for i := range top {
+		if expenses > 0 {
+			top[i].SharePct = int32(top[i].Amount * 100 / expenses)
+		}
+	}
+	return income, expenses, top
+}
+
+// budgetStatus confronta la spesa del mese con il budget delle categorie che ne hanno uno,
+// dalle più vicine al limite.
+func budgetStatus(categories []dbgen.Category, monthTotals []dbgen.GetCategoryTotalsByUserRow) []dto.DigestBudget {
+	spent := make(map[int64]int64, len(monthTotals))
+	for _, total := range monthTotals {
+		spent[total.ID] = -total.Total
+	}
+
+	var budgets []dto.DigestBudget
+	for _, category := range categories {
+		if !category.MonthlyBudget.Valid || category.ArchivedAt.Valid || category.MonthlyBudget.Int64 <= 0 {
+			continue
+		}
+		budget := dto.DigestBudget{
+			Name:   category.Name,
+			Budget: category.MonthlyBudget.Int64,
+			Spent:  max(spent[category.ID], 0),
+		}
+		budget.UsedPct = int32(budget.Spent * 100 / budget.Budget)
+		budget.Exceeded = budget.Spent > budget.Budget
+		budgets = append(budgets, budget)
+	}
+	sort.SliceStable(budgets, func(i, j int) bool { return budgets[i].UsedPct > budgets[j].UsedPct })
+	return budgets
+}
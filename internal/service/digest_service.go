@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/notify"
+	"koin/internal/report"
+	repo "koin/internal/repository"
+)
+
+// digestLargestEntries è il numero di movimenti più grandi mostrati nel riepilogo.
+const digestLargestEntries = 5
+
+// DigestService compone i riepiloghi periodici (entrate e uscite, categorie principali, budget,
+// movimenti più grandi e saldi) e li invia via email secondo le impostazioni di ogni utente.
+// Senza mailer (SMTP non configurato) i riepiloghi si possono solo visualizzare in anteprima.
+type DigestService struct {
+	userRepo     repo.UserRepository
+	categoryRepo repo.CategoryRepository
+	digestRepo   repo.DigestRepository
+	mailer       notify.Sender
+}
+
+func NewDigestService(
+	userRepo repo.UserRepository,
+	categoryRepo repo.CategoryRepository,
+	digestRepo repo.DigestRepository,
+	mailer notify.Sender,
+) *DigestService {
+	return &DigestService{
+		userRepo:     userRepo,
+		categoryRepo: categoryRepo,
+		digestRepo:   digestRepo,
+		mailer:       mailer,
+	}
+}
+
+func (digestService *DigestService) GetSettings(ctx context.Context, userID int64) (dto.DigestSettings, error) {
+	user, err := digestService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.DigestSettings{}, err
+	}
+
+	settings, err := digestService.digestRepo.GetSettings(ctx, user)
+	if err != nil {
+		return dto.DigestSettings{}, err
+	}
+	return toDigestSettings(settings), nil
+}
+
+func (digestService *DigestService) SaveSettings(ctx context.Context, settingsDto dto.DigestSettings) (dto.DigestSettings, error) {
+	if _, err := digestService.userRepo.GetUserByID(ctx, settingsDto.UserID); err != nil {
+		return dto.DigestSettings{}, err
+	}
+	if settingsDto.Weekday < time.Sunday || settingsDto.Weekday > time.Saturday {
+		return dto.DigestSettings{}, fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6", apierr.ErrInvalidData)
+	}
+
+	settings, err := digestService.digestRepo.SaveSettings(ctx, settingsDto)
+	if err != nil {
+		return dto.DigestSettings{}, err
+	}
+	return toDigestSettings(settings), nil
+}
+
+// Preview restituisce l'HTML dell'ultimo riepilogo previsto entro la data indicata (oggi se
+// assente), lo stesso che viene inviato via email.
+func (digestService *DigestService) Preview(ctx context.Context, userID int64, frequency dto.Frequency, date *time.Time, now time.Time) (string, error) {
+	if frequency != dto.Weekly && frequency != dto.Monthly {
+		return "", fmt.Errorf("%w: frequency must be WEEKLY or MONTHLY", apierr.ErrInvalidData)
+	}
+	user, err := digestService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	settings, err := digestService.digestRepo.GetSettings(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	today := toDate(now)
+	if date != nil {
+		today = toDate(*date)
+	}
+	_, dateFrom, dateTo := digestPeriod(frequency, today, time.Weekday(settings.Weekday))
+	digest, err := digestService.buildDigest(ctx, user, frequency, dateFrom, dateTo)
+	if err != nil {
+		return "", err
+	}
+	return report.RenderDigest(digest)
+}
+
+func (digestService *DigestService) buildDigest(ctx context.Context, user dbgen.User, frequency dto.Frequency, dateFrom time.Time, dateTo time.Time) (dto.Digest, error) {
+	digest := dto.Digest{
+		Frequency:   frequency,
+		DateFrom:    dateFrom,
+		DateTo:      dateTo,
+		BudgetMonth: time.Date(dateTo.Year(), dateTo.Month(), 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	totals, err := digestService.categoryRepo.GetCategoryTotals(ctx, user, dateFrom, dateTo, nil)
+	if err != nil {
+		return dto.Digest{}, err
+	}
+	digest.Income, digest.Expenses, digest.TopCategories = summarizeCategories(totals)
+
+	categories, err := digestService.categoryRepo.GetCategories(ctx, user)
+	if err != nil {
+		return dto.Digest{}, err
+	}
+	monthTotals, err := digestService.categoryRepo.GetCategoryTotals(ctx, user, digest.BudgetMonth, dateTo, nil)
+	if err != nil {
+		return dto.Digest{}, err
+	}
+	digest.Budgets = budgetStatus(categories, monthTotals)
+
+	digest.Largest, err = digestService.digestRepo.GetLargestEntries(ctx, user, dateFrom, dateTo, digestLargestEntries)
+	if err != nil {
+		return dto.Digest{}, err
+	}
+	digest.Accounts, err = digestService.digestRepo.GetBalanceChanges(ctx, user, dateFrom, dateTo)
+	if err != nil {
+		return dto.Digest{}, err
+	}
+	return digest, nil
+}
+
+// RunDigests invia i riepiloghi previsti entro oggi e non ancora partiti. Ogni invio viene
+// prenotato sul database prima di spedire l'email, così con più istanze parte una volta sola;
+// un invio fallito viene ritentato al giro successivo.
+func (digestService *DigestService) RunDigests(ctx context.Context, now time.Time) {
+	if digestService.mailer == nil {
+		return
+	}
+	settings, err := digestService.digestRepo.GetEnabledSettings(ctx)
+	if err != nil {
+		log.Printf("digests: %v", err)
+		return
+	}
+
+	today := toDate(now)
+	for _, setting := range settings {
+		if setting.Weekly {
+			sendOn, dateFrom, dateTo := digestPeriod(dto.Weekly, today, time.Weekday(setting.Weekday))
+			if digestDue(setting.LastWeeklySent, sendOn, setting.UpdatedAt) {
+				digestService.send(ctx, setting.UserID, dto.Weekly, setting.LastWeeklySent, sendOn, dateFrom, dateTo, now)
+			}
+		}
+		if setting.Monthly {
+			sendOn, dateFrom, dateTo := digestPeriod(dto.Monthly, today, time.Weekday(setting.Weekday))
+			if digestDue(setting.LastMonthlySent, sendOn, setting.UpdatedAt) {
+				digestService.send(ctx, setting.UserID, dto.Monthly, setting.LastMonthlySent, sendOn, dateFrom, dateTo, now)
+			}
+		}
+	}
+}
+
+// send prenota l'invio, spedisce il riepilogo e, se la spedizione fallisce, rilascia la
+// prenotazione ripristinando lastSent.
+func (digestService *DigestService) send(ctx context.Context, userID int64, frequency dto.Frequency, lastSent sql.NullTime, sendOn time.Time, dateFrom time.Time, dateTo time.Time, now time.Time) {
+	claimed, err := digestService.digestRepo.ClaimSend(ctx, userID, frequency, sendOn)
+	if err != nil {
+		log.Printf("digests: %v", err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	if err := digestService.sendDigest(ctx, userID, frequency, dateFrom, dateTo, now); err != nil {
+		log.Printf("digests: %s digest of user %d: %v", frequency, userID, err)
+		if err := digestService.digestRepo.ReleaseSend(ctx, userID, frequency, sendOn, lastSent); err != nil {
+			log.Printf("digests: %v", err)
+		}
+	}
+}
+
+func (digestService *DigestService) sendDigest(ctx context.Context, userID int64, frequency dto.Frequency, dateFrom time.Time, dateTo time.Time, now time.Time) error {
+	user, err := digestService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	digest, err := digestService.buildDigest(ctx, user, frequency, dateFrom, dateTo)
+	if err != nil {
+		return err
+	}
+	html, err := report.RenderDigest(digest)
+	if err != nil {
+		return err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return digestService.mailer.Send(sendCtx, user.Email, notify.Message{
+		UserID:    user.ID,
+		Kind:      "DIGEST",
+		Title:     report.DigestTitle(digest),
+		Body:      digestText(digest),
+		HTML:      html,
+		CreatedAt: now,
+	})
+}
+
+// digestText è la versione testuale dell'email, per i client che non mostrano l'HTML.
+func digestText(digest dto.Digest) string {
+	text := fmt.Sprintf("%s\n\nEntrate: %s\nUscite: %s\nRisparmio: %s\n",
+		report.DigestTitle(digest),
+		report.Money(digest.Income),
+		report.Money(digest.Expenses),
+		report.Money(digest.Income-digest.Expenses))
+	for _, account := range digest.Accounts {
+		text += fmt.Sprintf("\n%s: %s %s", account.Name, report.Money(account.ClosingBalance), account.Currency)
+	}
+	return text + "\n"
+}
+
+// StartDigests esegue RunDigests subito e poi a ogni intervallo, finché il contesto non viene annullato.
+func (digestService *DigestService) StartDigests(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			digestService.RunDigests(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func toDigestSettings(settings dbgen.DigestSetting) dto.DigestSettings {
+	return dto.DigestSettings{
+		UserID:  settings.UserID,
+		Weekly:  settings.Weekly,
+		Monthly: settings.Monthly,
+		Weekday: time.Weekday(settings.Weekday),
+	}
+}
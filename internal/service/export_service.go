@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/export"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+// exportBatchSize è il numero di movimenti letti dal database per volta durante l'esportazione.
+const exportBatchSize = 1000
+
+var (
+	accountExportColumns = []export.Column{
+		{Name: "id", Kind: export.Integer},
+		{Name: "name", Kind: export.Text},
+		{Name: "currency", Kind: export.Text},
+		{Name: "initialBalance", Kind: export.Money},
+		{Name: "balance", Kind: export.Money},
+		{Name: "overdraftLimit", Kind: export.Money},
+		{Name: "householdId", Kind: export.Integer},
+	}
+	categoryExportColumns = []export.Column{
+		{Name: "id", Kind: export.Integer},
+		{Name: "name", Kind: export.Text},
+		{Name: "path", Kind: export.Text},
+		{Name: "categoryType", Kind: export.Text},
+		{Name: "parentId", Kind: export.Integer},
+		{Name: "archived", Kind: export.Bool},
+		{Name: "monthlyBudget", Kind: export.Money},
+		{Name: "deductionCode", Kind: export.Text},
+		{Name: "householdId", Kind: export.Integer},
+	}
+	transactionExportColumns = []export.Column{
+		{Name: "transactionId", Kind: export.Integer},
+		{Name: "entryId", Kind: export.Integer},
+		{Name: "occurredAt", Kind: export.Date},
+		{Name: "accountName", Kind: export.Text},
+		{Name: "currency", Kind: export.Text},
+		{Name: "categoryName", Kind: export.Text},
+		{Name: "categoryType", Kind: export.Text},
+		{Name: "payeeName", Kind: export.Text},
+		{Name: "description", Kind: export.Text},
+		{Name: "amount", Kind: export.Money},
+		{Name: "status", Kind: export.Text},
+		{Name: "tags", Kind: export.List},
+	}
+)
+
+// ExportService esporta account, categorie e movimenti dell'utente in CSV, JSON o XLSX.
+type ExportService struct {
+	userRepo     repo.UserRepository
+	accountRepo  repo.AccountRepository
+	categoryRepo repo.CategoryRepository
+	exportRepo   repo.ExportRepository
+}
+
+func NewExportService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	categoryRepo repo.CategoryRepository,
+	exportRepo repo.ExportRepository,
+) *ExportService {
+	return &ExportService{
+		userRepo:     userRepo,
+		accountRepo:  accountRepo,
+		categoryRepo: categoryRepo,
+		exportRepo:   exportRepo,
+	}
+}
+
+// Export verifica i filtri e prepara il file: i movimenti vengono letti a lotti di
+// exportBatchSize mentre il contenuto è letto, quindi ctx deve restare valido fino alla fine
+// del download.
+func (exportService *ExportService) Export(ctx context.Context, filter dto.ExportFilter, now time.Time) (dto.ExportFile, error) {
+	if filter.Format != dto.ExportCSV && filter.Format != dto.ExportJSON && filter.Format != dto.ExportXLSX {
+		return dto.ExportFile{}, fmt.Errorf("%w: unknown format %q", apierr.ErrInvalidData, filter.Format)
+	}
+	if filter.DateFrom != nil && filter.DateTo != nil && filter.DateFrom.After(*filter.DateTo) {
+		return dto.ExportFile{}, fmt.Errorf("%w: dateFrom must not be after dateTo", apierr.ErrInvalidData)
+	}
+
+	user, err := exportService.userRepo.GetUserByID(ctx, filter.UserID)
+	if err != nil {
+		return dto.ExportFile{}, err
+	}
+	if filter.AccountID != nil {
+		if _, err := exportService.accountRepo.GetAccountByID(ctx, user, *filter.AccountID); err != nil {
+			return dto.ExportFile{}, err
+		}
+	}
+
+	var (
+		name    string
+		sheet   string
+		columns []export.Column
+		next    export.NextFunc
+	)
+	switch filter.Resource {
+	case dto.ExportAccounts:
+		name, sheet, columns = "account", "Account", accountExportColumns
+		next = exportService.accountRows(user, filter)
+	case dto.ExportCategories:
+		name, sheet, columns = "categorie", "Categorie", categoryExportColumns
+		next = exportService.categoryRows(user)
+	case dto.ExportTransactions:
+		name, sheet, columns = "transazioni", "Transazioni", transactionExportColumns
+		next = exportService.transactionRows(user, filter)
+	default:
+		return dto.ExportFile{}, fmt.Errorf("%w: unknown resource %q", apierr.ErrInvalidData, filter.Resource)
+	}
+
+	content, err := export.NewReader(ctx, filter.Format, sheet, columns, next)
+	if err != nil {
+		return dto.ExportFile{}, err
+	}
+	return dto.ExportFile{
+		Filename:    fmt.Sprintf("koin-%s-%s.%s", name, now.Format("2006-01-02"), export.Extension(filter.Format)),
+		ContentType: export.ContentType(filter.Format),
+		Content:     content,
+	}, nil
+}
+
+func (exportService *ExportService) accountRows(user dbgen.User, filter dto.ExportFilter) export.NextFunc {
+	done := false
+	return func(ctx context.Context) ([][]any, error) {
+		if done {
+			return nil, nil
+		}
+		done = true
+
+		accounts, err := exportService.exportRepo.GetAccounts(ctx, user, filter.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([][]any, len(accounts))
+		for i, account := range accounts {
+			rows[i] = []any{
+				account.ID,
+				account.Name,
+				account.Currency,
+				account.InitialBalance,
+				account.Balance,
+				account.OverdraftLimit,
+				nullInt64Value(account.HouseholdID),
+			}
+		}
+		return rows, nil
+	}
+}
+
+func (exportService *ExportService) categoryRows(user dbgen.User) export.NextFunc {
+	done := false
+	return func(ctx context.Context) ([][]any, error) {
+		if done {
+			return nil, nil
+		}
+		done = true
+
+		categories, err := exportService.categoryRepo.GetCategories(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		paths := CategoryPaths(categories)
+		rows := make([][]any, len(categories))
+		for i, category := range categories {
+			rows[i] = []any{
+				category.ID,
+				category.Name,
+				paths[category.ID],
+				category.Type,
+				nullInt64Value(category.ParentID),
+				category.ArchivedAt.Valid,
+				nullInt64Value(category.MonthlyBudget),
+				nullStringValue(category.DeductionCode),
+				nullInt64Value(category.HouseholdID),
+			}
+		}
+		return rows, nil
+	}
+}
+
+// transactionRows legge i movimenti a lotti, ripartendo ogni volta dopo l'ultimo ricevuto.
+func (exportService *ExportService) transactionRows(user dbgen.User, filter dto.ExportFilter) export.NextFunc {
+	var last *dbgen.GetExportEntriesRow
+	done := false
+	return func(ctx context.Context) ([][]any, error) {
+		if done {
+			return nil, nil
+		}
+
+		entries, err := exportService.exportRepo.GetEntries(ctx, user, filter, last, exportBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) < exportBatchSize {
+			done = true
+		}
+		if len(entries) > 0 {
+			last = &entries[len(entries)-1]
+		}
+
+		rows := make([][]any, len(entries))
+		for i, entry := range entries {
+			tags := []string{}
+			if entry.Tags != "" {
+				tags = strings.Split(entry.Tags, ",")
+			}
+			rows[i] = []any{
+				entry.TransactionID,
+				entry.EntryID,
+				entry.OccurredAt,
+				entry.AccountName,
+				entry.Currency,
+				nullStringValue(entry.CategoryName),
+				nullStringValue(entry.CategoryType),
+				nullStringValue(entry.PayeeName),
+				nullStringValue(entry.Description),
+				entry.Amount,
+				entry.Status,
+				tags,
+			}
+		}
+		return rows, nil
+	}
+}
+
+// nullInt64Value e nullStringValue convertono i valori nullable del database in nil, per le
+// celle vuote dell'esportazione.
+func nullInt64Value(value sql.NullInt64) any {
+	if !value.Valid {
+		return nil
+	}
+	return value.Int64
+}
+
+func nullStringValue(value sql.NullString) any {
+	if !value.Valid {
+		return nil
+	}
+	return value.String
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+// forecastPlan raccoglie, per ogni account, le variazioni di saldo previste giorno per giorno:
+// l'indice 0 è oggi, l'ultimo la fine dell'orizzonte.
+type forecastPlan struct {
+	today  time.Time
+	days   int
+	deltas map[int64][]int64
+	events map[int64][]dto.ForecastEvent
+}
+
+func newForecastPlan(today time.Time, to time.Time) *forecastPlan {
+	return &forecastPlan{
+		today:  today,
+		days:   daysBetween(today, to),
+		deltas: make(map[int64][]int64),
+		events: make(map[int64][]dto.ForecastEvent),
+	}
+}
+
+func (plan *forecastPlan) account(accountID int64) []int64 {
+	deltas, ok := plan.deltas[accountID]
+	if !ok {
+		deltas = make([]int64, plan.days+1)
+		plan.deltas[accountID] = deltas
+	}
+	return deltas
+}
+
+// add registra un movimento previsto. Quelli già scaduti ma non ancora registrati sono
+// anticipati a domani, quelli oltre l'orizzonte vengono ignorati.
+func (plan *forecastPlan) add(event dto.ForecastEvent) {
+	index := daysBetween(plan.today, event.Date)
+	if index > plan.days || event.Amount == 0 {
+		return
+	}
+	if index < 1 {
+		index = 1
+		event.Date = plan.today.AddDate(0, 0, 1)
+	}
+	plan.account(event.AccountID)[index] += event.Amount
+	plan.events[event.AccountID] = append(plan.events[event.AccountID], event)
+}
+
+// spread distribuisce una spesa mensile sui giorni dell'orizzonte in proporzione ai giorni
+// trascorsi (un mese medio dura 365/12 giorni); la spesa di ogni giorno è la differenza tra
+// i totali cumulati, così gli arrotondamenti non perdono centesimi.
+func (plan *forecastPlan) spread(accountID int64, monthly int64) {
+	deltas := plan.account(accountID)
+	var spent int64
+	for i := 1; i <= plan.days; i++ {
+		cumulative := monthly * 12 * int64(i) / 365
+		deltas[i] -= cumulative - spent
+		spent = cumulative
+	}
+}
+
+// between somma le variazioni previste dell'account dopo from e fino a to (incluso).
+func (plan *forecastPlan) between(accountID int64, from time.Time, to time.Time) int64 {
+	deltas := plan.account(accountID)
+	var sum int64
+	for i := max(daysBetween(plan.today, from)+1, 1); i <= min(daysBetween(plan.today, to), plan.days); i++ {
+		sum += deltas[i]
+	}
+	return sum
+}
+
+// cardDues prevede gli addebiti della carta sul conto di pagamento, assumendo che ogni estratto
+// conto venga saldato per intero alla scadenza: il residuo degli estratti già chiusi e, per ogni
+// ciclo che scade entro l'orizzonte, le spese del ciclo (già registrate e previste). Le spese
+// previste sulla carta devono essere già nel piano; gli addebiti vanno aggiunti solo dopo aver
+// calcolato quelli di tutte le carte.
+func (plan *forecastPlan) cardDues(creditCard dbgen.CreditCard, name string, statements []dto.CreditCardStatement) []dto.ForecastEvent {
+	var dues []dto.ForecastEvent
+	due := func(date time.Time, amount int64) {
+		if amount <= 0 {
+			return
+		}
+		description := fmt.Sprintf("Saldo carta %s", name)
+		dues = append(dues,
+			dto.ForecastEvent{Date: date, AccountID: creditCard.PaymentAccountID, Kind: dto.EventCreditCardDue, Amount: -amount, Description: description},
+			dto.ForecastEvent{Date: date, AccountID: creditCard.AccountID, Kind: dto.EventCreditCardDue, Amount: amount, Description: description},
+		)
+	}
+
+	for _, statement := range statements[1:] {
+		if statement.Status == dto.StatementDue || statement.Status == dto.StatementOverdue {
+			due(statement.DueDate, statement.Outstanding)
+		}
+	}
+
+	closing := statements[0].ClosingDate
+	owed := statements[0].Outstanding - plan.between(creditCard.AccountID, plan.today, closing)
+	for daysBetween(plan.today, dueDate(closing, creditCard)) <= plan.days {
+		due(dueDate(closing, creditCard), owed)
+		next := closingInMonth(closing, 1, creditCard.ClosingDay)
+		owed = -plan.between(creditCard.AccountID, closing, next)
+		closing = next
+	}
+	return dues
+}
+
+// project calcola il saldo a fine giornata lungo l'orizzonte. Con alerts segnala i giorni in
+// cui il saldo scende sotto zero oppure oltre il fido, se l'account ne ha uno.
+func (plan *forecastPlan) project(account dbgen.Account, start int64, alerts bool) dto.AccountForecast {
+	deltas := plan.account(account.ID)
+	events := plan.events[account.ID]
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+
+	forecast := dto.AccountForecast{
+		Account:        account,
+		StartBalance:   start,
+		MinBalance:     start,
+		MinBalanceDate: plan.today,
+		Points:         make([]dto.ForecastPoint, plan.days+1),
+		Events:         events,
+	}
+	balance := start
+	var previous dto.ForecastAlertKind
+	for i := 0; i <= plan.days; i++ {
+		balance += deltas[i]
+		date := plan.today.AddDate(0, 0, i)
+		forecast.Points[i] = dto.ForecastPoint{Date: date, Balance: balance}
+		if balance < forecast.MinBalance {
+			forecast.MinBalance = balance
+			forecast.MinBalanceDate = date
+		}
+		if !alerts {
+			continue
+		}
+
+		var kind dto.ForecastAlertKind
+		switch {
+		case account.OverdraftLimit > 0 && balance < -account.OverdraftLimit:
+			kind = dto.AlertBelowOverdraft
+		case balance < 0:
+			kind = dto.AlertBelowZero
+		}
+		if kind != "" && kind != previous && (previous == "" || kind == dto.AlertBelowOverdraft) {
+			forecast.Alerts = append(forecast.Alerts, dto.ForecastAlert{Date: date, Kind: kind, Balance: balance})
+		}
+		previous = kind
+	}
+	return forecast
+}
+
+// occurrences restituisce le date della ricorrenza comprese tra from e to. Le ricorrenze mensili
+// e annuali che cadono in un giorno assente nel mese passano all'ultimo giorno del mese.
+func occurrences(recurring dbgen.GetRecurringTransactionsByUserRow, from time.Time, to time.Time) []time.Time {
+	start := toDate(recurring.StartDate)
+	every := int(recurring.RepeatEvery)
+
+	var dates []time.Time
+	for n := 0; ; n++ {
+		var date time.Time
+		switch dto.Frequency(recurring.Frequency) {
+		case dto.Weekly:
+			date = start.AddDate(0, 0, 7*every*n)
+		case dto.Yearly:
+			date = closingInMonth(start, 12*every*n, int32(start.Day()))
+		default:
+			date = closingInMonth(start, every*n, int32(start.Day()))
+		}
+		if date.After(to) || (recurring.EndDate.Valid && date.After(toDate(recurring.EndDate.Time))) {
+			return dates
+		}
+		if !date.Before(from) {
+			dates = append(dates, date)
+		}
+	}
+}
+
+func daysBetween(from time.Time, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+// maxForecastMonths limita sia l'orizzonte della previsione sia lo storico usato per le medie.
+const maxForecastMonths = 24
+
+type ForecastService struct {
+	userRepo       repo.UserRepository
+	accountRepo    repo.AccountRepository
+	categoryRepo   repo.CategoryRepository
+	creditCardRepo repo.CreditCardRepository
+	loanRepo       repo.LoanRepository
+	forecastRepo   repo.ForecastRepository
+}
+
+func NewForecastService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	categoryRepo repo.CategoryRepository,
+	creditCardRepo repo.CreditCardRepository,
+	loanRepo repo.LoanRepository,
+	forecastRepo repo.ForecastRepository,
+) *ForecastService {
+	return &ForecastService{
+		userRepo:       userRepo,
+		accountRepo:    accountRepo,
+		categoryRepo:   categoryRepo,
+		creditCardRepo: creditCardRepo,
+		loanRepo:       loanRepo,
+		forecastRepo:   forecastRepo,
+	}
+}
+
+// CreateRecurring registra una transazione ricorrente nota. L'importo deve avere il segno del
+// tipo di categoria: negativo per le spese, positivo per le entrate.
+func (forecastService *ForecastService) CreateRecurring(ctx context.Context, createDto dto.CreateRecurringDto) (dbgen.GetRecurringTransactionsByUserRow, error) {
+	createDto.Description = strings.TrimSpace(createDto.Description)
+	if createDto.Description == "" {
+		return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: description is required", apierr.ErrInvalidData)
+	}
+	switch createDto.Frequency {
+	case dto.Weekly, dto.Monthly, dto.Yearly:
+	default:
+		return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: unknown frequency %q", apierr.ErrInvalidData, createDto.Frequency)
+	}
+	if createDto.RepeatEvery <= 0 {
+		return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: repeatEvery must be positive", apierr.ErrInvalidData)
+	}
+	createDto.StartDate = toDate(createDto.StartDate)
+	if createDto.EndDate != nil {
+		endDate := toDate(*createDto.EndDate)
+		if endDate.Before(createDto.StartDate) {
+			return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: endDate is before startDate", apierr.ErrInvalidData)
+		}
+		createDto.EndDate = &endDate
+	}
+
+	user, err := forecastService.userRepo.GetUserByID(ctx, createDto.UserID)
+	if err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+
+	account, err := forecastService.accountRepo.GetAccountByID(ctx, user, createDto.AccountID)
+	if err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+	if err := ensureCanEditAccount(ctx, forecastService.accountRepo, user, account); err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+
+	category, err := forecastService.categoryRepo.GetCategoryByID(ctx, user, createDto.CategoryID)
+	if err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+	switch dto.CategoryType(category.Type) {
+	case dto.Expense:
+		if createDto.Amount >= 0 {
+			return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: an expense needs a negative amount", apierr.ErrInvalidData)
+		}
+	case dto.Income:
+		if createDto.Amount <= 0 {
+			return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: an income needs a positive amount", apierr.ErrInvalidData)
+		}
+	default:
+		return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: category %q is neither an expense nor an income", apierr.ErrInvalidData, category.Name)
+	}
+
+	recurring, err := forecastService.forecastRepo.CreateRecurring(ctx, createDto)
+	if err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+
+	return recurringRow(recurring, account.Name, category.Name), nil
+}
+
+func (forecastService *ForecastService) GetRecurring(ctx context.Context, userID int64) ([]dbgen.GetRecurringTransactionsByUserRow, error) {
+	user, err := forecastService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return forecastService.forecastRepo.GetRecurring(ctx, user)
+}
+
+func (forecastService *ForecastService) DeleteRecurring(ctx context.Context, userID int64, recurringID int64) error {
+	user, err := forecastService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	recurring, err := forecastService.forecastRepo.GetRecurringByID(ctx, user, recurringID)
+	if err != nil {
+		return err
+	}
+
+	account, err := forecastService.accountRepo.GetAccountByID(ctx, user, recurring.AccountID)
+	if err != nil {
+		return err
+	}
+	if err := ensureCanEditAccount(ctx, forecastService.accountRepo, user, account); err != nil {
+		return err
+	}
+
+	return forecastService.forecastRepo.DeleteRecurring(ctx, recurring)
+}
+
+// SetOverdraft imposta il fido dell'account: la previsione segnala quando il saldo lo supera.
+func (forecastService *ForecastService) SetOverdraft(ctx context.Context, setDto dto.SetOverdraftDto) (dbgen.Account, error) {
+	if setDto.OverdraftLimit < 0 {
+		return dbgen.Account{}, fmt.Errorf("%w: overdraft limit cannot be negative", apierr.ErrInvalidData)
+	}
+
+	user, err := forecastService.userRepo.GetUserByID(ctx, setDto.UserID)
+	if err != nil {
+		return dbgen.Account{}, err
+	}
+
+	account, err := forecastService.accountRepo.GetAccountByID(ctx, user, setDto.AccountID)
+	if err != nil {
+		return dbgen.Account{}, err
+	}
+	if err := ensureCanEditAccount(ctx, forecastService.accountRepo, user, account); err != nil {
+		return dbgen.Account{}, err
+	}
+
+	return forecastService.forecastRepo.SetOverdraft(ctx, account, setDto.OverdraftLimit)
+}
+
+// GetForecast prevede il saldo di ogni account giorno per giorno da oggi ai prossimi mesi. Oltre
+// ai saldi attuali considera le transazioni ricorrenti, gli addebiti delle carte di credito, le
+// rate dei prestiti non ancora contabilizzate e la spesa discrezionale media per categoria degli
+// ultimi HistoryMonths mesi. Le carte e i prestiti hanno per natura saldo negativo, per cui
+// non generano segnalazioni.
+func (forecastService *ForecastService) GetForecast(ctx context.Context, forecastDto dto.ForecastDto, today time.Time) (dto.Forecast, error) {
+	if forecastDto.Months < 1 || forecastDto.Months > maxForecastMonths {
+		return dto.Forecast{}, fmt.Errorf("%w: months must be between 1 and %d", apierr.ErrInvalidData, maxForecastMonths)
+	}
+	if forecastDto.HistoryMonths < 1 || forecastDto.HistoryMonths > maxForecastMonths {
+		return dto.Forecast{}, fmt.Errorf("%w: historyMonths must be between 1 and %d", apierr.ErrInvalidData, maxForecastMonths)
+	}
+
+	user, err := forecastService.userRepo.GetUserByID(ctx, forecastDto.UserID)
+	if err != nil {
+		return dto.Forecast{}, err
+	}
+
+	today = toDate(today)
+	to := today.AddDate(0, forecastDto.Months, 0)
+	plan := newForecastPlan(today, to)
+
+	accounts, err := forecastService.accountRepo.GetAccounts(ctx, user)
+	if err != nil {
+		return dto.Forecast{}, err
+	}
+	names := make(map[int64]string, len(accounts))
+	for _, account := range accounts {
+		names[account.ID] = account.Name
+	}
+
+	spending, err := forecastService.forecastRepo.GetDiscretionarySpending(ctx, user, today.AddDate(0, -forecastDto.HistoryMonths, 1), today)
+	if err != nil {
+		return dto.Forecast{}, err
+	}
+	history := int64(forecastDto.HistoryMonths)
+	discretionary := make(map[int64][]dto.CategoryAverage)
+	for _, row := range spending {
+		discretionary[row.AccountID] = append(discretionary[row.AccountID], dto.CategoryAverage{
+			CategoryID:   row.CategoryID.Int64,
+			CategoryName: row.CategoryName,
+			Monthly:      (row.Total + history/2) / history,
+		})
+	}
+	for accountID, averages := range discretionary {
+		var monthly int64
+		for _, average := range averages {
+			monthly += average.Monthly
+		}
+		plan.spread(accountID, monthly)
+	}
+
+	recurring, err := forecastService.forecastRepo.GetRecurring(ctx, user)
+	if err != nil {
+		return dto.Forecast{}, err
+	}
+	for _, item := range recurring {
+		for _, date := range occurrences(item, today.AddDate(0, 0, 1), to) {
+			plan.add(dto.ForecastEvent{
+				Date:        date,
+				AccountID:   item.AccountID,
+				Kind:        dto.EventRecurring,
+				Amount:      item.Amount,
+				Description: item.Description,
+			})
+		}
+	}
+
+	noAlerts := make(map[int64]bool)
+	loans, err := forecastService.loanRepo.GetLoans(ctx, user)
+	if err != nil {
+		return dto.Forecast{}, err
+	}
+	for _, loan := range loans {
+		noAlerts[loan.AccountID] = true
+		installments, err := forecastService.loanRepo.GetInstallments(ctx, loan)
+		if err != nil {
+			return dto.Forecast{}, err
+		}
+		for _, installment := range installments {
+			if installment.TransactionID.Valid || installment.DueDate.After(to) {
+				continue
+			}
+			description := fmt.Sprintf("Rata %d/%d %s", installment.Number, loan.TermMonths, names[loan.AccountID])
+			plan.add(dto.ForecastEvent{
+				Date:        installment.DueDate,
+				AccountID:   loan.PaymentAccountID,
+				Kind:        dto.EventLoanInstallment,
+				Amount:      -(installment.Principal + installment.Interest),
+				Description: description,
+			})
+			plan.add(dto.ForecastEvent{
+				Date:        installment.DueDate,
+				AccountID:   loan.AccountID,
+				Kind:        dto.EventLoanInstallment,
+				Amount:      installment.Principal,
+				Description: description,
+			})
+		}
+	}
+
+	creditCards, err := forecastService.creditCardRepo.GetCreditCards(ctx, user)
+	if err != nil {
+		return dto.Forecast{}, err
+	}
+	var dues []dto.ForecastEvent
+	for _, creditCard := range creditCards {
+		noAlerts[creditCard.AccountID] = true
+		latest := currentClosing(today, creditCard.ClosingDay)
+		movements, err := forecastService.creditCardRepo.GetMovements(ctx, creditCard, closingInMonth(latest, -2, creditCard.ClosingDay))
+		if err != nil {
+			return dto.Forecast{}, err
+		}
+		statements := buildStatements(creditCard, latest, 2, movements, today)
+		dues = append(dues, plan.cardDues(creditCard, names[creditCard.AccountID], statements)...)
+	}
+	for _, due := range dues {
+		plan.add(due)
+	}
+
+	forecast := dto.Forecast{
+		From:          today,
+		To:            to,
+		HistoryMonths: forecastDto.HistoryMonths,
+		Accounts:      make([]dto.AccountForecast, len(accounts)),
+	}
+	for i, account := range accounts {
+		balance, err := forecastService.accountRepo.GetAccountBalance(ctx, account.ID)
+		if err != nil {
+			return dto.Forecast{}, err
+		}
+		forecast.Accounts[i] = plan.project(account, account.InitialBalance+balance, !noAlerts[account.ID])
+		forecast.Accounts[i].Discretionary = discretionary[account.ID]
+	}
+	return forecast, nil
+}
+
+// recurringRow completa la transazione ricorrente appena creata con i nomi di account e categoria,
+// come restituiti dall'elenco.
+func recurringRow(recurring dbgen.RecurringTransaction, accountName string, categoryName string) dbgen.GetRecurringTransactionsByUserRow {
+	return dbgen.GetRecurringTransactionsByUserRow{
+		ID:           recurring.ID,
+		UserID:       recurring.UserID,
+		AccountID:    recurring.AccountID,
+		CategoryID:   recurring.CategoryID,
+		Amount:       recurring.Amount,
+		Description:  recurring.Description,
+		Frequency:    recurring.Frequency,
+		RepeatEvery:  recurring.RepeatEvery,
+		StartDate:    recurring.StartDate,
+		EndDate:      recurring.EndDate,
+		CreatedAt:    recurring.CreatedAt,
+		AccountName:  accountName,
+		CategoryName: categoryName,
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+func testDate(value string) time.Time {
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return date
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name      string
+		frequency dto.Frequency
+		every     int32
+		start     string
+		end       string
+		from      string
+		to        string
+		want      []string
+	}{
+		{"monthly on the 31st", dto.Monthly, 1, "2025-01-31", "", "2025-01-01", "2025-04-30", []string{"2025-01-31", "2025-02-28", "2025-03-31", "2025-04-30"}},
+		{"every two weeks", dto.Weekly, 2, "2025-03-03", "", "2025-03-10", "2025-04-14", []string{"2025-03-17", "2025-03-31", "2025-04-14"}},
+		{"quarterly", dto.Monthly, 3, "2024-11-15", "", "2025-01-01", "2025-12-31", []string{"2025-02-15", "2025-05-15", "2025-08-15", "2025-11-15"}},
+		{"yearly on a leap day", dto.Yearly, 1, "2024-02-29", "", "2024-01-01", "2028-12-31", []string{"2024-02-29", "2025-02-28", "2026-02-28", "2027-02-28", "2028-02-29"}},
+		{"stops at the end date", dto.Monthly, 1, "2025-01-10", "2025-03-10", "2025-01-01", "2025-12-31", []string{"2025-01-10", "2025-02-10", "2025-03-10"}},
+		{"starts after the range", dto.Monthly, 1, "2026-01-10", "", "2025-01-01", "2025-12-31", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recurring := dbgen.GetRecurringTransactionsByUserRow{
+				Frequency:   string(tt.frequency),
+				RepeatEvery: tt.every,
+				StartDate:   testDate(tt.start),
+			}
+			if tt.end != "" {
+				recurring.EndDate = sql.NullTime{Time: testDate(tt.end), Valid: true}
+			}
+			dates := occurrences(recurring, testDate(tt.from), testDate(tt.to))
+			if len(dates) != len(tt.want) {
+				t.Fatalf("got %v, want %v", dates, tt.want)
+			}
+			for i, want := range tt.want {
+				if !dates[i].Equal(testDate(want)) {
+					t.Errorf("occurrence %d = %s, want %s", i, dates[i].Format("2006-01-02"), want)
+				}
+			}
+		})
+	}
+}
+
+func TestForecastPlanSpreadKeepsEveryCent(t *testing.T) {
+	today := testDate("2025-01-01")
+	plan := newForecastPlan(today, today.AddDate(0, 0, 365))
+	plan.spread(1, 30001)
+	if spent := -plan.between(1, today, today.AddDate(0, 0, 365)); spent != 30001*12 {
+		t.Errorf("spread over a year = %d, want %d", spent, 30001*12)
+	}
+}
+
+func TestForecastPlanAdd(t *testing.T) {
+	today := testDate("2025-01-01")
+	plan := newForecastPlan(today, testDate("2025-01-31"))
+	plan.add(dto.ForecastEvent{Date: testDate("2024-12-28"), AccountID: 1, Amount: -100})
+	plan.add(dto.ForecastEvent{Date: testDate("2025-01-15"), AccountID: 1, Amount: -200})
+	plan.add(dto.ForecastEvent{Date: testDate("2025-02-01"), AccountID: 1, Amount: -400})
+
+	deltas := plan.account(1)
+	if deltas[1] != -100 {
+		t.Errorf("overdue event: delta on the next day = %d, want -100", deltas[1])
+	}
+	if deltas[14] != -200 {
+		t.Errorf("delta on 2025-01-15 = %d, want -200", deltas[14])
+	}
+	if total := plan.between(1, today, testDate("2025-01-31")); total != -300 {
+		t.Errorf("total within the horizon = %d, want -300", total)
+	}
+	if events := plan.events[1]; len(events) != 2 || !events[0].Date.Equal(testDate("2025-01-02")) {
+		t.Errorf("events = %+v, want two with the overdue one moved to 2025-01-02", events)
+	}
+}
+
+func TestForecastPlanProjectAlerts(t *testing.T) {
+	today := testDate("2025-01-01")
+	plan := newForecastPlan(today, testDate("2025-01-10"))
+	for _, event := range []struct {
+		date   string
+		amount int64
+	}{
+		{"2025-01-02", -15000}, // sotto zero
+		{"2025-01-03", -1000},  // ancora sotto zero: nessun nuovo avviso
+		{"2025-01-04", -50000}, // oltre il fido
+		{"2025-01-06", 70000},  // di nuovo positivo
+		{"2025-01-08", -20000}, // sotto zero una seconda volta
+	} {
+		plan.add(dto.ForecastEvent{Date: testDate(event.date), AccountID: 1, Amount: event.amount})
+	}
+
+	forecast := plan.project(dbgen.Account{ID: 1, OverdraftLimit: 30000}, 10000, true)
+	want := []struct {
+		date string
+		kind dto.ForecastAlertKind
+	}{
+		{"2025-01-02", dto.AlertBelowZero},
+		{"2025-01-04", dto.AlertBelowOverdraft},
+		{"2025-01-08", dto.AlertBelowZero},
+	}
+	if len(forecast.Alerts) != len(want) {
+		t.Fatalf("got alerts %+v, want %d", forecast.Alerts, len(want))
+	}
+	for i, alert := range want {
+		if !forecast.Alerts[i].Date.Equal(testDate(alert.date)) || forecast.Alerts[i].Kind != alert.kind {
+			t.Errorf("alert %d = %+v, want %s on %s", i, forecast.Alerts[i], alert.kind, alert.date)
+		}
+	}
+	if forecast.MinBalance != -56000 || !forecast.MinBalanceDate.Equal(testDate("2025-01-04")) {
+		t.Errorf("minimum %d on %s, want -56000 on 2025-01-04", forecast.MinBalance, forecast.MinBalanceDate.Format("2006-01-02"))
+	}
+	if last := forecast.Points[len(forecast.Points)-1].Balance; last != -6000 {
+		t.Errorf("closing balance = %d, want -6000", last)
+	}
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+// inviteValidity è la durata predefinita di un invito.
+const inviteValidity = 7 * 24 * time.Hour
+
+type HouseholdService struct {
+	userRepo      repo.UserRepository
+	accountRepo   repo.AccountRepository
+	categoryRepo  repo.CategoryRepository
+	householdRepo repo.HouseholdRepository
+}
+
+func NewHouseholdService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	categoryRepo repo.CategoryRepository,
+	householdRepo repo.HouseholdRepository,
+) *HouseholdService {
+	return &HouseholdService{
+		userRepo:      userRepo,
+		accountRepo:   accountRepo,
+		categoryRepo:  categoryRepo,
+		householdRepo: householdRepo,
+	}
+}
+
+// CreateHousehold crea una household di cui l'utente diventa OWNER.
+func (householdService *HouseholdService) CreateHousehold(ctx context.Context, createDto dto.CreateHouseholdDto) (dto.HouseholdDetail, error) {
+	name := strings.TrimSpace(createDto.Name)
+	if name == "" {
+		return dto.HouseholdDetail{}, fmt.Errorf("%w: name is required", apierr.ErrInvalidData)
+	}
+
+	user, err := householdService.userRepo.GetUserByID(ctx, createDto.UserID)
+	if err != nil {
+		return dto.HouseholdDetail{}, err
+	}
+
+	household, err := householdService.householdRepo.CreateHousehold(ctx, user, name)
+	if err != nil {
+		return dto.HouseholdDetail{}, err
+	}
+
+	return householdService.detail(ctx, household, dto.Owner)
+}
+
+func (householdService *HouseholdService) GetHouseholds(ctx context.Context, userID int64) ([]dbgen.GetHouseholdsByUserRow, error) {
+	user, err := householdService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return householdService.householdRepo.GetHouseholds(ctx, user)
+}
+
+// GetHousehold restituisce membri e, solo agli OWNER, gli inviti in attesa.
+func (householdService *HouseholdService) GetHousehold(ctx context.Context, userID int64, householdID int64) (dto.HouseholdDetail, error) {
+	_, household, member, err := householdService.load(ctx, userID, householdID)
+	if err != nil {
+		return dto.HouseholdDetail{}, err
+	}
+
+	return householdService.detail(ctx, household, dto.HouseholdRole(member.Role))
+}
+
+// CreateInvite genera un codice d'invito monouso. Solo gli OWNER possono invitare.
+func (householdService *HouseholdService) CreateInvite(ctx context.Context, inviteDto dto.CreateInviteDto, now time.Time) (dbgen.HouseholdInvite, error) {
+	if inviteDto.Role == "" {
+		inviteDto.Role = dto.Editor
+	}
+	if !validRole(inviteDto.Role) {
+		return dbgen.HouseholdInvite{}, fmt.Errorf("%w: unknown role %q", apierr.ErrInvalidData, inviteDto.Role)
+	}
+	if inviteDto.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*inviteDto.Email))
+		if !strings.Contains(email, "@") {
+			return dbgen.HouseholdInvite{}, fmt.Errorf("%w: invalid email %q", apierr.ErrInvalidData, *inviteDto.Email)
+		}
+		inviteDto.Email = &email
+	}
+	if inviteDto.ExpiresAt.IsZero() {
+		inviteDto.ExpiresAt = now.Add(inviteValidity)
+	}
+	if !inviteDto.ExpiresAt.After(now) {
+		return dbgen.HouseholdInvite{}, fmt.Errorf("%w: expiration must be in the future", apierr.ErrInvalidData)
+	}
+
+	_, household, err := householdService.loadAsOwner(ctx, inviteDto.UserID, inviteDto.HouseholdID)
+	if err != nil {
+		return dbgen.HouseholdInvite{}, err
+	}
+
+	code, err := inviteCode()
+	if err != nil {
+		return dbgen.HouseholdInvite{}, err
+	}
+
+	return householdService.householdRepo.CreateInvite(ctx, household, code, inviteDto)
+}
+
+func (householdService *HouseholdService) RevokeInvite(ctx context.Context, userID int64, householdID int64, inviteID int64) error {
+	_, household, err := householdService.loadAsOwner(ctx, userID, householdID)
+	if err != nil {
+		return err
+	}
+
+	return householdService.householdRepo.RevokeInvite(ctx, household, inviteID)
+}
+
+// AcceptInvite aggiunge l'utente alla household dell'invito. Un invito con email può essere
+// accettato solo dall'utente registrato con quell'indirizzo.
+func (householdService *HouseholdService) AcceptInvite(ctx context.Context, userID int64, code string, now time.Time) (dto.HouseholdDetail, error) {
+	user, err := householdService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.HouseholdDetail{}, err
+	}
+
+	invite, err := householdService.householdRepo.GetInviteByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return dto.HouseholdDetail{}, err
+	}
+	if invite.AcceptedAt.Valid || !invite.ExpiresAt.After(now) {
+		return dto.HouseholdDetail{}, fmt.Errorf("%w: invite already used or expired", apierr.ErrConflict)
+	}
+	if invite.Email.Valid && !strings.EqualFold(invite.Email.String, user.Email) {
+		return dto.HouseholdDetail{}, fmt.Errorf("%w: invite is reserved to another email address", apierr.ErrForbidden)
+	}
+
+	household, err := householdService.householdRepo.GetHousehold(ctx, invite.HouseholdID)
+	if err != nil {
+		return dto.HouseholdDetail{}, err
+	}
+	_, err = householdService.householdRepo.GetMember(ctx, household, user.ID)
+	if err == nil {
+		return dto.HouseholdDetail{}, fmt.Errorf("%w: already a member of household %q", apierr.ErrConflict, household.Name)
+	}
+	if !errors.Is(err, apierr.ErrHouseholdNotFound) {
+		return dto.HouseholdDetail{}, err
+	}
+
+	member, err := householdService.householdRepo.AcceptInvite(ctx, user, invite)
+	if err != nil {
+		return dto.HouseholdDetail{}, err
+	}
+
+	return householdService.detail(ctx, household, dto.HouseholdRole(member.Role))
+}
+
+// UpdateMemberRole cambia il ruolo di un membro. Una household deve avere sempre almeno un OWNER.
+func (householdService *HouseholdService) UpdateMemberRole(ctx context.Context, userID int64, householdID int64, memberID int64, role dto.HouseholdRole) (dbgen.HouseholdMember, error) {
+	if !validRole(role) {
+		return dbgen.HouseholdMember{}, fmt.Errorf("%w: unknown role %q", apierr.ErrInvalidData, role)
+	}
+
+	_, household, err := householdService.loadAsOwner(ctx, userID, householdID)
+	if err != nil {
+		return dbgen.HouseholdMember{}, err
+	}
+
+	member, err := householdService.householdRepo.GetMember(ctx, household, memberID)
+	if err != nil {
+		return dbgen.HouseholdMember{}, err
+	}
+	if member.Role == string(dto.Owner) && role != dto.Owner {
+		if err := householdService.ensureAnotherOwner(ctx, household); err != nil {
+			return dbgen.HouseholdMember{}, err
+		}
+	}
+
+	return householdService.householdRepo.UpdateMemberRole(ctx, household, memberID, role)
+}
+
+// RemoveMember toglie un membro dalla household: gli OWNER possono rimuovere chiunque, gli altri
+// solo se stessi. I suoi account e le sue categorie smettono di essere condivisi.
+func (householdService *HouseholdService) RemoveMember(ctx context.Context, userID int64, householdID int64, memberID int64) error {
+	user, household, member, err := householdService.load(ctx, userID, householdID)
+	if err != nil {
+		return err
+	}
+	if memberID != user.ID && member.Role != string(dto.Owner) {
+		return fmt.Errorf("%w: only owners can remove other members", apierr.ErrForbidden)
+	}
+
+	removed, err := householdService.householdRepo.GetMember(ctx, household, memberID)
+	if err != nil {
+		return err
+	}
+	if removed.Role == string(dto.Owner) {
+		count, err := householdService.householdRepo.CountMembers(ctx, household)
+		if err != nil {
+			return err
+		}
+		// L'ultimo membro può uscire (la household viene eliminata), l'ultimo OWNER no
+		if count.Members > 1 && count.Owners <= 1 {
+			return fmt.Errorf("%w: household %q must keep at least one owner", apierr.ErrConflict, household.Name)
+		}
+	}
+
+	return householdService.householdRepo.RemoveMember(ctx, household, memberID)
+}
+
+// ShareAccount condivide un account dell'utente con una household di cui è membro, o smette
+// di condividerlo se householdID è nil.
+func (householdService *HouseholdService) ShareAccount(ctx context.Context, userID int64, accountID int64, householdID *int64) (dbgen.Account, error) {
+	user, err := householdService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dbgen.Account{}, err
+	}
+
+	account, err := householdService.accountRepo.GetAccountByID(ctx, user, accountID)
+	if err != nil {
+		return dbgen.Account{}, err
+	}
+	if account.UserID != user.ID {
+		return dbgen.Account{}, fmt.Errorf("%w: only the owner can share account %q", apierr.ErrForbidden, account.Name)
+	}
+	if err := householdService.ensureMember(ctx, user, householdID); err != nil {
+		return dbgen.Account{}, err
+	}
+
+	return householdService.householdRepo.ShareAccount(ctx, account, householdID)
+}
+
+// ShareCategory condivide una categoria dell'utente con una household di cui è membro.
+func (householdService *HouseholdService) ShareCategory(ctx context.Context, userID int64, categoryID int64, householdID *int64) (dbgen.Category, error) {
+	user, err := householdService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+
+	category, err := householdService.categoryRepo.GetCategoryByID(ctx, user, categoryID)
+	if err != nil {
+		return dbgen.Category{}, err
+	}
+	if category.UserID != user.ID {
+		return dbgen.Category{}, fmt.Errorf("%w: only the owner can share category %q", apierr.ErrForbidden, category.Name)
+	}
+	if err := householdService.ensureMember(ctx, user, householdID); err != nil {
+		return dbgen.Category{}, err
+	}
+
+	return householdService.householdRepo.ShareCategory(ctx, category, householdID)
+}
+
+// load carica la household verificando che l'utente ne sia membro; ai non membri risponde
+// ErrHouseholdNotFound, senza rivelarne l'esistenza.
+func (householdService *HouseholdService) load(ctx context.Context, userID int64, householdID int64) (dbgen.User, dbgen.Household, dbgen.HouseholdMember, error) {
+	user, err := householdService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dbgen.User{}, dbgen.Household{}, dbgen.HouseholdMember{}, err
+	}
+
+	household, err := householdService.householdRepo.GetHousehold(ctx, householdID)
+	if err != nil {
+		return dbgen.User{}, dbgen.Household{}, dbgen.HouseholdMember{}, err
+	}
+
+	member, err := householdService.householdRepo.GetMember(ctx, household, user.ID)
+	if err != nil {
+		return dbgen.User{}, dbgen.Household{}, dbgen.HouseholdMember{}, err
+	}
+
+	return user, household, member, nil
+}
+
+func (householdService *HouseholdService) loadAsOwner(ctx context.Context, userID int64, householdID int64) (dbgen.User, dbgen.Household, error) {
+	user, household, member, err := householdService.load(ctx, userID, householdID)
+	if err != nil {
+		return dbgen.User{}, dbgen.Household{}, err
+	}
+	if member.Role != string(dto.Owner) {
+		return dbgen.User{}, dbgen.Household{}, fmt.Errorf("%w: only owners can manage household %q", apierr.ErrForbidden, household.Name)
+	}
+	return user, household, nil
+}
+
+func (householdService *HouseholdService) ensureMember(ctx context.Context, user dbgen.User, householdID *int64) error {
+	if householdID == nil {
+		return nil
+	}
+	household, err := householdService.householdRepo.GetHousehold(ctx, *householdID)
+	if err != nil {
+		return err
+	}
+	_, err = householdService.householdRepo.GetMember(ctx, household, user.ID)
+	return err
+}
+
+func (householdService *HouseholdService) ensureAnotherOwner(ctx context.Context, household dbgen.Household) error {
+	count, err := householdService.householdRepo.CountMembers(ctx, household)
+	if err != nil {
+		return err
+	}
+	if count.Owners <= 1 {
+		return fmt.Errorf("%w: household %q must keep at least one owner", apierr.ErrConflict, household.Name)
+	}
+	return nil
+}
+
+func (householdService *HouseholdService) detail(ctx context.Context, household dbgen.Household, role dto.HouseholdRole) (dto.HouseholdDetail, error) {
+	members, err := householdService.householdRepo.GetMembers(ctx, household)
+	if err != nil {
+		return dto.HouseholdDetail{}, err
+	}
+
+	detail := dto.HouseholdDetail{
+		Household: household,
+		Role:      role,
+		Members:   members,
+		Invites:   []dbgen.HouseholdInvite{},
+	}
+	if role == dto.Owner {
+		detail.Invites, err = householdService.householdRepo.GetPendingInvites(ctx, household)
+		if err != nil {
+			return dto.HouseholdDetail{}, err
+		}
+	}
+	return detail, nil
+}
+
+// ensureCanEditAccount impedisce ai membri VIEWER di una household di modificare un account condiviso.
+func ensureCanEditAccount(ctx context.Context, accountRepo repo.AccountRepository, user dbgen.User, account dbgen.Account) error {
+	if account.UserID == user.ID {
+		return nil
+	}
+	role, err := accountRepo.GetAccountRole(ctx, user, account.ID)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return fmt.Errorf("%w: read-only access to account %q", apierr.ErrForbidden, account.Name)
+	}
+	return nil
+}
+
+// ensureCanEditTransaction impedisce di modificare una transazione se uno dei suoi account è in
+// sola lettura per l'utente, anche quando l'ha registrata lui.
+func ensureCanEditTransaction(ctx context.Context, accountRepo repo.AccountRepository, user dbgen.User, transaction dbgen.Transaction) error {
+	role, err := accountRepo.GetTransactionRole(ctx, user, transaction.ID)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return fmt.Errorf("%w: read-only access to transaction %d", apierr.ErrForbidden, transaction.ID)
+	}
+	return nil
+}
+
+// ensureCanEditCategory impedisce di modificare una categoria condivisa in sola lettura.
+func ensureCanEditCategory(ctx context.Context, categoryRepo repo.CategoryRepository, user dbgen.User, category dbgen.Category) error {
+	if category.UserID == user.ID {
+		return nil
+	}
+	role, err := categoryRepo.GetCategoryRole(ctx, user, category.ID)
+	if err != nil {
+		return err
+	}
+	if !role.CanEdit() {
+		return fmt.Errorf("%w: read-only access to category %q", apierr.ErrForbidden, category.Name)
+	}
+	return nil
+}
+
+func validRole(role dto.HouseholdRole) bool {
+	return role == dto.Owner || role == dto.Editor || role == dto.Viewer
+}
+
+// inviteCode genera un codice casuale di 10 caratteri facile da dettare (base32, senza padding).
+func inviteCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate invite code: %w", err)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)[:10], nil
+}
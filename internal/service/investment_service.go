@@ -0,0 +1,571 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+const (
+	feesCategory      = "Commissioni"
+	dividendsCategory = "Dividendi"
+	// MaxPriceImportSize limita la dimensione del CSV dei prezzi (5 MiB).
+	MaxPriceImportSize = 5 << 20
+)
+
+var (
+	isinPattern     = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{9}[0-9]$`)
+	currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+type InvestmentService struct {
+	userRepo       repo.UserRepository
+	accountRepo    repo.AccountRepository
+	categoryRepo   repo.CategoryRepository
+	securityRepo   repo.SecurityRepository
+	investmentRepo repo.InvestmentRepository
+}
+
+func NewInvestmentService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	categoryRepo repo.CategoryRepository,
+	securityRepo repo.SecurityRepository,
+	investmentRepo repo.InvestmentRepository,
+) *InvestmentService {
+	return &InvestmentService{
+		userRepo:       userRepo,
+		accountRepo:    accountRepo,
+		categoryRepo:   categoryRepo,
+		securityRepo:   securityRepo,
+		investmentRepo: investmentRepo,
+	}
+}
+
+// CreateSecurity registra un titolo identificato da ISIN e/o ticker, entrambi univoci per utente.
+func (investmentService *InvestmentService) CreateSecurity(ctx context.Context, createDto dto.CreateSecurityDto) (dbgen.Security, error) {
+	createDto.Name = strings.TrimSpace(createDto.Name)
+	createDto.Currency = strings.ToUpper(strings.TrimSpace(createDto.Currency))
+	createDto.Isin = normalizeIdentifier(createDto.Isin)
+	createDto.Ticker = normalizeIdentifier(createDto.Ticker)
+	if createDto.Name == "" || !currencyPattern.MatchString(createDto.Currency) {
+		return dbgen.Security{}, fmt.Errorf("%w: name and a valid currency are required", apierr.ErrInvalidData)
+	}
+	if createDto.Isin == nil && createDto.Ticker == nil {
+		return dbgen.Security{}, fmt.Errorf("%w: isin or ticker is required", apierr.ErrInvalidData)
+	}
+	if createDto.Isin != nil && !isinPattern.MatchString(*createDto.Isin) {
+		return dbgen.Security{}, fmt.Errorf("%w: invalid isin %q", apierr.ErrInvalidData, *createDto.Isin)
+	}
+
+	user, err := investmentService.userRepo.GetUserByID(ctx, createDto.UserID)
+	if err != nil {
+		return dbgen.Security{}, err
+	}
+
+	for _, identifier := range []*string{createDto.Isin, createDto.Ticker} {
+		if identifier == nil {
+			continue
+		}
+		_, err := investmentService.securityRepo.GetSecurityByIdentifier(ctx, user, *identifier)
+		if err == nil {
+			return dbgen.Security{}, fmt.Errorf("%w: security %q already exists", apierr.ErrConflict, *identifier)
+		}
+		if !errors.Is(err, apierr.ErrSecurityNotFound) {
+			return dbgen.Security{}, err
+		}
+	}
+
+	return investmentService.securityRepo.CreateSecurity(ctx, user, createDto)
+}
+
+// GetSecurities restituisce i titoli dell'utente con l'ultimo prezzo noto entro oggi.
+func (investmentService *InvestmentService) GetSecurities(ctx context.Context, userID int64, today time.Time) ([]dto.SecurityQuote, error) {
+	user, err := investmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	securities, err := investmentService.securityRepo.GetSecurities(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := investmentService.latestPrices(ctx, user, toDate(today))
+	if err != nil {
+		return nil, err
+	}
+
+	quotes := make([]dto.SecurityQuote, len(securities))
+	for i, security := range securities {
+		quotes[i] = dto.SecurityQuote{Security: security}
+		if price, ok := latest[security.ID]; ok {
+			quotes[i].LastPrice = &price
+		}
+	}
+	return quotes, nil
+}
+
+func (investmentService *InvestmentService) GetPrices(ctx context.Context, userID int64, securityID int64, dateFrom time.Time, dateTo time.Time) ([]dbgen.SecurityPrice, error) {
+	user, err := investmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	security, err := investmentService.securityRepo.GetSecurityByID(ctx, user, securityID)
+	if err != nil {
+		return nil, err
+	}
+
+	return investmentService.securityRepo.GetPrices(ctx, security, dateFrom, dateTo)
+}
+
+// ImportPrices importa lo storico prezzi da un CSV con colonne data, ISIN o ticker e prezzo.
+// Sono accettati separatore "," o ";" (con virgola decimale), date in formato 2006-01-02 o
+// 02/01/2006 e un'intestazione opzionale. Le righe non valide vengono saltate e segnalate.
+func (investmentService *InvestmentService) ImportPrices(ctx context.Context, userID int64, content io.Reader) (dto.PriceImportResult, error) {
+	user, err := investmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.PriceImportResult{}, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(content, MaxPriceImportSize+1))
+	if err != nil {
+		return dto.PriceImportResult{}, err
+	}
+	if len(data) > MaxPriceImportSize {
+		return dto.PriceImportResult{}, fmt.Errorf("%w: maximum size is %d bytes", apierr.ErrFileTooLarge, MaxPriceImportSize)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	decimalComma := false
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Contains(firstLine, []byte(";")) {
+		reader.Comma = ';'
+		decimalComma = true
+	}
+
+	columns := map[string]int{"date": 0, "identifier": 1, "price": 2}
+	securities := make(map[string]dbgen.Security)
+	var result dto.PriceImportResult
+	var prices []dto.SecurityPriceDto
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return dto.PriceImportResult{}, fmt.Errorf("%w: csv non valido alla riga %d: %v", apierr.ErrInvalidData, line, err)
+		}
+		if line == 1 {
+			if header, ok := priceHeader(record); ok {
+				columns = header
+				continue
+			}
+		}
+		if len(record) <= max(columns["date"], columns["identifier"], columns["price"]) {
+			result.Errors = append(result.Errors, fmt.Sprintf("riga %d: colonne mancanti", line))
+			continue
+		}
+
+		priceDate, err := parseImportDate(record[columns["date"]])
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("riga %d: data non valida %q", line, record[columns["date"]]))
+			continue
+		}
+		price, err := parseDecimal(record[columns["price"]], decimalComma)
+		if err != nil || price < 0 {
+			result.Errors = append(result.Errors, fmt.Sprintf("riga %d: prezzo non valido %q", line, record[columns["price"]]))
+			continue
+		}
+
+		identifier := strings.ToUpper(strings.TrimSpace(record[columns["identifier"]]))
+		security, ok := securities[identifier]
+		if !ok {
+			security, err = investmentService.securityRepo.GetSecurityByIdentifier(ctx, user, identifier)
+			if errors.Is(err, apierr.ErrSecurityNotFound) {
+				result.Errors = append(result.Errors, fmt.Sprintf("riga %d: titolo %q sconosciuto", line, identifier))
+				continue
+			}
+			if err != nil {
+				return dto.PriceImportResult{}, err
+			}
+			securities[identifier] = security
+		}
+
+		prices = append(prices, dto.SecurityPriceDto{
+			SecurityID: security.ID,
+			PriceDate:  priceDate,
+			Price:      price,
+		})
+	}
+
+	if len(prices) > 0 {
+		if err := investmentService.securityRepo.SavePrices(ctx, prices); err != nil {
+			return dto.PriceImportResult{}, err
+		}
+	}
+	result.Imported = len(prices)
+	return result, nil
+}
+
+// AddTrade registra un acquisto, una vendita o un dividendo e la relativa transazione di cassa:
+// acquisti e vendite movimentano la liquidità dell'account senza categoria, le commissioni
+// sono una spesa e i dividendi un'entrata.
+func (investmentService *InvestmentService) AddTrade(ctx context.Context, tradeDto dto.AddTradeDto) (dbgen.InvestmentTrade, error) {
+	if tradeDto.TradeDate.IsZero() || tradeDto.Fees < 0 {
+		return dbgen.InvestmentTrade{}, fmt.Errorf("%w: trade date is required and fees cannot be negative", apierr.ErrInvalidData)
+	}
+	switch tradeDto.Kind {
+	case dto.Buy, dto.Sell:
+		if tradeDto.Quantity <= 0 || tradeDto.Price <= 0 {
+			return dbgen.InvestmentTrade{}, fmt.Errorf("%w: quantity and price must be positive", apierr.ErrInvalidData)
+		}
+		tradeDto.Amount = marketValue(tradeDto.Quantity, tradeDto.Price)
+	case dto.Dividend:
+		if tradeDto.Amount <= 0 {
+			return dbgen.InvestmentTrade{}, fmt.Errorf("%w: dividend amount must be positive", apierr.ErrInvalidData)
+		}
+		tradeDto.Quantity = 0
+		tradeDto.Price = 0
+	default:
+		return dbgen.InvestmentTrade{}, fmt.Errorf("%w: unknown trade kind %q", apierr.ErrInvalidData, tradeDto.Kind)
+	}
+	tradeDto.TradeDate = toDate(tradeDto.TradeDate)
+
+	user, err := investmentService.userRepo.GetUserByID(ctx, tradeDto.UserID)
+	if err != nil {
+		return dbgen.InvestmentTrade{}, err
+	}
+
+	account, err := investmentService.accountRepo.GetAccountByID(ctx, user, tradeDto.AccountID)
+	if err != nil {
+		return dbgen.InvestmentTrade{}, err
+	}
+	if err := ensureCanEditAccount(ctx, investmentService.accountRepo, user, account); err != nil {
+		return dbgen.InvestmentTrade{}, err
+	}
+
+	security, err := investmentService.securityRepo.GetSecurityByID(ctx, user, tradeDto.SecurityID)
+	if err != nil {
+		return dbgen.InvestmentTrade{}, err
+	}
+	if security.Currency != account.Currency {
+		return dbgen.InvestmentTrade{}, fmt.Errorf("%w: security currency %s differs from account currency %s", apierr.ErrInvalidData, security.Currency, account.Currency)
+	}
+
+	if tradeDto.Kind == dto.Sell {
+		trades, err := investmentService.investmentRepo.GetTrades(ctx, user)
+		if err != nil {
+			return dbgen.InvestmentTrade{}, err
+		}
+		trades = append(trades, dbgen.InvestmentTrade{
+			AccountID:  account.ID,
+			SecurityID: security.ID,
+			Kind:       string(dto.Sell),
+			TradeDate:  tradeDto.TradeDate,
+			Quantity:   tradeDto.Quantity,
+			Price:      tradeDto.Price,
+			Amount:     tradeDto.Amount - tradeDto.Fees,
+		})
+		if _, err := replayTrades(trades, nil); err != nil {
+			return dbgen.InvestmentTrade{}, err
+		}
+	}
+
+	var entries []dto.TradeCashEntry
+	var description string
+	switch tradeDto.Kind {
+	case dto.Buy:
+		entries = append(entries, dto.TradeCashEntry{Amount: -tradeDto.Amount})
+		description = fmt.Sprintf("Acquisto %s %s", formatQuantity(tradeDto.Quantity), security.Name)
+	case dto.Sell:
+		entries = append(entries, dto.TradeCashEntry{Amount: tradeDto.Amount})
+		description = fmt.Sprintf("Vendita %s %s", formatQuantity(tradeDto.Quantity), security.Name)
+	case dto.Dividend:
+		category, err := getOrCreateCategory(ctx, investmentService.categoryRepo, user, dividendsCategory, dto.Income)
+		if err != nil {
+			return dbgen.InvestmentTrade{}, err
+		}
+		entries = append(entries, dto.TradeCashEntry{Amount: tradeDto.Amount, CategoryID: &category.ID})
+		description = fmt.Sprintf("Dividendo %s", security.Name)
+	}
+	if tradeDto.Fees > 0 {
+		category, err := getOrCreateCategory(ctx, investmentService.categoryRepo, user, feesCategory, dto.Expense)
+		if err != nil {
+			return dbgen.InvestmentTrade{}, err
+		}
+		entries = append(entries, dto.TradeCashEntry{Amount: -tradeDto.Fees, CategoryID: &category.ID})
+	}
+
+	return investmentService.investmentRepo.AddTrade(ctx, user, account, tradeDto, entries, description)
+}
+
+func (investmentService *InvestmentService) GetTrades(ctx context.Context, userID int64, accountID int64) ([]dbgen.InvestmentTrade, error) {
+	user, err := investmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := investmentService.accountRepo.GetAccountByID(ctx, user, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	trades, err := investmentService.investmentRepo.GetTrades(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	accountTrades := []dbgen.InvestmentTrade{}
+	for _, trade := range trades {
+		if trade.AccountID == account.ID {
+			accountTrades = append(accountTrades, trade)
+		}
+	}
+	return accountTrades, nil
+}
+
+// GetHoldings restituisce liquidità e posizioni dell'account valorizzate all'ultimo prezzo.
+func (investmentService *InvestmentService) GetHoldings(ctx context.Context, userID int64, accountID int64, today time.Time) (dto.Holdings, error) {
+	user, err := investmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.Holdings{}, err
+	}
+
+	account, err := investmentService.accountRepo.GetAccountByID(ctx, user, accountID)
+	if err != nil {
+		return dto.Holdings{}, err
+	}
+
+	cash, err := investmentService.accountRepo.GetAccountBalance(ctx, account.ID)
+	if err != nil {
+		return dto.Holdings{}, err
+	}
+
+	positions, _, err := investmentService.portfolio(ctx, user, toDate(today))
+	if err != nil {
+		return dto.Holdings{}, err
+	}
+
+	holdings := dto.Holdings{
+		Account:     account,
+		CashBalance: account.InitialBalance + cash,
+		Positions:   []dto.Position{},
+	}
+	for _, position := range positions {
+		if position.AccountID == account.ID {
+			holdings.Positions = append(holdings.Positions, position)
+			holdings.MarketValue += position.MarketValue
+		}
+	}
+	return holdings, nil
+}
+
+// GetGainsReport riporta le plusvalenze realizzate e i dividendi nel periodo e quelle latenti a oggi.
+func (investmentService *InvestmentService) GetGainsReport(ctx context.Context, userID int64, dateFrom time.Time, dateTo time.Time, today time.Time) (dto.GainsReport, error) {
+	user, err := investmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.GainsReport{}, err
+	}
+
+	positions, replayed, err := investmentService.portfolio(ctx, user, toDate(today))
+	if err != nil {
+		return dto.GainsReport{}, err
+	}
+
+	report := dto.GainsReport{
+		DateFrom:   dateFrom,
+		DateTo:     dateTo,
+		Realized:   []dto.RealizedGain{},
+		Unrealized: positions,
+	}
+	for _, gain := range replayed.realized {
+		if gain.SellDate.Before(dateFrom) || gain.SellDate.After(dateTo) {
+			continue
+		}
+		report.Realized = append(report.Realized, gain)
+		report.RealizedTotal += gain.Gain
+	}
+	for _, dividend := range replayed.dividends {
+		if !dividend.TradeDate.Before(dateFrom) && !dividend.TradeDate.After(dateTo) {
+			report.Dividends += dividend.Amount
+		}
+	}
+	for _, position := range positions {
+		report.UnrealizedTotal += position.UnrealizedGain
+	}
+	return report, nil
+}
+
+// GetNetWorth calcola il patrimonio netto: saldo di ogni account più il valore di mercato dei titoli.
+func (investmentService *InvestmentService) GetNetWorth(ctx context.Context, userID int64, today time.Time) (dto.NetWorth, error) {
+	user, err := investmentService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.NetWorth{}, err
+	}
+
+	accounts, err := investmentService.accountRepo.GetAccounts(ctx, user)
+	if err != nil {
+		return dto.NetWorth{}, err
+	}
+
+	positions, _, err := investmentService.portfolio(ctx, user, toDate(today))
+	if err != nil {
+		return dto.NetWorth{}, err
+	}
+	marketValues := make(map[int64]int64)
+	for _, position := range positions {
+		marketValues[position.AccountID] += position.MarketValue
+	}
+
+	netWorth := dto.NetWorth{
+		Accounts: make([]dto.NetWorthAccount, len(accounts)),
+		Totals:   make(map[string]int64),
+	}
+	for i, account := range accounts {
+		balance, err := investmentService.accountRepo.GetAccountBalance(ctx, account.ID)
+		if err != nil {
+			return dto.NetWorth{}, err
+		}
+		item := dto.NetWorthAccount{
+			Account:     account,
+			CashBalance: account.InitialBalance + balance,
+			MarketValue: marketValues[account.ID],
+		}
+		netWorth.Accounts[i] = item
+		netWorth.Totals[account.Currency] += item.CashBalance + item.MarketValue
+	}
+	return netWorth, nil
+}
+
+func (investmentService *InvestmentService) portfolio(ctx context.Context, user dbgen.User, today time.Time) ([]dto.Position, portfolio, error) {
+	securities, err := investmentService.securityRepo.GetSecurities(ctx, user)
+	if err != nil {
+		return nil, portfolio{}, err
+	}
+	byID := make(map[int64]dbgen.Security, len(securities))
+	for _, security := range securities {
+		byID[security.ID] = security
+	}
+
+	trades, err := investmentService.investmentRepo.GetTrades(ctx, user)
+	if err != nil {
+		return nil, portfolio{}, err
+	}
+
+	replayed, err := replayTrades(trades, byID)
+	if err != nil {
+		return nil, portfolio{}, err
+	}
+
+	latest, err := investmentService.latestPrices(ctx, user, today)
+	if err != nil {
+		return nil, portfolio{}, err
+	}
+
+	return replayed.positions(byID, latest), replayed, nil
+}
+
+func (investmentService *InvestmentService) latestPrices(ctx context.Context, user dbgen.User, asOf time.Time) (map[int64]dbgen.SecurityPrice, error) {
+	prices, err := investmentService.securityRepo.GetLatestPrices(ctx, user, asOf)
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[int64]dbgen.SecurityPrice, len(prices))
+	for _, price := range prices {
+		latest[price.SecurityID] = price
+	}
+	return latest, nil
+}
+
+// getOrCreateCategory restituisce la categoria indicata, creandola se non esiste.
+func getOrCreateCategory(ctx context.Context, categoryRepo repo.CategoryRepository, user dbgen.User, name string, categoryType dto.CategoryType) (dbgen.Category, error) {
+	category, err := categoryRepo.GetCategory(ctx, user, name, categoryType)
+	if err == nil {
+		return category, nil
+	}
+	return categoryRepo.CreateCategory(ctx, user, name, categoryType, nil)
+}
+
+// priceHeader riconosce l'intestazione del CSV dei prezzi e la posizione delle colonne.
+func priceHeader(record []string) (map[string]int, bool) {
+	columns := make(map[string]int)
+	for i, name := range record {
+		switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))) {
+		case "date", "data":
+			columns["date"] = i
+		case "isin", "ticker", "symbol", "identifier", "titolo":
+			columns["identifier"] = i
+		case "price", "close", "prezzo", "chiusura":
+			columns["price"] = i
+		}
+	}
+	_, hasDate := columns["date"]
+	_, hasIdentifier := columns["identifier"]
+	_, hasPrice := columns["price"]
+	return columns, hasDate && hasIdentifier && hasPrice
+}
+
+func parseImportDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02", "02/01/2006"} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+// parseDecimal converte un numero decimale in decimillesimi (4 decimali).
+func parseDecimal(value string, decimalComma bool) (int64, error) {
+	value = strings.TrimSpace(value)
+	if decimalComma {
+		value = strings.ReplaceAll(strings.ReplaceAll(value, ".", ""), ",", ".")
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	return ToFixed(parsed), nil
+}
+
+// ToFixed converte un valore decimale nella rappresentazione con 4 decimali usata per quantità e prezzi.
+func ToFixed(value float64) int64 {
+	return int64(math.Round(value * dto.QuantityScale))
+}
+
+// FromFixed converte una quantità o un prezzo con 4 decimali nel valore decimale.
+func FromFixed(value int64) float64 {
+	return float64(value) / dto.QuantityScale
+}
+
+func formatQuantity(quantity int64) string {
+	return strconv.FormatFloat(FromFixed(quantity), 'f', -1, 64)
+}
+
+func normalizeIdentifier(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	normalized := strings.ToUpper(strings.TrimSpace(*value))
+	if normalized == "" {
+		return nil
+	}
+	return &normalized
+}
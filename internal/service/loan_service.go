@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+// loanInterestCategory è la categoria di spesa su cui vengono registrati gli interessi delle rate.
+const loanInterestCategory = "Interessi passivi"
+
+type LoanService struct {
+	userRepo      repo.UserRepository
+	accountRepo   repo.AccountRepository
+	categoryRepo  repo.CategoryRepository
+	loanRepo      repo.LoanRepository
+	notifications NotificationBus
+}
+
+func NewLoanService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	categoryRepo repo.CategoryRepository,
+	loanRepo repo.LoanRepository,
+	notifications NotificationBus,
+) *LoanService {
+	return &LoanService{
+		userRepo:      userRepo,
+		accountRepo:   accountRepo,
+		categoryRepo:  categoryRepo,
+		loanRepo:      loanRepo,
+		notifications: notifications,
+	}
+}
+
+// CreateLoan crea un account di tipo prestito con il suo piano di ammortamento. La prima
+// rata scade un mese dopo la data di inizio.
+func (loanService *LoanService) CreateLoan(ctx context.Context, createDto dto.CreateLoanDto) (dto.LoanDetail, error) {
+	createDto.Name = strings.TrimSpace(createDto.Name)
+	if createDto.Name == "" || createDto.StartDate.IsZero() {
+		return dto.LoanDetail{}, fmt.Errorf("%w: name and start date are required", apierr.ErrInvalidData)
+	}
+	if createDto.Principal <= 0 || createDto.AnnualRateBps < 0 {
+		return dto.LoanDetail{}, fmt.Errorf("%w: principal must be positive and rate not negative", apierr.ErrInvalidData)
+	}
+	if createDto.TermMonths <= 0 || createDto.TermMonths > maxLoanMonths {
+		return dto.LoanDetail{}, fmt.Errorf("%w: term must be between 1 and %d months", apierr.ErrInvalidData, maxLoanMonths)
+	}
+	if createDto.Method != dto.French && createDto.Method != dto.Italian {
+		return dto.LoanDetail{}, fmt.Errorf("%w: unknown amortization method %q", apierr.ErrInvalidData, createDto.Method)
+	}
+
+	user, err := loanService.userRepo.GetUserByID(ctx, createDto.UserID)
+	if err != nil {
+		return dto.LoanDetail{}, err
+	}
+
+	if _, err := loanService.accountRepo.GetAccount(ctx, user, createDto.Name); err == nil {
+		return dto.LoanDetail{}, fmt.Errorf("%w: account %q already exists", apierr.ErrConflict, createDto.Name)
+	}
+
+	paymentAccount, err := loanService.accountRepo.GetAccountByID(ctx, user, createDto.PaymentAccountID)
+	if err != nil {
+		return dto.LoanDetail{}, err
+	}
+	if err := ensureCanEditAccount(ctx, loanService.accountRepo, user, paymentAccount); err != nil {
+		return dto.LoanDetail{}, err
+	}
+	if createDto.Currency == "" {
+		createDto.Currency = paymentAccount.Currency
+	}
+	if createDto.Currency != paymentAccount.Currency {
+		return dto.LoanDetail{}, fmt.Errorf("%w: payment account currency %s differs from loan currency %s", apierr.ErrInvalidData, paymentAccount.Currency, createDto.Currency)
+	}
+
+	createDto.StartDate = toDate(createDto.StartDate)
+	installments := amortize(createDto.Principal, createDto.AnnualRateBps, createDto.TermMonths, createDto.Method)
+	if len(installments) == 0 {
+		return dto.LoanDetail{}, fmt.Errorf("%w: cannot build an amortization schedule", apierr.ErrInvalidData)
+	}
+	installments = scheduleDates(installments, createDto.StartDate, 1)
+
+	loan, err := loanService.loanRepo.CreateLoan(ctx, user, createDto, installments)
+	if err != nil {
+		return dto.LoanDetail{}, err
+	}
+
+	return loanService.detail(ctx, user, loan)
+}
+
+func (loanService *LoanService) GetLoans(ctx context.Context, userID int64) ([]dto.LoanDetail, error) {
+	user, err := loanService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	loans, err := loanService.loanRepo.GetLoans(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	details := make([]dto.LoanDetail, len(loans))
+	for i, loan := range loans {
+		details[i], err = loanService.detail(ctx, user, loan)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return details, nil
+}
+
+// GetLoan restituisce il prestito con il piano di ammortamento e il debito residuo.
+func (loanService *LoanService) GetLoan(ctx context.Context, userID int64, accountID int64) (dto.LoanDetail, error) {
+	user, err := loanService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.LoanDetail{}, err
+	}
+
+	loan, err := loanService.loanRepo.GetLoan(ctx, user, accountID)
+	if err != nil {
+		return dto.LoanDetail{}, err
+	}
+
+	return loanService.detail(ctx, user, loan)
+}
+
+// PostDueInstallments contabilizza, in ordine, le rate non ancora registrate scadute entro upTo.
+func (loanService *LoanService) PostDueInstallments(ctx context.Context, userID int64, accountID int64, upTo time.Time) (dto.LoanDetail, int, error) {
+	user, err := loanService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.LoanDetail{}, 0, err
+	}
+
+	loan, err := loanService.loanRepo.GetLoan(ctx, user, accountID)
+	if err != nil {
+		return dto.LoanDetail{}, 0, err
+	}
+
+	for _, id := range []int64{loan.AccountID, loan.PaymentAccountID} {
+		account, err := loanService.accountRepo.GetAccountByID(ctx, user, id)
+		if err != nil {
+			return dto.LoanDetail{}, 0, err
+		}
+		if err := ensureCanEditAccount(ctx, loanService.accountRepo, user, account); err != nil {
+			return dto.LoanDetail{}, 0, err
+		}
+	}
+
+	posted, err := loanService.postDue(ctx, user, loan, toDate(upTo))
+	if err != nil {
+		return dto.LoanDetail{}, posted, err
+	}
+
+	detail, err := loanService.detail(ctx, user, loan)
+	return detail, posted, err
+}
+
+// RunAutoPost contabilizza le rate scadute di tutti i prestiti; gli errori vengono registrati
+// e la rata viene riprovata al giro successivo.
+func (loanService *LoanService) RunAutoPost(ctx context.Context, now time.Time) {
+	today := toDate(now)
+
+	loans, err := loanService.loanRepo.GetLoansWithDueInstallments(ctx, today)
+	if err != nil {
+		log.Printf("loans: auto post: %v", err)
+		return
+	}
+
+	for _, row := range loans {
+		user, err := loanService.userRepo.GetUserByID(ctx, row.UserID)
+		if err != nil {
+			log.Printf("loans: auto post loan %d: %v", row.AccountID, err)
+			continue
+		}
+
+		loan := dbgen.Loan{
+			AccountID:        row.AccountID,
+			PaymentAccountID: row.PaymentAccountID,
+			Principal:        row.Principal,
+			AnnualRateBps:    row.AnnualRateBps,
+			TermMonths:       row.TermMonths,
+			StartDate:        row.StartDate,
+			Method:           row.Method,
+			CreatedAt:        row.CreatedAt,
+		}
+		posted, err := loanService.postDue(ctx, user, loan, today)
+		if err != nil {
+			log.Printf("loans: auto post loan %d: %v", row.AccountID, err)
+		}
+		if posted > 0 {
+			log.Printf("loans: posted %d installments of loan %d", posted, row.AccountID)
+			loanService.notifyPosted(ctx, user, loan, posted, today)
+		}
+	}
+}
+
+// notifyPosted avvisa l'utente delle rate contabilizzate automaticamente.
+func (loanService *LoanService) notifyPosted(ctx context.Context, user dbgen.User, loan dbgen.Loan, posted int, today time.Time) {
+	account, err := loanService.accountRepo.GetAccountByID(ctx, user, loan.AccountID)
+	if err != nil {
+		log.Printf("loans: notify loan %d: %v", loan.AccountID, err)
+		return
+	}
+
+	body := fmt.Sprintf("Contabilizzata la rata del prestito %s.", account.Name)
+	if posted > 1 {
+		body = fmt.Sprintf("Contabilizzate %d rate del prestito %s.", posted, account.Name)
+	}
+	loanService.notifications.Publish(ctx, dto.NotificationEvent{
+		UserID:   user.ID,
+		Kind:     dto.NotifyRecurringPosted,
+		Title:    fmt.Sprintf("Rata contabilizzata: %s", account.Name),
+		Body:     body,
+		DedupKey: fmt.Sprintf("loan-posted:%d:%s", loan.AccountID, today.Format("2006-01-02")),
+	})
+}
+
+// StartAutoPost esegue RunAutoPost subito e poi a ogni intervallo, finché il contesto non viene annullato.
+func (loanService *LoanService) StartAutoPost(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			loanService.RunAutoPost(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// SimulatePrepayment calcola l'effetto di un'estinzione parziale versata oggi sul debito
+// residuo, senza modificare il prestito.
+func (loanService *LoanService) SimulatePrepayment(ctx context.Context, prepaymentDto dto.PrepaymentDto) (dto.PrepaymentSimulation, error) {
+	if prepaymentDto.Mode == "" {
+		prepaymentDto.Mode = dto.ReduceTerm
+	}
+	if prepaymentDto.Mode != dto.ReduceTerm && prepaymentDto.Mode != dto.ReduceInstallment {
+		return dto.PrepaymentSimulation{}, fmt.Errorf("%w: unknown prepayment mode %q", apierr.ErrInvalidData, prepaymentDto.Mode)
+	}
+
+	detail, err := loanService.GetLoan(ctx, prepaymentDto.UserID, prepaymentDto.AccountID)
+	if err != nil {
+		return dto.PrepaymentSimulation{}, err
+	}
+
+	balance := detail.Balance
+	if balance.NextInstallment == nil {
+		return dto.PrepaymentSimulation{}, fmt.Errorf("%w: loan is already repaid", apierr.ErrConflict)
+	}
+	if prepaymentDto.Amount <= 0 || prepaymentDto.Amount > balance.RemainingPrincipal {
+		return dto.PrepaymentSimulation{}, fmt.Errorf("%w: amount must be between 1 and %d", apierr.ErrInvalidData, balance.RemainingPrincipal)
+	}
+
+	loan := detail.Loan
+	remaining := balance.RemainingPrincipal - prepaymentDto.Amount
+	var schedule []dto.Installment
+	switch {
+	case prepaymentDto.Mode == dto.ReduceInstallment:
+		schedule = amortize(remaining, loan.AnnualRateBps, balance.InstallmentsLeft, dto.AmortizationMethod(loan.Method))
+	case dto.AmortizationMethod(loan.Method) == dto.Italian:
+		schedule = amortizeWithPrincipal(remaining, loan.AnnualRateBps, loan.Principal/int64(loan.TermMonths), balance.InstallmentsLeft)
+	default:
+		payment := frenchPayment(balance.RemainingPrincipal, loan.AnnualRateBps, balance.InstallmentsLeft)
+		schedule = amortizeWithPayment(remaining, loan.AnnualRateBps, payment, balance.InstallmentsLeft)
+	}
+	schedule = scheduleDates(schedule, loan.StartDate, balance.NextInstallment.Number)
+
+	simulation := dto.PrepaymentSimulation{
+		Mode:               prepaymentDto.Mode,
+		Amount:             prepaymentDto.Amount,
+		RemainingPrincipal: remaining,
+		CurrentInstallment: balance.NextInstallment.Principal + balance.NextInstallment.Interest,
+		CurrentMonths:      balance.InstallmentsLeft,
+		NewMonths:          int32(len(schedule)),
+		CurrentInterest:    balance.RemainingInterest,
+		Schedule:           schedule,
+	}
+	if len(schedule) > 0 {
+		simulation.NewInstallment = schedule[0].Principal + schedule[0].Interest
+	}
+	for _, installment := range schedule {
+		simulation.NewInterest += installment.Interest
+	}
+	simulation.InterestSaved = simulation.CurrentInterest - simulation.NewInterest
+	return simulation, nil
+}
+
+func (loanService *LoanService) postDue(ctx context.Context, user dbgen.User, loan dbgen.Loan, upTo time.Time) (int, error) {
+	installments, err := loanService.loanRepo.GetInstallments(ctx, loan)
+	if err != nil {
+		return 0, err
+	}
+
+	account, err := loanService.accountRepo.GetAccountByID(ctx, user, loan.AccountID)
+	if err != nil {
+		return 0, err
+	}
+
+	var category dbgen.Category
+	posted := 0
+	for _, installment := range installments {
+		if installment.TransactionID.Valid || installment.DueDate.After(upTo) {
+			continue
+		}
+		if category.ID == 0 {
+			category, err = getOrCreateCategory(ctx, loanService.categoryRepo, user, loanInterestCategory, dto.Expense)
+			if err != nil {
+				return posted, err
+			}
+		}
+
+		description := fmt.Sprintf("Rata %d/%d %s", installment.Number, len(installments), account.Name)
+		if _, err := loanService.loanRepo.PostInstallment(ctx, user, loan, installment, category, description); err != nil {
+			return posted, err
+		}
+		posted++
+	}
+	return posted, nil
+}
+
+func (loanService *LoanService) detail(ctx context.Context, user dbgen.User, loan dbgen.Loan) (dto.LoanDetail, error) {
+	account, err := loanService.accountRepo.GetAccountByID(ctx, user, loan.AccountID)
+	if err != nil {
+		return dto.LoanDetail{}, err
+	}
+
+	paymentAccount, err := loanService.accountRepo.GetAccountByID(ctx, user, loan.PaymentAccountID)
+	if err != nil {
+		return dto.LoanDetail{}, err
+	}
+
+	installments, err := loanService.loanRepo.GetInstallments(ctx, loan)
+	if err != nil {
+		return dto.LoanDetail{}, err
+	}
+
+	balance := dto.LoanBalance{RemainingPrincipal: loan.Principal}
+	for i, installment := range installments {
+		if installment.TransactionID.Valid {
+			balance.PaidPrincipal += installment.Principal
+			balance.PaidInterest += installment.Interest
+			balance.InstallmentsPaid++
+			continue
+		}
+		balance.RemainingInterest += installment.Interest
+		balance.InstallmentsLeft++
+		if balance.NextInstallment == nil {
+			balance.NextInstallment = &installments[i]
+		}
+	}
+	balance.RemainingPrincipal -= balance.PaidPrincipal
+
+	return dto.LoanDetail{
+		Loan:           loan,
+		Account:        account,
+		PaymentAccount: paymentAccount,
+		Installments:   installments,
+		Balance:        balance,
+	}, nil
+}
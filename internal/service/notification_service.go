@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/notify"
+	repo "koin/internal/repository"
+)
+
+const (
+	// deliveryBatchSize è il numero massimo di invii presi in carico a ogni giro.
+	deliveryBatchSize = 50
+	// deliveryLease è il tempo entro cui un invio preso in carico deve concludersi; poi viene ritentato.
+	deliveryLease = 5 * time.Minute
+	// maxDeliveryAttempts con il backoff esponenziale copre circa quattro ore di indisponibilità.
+	maxDeliveryAttempts = 8
+	firstRetryDelay     = time.Minute
+	maxRetryDelay       = 6 * time.Hour
+)
+
+// NotificationBus riceve gli eventi che i servizi notificano agli utenti. Publish non restituisce
+// errori: una notifica persa non deve far fallire l'operazione che l'ha generata.
+type NotificationBus interface {
+	Publish(ctx context.Context, event dto.NotificationEvent)
+}
+
+// NotificationService smista gli eventi sui canali scelti dall'utente per ciascun tipo: la inbox
+// dell'app, l'email e il webhook. Gli invii esterni sono registrati e consegnati in background,
+// con ritentativi a backoff esponenziale. I canali senza Sender (es. SMTP non configurato) vengono
+// ignorati.
+type NotificationService struct {
+	userRepo         repo.UserRepository
+	notificationRepo repo.NotificationRepository
+	senders          map[dto.NotificationChannel]notify.Sender
+}
+
+func NewNotificationService(
+	userRepo repo.UserRepository,
+	notificationRepo repo.NotificationRepository,
+	senders map[dto.NotificationChannel]notify.Sender,
+) *NotificationService {
+	return &NotificationService{
+		userRepo:         userRepo,
+		notificationRepo: notificationRepo,
+		senders:          senders,
+	}
+}
+
+// Publish registra l'evento e ne prepara gli invii secondo le preferenze dell'utente. Un evento
+// con una DedupKey già notificata viene ignorato.
+func (notificationService *NotificationService) Publish(ctx context.Context, event dto.NotificationEvent) {
+	if err := notificationService.publish(ctx, event); err != nil {
+		log.Printf("notifications: publish %s %q to user %d: %v", event.Kind, event.DedupKey, event.UserID, err)
+	}
+}
+
+func (notificationService *NotificationService) publish(ctx context.Context, event dto.NotificationEvent) error {
+	user, err := notificationService.userRepo.GetUserByID(ctx, event.UserID)
+	if err != nil {
+		return err
+	}
+	settings, err := notificationService.notificationRepo.GetSettings(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	var preference dto.NotificationPreference
+	for _, item := range settings.Preferences {
+		if item.Kind == event.Kind {
+			preference = item
+			break
+		}
+	}
+
+	deliveries := make(map[dto.NotificationChannel]string)
+	if preference.Email && notificationService.senders[dto.ChannelEmail] != nil {
+		deliveries[dto.ChannelEmail] = user.Email
+	}
+	if preference.Webhook && settings.WebhookURL != nil && notificationService.senders[dto.ChannelWebhook] != nil {
+		deliveries[dto.ChannelWebhook] = *settings.WebhookURL
+	}
+	if !preference.InApp && len(deliveries) == 0 {
+		return nil
+	}
+
+	_, _, err = notificationService.notificationRepo.CreateNotification(ctx, event, preference.InApp, deliveries)
+	return err
+}
+
+func (notificationService *NotificationService) GetSettings(ctx context.Context, userID int64) (dto.NotificationSettings, error) {
+	user, err := notificationService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.NotificationSettings{}, err
+	}
+
+	return notificationService.notificationRepo.GetSettings(ctx, user)
+}
+
+// SaveSettings aggiorna webhook e soglia di saldo basso; le preferenze indicate sostituiscono
+// quelle dei rispettivi tipi di evento, le altre restano invariate.
+func (notificationService *NotificationService) SaveSettings(ctx context.Context, settingsDto dto.NotificationSettings) (dto.NotificationSettings, error) {
+	user, err := notificationService.userRepo.GetUserByID(ctx, settingsDto.UserID)
+	if err != nil {
+		return dto.NotificationSettings{}, err
+	}
+
+	if settingsDto.LowBalanceThreshold < 0 {
+		return dto.NotificationSettings{}, fmt.Errorf("%w: lowBalanceThreshold must not be negative", apierr.ErrInvalidData)
+	}
+	if settingsDto.WebhookURL != nil {
+		if *settingsDto.WebhookURL == "" {
+			settingsDto.WebhookURL = nil
+		} else if parsed, err := url.Parse(*settingsDto.WebhookURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return dto.NotificationSettings{}, fmt.Errorf("%w: invalid webhook url %q", apierr.ErrInvalidData, *settingsDto.WebhookURL)
+		}
+	}
+
+	current, err := notificationService.notificationRepo.GetSettings(ctx, user)
+	if err != nil {
+		return dto.NotificationSettings{}, err
+	}
+	for _, preference := range settingsDto.Preferences {
+		found := false
+		for i := range current.Preferences {
+			if current.Preferences[i].Kind == preference.Kind {
+				current.Preferences[i] = preference
+				found = true
+				break
+			}
+		}
+		if !found {
+			return dto.NotificationSettings{}, fmt.Errorf("%w: unknown event kind %q", apierr.ErrInvalidData, preference.Kind)
+		}
+	}
+	current.WebhookURL = settingsDto.WebhookURL
+	current.LowBalanceThreshold = settingsDto.LowBalanceThreshold
+
+	if err := notificationService.notificationRepo.SaveSettings(ctx, current); err != nil {
+		return dto.NotificationSettings{}, err
+	}
+	return current, nil
+}
+
+// GetNotifications restituisce la inbox dell'utente, dalle notifiche più recenti.
+func (notificationService *NotificationService) GetNotifications(ctx context.Context, userID int64, unreadOnly bool) ([]dbgen.Notification, error) {
+	user, err := notificationService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return notificationService.notificationRepo.GetNotifications(ctx, user, unreadOnly)
+}
+
+func (notificationService *NotificationService) MarkRead(ctx context.Context, userID int64, notificationID int64) error {
+	user, err := notificationService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return notificationService.notificationRepo.MarkRead(ctx, user, notificationID)
+}
+
+// RunDelivery consegna gli invii in attesa. Un invio fallito viene ritentato con un ritardo che
+// raddoppia a ogni tentativo, fino a maxDeliveryAttempts.
+func (notificationService *NotificationService) RunDelivery(ctx context.Context, now time.Time) {
+	deliveries, err := notificationService.notificationRepo.ClaimDeliveries(ctx, now, now.Add(deliveryLease), deliveryBatchSize)
+	if err != nil {
+		log.Printf("notifications: delivery: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		err := notificationService.deliver(ctx, delivery)
+		if err == nil {
+			err = notificationService.notificationRepo.MarkDeliverySent(ctx, delivery.ID)
+			if err != nil {
+				log.Printf("notifications: delivery %d: %v", delivery.ID, err)
+			}
+			continue
+		}
+
+		attempts := int(delivery.Attempts) + 1
+		var next *time.Time
+		if attempts < maxDeliveryAttempts {
+			retryAt := now.Add(retryDelay(attempts))
+			next = &retryAt
+			log.Printf("notifications: %s delivery %d failed (attempt %d), retrying at %s: %v", delivery.Channel, delivery.ID, attempts, retryAt.Format(time.RFC3339), err)
+		} else {
+			log.Printf("notifications: %s delivery %d failed after %d attempts: %v", delivery.Channel, delivery.ID, attempts, err)
+		}
+		if err := notificationService.notificationRepo.MarkDeliveryFailed(ctx, delivery.ID, err.Error(), next); err != nil {
+			log.Printf("notifications: delivery %d: %v", delivery.ID, err)
+		}
+	}
+}
+
+func (notificationService *NotificationService) deliver(ctx context.Context, delivery dbgen.ClaimNotificationDeliveriesRow) error {
+	sender := notificationService.senders[dto.NotificationChannel(delivery.Channel)]
+	if sender == nil {
+		return fmt.Errorf("channel %s is not configured", delivery.Channel)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return sender.Send(ctx, delivery.Target, notify.Message{
+		NotificationID: delivery.NotificationID,
+		UserID:         delivery.UserID,
+		Kind:           delivery.Kind,
+		Title:          delivery.Title,
+		Body:           delivery.Body,
+		CreatedAt:      delivery.NotificationCreatedAt,
+	})
+}
+
+// retryDelay è l'attesa prima del tentativo successivo: 1, 2, 4, ... minuti, al massimo maxRetryDelay.
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay << (attempts - 1)
+	if delay <= 0 || delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// StartDelivery esegue RunDelivery subito e poi a ogni intervallo, finché il contesto non viene annullato.
+func (notificationService *NotificationService) StartDelivery(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			notificationService.RunDelivery(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunChecks controlla le soglie impostate dagli utenti: i budget mensili delle categorie
+// (una notifica al mese per categoria) e il saldo basso degli account (una al giorno per account).
+func (notificationService *NotificationService) RunChecks(ctx context.Context, now time.Time) {
+	today := toDate(now)
+	month := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	budgets, err := notificationService.notificationRepo.GetExceededBudgets(ctx, month, today)
+	if err != nil {
+		log.Printf("notifications: budget check: %v", err)
+	}
+	for _, budget := range budgets {
+		notificationService.Publish(ctx, dto.NotificationEvent{
+			UserID:   budget.UserID,
+			Kind:     dto.NotifyBudgetExceeded,
+			Title:    fmt.Sprintf("Budget superato: %s", budget.CategoryName),
+			Body:     fmt.Sprintf("Questo mese hai speso %s in %s, oltre il budget di %s.", formatCents(budget.Spent, ""), budget.CategoryName, formatCents(budget.MonthlyBudget, "")),
+			DedupKey: fmt.Sprintf("budget:%d:%s", budget.CategoryID, month.Format("2006-01")),
+		})
+	}
+
+	accounts, err := notificationService.notificationRepo.GetLowBalanceAccounts(ctx)
+	if err != nil {
+		log.Printf("notifications: low balance check: %v", err)
+	}
+	for _, account := range accounts {
+		notificationService.Publish(ctx, dto.NotificationEvent{
+			UserID:   account.UserID,
+			Kind:     dto.NotifyLowBalance,
+			Title:    fmt.Sprintf("Saldo basso: %s", account.AccountName),
+			Body:     fmt.Sprintf("Il saldo di %s è %s, sotto la soglia di %s.", account.AccountName, formatCents(account.Balance, account.Currency), formatCents(account.LowBalanceThreshold, account.Currency)),
+			DedupKey: fmt.Sprintf("low-balance:%d:%s", account.AccountID, today.Format("2006-01-02")),
+		})
+	}
+}
+
+// StartChecks esegue RunChecks subito e poi a ogni intervallo, finché il contesto non viene annullato.
+func (notificationService *NotificationService) StartChecks(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			notificationService.RunChecks(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
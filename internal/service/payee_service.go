@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+	"strings"
+)
+
+// Lunghezze massime di PAYEES.NAME e PAYEE_ALIASES.PATTERN.
+const (
+	maxPayeeNameLength    = 100
+	maxPayeePatternLength = 255
+)
+
+type PayeeService struct {
+	userRepo     repo.UserRepository
+	payeeRepo    repo.PayeeRepository
+	categoryRepo repo.CategoryRepository
+}
+
+func NewPayeeService(
+	userRepo repo.UserRepository,
+	payeeRepo repo.PayeeRepository,
+	categoryRepo repo.CategoryRepository,
+) *PayeeService {
+	return &PayeeService{
+		userRepo:     userRepo,
+		payeeRepo:    payeeRepo,
+		categoryRepo: categoryRepo,
+	}
+}
+
+func (payeeService *PayeeService) GetPayees(ctx context.Context, user dbgen.User) ([]dbgen.Payee, error) {
+	return payeeService.payeeRepo.GetPayees(ctx, user)
+}
+
+// GetPayeeAliases restituisce gli alias dell'utente raggruppati per beneficiario.
+func (payeeService *PayeeService) GetPayeeAliases(ctx context.Context, user dbgen.User) (map[int64][]dbgen.PayeeAlias, error) {
+	aliases, err := payeeService.payeeRepo.GetPayeeAliases(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	byPayee := make(map[int64][]dbgen.PayeeAlias)
+	for _, alias := range aliases {
+		byPayee[alias.PayeeID] = append(byPayee[alias.PayeeID], alias)
+	}
+	return byPayee, nil
+}
+
+func (payeeService *PayeeService) CreatePayee(ctx context.Context, createPayeeDto dto.CreatePayeeDto) (dbgen.Payee, []dbgen.PayeeAlias, error) {
+	user, err := payeeService.userRepo.GetUserByID(ctx, createPayeeDto.UserID)
+	if err != nil {
+		return dbgen.Payee{}, nil, err
+	}
+
+	name, err := NormalizePayeeName(createPayeeDto.Name)
+	if err != nil {
+		return dbgen.Payee{}, nil, err
+	}
+
+	_, err = payeeService.payeeRepo.GetPayeeByName(ctx, user, name)
+	if err == nil {
+		return dbgen.Payee{}, nil, fmt.Errorf("%w: payee %q already exists", apierr.ErrConflict, name)
+	}
+
+	if err := payeeService.validateDefaultCategory(ctx, user, createPayeeDto.DefaultCategoryID); err != nil {
+		return dbgen.Payee{}, nil, err
+	}
+
+	patterns := make([]string, 0, len(createPayeeDto.Aliases))
+	for _, alias := range createPayeeDto.Aliases {
+		pattern, err := normalizePayeePattern(alias)
+		if err != nil {
+			return dbgen.Payee{}, nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+
+	payee, err := payeeService.payeeRepo.CreatePayee(ctx, user, name, createPayeeDto.DefaultCategoryID)
+	if err != nil {
+		return dbgen.Payee{}, nil, err
+	}
+
+	aliases := make([]dbgen.PayeeAlias, 0, len(patterns))
+	for _, pattern := range patterns {
+		alias, err := payeeService.payeeRepo.AddPayeeAlias(ctx, payee, pattern)
+		if err != nil {
+			return dbgen.Payee{}, nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+	return payee, aliases, nil
+}
+
+// UpdatePayee rinomina un beneficiario e ne imposta la categoria predefinita.
+func (payeeService *PayeeService) UpdatePayee(ctx context.Context, updatePayeeDto dto.UpdatePayeeDto) (dbgen.Payee, error) {
+	user, err := payeeService.userRepo.GetUserByID(ctx, updatePayeeDto.UserID)
+	if err != nil {
+		return dbgen.Payee{}, err
+	}
+
+	payee, err := payeeService.payeeRepo.GetPayeeByID(ctx, user, updatePayeeDto.PayeeID)
+	if err != nil {
+		return dbgen.Payee{}, err
+	}
+
+	name, err := NormalizePayeeName(updatePayeeDto.Name)
+	if err != nil {
+		return dbgen.Payee{}, err
+	}
+	if name != payee.Name {
+		_, err = payeeService.payeeRepo.GetPayeeByName(ctx, user, name)
+		if err == nil {
+			return dbgen.Payee{}, fmt.Errorf("%w: payee %q already exists", apierr.ErrConflict, name)
+		}
+	}
+
+	if err := payeeService.validateDefaultCategory(ctx, user, updatePayeeDto.DefaultCategoryID); err != nil {
+		return dbgen.Payee{}, err
+	}
+
+	return payeeService.payeeRepo.UpdatePayee(ctx, user, payee.ID, name, updatePayeeDto.DefaultCategoryID)
+}
+
+// DeletePayee elimina un beneficiario; i movimenti collegati restano senza beneficiario.
+func (payeeService *PayeeService) DeletePayee(ctx context.Context, userID int64, payeeID int64) error {
+	user, err := payeeService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	payee, err := payeeService.payeeRepo.GetPayeeByID(ctx, user, payeeID)
+	if err != nil {
+		return err
+	}
+
+	return payeeService.payeeRepo.DeletePayee(ctx, user, payee.ID)
+}
+
+func (payeeService *PayeeService) AddPayeeAlias(ctx context.Context, addAliasDto dto.AddPayeeAliasDto) (dbgen.PayeeAlias, error) {
+	user, err := payeeService.userRepo.GetUserByID(ctx, addAliasDto.UserID)
+	if err != nil {
+		return dbgen.PayeeAlias{}, err
+	}
+
+	payee, err := payeeService.payeeRepo.GetPayeeByID(ctx, user, addAliasDto.PayeeID)
+	if err != nil {
+		return dbgen.PayeeAlias{}, err
+	}
+
+	pattern, err := normalizePayeePattern(addAliasDto.Pattern)
+	if err != nil {
+		return dbgen.PayeeAlias{}, err
+	}
+
+	aliases, err := payeeService.payeeRepo.GetPayeeAliases(ctx, user)
+	if err != nil {
+		return dbgen.PayeeAlias{}, err
+	}
+	for _, alias := range aliases {
+		if strings.EqualFold(alias.Pattern, pattern) {
+			return dbgen.PayeeAlias{}, fmt.Errorf("%w: alias %q already exists", apierr.ErrConflict, pattern)
+		}
+	}
+
+	return payeeService.payeeRepo.AddPayeeAlias(ctx, payee, pattern)
+}
+
+func (payeeService *PayeeService) DeletePayeeAlias(ctx context.Context, userID int64, payeeID int64, aliasID int64) error {
+	user, err := payeeService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	payee, err := payeeService.payeeRepo.GetPayeeByID(ctx, user, payeeID)
+	if err != nil {
+		return err
+	}
+
+	return payeeService.payeeRepo.DeletePayeeAlias(ctx, payee, aliasID)
+}
+
+// DetectPayee cerca il beneficiario corrispondente a una descrizione grezza (es. importata
+// da un estratto conto). Restituisce ok=false se nessun nome o alias compare nella descrizione.
+func (payeeService *PayeeService) DetectPayee(ctx context.Context, user dbgen.User, description string) (dbgen.Payee, bool, error) {
+	return detectPayee(ctx, payeeService.payeeRepo, user, description)
+}
+
+func (payeeService *PayeeService) GetPayeeReport(ctx context.Context, reportDto dto.PayeeReportDto) ([]dbgen.GetPayeeTotalsByUserRow, error) {
+	user, err := payeeService.userRepo.GetUserByID(ctx, reportDto.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return payeeService.payeeRepo.GetPayeeTotals(ctx, user, reportDto.DateFrom, reportDto.DateTo)
+}
+
+func (payeeService *PayeeService) validateDefaultCategory(ctx context.Context, user dbgen.User, categoryID *int64) error {
+	if categoryID == nil {
+		return nil
+	}
+
+	category, err := payeeService.categoryRepo.GetCategoryByID(ctx, user, *categoryID)
+	if err != nil {
+		return err
+	}
+	if category.ArchivedAt.Valid {
+		return fmt.Errorf("%w: %s", apierr.ErrCategoryArchived, category.Name)
+	}
+	return nil
+}
+
+func detectPayee(ctx context.Context, payeeRepo repo.PayeeRepository, user dbgen.User, description string) (dbgen.Payee, bool, error) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return dbgen.Payee{}, false, nil
+	}
+
+	payee, err := payeeRepo.DetectPayee(ctx, user, description)
+	if err != nil {
+		if errors.Is(err, apierr.ErrPayeeNotFound) {
+			return dbgen.Payee{}, false, nil
+		}
+		return dbgen.Payee{}, false, err
+	}
+	return payee, true, nil
+}
+
+// NormalizePayeeName rimuove gli spazi superflui dal nome di un beneficiario e ne verifica la validità.
+func NormalizePayeeName(name string) (string, error) {
+	normalized := strings.Join(strings.Fields(name), " ")
+	if normalized == "" {
+		return "", fmt.Errorf("%w: payee name must not be empty", apierr.ErrInvalidData)
+	}
+	if len(normalized) > maxPayeeNameLength {
+		return "", fmt.Errorf("%w: payee name %q is longer than %d characters", apierr.ErrInvalidData, normalized, maxPayeeNameLength)
+	}
+	return normalized, nil
+}
+
+func normalizePayeePattern(pattern string) (string, error) {
+	normalized := strings.Join(strings.Fields(pattern), " ")
+	if normalized == "" {
+		return "", fmt.Errorf("%w: alias must not be empty", apierr.ErrInvalidData)
+	}
+	if len(normalized) > maxPayeePatternLength {
+		return "", fmt.Errorf("%w: alias %q is longer than %d characters", apierr.ErrInvalidData, normalized, maxPayeePatternLength)
+	}
+	return normalized, nil
+}
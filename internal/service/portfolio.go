@@ -0,0 +1,161 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+// positionKey identifica una posizione: lo stesso titolo può stare su più account.
+type positionKey struct {
+	accountID  int64
+	securityID int64
+}
+
+// lot è un lotto di acquisto ancora aperto; cost è il costo residuo in centesimi.
+type lot struct {
+	quantity int64
+	cost     int64
+}
+
+// portfolio è il risultato della rilettura FIFO delle operazioni.
+type portfolio struct {
+	lots      map[positionKey][]lot
+	realized  []dto.RealizedGain
+	dividends []dbgen.InvestmentTrade
+	lastTrade map[int64]dbgen.SecurityPrice
+}
+
+// replayTrades rilegge le operazioni in ordine cronologico: gli acquisti aprono lotti al costo
+// comprensivo di commissioni, le vendite li chiudono in ordine FIFO. Restituisce ErrInvalidData se
+// una vendita supera la quantità posseduta alla sua data.
+func replayTrades(trades []dbgen.InvestmentTrade, securities map[int64]dbgen.Security) (portfolio, error) {
+	sorted := make([]dbgen.InvestmentTrade, len(trades))
+	copy(sorted, trades)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].TradeDate.Equal(sorted[j].TradeDate) {
+			return sorted[i].TradeDate.Before(sorted[j].TradeDate)
+		}
+		// A parità di data gli acquisti precedono le vendite
+		return sorted[i].Kind == string(dto.Buy) && sorted[j].Kind != string(dto.Buy)
+	})
+
+	result := portfolio{
+		lots:      make(map[positionKey][]lot),
+		lastTrade: make(map[int64]dbgen.SecurityPrice),
+	}
+	for _, trade := range sorted {
+		key := positionKey{accountID: trade.AccountID, securityID: trade.SecurityID}
+		switch dto.TradeKind(trade.Kind) {
+		case dto.Buy:
+			result.lots[key] = append(result.lots[key], lot{quantity: trade.Quantity, cost: -trade.Amount})
+		case dto.Sell:
+			remaining := trade.Quantity
+			var cost int64
+			open := result.lots[key]
+			for remaining > 0 && len(open) > 0 {
+				taken := min(remaining, open[0].quantity)
+				takenCost := scaleRound(open[0].cost, taken, open[0].quantity)
+				cost += takenCost
+				open[0].cost -= takenCost
+				open[0].quantity -= taken
+				remaining -= taken
+				if open[0].quantity == 0 {
+					open = open[1:]
+				}
+			}
+			result.lots[key] = open
+			if remaining > 0 {
+				return portfolio{}, fmt.Errorf("%w: selling more than held on %s", apierr.ErrInvalidData, trade.TradeDate.Format("2006-01-02"))
+			}
+			result.realized = append(result.realized, dto.RealizedGain{
+				TradeID:   trade.ID,
+				AccountID: trade.AccountID,
+				Security:  securities[trade.SecurityID],
+				SellDate:  trade.TradeDate,
+				Quantity:  trade.Quantity,
+				Proceeds:  trade.Amount,
+				CostBasis: cost,
+				Gain:      trade.Amount - cost,
+			})
+		case dto.Dividend:
+			result.dividends = append(result.dividends, trade)
+		}
+		if trade.Kind != string(dto.Dividend) {
+			result.lastTrade[trade.SecurityID] = dbgen.SecurityPrice{
+				SecurityID: trade.SecurityID,
+				PriceDate:  trade.TradeDate,
+				Price:      trade.Price,
+			}
+		}
+	}
+	return result, nil
+}
+
+// positions valorizza i lotti aperti all'ultimo prezzo disponibile: quello dello storico
+// oppure, se più recente, quello dell'ultima operazione sul titolo.
+func (p portfolio) positions(securities map[int64]dbgen.Security, latest map[int64]dbgen.SecurityPrice) []dto.Position {
+	var positions []dto.Position
+	for key, lots := range p.lots {
+		var quantity, cost int64
+		for _, open := range lots {
+			quantity += open.quantity
+			cost += open.cost
+		}
+		if quantity == 0 {
+			continue
+		}
+
+		position := dto.Position{
+			Security:  securities[key.securityID],
+			AccountID: key.accountID,
+			Quantity:  quantity,
+			CostBasis: cost,
+		}
+		price, hasPrice := latest[key.securityID]
+		if traded, ok := p.lastTrade[key.securityID]; ok && (!hasPrice || traded.PriceDate.After(price.PriceDate)) {
+			price, hasPrice = traded, true
+		}
+		if hasPrice {
+			position.LastPrice = &price
+			position.MarketValue = marketValue(quantity, price.Price)
+			position.UnrealizedGain = position.MarketValue - cost
+		}
+		positions = append(positions, position)
+	}
+
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].AccountID != positions[j].AccountID {
+			return positions[i].AccountID < positions[j].AccountID
+		}
+		return positions[i].Security.Name < positions[j].Security.Name
+	})
+	return positions
+}
+
+// marketValue converte quantità e prezzo (entrambi con 4 decimali) in centesimi.
+func marketValue(quantity int64, price int64) int64 {
+	return scaleRound(quantity, price, dto.QuantityScale*dto.QuantityScale/100)
+}
+
+// scaleRound calcola value*numerator/denominator arrotondando al più vicino, senza overflow.
+func scaleRound(value int64, numerator int64, denominator int64) int64 {
+	if denominator == 0 {
+		return 0
+	}
+	product := new(big.Int).Mul(big.NewInt(value), big.NewInt(numerator))
+	den := big.NewInt(denominator)
+	quotient, remainder := new(big.Int).QuoRem(product, den, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(new(big.Int).Abs(den)) >= 0 {
+		if product.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient.Int64()
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+// testTrade costruisce un'operazione sull'account 1; quantity è in unità intere del titolo e amount
+// in centesimi, negativo per gli acquisti.
+func testTrade(id int64, securityID int64, kind dto.TradeKind, date string, quantity int64, amount int64) dbgen.InvestmentTrade {
+	tradeDate, _ := time.Parse("2006-01-02", date)
+	var price int64
+	if quantity > 0 {
+		price = max(amount, -amount) * 100 / quantity
+	}
+	return dbgen.InvestmentTrade{
+		ID:         id,
+		AccountID:  1,
+		SecurityID: securityID,
+		Kind:       string(kind),
+		TradeDate:  tradeDate,
+		Quantity:   quantity * dto.QuantityScale,
+		Price:      price,
+		Amount:     amount,
+	}
+}
+
+func TestReplayTradesFIFO(t *testing.T) {
+	tests := []struct {
+		name      string
+		trades    []dbgen.InvestmentTrade
+		gains     []int64
+		openCost  int64
+		openUnits int64
+	}{
+		{
+			name: "sell consumes the oldest lot first",
+			trades: []dbgen.InvestmentTrade{
+				testTrade(1, 1, dto.Buy, "2024-01-10", 10, -100000),
+				testTrade(2, 1, dto.Buy, "2024-02-10", 10, -150000),
+				testTrade(3, 1, dto.Sell, "2024-03-10", 10, 160000),
+			},
+			gains:     []int64{60000},
+			openCost:  150000,
+			openUnits: 10,
+		},
+		{
+			name: "sell across two lots",
+			trades: []dbgen.InvestmentTrade{
+				testTrade(1, 1, dto.Buy, "2024-01-10", 10, -100000),
+				testTrade(2, 1, dto.Buy, "2024-02-10", 10, -150000),
+				testTrade(3, 1, dto.Sell, "2024-03-10", 15, 210000),
+			},
+			gains:     []int64{210000 - 100000 - 75000},
+			openCost:  75000,
+			openUnits: 5,
+		},
+		{
+			name: "partial sells keep the cost of the lot",
+			trades: []dbgen.InvestmentTrade{
+				testTrade(1, 1, dto.Buy, "2024-01-10", 3, -10000),
+				testTrade(2, 1, dto.Sell, "2024-02-10", 1, 4000),
+				testTrade(3, 1, dto.Sell, "2024-03-10", 1, 4000),
+				testTrade(4, 1, dto.Sell, "2024-04-10", 1, 4000),
+			},
+			gains: []int64{4000 - 3333, 4000 - 3334, 4000 - 3333},
+		},
+		{
+			name: "same day buy comes before the sell",
+			trades: []dbgen.InvestmentTrade{
+				testTrade(2, 1, dto.Sell, "2024-01-10", 5, 60000),
+				testTrade(1, 1, dto.Buy, "2024-01-10", 5, -50000),
+			},
+			gains: []int64{10000},
+		},
+		{
+			name: "dividends do not touch the lots",
+			trades: []dbgen.InvestmentTrade{
+				testTrade(1, 1, dto.Buy, "2024-01-10", 10, -100000),
+				testTrade(2, 1, dto.Dividend, "2024-06-10", 0, 2500),
+			},
+			openCost:  100000,
+			openUnits: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := replayTrades(tt.trades, map[int64]dbgen.Security{1: {ID: 1, Name: "ETF"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.realized) != len(tt.gains) {
+				t.Fatalf("got %d realized gains, want %d", len(result.realized), len(tt.gains))
+			}
+			for i, gain := range tt.gains {
+				if result.realized[i].Gain != gain {
+					t.Errorf("realized gain %d = %d, want %d", i, result.realized[i].Gain, gain)
+				}
+			}
+
+			var openCost, openQuantity int64
+			for _, open := range result.lots[positionKey{accountID: 1, securityID: 1}] {
+				openCost += open.cost
+				openQuantity += open.quantity
+			}
+			if openCost != tt.openCost || openQuantity != tt.openUnits*dto.QuantityScale {
+				t.Errorf("open position = %d units at cost %d, want %d units at cost %d", openQuantity/dto.QuantityScale, openCost, tt.openUnits, tt.openCost)
+			}
+
+			var bought, costBasis int64
+			for _, trade := range tt.trades {
+				if trade.Kind == string(dto.Buy) {
+					bought -= trade.Amount
+				}
+			}
+			for _, gain := range result.realized {
+				costBasis += gain.CostBasis
+			}
+			if costBasis+openCost != bought {
+				t.Errorf("cost basis %d plus open cost %d does not add up to %d", costBasis, openCost, bought)
+			}
+		})
+	}
+}
+
+func TestReplayTradesRejectsOverselling(t *testing.T) {
+	trades := []dbgen.InvestmentTrade{
+		testTrade(1, 1, dto.Buy, "2024-01-10", 5, -50000),
+		testTrade(2, 1, dto.Sell, "2024-01-09", 5, 50000),
+	}
+	if _, err := replayTrades(trades, nil); !errors.Is(err, apierr.ErrInvalidData) {
+		t.Fatalf("selling before buying: got %v, want ErrInvalidData", err)
+	}
+}
+
+func TestPositionsUseTheLatestPrice(t *testing.T) {
+	securities := map[int64]dbgen.Security{1: {ID: 1, Name: "ETF"}}
+	result, err := replayTrades([]dbgen.InvestmentTrade{testTrade(1, 1, dto.Buy, "2024-01-10", 10, -100000)}, securities)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	priceDate, _ := time.Parse("2006-01-02", "2024-05-31")
+	positions := result.positions(securities, map[int64]dbgen.SecurityPrice{
+		1: {SecurityID: 1, PriceDate: priceDate, Price: 120 * dto.QuantityScale},
+	})
+	if len(positions) != 1 {
+		t.Fatalf("got %d positions, want 1", len(positions))
+	}
+	if positions[0].MarketValue != 120000 || positions[0].UnrealizedGain != 20000 {
+		t.Errorf("market value %d, unrealized gain %d; want 120000 and 20000", positions[0].MarketValue, positions[0].UnrealizedGain)
+	}
+
+	// Senza prezzi nello storico vale quello dell'ultima operazione
+	positions = result.positions(securities, nil)
+	if positions[0].MarketValue != 100000 {
+		t.Errorf("market value at the trade price = %d, want 100000", positions[0].MarketValue)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+type ReconciliationService struct {
+	userRepo           repo.UserRepository
+	accountRepo        repo.AccountRepository
+	reconciliationRepo repo.ReconciliationRepository
+}
+
+func NewReconciliationService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	reconciliationRepo repo.ReconciliationRepository,
+) *ReconciliationService {
+	return &ReconciliationService{
+		userRepo:           userRepo,
+		accountRepo:        accountRepo,
+		reconciliationRepo: reconciliationRepo,
+	}
+}
+
+// StartReconciliation apre una riconciliazione dell'account con la data e il saldo di chiusura
+// dell'estratto conto. Ogni account può averne una sola aperta alla volta.
+func (reconciliationService *ReconciliationService) StartReconciliation(ctx context.Context, startDto dto.StartReconciliationDto) (dto.ReconciliationStatus, error) {
+	user, err := reconciliationService.userRepo.GetUserByID(ctx, startDto.UserID)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+
+	account, err := reconciliationService.accountRepo.GetAccountByID(ctx, user, startDto.AccountID)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+	if err := ensureCanEditAccount(ctx, reconciliationService.accountRepo, user, account); err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+
+	history, err := reconciliationService.reconciliationRepo.GetReconciliations(ctx, account)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+	for _, previous := range history {
+		if previous.Status == dto.ReconciliationInProgress {
+			return dto.ReconciliationStatus{}, fmt.Errorf("%w: reconciliation %d is already in progress", apierr.ErrConflict, previous.ID)
+		}
+		if !startDto.StatementDate.After(previous.StatementDate) {
+			return dto.ReconciliationStatus{}, fmt.Errorf("%w: statement date must be after %s", apierr.ErrInvalidData, previous.StatementDate.Format("2006-01-02"))
+		}
+	}
+
+	reconciliation, err := reconciliationService.reconciliationRepo.CreateReconciliation(ctx, account, startDto.StatementDate, startDto.StatementBalance)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+
+	return reconciliationService.status(ctx, account, reconciliation)
+}
+
+func (reconciliationService *ReconciliationService) GetReconciliations(ctx context.Context, userID int64, accountID int64) ([]dbgen.Reconciliation, error) {
+	user, err := reconciliationService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := reconciliationService.accountRepo.GetAccountByID(ctx, user, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return reconciliationService.reconciliationRepo.GetReconciliations(ctx, account)
+}
+
+func (reconciliationService *ReconciliationService) GetReconciliation(ctx context.Context, userID int64, reconciliationID int64) (dto.ReconciliationStatus, error) {
+	account, reconciliation, err := reconciliationService.load(ctx, userID, reconciliationID, false)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+
+	return reconciliationService.status(ctx, account, reconciliation)
+}
+
+// ClearEntries spunta (o toglie la spunta a) i movimenti indicati. Sono ammessi solo
+// movimenti non ancora riconciliati con data entro quella dell'estratto conto.
+func (reconciliationService *ReconciliationService) ClearEntries(ctx context.Context, clearDto dto.ClearEntriesDto) (dto.ReconciliationStatus, error) {
+	account, reconciliation, err := reconciliationService.load(ctx, clearDto.UserID, clearDto.ReconciliationID, true)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+	if reconciliation.Status != dto.ReconciliationInProgress {
+		return dto.ReconciliationStatus{}, fmt.Errorf("%w: reconciliation %d is already completed", apierr.ErrConflict, reconciliation.ID)
+	}
+	if len(clearDto.EntryIDs) == 0 {
+		return dto.ReconciliationStatus{}, fmt.Errorf("%w: at least one entry is required", apierr.ErrInvalidData)
+	}
+
+	entries, err := reconciliationService.reconciliationRepo.GetReconciliationEntries(ctx, account, reconciliation.StatementDate)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+	open := make(map[int64]bool, len(entries))
+	for _, entry := range entries {
+		open[entry.EntryID] = true
+	}
+	for _, entryID := range clearDto.EntryIDs {
+		if !open[entryID] {
+			return dto.ReconciliationStatus{}, fmt.Errorf("%w: entry %d is not part of this reconciliation", apierr.ErrInvalidData, entryID)
+		}
+	}
+
+	status := dto.Uncleared
+	if clearDto.Cleared {
+		status = dto.Cleared
+	}
+	if err := reconciliationService.reconciliationRepo.SetEntriesStatus(ctx, account, clearDto.EntryIDs, status); err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+
+	return reconciliationService.status(ctx, account, reconciliation)
+}
+
+// CompleteReconciliation chiude la riconciliazione quando la differenza è zero; da quel
+// momento i movimenti spuntati risultano riconciliati e non sono più modificabili. La
+// differenza viene ricalcolata nella transazione che riconcilia i movimenti, così una spunta
+// o una modifica concorrente non può chiudere una riconciliazione che non quadra.
+func (reconciliationService *ReconciliationService) CompleteReconciliation(ctx context.Context, userID int64, reconciliationID int64) (dto.ReconciliationStatus, error) {
+	account, reconciliation, err := reconciliationService.load(ctx, userID, reconciliationID, true)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+	if reconciliation.Status != dto.ReconciliationInProgress {
+		return dto.ReconciliationStatus{}, fmt.Errorf("%w: reconciliation %d is already completed", apierr.ErrConflict, reconciliation.ID)
+	}
+
+	completed, _, err := reconciliationService.reconciliationRepo.CompleteReconciliation(ctx, reconciliation, func(reconciled int64) error {
+		if difference := reconciliation.StatementBalance - (account.InitialBalance + reconciled); difference != 0 {
+			return fmt.Errorf("%w: %d cents left", apierr.ErrNotReconciled, difference)
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+
+	return reconciliationService.status(ctx, account, completed)
+}
+
+// CancelReconciliation elimina una riconciliazione aperta; le spunte sui movimenti restano.
+func (reconciliationService *ReconciliationService) CancelReconciliation(ctx context.Context, userID int64, reconciliationID int64) error {
+	_, reconciliation, err := reconciliationService.load(ctx, userID, reconciliationID, true)
+	if err != nil {
+		return err
+	}
+
+	return reconciliationService.reconciliationRepo.DeleteReconciliation(ctx, reconciliation)
+}
+
+// load carica la riconciliazione con il suo account; con edit verifica anche che l'utente
+// possa modificare l'account.
+func (reconciliationService *ReconciliationService) load(ctx context.Context, userID int64, reconciliationID int64, edit bool) (dbgen.Account, dbgen.Reconciliation, error) {
+	user, err := reconciliationService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dbgen.Account{}, dbgen.Reconciliation{}, err
+	}
+
+	reconciliation, err := reconciliationService.reconciliationRepo.GetReconciliationByID(ctx, user, reconciliationID)
+	if err != nil {
+		return dbgen.Account{}, dbgen.Reconciliation{}, err
+	}
+
+	account, err := reconciliationService.accountRepo.GetAccountByID(ctx, user, reconciliation.AccountID)
+	if err != nil {
+		return dbgen.Account{}, dbgen.Reconciliation{}, err
+	}
+	if edit {
+		if err := ensureCanEditAccount(ctx, reconciliationService.accountRepo, user, account); err != nil {
+			return dbgen.Account{}, dbgen.Reconciliation{}, err
+		}
+	}
+
+	return account, reconciliation, nil
+}
+
+func (reconciliationService *ReconciliationService) status(ctx context.Context, account dbgen.Account, reconciliation dbgen.Reconciliation) (dto.ReconciliationStatus, error) {
+	cleared, err := reconciliationService.reconciliationRepo.GetClearedBalance(ctx, account, reconciliation.StatementDate)
+	if err != nil {
+		return dto.ReconciliationStatus{}, err
+	}
+
+	entries := []dbgen.GetReconciliationEntriesRow{}
+	if reconciliation.Status == dto.ReconciliationInProgress {
+		entries, err = reconciliationService.reconciliationRepo.GetReconciliationEntries(ctx, account, reconciliation.StatementDate)
+		if err != nil {
+			return dto.ReconciliationStatus{}, err
+		}
+	}
+
+	clearedBalance := account.InitialBalance + cleared
+	return dto.ReconciliationStatus{
+		Reconciliation: reconciliation,
+		Account:        account,
+		ClearedBalance: clearedBalance,
+		Difference:     reconciliation.StatementBalance - clearedBalance,
+		Entries:        entries,
+	}, nil
+}
+
+// ensureNotReconciled impedisce di modificare una transazione con movimenti già riconciliati.
+func ensureNotReconciled(ctx context.Context, accountRepo repo.AccountRepository, transaction dbgen.Transaction) error {
+	reconciled, err := accountRepo.IsTransactionReconciled(ctx, transaction)
+	if err != nil {
+		return err
+	}
+	if reconciled {
+		return fmt.Errorf("%w: %d", apierr.ErrTransactionLocked, transaction.ID)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+type ReimbursementService struct {
+	userRepo          repo.UserRepository
+	accountRepo       repo.AccountRepository
+	reimbursementRepo repo.ReimbursementRepository
+}
+
+func NewReimbursementService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	reimbursementRepo repo.ReimbursementRepository,
+) *ReimbursementService {
+	return &ReimbursementService{
+		userRepo:          userRepo,
+		accountRepo:       accountRepo,
+		reimbursementRepo: reimbursementRepo,
+	}
+}
+
+// MarkReimbursable segna un movimento di spesa come rimborsabile da una controparte (il datore
+// di lavoro, una persona, ...).
+func (reimbursementService *ReimbursementService) MarkReimbursable(ctx context.Context, markDto dto.MarkReimbursableDto) (dto.ReimbursableEntry, error) {
+	party := strings.TrimSpace(markDto.Party)
+	if party == "" {
+		return dto.ReimbursableEntry{}, fmt.Errorf("%w: party is required", apierr.ErrInvalidData)
+	}
+
+	user, entry, err := reimbursementService.loadEntry(ctx, markDto.UserID, markDto.EntryID)
+	if err != nil {
+		return dto.ReimbursableEntry{}, err
+	}
+	if entry.Amount >= 0 || !entry.CategoryID.Valid {
+		return dto.ReimbursableEntry{}, fmt.Errorf("%w: entry %d is not an expense", apierr.ErrInvalidData, entry.ID)
+	}
+
+	if _, err := reimbursementService.reimbursementRepo.MarkReimbursable(ctx, user, entry, party); err != nil {
+		return dto.ReimbursableEntry{}, err
+	}
+
+	return reimbursementService.entry(ctx, user, entry.ID)
+}
+
+// UnmarkReimbursable toglie il segno di rimborsabile; i rimborsi collegati vengono scollegati.
+func (reimbursementService *ReimbursementService) UnmarkReimbursable(ctx context.Context, userID int64, entryID int64) error {
+	_, entry, err := reimbursementService.loadEntry(ctx, userID, entryID)
+	if err != nil {
+		return err
+	}
+
+	return reimbursementService.reimbursementRepo.UnmarkReimbursable(ctx, entry)
+}
+
+// AddReimbursement collega un movimento di entrata come rimborso, totale o parziale, di una
+// spesa rimborsabile. La stessa entrata può rimborsare più spese, fino al suo importo.
+func (reimbursementService *ReimbursementService) AddReimbursement(ctx context.Context, reimbursementDto dto.ReimbursementDto) (dto.ReimbursableEntry, error) {
+	if reimbursementDto.Amount != nil && *reimbursementDto.Amount <= 0 {
+		return dto.ReimbursableEntry{}, fmt.Errorf("%w: amount must be positive", apierr.ErrInvalidData)
+	}
+
+	user, expense, err := reimbursementService.loadEntry(ctx, reimbursementDto.UserID, reimbursementDto.ExpenseEntryID)
+	if err != nil {
+		return dto.ReimbursableEntry{}, err
+	}
+	_, income, err := reimbursementService.loadEntry(ctx, reimbursementDto.UserID, reimbursementDto.IncomeEntryID)
+	if err != nil {
+		return dto.ReimbursableEntry{}, err
+	}
+	if income.Amount <= 0 || !income.CategoryID.Valid {
+		return dto.ReimbursableEntry{}, fmt.Errorf("%w: entry %d is not an income", apierr.ErrInvalidData, income.ID)
+	}
+
+	expenseAccount, err := reimbursementService.accountRepo.GetAccountByID(ctx, user, expense.AccountID)
+	if err != nil {
+		return dto.ReimbursableEntry{}, err
+	}
+	incomeAccount, err := reimbursementService.accountRepo.GetAccountByID(ctx, user, income.AccountID)
+	if err != nil {
+		return dto.ReimbursableEntry{}, err
+	}
+	if expenseAccount.Currency != incomeAccount.Currency {
+		return dto.ReimbursableEntry{}, fmt.Errorf("%w: income currency %s differs from expense currency %s", apierr.ErrInvalidData, incomeAccount.Currency, expenseAccount.Currency)
+	}
+
+	if _, err := reimbursementService.reimbursementRepo.AddReimbursement(ctx, expense, income, reimbursementDto.Amount); err != nil {
+		return dto.ReimbursableEntry{}, err
+	}
+
+	return reimbursementService.entry(ctx, user, expense.ID)
+}
+
+// DeleteReimbursement scollega un rimborso: la spesa torna da rimborsare per quell'importo.
+func (reimbursementService *ReimbursementService) DeleteReimbursement(ctx context.Context, userID int64, reimbursementID int64) error {
+	user, err := reimbursementService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	reimbursement, err := reimbursementService.reimbursementRepo.GetReimbursement(ctx, user, reimbursementID)
+	if err != nil {
+		return err
+	}
+	if _, _, err := reimbursementService.loadEntry(ctx, userID, reimbursement.ExpenseEntryID); err != nil {
+		return err
+	}
+
+	return reimbursementService.reimbursementRepo.DeleteReimbursement(ctx, reimbursement)
+}
+
+// GetReport restituisce le spese rimborsabili (di default solo quelle ancora aperte) con il
+// totale dovuto da ciascuna controparte.
+func (reimbursementService *ReimbursementService) GetReport(ctx context.Context, userID int64, party *string, includeSettled bool) (dto.ReimbursementReport, error) {
+	user, err := reimbursementService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dto.ReimbursementReport{}, err
+	}
+
+	entries, err := reimbursementService.entries(ctx, user, nil, party, includeSettled)
+	if err != nil {
+		return dto.ReimbursementReport{}, err
+	}
+
+	type partyKey struct {
+		party    string
+		currency string
+	}
+	totals := make(map[partyKey]int64)
+	for _, entry := range entries {
+		totals[partyKey{party: entry.Entry.Party, currency: entry.Entry.Currency}] += entry.Outstanding()
+	}
+	report := dto.ReimbursementReport{
+		Entries: entries,
+		Parties: make([]dto.PartyOutstanding, 0, len(totals)),
+	}
+	for key, outstanding := range totals {
+		report.Parties = append(report.Parties, dto.PartyOutstanding{
+			Party:       key.party,
+			Currency:    key.currency,
+			Outstanding: outstanding,
+		})
+	}
+	sort.Slice(report.Parties, func(i, j int) bool {
+		if report.Parties[i].Outstanding != report.Parties[j].Outstanding {
+			return report.Parties[i].Outstanding > report.Parties[j].Outstanding
+		}
+		return report.Parties[i].Party < report.Parties[j].Party
+	})
+	return report, nil
+}
+
+// loadEntry carica il movimento verificando che l'utente possa modificarne la transazione.
+func (reimbursementService *ReimbursementService) loadEntry(ctx context.Context, userID int64, entryID int64) (dbgen.User, dbgen.TransactionEntry, error) {
+	user, err := reimbursementService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dbgen.User{}, dbgen.TransactionEntry{}, err
+	}
+
+	entry, err := reimbursementService.reimbursementRepo.GetEntry(ctx, user, entryID)
+	if err != nil {
+		return dbgen.User{}, dbgen.TransactionEntry{}, err
+	}
+
+	transaction, err := reimbursementService.accountRepo.GetTransaction(ctx, user, entry.TransactionID)
+	if err != nil {
+		return dbgen.User{}, dbgen.TransactionEntry{}, err
+	}
+	if err := ensureCanEditTransaction(ctx, reimbursementService.accountRepo, user, transaction); err != nil {
+		return dbgen.User{}, dbgen.TransactionEntry{}, err
+	}
+
+	return user, entry, nil
+}
+
+func (reimbursementService *ReimbursementService) entry(ctx context.Context, user dbgen.User, entryID int64) (dto.ReimbursableEntry, error) {
+	entries, err := reimbursementService.entries(ctx, user, &entryID, nil, true)
+	if err != nil {
+		return dto.ReimbursableEntry{}, err
+	}
+	if len(entries) == 0 {
+		return dto.ReimbursableEntry{}, fmt.Errorf("%w: entry %d is not reimbursable", apierr.ErrReimbursementNotFound, entryID)
+	}
+	return entries[0], nil
+}
+
+func (reimbursementService *ReimbursementService) entries(ctx context.Context, user dbgen.User, entryID *int64, party *string, includeSettled bool) ([]dto.ReimbursableEntry, error) {
+	rows, err := reimbursementService.reimbursementRepo.GetReimbursables(ctx, user, entryID, party, includeSettled)
+	if err != nil {
+		return nil, err
+	}
+	reimbursements, err := reimbursementService.reimbursementRepo.GetReimbursements(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	byEntry := make(map[int64][]dbgen.Reimbursement)
+	for _, reimbursement := range reimbursements {
+		byEntry[reimbursement.ExpenseEntryID] = append(byEntry[reimbursement.ExpenseEntryID], reimbursement)
+	}
+	entries := make([]dto.ReimbursableEntry, len(rows))
+	for i, row := range rows {
+		entries[i] = dto.ReimbursableEntry{
+			Entry:          row,
+			Reimbursements: byEntry[row.EntryID],
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,44 @@
+package service
+
+import "testing"
+
+func TestPgDumpConnection(t *testing.T) {
+	tests := []struct {
+		name         string
+		databaseURL  string
+		wantDBName   string
+		wantPassword string
+	}{
+		{
+			name:         "url with password",
+			databaseURL:  "postgres://koin:s3cr%40t@db:5432/koin?sslmode=disable",
+			wantDBName:   "postgres://koin@db:5432/koin?sslmode=disable",
+			wantPassword: "s3cr@t",
+		},
+		{
+			name:         "password in the query",
+			databaseURL:  "postgresql://koin@db/koin?password=secret&sslmode=require",
+			wantDBName:   "postgresql://koin@db/koin?sslmode=require",
+			wantPassword: "secret",
+		},
+		{
+			name:        "url without password",
+			databaseURL: "postgres://koin@db/koin",
+			wantDBName:  "postgres://koin@db/koin",
+		},
+		{
+			name:         "key value",
+			databaseURL:  "host=db user=koin password='secret' dbname=koin",
+			wantDBName:   "host=db user=koin dbname=koin",
+			wantPassword: "secret",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dbname, password := pgDumpConnection(tt.databaseURL)
+			if dbname != tt.wantDBName || password != tt.wantPassword {
+				t.Errorf("pgDumpConnection(%q) = %q, %q, want %q, %q", tt.databaseURL, dbname, password, tt.wantDBName, tt.wantPassword)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+)
+
+// splitAmounts divide total (in centesimi) tra le parti secondo il metodo scelto. Con EQUAL e
+// SHARES i centesimi avanzati dall'arrotondamento vanno alle parti con il resto più alto (a
+// parità, nell'ordine indicato), così che la somma delle quote sia sempre pari a total.
+func splitAmounts(total int64, method dto.SplitMethod, parts []dto.SplitPart) ([]int64, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: at least one participant is required", apierr.ErrInvalidData)
+	}
+
+	weights := make([]int64, len(parts))
+	switch method {
+	case dto.SplitEqual:
+		for i := range weights {
+			weights[i] = 1
+		}
+	case dto.SplitShares:
+		for i, part := range parts {
+			if part.Shares == nil || *part.Shares < 0 {
+				return nil, fmt.Errorf("%w: member %d needs a non-negative number of shares", apierr.ErrInvalidData, part.MemberID)
+			}
+			weights[i] = int64(*part.Shares)
+		}
+	case dto.SplitExact:
+		amounts := make([]int64, len(parts))
+		var sum int64
+		for i, part := range parts {
+			if part.Amount == nil || *part.Amount < 0 {
+				return nil, fmt.Errorf("%w: member %d needs a non-negative amount", apierr.ErrInvalidData, part.MemberID)
+			}
+			amounts[i] = *part.Amount
+			sum += amounts[i]
+		}
+		if sum != total {
+			return nil, fmt.Errorf("%w: exact amounts add up to %d instead of %d", apierr.ErrInvalidData, sum, total)
+		}
+		return amounts, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown split method %q", apierr.ErrInvalidData, method)
+	}
+
+	var totalWeight int64
+	for _, weight := range weights {
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return nil, fmt.Errorf("%w: total shares must be positive", apierr.ErrInvalidData)
+	}
+
+	amounts := make([]int64, len(parts))
+	remainders := make([]*big.Int, len(parts))
+	assigned := int64(0)
+	for i, weight := range weights {
+		product := new(big.Int).Mul(big.NewInt(total), big.NewInt(weight))
+		quotient, remainder := product.QuoRem(product, big.NewInt(totalWeight), new(big.Int))
+		amounts[i] = quotient.Int64()
+		remainders[i] = remainder
+		assigned += amounts[i]
+	}
+
+	order := make([]int, len(parts))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+	for _, i := range order[:total-assigned] {
+		amounts[i]++
+	}
+	return amounts, nil
+}
+
+// groupBalances calcola per ogni membro quanto ha pagato, quanto deve e il saldo netto, tenendo
+// conto dei pagamenti già registrati tra i partecipanti.
+func groupBalances(members []dbgen.SplitMember, expenses []dbgen.GetSplitExpensesRow, shares []dbgen.SplitShare, settlements []dbgen.SplitSettlement) []dto.MemberBalance {
+	index := make(map[int64]int, len(members))
+	balances := make([]dto.MemberBalance, len(members))
+	for i, member := range members {
+		index[member.ID] = i
+		balances[i].Member = member
+	}
+
+	for _, expense := range expenses {
+		balances[index[expense.PaidBy]].Paid += expense.Amount
+	}
+	for _, share := range shares {
+		balances[index[share.MemberID]].Owed += share.Amount
+	}
+	for i := range balances {
+		balances[i].Net = balances[i].Paid - balances[i].Owed
+	}
+	for _, settlement := range settlements {
+		balances[index[settlement.FromMemberID]].Net += settlement.Amount
+		balances[index[settlement.ToMemberID]].Net -= settlement.Amount
+	}
+	return balances
+}
+
+// settleUp propone i pagamenti che azzerano i saldi. Prima abbina debitori e creditori con
+// importi identici, poi salda sempre il debito più alto con il credito più alto: il numero di
+// pagamenti non supera mai quello dei membri con saldo diverso da zero meno uno.
+func settleUp(balances []dto.MemberBalance) []dto.SettleUpTransfer {
+	type position struct {
+		memberID int64
+		amount   int64
+	}
+	var debtors, creditors []position
+	for _, balance := range balances {
+		if balance.Net < 0 {
+			debtors = append(debtors, position{memberID: balance.Member.ID, amount: -balance.Net})
+		} else if balance.Net > 0 {
+			creditors = append(creditors, position{memberID: balance.Member.ID, amount: balance.Net})
+		}
+	}
+
+	var transfers []dto.SettleUpTransfer
+	for d := range debtors {
+		for c := range creditors {
+			if debtors[d].amount > 0 && debtors[d].amount == creditors[c].amount {
+				transfers = append(transfers, dto.SettleUpTransfer{
+					FromMemberID: debtors[d].memberID,
+					ToMemberID:   creditors[c].memberID,
+					Amount:       debtors[d].amount,
+				})
+				debtors[d].amount, creditors[c].amount = 0, 0
+				break
+			}
+		}
+	}
+
+	largest := func(positions []position) int {
+		best := -1
+		for i, current := range positions {
+			if current.amount > 0 && (best < 0 || current.amount > positions[best].amount) {
+				best = i
+			}
+		}
+		return best
+	}
+	for {
+		d, c := largest(debtors), largest(creditors)
+		if d < 0 || c < 0 {
+			break
+		}
+		amount := min(debtors[d].amount, creditors[c].amount)
+		transfers = append(transfers, dto.SettleUpTransfer{
+			FromMemberID: debtors[d].memberID,
+			ToMemberID:   creditors[c].memberID,
+			Amount:       amount,
+		})
+		debtors[d].amount -= amount
+		creditors[c].amount -= amount
+	}
+	return transfers
+}
@@ -0,0 +1,456 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+type SplitService struct {
+	userRepo     repo.UserRepository
+	accountRepo  repo.AccountRepository
+	categoryRepo repo.CategoryRepository
+	splitRepo    repo.SplitRepository
+}
+
+func NewSplitService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	categoryRepo repo.CategoryRepository,
+	splitRepo repo.SplitRepository,
+) *SplitService {
+	return &SplitService{
+		userRepo:     userRepo,
+		accountRepo:  accountRepo,
+		categoryRepo: categoryRepo,
+		splitRepo:    splitRepo,
+	}
+}
+
+// CreateGroup crea un gruppo di spese condivise di cui l'utente è il primo partecipante.
+func (splitService *SplitService) CreateGroup(ctx context.Context, createDto dto.CreateSplitGroupDto) (dto.SplitGroupDetail, error) {
+	createDto.Name = strings.TrimSpace(createDto.Name)
+	createDto.Currency = strings.ToUpper(strings.TrimSpace(createDto.Currency))
+	if createDto.Name == "" || !currencyPattern.MatchString(createDto.Currency) {
+		return dto.SplitGroupDetail{}, fmt.Errorf("%w: name and a 3-letter currency are required", apierr.ErrInvalidData)
+	}
+
+	user, err := splitService.userRepo.GetUserByID(ctx, createDto.UserID)
+	if err != nil {
+		return dto.SplitGroupDetail{}, err
+	}
+
+	memberName := user.Email
+	if createDto.MemberName != nil && strings.TrimSpace(*createDto.MemberName) != "" {
+		memberName = strings.TrimSpace(*createDto.MemberName)
+	}
+
+	group, err := splitService.splitRepo.CreateGroup(ctx, user, createDto, memberName)
+	if err != nil {
+		return dto.SplitGroupDetail{}, err
+	}
+
+	return splitService.detail(ctx, group)
+}
+
+func (splitService *SplitService) GetGroups(ctx context.Context, userID int64) ([]dbgen.SplitGroup, error) {
+	user, err := splitService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return splitService.splitRepo.GetGroups(ctx, user)
+}
+
+// GetGroup restituisce il gruppo con i saldi dei membri e i pagamenti suggeriti per azzerarli.
+func (splitService *SplitService) GetGroup(ctx context.Context, userID int64, groupID int64) (dto.SplitGroupDetail, error) {
+	_, group, _, err := splitService.load(ctx, userID, groupID)
+	if err != nil {
+		return dto.SplitGroupDetail{}, err
+	}
+
+	return splitService.detail(ctx, group)
+}
+
+// AddMember aggiunge al gruppo un utente koin oppure una persona senza account. Il nome deve
+// essere unico nel gruppo; per gli utenti koin è di default la loro email.
+func (splitService *SplitService) AddMember(ctx context.Context, memberDto dto.AddSplitMemberDto) (dbgen.SplitMember, error) {
+	_, group, _, err := splitService.load(ctx, memberDto.UserID, memberDto.GroupID)
+	if err != nil {
+		return dbgen.SplitMember{}, err
+	}
+
+	var name string
+	if memberDto.Name != nil {
+		name = strings.TrimSpace(*memberDto.Name)
+	}
+	if memberDto.MemberUserID != nil {
+		memberUser, err := splitService.userRepo.GetUserByID(ctx, *memberDto.MemberUserID)
+		if err != nil {
+			return dbgen.SplitMember{}, err
+		}
+		_, err = splitService.splitRepo.GetMemberByUser(ctx, group, memberUser.ID)
+		if err == nil {
+			return dbgen.SplitMember{}, fmt.Errorf("%w: user %d is already part of group %q", apierr.ErrConflict, memberUser.ID, group.Name)
+		}
+		if !errors.Is(err, apierr.ErrSplitGroupNotFound) {
+			return dbgen.SplitMember{}, err
+		}
+		if name == "" {
+			name = memberUser.Email
+		}
+	}
+	if name == "" {
+		return dbgen.SplitMember{}, fmt.Errorf("%w: a name or a koin user is required", apierr.ErrInvalidData)
+	}
+
+	members, err := splitService.splitRepo.GetMembers(ctx, group)
+	if err != nil {
+		return dbgen.SplitMember{}, err
+	}
+	for _, member := range members {
+		if strings.EqualFold(member.Name, name) {
+			return dbgen.SplitMember{}, fmt.Errorf("%w: group %q already has a member named %q", apierr.ErrConflict, group.Name, name)
+		}
+	}
+
+	return splitService.splitRepo.AddMember(ctx, group, memberDto.MemberUserID, name)
+}
+
+// AddExpense divide tra i membri la parte di spesa di una transazione. Paga il membro che
+// corrisponde all'utente che ha registrato la transazione.
+func (splitService *SplitService) AddExpense(ctx context.Context, expenseDto dto.SplitExpenseDto) (dto.SplitExpenseDetail, error) {
+	user, group, _, err := splitService.load(ctx, expenseDto.UserID, expenseDto.GroupID)
+	if err != nil {
+		return dto.SplitExpenseDetail{}, err
+	}
+
+	transaction, err := splitService.accountRepo.GetTransaction(ctx, user, expenseDto.TransactionID)
+	if err != nil {
+		return dto.SplitExpenseDetail{}, err
+	}
+	if err := ensureCanEditTransaction(ctx, splitService.accountRepo, user, transaction); err != nil {
+		return dto.SplitExpenseDetail{}, err
+	}
+
+	expense, err := splitService.splitRepo.GetTransactionExpense(ctx, transaction)
+	if err != nil {
+		return dto.SplitExpenseDetail{}, err
+	}
+	if expense.Amount <= 0 {
+		return dto.SplitExpenseDetail{}, fmt.Errorf("%w: transaction %d is not an expense", apierr.ErrInvalidData, transaction.ID)
+	}
+	if expense.Currencies != 1 || expense.Currency != group.Currency {
+		return dto.SplitExpenseDetail{}, fmt.Errorf("%w: transaction currency differs from group currency %s", apierr.ErrInvalidData, group.Currency)
+	}
+
+	payer, err := splitService.splitRepo.GetMemberByUser(ctx, group, transaction.UserID)
+	if err != nil {
+		if errors.Is(err, apierr.ErrSplitGroupNotFound) {
+			return dto.SplitExpenseDetail{}, fmt.Errorf("%w: the user who paid is not part of group %q", apierr.ErrInvalidData, group.Name)
+		}
+		return dto.SplitExpenseDetail{}, err
+	}
+
+	members, err := splitService.splitRepo.GetMembers(ctx, group)
+	if err != nil {
+		return dto.SplitExpenseDetail{}, err
+	}
+	if expenseDto.Method == "" {
+		expenseDto.Method = dto.SplitEqual
+	}
+	parts := expenseDto.Parts
+	if len(parts) == 0 {
+		if expenseDto.Method != dto.SplitEqual {
+			return dto.SplitExpenseDetail{}, fmt.Errorf("%w: method %s requires the participants", apierr.ErrInvalidData, expenseDto.Method)
+		}
+		for _, member := range members {
+			parts = append(parts, dto.SplitPart{MemberID: member.ID})
+		}
+	}
+	inGroup := make(map[int64]bool, len(members))
+	for _, member := range members {
+		inGroup[member.ID] = true
+	}
+	seen := make(map[int64]bool, len(parts))
+	for _, part := range parts {
+		if !inGroup[part.MemberID] {
+			return dto.SplitExpenseDetail{}, fmt.Errorf("%w: member %d is not part of group %q", apierr.ErrInvalidData, part.MemberID, group.Name)
+		}
+		if seen[part.MemberID] {
+			return dto.SplitExpenseDetail{}, fmt.Errorf("%w: member %d appears more than once", apierr.ErrInvalidData, part.MemberID)
+		}
+		seen[part.MemberID] = true
+	}
+
+	amounts, err := splitAmounts(expense.Amount, expenseDto.Method, parts)
+	if err != nil {
+		return dto.SplitExpenseDetail{}, err
+	}
+	shares := make([]dto.SplitShareAmount, len(parts))
+	for i, part := range parts {
+		shares[i] = dto.SplitShareAmount{
+			MemberID: part.MemberID,
+			Amount:   amounts[i],
+		}
+		if expenseDto.Method == dto.SplitShares {
+			shares[i].Shares = part.Shares
+		}
+	}
+
+	created, err := splitService.splitRepo.CreateExpense(ctx, group, transaction, payer, expense.Amount, expenseDto.Method, shares)
+	if err != nil {
+		return dto.SplitExpenseDetail{}, err
+	}
+
+	details, err := splitService.expenses(ctx, group)
+	if err != nil {
+		return dto.SplitExpenseDetail{}, err
+	}
+	for _, detail := range details {
+		if detail.Expense.ID == created.ID {
+			return detail, nil
+		}
+	}
+	return dto.SplitExpenseDetail{}, fmt.Errorf("%w: %d", apierr.ErrSplitExpenseNotFound, created.ID)
+}
+
+func (splitService *SplitService) GetExpenses(ctx context.Context, userID int64, groupID int64) ([]dto.SplitExpenseDetail, error) {
+	_, group, _, err := splitService.load(ctx, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return splitService.expenses(ctx, group)
+}
+
+// DeleteExpense annulla la divisione di una spesa; può farlo chi ha pagato o chi ha creato il gruppo.
+func (splitService *SplitService) DeleteExpense(ctx context.Context, userID int64, groupID int64, expenseID int64) error {
+	user, group, member, err := splitService.load(ctx, userID, groupID)
+	if err != nil {
+		return err
+	}
+
+	expense, err := splitService.splitRepo.GetExpense(ctx, group, expenseID)
+	if err != nil {
+		return err
+	}
+	if expense.PaidBy != member.ID && group.CreatedBy != user.ID {
+		return fmt.Errorf("%w: only the payer or the group creator can delete expense %d", apierr.ErrForbidden, expense.ID)
+	}
+
+	return splitService.splitRepo.DeleteExpense(ctx, group, expense)
+}
+
+// RecordSettlement registra un pagamento tra due membri e, se chi lo registra è uno dei due, il
+// suo movimento: una spesa in "Spese condivise" per chi paga o un'entrata in "Rimborsi spese
+// condivise" per chi riceve. L'altro membro, se è un utente koin, registra il proprio con
+// ConfirmSettlement sull'account che sceglie. Solo le persone coinvolte possono registrare il
+// pagamento, salvo che siano entrambe senza account.
+func (splitService *SplitService) RecordSettlement(ctx context.Context, settlementDto dto.SettlementDto) (dbgen.SplitSettlement, error) {
+	if settlementDto.Amount <= 0 || settlementDto.FromMemberID == settlementDto.ToMemberID {
+		return dbgen.SplitSettlement{}, fmt.Errorf("%w: a positive amount between two different members is required", apierr.ErrInvalidData)
+	}
+	if settlementDto.SettledAt.IsZero() {
+		settlementDto.SettledAt = time.Now()
+	}
+	settlementDto.SettledAt = toDate(settlementDto.SettledAt)
+
+	user, group, member, err := splitService.load(ctx, settlementDto.UserID, settlementDto.GroupID)
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+
+	members, err := splitService.splitRepo.GetMembers(ctx, group)
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+	var from, to *dbgen.SplitMember
+	for i := range members {
+		switch members[i].ID {
+		case settlementDto.FromMemberID:
+			from = &members[i]
+		case settlementDto.ToMemberID:
+			to = &members[i]
+		}
+	}
+	if from == nil || to == nil {
+		return dbgen.SplitSettlement{}, fmt.Errorf("%w: both members must be part of group %q", apierr.ErrInvalidData, group.Name)
+	}
+	if (from.UserID.Valid || to.UserID.Valid) && from.ID != member.ID && to.ID != member.ID {
+		return dbgen.SplitSettlement{}, fmt.Errorf("%w: only the members involved can record this settlement", apierr.ErrForbidden)
+	}
+
+	description := fmt.Sprintf("%s: %s → %s", group.Name, from.Name, to.Name)
+	var fromEntry, toEntry *dto.SettlementEntry
+	switch member.ID {
+	case from.ID:
+		fromEntry, err = splitService.settlementEntry(ctx, group, user, settlementDto.AccountID, -settlementDto.Amount, description)
+	case to.ID:
+		toEntry, err = splitService.settlementEntry(ctx, group, user, settlementDto.AccountID, settlementDto.Amount, description)
+	}
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+
+	return splitService.splitRepo.RecordSettlement(ctx, group, settlementDto, fromEntry, toEntry)
+}
+
+// ConfirmSettlement registra sull'account indicato il movimento di un membro coinvolto in un
+// pagamento registrato da un altro; fallisce con ErrConflict se il movimento c'è già.
+func (splitService *SplitService) ConfirmSettlement(ctx context.Context, userID int64, groupID int64, settlementID int64, accountID int64) (dbgen.SplitSettlement, error) {
+	user, group, member, err := splitService.load(ctx, userID, groupID)
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+	settlement, err := splitService.splitRepo.GetSettlement(ctx, group, settlementID)
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+
+	var amount int64
+	var confirmed bool
+	switch member.ID {
+	case settlement.FromMemberID:
+		amount, confirmed = -settlement.Amount, settlement.FromTransactionID.Valid
+	case settlement.ToMemberID:
+		amount, confirmed = settlement.Amount, settlement.ToTransactionID.Valid
+	default:
+		return dbgen.SplitSettlement{}, fmt.Errorf("%w: only the members involved can confirm settlement %d", apierr.ErrForbidden, settlement.ID)
+	}
+	if confirmed {
+		return dbgen.SplitSettlement{}, fmt.Errorf("%w: settlement %d is already confirmed", apierr.ErrConflict, settlement.ID)
+	}
+
+	members, err := splitService.splitRepo.GetMembers(ctx, group)
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+	names := make(map[int64]string, len(members))
+	for _, groupMember := range members {
+		names[groupMember.ID] = groupMember.Name
+	}
+	description := fmt.Sprintf("%s: %s → %s", group.Name, names[settlement.FromMemberID], names[settlement.ToMemberID])
+
+	entry, err := splitService.settlementEntry(ctx, group, user, &accountID, amount, description)
+	if err != nil {
+		return dbgen.SplitSettlement{}, err
+	}
+	return splitService.splitRepo.ConfirmSettlement(ctx, settlement, *entry)
+}
+
+// settlementEntry prepara il movimento del pagamento sull'account dell'utente che lo registra
+// o lo conferma: una spesa per chi paga (amount negativo), un'entrata per chi riceve.
+func (splitService *SplitService) settlementEntry(ctx context.Context, group dbgen.SplitGroup, user dbgen.User, accountID *int64, amount int64, description string) (*dto.SettlementEntry, error) {
+	if accountID == nil {
+		return nil, fmt.Errorf("%w: accountId is required for your side of the settlement", apierr.ErrInvalidData)
+	}
+
+	account, err := splitService.accountRepo.GetAccountByID(ctx, user, *accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureCanEditAccount(ctx, splitService.accountRepo, user, account); err != nil {
+		return nil, err
+	}
+	if account.Currency != group.Currency {
+		return nil, fmt.Errorf("%w: account currency %s differs from group currency %s", apierr.ErrInvalidData, account.Currency, group.Currency)
+	}
+
+	categoryName, categoryType := dto.SharedExpensesCategory, dto.Expense
+	if amount > 0 {
+		categoryName, categoryType = dto.SharedExpensesRefundCategory, dto.Income
+	}
+	category, err := getOrCreateCategory(ctx, splitService.categoryRepo, user, categoryName, categoryType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.SettlementEntry{
+		User:        user,
+		Account:     account,
+		Category:    category,
+		Amount:      amount,
+		Description: description,
+	}, nil
+}
+
+// load carica il gruppo verificando che l'utente ne faccia parte; ai non partecipanti risponde
+// ErrSplitGroupNotFound.
+func (splitService *SplitService) load(ctx context.Context, userID int64, groupID int64) (dbgen.User, dbgen.SplitGroup, dbgen.SplitMember, error) {
+	user, err := splitService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return dbgen.User{}, dbgen.SplitGroup{}, dbgen.SplitMember{}, err
+	}
+
+	group, err := splitService.splitRepo.GetGroup(ctx, groupID)
+	if err != nil {
+		return dbgen.User{}, dbgen.SplitGroup{}, dbgen.SplitMember{}, err
+	}
+
+	member, err := splitService.splitRepo.GetMemberByUser(ctx, group, user.ID)
+	if err != nil {
+		return dbgen.User{}, dbgen.SplitGroup{}, dbgen.SplitMember{}, err
+	}
+
+	return user, group, member, nil
+}
+
+func (splitService *SplitService) expenses(ctx context.Context, group dbgen.SplitGroup) ([]dto.SplitExpenseDetail, error) {
+	expenses, err := splitService.splitRepo.GetExpenses(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+	shares, err := splitService.splitRepo.GetShares(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	byExpense := make(map[int64][]dbgen.SplitShare)
+	for _, share := range shares {
+		byExpense[share.ExpenseID] = append(byExpense[share.ExpenseID], share)
+	}
+	details := make([]dto.SplitExpenseDetail, len(expenses))
+	for i, expense := range expenses {
+		details[i] = dto.SplitExpenseDetail{
+			Expense: expense,
+			Shares:  byExpense[expense.ID],
+		}
+	}
+	return details, nil
+}
+
+func (splitService *SplitService) detail(ctx context.Context, group dbgen.SplitGroup) (dto.SplitGroupDetail, error) {
+	members, err := splitService.splitRepo.GetMembers(ctx, group)
+	if err != nil {
+		return dto.SplitGroupDetail{}, err
+	}
+	expenses, err := splitService.splitRepo.GetExpenses(ctx, group)
+	if err != nil {
+		return dto.SplitGroupDetail{}, err
+	}
+	shares, err := splitService.splitRepo.GetShares(ctx, group)
+	if err != nil {
+		return dto.SplitGroupDetail{}, err
+	}
+	settlements, err := splitService.splitRepo.GetSettlements(ctx, group)
+	if err != nil {
+		return dto.SplitGroupDetail{}, err
+	}
+
+	balances := groupBalances(members, expenses, shares, settlements)
+	return dto.SplitGroupDetail{
+		Group:       group,
+		Members:     members,
+		Balances:    balances,
+		SettleUp:    settleUp(balances),
+		Settlements: settlements,
+	}, nil
+}
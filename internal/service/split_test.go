@@ -0,0 +1,157 @@
+package service
+
+import (
+	"math/rand"
+	"testing"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+func TestSettleUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		nets      []int64
+		transfers int
+	}{
+		{"already settled", []int64{0, 0, 0}, 0},
+		{"one debtor", []int64{3000, -1000, -2000}, 2},
+		{"one creditor", []int64{-3000, 1000, 2000}, 2},
+		{"exact matches first", []int64{500, -500, 1200, -700, -500}, 3},
+		{"chain", []int64{100, 200, 300, -600}, 3},
+		{"uneven cents", []int64{3334, -1667, -1667, 0}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfers := settleUp(memberBalances(tt.nets))
+			if len(transfers) != tt.transfers {
+				t.Errorf("got %d transfers, want %d: %+v", len(transfers), tt.transfers, transfers)
+			}
+			checkSettled(t, tt.nets, transfers)
+		})
+	}
+}
+
+func TestSettleUpRandomBalances(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for range 1000 {
+		nets := make([]int64, 2+random.Intn(8))
+		var sum int64
+		for i := range nets[1:] {
+			nets[i+1] = random.Int63n(20001) - 10000
+			sum += nets[i+1]
+		}
+		nets[0] = -sum
+
+		transfers := settleUp(memberBalances(nets))
+		checkSettled(t, nets, transfers)
+		nonZero := 0
+		for _, net := range nets {
+			if net != 0 {
+				nonZero++
+			}
+		}
+		if nonZero > 0 && len(transfers) > nonZero-1 {
+			t.Fatalf("balances %v: %d transfers for %d members with a balance", nets, len(transfers), nonZero)
+		}
+	}
+}
+
+func TestSplitAmountsAddUpToTotal(t *testing.T) {
+	shares := func(values ...int32) []dto.SplitPart {
+		parts := make([]dto.SplitPart, len(values))
+		for i := range values {
+			parts[i] = dto.SplitPart{MemberID: int64(i + 1), Shares: &values[i]}
+		}
+		return parts
+	}
+	tests := []struct {
+		name   string
+		total  int64
+		method dto.SplitMethod
+		parts  []dto.SplitPart
+		want   []int64
+	}{
+		{"equal", 900, dto.SplitEqual, shares(0, 0, 0), []int64{300, 300, 300}},
+		{"equal with remainder", 1000, dto.SplitEqual, shares(0, 0, 0), []int64{334, 333, 333}},
+		{"shares", 1000, dto.SplitShares, shares(2, 1, 1), []int64{500, 250, 250}},
+		{"shares with remainder", 100, dto.SplitShares, shares(1, 1, 1), []int64{34, 33, 33}},
+		{"zero shares", 1000, dto.SplitShares, shares(3, 0, 1), []int64{750, 0, 250}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amounts, err := splitAmounts(tt.total, tt.method, tt.parts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.want {
+				if amounts[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", amounts, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitAmountsRejectsInvalidInput(t *testing.T) {
+	exact := func(values ...int64) []dto.SplitPart {
+		parts := make([]dto.SplitPart, len(values))
+		for i := range values {
+			parts[i] = dto.SplitPart{MemberID: int64(i + 1), Amount: &values[i]}
+		}
+		return parts
+	}
+	zero := int32(0)
+	tests := []struct {
+		name   string
+		method dto.SplitMethod
+		parts  []dto.SplitPart
+	}{
+		{"no participants", dto.SplitEqual, nil},
+		{"exact sum mismatch", dto.SplitExact, exact(400, 500)},
+		{"negative exact amount", dto.SplitExact, exact(1100, -100)},
+		{"missing shares", dto.SplitShares, []dto.SplitPart{{MemberID: 1}}},
+		{"all shares zero", dto.SplitShares, []dto.SplitPart{{MemberID: 1, Shares: &zero}}},
+		{"unknown method", dto.SplitMethod("THIRDS"), exact(1000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if amounts, err := splitAmounts(1000, tt.method, tt.parts); err == nil {
+				t.Fatalf("expected an error, got %v", amounts)
+			}
+		})
+	}
+}
+
+func memberBalances(nets []int64) []dto.MemberBalance {
+	balances := make([]dto.MemberBalance, len(nets))
+	for i, net := range nets {
+		balances[i] = dto.MemberBalance{Member: dbgen.SplitMember{ID: int64(i + 1)}, Net: net}
+	}
+	return balances
+}
+
+// checkSettled verifica che i pagamenti siano positivi, vadano dai debitori ai creditori e
+// azzerino tutti i saldi.
+func checkSettled(t *testing.T, nets []int64, transfers []dto.SettleUpTransfer) {
+	t.Helper()
+	remaining := make(map[int64]int64, len(nets))
+	for i, net := range nets {
+		remaining[int64(i+1)] = net
+	}
+	for _, transfer := range transfers {
+		if transfer.Amount <= 0 {
+			t.Fatalf("balances %v: non-positive transfer %+v", nets, transfer)
+		}
+		if remaining[transfer.FromMemberID] >= 0 || remaining[transfer.ToMemberID] <= 0 {
+			t.Fatalf("balances %v: transfer %+v does not go from a debtor to a creditor", nets, transfer)
+		}
+		remaining[transfer.FromMemberID] += transfer.Amount
+		remaining[transfer.ToMemberID] -= transfer.Amount
+	}
+	for memberID, net := range remaining {
+		if net != 0 {
+			t.Fatalf("balances %v: member %d left with %d after %+v", nets, memberID, net, transfers)
+		}
+	}
+}
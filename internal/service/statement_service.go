@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/report"
+	repo "koin/internal/repository"
+)
+
+// StatementService produce gli estratti conto mensili e annuali in PDF.
+type StatementService struct {
+	userRepo      repo.UserRepository
+	categoryRepo  repo.CategoryRepository
+	statementRepo repo.StatementRepository
+}
+
+func NewStatementService(
+	userRepo repo.UserRepository,
+	categoryRepo repo.CategoryRepository,
+	statementRepo repo.StatementRepository,
+) *StatementService {
+	return &StatementService{
+		userRepo:      userRepo,
+		categoryRepo:  categoryRepo,
+		statementRepo: statementRepo,
+	}
+}
+
+// GetStatement restituisce il PDF dell'estratto conto del mese indicato o, senza mese, dell'anno,
+// con il nome del file. Un periodo in corso arriva fino a oggi; uno futuro non è valido.
+func (statementService *StatementService) GetStatement(ctx context.Context, userID int64, year int, month *int, now time.Time) ([]byte, string, error) {
+	user, err := statementService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if year < 1900 {
+		return nil, "", fmt.Errorf("%w: invalid year %d", apierr.ErrInvalidData, year)
+	}
+
+	statement := dto.Statement{UserEmail: user.Email, Year: year}
+	if month != nil {
+		if *month < 1 || *month > 12 {
+			return nil, "", fmt.Errorf("%w: month must be between 1 and 12", apierr.ErrInvalidData)
+		}
+		m := time.Month(*month)
+		statement.Month = &m
+		statement.DateFrom = time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+		statement.DateTo = statement.DateFrom.AddDate(0, 1, -1)
+	} else {
+		statement.DateFrom = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		statement.DateTo = time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	}
+	today := toDate(now)
+	if statement.DateFrom.After(today) {
+		return nil, "", fmt.Errorf("%w: statement period must not be in the future", apierr.ErrInvalidData)
+	}
+	if statement.DateTo.After(today) {
+		statement.DateTo = today
+	}
+
+	statement.Accounts, err = statementService.statementRepo.GetAccountSummaries(ctx, user, statement.DateFrom, statement.DateTo)
+	if err != nil {
+		return nil, "", err
+	}
+	statement.Categories, err = statementService.categoryRepo.GetCategoryTotals(ctx, user, statement.DateFrom, statement.DateTo, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	statement.Entries, err = statementService.statementRepo.GetEntries(ctx, user, statement.DateFrom, statement.DateTo)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pdf, err := report.RenderStatement(statement, now)
+	if err != nil {
+		return nil, "", err
+	}
+	return pdf, report.StatementFilename(statement), nil
+}
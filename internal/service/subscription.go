@@ -0,0 +1,215 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	"koin/internal/model/dto"
+)
+
+// subscriptionPeriod è una cadenza riconoscibile: l'intervallo nominale in giorni e lo scarto
+// ammesso tra un addebito e il successivo (i giorni festivi spostano gli addebiti).
+type subscriptionPeriod struct {
+	frequency dto.Frequency
+	every     int32
+	days      int
+	tolerance int
+}
+
+var subscriptionPeriods = []subscriptionPeriod{
+	{frequency: dto.Weekly, every: 1, days: 7, tolerance: 1},
+	{frequency: dto.Weekly, every: 2, days: 14, tolerance: 2},
+	{frequency: dto.Monthly, every: 1, days: 30, tolerance: 4},
+	{frequency: dto.Monthly, every: 3, days: 91, tolerance: 7},
+	{frequency: dto.Monthly, every: 6, days: 182, tolerance: 10},
+	{frequency: dto.Yearly, every: 1, days: 365, tolerance: 15},
+}
+
+const (
+	// amountTolerance è lo scostamento massimo dalla mediana, in percentuale, tra gli importi
+	// dello stesso abbonamento.
+	amountTolerance = 25
+	// minRegularGaps è la percentuale minima di intervalli compatibili con la cadenza.
+	minRegularGaps = 75
+)
+
+var nonLetters = regexp.MustCompile(`[^\p{L}]+`)
+
+// subscriptionKey raggruppa le spese per account e beneficiario oppure, senza beneficiario, per
+// descrizione normalizzata: in minuscolo e senza numeri né punteggiatura, che nelle descrizioni
+// bancarie cambiano a ogni addebito (date, riferimenti).
+func subscriptionKey(row dbgen.GetSubscriptionCandidatesRow) string {
+	if row.PayeeID.Valid {
+		return fmt.Sprintf("%d:payee:%d", row.AccountID, row.PayeeID.Int64)
+	}
+	normalized := strings.TrimSpace(nonLetters.ReplaceAllString(strings.ToLower(row.Description), " "))
+	if normalized == "" {
+		return ""
+	}
+	return fmt.Sprintf("%d:%s", row.AccountID, normalized)
+}
+
+// detectSubscriptions cerca gli abbonamenti ancora attivi tra le spese, in ordine cronologico,
+// e li restituisce dal più costoso in un anno.
+func detectSubscriptions(rows []dbgen.GetSubscriptionCandidatesRow, today time.Time) []dto.Subscription {
+	groups := make(map[string][]dbgen.GetSubscriptionCandidatesRow)
+	var keys []string
+	for _, row := range rows {
+		key := subscriptionKey(row)
+		if key == "" {
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], row)
+	}
+
+	var subscriptions []dto.Subscription
+	for _, key := range keys {
+		if subscription, ok := detectSubscription(key, groups[key], today); ok {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		return subscriptions[i].AnnualCost > subscriptions[j].AnnualCost
+	})
+	return subscriptions
+}
+
+// detectSubscription riconosce un abbonamento in un gruppo di spese: scartati gli importi troppo
+// lontani dalla mediana, servono almeno tre addebiti (due per quelli annuali) a intervalli regolari.
+// L'abbonamento è considerato disdetto se l'addebito atteso manca da più di metà periodo.
+func detectSubscription(key string, rows []dbgen.GetSubscriptionCandidatesRow, today time.Time) (dto.Subscription, bool) {
+	amounts := make([]int64, len(rows))
+	for i, row := range rows {
+		amounts[i] = row.Amount
+	}
+	median := medianOf(amounts)
+
+	var charges []dbgen.GetSubscriptionCandidatesRow
+	for _, row := range rows {
+		if abs64(row.Amount-median)*100 > median*amountTolerance {
+			continue
+		}
+		if len(charges) > 0 && daysBetween(charges[len(charges)-1].OccurredAt, row.OccurredAt) == 0 {
+			continue
+		}
+		charges = append(charges, row)
+	}
+	if len(charges) < 2 {
+		return dto.Subscription{}, false
+	}
+
+	gaps := make([]int64, len(charges)-1)
+	for i := range gaps {
+		gaps[i] = int64(daysBetween(charges[i].OccurredAt, charges[i+1].OccurredAt))
+	}
+	period, ok := matchPeriod(gaps)
+	if !ok || (len(charges) < 3 && period.frequency != dto.Yearly) {
+		return dto.Subscription{}, false
+	}
+
+	last := charges[len(charges)-1]
+	next := nextCharge(toDate(last.OccurredAt), period)
+	if daysBetween(next, today) > period.days/2+period.tolerance {
+		return dto.Subscription{}, false
+	}
+
+	subscription := dto.Subscription{
+		Key:          key,
+		AccountID:    last.AccountID,
+		AccountName:  last.AccountName,
+		Currency:     last.Currency,
+		CategoryID:   last.CategoryID.Int64,
+		CategoryName: last.CategoryName,
+		Name:         strings.TrimSpace(last.Description),
+		Frequency:    period.frequency,
+		RepeatEvery:  period.every,
+		Amount:       last.Amount,
+		Occurrences:  len(charges),
+		FirstDate:    charges[0].OccurredAt,
+		LastDate:     last.OccurredAt,
+		NextDate:     next,
+		AnnualCost:   annualCost(last.Amount, period),
+	}
+	if last.PayeeID.Valid {
+		subscription.PayeeID = &last.PayeeID.Int64
+		subscription.Name = last.PayeeName.String
+	}
+
+	// L'aumento di prezzo è il passaggio all'importo attuale, se segnalato nell'ultimo anno.
+	i := len(charges) - 1
+	for i > 0 && charges[i-1].Amount == last.Amount {
+		i--
+	}
+	if i > 0 && charges[i-1].Amount < last.Amount && daysBetween(charges[i].OccurredAt, today) <= 365 {
+		subscription.PriceIncrease = &dto.PriceIncrease{
+			PreviousAmount: charges[i-1].Amount,
+			Amount:         last.Amount,
+			Since:          charges[i].OccurredAt,
+		}
+	}
+	return subscription, true
+}
+
+// matchPeriod sceglie la cadenza in base all'intervallo mediano e la accetta se almeno
+// minRegularGaps intervalli su cento le sono compatibili.
+func matchPeriod(gaps []int64) (subscriptionPeriod, bool) {
+	median := medianOf(gaps)
+	for _, period := range subscriptionPeriods {
+		if abs64(median-int64(period.days)) > int64(period.tolerance) {
+			continue
+		}
+		regular := 0
+		for _, gap := range gaps {
+			if abs64(gap-int64(period.days)) <= int64(period.tolerance) {
+				regular++
+			}
+		}
+		return period, regular*100 >= len(gaps)*minRegularGaps
+	}
+	return subscriptionPeriod{}, false
+}
+
+// nextCharge è la data attesa del prossimo addebito; per le cadenze mensili e annuali resta nello
+// stesso giorno del mese, o l'ultimo se il mese è più corto.
+func nextCharge(last time.Time, period subscriptionPeriod) time.Time {
+	switch period.frequency {
+	case dto.Weekly:
+		return last.AddDate(0, 0, 7*int(period.every))
+	case dto.Yearly:
+		return closingInMonth(last, 12*int(period.every), int32(last.Day()))
+	default:
+		return closingInMonth(last, int(period.every), int32(last.Day()))
+	}
+}
+
+func annualCost(amount int64, period subscriptionPeriod) int64 {
+	switch period.frequency {
+	case dto.Weekly:
+		return amount * 52 / int64(period.every)
+	case dto.Yearly:
+		return amount / int64(period.every)
+	default:
+		return amount * 12 / int64(period.every)
+	}
+}
+
+// medianOf restituisce la mediana (per difetto con un numero pari di valori) senza modificare values.
+func medianOf(values []int64) int64 {
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[(len(sorted)-1)/2]
+}
+
+func abs64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+)
+
+// subscriptionHistoryMonths è lo storico analizzato: due anni bastano a riconoscere anche gli
+// abbonamenti annuali.
+const subscriptionHistoryMonths = 24
+
+type SubscriptionService struct {
+	userRepo         repo.UserRepository
+	accountRepo      repo.AccountRepository
+	subscriptionRepo repo.SubscriptionRepository
+	forecastRepo     repo.ForecastRepository
+}
+
+func NewSubscriptionService(
+	userRepo repo.UserRepository,
+	accountRepo repo.AccountRepository,
+	subscriptionRepo repo.SubscriptionRepository,
+	forecastRepo repo.ForecastRepository,
+) *SubscriptionService {
+	return &SubscriptionService{
+		userRepo:         userRepo,
+		accountRepo:      accountRepo,
+		subscriptionRepo: subscriptionRepo,
+		forecastRepo:     forecastRepo,
+	}
+}
+
+// GetSubscriptions propone gli abbonamenti riconosciuti nelle spese degli ultimi due anni, con la
+// data del prossimo addebito, il costo annuo e gli eventuali aumenti di prezzo.
+func (subscriptionService *SubscriptionService) GetSubscriptions(ctx context.Context, userID int64, today time.Time) ([]dto.Subscription, error) {
+	user, err := subscriptionService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscriptionService.detect(ctx, user, toDate(today))
+}
+
+// TrackSubscription trasforma un abbonamento riconosciuto in una transazione ricorrente che parte
+// dal prossimo addebito atteso, così che la previsione dei saldi ne tenga conto.
+func (subscriptionService *SubscriptionService) TrackSubscription(ctx context.Context, trackDto dto.TrackSubscriptionDto, today time.Time) (dbgen.GetRecurringTransactionsByUserRow, error) {
+	user, err := subscriptionService.userRepo.GetUserByID(ctx, trackDto.UserID)
+	if err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+
+	subscriptions, err := subscriptionService.detect(ctx, user, toDate(today))
+	if err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+	var subscription *dto.Subscription
+	for i := range subscriptions {
+		if subscriptions[i].Key == trackDto.Key {
+			subscription = &subscriptions[i]
+			break
+		}
+	}
+	if subscription == nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: %s", apierr.ErrSubscriptionNotFound, trackDto.Key)
+	}
+	if subscription.RecurringID != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, fmt.Errorf("%w: subscription %q is already tracked by recurring transaction %d", apierr.ErrConflict, subscription.Name, *subscription.RecurringID)
+	}
+
+	account, err := subscriptionService.accountRepo.GetAccountByID(ctx, user, subscription.AccountID)
+	if err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+	if err := ensureCanEditAccount(ctx, subscriptionService.accountRepo, user, account); err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+
+	recurring, err := subscriptionService.forecastRepo.CreateRecurring(ctx, dto.CreateRecurringDto{
+		UserID:      user.ID,
+		AccountID:   subscription.AccountID,
+		CategoryID:  subscription.CategoryID,
+		Amount:      -subscription.Amount,
+		Description: subscription.Name,
+		Frequency:   subscription.Frequency,
+		RepeatEvery: subscription.RepeatEvery,
+		StartDate:   subscription.NextDate,
+	})
+	if err != nil {
+		return dbgen.GetRecurringTransactionsByUserRow{}, err
+	}
+
+	return recurringRow(recurring, account.Name, subscription.CategoryName), nil
+}
+
+// detect riconosce gli abbonamenti e li collega alle transazioni ricorrenti che li tracciano già:
+// stesso account, categoria e cadenza e descrizione uguale al nome dell'abbonamento.
+func (subscriptionService *SubscriptionService) detect(ctx context.Context, user dbgen.User, today time.Time) ([]dto.Subscription, error) {
+	rows, err := subscriptionService.subscriptionRepo.GetCandidates(ctx, user, today.AddDate(0, -subscriptionHistoryMonths, 0))
+	if err != nil {
+		return nil, err
+	}
+	recurring, err := subscriptionService.forecastRepo.GetRecurring(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions := detectSubscriptions(rows, today)
+	for i, subscription := range subscriptions {
+		for _, item := range recurring {
+			if item.AccountID == subscription.AccountID &&
+				item.CategoryID == subscription.CategoryID &&
+				dto.Frequency(item.Frequency) == subscription.Frequency &&
+				item.RepeatEvery == subscription.RepeatEvery &&
+				strings.EqualFold(item.Description, subscription.Name) {
+				subscriptions[i].RecurringID = &item.ID
+				break
+			}
+		}
+	}
+	return subscriptions, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	repo "koin/internal/repository"
+	"strings"
+)
+
+// maxTagLength corrisponde a TAGS.NAME VARCHAR(64).
+const maxTagLength = 64
+
+type TagService struct {
+	userRepo    repo.UserRepository
+	tagRepo     repo.TagRepository
+	accountRepo repo.AccountRepository
+}
+
+func NewTagService(
+	userRepo repo.UserRepository,
+	tagRepo repo.TagRepository,
+	accountRepo repo.AccountRepository,
+) *TagService {
+	return &TagService{
+		userRepo:    userRepo,
+		tagRepo:     tagRepo,
+		accountRepo: accountRepo,
+	}
+}
+
+func (tagService *TagService) GetTags(ctx context.Context, user dbgen.User) ([]dbgen.Tag, error) {
+	return tagService.tagRepo.GetTags(ctx, user)
+}
+
+func (tagService *TagService) CreateTag(ctx context.Context, createTagDto dto.CreateTagDto) (dbgen.Tag, error) {
+	user, err := tagService.userRepo.GetUserByID(ctx, createTagDto.UserID)
+	if err != nil {
+		return dbgen.Tag{}, err
+	}
+
+	name, err := NormalizeTag(createTagDto.Name)
+	if err != nil {
+		return dbgen.Tag{}, err
+	}
+
+	_, err = tagService.tagRepo.GetTagByName(ctx, user, name)
+	if err == nil {
+		return dbgen.Tag{}, fmt.Errorf("%w: tag %q already exists", apierr.ErrConflict, name)
+	}
+
+	return tagService.tagRepo.CreateTag(ctx, user, name)
+}
+
+func (tagService *TagService) RenameTag(ctx context.Context, renameTagDto dto.RenameTagDto) (dbgen.Tag, error) {
+	user, err := tagService.userRepo.GetUserByID(ctx, renameTagDto.UserID)
+	if err != nil {
+		return dbgen.Tag{}, err
+	}
+
+	tag, err := tagService.tagRepo.GetTagByID(ctx, user, renameTagDto.TagID)
+	if err != nil {
+		return dbgen.Tag{}, err
+	}
+
+	name, err := NormalizeTag(renameTagDto.Name)
+	if err != nil {
+		return dbgen.Tag{}, err
+	}
+	if name == tag.Name {
+		return tag, nil
+	}
+
+	_, err = tagService.tagRepo.GetTagByName(ctx, user, name)
+	if err == nil {
+		return dbgen.Tag{}, fmt.Errorf("%w: tag %q already exists, use merge instead", apierr.ErrConflict, name)
+	}
+
+	return tagService.tagRepo.RenameTag(ctx, user, tag.ID, name)
+}
+
+func (tagService *TagService) DeleteTag(ctx context.Context, userID int64, tagID int64) error {
+	user, err := tagService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tagService.tagRepo.GetTagByID(ctx, user, tagID)
+	if err != nil {
+		return err
+	}
+
+	return tagService.tagRepo.DeleteTag(ctx, user, tag.ID)
+}
+
+// MergeTags unisce il tag sorgente nella destinazione; il sorgente viene eliminato.
+func (tagService *TagService) MergeTags(ctx context.Context, mergeDto dto.MergeTagsDto) (dbgen.Tag, int64, error) {
+	if mergeDto.SourceTagID == mergeDto.TargetTagID {
+		return dbgen.Tag{}, 0, fmt.Errorf("%w: source and target tag must be different", apierr.ErrInvalidData)
+	}
+
+	user, err := tagService.userRepo.GetUserByID(ctx, mergeDto.UserID)
+	if err != nil {
+		return dbgen.Tag{}, 0, err
+	}
+
+	source, err := tagService.tagRepo.GetTagByID(ctx, user, mergeDto.SourceTagID)
+	if err != nil {
+		return dbgen.Tag{}, 0, err
+	}
+	target, err := tagService.tagRepo.GetTagByID(ctx, user, mergeDto.TargetTagID)
+	if err != nil {
+		return dbgen.Tag{}, 0, err
+	}
+
+	moved, err := tagService.tagRepo.MergeTags(ctx, user, source, target)
+	if err != nil {
+		return dbgen.Tag{}, 0, err
+	}
+	return target, moved, nil
+}
+
+// SetTransactionTags sostituisce i tag di una transazione esistente.
+func (tagService *TagService) SetTransactionTags(ctx context.Context, setTagsDto dto.SetTransactionTagsDto) ([]dbgen.Tag, error) {
+	user, err := tagService.userRepo.GetUserByID(ctx, setTagsDto.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := tagService.accountRepo.GetTransaction(ctx, user, setTagsDto.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureCanEditTransaction(ctx, tagService.accountRepo, user, transaction); err != nil {
+		return nil, err
+	}
+	if err := ensureNotReconciled(ctx, tagService.accountRepo, transaction); err != nil {
+		return nil, err
+	}
+
+	names, err := NormalizeTags(setTagsDto.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	return tagService.tagRepo.SetTransactionTags(ctx, user, transaction, names)
+}
+
+func (tagService *TagService) GetTagReport(ctx context.Context, reportDto dto.TagReportDto) ([]dbgen.GetTagTotalsByUserRow, error) {
+	user, err := tagService.userRepo.GetUserByID(ctx, reportDto.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return tagService.tagRepo.GetTagTotals(ctx, user, reportDto.DateFrom, reportDto.DateTo)
+}
+
+// NormalizeTag porta un tag in forma canonica (minuscolo, senza spazi ai lati)
+// e ne verifica la validità. La virgola non è ammessa perché separa i tag negli elenchi.
+func NormalizeTag(name string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	if normalized == "" {
+		return "", fmt.Errorf("%w: tag must not be empty", apierr.ErrInvalidData)
+	}
+	if len(normalized) > maxTagLength {
+		return "", fmt.Errorf("%w: tag %q is longer than %d characters", apierr.ErrInvalidData, normalized, maxTagLength)
+	}
+	if strings.Contains(normalized, ",") {
+		return "", fmt.Errorf("%w: tag %q must not contain commas", apierr.ErrInvalidData, normalized)
+	}
+	return normalized, nil
+}
+
+// NormalizeTags normalizza un elenco di tag eliminando i duplicati.
+func NormalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// SplitTags converte l'elenco di tag separati da virgola restituito dalle query.
+func SplitTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+	return strings.Split(tags, ",")
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	apierr "koin/internal/errors"
+)
+
+const (
+	// thumbnailSize è il lato massimo, in pixel, delle miniature generate.
+	thumbnailSize = 320
+	// maxThumbnailSourcePixels evita di decodificare immagini enormi solo per ridurle.
+	maxThumbnailSourcePixels = 40_000_000
+)
+
+// makeThumbnail genera una miniatura JPEG dell'immagine. Restituisce ok=false per i
+// formati che la libreria standard non sa decodificare (es. WebP) o per immagini troppo grandi.
+func makeThumbnail(content []byte) (thumbnail []byte, ok bool, err error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, false, nil
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxThumbnailSourcePixels {
+		return nil, false, nil
+	}
+
+	source, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: cannot decode image: %v", apierr.ErrInvalidData, err)
+	}
+
+	scaled := scaleToFit(source, thumbnailSize)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, false, fmt.Errorf("encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), true, nil
+}
+
+// scaleToFit riduce l'immagine mantenendo le proporzioni in modo che il lato maggiore
+// non superi maxSide, facendo la media dei pixel sorgente coperti da ogni pixel di destinazione.
+// Le aree trasparenti diventano bianche, dato che il JPEG non ha canale alfa.
+func scaleToFit(source image.Image, maxSide int) *image.RGBA {
+	bounds := source.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	targetWidth, targetHeight := width, height
+	if width > maxSide || height > maxSide {
+		if width >= height {
+			targetWidth = maxSide
+			targetHeight = max(1, height*maxSide/width)
+		} else {
+			targetHeight = maxSide
+			targetWidth = max(1, width*maxSide/height)
+		}
+	}
+
+	flat := image.NewRGBA(bounds)
+	draw.Draw(flat, bounds, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, bounds, source, bounds.Min, draw.Over)
+
+	target := image.NewRGBA(image.Rect(0, 0, targetWidth, targetHeight))
+	for y := 0; y < targetHeight; y++ {
+		y0 := bounds.Min.Y + y*height/targetHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/targetHeight)
+		for x := 0; x < targetWidth; x++ {
+			x0 := bounds.Min.X + x*width/targetWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/targetWidth)
+
+			var r, g, b, count uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					offset := flat.PixOffset(sx, sy)
+					r += uint64(flat.Pix[offset])
+					g += uint64(flat.Pix[offset+1])
+					b += uint64(flat.Pix[offset+2])
+					count++
+				}
+			}
+			target.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / count),
+				G: uint8(g / count),
+				B: uint8(b / count),
+				A: 255,
+			})
+		}
+	}
+	return target
+}
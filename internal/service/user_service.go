@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
 	"koin/internal/model/dto"
 	repo "koin/internal/repository"
 
@@ -28,6 +29,19 @@ func (userService *UserService) CreateUser(ctx context.Context, createUserDto dt
 	return user, nil
 }
 
+// ResetPassword sostituisce la password dell'utente con l'email indicata, con le stesse regole
+// della registrazione.
+func (userService *UserService) ResetPassword(ctx context.Context, email string, password string) error {
+	if err := ValidatePassword(password); err != nil {
+		return err
+	}
+	user, err := userService.userRepo.GetUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	return userService.userRepo.UpdatePassword(ctx, user, password)
+}
+
 func (userService *UserService) GetUserByEmail(ctx context.Context, email string) (dbgen.User, error) {
 	return userService.userRepo.GetUser(ctx, email)
 }
@@ -52,3 +66,27 @@ func (userService *UserService) Login(ctx context.Context, email, password strin
 
 	return user, nil
 }
+
+// ValidatePassword applica le regole della registrazione: almeno 8 caratteri, una lettera
+// maiuscola e un numero.
+func ValidatePassword(password string) error {
+	if len(password) < 8 {
+		return fmt.Errorf("%w: password must be at least 8 characters long", apierr.ErrInvalidData)
+	}
+	var hasUpper, hasNumber bool
+	for _, r := range password {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			hasUpper = true
+		case r >= '0' && r <= '9':
+			hasNumber = true
+		}
+	}
+	if !hasUpper {
+		return fmt.Errorf("%w: password must contain an uppercase letter", apierr.ErrInvalidData)
+	}
+	if !hasNumber {
+		return fmt.Errorf("%w: password must contain a number", apierr.ErrInvalidData)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	dbgen "koin/internal/db/generated"
+	apierr "koin/internal/errors"
+	"koin/internal/model/dto"
+	"koin/internal/notify"
+	repo "koin/internal/repository"
+)
+
+const (
+	// outboxBatchSize è il numero massimo di eventi smistati sugli endpoint per volta.
+	outboxBatchSize = 200
+	// maxWebhookAttempts con il backoff di retryDelay copre circa un giorno di indisponibilità.
+	maxWebhookAttempts = 10
+	// maxWebhookDeliveries limita il registro degli invii restituito per un endpoint.
+	maxWebhookDeliveries = 500
+	// webhookOutboxRetention è per quanto restano gli eventi smistati, e con loro il registro
+	// degli invii, una volta chiusi tutti gli invii.
+	webhookOutboxRetention = 30 * 24 * time.Hour
+)
+
+// WebhookService gestisce gli endpoint webhook degli utenti e consegna gli eventi di transazioni,
+// account e categorie. Gli eventi sono scritti nell'outbox dai trigger del database nella stessa
+// transazione della modifica; il dispatcher li smista sugli endpoint e li invia firmati, con
+// ritentativi a backoff esponenziale.
+type WebhookService struct {
+	userRepo    repo.UserRepository
+	webhookRepo repo.WebhookRepository
+	sender      *notify.EventSender
+}
+
+func NewWebhookService(
+	userRepo repo.UserRepository,
+	webhookRepo repo.WebhookRepository,
+	sender *notify.EventSender,
+) *WebhookService {
+	return &WebhookService{
+		userRepo:    userRepo,
+		webhookRepo: webhookRepo,
+		sender:      sender,
+	}
+}
+
+// CreateEndpoint registra un endpoint con un segreto di firma generato, restituito solo alla
+// creazione: il client deve conservarlo per verificare le firme.
+func (webhookService *WebhookService) CreateEndpoint(ctx context.Context, createDto dto.CreateWebhookDto) (dbgen.WebhookEndpoint, error) {
+	user, err := webhookService.userRepo.GetUserByID(ctx, createDto.UserID)
+	if err != nil {
+		return dbgen.WebhookEndpoint{}, err
+	}
+
+	if parsed, err := url.Parse(createDto.URL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return dbgen.WebhookEndpoint{}, fmt.Errorf("%w: invalid webhook url %q", apierr.ErrInvalidData, createDto.URL)
+	}
+	secret, err := webhookSecret()
+	if err != nil {
+		return dbgen.WebhookEndpoint{}, err
+	}
+
+	return webhookService.webhookRepo.CreateEndpoint(ctx, user, createDto.URL, secret, createDto.Description)
+}
+
+func (webhookService *WebhookService) GetEndpoints(ctx context.Context, userID int64) ([]dbgen.WebhookEndpoint, error) {
+	user, err := webhookService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhookService.webhookRepo.GetEndpoints(ctx, user)
+}
+
+// DeleteEndpoint elimina l'endpoint con il suo registro degli invii; gli invii in sospeso non
+// vengono più tentati.
+func (webhookService *WebhookService) DeleteEndpoint(ctx context.Context, userID int64, endpointID int64) error {
+	user, err := webhookService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	return webhookService.webhookRepo.DeleteEndpoint(ctx, user, endpointID)
+}
+
+// GetDeliveries restituisce il registro degli invii all'endpoint, dai più recenti.
+func (webhookService *WebhookService) GetDeliveries(ctx context.Context, userID int64, endpointID int64, limit *int32) ([]dbgen.GetWebhookDeliveriesRow, error) {
+	user, err := webhookService.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := webhookService.webhookRepo.GetEndpoint(ctx, user, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	maxDeliveries := int32(100)
+	if limit != nil {
+		if *limit < 1 || *limit > maxWebhookDeliveries {
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", apierr.ErrInvalidData, maxWebhookDeliveries)
+		}
+		maxDeliveries = *limit
+	}
+	return webhookService.webhookRepo.GetDeliveries(ctx, endpoint, maxDeliveries)
+}
+
+// RunDispatch smista sugli endpoint gli eventi nuovi dell'outbox, invia quelli scaduti e
+// cancella quelli oltre la retention.
+func (webhookService *WebhookService) RunDispatch(ctx context.Context, now time.Time) {
+	if _, err := webhookService.webhookRepo.PurgeOutbox(ctx, now.Add(-webhookOutboxRetention)); err != nil {
+		log.Printf("webhooks: retention: %v", err)
+	}
+
+	for {
+		events, err := webhookService.webhookRepo.FanOutOutbox(ctx, outboxBatchSize)
+		if err != nil {
+			log.Printf("webhooks: outbox: %v", err)
+			break
+		}
+		if events < outboxBatchSize {
+			break
+		}
+	}
+
+	deliveries, err := webhookService.webhookRepo.ClaimDeliveries(ctx, now, now.Add(deliveryLease), deliveryBatchSize)
+	if err != nil {
+		log.Printf("webhooks: delivery: %v", err)
+		return
+	}
+
+	for _, delivery := range deliveries {
+		statusCode, err := webhookService.deliver(ctx, delivery)
+		if err == nil {
+			if err := webhookService.webhookRepo.MarkDeliverySent(ctx, delivery.ID, statusCode); err != nil {
+				log.Printf("webhooks: delivery %d: %v", delivery.ID, err)
+			}
+			continue
+		}
+
+		attempts := int(delivery.Attempts) + 1
+		var next *time.Time
+		if attempts < maxWebhookAttempts {
+			retryAt := now.Add(retryDelay(attempts))
+			next = &retryAt
+			log.Printf("webhooks: %s delivery %d failed (attempt %d), retrying at %s: %v", delivery.Event, delivery.ID, attempts, retryAt.Format(time.RFC3339), err)
+		} else {
+			log.Printf("webhooks: %s delivery %d failed after %d attempts: %v", delivery.Event, delivery.ID, attempts, err)
+		}
+		if err := webhookService.webhookRepo.MarkDeliveryFailed(ctx, delivery.ID, statusCode, err.Error(), next); err != nil {
+			log.Printf("webhooks: delivery %d: %v", delivery.ID, err)
+		}
+	}
+}
+
+func (webhookService *WebhookService) deliver(ctx context.Context, delivery dbgen.ClaimWebhookDeliveriesRow) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	return webhookService.sender.Send(ctx, delivery.Url, delivery.Secret, notify.Event{
+		ID:         delivery.OutboxID,
+		DeliveryID: delivery.ID,
+		Name:       delivery.Event,
+		Data:       delivery.Payload,
+		CreatedAt:  delivery.EventCreatedAt,
+	})
+}
+
+// StartDispatch esegue RunDispatch subito e poi a ogni intervallo, finché il contesto non viene annullato.
+func (webhookService *WebhookService) StartDispatch(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			webhookService.RunDispatch(ctx, time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// webhookSecret genera il segreto di firma di un endpoint: "whsec_" seguito da 32 byte casuali.
+func webhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
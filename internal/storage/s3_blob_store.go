@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// unsignedPayload evita di dover leggere due volte il corpo della richiesta per calcolarne l'hash.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+type S3Config struct {
+	Endpoint     string // es. "http://localhost:9000" per MinIO o "https://s3.eu-south-1.amazonaws.com"
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	UsePathStyle bool // true per MinIO e per la maggior parte dei servizi compatibili
+}
+
+// S3BlobStore salva i file su un bucket S3 o compatibile (MinIO, Garage, ...),
+// firmando le richieste con AWS Signature Version 4.
+type S3BlobStore struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+func NewS3BlobStore(config S3Config) (*S3BlobStore, error) {
+	if config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("s3 blob store: bucket, access key and secret key are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 blob store: invalid endpoint %q", config.Endpoint)
+	}
+	return &S3BlobStore{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+func (store *S3BlobStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := store.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	store.sign(req, time.Now().UTC())
+
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("put blob %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("put blob %s: %s", key, responseError(resp))
+	}
+	return nil
+}
+
+func (store *S3BlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := store.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	store.sign(req, time.Now().UTC())
+
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get blob %s: %w", key, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, key)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("get blob %s: %s", key, responseError(resp))
+	}
+	return resp.Body, nil
+}
+
+func (store *S3BlobStore) Delete(ctx context.Context, key string) error {
+	req, err := store.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	store.sign(req, time.Now().UTC())
+
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("delete blob %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	// S3 risponde 204 anche se l'oggetto non esiste; alcuni servizi compatibili restituiscono 404.
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("delete blob %s: %s", key, responseError(resp))
+	}
+	return nil
+}
+
+func (store *S3BlobStore) newRequest(ctx context.Context, method string, key string, body io.Reader) (*http.Request, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+
+	target := *store.endpoint
+	basePath := strings.TrimSuffix(target.Path, "/")
+	if store.config.UsePathStyle {
+		target.Path = basePath + "/" + store.config.Bucket + "/" + key
+	} else {
+		target.Host = store.config.Bucket + "." + target.Host
+		target.Path = basePath + "/" + key
+	}
+	target.RawPath = uriEncode(target.Path, false)
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("build s3 request for %s: %w", key, err)
+	}
+	return req, nil
+}
+
+// sign aggiunge gli header di autenticazione AWS Signature Version 4.
+func (store *S3BlobStore) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": unsignedPayload,
+		"x-amz-date":           amzDate,
+	}
+	names := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+		names = append([]string{"content-type"}, names...)
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope := day + "/" + store.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+store.config.SecretKey), day)
+	key = hmacSHA256(key, store.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		store.config.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// uriEncode applica la codifica richiesta da SigV4: restano in chiaro solo A-Z, a-z, 0-9, '-', '.', '_' e '~'.
+func uriEncode(value string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(value) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9',
+			b == '-', b == '.', b == '_', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+func responseError(resp *http.Response) string {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Sprintf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 è un bucket in memoria che, come MinIO, rifiuta le richieste con una firma
+// SigV4 diversa da quella ricalcolata sulla richiesta ricevuta.
+type fakeS3 struct {
+	secretKey string
+	region    string
+
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	hosts   []string
+}
+
+type fakeObject struct {
+	body        []byte
+	contentType string
+}
+
+func newFakeS3(config S3Config) *fakeS3 {
+	return &fakeS3{secretKey: config.SecretKey, region: config.Region, objects: map[string]fakeObject{}}
+}
+
+func (s3 *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s3.verify(r); err != nil {
+		http.Error(w, "<Error><Code>SignatureDoesNotMatch</Code></Error>", http.StatusForbidden)
+		return
+	}
+
+	s3.mu.Lock()
+	defer s3.mu.Unlock()
+	s3.hosts = append(s3.hosts, r.Host)
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		if int64(len(body)) != r.ContentLength {
+			http.Error(w, "<Error><Code>IncompleteBody</Code></Error>", http.StatusBadRequest)
+			return
+		}
+		s3.objects[key] = fakeObject{body: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		object, ok := s3.objects[key]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", object.contentType)
+		w.Write(object.body)
+	case http.MethodDelete:
+		delete(s3.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// verify ricostruisce la richiesta canonica dai byte ricevuti: host e percorso devono
+// coincidere con quelli firmati dal client.
+func (s3 *fakeS3) verify(r *http.Request) error {
+	authorization := r.Header.Get("Authorization")
+	credential, rest, _ := strings.Cut(strings.TrimPrefix(authorization, "AWS4-HMAC-SHA256 Credential="), ", SignedHeaders=")
+	signedHeaders, signature, _ := strings.Cut(rest, ", Signature=")
+	scope := credential[strings.Index(credential, "/")+1:]
+	day, _, _ := strings.Cut(scope, "/")
+
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + value + "\n")
+	}
+	path, query, _ := strings.Cut(r.RequestURI, "?")
+	canonicalRequest := strings.Join([]string{r.Method, path, query, canonicalHeaders.String(), signedHeaders, r.Header.Get("X-Amz-Content-Sha256")}, "\n")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", r.Header.Get("X-Amz-Date"), scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s3.secretKey), day)
+	for _, part := range []string{s3.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	expected := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	if scope != day+"/"+s3.region+"/s3/aws4_request" || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// newTestS3BlobStore collega lo store al server di prova, anche quando l'host
+// virtuale del bucket non è risolvibile.
+func newTestS3BlobStore(t *testing.T, config S3Config) (*S3BlobStore, *fakeS3) {
+	t.Helper()
+	s3 := newFakeS3(config)
+	server := httptest.NewServer(s3)
+	t.Cleanup(server.Close)
+
+	config.Endpoint = server.URL + config.Endpoint
+	store, err := NewS3BlobStore(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.client = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+		},
+	}}
+	return store, s3
+}
+
+func TestS3BlobStore(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   S3Config
+		key      string
+		wantPath string
+		bucketVH bool
+	}{
+		{
+			name:     "path style",
+			config:   S3Config{Region: "us-east-1", Bucket: "koin-attachments", AccessKey: "minioadmin", SecretKey: "minioadmin", UsePathStyle: true},
+			key:      "users/1/transactions/42/ab12cd",
+			wantPath: "/koin-attachments/users/1/transactions/42/ab12cd",
+		},
+		{
+			name:     "path style behind a prefix",
+			config:   S3Config{Endpoint: "/s3/", Region: "eu-south-1", Bucket: "koin", AccessKey: "key", SecretKey: "secret", UsePathStyle: true},
+			key:      "users/1/scontrino aprile+iva.pdf",
+			wantPath: "/s3/koin/users/1/scontrino aprile+iva.pdf",
+		},
+		{
+			name:     "virtual host",
+			config:   S3Config{Region: "eu-south-1", Bucket: "koin", AccessKey: "key", SecretKey: "secret"},
+			key:      "users/1/transactions/42/thumb",
+			wantPath: "/users/1/transactions/42/thumb",
+			bucketVH: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, s3 := newTestS3BlobStore(t, tt.config)
+			ctx := context.Background()
+			content := []byte("%PDF-1.4 scontrino")
+
+			if err := store.Put(ctx, tt.key, bytes.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+				t.Fatal(err)
+			}
+			object, ok := s3.objects[tt.wantPath]
+			if !ok || object.contentType != "application/pdf" {
+				t.Fatalf("objects = %v, want %s stored as application/pdf", s3.objects, tt.wantPath)
+			}
+			if host := s3.hosts[0]; strings.HasPrefix(host, tt.config.Bucket+".") != tt.bucketVH {
+				t.Errorf("request host %s, virtual host %v", host, tt.bucketVH)
+			}
+
+			body, err := store.Get(ctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			read, _ := io.ReadAll(body)
+			body.Close()
+			if !bytes.Equal(read, content) {
+				t.Errorf("Get = %q, want %q", read, content)
+			}
+
+			if err := store.Delete(ctx, tt.key); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Get(ctx, tt.key); !errors.Is(err, ErrBlobNotFound) {
+				t.Errorf("Get after Delete = %v, want ErrBlobNotFound", err)
+			}
+			if err := store.Delete(ctx, tt.key); err != nil {
+				t.Errorf("Delete of a missing blob = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestS3BlobStoreErrors(t *testing.T) {
+	config := S3Config{Bucket: "koin", AccessKey: "key", SecretKey: "secret", UsePathStyle: true}
+	store, _ := newTestS3BlobStore(t, config)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "../escape", strings.NewReader("x"), 1, ""); err == nil {
+		t.Error("Put with an invalid key succeeded")
+	}
+
+	// Con una chiave segreta diversa il server risponde 403 e il corpo finisce nell'errore
+	store.config.SecretKey = "wrong"
+	err := store.Put(ctx, "users/1/a", strings.NewReader("x"), 1, "")
+	if err == nil || !strings.Contains(err.Error(), "status 403") || !strings.Contains(err.Error(), "SignatureDoesNotMatch") {
+		t.Errorf("Put with a wrong secret = %v, want status 403", err)
+	}
+}
+
+func TestNewS3BlobStore(t *testing.T) {
+	tests := []struct {
+		name   string
+		config S3Config
+		valid  bool
+	}{
+		{"complete", S3Config{Endpoint: "http://localhost:9000", Bucket: "koin", AccessKey: "key", SecretKey: "secret"}, true},
+		{"missing bucket", S3Config{Endpoint: "http://localhost:9000", AccessKey: "key", SecretKey: "secret"}, false},
+		{"missing secret", S3Config{Endpoint: "http://localhost:9000", Bucket: "koin", AccessKey: "key"}, false},
+		{"endpoint without scheme", S3Config{Endpoint: "localhost:9000", Bucket: "koin", AccessKey: "key", SecretKey: "secret"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewS3BlobStore(tt.config)
+			if (err == nil) != tt.valid {
+				t.Fatalf("NewS3BlobStore = %v, want valid %v", err, tt.valid)
+			}
+			if err == nil && store.config.Region != "us-east-1" {
+				t.Errorf("default region %q, want us-east-1", store.config.Region)
+			}
+		})
+	}
+}
+
+func TestURIEncode(t *testing.T) {
+	tests := []struct {
+		value       string
+		encodeSlash bool
+		want        string
+	}{
+		{"/koin/users/1/a-b_c.d~e", false, "/koin/users/1/a-b_c.d~e"},
+		{"/koin/scontrino aprile+iva.pdf", false, "/koin/scontrino%20aprile%2Biva.pdf"},
+		{"/koin/caffè", false, "/koin/caff%C3%A8"},
+		{"a/b", true, "a%2Fb"},
+	}
+	for _, tt := range tests {
+		if got := uriEncode(tt.value, tt.encodeSlash); got != tt.want {
+			t.Errorf("uriEncode(%q, %v) = %q, want %q", tt.value, tt.encodeSlash, got, tt.want)
+		}
+	}
+}